@@ -0,0 +1,44 @@
+// Package audit records the append-only audit log of administrative, organization, team and facilitator actions
+package audit
+
+import (
+	"context"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+
+	"go.uber.org/zap"
+)
+
+type contextKey string
+
+const contextKeyIP contextKey = "auditIP"
+
+// WithIP returns a copy of the context carrying the client IP address actions are recorded with,
+// used by websocket connections whose events are handled outside of their request
+func WithIP(ctx context.Context, IP string) context.Context {
+	return context.WithValue(ctx, contextKeyIP, IP)
+}
+
+// Recorder appends entries to the audit log
+type Recorder struct {
+	DataSvc thunderdome.AuditDataSvc
+	Logger  *otelzap.Logger
+}
+
+// Record appends the entry to the audit log, using the contexts client IP address when the entry has none.
+// Errors are logged and never fail the audited action.
+func (a *Recorder) Record(ctx context.Context, Entry thunderdome.AuditLog) {
+	if a == nil {
+		return
+	}
+
+	if Entry.IP == "" {
+		Entry.IP, _ = ctx.Value(contextKeyIP).(string)
+	}
+
+	if err := a.DataSvc.AuditLogCreate(ctx, &Entry); err != nil {
+		a.Logger.Ctx(ctx).Error("audit log record error", zap.Error(err),
+			zap.String("action", Entry.Action), zap.String("actor_id", Entry.ActorId))
+	}
+}
@@ -0,0 +1,190 @@
+// Package chat provides Slack and Mattermost channel notifications for Thunderdome
+package chat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+
+	"go.uber.org/zap"
+)
+
+// Config contains the chat notification values
+type Config struct {
+	AppURL string
+}
+
+// Service contains all the methods to send chat notifications
+type Service struct {
+	Config                 *Config
+	Logger                 *otelzap.Logger
+	Client                 *http.Client
+	ChatIntegrationDataSvc thunderdome.ChatIntegrationDataSvc
+}
+
+// New creates a new instance of Service
+func New(AppURL string, logger *otelzap.Logger, chatIntegrationDataSvc thunderdome.ChatIntegrationDataSvc) *Service {
+	return &Service{
+		Config: &Config{
+			AppURL: AppURL,
+		},
+		Logger:                 logger,
+		Client:                 newWebhookClient(),
+		ChatIntegrationDataSvc: chatIntegrationDataSvc,
+	}
+}
+
+// field a labeled value displayed in the notification
+type field struct {
+	Title string
+	Value string
+	Short bool
+}
+
+// message is the provider agnostic notification content
+type message struct {
+	Title  string
+	Link   string
+	Text   string
+	Color  string
+	Fields []field
+}
+
+// slackHeaderMaxLength the most characters Slack allows in a header block
+const slackHeaderMaxLength = 150
+
+// truncate shortens the text to at most max characters ending it with an ellipsis when shortened
+func truncate(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+
+	return string(runes[:max-1]) + "…"
+}
+
+// slackPayload formats the message as Slack Block Kit blocks
+func slackPayload(m message) map[string]interface{} {
+	blocks := []map[string]interface{}{
+		{
+			"type": "header",
+			"text": map[string]interface{}{"type": "plain_text", "text": truncate(m.Title, slackHeaderMaxLength)},
+		},
+	}
+	if m.Text != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "section",
+			"text": map[string]interface{}{"type": "mrkdwn", "text": m.Text},
+		})
+	}
+	if len(m.Fields) > 0 {
+		fields := make([]map[string]interface{}, 0, len(m.Fields))
+		for _, f := range m.Fields {
+			fields = append(fields, map[string]interface{}{
+				"type": "mrkdwn",
+				"text": fmt.Sprintf("*%s*\n%s", f.Title, f.Value),
+			})
+		}
+		// slack limits section blocks to ten fields
+		for len(fields) > 0 {
+			n := len(fields)
+			if n > 10 {
+				n = 10
+			}
+			blocks = append(blocks, map[string]interface{}{
+				"type":   "section",
+				"fields": fields[:n],
+			})
+			fields = fields[n:]
+		}
+	}
+	if m.Link != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "context",
+			"elements": []map[string]interface{}{
+				{"type": "mrkdwn", "text": fmt.Sprintf("<%s|View in Thunderdome>", m.Link)},
+			},
+		})
+	}
+
+	return map[string]interface{}{
+		"text":   m.Title,
+		"blocks": blocks,
+	}
+}
+
+// mattermostPayload formats the message as a Mattermost message attachment
+func mattermostPayload(m message) map[string]interface{} {
+	fields := make([]map[string]interface{}, 0, len(m.Fields))
+	for _, f := range m.Fields {
+		fields = append(fields, map[string]interface{}{
+			"title": f.Title,
+			"value": f.Value,
+			"short": f.Short,
+		})
+	}
+
+	return map[string]interface{}{
+		"attachments": []map[string]interface{}{
+			{
+				"fallback":   m.Title,
+				"color":      m.Color,
+				"title":      m.Title,
+				"title_link": m.Link,
+				"text":       m.Text,
+				"fields":     fields,
+			},
+		},
+	}
+}
+
+// notify posts the message to each webhook formatted for its provider
+func (s *Service) notify(ctx context.Context, webhooks []*thunderdome.ChatWebhook, m message) {
+	for _, wh := range webhooks {
+		var payload map[string]interface{}
+		switch wh.Provider {
+		case thunderdome.ChatProviderSlack:
+			payload = slackPayload(m)
+		case thunderdome.ChatProviderMattermost:
+			payload = mattermostPayload(m)
+		default:
+			continue
+		}
+
+		if err := s.post(ctx, wh.URL, payload); err != nil {
+			s.Logger.Ctx(ctx).Error("chat webhook post error",
+				zap.String("team_id", wh.TeamId), zap.String("provider", wh.Provider), zap.Error(err))
+		}
+	}
+}
+
+// post sends the payload to the incoming webhook
+func (s *Service) post(ctx context.Context, URL string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("chat webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
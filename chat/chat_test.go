@@ -0,0 +1,149 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+
+	"go.uber.org/zap"
+)
+
+// webhookDataSvc returns the same webhooks for every lookup
+type webhookDataSvc struct {
+	thunderdome.ChatIntegrationDataSvc
+	webhooks []*thunderdome.ChatWebhook
+}
+
+func (d *webhookDataSvc) ChatWebhooksByTeam(ctx context.Context, TeamId string, Event string) ([]*thunderdome.ChatWebhook, error) {
+	return d.webhooks, nil
+}
+
+func (d *webhookDataSvc) ChatWebhooksByPoker(ctx context.Context, PokerId string, Event string) ([]*thunderdome.ChatWebhook, error) {
+	return d.webhooks, nil
+}
+
+func (d *webhookDataSvc) ChatWebhooksByRetro(ctx context.Context, RetroId string, Event string) ([]*thunderdome.ChatWebhook, error) {
+	return d.webhooks, nil
+}
+
+// newTestService creates a Service posting to a local webhook stand-in that records the payloads by path
+func newTestService(t *testing.T) (*Service, map[string]map[string]interface{}) {
+	payloads := make(map[string]map[string]interface{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload map[string]interface{}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Errorf("invalid webhook payload: %v", err)
+		}
+		payloads[r.URL.Path] = payload
+	}))
+	t.Cleanup(srv.Close)
+
+	s := New("https://thunderdome.dev/", otelzap.New(zap.NewNop()), &webhookDataSvc{
+		webhooks: []*thunderdome.ChatWebhook{
+			{Provider: thunderdome.ChatProviderSlack, URL: srv.URL + "/slack", TeamName: "Avengers"},
+			{Provider: thunderdome.ChatProviderMattermost, URL: srv.URL + "/mattermost", TeamName: "Avengers"},
+		},
+	})
+	// the webhook stand-in is local so skip the public address restriction
+	s.Client = srv.Client()
+
+	return s, payloads
+}
+
+func TestCheckinBlockers(t *testing.T) {
+	s, payloads := newTestService(t)
+
+	s.CheckinBlockers(context.Background(), "team", "Thor", "<p>Waiting on <strong>Stormbreaker</strong></p>")
+
+	blocks, ok := payloads["/slack"]["blocks"].([]interface{})
+	if !ok || len(blocks) == 0 {
+		t.Fatalf("expected slack blocks, got %v", payloads["/slack"])
+	}
+	section := blocks[1].(map[string]interface{})["text"].(map[string]interface{})
+	if section["text"] != "Waiting on Stormbreaker" {
+		t.Fatalf("expected blockers without html, got %v", section["text"])
+	}
+
+	attachments, ok := payloads["/mattermost"]["attachments"].([]interface{})
+	if !ok || len(attachments) != 1 {
+		t.Fatalf("expected one mattermost attachment, got %v", payloads["/mattermost"])
+	}
+	attachment := attachments[0].(map[string]interface{})
+	if attachment["title"] != "Thor has a blocker" {
+		t.Fatalf("expected blocker title, got %v", attachment["title"])
+	}
+	if attachment["title_link"] != "https://thunderdome.dev/team/team/checkin" {
+		t.Fatalf("expected checkin link, got %v", attachment["title_link"])
+	}
+}
+
+func TestCheckinBlockersEmpty(t *testing.T) {
+	s, payloads := newTestService(t)
+
+	s.CheckinBlockers(context.Background(), "team", "Thor", "<p></p>")
+
+	if len(payloads) != 0 {
+		t.Fatalf("expected no notifications for empty blockers, got %v", payloads)
+	}
+}
+
+func TestPokerFinished(t *testing.T) {
+	s, payloads := newTestService(t)
+
+	s.PokerFinished(context.Background(), &thunderdome.Poker{
+		Id:   "game",
+		Name: "Sprint 42",
+		Stories: []*thunderdome.Story{
+			{Name: "Find the stones", Points: "8"},
+			{Name: "Snap", Points: "1/2"},
+			{Name: "Shawarma", Points: "3"},
+			{Name: "Time travel", Skipped: true},
+		},
+	})
+
+	attachment := payloads["/mattermost"]["attachments"].([]interface{})[0].(map[string]interface{})
+	fields := attachment["fields"].([]interface{})
+	expected := map[string]string{
+		"Stories Pointed": "3",
+		"Total Points":    "11",
+		"Stories Skipped": "1",
+	}
+	for _, f := range fields {
+		f := f.(map[string]interface{})
+		if want, ok := expected[f["title"].(string)]; ok && f["value"] != want {
+			t.Fatalf("expected %s to be %s, got %v", f["title"], want, f["value"])
+		}
+	}
+
+	if payloads["/slack"]["text"] != "Sprint 42 finished pointing" {
+		t.Fatalf("expected slack fallback text, got %v", payloads["/slack"]["text"])
+	}
+}
+
+func TestRetroCompleted(t *testing.T) {
+	s, payloads := newTestService(t)
+
+	s.RetroCompleted(context.Background(), &thunderdome.Retro{
+		Id:   "retro",
+		Name: "Sprint 42",
+		ActionItems: []*thunderdome.RetroAction{
+			{Content: "Bring a mouthguard"},
+			{Content: "Assemble earlier"},
+		},
+	})
+
+	attachment := payloads["/mattermost"]["attachments"].([]interface{})[0].(map[string]interface{})
+	if attachment["text"] != "• Bring a mouthguard\n• Assemble earlier" {
+		t.Fatalf("expected action items, got %v", attachment["text"])
+	}
+	if attachment["title_link"] != "https://thunderdome.dev/retro/retro" {
+		t.Fatalf("expected retro link, got %v", attachment["title_link"])
+	}
+}
@@ -0,0 +1,125 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"github.com/microcosm-cc/bluemonday"
+	"go.uber.org/zap"
+)
+
+// maxListItems limits how many stories or action items are listed in a notification
+const maxListItems = 20
+
+// textPolicy strips the rich text formatting from content for chat messages
+var textPolicy = bluemonday.StrictPolicy()
+
+// CheckinBlockers notifies the teams chat channels of a checkin posted with blockers
+func (s *Service) CheckinBlockers(ctx context.Context, TeamId string, UserName string, Blockers string) {
+	blockers := strings.TrimSpace(textPolicy.Sanitize(Blockers))
+	if blockers == "" {
+		return
+	}
+
+	webhooks, err := s.ChatIntegrationDataSvc.ChatWebhooksByTeam(ctx, TeamId, thunderdome.ChatEventCheckinBlockers)
+	if err != nil {
+		s.Logger.Ctx(ctx).Error("chat checkin blockers webhooks error", zap.Error(err))
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	s.notify(ctx, webhooks, message{
+		Title: fmt.Sprintf("%s has a blocker", UserName),
+		Link:  s.Config.AppURL + "team/" + TeamId + "/checkin",
+		Text:  blockers,
+		Color: "#E53E3E",
+		Fields: []field{
+			{Title: "Team", Value: webhooks[0].TeamName, Short: true},
+		},
+	})
+}
+
+// PokerFinished notifies the games teams chat channels with a points summary
+func (s *Service) PokerFinished(ctx context.Context, Game *thunderdome.Poker) {
+	webhooks, err := s.ChatIntegrationDataSvc.ChatWebhooksByPoker(ctx, Game.Id, thunderdome.ChatEventPokerFinished)
+	if err != nil {
+		s.Logger.Ctx(ctx).Error("chat poker finished webhooks error", zap.Error(err))
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	var total float64
+	var pointed, skipped int
+	stories := make([]string, 0, len(Game.Stories))
+	for _, story := range Game.Stories {
+		if story.Skipped {
+			skipped++
+			continue
+		}
+		pointed++
+		if points, err := strconv.ParseFloat(story.Points, 64); err == nil {
+			total += points
+		}
+		if len(stories) < maxListItems {
+			stories = append(stories, fmt.Sprintf("• %s: %s", story.Name, story.Points))
+		}
+	}
+	if pointed > len(stories) {
+		stories = append(stories, fmt.Sprintf("…and %d more", pointed-len(stories)))
+	}
+
+	s.notify(ctx, webhooks, message{
+		Title: fmt.Sprintf("%s finished pointing", Game.Name),
+		Link:  s.Config.AppURL + "battle/" + Game.Id,
+		Text:  strings.Join(stories, "\n"),
+		Color: "#22BC66",
+		Fields: []field{
+			{Title: "Stories Pointed", Value: strconv.Itoa(pointed), Short: true},
+			{Title: "Total Points", Value: strconv.FormatFloat(total, 'f', -1, 64), Short: true},
+			{Title: "Stories Skipped", Value: strconv.Itoa(skipped), Short: true},
+		},
+	})
+}
+
+// RetroCompleted notifies the retros teams chat channels with the retros action items
+func (s *Service) RetroCompleted(ctx context.Context, Retro *thunderdome.Retro) {
+	webhooks, err := s.ChatIntegrationDataSvc.ChatWebhooksByRetro(ctx, Retro.Id, thunderdome.ChatEventRetroCompleted)
+	if err != nil {
+		s.Logger.Ctx(ctx).Error("chat retro completed webhooks error", zap.Error(err))
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	actions := make([]string, 0, len(Retro.ActionItems))
+	for _, action := range Retro.ActionItems {
+		if len(actions) == maxListItems {
+			actions = append(actions, fmt.Sprintf("…and %d more", len(Retro.ActionItems)-maxListItems))
+			break
+		}
+		actions = append(actions, "• "+textPolicy.Sanitize(action.Content))
+	}
+	text := strings.Join(actions, "\n")
+	if len(actions) == 0 {
+		text = "No action items"
+	}
+
+	s.notify(ctx, webhooks, message{
+		Title: fmt.Sprintf("%s retro completed", Retro.Name),
+		Link:  s.Config.AppURL + "retro/" + Retro.Id,
+		Text:  text,
+		Color: "#4299E1",
+		Fields: []field{
+			{Title: "Action Items", Value: strconv.Itoa(len(Retro.ActionItems)), Short: true},
+		},
+	})
+}
@@ -0,0 +1,133 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// slackRequestMaxAge is how old a slack request timestamp can be to guard against replays
+const slackRequestMaxAge = 5 * time.Minute
+
+// Slack verifies inbound Slack requests and looks up Slack users
+type Slack struct {
+	SigningSecret string
+	BotToken      string
+	APIURL        string
+	Client        *http.Client
+}
+
+// NewSlack creates a new instance of Slack
+func NewSlack(SigningSecret string, BotToken string) *Slack {
+	return &Slack{
+		SigningSecret: SigningSecret,
+		BotToken:      BotToken,
+		APIURL:        "https://slack.com/api/",
+		Client:        &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// VerifyRequest validates the slack request signature of the raw request body
+func (s *Slack) VerifyRequest(header http.Header, body []byte, now time.Time) error {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	signature := header.Get("X-Slack-Signature")
+	if s.SigningSecret == "" || timestamp == "" || signature == "" {
+		return errors.New("INVALID_SLACK_SIGNATURE")
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("INVALID_SLACK_SIGNATURE")
+	}
+	age := now.Sub(time.Unix(ts, 0))
+	if age > slackRequestMaxAge || age < -slackRequestMaxAge {
+		return errors.New("EXPIRED_SLACK_REQUEST")
+	}
+
+	mac := hmac.New(sha256.New, []byte(s.SigningSecret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return errors.New("INVALID_SLACK_SIGNATURE")
+	}
+
+	return nil
+}
+
+// UserEmail gets the slack users email address, requires the users:read.email scope
+func (s *Slack) UserEmail(ctx context.Context, UserID string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.APIURL+"users.info?user="+url.QueryEscape(UserID), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.BotToken)
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var info struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+		User  struct {
+			Profile struct {
+				Email string `json:"email"`
+			} `json:"profile"`
+		} `json:"user"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return "", err
+	}
+	if !info.OK {
+		return "", errors.New(info.Error)
+	}
+	if info.User.Profile.Email == "" {
+		return "", errors.New("SLACK_USER_EMAIL_NOT_FOUND")
+	}
+
+	return info.User.Profile.Email, nil
+}
+
+// Respond posts a message to a slack interactions response URL
+func (s *Slack) Respond(ctx context.Context, ResponseURL string, message interface{}) error {
+	u, err := url.Parse(ResponseURL)
+	if err != nil || u.Scheme != "https" || u.Hostname() != slackWebhookHost {
+		return errors.New("invalid slack response url")
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ResponseURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("slack response url responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
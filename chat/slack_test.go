@@ -0,0 +1,87 @@
+package chat
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func signSlackRequest(secret string, timestamp string, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":" + body))
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestSlackVerifyRequest(t *testing.T) {
+	s := NewSlack("avengers-assemble", "")
+	now := time.Unix(1694800000, 0)
+	body := "command=%2Fthunderdome&text=poker+Sprint+42"
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	header := http.Header{}
+	header.Set("X-Slack-Request-Timestamp", timestamp)
+	header.Set("X-Slack-Signature", signSlackRequest("avengers-assemble", timestamp, body))
+	if err := s.VerifyRequest(header, []byte(body), now); err != nil {
+		t.Fatalf("expected valid signature, got %v", err)
+	}
+
+	if err := s.VerifyRequest(header, []byte(body+"&user_id=U1"), now); err == nil {
+		t.Fatalf("expected tampered body to fail verification")
+	}
+
+	if err := s.VerifyRequest(header, []byte(body), now.Add(10*time.Minute)); err == nil || err.Error() != "EXPIRED_SLACK_REQUEST" {
+		t.Fatalf("expected expired request, got %v", err)
+	}
+
+	header.Set("X-Slack-Signature", signSlackRequest("hydra", timestamp, body))
+	if err := s.VerifyRequest(header, []byte(body), now); err == nil {
+		t.Fatalf("expected wrong secret to fail verification")
+	}
+}
+
+func TestSlackUserEmail(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer xoxb-token" {
+			_, _ = w.Write([]byte(`{"ok":false,"error":"not_authed"}`))
+			return
+		}
+		if r.URL.Query().Get("user") != "U123" {
+			_, _ = w.Write([]byte(`{"ok":false,"error":"user_not_found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true,"user":{"id":"U123","profile":{"email":"thor@thunderdome.dev"}}}`))
+	}))
+	defer srv.Close()
+
+	s := NewSlack("", "xoxb-token")
+	s.APIURL = srv.URL + "/"
+
+	email, err := s.UserEmail(context.Background(), "U123")
+	if err != nil || email != "thor@thunderdome.dev" {
+		t.Fatalf("expected thor@thunderdome.dev, got %s %v", email, err)
+	}
+
+	if _, err := s.UserEmail(context.Background(), "U404"); err == nil || err.Error() != "user_not_found" {
+		t.Fatalf("expected user_not_found, got %v", err)
+	}
+}
+
+func TestSlackRespondRequiresSlackResponseURL(t *testing.T) {
+	s := NewSlack("avengers-assemble", "")
+
+	for _, responseURL := range []string{
+		"http://hooks.slack.com/actions/T0/1/x",
+		"https://169.254.169.254/latest/meta-data",
+		"https://hooks.slack.com.evil.dev/actions/T0/1/x",
+	} {
+		if err := s.Respond(context.Background(), responseURL, map[string]string{"text": "hi"}); err == nil {
+			t.Errorf("expected responding to %s to be refused", responseURL)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package chat
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// slackWebhookHost the host of Slack incoming webhooks and interaction response URLs
+const slackWebhookHost = "hooks.slack.com"
+
+// ValidateWebhookURL checks the webhook URL is one Thunderdome will post to, webhooks must use https,
+// Slack webhooks must be Slack's and Mattermost webhooks can't be for a private or loopback address
+func ValidateWebhookURL(Provider string, WebhookURL string) error {
+	u, err := url.Parse(WebhookURL)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" || u.User != nil {
+		return errors.New("INVALID_WEBHOOK_URL")
+	}
+	host := strings.ToLower(u.Hostname())
+
+	switch Provider {
+	case thunderdome.ChatProviderSlack:
+		if host != slackWebhookHost {
+			return errors.New("INVALID_WEBHOOK_URL")
+		}
+	case thunderdome.ChatProviderMattermost:
+		if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+			return errors.New("INVALID_WEBHOOK_URL")
+		}
+		if ip := net.ParseIP(host); ip != nil && !isPublicIP(ip) {
+			return errors.New("INVALID_WEBHOOK_URL")
+		}
+	default:
+		return errors.New("INVALID_WEBHOOK_URL")
+	}
+
+	return nil
+}
+
+// reservedNetworks the private and shared address ranges that aren't reachable from the internet
+var reservedNetworks = func() []*net.IPNet {
+	cidrs := []string{
+		"10.0.0.0/8",
+		"100.64.0.0/10",
+		"172.16.0.0/12",
+		"192.0.0.0/24",
+		"192.168.0.0/16",
+		"198.18.0.0/15",
+		"fc00::/7",
+	}
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, _ := net.ParseCIDR(cidr)
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+// isPublicIP whether the address is routable on the internet rather than a private,
+// loopback, link-local (e.g. cloud metadata) or otherwise reserved address
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, network := range reservedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// newWebhookClient creates the http client used to post to webhooks, it only connects to public addresses
+// so a hostname that resolves to an internal address can't be used to reach it, and doesn't follow redirects
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network string, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return errors.New("webhook address is not public")
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
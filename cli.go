@@ -0,0 +1,539 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/audit"
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	dbaudit "github.com/StevenWeathers/thunderdome-planning-poker/db/audit"
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/auth"
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/poker"
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/retro"
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/storyboard"
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/team"
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/user"
+	"github.com/StevenWeathers/thunderdome-planning-poker/email"
+	"github.com/StevenWeathers/thunderdome-planning-poker/ratelimit"
+	"github.com/StevenWeathers/thunderdome-planning-poker/scheduler"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+
+	"github.com/spf13/viper"
+)
+
+const cliUsage = `Usage: thunderdome [command]
+
+Runs the Thunderdome server when no command is given, or "live" to serve the UI from the dist directory.
+
+Commands:
+  migrate up                            apply all pending database migrations
+  migrate down [-y] [steps]             roll back the most recent migrations, 1 by default
+  migrate status                        show the applied and latest migration versions
+  migrate force [-y] <version>          set the migration version and clear a failed migration
+  user create -name <name> -email <email> [-password <password>] [-admin]
+                                        create a registered user, optionally as an admin
+  user promote <email>                  promote the user to admin
+  user disable <email>                  disable the user and revoke their sessions
+  user reset-password [-password <password>] <email>
+                                        set the users password and revoke their sessions
+  cleanup guests|battles|retros|storyboards
+                                        delete data older than the configured config.cleanup_*_days_old
+  export team [-o <file>] <id>          export the team, its members, games, retros and storyboards as JSON
+  config validate                       check the configuration for invalid values
+
+Passwords not given with -password are read from the first line of stdin.
+migrate down and force ask for confirmation on stdin unless given -y or --yes.
+`
+
+// errUsage an invalid command line, reported with the usage
+var errUsage = errors.New("invalid command")
+
+// cli the administrative commands, run against the database without starting the server
+type cli struct {
+	logger *otelzap.Logger
+	db     *db.Service
+	out    io.Writer
+	in     io.Reader
+}
+
+// runCommand runs the administrative command of the arguments, returning the process exit code
+func runCommand(logger *otelzap.Logger, args []string) int {
+	c := &cli{logger: logger, out: os.Stdout, in: os.Stdin}
+	ctx := context.Background()
+
+	var err error
+	switch args[0] {
+	case "help", "-h", "--help":
+		fmt.Fprint(c.out, cliUsage)
+		return 0
+	case "config":
+		err = c.config(args[1:])
+	case "migrate":
+		err = c.withDB(func() error { return c.migrate(args[1:]) })
+	case "user":
+		err = c.withDB(func() error { return c.user(ctx, args[1:]) })
+	case "cleanup":
+		err = c.withDB(func() error { return c.cleanup(ctx, args[1:]) })
+	case "export":
+		err = c.withDB(func() error { return c.export(ctx, args[1:]) })
+	default:
+		err = errUsage
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		if errors.Is(err, errUsage) {
+			fmt.Fprint(os.Stderr, "\n"+cliUsage)
+		}
+		return 1
+	}
+
+	return 0
+}
+
+// withDB runs the command with a database connection, migrations are only applied by the migrate command
+func (c *cli) withDB(command func() error) error {
+	c.db = db.Open(dbConfig(), c.logger)
+	defer func() {
+		_ = c.db.Close()
+	}()
+
+	return command()
+}
+
+// recorder the audit log recorder of the commands administrative actions, which have no acting user
+func (c *cli) recorder() *audit.Recorder {
+	return &audit.Recorder{DataSvc: &dbaudit.Service{DB: c.db.DB, Logger: c.logger}, Logger: c.logger}
+}
+
+// password gets the password flag value or reads it from the first line of stdin
+func (c *cli) password(flagValue string) (string, error) {
+	password := flagValue
+	if password == "" {
+		line, err := bufio.NewReader(c.in).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+
+	if len(password) < 6 || len(password) > 72 {
+		return "", errors.New("password must be between 6 and 72 characters")
+	}
+
+	return password, nil
+}
+
+// confirmFlag removes the -y or --yes flag from the arguments, returning whether it was given
+func confirmFlag(args []string) ([]string, bool) {
+	rest := make([]string, 0, len(args))
+	yes := false
+	for _, arg := range args {
+		if arg == "-y" || arg == "--yes" {
+			yes = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+
+	return rest, yes
+}
+
+// confirm asks to continue reading the answer from the first line of stdin, only yes continues
+func (c *cli) confirm() bool {
+	fmt.Fprint(c.out, "continue? [y/N] ")
+	line, err := bufio.NewReader(c.in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false
+	}
+
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes"
+}
+
+func (c *cli) migrate(args []string) error {
+	args, yes := confirmFlag(args)
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	switch args[0] {
+	case "up":
+		if err := c.db.MigrateUp(); err != nil {
+			return err
+		}
+		fmt.Fprintln(c.out, "migrations applied")
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("%w: steps must be a positive number", errUsage)
+			}
+		}
+		names, err := c.db.MigrationsDown(steps)
+		if err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			fmt.Fprintln(c.out, "no migrations to roll back")
+			return nil
+		}
+		fmt.Fprintln(c.out, "rolling back migrations:")
+		for _, name := range names {
+			fmt.Fprintf(c.out, "  %s\n", name)
+		}
+		if !yes && !c.confirm() {
+			return errors.New("migrate down cancelled")
+		}
+		if err := c.db.MigrateDown(len(names)); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.out, "rolled back %d migrations\n", len(names))
+	case "status":
+		status, err := c.db.MigrateStatus()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.out, "version: %d\nlatest: %d\ndirty: %t\n", status.Version, status.Latest, status.Dirty)
+	case "force":
+		if len(args) < 2 {
+			return errUsage
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("%w: version must be a number", errUsage)
+		}
+		status, err := c.db.MigrateStatus()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.out, "forcing migration version from %d (dirty: %t) to %d without running any migrations\n",
+			status.Version, status.Dirty, version)
+		if !yes && !c.confirm() {
+			return errors.New("migrate force cancelled")
+		}
+		if err := c.db.MigrateForce(version); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.out, "migration version forced to %d\n", version)
+	default:
+		return errUsage
+	}
+
+	return nil
+}
+
+func (c *cli) user(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	userService := &user.Service{DB: c.db.DB, Logger: c.logger}
+
+	if args[0] == "create" {
+		return c.userCreate(ctx, userService, args[1:])
+	}
+
+	flags := flag.NewFlagSet("user "+args[0], flag.ContinueOnError)
+	passwordFlag := flags.String("password", "", "the users new password")
+	if err := flags.Parse(args[1:]); err != nil {
+		return errUsage
+	}
+	if flags.NArg() != 1 {
+		return errUsage
+	}
+
+	u, err := userService.GetUserByEmail(ctx, flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("user %s not found", flags.Arg(0))
+	}
+
+	switch args[0] {
+	case "promote":
+		if err := userService.PromoteUser(ctx, u.Id); err != nil {
+			return err
+		}
+		c.recorder().Record(ctx, thunderdome.AuditLog{
+			Action: thunderdome.AuditActionUserPromote, TargetType: "user", TargetId: u.Id,
+			Before: map[string]string{"type": u.Type}, After: map[string]string{"type": "ADMIN"},
+		})
+		fmt.Fprintf(c.out, "user %s promoted to admin\n", u.Id)
+	case "disable":
+		// disabling also removes the users sessions
+		if err := userService.DisableUser(ctx, u.Id); err != nil {
+			return err
+		}
+		c.recorder().Record(ctx, thunderdome.AuditLog{
+			Action: thunderdome.AuditActionUserDisable, TargetType: "user", TargetId: u.Id,
+			Before: map[string]bool{"disabled": u.Disabled}, After: map[string]bool{"disabled": true},
+		})
+		fmt.Fprintf(c.out, "user %s disabled\n", u.Id)
+	case "reset-password":
+		password, err := c.password(*passwordFlag)
+		if err != nil {
+			return err
+		}
+		authService := &auth.Service{DB: c.db.DB, Logger: c.logger, AESHashkey: c.db.Config.AESHashkey}
+		if _, _, err := authService.UserUpdatePassword(ctx, u.Id, password); err != nil {
+			return err
+		}
+		SessionIDs, err := authService.UserSessionDeleteAll(ctx, u.Id, "")
+		if err != nil {
+			return err
+		}
+		c.recorder().Record(ctx, thunderdome.AuditLog{
+			Action: thunderdome.AuditActionUserPasswordUpdate, TargetType: "user", TargetId: u.Id,
+		})
+		fmt.Fprintf(c.out, "user %s password updated, %d sessions revoked\n", u.Id, len(SessionIDs))
+	default:
+		return errUsage
+	}
+
+	return nil
+}
+
+func (c *cli) userCreate(ctx context.Context, userService *user.Service, args []string) error {
+	flags := flag.NewFlagSet("user create", flag.ContinueOnError)
+	name := flags.String("name", "", "the users name")
+	userEmail := flags.String("email", "", "the users email")
+	passwordFlag := flags.String("password", "", "the users password")
+	admin := flags.Bool("admin", false, "promote the user to admin")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if *name == "" || *userEmail == "" {
+		return fmt.Errorf("%w: name and email are required", errUsage)
+	}
+
+	password, err := c.password(*passwordFlag)
+	if err != nil {
+		return err
+	}
+
+	newUser, _, err := userService.CreateUser(ctx, *name, strings.ToLower(*userEmail), password)
+	if err != nil {
+		return err
+	}
+	c.recorder().Record(ctx, thunderdome.AuditLog{
+		Action: thunderdome.AuditActionUserCreate, TargetType: "user", TargetId: newUser.Id,
+		After: map[string]string{"name": *name, "email": *userEmail},
+	})
+
+	if *admin {
+		if err := userService.PromoteUser(ctx, newUser.Id); err != nil {
+			return err
+		}
+		c.recorder().Record(ctx, thunderdome.AuditLog{
+			Action: thunderdome.AuditActionUserPromote, TargetType: "user", TargetId: newUser.Id,
+			Before: map[string]string{"type": newUser.Type}, After: map[string]string{"type": "ADMIN"},
+		})
+	}
+
+	fmt.Fprintf(c.out, "user %s created\n", newUser.Id)
+	return nil
+}
+
+// cleanupJobs the maintenance job of each cleanup command
+var cleanupJobs = map[string]string{
+	"guests":      scheduler.JobCleanGuests,
+	"battles":     scheduler.JobCleanBattles,
+	"retros":      scheduler.JobCleanRetros,
+	"storyboards": scheduler.JobCleanStoryboards,
+}
+
+func (c *cli) cleanup(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	jobName, ok := cleanupJobs[args[0]]
+	if !ok {
+		return errUsage
+	}
+
+	m := maintenance(
+		email.New(viper.GetString("http.domain"), viper.GetString("http.path_prefix"), c.logger),
+		&user.Service{DB: c.db.DB, Logger: c.logger},
+		&poker.Service{DB: c.db.DB, Logger: c.logger, AESHashKey: c.db.Config.AESHashkey, HTMLSanitizerPolicy: c.db.HTMLSanitizerPolicy},
+		&retro.Service{DB: c.db.DB, Logger: c.logger, AESHashKey: c.db.Config.AESHashkey},
+		&storyboard.Service{DB: c.db.DB, Logger: c.logger, AESHashKey: c.db.Config.AESHashkey},
+		&auth.Service{DB: c.db.DB, Logger: c.logger, AESHashkey: c.db.Config.AESHashkey},
+	)
+
+	result, err := m.Run(ctx, jobName)
+	if err != nil {
+		return err
+	}
+	c.recorder().Record(ctx, thunderdome.AuditLog{
+		Action: thunderdome.AuditActionMaintenanceClean, TargetType: strings.TrimSuffix(args[0], "s"),
+		After: map[string]string{"result": result},
+	})
+	fmt.Fprintln(c.out, result)
+
+	return nil
+}
+
+// teamExport a team with its members and the games, retros and storyboards associated to it
+type teamExport struct {
+	Team        *thunderdome.Team         `json:"team"`
+	Users       []*thunderdome.TeamUser   `json:"users"`
+	Games       []*thunderdome.Poker      `json:"games"`
+	Retros      []*thunderdome.Retro      `json:"retros"`
+	Storyboards []*thunderdome.Storyboard `json:"storyboards"`
+}
+
+// exportPageSize the rows fetched per query when exporting
+const exportPageSize = 500
+
+func (c *cli) export(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] != "team" {
+		return errUsage
+	}
+
+	flags := flag.NewFlagSet("export team", flag.ContinueOnError)
+	output := flags.String("o", "", "the file to write the export to, stdout by default")
+	if err := flags.Parse(args[1:]); err != nil {
+		return errUsage
+	}
+	if flags.NArg() != 1 {
+		return errUsage
+	}
+	TeamID := flags.Arg(0)
+
+	teamService := &team.Service{DB: c.db.DB, Logger: c.logger}
+	t, err := teamService.TeamGet(ctx, TeamID)
+	if err != nil {
+		return fmt.Errorf("team %s not found", TeamID)
+	}
+
+	export := teamExport{Team: t}
+	for Offset := 0; ; Offset += exportPageSize {
+		Users, Count, err := teamService.TeamUserList(ctx, TeamID, exportPageSize, Offset)
+		if err != nil {
+			return err
+		}
+		export.Users = append(export.Users, Users...)
+		if Offset+exportPageSize >= Count {
+			break
+		}
+	}
+	for Offset := 0; ; Offset += exportPageSize {
+		Games := teamService.TeamPokerList(ctx, TeamID, exportPageSize, Offset)
+		export.Games = append(export.Games, Games...)
+		if len(Games) < exportPageSize {
+			break
+		}
+	}
+	for Offset := 0; ; Offset += exportPageSize {
+		Retros := teamService.TeamRetroList(ctx, TeamID, exportPageSize, Offset)
+		export.Retros = append(export.Retros, Retros...)
+		if len(Retros) < exportPageSize {
+			break
+		}
+	}
+	for Offset := 0; ; Offset += exportPageSize {
+		Storyboards := teamService.TeamStoryboardList(ctx, TeamID, exportPageSize, Offset)
+		export.Storyboards = append(export.Storyboards, Storyboards...)
+		if len(Storyboards) < exportPageSize {
+			break
+		}
+	}
+
+	out := c.out
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(export)
+}
+
+// config validates the configuration without connecting to the database
+func (c *cli) config(args []string) error {
+	if len(args) != 1 || args[0] != "validate" {
+		return errUsage
+	}
+
+	problems := configProblems()
+	for _, problem := range problems {
+		fmt.Fprintln(c.out, problem)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d configuration problems found", len(problems))
+	}
+
+	if file := viper.ConfigFileUsed(); file != "" {
+		fmt.Fprintf(c.out, "configuration %s is valid\n", file)
+	} else {
+		fmt.Fprintln(c.out, "configuration is valid")
+	}
+	return nil
+}
+
+// configProblems checks the configuration for values the server would reject or misbehave with
+func configProblems() []string {
+	var problems []string
+
+	if !oneOf(viper.GetString("auth.method"), "normal", "ldap", "header") {
+		problems = append(problems, "auth.method must be one of normal, ldap or header")
+	}
+	if !oneOf(viper.GetString("config.avatar_service"), "gravatar", "robohash", "govatar", "goadorable", "dicebear") {
+		problems = append(problems, "config.avatar_service must be one of gravatar, robohash, govatar, goadorable or dicebear")
+	}
+	if !oneOf(viper.GetString("email.transport"), "smtp", "sendmail", "file") {
+		problems = append(problems, "email.transport must be one of smtp, sendmail or file")
+	}
+	if !oneOf(viper.GetString("ratelimit.store"), "memory", "postgres") {
+		problems = append(problems, "ratelimit.store must be one of memory or postgres")
+	}
+	if _, err := ratelimit.ParseLimits(viper.GetString("ratelimit.groups")); err != nil {
+		problems = append(problems, "ratelimit.groups "+err.Error())
+	}
+	if viper.GetBool("metrics.enabled") && viper.GetString("metrics.listen_address") == "" && viper.GetString("metrics.bearer_token") == "" {
+		problems = append(problems, "metrics.listen_address or metrics.bearer_token is required when metrics.enabled")
+	}
+	if _, err := jobSchedules(); err != nil {
+		problems = append(problems, "jobs "+err.Error())
+	}
+	if path := viper.GetString("http.path_prefix"); path != "" && (!strings.HasPrefix(path, "/") || strings.HasSuffix(path, "/")) {
+		problems = append(problems, "http.path_prefix must start with / and not end with /")
+	}
+	if viper.GetString("db.host") == "" || viper.GetString("db.name") == "" {
+		problems = append(problems, "db.host and db.name are required")
+	}
+	if viper.GetString("auth.method") == "ldap" && viper.GetString("auth.ldap.url") == "" {
+		problems = append(problems, "auth.ldap.url is required when auth.method is ldap")
+	}
+	if viper.GetString("http.cookie_hashkey") == "strongest-avenger" {
+		problems = append(problems, "http.cookie_hashkey must be changed from the default")
+	}
+	if viper.GetString("config.aes_hashkey") == "therevengers" {
+		problems = append(problems, "config.aes_hashkey must be changed from the default")
+	}
+
+	return problems
+}
+
+func oneOf(value string, allowed ...string) bool {
+	for _, a := range allowed {
+		if value == a {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/auth"
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/user"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+
+	"go.uber.org/zap"
+)
+
+// testCLI connects the commands to and migrates the database named by TEST_DB_NAME using the DB_* connection
+// environment variables, skipping the test when it isn't set
+func testCLI(t *testing.T, in string) (*cli, *bytes.Buffer) {
+	t.Helper()
+
+	name := os.Getenv("TEST_DB_NAME")
+	if name == "" {
+		t.Skip("TEST_DB_NAME not set, skipping database test")
+	}
+
+	logger := otelzap.New(zap.NewNop())
+	d := db.Open(&db.Config{
+		Host:            getTestEnv("DB_HOST", "localhost"),
+		Port:            5432,
+		User:            getTestEnv("DB_USER", "thor"),
+		Password:        getTestEnv("DB_PASS", "odinson"),
+		Name:            name,
+		SSLMode:         getTestEnv("DB_SSLMODE", "disable"),
+		AESHashkey:      "therevengers",
+		MaxOpenConns:    5,
+		MaxIdleConns:    5,
+		ConnMaxLifetime: 5,
+	}, logger)
+	if err := d.MigrateUp(); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	t.Cleanup(func() { _ = d.Close() })
+
+	out := &bytes.Buffer{}
+	return &cli{logger: logger, db: d, out: out, in: strings.NewReader(in)}, out
+}
+
+func getTestEnv(key string, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// newSignedInUser creates a registered user with an active session, returning their ID and email
+func newSignedInUser(t *testing.T, c *cli) (string, string) {
+	t.Helper()
+	ctx := context.Background()
+	us := &user.Service{DB: c.db.DB, Logger: c.logger}
+	as := &auth.Service{DB: c.db.DB, Logger: c.logger, AESHashkey: c.db.Config.AESHashkey}
+
+	suffix, err := db.RandomString(8)
+	if err != nil {
+		t.Fatalf("random email: %v", err)
+	}
+	Email := strings.ToLower("thor-" + suffix + "@asgard.dev")
+
+	u, _, err := us.CreateUserRegistered(ctx, "Thor", Email, "mjolnir1", "")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if _, err := as.CreateSession(ctx, u.Id); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+
+	return u.Id, Email
+}
+
+// userSessions the users active sessions
+func userSessions(t *testing.T, c *cli, UserID string) []*thunderdome.UserSession {
+	t.Helper()
+	as := &auth.Service{DB: c.db.DB, Logger: c.logger, AESHashkey: c.db.Config.AESHashkey}
+
+	Sessions, err := as.UserSessionList(context.Background(), UserID, "")
+	if err != nil {
+		t.Fatalf("list sessions: %v", err)
+	}
+
+	return Sessions
+}
+
+// TestUserDisable makes sure disabling a user also signs them out
+func TestUserDisable(t *testing.T) {
+	c, _ := testCLI(t, "")
+	ctx := context.Background()
+	UserID, Email := newSignedInUser(t, c)
+
+	if err := c.user(ctx, []string{"disable", Email}); err != nil {
+		t.Fatalf("user disable: %v", err)
+	}
+
+	us := &user.Service{DB: c.db.DB, Logger: c.logger}
+	disabled, err := us.GetUser(ctx, UserID)
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+	if !disabled.Disabled {
+		t.Fatal("expected the user to be disabled")
+	}
+	if Sessions := userSessions(t, c, UserID); len(Sessions) != 0 {
+		t.Fatalf("expected the disabled users sessions to be removed, got %d", len(Sessions))
+	}
+}
+
+// TestUserResetPassword makes sure resetting a users password from stdin lets them log in with it
+// and signs them out of their other sessions
+func TestUserResetPassword(t *testing.T) {
+	c, out := testCLI(t, "stormbreaker\n")
+	ctx := context.Background()
+	UserID, Email := newSignedInUser(t, c)
+
+	if err := c.user(ctx, []string{"reset-password", Email}); err != nil {
+		t.Fatalf("user reset-password: %v", err)
+	}
+	if !strings.Contains(out.String(), "1 sessions revoked") {
+		t.Fatalf("expected the session to be reported revoked, got %q", out.String())
+	}
+
+	as := &auth.Service{DB: c.db.DB, Logger: c.logger, AESHashkey: c.db.Config.AESHashkey}
+	if _, _, err := as.AuthUser(ctx, Email, "stormbreaker"); err != nil {
+		t.Fatalf("expected to log in with the new password: %v", err)
+	}
+	if Sessions := userSessions(t, c, UserID); len(Sessions) != 0 {
+		t.Fatalf("expected the users sessions to be revoked, got %d", len(Sessions))
+	}
+}
@@ -19,6 +19,7 @@ func InitConfig(logger *otelzap.Logger) {
 	viper.SetDefault("http.cookie_hashkey", "strongest-avenger")
 	viper.SetDefault("http.port", "8080")
 	viper.SetDefault("http.secure_cookie", true)
+	viper.SetDefault("http.secure_protocol", true)
 	viper.SetDefault("http.backend_cookie_name", "warriorId")
 	viper.SetDefault("http.session_cookie_name", "sessionId")
 	viper.SetDefault("http.frontend_cookie_name", "warrior")
@@ -28,6 +29,8 @@ func InitConfig(logger *otelzap.Logger) {
 	viper.SetDefault("http.read_timeout", 5)
 	viper.SetDefault("http.idle_timeout", 30)
 	viper.SetDefault("http.read_header_timeout", 2)
+	viper.SetDefault("http.shutdown_timeout", 30)
+	viper.SetDefault("http.trusted_proxies", "")
 
 	viper.SetDefault("analytics.enabled", true)
 	viper.SetDefault("analytics.id", "UA-140245309-1")
@@ -37,6 +40,10 @@ func InitConfig(logger *otelzap.Logger) {
 	viper.SetDefault("otel.collector_url", "localhost:4317")
 	viper.SetDefault("otel.insecure_mode", false)
 
+	viper.SetDefault("metrics.enabled", false)
+	viper.SetDefault("metrics.listen_address", "")
+	viper.SetDefault("metrics.bearer_token", "")
+
 	viper.SetDefault("db.host", "db")
 	viper.SetDefault("db.port", 5432)
 	viper.SetDefault("db.user", "thor")
@@ -53,6 +60,12 @@ func InitConfig(logger *otelzap.Logger) {
 	viper.SetDefault("smtp.secure", true)
 	viper.SetDefault("smtp.sender", "no-reply@thunderdome.dev")
 
+	viper.SetDefault("email.transport", "smtp")
+	viper.SetDefault("email.sendmail_path", "/usr/sbin/sendmail")
+	viper.SetDefault("email.file_path", "")
+	viper.SetDefault("email.queue_enabled", true)
+	viper.SetDefault("email.queue_max_attempts", 5)
+
 	viper.SetDefault("config.aes_hashkey", "therevengers")
 	viper.SetDefault("config.allowedPointValues",
 		[]string{"0", "1/2", "1", "2", "3", "5", "8", "13", "20", "21", "34", "40", "55", "100", "?", "☕️"})
@@ -77,12 +90,16 @@ func InitConfig(logger *otelzap.Logger) {
 	viper.SetDefault("config.cleanup_storyboards_days_old", 180)
 	viper.SetDefault("config.organizations_enabled", true)
 	viper.SetDefault("config.require_teams", false)
+	viper.SetDefault("config.checkin_schedule_enabled", true)
 
 	// feature flags
 	viper.SetDefault("feature.poker", true)
 	viper.SetDefault("feature.retro", true)
 	viper.SetDefault("feature.storyboard", true)
 
+	viper.SetDefault("slack.signing_secret", "")
+	viper.SetDefault("slack.bot_token", "")
+
 	viper.SetDefault("auth.method", "normal")
 	viper.SetDefault("auth.ldap.url", "")
 	viper.SetDefault("auth.ldap.use_tls", true)
@@ -94,10 +111,32 @@ func InitConfig(logger *otelzap.Logger) {
 	viper.SetDefault("auth.ldap.cn_attr", "cn")
 	viper.SetDefault("auth.header.usernameHeader", "Remote-User")
 	viper.SetDefault("auth.header.emailHeader", "Remote-Email")
+	viper.SetDefault("auth.passkey.passwordless", false)
+	viper.SetDefault("auth.throttle.account_attempts", 5)
+	viper.SetDefault("auth.throttle.ip_attempts", 20)
+	viper.SetDefault("auth.throttle.join_code_attempts", 5)
+	viper.SetDefault("auth.throttle.lockout_seconds", 60)
+	viper.SetDefault("auth.throttle.max_lockout_seconds", 3600)
+
+	viper.SetDefault("ratelimit.enabled", true)
+	viper.SetDefault("ratelimit.store", "memory")
+	viper.SetDefault("ratelimit.requests", 300)
+	viper.SetDefault("ratelimit.period_seconds", 60)
+	viper.SetDefault("ratelimit.groups", "auth=30/60")
+	viper.SetDefault("ratelimit.allow_on_error", false)
+
+	viper.SetDefault("jobs.enabled", false)
+	viper.SetDefault("jobs.clean_guests", "0 2 * * *")
+	viper.SetDefault("jobs.clean_battles", "15 2 * * *")
+	viper.SetDefault("jobs.clean_retros", "30 2 * * *")
+	viper.SetDefault("jobs.clean_storyboards", "45 2 * * *")
+	viper.SetDefault("jobs.lowercase_emails", "")
+	viper.SetDefault("jobs.clean_auth_attempts", "5 * * * *")
 
 	_ = viper.BindEnv("http.cookie_hashkey", "COOKIE_HASHKEY")
 	_ = viper.BindEnv("http.port", "PORT")
 	_ = viper.BindEnv("http.secure_cookie", "COOKIE_SECURE")
+	_ = viper.BindEnv("http.secure_protocol", "SECURE_PROTOCOL")
 	_ = viper.BindEnv("http.backend_cookie_name", "SECURE_COOKIE_NAME")
 	_ = viper.BindEnv("http.session_cookie_name", "SESSION_COOKIE_NAME")
 	_ = viper.BindEnv("http.frontend_cookie_name", "FRONTEND_COOKIE_NAME")
@@ -107,6 +146,8 @@ func InitConfig(logger *otelzap.Logger) {
 	_ = viper.BindEnv("http.read_timeout", "HTTP_READ_TIMEOUT")
 	_ = viper.BindEnv("http.idle_timeout", "HTTP_IDLE_TIMEOUT")
 	_ = viper.BindEnv("http.read_header_timeout", "HTTP_READ_HEADER_TIMEOUT")
+	_ = viper.BindEnv("http.shutdown_timeout", "HTTP_SHUTDOWN_TIMEOUT")
+	_ = viper.BindEnv("http.trusted_proxies", "HTTP_TRUSTED_PROXIES")
 
 	_ = viper.BindEnv("analytics.enabled", "ANALYTICS_ENABLED")
 	_ = viper.BindEnv("analytics.id", "ANALYTICS_ID")
@@ -117,6 +158,10 @@ func InitConfig(logger *otelzap.Logger) {
 	_ = viper.BindEnv("otel.collector_url", "OTEL_COLLECTOR_URL")
 	_ = viper.BindEnv("otel.insecure_mode", "OTEL_INSECURE_MODE")
 
+	_ = viper.BindEnv("metrics.enabled", "METRICS_ENABLED")
+	_ = viper.BindEnv("metrics.listen_address", "METRICS_LISTEN_ADDRESS")
+	_ = viper.BindEnv("metrics.bearer_token", "METRICS_BEARER_TOKEN")
+
 	_ = viper.BindEnv("db.host", "DB_HOST")
 	_ = viper.BindEnv("db.port", "DB_PORT")
 	_ = viper.BindEnv("db.user", "DB_USER")
@@ -135,6 +180,11 @@ func InitConfig(logger *otelzap.Logger) {
 	_ = viper.BindEnv("smtp.user", "SMTP_USER")
 	_ = viper.BindEnv("smtp.pass", "SMTP_PASS")
 	_ = viper.BindEnv("smtp.sender", "SMTP_SENDER")
+	_ = viper.BindEnv("email.transport", "EMAIL_TRANSPORT")
+	_ = viper.BindEnv("email.sendmail_path", "EMAIL_SENDMAIL_PATH")
+	_ = viper.BindEnv("email.file_path", "EMAIL_FILE_PATH")
+	_ = viper.BindEnv("email.queue_enabled", "EMAIL_QUEUE_ENABLED")
+	_ = viper.BindEnv("email.queue_max_attempts", "EMAIL_QUEUE_MAX_ATTEMPTS")
 
 	_ = viper.BindEnv("config.aes_hashkey", "CONFIG_AES_HASHKEY")
 	_ = viper.BindEnv("config.allowedPointValues", "CONFIG_POINTS_ALLOWED")
@@ -158,11 +208,15 @@ func InitConfig(logger *otelzap.Logger) {
 	_ = viper.BindEnv("config.cleanup_storyboards_days_old", "CONFIG_CLEANUP_STORYBOARDS_DAYS_OLD")
 	_ = viper.BindEnv("config.organizations_enabled", "CONFIG_ORGANIZATIONS_ENABLED")
 	_ = viper.BindEnv("config.require_teams", "CONFIG_REQUIRE_TEAMS")
+	_ = viper.BindEnv("config.checkin_schedule_enabled", "CONFIG_CHECKIN_SCHEDULE_ENABLED")
 
 	_ = viper.BindEnv("feature.poker", "FEATURE_POKER")
 	_ = viper.BindEnv("feature.retro", "FEATURE_RETRO")
 	_ = viper.BindEnv("feature.storyboard", "FEATURE_STORYBOARD")
 
+	_ = viper.BindEnv("slack.signing_secret", "SLACK_SIGNING_SECRET")
+	_ = viper.BindEnv("slack.bot_token", "SLACK_BOT_TOKEN")
+
 	_ = viper.BindEnv("auth.method", "AUTH_METHOD")
 	_ = viper.BindEnv("auth.ldap.url", "AUTH_LDAP_URL")
 	_ = viper.BindEnv("auth.ldap.use_tls", "AUTH_LDAP_USE_TLS")
@@ -174,6 +228,25 @@ func InitConfig(logger *otelzap.Logger) {
 	_ = viper.BindEnv("auth.ldap.cn_attr", "AUTH_LDAP_CN_ATTR")
 	_ = viper.BindEnv("auth.header.usernameHeader", "AUTH_HEADER_USERNAME_HEADER")
 	_ = viper.BindEnv("auth.header.emailHeader", "AUTH_HEADER_EMAIL_HEADER")
+	_ = viper.BindEnv("auth.passkey.passwordless", "AUTH_PASSKEY_PASSWORDLESS")
+	_ = viper.BindEnv("auth.throttle.account_attempts", "AUTH_THROTTLE_ACCOUNT_ATTEMPTS")
+	_ = viper.BindEnv("auth.throttle.ip_attempts", "AUTH_THROTTLE_IP_ATTEMPTS")
+	_ = viper.BindEnv("auth.throttle.join_code_attempts", "AUTH_THROTTLE_JOIN_CODE_ATTEMPTS")
+	_ = viper.BindEnv("auth.throttle.lockout_seconds", "AUTH_THROTTLE_LOCKOUT_SECONDS")
+	_ = viper.BindEnv("auth.throttle.max_lockout_seconds", "AUTH_THROTTLE_MAX_LOCKOUT_SECONDS")
+	_ = viper.BindEnv("ratelimit.enabled", "RATELIMIT_ENABLED")
+	_ = viper.BindEnv("ratelimit.store", "RATELIMIT_STORE")
+	_ = viper.BindEnv("ratelimit.requests", "RATELIMIT_REQUESTS")
+	_ = viper.BindEnv("ratelimit.period_seconds", "RATELIMIT_PERIOD_SECONDS")
+	_ = viper.BindEnv("ratelimit.groups", "RATELIMIT_GROUPS")
+	_ = viper.BindEnv("ratelimit.allow_on_error", "RATELIMIT_ALLOW_ON_ERROR")
+	_ = viper.BindEnv("jobs.enabled", "JOBS_ENABLED")
+	_ = viper.BindEnv("jobs.clean_guests", "JOBS_CLEAN_GUESTS")
+	_ = viper.BindEnv("jobs.clean_battles", "JOBS_CLEAN_BATTLES")
+	_ = viper.BindEnv("jobs.clean_retros", "JOBS_CLEAN_RETROS")
+	_ = viper.BindEnv("jobs.clean_storyboards", "JOBS_CLEAN_STORYBOARDS")
+	_ = viper.BindEnv("jobs.lowercase_emails", "JOBS_LOWERCASE_EMAILS")
+	_ = viper.BindEnv("jobs.clean_auth_attempts", "JOBS_CLEAN_AUTH_ATTEMPTS")
 
 	err := viper.ReadInConfig()
 	if err != nil {
@@ -143,6 +143,36 @@ func (d *Service) AlertsUpdate(ctx context.Context, ID string, Name string, Type
 	return nil
 }
 
+// AlertGet gets a global alert
+func (d *Service) AlertGet(ctx context.Context, AlertID string) (*thunderdome.Alert, error) {
+	var a thunderdome.Alert
+
+	err := d.DB.QueryRowContext(ctx,
+		`SELECT id, name, type, content, active, allow_dismiss, registered_only, created_date, updated_date
+		FROM thunderdome.alert WHERE id = $1;`,
+		AlertID,
+	).Scan(
+		&a.Id,
+		&a.Name,
+		&a.Type,
+		&a.Content,
+		&a.Active,
+		&a.AllowDismiss,
+		&a.RegisteredOnly,
+		&a.CreatedDate,
+		&a.UpdatedDate,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("ALERT_NOT_FOUND")
+		}
+		d.Logger.Ctx(ctx).Error("alert get query error", zap.Error(err))
+		return nil, err
+	}
+
+	return &a, nil
+}
+
 // AlertDelete deletes a global alert
 func (d *Service) AlertDelete(ctx context.Context, AlertID string) error {
 	_, err := d.DB.ExecContext(ctx,
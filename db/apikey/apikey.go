@@ -10,6 +10,7 @@ import (
 	"github.com/StevenWeathers/thunderdome-planning-poker/db"
 
 	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+	"github.com/lib/pq"
 	"github.com/uptrace/opentelemetry-go-extra/otelzap"
 
 	"go.uber.org/zap"
@@ -21,8 +22,10 @@ type Service struct {
 	Logger *otelzap.Logger
 }
 
-// GenerateApiKey generates a new API key for a User
-func (d *Service) GenerateApiKey(ctx context.Context, UserID string, KeyName string) (*thunderdome.APIKey, error) {
+// GenerateApiKey generates a new API key for a User with the scopes, optional team restriction and expiration
+func (d *Service) GenerateApiKey(
+	ctx context.Context, UserID string, KeyName string, Scopes []string, TeamID string, ExpireDate *time.Time,
+) (*thunderdome.APIKey, error) {
 	apiPrefix, prefixErr := db.RandomString(8)
 	if prefixErr != nil {
 		err := errors.New("error generating api prefix")
@@ -48,14 +51,21 @@ func (d *Service) GenerateApiKey(ctx context.Context, UserID string, KeyName str
 		UserId:      UserID,
 		Prefix:      apiPrefix,
 		Active:      true,
+		Scopes:      Scopes,
+		TeamId:      TeamID,
+		ExpireDate:  ExpireDate,
 		CreatedDate: time.Now(),
 	}
 
 	e := d.DB.QueryRowContext(ctx,
-		`INSERT INTO thunderdome.api_key (id, name, user_id) VALUES ($1, $2, $3) RETURNING created_date;`,
+		`INSERT INTO thunderdome.api_key (id, name, user_id, scopes, team_id, expire_date)
+		VALUES ($1, $2, $3, $4, NULLIF($5, '')::uuid, $6) RETURNING created_date;`,
 		keyID,
 		KeyName,
 		UserID,
+		pq.StringArray(Scopes),
+		TeamID,
+		ExpireDate,
 	).Scan(&APIKEY.CreatedDate)
 	if e != nil {
 		d.Logger.Ctx(ctx).Error("user_apikey_add query error", zap.Error(e))
@@ -69,7 +79,9 @@ func (d *Service) GenerateApiKey(ctx context.Context, UserID string, KeyName str
 func (d *Service) GetUserApiKeys(ctx context.Context, UserID string) ([]*thunderdome.APIKey, error) {
 	var APIKeys = make([]*thunderdome.APIKey, 0)
 	rows, err := d.DB.QueryContext(ctx,
-		"SELECT id, name, user_id, active, created_date, updated_date FROM thunderdome.api_key WHERE user_id = $1 ORDER BY created_date",
+		`SELECT id, name, user_id, active, scopes, COALESCE(team_id::text, ''), expire_date,
+		last_used_date, COALESCE(last_used_ip, ''), created_date, updated_date
+		FROM thunderdome.api_key WHERE user_id = $1 ORDER BY created_date`,
 		UserID,
 	)
 	if err == nil {
@@ -83,6 +95,11 @@ func (d *Service) GetUserApiKeys(ctx context.Context, UserID string) ([]*thunder
 				&ak.Name,
 				&ak.UserId,
 				&ak.Active,
+				(*pq.StringArray)(&ak.Scopes),
+				&ak.TeamId,
+				&ak.ExpireDate,
+				&ak.LastUsedDate,
+				&ak.LastUsedIP,
 				&ak.CreatedDate,
 				&ak.UpdatedDate,
 			); err != nil {
@@ -135,19 +152,21 @@ func (d *Service) DeleteUserApiKey(ctx context.Context, UserID string, KeyID str
 	return keys, nil
 }
 
-// GetApiKeyUser checks to see if the API key exists and returns the User
-func (d *Service) GetApiKeyUser(ctx context.Context, APK string) (*thunderdome.User, error) {
+// GetApiKeyUser checks to see if the API key exists, is active and unexpired and returns the User and key
+func (d *Service) GetApiKeyUser(ctx context.Context, APK string) (*thunderdome.User, *thunderdome.APIKey, error) {
 	User := &thunderdome.User{}
+	Key := &thunderdome.APIKey{}
 
 	splitKey := strings.Split(APK, ".")
 	hashedKey := db.HashString(APK)
 	keyID := splitKey[0] + "." + hashedKey
 
 	e := d.DB.QueryRowContext(ctx, `
-		SELECT u.id, u.name, u.email, u.type, u.avatar, u.verified, u.notifications_enabled, COALESCE(u.country, ''), COALESCE(u.locale, ''), COALESCE(u.company, ''), COALESCE(u.job_title, ''), u.created_date, u.updated_date, u.last_active 
+		SELECT u.id, u.name, u.email, u.type, u.avatar, u.verified, u.notifications_enabled, COALESCE(u.country, ''), COALESCE(u.locale, ''), COALESCE(u.company, ''), COALESCE(u.job_title, ''), u.created_date, u.updated_date, u.last_active,
+		ak.id, ak.name, ak.scopes, COALESCE(ak.team_id::text, ''), ak.expire_date, ak.last_used_date
 		FROM thunderdome.api_key ak
 		LEFT JOIN thunderdome.users u ON u.id = ak.user_id
-		WHERE ak.id = $1 AND ak.active = true
+		WHERE ak.id = $1 AND ak.active = true AND (ak.expire_date IS NULL OR ak.expire_date > NOW())
 `,
 		keyID,
 	).Scan(
@@ -164,22 +183,48 @@ func (d *Service) GetApiKeyUser(ctx context.Context, APK string) (*thunderdome.U
 		&User.JobTitle,
 		&User.CreatedDate,
 		&User.UpdatedDate,
-		&User.LastActive)
+		&User.LastActive,
+		&Key.Id,
+		&Key.Name,
+		(*pq.StringArray)(&Key.Scopes),
+		&Key.TeamId,
+		&Key.ExpireDate,
+		&Key.LastUsedDate,
+	)
 	if e != nil {
 		d.Logger.Ctx(ctx).Error("GetApiKeyUser query error", zap.Error(e))
-		return nil, errors.New("active API Key match not found")
+		return nil, nil, errors.New("active API Key match not found")
 	}
 
 	User.GravatarHash = db.CreateGravatarHash(User.Email)
+	Key.Prefix = splitKey[0]
+	Key.UserId = User.Id
+	Key.Active = true
+
+	return User, Key, nil
+}
+
+// APIKeyUsed records the last use of the API key, throttled to once a minute to avoid a write per request
+func (d *Service) APIKeyUsed(ctx context.Context, KeyID string, IPAddress string) error {
+	if _, err := d.DB.ExecContext(ctx,
+		`UPDATE thunderdome.api_key SET last_used_date = NOW(), last_used_ip = $2
+		WHERE id = $1 AND (last_used_date IS NULL OR last_used_date < NOW() - INTERVAL '1 minute');`,
+		KeyID,
+		IPAddress,
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("APIKeyUsed query error", zap.Error(err))
+		return err
+	}
 
-	return User, nil
+	return nil
 }
 
 // GetAPIKeys gets a list of api keys
 func (d *Service) GetAPIKeys(ctx context.Context, Limit int, Offset int) []*thunderdome.UserAPIKey {
 	var APIKeys = make([]*thunderdome.UserAPIKey, 0)
 	rows, err := d.DB.QueryContext(ctx,
-		`SELECT apk.id, apk.name, u.id, u.name, u.email, apk.active, apk.created_date, apk.updated_date
+		`SELECT apk.id, apk.name, u.id, u.name, u.email, apk.active, apk.scopes, COALESCE(apk.team_id::text, ''),
+		apk.expire_date, apk.last_used_date, COALESCE(apk.last_used_ip, ''), apk.created_date, apk.updated_date
 		FROM thunderdome.api_key apk
 		LEFT JOIN thunderdome.users u ON apk.user_id = u.id
 		ORDER BY apk.created_date
@@ -201,6 +246,11 @@ func (d *Service) GetAPIKeys(ctx context.Context, Limit int, Offset int) []*thun
 				&ak.UserName,
 				&ak.UserEmail,
 				&ak.Active,
+				(*pq.StringArray)(&ak.Scopes),
+				&ak.TeamId,
+				&ak.ExpireDate,
+				&ak.LastUsedDate,
+				&ak.LastUsedIP,
 				&ak.CreatedDate,
 				&ak.UpdatedDate,
 			); err != nil {
@@ -0,0 +1,149 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+
+	"go.uber.org/zap"
+)
+
+// Service represents a PostgreSQL implementation of thunderdome.AuditDataSvc.
+type Service struct {
+	DB     *sql.DB
+	Logger *otelzap.Logger
+}
+
+// marshalState marshals the before or after state of an audited target, nil when unknown
+func marshalState(State interface{}) (interface{}, error) {
+	if State == nil {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(State)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
+
+// AuditLogCreate appends an entry to the audit log, snapshotting the actors name so it outlives the actor
+func (d *Service) AuditLogCreate(ctx context.Context, Entry *thunderdome.AuditLog) error {
+	Before, err := marshalState(Entry.Before)
+	if err != nil {
+		return err
+	}
+	After, err := marshalState(Entry.After)
+	if err != nil {
+		return err
+	}
+
+	if _, err := d.DB.ExecContext(ctx,
+		`INSERT INTO thunderdome.audit_log
+		(actor_id, actor_name, organization_id, action, target_type, target_id, before, after, ip)
+		VALUES (
+			NULLIF($1, '')::uuid, (SELECT name FROM thunderdome.users WHERE id = NULLIF($1, '')::uuid),
+			NULLIF($2, '')::uuid, $3, $4, $5, $6::jsonb, $7::jsonb, $8
+		);`,
+		Entry.ActorId,
+		Entry.OrgId,
+		Entry.Action,
+		Entry.TargetType,
+		Entry.TargetId,
+		Before,
+		After,
+		Entry.IP,
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("audit log create query error", zap.Error(err))
+		return errors.New("unable to create audit log entry")
+	}
+
+	return nil
+}
+
+// AuditLogList gets a list of audit log entries matching the filter, newest first
+func (d *Service) AuditLogList(ctx context.Context, Filter thunderdome.AuditLogFilter, Limit int, Offset int) ([]*thunderdome.AuditLog, int, error) {
+	Entries := make([]*thunderdome.AuditLog, 0)
+	var Count int
+
+	where := `WHERE ($1 = '' OR organization_id = NULLIF($1, '')::uuid)
+		AND ($2 = '' OR actor_id = NULLIF($2, '')::uuid)
+		AND ($3 = '' OR action = $3)
+		AND ($4 = '' OR target_type = $4)
+		AND ($5 = '' OR target_id = $5)
+		AND ($6::timestamptz IS NULL OR created_date >= $6)
+		AND ($7::timestamptz IS NULL OR created_date < $7)`
+	args := []interface{}{
+		Filter.OrgId,
+		Filter.ActorId,
+		Filter.Action,
+		Filter.TargetType,
+		Filter.TargetId,
+		Filter.Since,
+		Filter.Until,
+	}
+
+	err := d.DB.QueryRowContext(ctx,
+		`SELECT count(id) FROM thunderdome.audit_log `+where+`;`,
+		args...,
+	).Scan(&Count)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("audit log list count query error", zap.Error(err))
+		return nil, 0, err
+	}
+
+	if Count == 0 {
+		return Entries, Count, nil
+	}
+
+	rows, err := d.DB.QueryContext(ctx,
+		`SELECT id, COALESCE(actor_id::text, ''), COALESCE(actor_name, ''), COALESCE(organization_id::text, ''),
+		action, target_type, target_id, before, after, ip, created_date
+		FROM thunderdome.audit_log `+where+`
+		ORDER BY created_date DESC
+		LIMIT $8
+		OFFSET $9;`,
+		append(args, Limit, Offset)...,
+	)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("audit log list query error", zap.Error(err))
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e thunderdome.AuditLog
+		var Before, After []byte
+
+		if err := rows.Scan(
+			&e.Id,
+			&e.ActorId,
+			&e.ActorName,
+			&e.OrgId,
+			&e.Action,
+			&e.TargetType,
+			&e.TargetId,
+			&Before,
+			&After,
+			&e.IP,
+			&e.CreatedDate,
+		); err != nil {
+			d.Logger.Ctx(ctx).Error("audit log list scan error", zap.Error(err))
+		} else {
+			if Before != nil {
+				e.Before = json.RawMessage(Before)
+			}
+			if After != nil {
+				e.After = json.RawMessage(After)
+			}
+			Entries = append(Entries, &e)
+		}
+	}
+
+	return Entries, Count, nil
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// AuthAttemptGet gets the failed authentication attempts of the key, a key without failures has none
+func (d *Service) AuthAttemptGet(ctx context.Context, Scope string, Key string) (*thunderdome.AuthAttempt, error) {
+	a := &thunderdome.AuthAttempt{Scope: Scope, Key: Key}
+
+	err := d.DB.QueryRowContext(ctx,
+		`SELECT failures, locked_until FROM thunderdome.auth_attempt WHERE scope = $1 AND key = $2;`,
+		Scope,
+		Key,
+	).Scan(&a.Failures, &a.LockedUntil)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		d.Logger.Ctx(ctx).Error("auth attempt get query error", zap.Error(err))
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// AuthAttemptFail records a failed authentication attempt of the key returning its failures,
+// failures older than the window are no longer counted
+func (d *Service) AuthAttemptFail(ctx context.Context, Scope string, Key string, Window time.Duration) (*thunderdome.AuthAttempt, error) {
+	a := &thunderdome.AuthAttempt{Scope: Scope, Key: Key}
+
+	err := d.DB.QueryRowContext(ctx,
+		`INSERT INTO thunderdome.auth_attempt AS aa (scope, key, failures, last_failure_date)
+		VALUES ($1, $2, 1, NOW())
+		ON CONFLICT (scope, key) DO UPDATE SET
+			failures = CASE WHEN aa.last_failure_date < NOW() - make_interval(secs => $3) THEN 1 ELSE aa.failures + 1 END,
+			last_failure_date = NOW()
+		RETURNING failures, locked_until;`,
+		Scope,
+		Key,
+		Window.Seconds(),
+	).Scan(&a.Failures, &a.LockedUntil)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("auth attempt fail query error", zap.Error(err))
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// AuthAttemptLock locks the key from further authentication attempts until the given time
+func (d *Service) AuthAttemptLock(ctx context.Context, Scope string, Key string, Until time.Time) error {
+	if _, err := d.DB.ExecContext(ctx,
+		`UPDATE thunderdome.auth_attempt SET locked_until = $3 WHERE scope = $1 AND key = $2;`,
+		Scope,
+		Key,
+		Until,
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("auth attempt lock query error", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// AuthAttemptReset clears the failed authentication attempts of the key
+func (d *Service) AuthAttemptReset(ctx context.Context, Scope string, Key string) error {
+	if _, err := d.DB.ExecContext(ctx,
+		`DELETE FROM thunderdome.auth_attempt WHERE scope = $1 AND key = $2;`,
+		Scope,
+		Key,
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("auth attempt reset query error", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// AuthAttemptPrune deletes the failed authentication attempts older than the window that aren't locked out,
+// returning how many were deleted
+func (d *Service) AuthAttemptPrune(ctx context.Context, Window time.Duration) (int64, error) {
+	res, err := d.DB.ExecContext(ctx,
+		`DELETE FROM thunderdome.auth_attempt
+		WHERE last_failure_date < NOW() - make_interval(secs => $1)
+		AND (locked_until IS NULL OR locked_until < NOW());`,
+		Window.Seconds(),
+	)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("auth attempt prune query error", zap.Error(err))
+		return 0, err
+	}
+
+	deleted, _ := res.RowsAffected()
+
+	return deleted, nil
+}
@@ -234,31 +234,53 @@ func (d *Service) MFASetupGenerate(email string) (string, string, error) {
 }
 
 // MFASetupValidate validates the MFA secret and authenticator token
-// if success enables the user mfa and stores the secret in db
-func (d *Service) MFASetupValidate(ctx context.Context, UserID string, secret string, passcode string) error {
+// if success enables the user mfa, stores the secret in db and returns a new set of recovery codes
+func (d *Service) MFASetupValidate(ctx context.Context, UserID string, secret string, passcode string) ([]string, error) {
 	if passcode == "" || secret == "" {
-		return errors.New("MISSING_SECRET_OR_PASSCODE")
+		return nil, errors.New("MISSING_SECRET_OR_PASSCODE")
 	}
 	valid := totp.Validate(passcode, secret)
 
 	if !valid {
-		return errors.New("INVALID_AUTHENTICATOR_TOKEN")
+		return nil, errors.New("INVALID_AUTHENTICATOR_TOKEN")
 	}
 
 	encryptedSecret, secretErr := db.Encrypt(secret, d.AESHashkey)
 	if secretErr != nil {
-		return fmt.Errorf("error encrypting MFA secret: %w", secretErr)
+		return nil, fmt.Errorf("error encrypting MFA secret: %w", secretErr)
 	}
 
-	if _, err := d.DB.ExecContext(ctx,
-		`CALL thunderdome.user_mfa_enable($1, $2)`, UserID, encryptedSecret); err != nil {
-		return fmt.Errorf("error enabling user MFA: %w", err)
+	// the user_mfa_enable procedure commits on its own so its statements are run here to enable MFA
+	// along with storing the recovery codes in one transaction
+	tx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error enabling user MFA: %w", err)
 	}
+	defer tx.Rollback()
 
-	return nil
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO thunderdome.user_mfa (user_id, secret) VALUES ($1, $2);`, UserID, encryptedSecret); err != nil {
+		return nil, fmt.Errorf("error enabling user MFA: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE thunderdome.users SET mfa_enabled = true, updated_date = NOW() WHERE id = $1;`, UserID); err != nil {
+		return nil, fmt.Errorf("error enabling user MFA: %w", err)
+	}
+
+	RecoveryCodes, err := d.recoveryCodesReplace(ctx, tx, UserID)
+	if err != nil {
+		return nil, fmt.Errorf("error enabling user MFA: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error enabling user MFA: %w", err)
+	}
+
+	return RecoveryCodes, nil
 }
 
-// MFARemove removes MFA requirement from user
+// MFARemove removes MFA requirement from user, along with their recovery codes unless they still have a passkey
 func (d *Service) MFARemove(ctx context.Context, UserID string) error {
 	if _, err := d.DB.ExecContext(ctx,
 		`CALL thunderdome.user_mfa_remove($1)`, UserID); err != nil {
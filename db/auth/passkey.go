@@ -0,0 +1,267 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+	"github.com/lib/pq"
+
+	"go.uber.org/zap"
+)
+
+// PasskeyChallengeCreate stores a WebAuthn ceremony challenge, pruning expired ones
+func (d *Service) PasskeyChallengeCreate(ctx context.Context, Challenge string, Ceremony string, UserId string, SessionId string) error {
+	if _, err := d.DB.ExecContext(ctx,
+		`DELETE FROM thunderdome.webauthn_challenge WHERE expire_date < NOW();`,
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("webauthn challenge prune query error", zap.Error(err))
+	}
+
+	if _, err := d.DB.ExecContext(ctx,
+		`INSERT INTO thunderdome.webauthn_challenge (challenge, ceremony, user_id, session_id)
+		VALUES ($1, $2, NULLIF($3, '')::uuid, NULLIF($4, ''));`,
+		Challenge,
+		Ceremony,
+		UserId,
+		SessionId,
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("webauthn challenge create query error", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// PasskeyChallengeConsume deletes an unexpired WebAuthn ceremony challenge returning it, challenges are single use
+func (d *Service) PasskeyChallengeConsume(ctx context.Context, Challenge string, Ceremony string) (*thunderdome.PasskeyChallenge, error) {
+	c := &thunderdome.PasskeyChallenge{}
+
+	err := d.DB.QueryRowContext(ctx,
+		`DELETE FROM thunderdome.webauthn_challenge
+		WHERE challenge = $1 AND ceremony = $2 AND NOW() < expire_date
+		RETURNING challenge, ceremony, COALESCE(user_id::text, ''), COALESCE(session_id, '');`,
+		Challenge,
+		Ceremony,
+	).Scan(&c.Challenge, &c.Ceremony, &c.UserId, &c.SessionId)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			d.Logger.Ctx(ctx).Error("webauthn challenge consume query error", zap.Error(err))
+		}
+		return nil, errors.New("INVALID_CHALLENGE")
+	}
+
+	return c, nil
+}
+
+// PasskeyCreate stores a users verified passkey enabling MFA for the user, when the user has no unused
+// recovery codes yet a new set is generated and returned so a passkey only user isn't locked out
+func (d *Service) PasskeyCreate(ctx context.Context, Passkey *thunderdome.Passkey) (*thunderdome.Passkey, []string, error) {
+	tx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("passkey create begin transaction error", zap.Error(err))
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO thunderdome.user_passkey
+		(user_id, name, credential_id, public_key, algorithm, sign_count, transports)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_date;`,
+		Passkey.UserId,
+		Passkey.Name,
+		Passkey.CredentialId,
+		Passkey.PublicKey,
+		Passkey.Algorithm,
+		int64(Passkey.SignCount),
+		pq.StringArray(Passkey.Transports),
+	).Scan(&Passkey.Id, &Passkey.CreatedDate)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("passkey create query error", zap.Error(err))
+		return nil, nil, errors.New("PASSKEY_ALREADY_REGISTERED")
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE thunderdome.users SET mfa_enabled = true, updated_date = NOW() WHERE id = $1;`,
+		Passkey.UserId,
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("passkey create enable mfa query error", zap.Error(err))
+		return nil, nil, err
+	}
+
+	var HasRecoveryCodes bool
+	if err := tx.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM thunderdome.user_mfa_recovery_code WHERE user_id = $1 AND used_date IS NULL);`,
+		Passkey.UserId,
+	).Scan(&HasRecoveryCodes); err != nil {
+		d.Logger.Ctx(ctx).Error("passkey create recovery codes query error", zap.Error(err))
+		return nil, nil, err
+	}
+
+	var RecoveryCodes []string
+	if !HasRecoveryCodes {
+		if RecoveryCodes, err = d.recoveryCodesReplace(ctx, tx, Passkey.UserId); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		d.Logger.Ctx(ctx).Error("passkey create commit error", zap.Error(err))
+		return nil, nil, err
+	}
+
+	return Passkey, RecoveryCodes, nil
+}
+
+// PasskeyList gets a list of the users passkeys
+func (d *Service) PasskeyList(ctx context.Context, UserId string) ([]*thunderdome.Passkey, error) {
+	Passkeys := make([]*thunderdome.Passkey, 0)
+
+	rows, err := d.DB.QueryContext(ctx,
+		`SELECT id, user_id, name, credential_id, transports, created_date, last_used_date
+		FROM thunderdome.user_passkey
+		WHERE user_id = $1
+		ORDER BY created_date;`,
+		UserId,
+	)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("passkey list query error", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p thunderdome.Passkey
+		var transports pq.StringArray
+
+		if err := rows.Scan(
+			&p.Id,
+			&p.UserId,
+			&p.Name,
+			&p.CredentialId,
+			&transports,
+			&p.CreatedDate,
+			&p.LastUsedDate,
+		); err != nil {
+			d.Logger.Ctx(ctx).Error("passkey list scan error", zap.Error(err))
+		} else {
+			p.Transports = transports
+			Passkeys = append(Passkeys, &p)
+		}
+	}
+
+	return Passkeys, nil
+}
+
+// PasskeyGetByCredential gets a passkey by its WebAuthn credential ID
+func (d *Service) PasskeyGetByCredential(ctx context.Context, CredentialId string) (*thunderdome.Passkey, error) {
+	var p thunderdome.Passkey
+	var transports pq.StringArray
+	var signCount int64
+
+	err := d.DB.QueryRowContext(ctx,
+		`SELECT id, user_id, name, credential_id, public_key, algorithm, sign_count, transports, created_date, last_used_date
+		FROM thunderdome.user_passkey
+		WHERE credential_id = $1;`,
+		CredentialId,
+	).Scan(
+		&p.Id,
+		&p.UserId,
+		&p.Name,
+		&p.CredentialId,
+		&p.PublicKey,
+		&p.Algorithm,
+		&signCount,
+		&transports,
+		&p.CreatedDate,
+		&p.LastUsedDate,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("PASSKEY_NOT_FOUND")
+		}
+		d.Logger.Ctx(ctx).Error("passkey get query error", zap.Error(err))
+		return nil, err
+	}
+	p.SignCount = uint32(signCount)
+	p.Transports = transports
+
+	return &p, nil
+}
+
+// PasskeyUsed records the passkeys latest signature counter and use, the counter must have increased
+// since it was read for verification unless the authenticator doesn't implement one,
+// otherwise another login with a cloned authenticator or a replayed assertion got there first
+func (d *Service) PasskeyUsed(ctx context.Context, PasskeyId string, SignCount uint32) error {
+	res, err := d.DB.ExecContext(ctx,
+		`UPDATE thunderdome.user_passkey SET sign_count = $2, last_used_date = NOW()
+		WHERE id = $1 AND (sign_count < $2 OR $2 = 0);`,
+		PasskeyId,
+		int64(SignCount),
+	)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("passkey used query error", zap.Error(err))
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		d.Logger.Ctx(ctx).Warn("passkey sign count did not increase, possible cloned authenticator or replay",
+			zap.String("passkey_id", PasskeyId))
+		return errors.New("INVALID_SIGN_COUNT")
+	}
+
+	return nil
+}
+
+// PasskeyDelete deletes a users passkey, disabling MFA and removing the recovery codes
+// when it was the users last second factor
+func (d *Service) PasskeyDelete(ctx context.Context, UserId string, PasskeyId string) error {
+	tx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("passkey delete begin transaction error", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`DELETE FROM thunderdome.user_passkey WHERE user_id = $1 AND id = $2;`,
+		UserId,
+		PasskeyId,
+	)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("passkey delete query error", zap.Error(err))
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return errors.New("PASSKEY_NOT_FOUND")
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE thunderdome.users SET mfa_enabled = (
+			EXISTS(SELECT 1 FROM thunderdome.user_passkey WHERE user_id = $1)
+			OR EXISTS(SELECT 1 FROM thunderdome.user_mfa WHERE user_id = $1)
+		), updated_date = NOW() WHERE id = $1;`,
+		UserId,
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("passkey delete update mfa query error", zap.Error(err))
+		return err
+	}
+
+	// recovery codes are useless without a second factor left to recover
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM thunderdome.user_mfa_recovery_code WHERE user_id = $1
+			AND NOT (SELECT mfa_enabled FROM thunderdome.users WHERE id = $1);`,
+		UserId,
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("passkey delete recovery codes query error", zap.Error(err))
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		d.Logger.Ctx(ctx).Error("passkey delete commit error", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
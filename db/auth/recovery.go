@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+
+	"go.uber.org/zap"
+)
+
+// number of recovery codes generated for a user
+const mfaRecoveryCodeCount = 10
+
+// recovery code alphabet without easily confused characters
+const mfaRecoveryCodeChars = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// generateRecoveryCode generates a random recovery code formatted as xxxxx-xxxxx
+func generateRecoveryCode() (string, error) {
+	code := make([]byte, 0, 11)
+	for i := 0; i < 10; i++ {
+		if i == 5 {
+			code = append(code, '-')
+		}
+		num, err := rand.Int(rand.Reader, big.NewInt(int64(len(mfaRecoveryCodeChars))))
+		if err != nil {
+			return "", err
+		}
+		code = append(code, mfaRecoveryCodeChars[num.Int64()])
+	}
+
+	return string(code), nil
+}
+
+// normalizeRecoveryCode ignores case, whitespace and dashes of the entered recovery code
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.Replace(code, "-", "", -1)
+	return strings.Join(strings.Fields(code), "")
+}
+
+// MFARecoveryCodesGenerate replaces the users MFA recovery codes with a new set, only the hashes are stored
+func (d *Service) MFARecoveryCodesGenerate(ctx context.Context, UserID string) ([]string, error) {
+	tx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("mfa recovery codes begin transaction error", zap.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	Codes, err := d.recoveryCodesReplace(ctx, tx, UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		d.Logger.Ctx(ctx).Error("mfa recovery codes commit error", zap.Error(err))
+		return nil, err
+	}
+
+	return Codes, nil
+}
+
+// recoveryCodesReplace replaces the users MFA recovery codes with a new set as part of the transaction
+func (d *Service) recoveryCodesReplace(ctx context.Context, tx *sql.Tx, UserID string) ([]string, error) {
+	Codes := make([]string, 0, mfaRecoveryCodeCount)
+
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM thunderdome.user_mfa_recovery_code WHERE user_id = $1;`,
+		UserID,
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("mfa recovery codes delete query error", zap.Error(err))
+		return nil, err
+	}
+
+	for i := 0; i < mfaRecoveryCodeCount; i++ {
+		Code, err := generateRecoveryCode()
+		if err != nil {
+			d.Logger.Ctx(ctx).Error("error generating mfa recovery code", zap.Error(err))
+			return nil, errors.New("error generating mfa recovery code")
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO thunderdome.user_mfa_recovery_code (user_id, code_hash) VALUES ($1, $2);`,
+			UserID,
+			db.HashString(normalizeRecoveryCode(Code)),
+		); err != nil {
+			d.Logger.Ctx(ctx).Error("mfa recovery code insert query error", zap.Error(err))
+			return nil, err
+		}
+		Codes = append(Codes, Code)
+	}
+
+	return Codes, nil
+}
+
+// MFARecoveryCodeValidate uses one of the session users recovery codes as the second factor for auth login,
+// enabling the session and returning how many unused codes remain
+func (d *Service) MFARecoveryCodeValidate(ctx context.Context, SessionId string, code string) (int, error) {
+	var Remaining int
+
+	tx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("mfa recovery code begin transaction error", zap.Error(err))
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var UserID string
+	err = tx.QueryRowContext(ctx,
+		`UPDATE thunderdome.user_mfa_recovery_code rc SET used_date = NOW()
+		FROM thunderdome.user_session us
+		WHERE us.session_id = $1 AND rc.user_id = us.user_id
+		AND rc.code_hash = $2 AND rc.used_date IS NULL
+		RETURNING rc.user_id;`,
+		SessionId,
+		db.HashString(normalizeRecoveryCode(code)),
+	).Scan(&UserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, errors.New("INVALID_RECOVERY_CODE")
+		}
+		d.Logger.Ctx(ctx).Error("mfa recovery code use query error", zap.Error(err))
+		return 0, err
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE thunderdome.user_session SET disabled = false WHERE session_id = $1;`,
+		SessionId,
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("mfa recovery code enable session query error", zap.Error(err))
+		return 0, errors.New("unable to enable user session")
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`SELECT count(id) FROM thunderdome.user_mfa_recovery_code WHERE user_id = $1 AND used_date IS NULL;`,
+		UserID,
+	).Scan(&Remaining)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("mfa recovery code count query error", zap.Error(err))
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		d.Logger.Ctx(ctx).Error("mfa recovery code commit error", zap.Error(err))
+		return 0, err
+	}
+
+	return Remaining, nil
+}
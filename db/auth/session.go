@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/StevenWeathers/thunderdome-planning-poker/db"
 
@@ -97,6 +98,24 @@ func (d *Service) GetSessionUser(ctx context.Context, SessionId string) (*thunde
 	return User, nil
 }
 
+// GetSessionCreatedDate gets when an active user session was created
+func (d *Service) GetSessionCreatedDate(ctx context.Context, SessionId string) (time.Time, error) {
+	var CreatedDate time.Time
+
+	e := d.DB.QueryRowContext(ctx,
+		`SELECT created_date FROM thunderdome.user_session WHERE session_id = $1 AND NOW() < expire_date;`,
+		SessionId,
+	).Scan(&CreatedDate)
+	if e != nil {
+		if !errors.Is(e, sql.ErrNoRows) {
+			d.Logger.Ctx(ctx).Error("user_session_created_date query error", zap.Error(e))
+		}
+		return CreatedDate, errors.New("active session match not found")
+	}
+
+	return CreatedDate, nil
+}
+
 // DeleteSession deletes a user authenticated session
 func (d *Service) DeleteSession(ctx context.Context, SessionId string) error {
 	if _, sessionErr := d.DB.ExecContext(ctx, `
@@ -110,3 +129,106 @@ func (d *Service) DeleteSession(ctx context.Context, SessionId string) error {
 
 	return nil
 }
+
+// SessionSeen records the client and last seen date of an active user session, at most once a minute
+func (d *Service) SessionSeen(ctx context.Context, SessionId string, UserAgent string, IPAddress string) error {
+	if _, err := d.DB.ExecContext(ctx,
+		`UPDATE thunderdome.user_session SET last_seen_date = NOW(), user_agent = $2, ip_address = $3
+		WHERE session_id = $1
+		AND (last_seen_date < NOW() - INTERVAL '1 minute' OR user_agent != $2 OR ip_address != $3);`,
+		SessionId,
+		UserAgent,
+		IPAddress,
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("user session seen query error", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// UserSessionList gets a list of the users active sessions
+func (d *Service) UserSessionList(ctx context.Context, UserId string, CurrentSessionId string) ([]*thunderdome.UserSession, error) {
+	Sessions := make([]*thunderdome.UserSession, 0)
+
+	rows, err := d.DB.QueryContext(ctx,
+		`SELECT id, user_agent, ip_address, session_id = $2, created_date, last_seen_date, expire_date
+		FROM thunderdome.user_session
+		WHERE user_id = $1 AND NOW() < expire_date AND disabled = false
+		ORDER BY last_seen_date DESC;`,
+		UserId,
+		CurrentSessionId,
+	)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("user session list query error", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var us thunderdome.UserSession
+
+		if err := rows.Scan(
+			&us.Id,
+			&us.UserAgent,
+			&us.IPAddress,
+			&us.Current,
+			&us.CreatedDate,
+			&us.LastSeenDate,
+			&us.ExpireDate,
+		); err != nil {
+			d.Logger.Ctx(ctx).Error("user session list scan error", zap.Error(err))
+		} else {
+			Sessions = append(Sessions, &us)
+		}
+	}
+
+	return Sessions, nil
+}
+
+// UserSessionDelete deletes one of the users sessions returning its session id
+func (d *Service) UserSessionDelete(ctx context.Context, UserId string, Id string) (string, error) {
+	var SessionId string
+
+	err := d.DB.QueryRowContext(ctx,
+		`DELETE FROM thunderdome.user_session WHERE user_id = $1 AND id = $2 RETURNING session_id;`,
+		UserId,
+		Id,
+	).Scan(&SessionId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", errors.New("SESSION_NOT_FOUND")
+		}
+		d.Logger.Ctx(ctx).Error("user session delete query error", zap.Error(err))
+		return "", err
+	}
+
+	return SessionId, nil
+}
+
+// UserSessionDeleteAll deletes all the users sessions except the optional one returning the deleted session ids
+func (d *Service) UserSessionDeleteAll(ctx context.Context, UserId string, ExceptSessionId string) ([]string, error) {
+	SessionIds := make([]string, 0)
+
+	rows, err := d.DB.QueryContext(ctx,
+		`DELETE FROM thunderdome.user_session WHERE user_id = $1 AND session_id != $2 RETURNING session_id;`,
+		UserId,
+		ExceptSessionId,
+	)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("user session delete all query error", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var SessionId string
+		if err := rows.Scan(&SessionId); err != nil {
+			d.Logger.Ctx(ctx).Error("user session delete all scan error", zap.Error(err))
+		} else {
+			SessionIds = append(SessionIds, SessionId)
+		}
+	}
+
+	return SessionIds, nil
+}
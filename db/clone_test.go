@@ -0,0 +1,176 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/poker"
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/retro"
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/storyboard"
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/team"
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/user"
+)
+
+// cloneFixture a user with a team and one without
+type cloneFixture struct {
+	userID      string
+	otherUserID string
+	teamID      string
+}
+
+func newCloneFixture(t *testing.T, d *db.Service) cloneFixture {
+	t.Helper()
+	ctx := context.Background()
+
+	us := &user.Service{DB: d.DB, Logger: d.Logger}
+	u, err := us.CreateUserGuest(ctx, "Thor")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	o, err := us.CreateUserGuest(ctx, "Loki")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	ts := &team.Service{DB: d.DB, Logger: d.Logger}
+	tm, err := ts.TeamCreate(ctx, u.Id, "Asgard")
+	if err != nil {
+		t.Fatalf("create team: %v", err)
+	}
+
+	return cloneFixture{userID: u.Id, otherUserID: o.Id, teamID: tm.Id}
+}
+
+// TestCloneGame makes sure poker clones don't copy the join and facilitator codes
+// and that templates always belong to a team
+func TestCloneGame(t *testing.T) {
+	d := testDB(t)
+	ctx := context.Background()
+	f := newCloneFixture(t, d)
+	ps := &poker.Service{DB: d.DB, Logger: d.Logger, AESHashKey: testAESHashKey, HTMLSanitizerPolicy: d.HTMLSanitizerPolicy}
+	ts := &team.Service{DB: d.DB, Logger: d.Logger}
+
+	g, err := ps.CreateGame(ctx, f.userID, "Bifrost", []string{"1", "2", "3"}, nil, true, "ceil", "joinme", "leadme", false)
+	if err != nil {
+		t.Fatalf("create game: %v", err)
+	}
+
+	clone, err := ps.CloneGame(ctx, g.Id, f.otherUserID, "", false, "")
+	if err != nil {
+		t.Fatalf("clone game: %v", err)
+	}
+	if clone.JoinCode != "" || clone.FacilitatorCode != "" {
+		t.Fatalf("expected clone codes to be empty, got join code %q and leader code %q", clone.JoinCode, clone.FacilitatorCode)
+	}
+	if clone.Name != g.Name {
+		t.Fatalf("expected clone name %q, got %q", g.Name, clone.Name)
+	}
+
+	if _, err := ps.CloneGame(ctx, g.Id, f.userID, "", true, ""); err == nil || err.Error() != "TEMPLATE_REQUIRES_TEAM" {
+		t.Fatalf("expected TEMPLATE_REQUIRES_TEAM cloning a team-less template, got %v", err)
+	}
+
+	tmpl, err := ps.CloneGame(ctx, g.Id, f.userID, "Bifrost Template", true, f.teamID)
+	if err != nil {
+		t.Fatalf("clone template: %v", err)
+	}
+	if !tmpl.Template {
+		t.Fatal("expected clone to be a template")
+	}
+
+	templates := ts.TeamPokerTemplateList(ctx, f.teamID, 10, 0)
+	if len(templates) != 1 || templates[0].Id != tmpl.Id {
+		t.Fatalf("expected the template to belong to the team, got %d team templates", len(templates))
+	}
+	if _, err := ps.TeamCreateGame(ctx, f.teamID, f.userID, "Bifrost", []string{"1", "2", "3"}, nil, true, "ceil", "", "", false, g.Id); err == nil || err.Error() != "TEMPLATE_NOT_FOUND" {
+		t.Fatalf("expected TEMPLATE_NOT_FOUND creating a game from a non template, got %v", err)
+	}
+	if _, err := ps.TeamCreateGame(ctx, f.teamID, f.userID, "Bifrost", []string{"1", "2", "3"}, nil, true, "ceil", "", "", false, tmpl.Id); err != nil {
+		t.Fatalf("create game from template: %v", err)
+	}
+}
+
+// TestCloneRetro makes sure retro clones don't copy the join and facilitator codes
+// and that templates always belong to a team
+func TestCloneRetro(t *testing.T) {
+	d := testDB(t)
+	ctx := context.Background()
+	f := newCloneFixture(t, d)
+	rs := &retro.Service{DB: d.DB, Logger: d.Logger, AESHashKey: testAESHashKey}
+	ts := &team.Service{DB: d.DB, Logger: d.Logger}
+
+	r, err := rs.RetroCreate(f.userID, "Valhalla", "worked_improve_question", "joinme", "facilitateme", 3, "visible")
+	if err != nil {
+		t.Fatalf("create retro: %v", err)
+	}
+
+	clone, err := rs.CloneRetro(ctx, r.Id, f.otherUserID, "", false, "")
+	if err != nil {
+		t.Fatalf("clone retro: %v", err)
+	}
+	if clone.JoinCode != "" || clone.FacilitatorCode != "" {
+		t.Fatalf("expected clone codes to be empty, got join code %q and facilitator code %q", clone.JoinCode, clone.FacilitatorCode)
+	}
+
+	if _, err := rs.CloneRetro(ctx, r.Id, f.userID, "", true, ""); err == nil || err.Error() != "TEMPLATE_REQUIRES_TEAM" {
+		t.Fatalf("expected TEMPLATE_REQUIRES_TEAM cloning a team-less template, got %v", err)
+	}
+
+	if err := ts.TeamAddRetro(ctx, f.teamID, r.Id); err != nil {
+		t.Fatalf("add retro to team: %v", err)
+	}
+
+	tmpl, err := rs.CloneRetro(ctx, r.Id, f.userID, "Valhalla Template", true, "")
+	if err != nil {
+		t.Fatalf("clone template: %v", err)
+	}
+
+	templates := ts.TeamRetroTemplateList(ctx, f.teamID, 10, 0)
+	if len(templates) != 1 || templates[0].Id != tmpl.Id {
+		t.Fatalf("expected the template to belong to the sources team, got %d team templates", len(templates))
+	}
+}
+
+// TestCloneStoryboard makes sure storyboard clones don't copy the join and facilitator codes
+// and that templates always belong to a team
+func TestCloneStoryboard(t *testing.T) {
+	d := testDB(t)
+	ctx := context.Background()
+	f := newCloneFixture(t, d)
+	ss := &storyboard.Service{DB: d.DB, Logger: d.Logger, AESHashKey: testAESHashKey}
+	ts := &team.Service{DB: d.DB, Logger: d.Logger}
+
+	sb, err := ss.CreateStoryboard(ctx, f.userID, "Yggdrasil", "joinme", "facilitateme")
+	if err != nil {
+		t.Fatalf("create storyboard: %v", err)
+	}
+
+	clone, err := ss.CloneStoryboard(ctx, sb.Id, f.otherUserID, "", false, "")
+	if err != nil {
+		t.Fatalf("clone storyboard: %v", err)
+	}
+	if clone.JoinCode != "" || clone.FacilitatorCode != "" {
+		t.Fatalf("expected clone codes to be empty, got join code %q and facilitator code %q", clone.JoinCode, clone.FacilitatorCode)
+	}
+
+	if _, err := ss.CloneStoryboard(ctx, sb.Id, f.userID, "", true, ""); err == nil || err.Error() != "TEMPLATE_REQUIRES_TEAM" {
+		t.Fatalf("expected TEMPLATE_REQUIRES_TEAM cloning a team-less template, got %v", err)
+	}
+
+	tmpl, err := ss.CloneStoryboard(ctx, sb.Id, f.userID, "Yggdrasil Template", true, f.teamID)
+	if err != nil {
+		t.Fatalf("clone template: %v", err)
+	}
+
+	templates := ts.TeamStoryboardTemplateList(ctx, f.teamID, 10, 0)
+	if len(templates) != 1 || templates[0].Id != tmpl.Id {
+		t.Fatalf("expected the template to belong to the team, got %d team templates", len(templates))
+	}
+	if _, err := ss.TeamCreateStoryboard(ctx, f.teamID, f.userID, "Yggdrasil", "", "", sb.Id); err == nil || err.Error() != "TEMPLATE_NOT_FOUND" {
+		t.Fatalf("expected TEMPLATE_NOT_FOUND creating a storyboard from a non template, got %v", err)
+	}
+	if _, err := ss.TeamCreateStoryboard(ctx, f.teamID, f.userID, "Yggdrasil", "", "", tmpl.Id); err != nil {
+		t.Fatalf("create storyboard from template: %v", err)
+	}
+}
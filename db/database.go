@@ -12,28 +12,49 @@ import (
 	"github.com/XSAM/otelsql"
 	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
 
-	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"go.uber.org/zap"
 
-	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	_ "github.com/lib/pq" // necessary for postgres
 	"github.com/microcosm-cc/bluemonday"
 )
 
 //go:embed migrations/*.sql
-var fs embed.FS
+var migrationsFS embed.FS
 
 // New runs db migrations, sets up a db connection pool
 // and sets previously active users to false during startup
 func New(AdminEmail string, config *Config, logger *otelzap.Logger) *Service {
 	ctx := context.Background()
-	dms, err := iofs.New(fs, "migrations")
-	if err != nil {
-		logger.Ctx(ctx).Fatal("error loading db migrations", zap.Error(err))
+	d := Open(config, logger)
+
+	if err := d.MigrateUp(); err != nil {
+		d.Logger.Ctx(ctx).Error("db migration up error", zap.Error(err))
+	}
+
+	// on server start reset all users to active false for games
+	if _, err := d.DB.Exec(
+		`CALL thunderdome.users_deactivate_all();`); err != nil {
+		d.Logger.Ctx(ctx).Error("CALL thunderdome.deactivate_all_users error", zap.Error(err))
+	}
+
+	// on server start if admin email is specified set that user to admin type
+	if AdminEmail != "" {
+		if _, err := d.DB.Exec(
+			`UPDATE thunderdome.users SET type = 'ADMIN', updated_date = NOW() WHERE email = $1;`,
+			AdminEmail,
+		); err != nil {
+			d.Logger.Ctx(ctx).Error("CALL thunderdome.promote_user_by_email error", zap.Error(err), zap.String("admin_email", AdminEmail))
+		}
 	}
 
+	return d
+}
+
+// Open sets up a db connection pool without running migrations or any startup changes
+func Open(config *Config, logger *otelzap.Logger) *Service {
+	ctx := context.Background()
+
 	// Do this once for each unique policy, and use the policy for the life of the program
 	// Policy creation/editing is not safe to use in multiple goroutines
 	bmp := bluemonday.UGCPolicy()
@@ -73,37 +94,5 @@ func New(AdminEmail string, config *Config, logger *otelzap.Logger) *Service {
 		d.Logger.Ctx(ctx).Error("RegisterDBStatsMetrics error", zap.Error(err))
 	}
 
-	driver, err := postgres.WithInstance(pdb, &postgres.Config{})
-	if err != nil {
-		d.Logger.Ctx(ctx).Error("db driver error", zap.Error(err))
-	}
-	m, err := migrate.NewWithInstance(
-		"iofs",
-		dms,
-		"postgres",
-		driver)
-	if err != nil {
-		d.Logger.Error("new db migration instance", zap.Error(err))
-	}
-	if err := m.Up(); err != nil && err.Error() != "no change" {
-		d.Logger.Ctx(ctx).Error("db migration up error", zap.Error(err))
-	}
-
-	// on server start reset all users to active false for games
-	if _, err := d.DB.Exec(
-		`CALL thunderdome.users_deactivate_all();`); err != nil {
-		d.Logger.Ctx(ctx).Error("CALL thunderdome.deactivate_all_users error", zap.Error(err))
-	}
-
-	// on server start if admin email is specified set that user to admin type
-	if AdminEmail != "" {
-		if _, err := d.DB.Exec(
-			`UPDATE thunderdome.users SET type = 'ADMIN', updated_date = NOW() WHERE email = $1;`,
-			AdminEmail,
-		); err != nil {
-			d.Logger.Ctx(ctx).Error("CALL thunderdome.promote_user_by_email error", zap.Error(err), zap.String("admin_email", AdminEmail))
-		}
-	}
-
 	return d
 }
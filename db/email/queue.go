@@ -0,0 +1,228 @@
+package email
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+
+	"go.uber.org/zap"
+)
+
+// Service represents a PostgreSQL implementation of thunderdome.EmailQueueDataSvc.
+type Service struct {
+	DB     *sql.DB
+	Logger *otelzap.Logger
+}
+
+// EmailQueueAdd queues an email to be sent
+func (d *Service) EmailQueueAdd(ctx context.Context, ToName string, ToEmail string, Subject string, Body string) error {
+	if _, err := d.DB.ExecContext(ctx,
+		`INSERT INTO thunderdome.email_queue (to_name, to_email, subject, body) VALUES ($1, $2, $3, $4);`,
+		ToName,
+		ToEmail,
+		Subject,
+		Body,
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("email queue add query error", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// EmailQueueClaim claims up to the limit of emails due to be sent for the lease duration, counting the attempt.
+// Emails whose lease expires without being sent e.g. the instance stopped mid send are claimable again,
+// claims skip emails locked by other instances claiming at the same time
+func (d *Service) EmailQueueClaim(ctx context.Context, Limit int, Lease time.Duration) ([]*thunderdome.QueuedEmail, error) {
+	Emails := make([]*thunderdome.QueuedEmail, 0)
+
+	rows, err := d.DB.QueryContext(ctx,
+		`UPDATE thunderdome.email_queue SET status = 'SENDING', attempts = attempts + 1,
+		next_attempt_date = NOW() + make_interval(secs => $2), updated_date = NOW()
+		WHERE id IN (
+			SELECT id FROM thunderdome.email_queue
+			WHERE status IN ('PENDING', 'SENDING') AND next_attempt_date <= NOW()
+			ORDER BY next_attempt_date
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, to_name, to_email, subject, body, status, attempts, last_error,
+		next_attempt_date, created_date, updated_date;`,
+		Limit,
+		Lease.Seconds(),
+	)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("email queue claim query error", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e thunderdome.QueuedEmail
+
+		if err := rows.Scan(
+			&e.Id,
+			&e.ToName,
+			&e.ToEmail,
+			&e.Subject,
+			&e.Body,
+			&e.Status,
+			&e.Attempts,
+			&e.LastError,
+			&e.NextAttemptDate,
+			&e.CreatedDate,
+			&e.UpdatedDate,
+		); err != nil {
+			d.Logger.Ctx(ctx).Error("email queue claim scan error", zap.Error(err))
+		} else {
+			Emails = append(Emails, &e)
+		}
+	}
+
+	return Emails, nil
+}
+
+// EmailQueueSent removes the sent email from the queue
+func (d *Service) EmailQueueSent(ctx context.Context, EmailID string) error {
+	if _, err := d.DB.ExecContext(ctx,
+		`DELETE FROM thunderdome.email_queue WHERE id = $1;`,
+		EmailID,
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("email queue sent query error", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// EmailQueueRetryLater records the send error and schedules the next attempt
+func (d *Service) EmailQueueRetryLater(ctx context.Context, EmailID string, SendErr string, RetryAfter time.Duration) error {
+	if _, err := d.DB.ExecContext(ctx,
+		`UPDATE thunderdome.email_queue SET status = 'PENDING', last_error = $2,
+		next_attempt_date = NOW() + make_interval(secs => $3), updated_date = NOW()
+		WHERE id = $1;`,
+		EmailID,
+		SendErr,
+		RetryAfter.Seconds(),
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("email queue retry later query error", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// EmailQueueDeadLetter records the send error and marks the email failed, it won't be attempted again
+func (d *Service) EmailQueueDeadLetter(ctx context.Context, EmailID string, SendErr string) error {
+	if _, err := d.DB.ExecContext(ctx,
+		`UPDATE thunderdome.email_queue SET status = 'FAILED', last_error = $2, updated_date = NOW()
+		WHERE id = $1;`,
+		EmailID,
+		SendErr,
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("email queue dead letter query error", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// EmailQueueFailedList gets the failed emails, most recently failed first
+func (d *Service) EmailQueueFailedList(ctx context.Context, Limit int, Offset int) ([]*thunderdome.QueuedEmail, int, error) {
+	Emails := make([]*thunderdome.QueuedEmail, 0)
+	var Count int
+
+	err := d.DB.QueryRowContext(ctx,
+		`SELECT count(id) FROM thunderdome.email_queue WHERE status = 'FAILED';`,
+	).Scan(&Count)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("email queue failed list count query error", zap.Error(err))
+		return nil, 0, err
+	}
+
+	if Count == 0 {
+		return Emails, Count, nil
+	}
+
+	rows, err := d.DB.QueryContext(ctx,
+		`SELECT id, to_name, to_email, subject, status, attempts, last_error,
+		next_attempt_date, created_date, updated_date
+		FROM thunderdome.email_queue
+		WHERE status = 'FAILED'
+		ORDER BY updated_date DESC
+		LIMIT $1
+		OFFSET $2;`,
+		Limit,
+		Offset,
+	)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("email queue failed list query error", zap.Error(err))
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e thunderdome.QueuedEmail
+
+		if err := rows.Scan(
+			&e.Id,
+			&e.ToName,
+			&e.ToEmail,
+			&e.Subject,
+			&e.Status,
+			&e.Attempts,
+			&e.LastError,
+			&e.NextAttemptDate,
+			&e.CreatedDate,
+			&e.UpdatedDate,
+		); err != nil {
+			d.Logger.Ctx(ctx).Error("email queue failed list scan error", zap.Error(err))
+		} else {
+			Emails = append(Emails, &e)
+		}
+	}
+
+	return Emails, Count, nil
+}
+
+// EmailQueueRetry requeues the failed email to be sent with its attempts reset
+func (d *Service) EmailQueueRetry(ctx context.Context, EmailID string) error {
+	res, err := d.DB.ExecContext(ctx,
+		`UPDATE thunderdome.email_queue SET status = 'PENDING', attempts = 0,
+		next_attempt_date = NOW(), updated_date = NOW()
+		WHERE id = $1 AND status = 'FAILED';`,
+		EmailID,
+	)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("email queue retry query error", zap.Error(err))
+		return err
+	}
+
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return errors.New("EMAIL_NOT_FOUND")
+	}
+
+	return nil
+}
+
+// EmailQueueDelete deletes the failed email
+func (d *Service) EmailQueueDelete(ctx context.Context, EmailID string) error {
+	res, err := d.DB.ExecContext(ctx,
+		`DELETE FROM thunderdome.email_queue WHERE id = $1 AND status = 'FAILED';`,
+		EmailID,
+	)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("email queue delete query error", zap.Error(err))
+		return err
+	}
+
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return errors.New("EMAIL_NOT_FOUND")
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+// latestMigrationVersion gets the version of the newest embedded migration
+func latestMigrationVersion() (uint, error) {
+	entries, err := fs.ReadDir(migrationsFS, "migrations")
+	if err != nil {
+		return 0, err
+	}
+
+	var latest uint64
+	for _, entry := range entries {
+		parts := strings.SplitN(entry.Name(), "_", 2)
+		version, err := strconv.ParseUint(parts[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		if version > latest {
+			latest = version
+		}
+	}
+
+	return uint(latest), nil
+}
+
+// Ready checks the database is reachable and all migrations have been applied without failing
+func (d *Service) Ready(ctx context.Context) error {
+	if err := d.DB.PingContext(ctx); err != nil {
+		return fmt.Errorf("database unreachable: %v", err)
+	}
+
+	var version uint
+	var dirty bool
+	if err := d.DB.QueryRowContext(ctx,
+		`SELECT version, dirty FROM schema_migrations LIMIT 1;`,
+	).Scan(&version, &dirty); err != nil {
+		return fmt.Errorf("database migration state unavailable: %v", err)
+	}
+
+	if dirty {
+		return errors.New("database migration " + strconv.FormatUint(uint64(version), 10) + " failed and is dirty")
+	}
+
+	latest, err := latestMigrationVersion()
+	if err != nil {
+		return err
+	}
+	if version < latest {
+		return fmt.Errorf("database migrations pending, at %d of %d", version, latest)
+	}
+
+	return nil
+}
+
+// Close closes the database connection pool
+func (d *Service) Close() error {
+	return d.DB.Close()
+}
@@ -0,0 +1,73 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/team"
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/user"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestInviteAcceptByEmailOrganizationPolicy makes sure organization invites are only accepted
+// when the organizations email domain policy allows the user, leaving the others pending
+func TestInviteAcceptByEmailOrganizationPolicy(t *testing.T) {
+	d := testDB(t)
+	ctx := context.Background()
+	us := &user.Service{DB: d.DB, Logger: d.Logger}
+	ts := &team.Service{DB: d.DB, Logger: d.Logger}
+	orgs := &team.OrganizationService{DB: d.DB, Logger: d.Logger}
+	is := &team.InviteService{DB: d.DB, Logger: d.Logger}
+
+	owner, err := us.CreateUserGuest(ctx, "Odin")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	org, err := orgs.OrganizationCreate(ctx, owner.Id, "Asgard")
+	if err != nil {
+		t.Fatalf("create organization: %v", err)
+	}
+	domain, err := orgs.OrganizationDomainCreate(ctx, org.Id, "asgard.dev")
+	if err != nil {
+		t.Fatalf("create organization domain: %v", err)
+	}
+	if _, err := orgs.OrganizationDomainVerify(ctx, org.Id, domain.Id); err != nil {
+		t.Fatalf("verify organization domain: %v", err)
+	}
+	if _, err := orgs.OrganizationPolicyUpdate(ctx, org.Id, false, []string{"asgard.dev"}, false, 0); err != nil {
+		t.Fatalf("update organization policy: %v", err)
+	}
+	tm, err := ts.TeamCreate(ctx, owner.Id, "Einherjar")
+	if err != nil {
+		t.Fatalf("create team: %v", err)
+	}
+
+	email := "loki@jotunheim.dev"
+	if _, err := is.InviteCreate(ctx, thunderdome.InviteTypeOrganization, org.Id, email, "MEMBER", owner.Id); err != nil {
+		t.Fatalf("create organization invite: %v", err)
+	}
+	if _, err := is.InviteCreate(ctx, thunderdome.InviteTypeTeam, tm.Id, email, "MEMBER", owner.Id); err != nil {
+		t.Fatalf("create team invite: %v", err)
+	}
+
+	loki, _, err := us.CreateUser(ctx, "Loki", email, "lokiIsAJoke")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	accepted, err := is.InviteAcceptByEmail(ctx, loki.Id, email)
+	if err != nil {
+		t.Fatalf("accept invites: %v", err)
+	}
+	if len(accepted) != 1 || accepted[0].Type != thunderdome.InviteTypeTeam {
+		t.Fatalf("expected only the team invite to be accepted, got %d accepted", len(accepted))
+	}
+
+	pending, err := is.InviteList(ctx, thunderdome.InviteTypeOrganization, org.Id)
+	if err != nil {
+		t.Fatalf("list organization invites: %v", err)
+	}
+	if len(pending) != 1 {
+		t.Fatalf("expected the organization invite to stay pending, got %d pending", len(pending))
+	}
+}
@@ -0,0 +1,161 @@
+package job
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+
+	"go.uber.org/zap"
+)
+
+// leaderLockKey the name hashed into the advisory lock key held by the scheduled jobs leader
+const leaderLockKey = "thunderdome_job_scheduler"
+
+// Service represents a PostgreSQL implementation of thunderdome.JobDataSvc.
+type Service struct {
+	DB     *sql.DB
+	Logger *otelzap.Logger
+}
+
+// leaderLock a session level advisory lock held on a dedicated connection,
+// released by Postgres should the connection be lost
+type leaderLock struct {
+	conn   *sql.Conn
+	logger *otelzap.Logger
+}
+
+// Held checks the locks connection is still alive
+func (l *leaderLock) Held(ctx context.Context) bool {
+	return l.conn.PingContext(ctx) == nil
+}
+
+// Release unlocks the advisory lock and returns its connection to the pool
+func (l *leaderLock) Release(ctx context.Context) {
+	if _, err := l.conn.ExecContext(ctx,
+		`SELECT pg_advisory_unlock(hashtext($1));`, leaderLockKey,
+	); err != nil {
+		l.logger.Ctx(ctx).Error("job leader lock release query error", zap.Error(err))
+	}
+	_ = l.conn.Close()
+}
+
+// JobLeaderLockAcquire tries to acquire the scheduled jobs leader lock, returns nil when another replica holds it
+func (d *Service) JobLeaderLockAcquire(ctx context.Context) (thunderdome.JobLeaderLock, error) {
+	conn, err := d.DB.Conn(ctx)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("job leader lock connection error", zap.Error(err))
+		return nil, err
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx,
+		`SELECT pg_try_advisory_lock(hashtext($1));`, leaderLockKey,
+	).Scan(&acquired); err != nil {
+		_ = conn.Close()
+		d.Logger.Ctx(ctx).Error("job leader lock acquire query error", zap.Error(err))
+		return nil, err
+	}
+
+	if !acquired {
+		_ = conn.Close()
+		return nil, nil
+	}
+
+	return &leaderLock{conn: conn, logger: d.Logger}, nil
+}
+
+// JobRunStart records the start of the jobs run for the scheduled time,
+// returns nil when it has already been run for that time
+func (d *Service) JobRunStart(ctx context.Context, JobName string, ScheduledDate time.Time) (*thunderdome.JobRun, error) {
+	r := &thunderdome.JobRun{}
+
+	err := d.DB.QueryRowContext(ctx,
+		`INSERT INTO thunderdome.job_run (job_name, scheduled_date)
+		VALUES ($1, $2)
+		ON CONFLICT (job_name, scheduled_date) DO NOTHING
+		RETURNING id, job_name, scheduled_date, started_date, status;`,
+		JobName,
+		ScheduledDate,
+	).Scan(&r.Id, &r.JobName, &r.ScheduledDate, &r.StartedDate, &r.Status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		d.Logger.Ctx(ctx).Error("job run start query error", zap.Error(err))
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// JobRunComplete records the outcome of the job run
+func (d *Service) JobRunComplete(ctx context.Context, RunID string, Status string, Result string) error {
+	if _, err := d.DB.ExecContext(ctx,
+		`UPDATE thunderdome.job_run SET status = $2, result = $3, completed_date = NOW() WHERE id = $1;`,
+		RunID,
+		Status,
+		Result,
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("job run complete query error", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// JobRunsFailRunning marks the runs still running as failed, run by a new leader
+// as only the leader runs jobs so they were left running by a replica that stopped before completing them
+func (d *Service) JobRunsFailRunning(ctx context.Context, Result string) (int64, error) {
+	res, err := d.DB.ExecContext(ctx,
+		`UPDATE thunderdome.job_run SET status = $1, result = $2, completed_date = NOW() WHERE status = $3;`,
+		thunderdome.JobRunStatusFailed,
+		Result,
+		thunderdome.JobRunStatusRunning,
+	)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("job runs fail running query error", zap.Error(err))
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// JobRunsLatest gets the most recent run of each job
+func (d *Service) JobRunsLatest(ctx context.Context) ([]*thunderdome.JobRun, error) {
+	Runs := make([]*thunderdome.JobRun, 0)
+
+	rows, err := d.DB.QueryContext(ctx,
+		`SELECT DISTINCT ON (job_name) id, job_name, scheduled_date, started_date, completed_date, status, result
+		FROM thunderdome.job_run
+		ORDER BY job_name, started_date DESC;`,
+	)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("job runs latest query error", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r thunderdome.JobRun
+
+		if err := rows.Scan(
+			&r.Id,
+			&r.JobName,
+			&r.ScheduledDate,
+			&r.StartedDate,
+			&r.CompletedDate,
+			&r.Status,
+			&r.Result,
+		); err != nil {
+			d.Logger.Ctx(ctx).Error("job runs latest scan error", zap.Error(err))
+		} else {
+			Runs = append(Runs, &r)
+		}
+	}
+
+	return Runs, nil
+}
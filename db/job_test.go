@@ -0,0 +1,53 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/job"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestJobRunsFailRunning makes sure runs left running are failed while completed runs are kept
+func TestJobRunsFailRunning(t *testing.T) {
+	d := testDB(t)
+	ctx := context.Background()
+	js := &job.Service{DB: d.DB, Logger: d.Logger}
+	scheduled := time.Now().Truncate(time.Minute)
+	t.Cleanup(func() {
+		_, _ = d.DB.Exec(`DELETE FROM thunderdome.job_run WHERE job_name IN ('test_completed', 'test_interrupted');`)
+	})
+
+	completed, err := js.JobRunStart(ctx, "test_completed", scheduled)
+	if err != nil || completed == nil {
+		t.Fatalf("start job run: %v", err)
+	}
+	if err := js.JobRunComplete(ctx, completed.Id, thunderdome.JobRunStatusSucceeded, "done"); err != nil {
+		t.Fatalf("complete job run: %v", err)
+	}
+	if _, err := js.JobRunStart(ctx, "test_interrupted", scheduled); err != nil {
+		t.Fatalf("start job run: %v", err)
+	}
+
+	if _, err := js.JobRunsFailRunning(ctx, "interrupted"); err != nil {
+		t.Fatalf("fail running job runs: %v", err)
+	}
+
+	runs, err := js.JobRunsLatest(ctx)
+	if err != nil {
+		t.Fatalf("latest job runs: %v", err)
+	}
+	for _, run := range runs {
+		switch run.JobName {
+		case "test_completed":
+			if run.Status != thunderdome.JobRunStatusSucceeded {
+				t.Errorf("expected the completed run to keep its status, got %s", run.Status)
+			}
+		case "test_interrupted":
+			if run.Status != thunderdome.JobRunStatusFailed || run.CompletedDate == nil {
+				t.Errorf("expected the running run to be failed, got %s", run.Status)
+			}
+		}
+	}
+}
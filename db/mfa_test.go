@@ -0,0 +1,92 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/auth"
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/user"
+
+	"github.com/pquerna/otp/totp"
+)
+
+// TestMFASetupValidate makes sure validating MFA setup enables MFA for the user along with returning
+// recovery codes, and a failed setup leaves MFA disabled
+func TestMFASetupValidate(t *testing.T) {
+	d := testDB(t)
+	ctx := context.Background()
+	as := &auth.Service{DB: d.DB, Logger: d.Logger, AESHashkey: testAESHashKey}
+	us := &user.Service{DB: d.DB, Logger: d.Logger}
+
+	thor, err := us.CreateUserGuest(ctx, "Thor")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	secret, _, err := as.MFASetupGenerate("thor@asgard.dev")
+	if err != nil {
+		t.Fatalf("generate mfa secret: %v", err)
+	}
+
+	if _, err := as.MFASetupValidate(ctx, thor.Id, secret, "000000x"); err == nil {
+		t.Fatal("expected an invalid passcode to fail")
+	}
+	u, err := us.GetUser(ctx, thor.Id)
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+	if u.MFAEnabled {
+		t.Fatal("expected a failed setup to leave MFA disabled")
+	}
+
+	passcode, err := totp.GenerateCode(secret, time.Now())
+	if err != nil {
+		t.Fatalf("generate passcode: %v", err)
+	}
+	RecoveryCodes, err := as.MFASetupValidate(ctx, thor.Id, secret, passcode)
+	if err != nil {
+		t.Fatalf("validate mfa setup: %v", err)
+	}
+	if len(RecoveryCodes) == 0 {
+		t.Fatal("expected recovery codes with MFA setup")
+	}
+	u, err = us.GetUser(ctx, thor.Id)
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+	if !u.MFAEnabled {
+		t.Fatal("expected MFA to be enabled")
+	}
+}
+
+// TestMFARemoveRecoveryCodes makes sure removing MFA removes the users recovery codes
+func TestMFARemoveRecoveryCodes(t *testing.T) {
+	d := testDB(t)
+	ctx := context.Background()
+	as := &auth.Service{DB: d.DB, Logger: d.Logger, AESHashkey: testAESHashKey}
+	us := &user.Service{DB: d.DB, Logger: d.Logger}
+
+	thor, err := us.CreateUserGuest(ctx, "Thor")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	secret, _, err := as.MFASetupGenerate("thor@asgard.dev")
+	if err != nil {
+		t.Fatalf("generate mfa secret: %v", err)
+	}
+	passcode, err := totp.GenerateCode(secret, time.Now())
+	if err != nil {
+		t.Fatalf("generate passcode: %v", err)
+	}
+	if _, err := as.MFASetupValidate(ctx, thor.Id, secret, passcode); err != nil {
+		t.Fatalf("validate mfa setup: %v", err)
+	}
+
+	if err := as.MFARemove(ctx, thor.Id); err != nil {
+		t.Fatalf("remove mfa: %v", err)
+	}
+	if count := recoveryCodeCount(t, d, thor.Id); count != 0 {
+		t.Fatalf("expected the recovery codes to be removed with MFA, got %d", count)
+	}
+}
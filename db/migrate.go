@@ -0,0 +1,128 @@
+package db
+
+import (
+	"errors"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// MigrationStatus the applied migration version of the database and the newest embedded migration version
+type MigrationStatus struct {
+	Version uint
+	Dirty   bool
+	Latest  uint
+}
+
+// migrator creates a migration instance of the embedded migrations against the database
+func (d *Service) migrator() (*migrate.Migrate, error) {
+	dms, err := iofs.New(migrationsFS, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	driver, err := postgres.WithInstance(d.DB, &postgres.Config{})
+	if err != nil {
+		return nil, err
+	}
+
+	return migrate.NewWithInstance("iofs", dms, "postgres", driver)
+}
+
+// MigrateUp applies all pending migrations
+func (d *Service) MigrateUp() error {
+	m, err := d.migrator()
+	if err != nil {
+		return err
+	}
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+
+	return nil
+}
+
+// MigrateDown rolls back the number of most recently applied migrations
+func (d *Service) MigrateDown(Steps int) error {
+	m, err := d.migrator()
+	if err != nil {
+		return err
+	}
+
+	if err := m.Steps(-Steps); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+
+	return nil
+}
+
+// MigrationsDown gets the names of the applied migrations rolling back the number of steps would revert,
+// most recent first
+func (d *Service) MigrationsDown(Steps int) ([]string, error) {
+	status, err := d.MigrateStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := fs.ReadDir(migrationsFS, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0)
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".up.sql") {
+			continue
+		}
+		parts := strings.SplitN(entry.Name(), "_", 2)
+		version, err := strconv.ParseUint(parts[0], 10, 64)
+		if err != nil || uint(version) > status.Version {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(entry.Name(), ".up.sql"))
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+
+	if len(names) > Steps {
+		names = names[:Steps]
+	}
+
+	return names, nil
+}
+
+// MigrateForce sets the migration version without running any migration and clears the dirty state,
+// used to recover after a failed migration has been fixed manually
+func (d *Service) MigrateForce(Version int) error {
+	m, err := d.migrator()
+	if err != nil {
+		return err
+	}
+
+	return m.Force(Version)
+}
+
+// MigrateStatus gets the applied migration version and whether it failed leaving the database dirty
+func (d *Service) MigrateStatus() (*MigrationStatus, error) {
+	m, err := d.migrator()
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := latestMigrationVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	version, dirty, err := m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return nil, err
+	}
+
+	return &MigrationStatus{Version: version, Dirty: dirty, Latest: latest}, nil
+}
@@ -0,0 +1,41 @@
+DROP FUNCTION thunderdome.team_create_storyboard(teamid uuid, ownerid uuid, storyboardname character varying, joincode text, facilitatorcode text, templateid uuid);
+CREATE OR REPLACE FUNCTION thunderdome.team_create_storyboard(teamid uuid, ownerid uuid, storyboardname character varying, joincode text, facilitatorcode text)
+ RETURNS uuid
+ LANGUAGE plpgsql
+AS $function$
+DECLARE storyId UUID;
+BEGIN
+    INSERT INTO thunderdome.storyboard (owner_id, name, join_code, facilitator_code)
+        VALUES (ownerId, storyboardName, joinCode, facilitatorCode) RETURNING id INTO storyId;
+    INSERT INTO thunderdome.storyboard_facilitator (storyboard_id, user_id) VALUES (storyId, ownerId);
+    INSERT INTO thunderdome.storyboard_user (storyboard_id, user_id) VALUES(storyId, ownerId);
+    INSERT INTO thunderdome.team_storyboard (team_id, storyboard_id) VALUES (teamid, storyId);
+
+    RETURN storyId;
+END;
+$function$;
+
+DROP FUNCTION thunderdome.team_create_poker(teamid uuid, leaderid uuid, battlename character varying, pointsallowed jsonb, autovoting boolean, pointaveragerounding character varying, hidevoteridentity boolean, joincode text, leadercode text, templateid uuid, OUT pokerid uuid);
+CREATE OR REPLACE FUNCTION thunderdome.team_create_poker(teamid uuid, leaderid uuid, battlename character varying, pointsallowed jsonb, autovoting boolean, pointaveragerounding character varying, hidevoteridentity boolean, joincode text, leadercode text, OUT pokerid uuid)
+ RETURNS uuid
+ LANGUAGE plpgsql
+AS $function$
+BEGIN
+    INSERT INTO thunderdome.poker (owner_id, name, point_values_allowed, auto_finish_voting, point_average_rounding, hide_voter_identity, join_code, leader_code)
+        VALUES (leaderId, battleName, pointsAllowed, autoVoting, pointAverageRounding, hidevoteridentity, joinCode, leaderCode)
+        RETURNING id INTO pokerid;
+    INSERT INTO thunderdome.poker_facilitator (poker_id, user_id) VALUES (pokerid, leaderId);
+    INSERT INTO thunderdome.poker_user (poker_id, user_id) VALUES (pokerid, leaderId);
+    INSERT INTO thunderdome.team_poker (team_id, poker_id) VALUES (teamid, pokerid);
+END;
+$function$;
+
+DROP FUNCTION thunderdome.poker_clone(uuid, uuid, character varying, boolean);
+DROP FUNCTION thunderdome.retro_clone(uuid, uuid, character varying, boolean);
+DROP FUNCTION thunderdome.sb_clone(uuid, uuid, character varying, boolean);
+DROP PROCEDURE thunderdome.poker_template_apply(uuid, uuid);
+DROP PROCEDURE thunderdome.sb_template_apply(uuid, uuid);
+
+ALTER TABLE thunderdome.storyboard DROP COLUMN template;
+ALTER TABLE thunderdome.retro DROP COLUMN template;
+ALTER TABLE thunderdome.poker DROP COLUMN template;
@@ -0,0 +1,179 @@
+ALTER TABLE thunderdome.poker ADD COLUMN template BOOL NOT NULL DEFAULT false;
+ALTER TABLE thunderdome.retro ADD COLUMN template BOOL NOT NULL DEFAULT false;
+ALTER TABLE thunderdome.storyboard ADD COLUMN template BOOL NOT NULL DEFAULT false;
+
+-- copies a storyboards scaffolding (color legend, personas, goals, columns) into another storyboard --
+CREATE OR REPLACE PROCEDURE thunderdome.sb_template_apply(IN templateid uuid, IN storyboardid uuid)
+ LANGUAGE plpgsql
+AS $procedure$
+DECLARE persona RECORD;
+DECLARE goal RECORD;
+DECLARE col RECORD;
+DECLARE newPersonaId UUID;
+DECLARE newGoalId UUID;
+DECLARE newColumnId UUID;
+DECLARE personaMap JSONB := '{}'::JSONB;
+BEGIN
+    UPDATE thunderdome.storyboard sb SET color_legend = t.color_legend, updated_date = NOW()
+        FROM thunderdome.storyboard t WHERE t.id = templateId AND sb.id = storyboardId;
+
+    FOR persona IN SELECT * FROM thunderdome.storyboard_persona WHERE storyboard_id = templateId LOOP
+        INSERT INTO thunderdome.storyboard_persona (storyboard_id, name, role, description)
+            VALUES (storyboardId, persona.name, persona.role, persona.description) RETURNING id INTO newPersonaId;
+        personaMap := personaMap || jsonb_build_object(persona.id::TEXT, newPersonaId);
+    END LOOP;
+
+    FOR goal IN SELECT * FROM thunderdome.storyboard_goal WHERE storyboard_id = templateId ORDER BY sort_order LOOP
+        INSERT INTO thunderdome.storyboard_goal (storyboard_id, name, sort_order)
+            VALUES (storyboardId, goal.name, goal.sort_order) RETURNING id INTO newGoalId;
+        INSERT INTO thunderdome.storyboard_goal_persona (goal_id, persona_id)
+            SELECT newGoalId, (personaMap ->> gp.persona_id::TEXT)::UUID
+            FROM thunderdome.storyboard_goal_persona gp WHERE gp.goal_id = goal.id;
+
+        FOR col IN SELECT * FROM thunderdome.storyboard_column WHERE goal_id = goal.id ORDER BY sort_order LOOP
+            INSERT INTO thunderdome.storyboard_column (storyboard_id, goal_id, name, sort_order)
+                VALUES (storyboardId, newGoalId, col.name, col.sort_order) RETURNING id INTO newColumnId;
+            INSERT INTO thunderdome.storyboard_column_persona (column_id, persona_id)
+                SELECT newColumnId, (personaMap ->> cp.persona_id::TEXT)::UUID
+                FROM thunderdome.storyboard_column_persona cp WHERE cp.column_id = col.id;
+        END LOOP;
+    END LOOP;
+END;
+$procedure$;
+
+-- copies a poker games un-estimated stories into another poker game --
+CREATE OR REPLACE PROCEDURE thunderdome.poker_template_apply(IN templateid uuid, IN pokerid uuid)
+ LANGUAGE plpgsql
+AS $procedure$
+BEGIN
+    INSERT INTO thunderdome.poker_story (poker_id, name, type, reference_id, link, description, acceptance_criteria, priority)
+        SELECT pokerId, ps.name, ps.type, ps.reference_id, ps.link, ps.description, ps.acceptance_criteria, ps.priority
+        FROM thunderdome.poker_story ps
+        WHERE ps.poker_id = templateId AND COALESCE(ps.points, '') = ''
+        ORDER BY ps.created_date;
+END;
+$procedure$;
+
+CREATE OR REPLACE FUNCTION thunderdome.sb_clone(storyboardid uuid, ownerid uuid, storyboardname character varying, istemplate boolean)
+ RETURNS uuid
+ LANGUAGE plpgsql
+AS $function$
+DECLARE newId UUID;
+BEGIN
+    INSERT INTO thunderdome.storyboard (owner_id, name, join_code, facilitator_code, template)
+        SELECT ownerId, COALESCE(NULLIF(storyboardName, ''), s.name), s.join_code, s.facilitator_code, isTemplate
+        FROM thunderdome.storyboard s WHERE s.id = storyboardId
+        RETURNING id INTO newId;
+    IF newId IS NULL THEN
+        RAISE EXCEPTION 'STORYBOARD_NOT_FOUND';
+    END IF;
+    INSERT INTO thunderdome.storyboard_facilitator (storyboard_id, user_id) VALUES (newId, ownerId);
+    INSERT INTO thunderdome.storyboard_user (storyboard_id, user_id) VALUES (newId, ownerId);
+    INSERT INTO thunderdome.team_storyboard (team_id, storyboard_id)
+        SELECT ts.team_id, newId FROM thunderdome.team_storyboard ts WHERE ts.storyboard_id = storyboardId;
+    CALL thunderdome.sb_template_apply(storyboardId, newId);
+
+    RETURN newId;
+END;
+$function$;
+
+CREATE OR REPLACE FUNCTION thunderdome.retro_clone(retroid uuid, userid uuid, retroname character varying, istemplate boolean)
+ RETURNS uuid
+ LANGUAGE plpgsql
+AS $function$
+DECLARE newId UUID;
+BEGIN
+    INSERT INTO thunderdome.retro (owner_id, name, format, join_code, facilitator_code, max_votes, brainstorm_visibility, template)
+        SELECT userId, COALESCE(NULLIF(retroName, ''), r.name), r.format, r.join_code, r.facilitator_code, r.max_votes, r.brainstorm_visibility, isTemplate
+        FROM thunderdome.retro r WHERE r.id = retroId
+        RETURNING id INTO newId;
+    IF newId IS NULL THEN
+        RAISE EXCEPTION 'RETRO_NOT_FOUND';
+    END IF;
+    INSERT INTO thunderdome.retro_facilitator (retro_id, user_id) VALUES (newId, userId);
+    INSERT INTO thunderdome.retro_user (retro_id, user_id) VALUES (newId, userId);
+    INSERT INTO thunderdome.team_retro (team_id, retro_id)
+        SELECT tr.team_id, newId FROM thunderdome.team_retro tr WHERE tr.retro_id = retroId;
+
+    RETURN newId;
+END;
+$function$;
+
+CREATE OR REPLACE FUNCTION thunderdome.poker_clone(pokerid uuid, userid uuid, pokername character varying, istemplate boolean)
+ RETURNS uuid
+ LANGUAGE plpgsql
+AS $function$
+DECLARE newId UUID;
+BEGIN
+    INSERT INTO thunderdome.poker (owner_id, name, point_values_allowed, auto_finish_voting, point_average_rounding, hide_voter_identity, join_code, leader_code, template)
+        SELECT userId, COALESCE(NULLIF(pokerName, ''), p.name), p.point_values_allowed, p.auto_finish_voting, p.point_average_rounding, p.hide_voter_identity, p.join_code, p.leader_code, isTemplate
+        FROM thunderdome.poker p WHERE p.id = pokerId
+        RETURNING id INTO newId;
+    IF newId IS NULL THEN
+        RAISE EXCEPTION 'POKER_NOT_FOUND';
+    END IF;
+    INSERT INTO thunderdome.poker_facilitator (poker_id, user_id) VALUES (newId, userId);
+    INSERT INTO thunderdome.poker_user (poker_id, user_id) VALUES (newId, userId);
+    INSERT INTO thunderdome.team_poker (team_id, poker_id)
+        SELECT tp.team_id, newId FROM thunderdome.team_poker tp WHERE tp.poker_id = pokerId;
+    CALL thunderdome.poker_template_apply(pokerId, newId);
+
+    RETURN newId;
+END;
+$function$;
+
+DROP FUNCTION thunderdome.team_create_poker(teamid uuid, leaderid uuid, battlename character varying, pointsallowed jsonb, autovoting boolean, pointaveragerounding character varying, hidevoteridentity boolean, joincode text, leadercode text, OUT pokerid uuid);
+CREATE OR REPLACE FUNCTION thunderdome.team_create_poker(teamid uuid, leaderid uuid, battlename character varying, pointsallowed jsonb, autovoting boolean, pointaveragerounding character varying, hidevoteridentity boolean, joincode text, leadercode text, templateid uuid, OUT pokerid uuid)
+ RETURNS uuid
+ LANGUAGE plpgsql
+AS $function$
+BEGIN
+    IF templateid IS NOT NULL AND NOT EXISTS (
+        SELECT 1 FROM thunderdome.team_poker tp
+        JOIN thunderdome.poker p ON p.id = tp.poker_id
+        WHERE tp.team_id = teamid AND tp.poker_id = templateid AND p.template = true
+    ) THEN
+        RAISE EXCEPTION 'TEMPLATE_NOT_FOUND';
+    END IF;
+
+    INSERT INTO thunderdome.poker (owner_id, name, point_values_allowed, auto_finish_voting, point_average_rounding, hide_voter_identity, join_code, leader_code)
+        VALUES (leaderId, battleName, pointsAllowed, autoVoting, pointAverageRounding, hidevoteridentity, joinCode, leaderCode)
+        RETURNING id INTO pokerid;
+    INSERT INTO thunderdome.poker_facilitator (poker_id, user_id) VALUES (pokerid, leaderId);
+    INSERT INTO thunderdome.poker_user (poker_id, user_id) VALUES (pokerid, leaderId);
+    INSERT INTO thunderdome.team_poker (team_id, poker_id) VALUES (teamid, pokerid);
+
+    IF templateid IS NOT NULL THEN
+        CALL thunderdome.poker_template_apply(templateid, pokerid);
+    END IF;
+END;
+$function$;
+
+DROP FUNCTION thunderdome.team_create_storyboard(teamid uuid, ownerid uuid, storyboardname character varying, joincode text, facilitatorcode text);
+CREATE OR REPLACE FUNCTION thunderdome.team_create_storyboard(teamid uuid, ownerid uuid, storyboardname character varying, joincode text, facilitatorcode text, templateid uuid)
+ RETURNS uuid
+ LANGUAGE plpgsql
+AS $function$
+DECLARE storyId UUID;
+BEGIN
+    IF templateid IS NOT NULL AND NOT EXISTS (
+        SELECT 1 FROM thunderdome.team_storyboard ts
+        JOIN thunderdome.storyboard s ON s.id = ts.storyboard_id
+        WHERE ts.team_id = teamid AND ts.storyboard_id = templateid AND s.template = true
+    ) THEN
+        RAISE EXCEPTION 'TEMPLATE_NOT_FOUND';
+    END IF;
+
+    INSERT INTO thunderdome.storyboard (owner_id, name, join_code, facilitator_code)
+        VALUES (ownerId, storyboardName, joinCode, facilitatorCode) RETURNING id INTO storyId;
+    INSERT INTO thunderdome.storyboard_facilitator (storyboard_id, user_id) VALUES (storyId, ownerId);
+    INSERT INTO thunderdome.storyboard_user (storyboard_id, user_id) VALUES(storyId, ownerId);
+    INSERT INTO thunderdome.team_storyboard (team_id, storyboard_id) VALUES (teamid, storyId);
+
+    IF templateid IS NOT NULL THEN
+        CALL thunderdome.sb_template_apply(templateid, storyId);
+    END IF;
+
+    RETURN storyId;
+END;
+$function$;
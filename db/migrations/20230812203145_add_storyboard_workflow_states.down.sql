@@ -0,0 +1,43 @@
+-- copies a storyboards scaffolding (color legend, personas, goals, columns) into another storyboard --
+CREATE OR REPLACE PROCEDURE thunderdome.sb_template_apply(IN templateid uuid, IN storyboardid uuid)
+ LANGUAGE plpgsql
+AS $procedure$
+DECLARE persona RECORD;
+DECLARE goal RECORD;
+DECLARE col RECORD;
+DECLARE newPersonaId UUID;
+DECLARE newGoalId UUID;
+DECLARE newColumnId UUID;
+DECLARE personaMap JSONB := '{}'::JSONB;
+BEGIN
+    UPDATE thunderdome.storyboard sb SET color_legend = t.color_legend, updated_date = NOW()
+        FROM thunderdome.storyboard t WHERE t.id = templateId AND sb.id = storyboardId;
+
+    FOR persona IN SELECT * FROM thunderdome.storyboard_persona WHERE storyboard_id = templateId LOOP
+        INSERT INTO thunderdome.storyboard_persona (storyboard_id, name, role, description)
+            VALUES (storyboardId, persona.name, persona.role, persona.description) RETURNING id INTO newPersonaId;
+        personaMap := personaMap || jsonb_build_object(persona.id::TEXT, newPersonaId);
+    END LOOP;
+
+    FOR goal IN SELECT * FROM thunderdome.storyboard_goal WHERE storyboard_id = templateId ORDER BY sort_order LOOP
+        INSERT INTO thunderdome.storyboard_goal (storyboard_id, name, sort_order)
+            VALUES (storyboardId, goal.name, goal.sort_order) RETURNING id INTO newGoalId;
+        INSERT INTO thunderdome.storyboard_goal_persona (goal_id, persona_id)
+            SELECT newGoalId, (personaMap ->> gp.persona_id::TEXT)::UUID
+            FROM thunderdome.storyboard_goal_persona gp WHERE gp.goal_id = goal.id;
+
+        FOR col IN SELECT * FROM thunderdome.storyboard_column WHERE goal_id = goal.id ORDER BY sort_order LOOP
+            INSERT INTO thunderdome.storyboard_column (storyboard_id, goal_id, name, sort_order)
+                VALUES (storyboardId, newGoalId, col.name, col.sort_order) RETURNING id INTO newColumnId;
+            INSERT INTO thunderdome.storyboard_column_persona (column_id, persona_id)
+                SELECT newColumnId, (personaMap ->> cp.persona_id::TEXT)::UUID
+                FROM thunderdome.storyboard_column_persona cp WHERE cp.column_id = col.id;
+        END LOOP;
+    END LOOP;
+END;
+$procedure$;
+
+ALTER TABLE thunderdome.storyboard_story DROP COLUMN state;
+ALTER TABLE thunderdome.storyboard_column DROP COLUMN wip_limit;
+ALTER TABLE thunderdome.storyboard DROP COLUMN wip_limit_enforced;
+ALTER TABLE thunderdome.storyboard DROP COLUMN workflow_states;
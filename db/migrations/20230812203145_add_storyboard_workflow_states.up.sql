@@ -0,0 +1,45 @@
+ALTER TABLE thunderdome.storyboard ADD COLUMN workflow_states JSONB NOT NULL DEFAULT '[{"name":"todo","wip":false,"done":false},{"name":"in-progress","wip":true,"done":false},{"name":"review","wip":true,"done":false},{"name":"done","wip":false,"done":true}]'::JSONB;
+ALTER TABLE thunderdome.storyboard ADD COLUMN wip_limit_enforced BOOL NOT NULL DEFAULT false;
+ALTER TABLE thunderdome.storyboard_column ADD COLUMN wip_limit INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE thunderdome.storyboard_story ADD COLUMN state VARCHAR(64) NOT NULL DEFAULT 'todo';
+UPDATE thunderdome.storyboard_story SET state = 'done' WHERE closed = true;
+
+-- copies a storyboards scaffolding (color legend, workflow, personas, goals, columns) into another storyboard --
+CREATE OR REPLACE PROCEDURE thunderdome.sb_template_apply(IN templateid uuid, IN storyboardid uuid)
+ LANGUAGE plpgsql
+AS $procedure$
+DECLARE persona RECORD;
+DECLARE goal RECORD;
+DECLARE col RECORD;
+DECLARE newPersonaId UUID;
+DECLARE newGoalId UUID;
+DECLARE newColumnId UUID;
+DECLARE personaMap JSONB := '{}'::JSONB;
+BEGIN
+    UPDATE thunderdome.storyboard sb SET color_legend = t.color_legend, workflow_states = t.workflow_states,
+        wip_limit_enforced = t.wip_limit_enforced, updated_date = NOW()
+        FROM thunderdome.storyboard t WHERE t.id = templateId AND sb.id = storyboardId;
+
+    FOR persona IN SELECT * FROM thunderdome.storyboard_persona WHERE storyboard_id = templateId LOOP
+        INSERT INTO thunderdome.storyboard_persona (storyboard_id, name, role, description)
+            VALUES (storyboardId, persona.name, persona.role, persona.description) RETURNING id INTO newPersonaId;
+        personaMap := personaMap || jsonb_build_object(persona.id::TEXT, newPersonaId);
+    END LOOP;
+
+    FOR goal IN SELECT * FROM thunderdome.storyboard_goal WHERE storyboard_id = templateId ORDER BY sort_order LOOP
+        INSERT INTO thunderdome.storyboard_goal (storyboard_id, name, sort_order)
+            VALUES (storyboardId, goal.name, goal.sort_order) RETURNING id INTO newGoalId;
+        INSERT INTO thunderdome.storyboard_goal_persona (goal_id, persona_id)
+            SELECT newGoalId, (personaMap ->> gp.persona_id::TEXT)::UUID
+            FROM thunderdome.storyboard_goal_persona gp WHERE gp.goal_id = goal.id;
+
+        FOR col IN SELECT * FROM thunderdome.storyboard_column WHERE goal_id = goal.id ORDER BY sort_order LOOP
+            INSERT INTO thunderdome.storyboard_column (storyboard_id, goal_id, name, sort_order, wip_limit)
+                VALUES (storyboardId, newGoalId, col.name, col.sort_order, col.wip_limit) RETURNING id INTO newColumnId;
+            INSERT INTO thunderdome.storyboard_column_persona (column_id, persona_id)
+                SELECT newColumnId, (personaMap ->> cp.persona_id::TEXT)::UUID
+                FROM thunderdome.storyboard_column_persona cp WHERE cp.column_id = col.id;
+        END LOOP;
+    END LOOP;
+END;
+$procedure$;
@@ -0,0 +1,58 @@
+ALTER TABLE thunderdome.storyboard_goal ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE thunderdome.storyboard_column ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE thunderdome.storyboard_story ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE thunderdome.retro_group ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE thunderdome.retro_action ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+DROP FUNCTION thunderdome.sb_goals_get(storyboardid uuid);
+CREATE OR REPLACE FUNCTION thunderdome.sb_goals_get(storyboardid uuid)
+ RETURNS TABLE(id uuid, sort_order integer, name character varying, version integer, columns json, personas json)
+ LANGUAGE plpgsql
+AS $function$
+BEGIN
+    RETURN QUERY
+        SELECT
+            sg.id,
+            sg.sort_order,
+            sg.name,
+            sg.version,
+            COALESCE(json_agg(to_jsonb(t) - 'goal_id' ORDER BY t.sort_order) FILTER (WHERE t.id IS NOT NULL), '[]') AS columns,
+            COALESCE(json_agg(to_jsonb(sgp) - 'goal_id') FILTER (WHERE sgp.goal_id IS NOT NULL), '[]') AS personas
+        FROM thunderdome.storyboard_goal sg
+        LEFT JOIN (
+            SELECT
+                sc.*,
+                COALESCE(
+                    json_agg(stss ORDER BY stss.sort_order) FILTER (WHERE stss.id IS NOT NULL), '[]'
+                ) AS stories,
+                COALESCE(
+                    json_agg(scp) FILTER (WHERE scp.column_id IS NOT NULL), '[]'
+                ) AS personas
+            FROM thunderdome.storyboard_column sc
+            LEFT JOIN (
+                SELECT cp.column_id, sp.*
+                FROM thunderdome.storyboard_column_persona cp
+                LEFT JOIN thunderdome.storyboard_persona sp ON sp.id = cp.persona_id
+            ) scp ON scp.column_id = sc.id
+            LEFT JOIN (
+                SELECT
+                    ss.*,
+                    COALESCE(
+                        json_agg(stcm ORDER BY stcm.created_date) FILTER (WHERE stcm.id IS NOT NULL), '[]'
+                    ) AS comments
+                FROM thunderdome.storyboard_story ss
+                LEFT JOIN thunderdome.storyboard_story_comment stcm ON stcm.story_id = ss.id
+                GROUP BY ss.id
+            ) stss ON stss.column_id = sc.id
+            GROUP BY sc.id
+        ) t ON t.goal_id = sg.id
+        LEFT JOIN (
+            SELECT gp.goal_id, sp.*
+            FROM thunderdome.storyboard_goal_persona gp
+            LEFT JOIN thunderdome.storyboard_persona sp ON sp.id = gp.persona_id
+        ) sgp ON sgp.goal_id = sg.id
+        WHERE sg.storyboard_id = storyboardId
+        GROUP BY sg.id
+        ORDER BY sg.sort_order;
+END;
+$function$;
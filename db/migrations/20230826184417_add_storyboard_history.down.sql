@@ -0,0 +1,4 @@
+DROP FUNCTION thunderdome.sb_history_undo(storyboardid uuid, userid uuid);
+DROP PROCEDURE thunderdome.sb_snapshot_restore(IN storyboardid uuid, IN snapshot jsonb);
+DROP FUNCTION thunderdome.sb_snapshot_get(storyboardid uuid);
+DROP TABLE thunderdome.storyboard_history;
@@ -0,0 +1,128 @@
+CREATE TABLE thunderdome.storyboard_history (
+    id UUID NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(),
+    storyboard_id UUID NOT NULL REFERENCES thunderdome.storyboard(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES thunderdome.users(id) ON DELETE SET NULL,
+    event_type VARCHAR(64) NOT NULL,
+    before JSONB,
+    after JSONB,
+    undo_of UUID REFERENCES thunderdome.storyboard_history(id) ON DELETE SET NULL,
+    created_date TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX storyboard_history_storyboard_id_idx ON thunderdome.storyboard_history (storyboard_id, created_date);
+CREATE INDEX storyboard_history_undo_of_idx ON thunderdome.storyboard_history (undo_of);
+
+CREATE OR REPLACE FUNCTION thunderdome.sb_snapshot_get(storyboardid uuid)
+ RETURNS jsonb
+ LANGUAGE plpgsql
+AS $function$
+DECLARE snapshot jsonb;
+BEGIN
+    SELECT jsonb_build_object(
+        'storyboard', jsonb_build_object(
+            'color_legend', s.color_legend,
+            'workflow_states', s.workflow_states,
+            'wip_limit_enforced', s.wip_limit_enforced
+        ),
+        'personas', (
+            SELECT COALESCE(jsonb_agg(to_jsonb(sp) ORDER BY sp.id), '[]')
+            FROM thunderdome.storyboard_persona sp WHERE sp.storyboard_id = s.id
+        ),
+        'goals', (
+            SELECT COALESCE(jsonb_agg(to_jsonb(sg) ORDER BY sg.sort_order), '[]')
+            FROM thunderdome.storyboard_goal sg WHERE sg.storyboard_id = s.id
+        ),
+        'goal_personas', (
+            SELECT COALESCE(jsonb_agg(to_jsonb(gp) ORDER BY gp.goal_id, gp.persona_id), '[]')
+            FROM thunderdome.storyboard_goal_persona gp
+            JOIN thunderdome.storyboard_goal sg ON sg.id = gp.goal_id
+            WHERE sg.storyboard_id = s.id
+        ),
+        'columns', (
+            SELECT COALESCE(jsonb_agg(to_jsonb(sc) ORDER BY sc.goal_id, sc.sort_order), '[]')
+            FROM thunderdome.storyboard_column sc WHERE sc.storyboard_id = s.id
+        ),
+        'column_personas', (
+            SELECT COALESCE(jsonb_agg(to_jsonb(cp) ORDER BY cp.column_id, cp.persona_id), '[]')
+            FROM thunderdome.storyboard_column_persona cp
+            JOIN thunderdome.storyboard_column sc ON sc.id = cp.column_id
+            WHERE sc.storyboard_id = s.id
+        ),
+        'stories', (
+            SELECT COALESCE(jsonb_agg(to_jsonb(ss) ORDER BY ss.column_id, ss.sort_order), '[]')
+            FROM thunderdome.storyboard_story ss WHERE ss.storyboard_id = s.id
+        ),
+        'comments', (
+            SELECT COALESCE(jsonb_agg(to_jsonb(sm) ORDER BY sm.created_date, sm.id), '[]')
+            FROM thunderdome.storyboard_story_comment sm WHERE sm.storyboard_id = s.id
+        )
+    ) INTO snapshot
+    FROM thunderdome.storyboard s
+    WHERE s.id = storyboardid;
+
+    RETURN snapshot;
+END;
+$function$;
+
+CREATE OR REPLACE PROCEDURE thunderdome.sb_snapshot_restore(IN storyboardid uuid, IN snapshot jsonb)
+ LANGUAGE plpgsql
+AS $procedure$
+BEGIN
+    UPDATE thunderdome.storyboard SET
+        color_legend = snapshot->'storyboard'->'color_legend',
+        workflow_states = snapshot->'storyboard'->'workflow_states',
+        wip_limit_enforced = (snapshot->'storyboard'->>'wip_limit_enforced')::BOOL,
+        updated_date = NOW()
+    WHERE id = storyboardid;
+
+    -- goals cascade to their columns, stories, comments and persona links
+    DELETE FROM thunderdome.storyboard_goal WHERE storyboard_id = storyboardid;
+    DELETE FROM thunderdome.storyboard_persona WHERE storyboard_id = storyboardid;
+
+    INSERT INTO thunderdome.storyboard_persona
+    SELECT * FROM jsonb_populate_recordset(NULL::thunderdome.storyboard_persona, snapshot->'personas');
+    INSERT INTO thunderdome.storyboard_goal
+    SELECT * FROM jsonb_populate_recordset(NULL::thunderdome.storyboard_goal, snapshot->'goals');
+    INSERT INTO thunderdome.storyboard_goal_persona
+    SELECT * FROM jsonb_populate_recordset(NULL::thunderdome.storyboard_goal_persona, snapshot->'goal_personas');
+    INSERT INTO thunderdome.storyboard_column
+    SELECT * FROM jsonb_populate_recordset(NULL::thunderdome.storyboard_column, snapshot->'columns');
+    INSERT INTO thunderdome.storyboard_column_persona
+    SELECT * FROM jsonb_populate_recordset(NULL::thunderdome.storyboard_column_persona, snapshot->'column_personas');
+    INSERT INTO thunderdome.storyboard_story
+    SELECT * FROM jsonb_populate_recordset(NULL::thunderdome.storyboard_story, snapshot->'stories');
+    -- comments by users that have since been deleted can't be restored
+    INSERT INTO thunderdome.storyboard_story_comment
+    SELECT sm.* FROM jsonb_populate_recordset(NULL::thunderdome.storyboard_story_comment, snapshot->'comments') sm
+    WHERE sm.user_id IS NULL OR EXISTS (SELECT 1 FROM thunderdome.users u WHERE u.id = sm.user_id);
+END;
+$procedure$;
+
+CREATE OR REPLACE FUNCTION thunderdome.sb_history_undo(storyboardid uuid, userid uuid)
+ RETURNS uuid
+ LANGUAGE plpgsql
+AS $function$
+DECLARE historyId UUID;
+DECLARE beforeSnapshot JSONB;
+DECLARE currentSnapshot JSONB;
+BEGIN
+    SELECT h.id, h.before INTO historyId, beforeSnapshot
+    FROM thunderdome.storyboard_history h
+    WHERE h.storyboard_id = storyboardid AND h.before IS NOT NULL AND h.event_type <> 'undo'
+        AND NOT EXISTS (SELECT 1 FROM thunderdome.storyboard_history u WHERE u.undo_of = h.id)
+    ORDER BY h.created_date DESC
+    LIMIT 1
+    FOR UPDATE;
+
+    IF historyId IS NULL THEN
+        RAISE EXCEPTION 'NOTHING_TO_UNDO';
+    END IF;
+
+    currentSnapshot := thunderdome.sb_snapshot_get(storyboardid);
+    CALL thunderdome.sb_snapshot_restore(storyboardid, beforeSnapshot);
+
+    INSERT INTO thunderdome.storyboard_history (storyboard_id, user_id, event_type, before, after, undo_of)
+    VALUES (storyboardid, userid, 'undo', currentSnapshot, beforeSnapshot, historyId);
+
+    RETURN historyId;
+END;
+$function$;
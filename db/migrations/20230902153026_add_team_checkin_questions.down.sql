@@ -0,0 +1,2 @@
+DROP TABLE thunderdome.team_checkin_answer;
+DROP TABLE thunderdome.team_checkin_question;
@@ -0,0 +1,21 @@
+CREATE TABLE thunderdome.team_checkin_question (
+    "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+    "team_id" uuid NOT NULL REFERENCES thunderdome.team ("id") ON DELETE CASCADE,
+    "question" VARCHAR(256) NOT NULL,
+    "type" VARCHAR(16) NOT NULL DEFAULT 'text',
+    "required" bool NOT NULL DEFAULT false,
+    "sort_order" INTEGER NOT NULL DEFAULT 0,
+    "created_date" timestamptz NOT NULL DEFAULT now(),
+    "updated_date" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id"),
+    CONSTRAINT team_checkin_question_type_check CHECK (type IN ('text', 'yesno', 'scale'))
+);
+CREATE INDEX team_checkin_question_team_id_idx ON thunderdome.team_checkin_question (team_id);
+
+CREATE TABLE thunderdome.team_checkin_answer (
+    "checkin_id" uuid NOT NULL REFERENCES thunderdome.team_checkin ("id") ON DELETE CASCADE,
+    "question_id" uuid NOT NULL REFERENCES thunderdome.team_checkin_question ("id") ON DELETE CASCADE,
+    "answer" text NOT NULL DEFAULT '',
+    PRIMARY KEY ("checkin_id", "question_id")
+);
+CREATE INDEX team_checkin_answer_question_id_idx ON thunderdome.team_checkin_answer (question_id);
@@ -0,0 +1 @@
+DROP TABLE thunderdome.team_checkin_schedule;
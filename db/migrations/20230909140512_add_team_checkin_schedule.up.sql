@@ -0,0 +1,13 @@
+CREATE TABLE thunderdome.team_checkin_schedule (
+    "team_id" uuid NOT NULL REFERENCES thunderdome.team ("id") ON DELETE CASCADE,
+    "enabled" bool NOT NULL DEFAULT true,
+    "days" SMALLINT[] NOT NULL DEFAULT '{1,2,3,4,5}',
+    "reminder_time" VARCHAR(5) NOT NULL DEFAULT '09:00',
+    "digest_time" VARCHAR(5) NOT NULL DEFAULT '17:00',
+    "time_zone" VARCHAR(64) NOT NULL DEFAULT 'America/New_York',
+    "last_reminder_date" DATE,
+    "last_digest_date" DATE,
+    "created_date" timestamptz NOT NULL DEFAULT now(),
+    "updated_date" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("team_id")
+);
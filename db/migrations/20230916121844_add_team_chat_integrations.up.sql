@@ -0,0 +1,14 @@
+CREATE TABLE thunderdome.team_chat_integration (
+    "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+    "team_id" uuid NOT NULL REFERENCES thunderdome.team ("id") ON DELETE CASCADE,
+    "provider" VARCHAR(16) NOT NULL,
+    "name" VARCHAR(64) NOT NULL DEFAULT '',
+    "webhook_url" text NOT NULL,
+    "events" TEXT[] NOT NULL DEFAULT '{checkin_blockers,poker_finished,retro_completed}',
+    "enabled" bool NOT NULL DEFAULT true,
+    "created_date" timestamptz NOT NULL DEFAULT now(),
+    "updated_date" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id"),
+    CONSTRAINT team_chat_integration_provider_check CHECK (provider IN ('slack', 'mattermost'))
+);
+CREATE INDEX team_chat_integration_team_id_idx ON thunderdome.team_chat_integration (team_id);
@@ -0,0 +1,9 @@
+CREATE TABLE thunderdome.team_slack_channel (
+    "slack_team_id" VARCHAR(32) NOT NULL,
+    "slack_channel_id" VARCHAR(32) NOT NULL,
+    "team_id" uuid NOT NULL REFERENCES thunderdome.team ("id") ON DELETE CASCADE,
+    "created_by" uuid REFERENCES thunderdome.users ("id") ON DELETE SET NULL,
+    "created_date" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("slack_team_id", "slack_channel_id")
+);
+CREATE INDEX team_slack_channel_team_id_idx ON thunderdome.team_slack_channel (team_id);
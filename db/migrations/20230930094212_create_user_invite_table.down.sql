@@ -0,0 +1 @@
+DROP TABLE thunderdome.user_invite;
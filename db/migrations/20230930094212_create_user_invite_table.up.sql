@@ -0,0 +1,22 @@
+CREATE TABLE thunderdome.user_invite (
+    "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+    "invite_type" VARCHAR(16) NOT NULL,
+    "team_id" uuid REFERENCES thunderdome.team ("id") ON DELETE CASCADE,
+    "organization_id" uuid REFERENCES thunderdome.organization ("id") ON DELETE CASCADE,
+    "department_id" uuid REFERENCES thunderdome.organization_department ("id") ON DELETE CASCADE,
+    "email" VARCHAR(320) NOT NULL,
+    "role" VARCHAR(16) NOT NULL DEFAULT 'MEMBER',
+    "invited_by" uuid REFERENCES thunderdome.users ("id") ON DELETE SET NULL,
+    "created_date" timestamptz NOT NULL DEFAULT now(),
+    "expire_date" timestamptz NOT NULL DEFAULT (now() + '7 days'::interval),
+    PRIMARY KEY ("id"),
+    CONSTRAINT user_invite_type_check CHECK (
+        (invite_type = 'team' AND team_id IS NOT NULL)
+        OR (invite_type = 'organization' AND organization_id IS NOT NULL)
+        OR (invite_type = 'department' AND department_id IS NOT NULL)
+    )
+);
+CREATE INDEX user_invite_email_idx ON thunderdome.user_invite (LOWER(email));
+CREATE INDEX user_invite_team_id_idx ON thunderdome.user_invite (team_id);
+CREATE INDEX user_invite_organization_id_idx ON thunderdome.user_invite (organization_id);
+CREATE INDEX user_invite_department_id_idx ON thunderdome.user_invite (department_id);
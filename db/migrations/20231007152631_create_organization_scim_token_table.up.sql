@@ -0,0 +1,13 @@
+CREATE TABLE thunderdome.organization_scim_token (
+    "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+    "organization_id" uuid NOT NULL REFERENCES thunderdome.organization ("id") ON DELETE CASCADE,
+    "name" VARCHAR(64) NOT NULL DEFAULT '',
+    "prefix" VARCHAR(8) NOT NULL,
+    "token_hash" VARCHAR(128) NOT NULL,
+    "created_by" uuid REFERENCES thunderdome.users ("id") ON DELETE SET NULL,
+    "created_date" timestamptz NOT NULL DEFAULT now(),
+    "last_used_date" timestamptz,
+    PRIMARY KEY ("id"),
+    UNIQUE ("token_hash")
+);
+CREATE INDEX organization_scim_token_organization_id_idx ON thunderdome.organization_scim_token (organization_id);
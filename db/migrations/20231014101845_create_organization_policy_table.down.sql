@@ -0,0 +1 @@
+DROP TABLE thunderdome.organization_policy;
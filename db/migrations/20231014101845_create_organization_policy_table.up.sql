@@ -0,0 +1,11 @@
+CREATE TABLE thunderdome.organization_policy (
+    "organization_id" uuid NOT NULL REFERENCES thunderdome.organization ("id") ON DELETE CASCADE,
+    "require_mfa" bool NOT NULL DEFAULT false,
+    "allowed_domains" TEXT[] NOT NULL DEFAULT '{}',
+    "auto_join" bool NOT NULL DEFAULT false,
+    "session_lifetime_hours" INTEGER NOT NULL DEFAULT 0,
+    "created_date" timestamptz NOT NULL DEFAULT now(),
+    "updated_date" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("organization_id")
+);
+CREATE INDEX organization_policy_allowed_domains_idx ON thunderdome.organization_policy USING GIN (allowed_domains) WHERE auto_join = true;
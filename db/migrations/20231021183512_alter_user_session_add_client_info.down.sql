@@ -0,0 +1,5 @@
+ALTER TABLE thunderdome.user_session DROP COLUMN last_seen_date;
+ALTER TABLE thunderdome.user_session DROP COLUMN ip_address;
+ALTER TABLE thunderdome.user_session DROP COLUMN user_agent;
+ALTER TABLE thunderdome.user_session DROP CONSTRAINT user_session_id_key;
+ALTER TABLE thunderdome.user_session DROP COLUMN id;
@@ -0,0 +1,5 @@
+ALTER TABLE thunderdome.user_session ADD COLUMN id UUID NOT NULL DEFAULT gen_random_uuid();
+ALTER TABLE thunderdome.user_session ADD CONSTRAINT user_session_id_key UNIQUE (id);
+ALTER TABLE thunderdome.user_session ADD COLUMN user_agent TEXT NOT NULL DEFAULT '';
+ALTER TABLE thunderdome.user_session ADD COLUMN ip_address VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE thunderdome.user_session ADD COLUMN last_seen_date TIMESTAMPTZ NOT NULL DEFAULT NOW();
@@ -0,0 +1,13 @@
+CREATE OR REPLACE PROCEDURE thunderdome.user_mfa_remove(IN userid uuid)
+ LANGUAGE plpgsql
+AS $procedure$
+BEGIN
+    DELETE FROM thunderdome.user_mfa WHERE user_id = userId;
+    UPDATE thunderdome.users SET mfa_enabled = false, updated_date = NOW() WHERE id = userId;
+
+    COMMIT;
+END;
+$procedure$;
+
+DROP TABLE thunderdome.webauthn_challenge;
+DROP TABLE thunderdome.user_passkey;
@@ -0,0 +1,37 @@
+CREATE TABLE thunderdome.user_passkey (
+    "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+    "user_id" uuid NOT NULL REFERENCES thunderdome.users ("id") ON DELETE CASCADE,
+    "name" VARCHAR(64) NOT NULL,
+    "credential_id" TEXT NOT NULL UNIQUE,
+    "public_key" BYTEA NOT NULL,
+    "algorithm" INTEGER NOT NULL,
+    "sign_count" BIGINT NOT NULL DEFAULT 0,
+    "transports" TEXT[] NOT NULL DEFAULT '{}',
+    "created_date" timestamptz NOT NULL DEFAULT now(),
+    "last_used_date" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX user_passkey_user_id_idx ON thunderdome.user_passkey (user_id);
+
+CREATE TABLE thunderdome.webauthn_challenge (
+    "challenge" TEXT NOT NULL,
+    "ceremony" VARCHAR(16) NOT NULL CHECK (ceremony IN ('register', 'login', 'mfa')),
+    "user_id" uuid REFERENCES thunderdome.users ("id") ON DELETE CASCADE,
+    "session_id" TEXT,
+    "expire_date" timestamptz NOT NULL DEFAULT now() + INTERVAL '5 minutes',
+    PRIMARY KEY ("challenge")
+);
+
+-- passkeys are a second factor so MFA remains enabled while the user has any
+CREATE OR REPLACE PROCEDURE thunderdome.user_mfa_remove(IN userid uuid)
+ LANGUAGE plpgsql
+AS $procedure$
+BEGIN
+    DELETE FROM thunderdome.user_mfa WHERE user_id = userId;
+    UPDATE thunderdome.users SET mfa_enabled = EXISTS(
+        SELECT 1 FROM thunderdome.user_passkey WHERE user_id = userId
+    ), updated_date = NOW() WHERE id = userId;
+
+    COMMIT;
+END;
+$procedure$;
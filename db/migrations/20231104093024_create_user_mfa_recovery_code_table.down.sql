@@ -0,0 +1 @@
+DROP TABLE thunderdome.user_mfa_recovery_code;
@@ -0,0 +1,9 @@
+CREATE TABLE thunderdome.user_mfa_recovery_code (
+    "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+    "user_id" uuid NOT NULL REFERENCES thunderdome.users ("id") ON DELETE CASCADE,
+    "code_hash" TEXT NOT NULL,
+    "used_date" timestamptz,
+    "created_date" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id")
+);
+CREATE INDEX user_mfa_recovery_code_user_id_idx ON thunderdome.user_mfa_recovery_code (user_id);
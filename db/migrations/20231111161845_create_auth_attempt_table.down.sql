@@ -0,0 +1 @@
+DROP TABLE thunderdome.auth_attempt;
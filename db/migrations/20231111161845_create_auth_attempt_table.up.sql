@@ -0,0 +1,9 @@
+CREATE TABLE thunderdome.auth_attempt (
+    "scope" VARCHAR(32) NOT NULL,
+    "key" TEXT NOT NULL,
+    "failures" INTEGER NOT NULL DEFAULT 0,
+    "locked_until" timestamptz,
+    "last_failure_date" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("scope", "key")
+);
+CREATE INDEX auth_attempt_last_failure_date_idx ON thunderdome.auth_attempt (last_failure_date);
@@ -0,0 +1,5 @@
+ALTER TABLE thunderdome.api_key DROP COLUMN last_used_ip;
+ALTER TABLE thunderdome.api_key DROP COLUMN last_used_date;
+ALTER TABLE thunderdome.api_key DROP COLUMN expire_date;
+ALTER TABLE thunderdome.api_key DROP COLUMN team_id;
+ALTER TABLE thunderdome.api_key DROP COLUMN scopes;
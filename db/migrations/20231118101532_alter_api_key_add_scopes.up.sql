@@ -0,0 +1,11 @@
+ALTER TABLE thunderdome.api_key ADD COLUMN scopes TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE thunderdome.api_key ADD COLUMN team_id uuid REFERENCES thunderdome.team ("id") ON DELETE CASCADE;
+ALTER TABLE thunderdome.api_key ADD COLUMN expire_date timestamptz;
+ALTER TABLE thunderdome.api_key ADD COLUMN last_used_date timestamptz;
+ALTER TABLE thunderdome.api_key ADD COLUMN last_used_ip VARCHAR(64);
+
+-- existing keys keep the full access of their user
+UPDATE thunderdome.api_key SET scopes = ARRAY[
+    'poker:read', 'poker:write', 'retro:read', 'retro:write', 'storyboard:read', 'storyboard:write',
+    'teams:read', 'teams:write', 'users:read', 'users:write', 'admin'
+];
@@ -0,0 +1,3 @@
+DROP TRIGGER organization_service_account_delete_user ON thunderdome.organization_service_account;
+DROP FUNCTION thunderdome.organization_service_account_delete_user();
+DROP TABLE thunderdome.organization_service_account;
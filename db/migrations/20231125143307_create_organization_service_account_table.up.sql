@@ -0,0 +1,20 @@
+CREATE TABLE thunderdome.organization_service_account (
+    "user_id" uuid NOT NULL REFERENCES thunderdome.users ("id") ON DELETE CASCADE,
+    "organization_id" uuid NOT NULL REFERENCES thunderdome.organization ("id") ON DELETE CASCADE,
+    "created_by" uuid REFERENCES thunderdome.users ("id") ON DELETE SET NULL,
+    "created_date" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("user_id")
+);
+CREATE INDEX organization_service_account_organization_id_idx ON thunderdome.organization_service_account (organization_id);
+
+-- service account users are owned by their organization and removed along with it
+CREATE OR REPLACE FUNCTION thunderdome.organization_service_account_delete_user() RETURNS trigger AS $$
+BEGIN
+    DELETE FROM thunderdome.users WHERE id = OLD.user_id AND type = 'SERVICE';
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER organization_service_account_delete_user
+    AFTER DELETE ON thunderdome.organization_service_account
+    FOR EACH ROW EXECUTE PROCEDURE thunderdome.organization_service_account_delete_user();
@@ -0,0 +1,2 @@
+DROP FUNCTION thunderdome.rate_limit_take(varchar, integer, double precision);
+DROP TABLE thunderdome.rate_limit_bucket;
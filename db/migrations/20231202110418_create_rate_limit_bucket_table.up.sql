@@ -0,0 +1,36 @@
+CREATE TABLE thunderdome.rate_limit_bucket (
+    "key" varchar(256) NOT NULL,
+    "tokens" double precision NOT NULL,
+    "updated_date" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("key")
+);
+CREATE INDEX rate_limit_bucket_updated_date_idx ON thunderdome.rate_limit_bucket (updated_date);
+
+-- takes a request token from the keys bucket after refilling it for the time elapsed since it was last updated,
+-- buckets unused for a day are full and occasionally pruned
+CREATE OR REPLACE FUNCTION thunderdome.rate_limit_take(
+    bucketKey varchar, capacity integer, refillRate double precision, OUT allowed boolean, OUT remaining double precision
+) AS $$
+DECLARE
+    currentTokens double precision;
+    lastUpdated timestamptz;
+BEGIN
+    IF random() < 0.001 THEN
+        DELETE FROM thunderdome.rate_limit_bucket WHERE updated_date < NOW() - interval '1 day';
+    END IF;
+
+    INSERT INTO thunderdome.rate_limit_bucket (key, tokens) VALUES (bucketKey, capacity)
+    ON CONFLICT (key) DO NOTHING;
+
+    SELECT tokens, updated_date INTO currentTokens, lastUpdated
+    FROM thunderdome.rate_limit_bucket WHERE key = bucketKey FOR UPDATE;
+
+    remaining := LEAST(capacity, currentTokens + EXTRACT(EPOCH FROM (clock_timestamp() - lastUpdated)) * refillRate);
+    allowed := remaining >= 1;
+    IF allowed THEN
+        remaining := remaining - 1;
+    END IF;
+
+    UPDATE thunderdome.rate_limit_bucket SET tokens = remaining, updated_date = clock_timestamp() WHERE key = bucketKey;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,3 @@
+DROP TRIGGER audit_log_immutable ON thunderdome.audit_log;
+DROP FUNCTION thunderdome.audit_log_immutable();
+DROP TABLE thunderdome.audit_log;
@@ -0,0 +1,29 @@
+CREATE TABLE thunderdome.audit_log (
+    "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+    "actor_id" uuid,
+    "actor_name" varchar(256),
+    "organization_id" uuid,
+    "action" varchar(64) NOT NULL,
+    "target_type" varchar(64) NOT NULL,
+    "target_id" varchar(256) NOT NULL DEFAULT '',
+    "before" jsonb,
+    "after" jsonb,
+    "ip" varchar(64) NOT NULL DEFAULT '',
+    "created_date" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id")
+);
+CREATE INDEX audit_log_created_date_idx ON thunderdome.audit_log (created_date);
+CREATE INDEX audit_log_organization_id_idx ON thunderdome.audit_log (organization_id, created_date);
+CREATE INDEX audit_log_actor_id_idx ON thunderdome.audit_log (actor_id);
+CREATE INDEX audit_log_target_idx ON thunderdome.audit_log (target_type, target_id);
+
+-- the audit log is append-only, actors and targets are not foreign keys so entries outlive them
+CREATE OR REPLACE FUNCTION thunderdome.audit_log_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_immutable
+    BEFORE UPDATE OR DELETE ON thunderdome.audit_log
+    FOR EACH ROW EXECUTE PROCEDURE thunderdome.audit_log_immutable();
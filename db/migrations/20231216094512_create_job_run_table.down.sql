@@ -0,0 +1 @@
+DROP TABLE thunderdome.job_run;
@@ -0,0 +1,12 @@
+CREATE TABLE thunderdome.job_run (
+    "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+    "job_name" varchar(64) NOT NULL,
+    "scheduled_date" timestamptz NOT NULL,
+    "started_date" timestamptz NOT NULL DEFAULT now(),
+    "completed_date" timestamptz,
+    "status" varchar(16) NOT NULL DEFAULT 'RUNNING',
+    "result" text NOT NULL DEFAULT '',
+    PRIMARY KEY ("id"),
+    UNIQUE ("job_name", "scheduled_date")
+);
+CREATE INDEX job_run_job_name_idx ON thunderdome.job_run (job_name, started_date);
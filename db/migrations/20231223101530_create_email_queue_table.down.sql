@@ -0,0 +1 @@
+DROP TABLE thunderdome.email_queue;
@@ -0,0 +1,15 @@
+CREATE TABLE thunderdome.email_queue (
+    "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+    "to_name" varchar(256) NOT NULL DEFAULT '',
+    "to_email" varchar(320) NOT NULL,
+    "subject" varchar(256) NOT NULL,
+    "body" text NOT NULL,
+    "status" varchar(16) NOT NULL DEFAULT 'PENDING',
+    "attempts" int NOT NULL DEFAULT 0,
+    "last_error" text NOT NULL DEFAULT '',
+    "next_attempt_date" timestamptz NOT NULL DEFAULT now(),
+    "created_date" timestamptz NOT NULL DEFAULT now(),
+    "updated_date" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id")
+);
+CREATE INDEX email_queue_status_next_attempt_idx ON thunderdome.email_queue (status, next_attempt_date);
@@ -0,0 +1,71 @@
+DROP FUNCTION thunderdome.sb_clone(uuid, uuid, character varying, boolean, uuid);
+DROP FUNCTION thunderdome.retro_clone(uuid, uuid, character varying, boolean, uuid);
+DROP FUNCTION thunderdome.poker_clone(uuid, uuid, character varying, boolean, uuid);
+
+CREATE OR REPLACE FUNCTION thunderdome.sb_clone(storyboardid uuid, ownerid uuid, storyboardname character varying, istemplate boolean)
+ RETURNS uuid
+ LANGUAGE plpgsql
+AS $function$
+DECLARE newId UUID;
+BEGIN
+    INSERT INTO thunderdome.storyboard (owner_id, name, join_code, facilitator_code, template)
+        SELECT ownerId, COALESCE(NULLIF(storyboardName, ''), s.name), s.join_code, s.facilitator_code, isTemplate
+        FROM thunderdome.storyboard s WHERE s.id = storyboardId
+        RETURNING id INTO newId;
+    IF newId IS NULL THEN
+        RAISE EXCEPTION 'STORYBOARD_NOT_FOUND';
+    END IF;
+    INSERT INTO thunderdome.storyboard_facilitator (storyboard_id, user_id) VALUES (newId, ownerId);
+    INSERT INTO thunderdome.storyboard_user (storyboard_id, user_id) VALUES (newId, ownerId);
+    INSERT INTO thunderdome.team_storyboard (team_id, storyboard_id)
+        SELECT ts.team_id, newId FROM thunderdome.team_storyboard ts WHERE ts.storyboard_id = storyboardId;
+    CALL thunderdome.sb_template_apply(storyboardId, newId);
+
+    RETURN newId;
+END;
+$function$;
+
+CREATE OR REPLACE FUNCTION thunderdome.retro_clone(retroid uuid, userid uuid, retroname character varying, istemplate boolean)
+ RETURNS uuid
+ LANGUAGE plpgsql
+AS $function$
+DECLARE newId UUID;
+BEGIN
+    INSERT INTO thunderdome.retro (owner_id, name, format, join_code, facilitator_code, max_votes, brainstorm_visibility, template)
+        SELECT userId, COALESCE(NULLIF(retroName, ''), r.name), r.format, r.join_code, r.facilitator_code, r.max_votes, r.brainstorm_visibility, isTemplate
+        FROM thunderdome.retro r WHERE r.id = retroId
+        RETURNING id INTO newId;
+    IF newId IS NULL THEN
+        RAISE EXCEPTION 'RETRO_NOT_FOUND';
+    END IF;
+    INSERT INTO thunderdome.retro_facilitator (retro_id, user_id) VALUES (newId, userId);
+    INSERT INTO thunderdome.retro_user (retro_id, user_id) VALUES (newId, userId);
+    INSERT INTO thunderdome.team_retro (team_id, retro_id)
+        SELECT tr.team_id, newId FROM thunderdome.team_retro tr WHERE tr.retro_id = retroId;
+
+    RETURN newId;
+END;
+$function$;
+
+CREATE OR REPLACE FUNCTION thunderdome.poker_clone(pokerid uuid, userid uuid, pokername character varying, istemplate boolean)
+ RETURNS uuid
+ LANGUAGE plpgsql
+AS $function$
+DECLARE newId UUID;
+BEGIN
+    INSERT INTO thunderdome.poker (owner_id, name, point_values_allowed, auto_finish_voting, point_average_rounding, hide_voter_identity, join_code, leader_code, template)
+        SELECT userId, COALESCE(NULLIF(pokerName, ''), p.name), p.point_values_allowed, p.auto_finish_voting, p.point_average_rounding, p.hide_voter_identity, p.join_code, p.leader_code, isTemplate
+        FROM thunderdome.poker p WHERE p.id = pokerId
+        RETURNING id INTO newId;
+    IF newId IS NULL THEN
+        RAISE EXCEPTION 'POKER_NOT_FOUND';
+    END IF;
+    INSERT INTO thunderdome.poker_facilitator (poker_id, user_id) VALUES (newId, userId);
+    INSERT INTO thunderdome.poker_user (poker_id, user_id) VALUES (newId, userId);
+    INSERT INTO thunderdome.team_poker (team_id, poker_id)
+        SELECT tp.team_id, newId FROM thunderdome.team_poker tp WHERE tp.poker_id = pokerId;
+    CALL thunderdome.poker_template_apply(pokerId, newId);
+
+    RETURN newId;
+END;
+$function$;
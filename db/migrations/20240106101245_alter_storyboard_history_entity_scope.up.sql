@@ -0,0 +1,230 @@
+-- history entries record only the entities the change touched instead of the whole storyboard --
+ALTER TABLE thunderdome.storyboard_history ADD COLUMN entity_kind VARCHAR(32);
+ALTER TABLE thunderdome.storyboard_history ADD COLUMN entity_ids UUID[] NOT NULL DEFAULT '{}';
+-- the full storyboard snapshots of existing entries can no longer be undone --
+UPDATE thunderdome.storyboard_history SET before = NULL, after = NULL;
+
+DROP FUNCTION thunderdome.sb_history_undo(storyboardid uuid, userid uuid);
+DROP PROCEDURE thunderdome.sb_snapshot_restore(IN storyboardid uuid, IN snapshot jsonb);
+DROP FUNCTION thunderdome.sb_snapshot_get(storyboardid uuid);
+
+-- gets the ids of the entities of the kind under the parent e.g. the stories in a column --
+CREATE OR REPLACE FUNCTION thunderdome.sb_entity_ids(storyboardid uuid, entitykind character varying, parentid uuid)
+ RETURNS uuid[]
+ LANGUAGE plpgsql
+AS $function$
+DECLARE ids UUID[];
+BEGIN
+    IF entityKind = 'goal' THEN
+        SELECT array_agg(id) INTO ids FROM thunderdome.storyboard_goal WHERE storyboard_id = storyboardId;
+    ELSIF entityKind = 'persona' THEN
+        SELECT array_agg(id) INTO ids FROM thunderdome.storyboard_persona WHERE storyboard_id = storyboardId;
+    ELSIF entityKind = 'column' THEN
+        SELECT array_agg(id) INTO ids FROM thunderdome.storyboard_column WHERE storyboard_id = storyboardId AND goal_id = parentId;
+    ELSIF entityKind = 'story' THEN
+        SELECT array_agg(id) INTO ids FROM thunderdome.storyboard_story WHERE storyboard_id = storyboardId AND column_id = parentId;
+    ELSIF entityKind = 'comment' THEN
+        SELECT array_agg(id) INTO ids FROM thunderdome.storyboard_story_comment WHERE storyboard_id = storyboardId AND story_id = parentId;
+    END IF;
+
+    RETURN COALESCE(ids, '{}');
+END;
+$function$;
+
+-- gets a snapshot of the entities and everything under them e.g. a column with its stories and their comments,
+-- null when none of the entities exist --
+CREATE OR REPLACE FUNCTION thunderdome.sb_entity_snapshot(storyboardid uuid, entitykind character varying, entityids uuid[])
+ RETURNS jsonb
+ LANGUAGE plpgsql
+AS $function$
+DECLARE goalIds UUID[] := '{}';
+DECLARE columnIds UUID[] := '{}';
+DECLARE storyIds UUID[] := '{}';
+DECLARE commentIds UUID[] := '{}';
+DECLARE personaIds UUID[] := '{}';
+BEGIN
+    IF entityKind = 'color_legend' THEN
+        RETURN (SELECT jsonb_build_object('color_legend', s.color_legend) FROM thunderdome.storyboard s WHERE s.id = storyboardId);
+    ELSIF entityKind = 'workflow' THEN
+        RETURN (
+            SELECT jsonb_build_object(
+                'workflow_states', s.workflow_states,
+                'wip_limit_enforced', s.wip_limit_enforced,
+                'story_states', (
+                    SELECT COALESCE(jsonb_agg(jsonb_build_object('id', ss.id, 'state', ss.state, 'closed', ss.closed) ORDER BY ss.id), '[]')
+                    FROM thunderdome.storyboard_story ss WHERE ss.storyboard_id = s.id
+                )
+            ) FROM thunderdome.storyboard s WHERE s.id = storyboardId
+        );
+    END IF;
+
+    IF entityKind = 'goal' THEN
+        SELECT COALESCE(array_agg(id), '{}') INTO goalIds FROM thunderdome.storyboard_goal
+            WHERE storyboard_id = storyboardId AND id = ANY(entityIds);
+        SELECT COALESCE(array_agg(id), '{}') INTO columnIds FROM thunderdome.storyboard_column
+            WHERE storyboard_id = storyboardId AND goal_id = ANY(goalIds);
+    ELSIF entityKind = 'column' THEN
+        SELECT COALESCE(array_agg(id), '{}') INTO columnIds FROM thunderdome.storyboard_column
+            WHERE storyboard_id = storyboardId AND id = ANY(entityIds);
+    ELSIF entityKind = 'persona' THEN
+        SELECT COALESCE(array_agg(id), '{}') INTO personaIds FROM thunderdome.storyboard_persona
+            WHERE storyboard_id = storyboardId AND id = ANY(entityIds);
+    END IF;
+
+    IF entityKind = 'story' THEN
+        SELECT COALESCE(array_agg(id), '{}') INTO storyIds FROM thunderdome.storyboard_story
+            WHERE storyboard_id = storyboardId AND id = ANY(entityIds);
+    ELSE
+        SELECT COALESCE(array_agg(id), '{}') INTO storyIds FROM thunderdome.storyboard_story
+            WHERE storyboard_id = storyboardId AND column_id = ANY(columnIds);
+    END IF;
+
+    IF entityKind = 'comment' THEN
+        SELECT COALESCE(array_agg(id), '{}') INTO commentIds FROM thunderdome.storyboard_story_comment
+            WHERE storyboard_id = storyboardId AND id = ANY(entityIds);
+    ELSE
+        SELECT COALESCE(array_agg(id), '{}') INTO commentIds FROM thunderdome.storyboard_story_comment
+            WHERE storyboard_id = storyboardId AND story_id = ANY(storyIds);
+    END IF;
+
+    IF cardinality(goalIds) + cardinality(columnIds) + cardinality(storyIds) + cardinality(commentIds) + cardinality(personaIds) = 0 THEN
+        RETURN NULL;
+    END IF;
+
+    RETURN jsonb_build_object(
+        'personas', (
+            SELECT COALESCE(jsonb_agg(to_jsonb(sp) ORDER BY sp.id), '[]')
+            FROM thunderdome.storyboard_persona sp WHERE sp.id = ANY(personaIds)
+        ),
+        'goals', (
+            SELECT COALESCE(jsonb_agg(to_jsonb(sg) ORDER BY sg.sort_order), '[]')
+            FROM thunderdome.storyboard_goal sg WHERE sg.id = ANY(goalIds)
+        ),
+        'goal_personas', (
+            SELECT COALESCE(jsonb_agg(to_jsonb(gp) ORDER BY gp.goal_id, gp.persona_id), '[]')
+            FROM thunderdome.storyboard_goal_persona gp WHERE gp.goal_id = ANY(goalIds) OR gp.persona_id = ANY(personaIds)
+        ),
+        'columns', (
+            SELECT COALESCE(jsonb_agg(to_jsonb(sc) ORDER BY sc.goal_id, sc.sort_order), '[]')
+            FROM thunderdome.storyboard_column sc WHERE sc.id = ANY(columnIds)
+        ),
+        'column_personas', (
+            SELECT COALESCE(jsonb_agg(to_jsonb(cp) ORDER BY cp.column_id, cp.persona_id), '[]')
+            FROM thunderdome.storyboard_column_persona cp WHERE cp.column_id = ANY(columnIds) OR cp.persona_id = ANY(personaIds)
+        ),
+        'stories', (
+            SELECT COALESCE(jsonb_agg(to_jsonb(ss) ORDER BY ss.column_id, ss.sort_order), '[]')
+            FROM thunderdome.storyboard_story ss WHERE ss.id = ANY(storyIds)
+        ),
+        'comments', (
+            SELECT COALESCE(jsonb_agg(to_jsonb(sm) ORDER BY sm.created_date, sm.id), '[]')
+            FROM thunderdome.storyboard_story_comment sm WHERE sm.id = ANY(commentIds)
+        )
+    );
+END;
+$function$;
+
+-- restores the entities to their snapshot, removing them when the snapshot is null e.g. undoing their addition --
+CREATE OR REPLACE PROCEDURE thunderdome.sb_entity_restore(IN storyboardid uuid, IN entitykind character varying, IN entityids uuid[], IN snapshot jsonb)
+ LANGUAGE plpgsql
+AS $procedure$
+BEGIN
+    IF entityKind = 'color_legend' THEN
+        UPDATE thunderdome.storyboard SET color_legend = snapshot->'color_legend', updated_date = NOW()
+        WHERE id = storyboardId;
+        RETURN;
+    ELSIF entityKind = 'workflow' THEN
+        UPDATE thunderdome.storyboard SET
+            workflow_states = snapshot->'workflow_states',
+            wip_limit_enforced = (snapshot->>'wip_limit_enforced')::BOOL,
+            updated_date = NOW()
+        WHERE id = storyboardId;
+        UPDATE thunderdome.storyboard_story ss SET state = st.state, closed = st.closed, updated_date = NOW()
+        FROM jsonb_to_recordset(snapshot->'story_states') AS st(id UUID, state VARCHAR(64), closed BOOL)
+        WHERE ss.id = st.id AND ss.storyboard_id = storyboardId;
+        RETURN;
+    END IF;
+
+    -- removing the entities cascades to everything under them
+    IF entityKind = 'goal' THEN
+        DELETE FROM thunderdome.storyboard_goal WHERE storyboard_id = storyboardId AND id = ANY(entityIds);
+    ELSIF entityKind = 'column' THEN
+        DELETE FROM thunderdome.storyboard_column WHERE storyboard_id = storyboardId AND id = ANY(entityIds);
+    ELSIF entityKind = 'story' THEN
+        DELETE FROM thunderdome.storyboard_story WHERE storyboard_id = storyboardId AND id = ANY(entityIds);
+    ELSIF entityKind = 'comment' THEN
+        DELETE FROM thunderdome.storyboard_story_comment WHERE storyboard_id = storyboardId AND id = ANY(entityIds);
+    ELSIF entityKind = 'persona' THEN
+        DELETE FROM thunderdome.storyboard_persona WHERE storyboard_id = storyboardId AND id = ANY(entityIds);
+    END IF;
+
+    IF snapshot IS NULL THEN
+        RETURN;
+    END IF;
+
+    INSERT INTO thunderdome.storyboard_persona
+    SELECT * FROM jsonb_populate_recordset(NULL::thunderdome.storyboard_persona, snapshot->'personas');
+    INSERT INTO thunderdome.storyboard_goal
+    SELECT * FROM jsonb_populate_recordset(NULL::thunderdome.storyboard_goal, snapshot->'goals');
+    INSERT INTO thunderdome.storyboard_column
+    SELECT * FROM jsonb_populate_recordset(NULL::thunderdome.storyboard_column, snapshot->'columns');
+    INSERT INTO thunderdome.storyboard_story
+    SELECT * FROM jsonb_populate_recordset(NULL::thunderdome.storyboard_story, snapshot->'stories');
+    -- comments by users that have since been deleted can't be restored
+    INSERT INTO thunderdome.storyboard_story_comment
+    SELECT sm.* FROM jsonb_populate_recordset(NULL::thunderdome.storyboard_story_comment, snapshot->'comments') sm
+    WHERE sm.user_id IS NULL OR EXISTS (SELECT 1 FROM thunderdome.users u WHERE u.id = sm.user_id);
+    -- persona links to goals, columns or personas that have since been deleted are dropped
+    INSERT INTO thunderdome.storyboard_goal_persona
+    SELECT gp.* FROM jsonb_populate_recordset(NULL::thunderdome.storyboard_goal_persona, snapshot->'goal_personas') gp
+    WHERE EXISTS (SELECT 1 FROM thunderdome.storyboard_goal sg WHERE sg.id = gp.goal_id)
+        AND EXISTS (SELECT 1 FROM thunderdome.storyboard_persona sp WHERE sp.id = gp.persona_id)
+    ON CONFLICT DO NOTHING;
+    INSERT INTO thunderdome.storyboard_column_persona
+    SELECT cp.* FROM jsonb_populate_recordset(NULL::thunderdome.storyboard_column_persona, snapshot->'column_personas') cp
+    WHERE EXISTS (SELECT 1 FROM thunderdome.storyboard_column sc WHERE sc.id = cp.column_id)
+        AND EXISTS (SELECT 1 FROM thunderdome.storyboard_persona sp WHERE sp.id = cp.persona_id)
+    ON CONFLICT DO NOTHING;
+END;
+$procedure$;
+
+-- reverts the entities changed by the most recent change that hasn't already been undone,
+-- returns null when the change can't be undone as what it belonged to has since been deleted e.g. a story of a deleted column --
+CREATE OR REPLACE FUNCTION thunderdome.sb_history_undo(storyboardid uuid, userid uuid)
+ RETURNS uuid
+ LANGUAGE plpgsql
+AS $function$
+DECLARE historyId UUID;
+DECLARE entityKind VARCHAR(32);
+DECLARE entityIds UUID[];
+DECLARE beforeSnapshot JSONB;
+DECLARE currentSnapshot JSONB;
+BEGIN
+    SELECT h.id, h.entity_kind, h.entity_ids, h.before INTO historyId, entityKind, entityIds, beforeSnapshot
+    FROM thunderdome.storyboard_history h
+    WHERE h.storyboard_id = storyboardId AND h.entity_kind IS NOT NULL AND h.event_type <> 'undo'
+        AND NOT EXISTS (SELECT 1 FROM thunderdome.storyboard_history u WHERE u.undo_of = h.id)
+    ORDER BY h.created_date DESC
+    LIMIT 1
+    FOR UPDATE;
+
+    IF historyId IS NULL THEN
+        RAISE EXCEPTION 'NOTHING_TO_UNDO';
+    END IF;
+
+    currentSnapshot := thunderdome.sb_entity_snapshot(storyboardId, entityKind, entityIds);
+    BEGIN
+        CALL thunderdome.sb_entity_restore(storyboardId, entityKind, entityIds, beforeSnapshot);
+    EXCEPTION WHEN foreign_key_violation THEN
+        -- recorded as undone so the next undo moves on to the change before it
+        INSERT INTO thunderdome.storyboard_history (storyboard_id, user_id, event_type, entity_kind, entity_ids, undo_of)
+        VALUES (storyboardId, userId, 'undo', entityKind, entityIds, historyId);
+        RETURN NULL;
+    END;
+
+    INSERT INTO thunderdome.storyboard_history (storyboard_id, user_id, event_type, entity_kind, entity_ids, before, after, undo_of)
+    VALUES (storyboardId, userId, 'undo', entityKind, entityIds, currentSnapshot, beforeSnapshot, historyId);
+
+    RETURN historyId;
+END;
+$function$;
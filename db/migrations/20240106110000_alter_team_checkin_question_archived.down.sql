@@ -0,0 +1,2 @@
+DELETE FROM thunderdome.team_checkin_question WHERE archived_date IS NOT NULL;
+ALTER TABLE thunderdome.team_checkin_question DROP COLUMN archived_date;
@@ -0,0 +1 @@
+ALTER TABLE thunderdome.team_checkin_question ADD COLUMN "archived_date" timestamptz;
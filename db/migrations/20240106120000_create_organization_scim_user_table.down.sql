@@ -0,0 +1 @@
+DROP TABLE thunderdome.organization_scim_user;
@@ -0,0 +1,7 @@
+CREATE TABLE thunderdome.organization_scim_user (
+    "organization_id" uuid NOT NULL REFERENCES thunderdome.organization ("id") ON DELETE CASCADE,
+    "user_id" uuid NOT NULL REFERENCES thunderdome.users ("id") ON DELETE CASCADE,
+    "created_date" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("organization_id", "user_id")
+);
+CREATE INDEX organization_scim_user_user_id_idx ON thunderdome.organization_scim_user (user_id);
@@ -0,0 +1,20 @@
+CREATE TABLE thunderdome.organization_domain (
+    "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+    "organization_id" uuid NOT NULL REFERENCES thunderdome.organization ("id") ON DELETE CASCADE,
+    "domain" VARCHAR(253) NOT NULL,
+    "verification_token" VARCHAR(64) NOT NULL,
+    "verified_date" timestamptz,
+    "created_date" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id"),
+    UNIQUE ("organization_id", "domain")
+);
+-- a domain can only be verified by one organization
+CREATE UNIQUE INDEX organization_domain_verified_idx ON thunderdome.organization_domain (domain) WHERE verified_date IS NOT NULL;
+
+-- policy domains have to be verified before they take effect again
+INSERT INTO thunderdome.organization_domain (organization_id, domain, verification_token)
+SELECT DISTINCT op.organization_id, LOWER(d.domain), md5(random()::text || clock_timestamp()::text)
+FROM thunderdome.organization_policy op, unnest(op.allowed_domains) AS d(domain)
+ON CONFLICT DO NOTHING;
+UPDATE thunderdome.organization_policy SET allowed_domains = '{}', auto_join = false, updated_date = NOW()
+WHERE cardinality(allowed_domains) > 0;
@@ -0,0 +1,12 @@
+CREATE OR REPLACE PROCEDURE thunderdome.user_mfa_remove(IN userid uuid)
+ LANGUAGE plpgsql
+AS $procedure$
+BEGIN
+    DELETE FROM thunderdome.user_mfa WHERE user_id = userId;
+    UPDATE thunderdome.users SET mfa_enabled = EXISTS(
+        SELECT 1 FROM thunderdome.user_passkey WHERE user_id = userId
+    ), updated_date = NOW() WHERE id = userId;
+
+    COMMIT;
+END;
+$procedure$;
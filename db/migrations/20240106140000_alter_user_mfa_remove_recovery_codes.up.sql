@@ -0,0 +1,16 @@
+-- recovery codes are only kept while the user has a second factor left to recover
+CREATE OR REPLACE PROCEDURE thunderdome.user_mfa_remove(IN userid uuid)
+ LANGUAGE plpgsql
+AS $procedure$
+BEGIN
+    DELETE FROM thunderdome.user_mfa WHERE user_id = userId;
+    UPDATE thunderdome.users SET mfa_enabled = EXISTS(
+        SELECT 1 FROM thunderdome.user_passkey WHERE user_id = userId
+    ), updated_date = NOW() WHERE id = userId;
+    DELETE FROM thunderdome.user_mfa_recovery_code WHERE user_id = userId AND NOT EXISTS(
+        SELECT 1 FROM thunderdome.user_passkey WHERE user_id = userId
+    );
+
+    COMMIT;
+END;
+$procedure$;
@@ -0,0 +1,69 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/team"
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/user"
+)
+
+// TestOrganizationDomainClaims makes sure a policy can only use verified domains and a domain
+// can only be verified by one organization
+func TestOrganizationDomainClaims(t *testing.T) {
+	d := testDB(t)
+	ctx := context.Background()
+	us := &user.Service{DB: d.DB, Logger: d.Logger}
+	orgs := &team.OrganizationService{DB: d.DB, Logger: d.Logger}
+
+	owner, err := us.CreateUserGuest(ctx, "Odin")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	asgard, err := orgs.OrganizationCreate(ctx, owner.Id, "Asgard")
+	if err != nil {
+		t.Fatalf("create organization: %v", err)
+	}
+	jotunheim, err := orgs.OrganizationCreate(ctx, owner.Id, "Jotunheim")
+	if err != nil {
+		t.Fatalf("create organization: %v", err)
+	}
+
+	domain, err := orgs.OrganizationDomainCreate(ctx, asgard.Id, "Bifrost.dev")
+	if err != nil {
+		t.Fatalf("create organization domain: %v", err)
+	}
+	claim, err := orgs.OrganizationDomainCreate(ctx, jotunheim.Id, "bifrost.dev")
+	if err != nil {
+		t.Fatalf("expected another organization to claim the unverified domain, got %v", err)
+	}
+
+	if _, err := orgs.OrganizationPolicyUpdate(ctx, asgard.Id, false, []string{"bifrost.dev"}, true, 0); err == nil {
+		t.Fatal("expected the policy to reject an unverified domain")
+	}
+
+	if _, err := orgs.OrganizationDomainVerify(ctx, asgard.Id, domain.Id); err != nil {
+		t.Fatalf("verify organization domain: %v", err)
+	}
+	if _, err := orgs.OrganizationDomainVerify(ctx, jotunheim.Id, claim.Id); err == nil || err.Error() != "DOMAIN_ALREADY_VERIFIED" {
+		t.Fatalf("expected DOMAIN_ALREADY_VERIFIED, got %v", err)
+	}
+	if _, err := orgs.OrganizationDomainCreate(ctx, jotunheim.Id, "bifrost.dev"); err == nil {
+		t.Fatal("expected a verified domain not to be claimable")
+	}
+
+	if _, err := orgs.OrganizationPolicyUpdate(ctx, asgard.Id, false, []string{"bifrost.dev"}, true, 0); err != nil {
+		t.Fatalf("update organization policy: %v", err)
+	}
+
+	if err := orgs.OrganizationDomainDelete(ctx, asgard.Id, domain.Id); err != nil {
+		t.Fatalf("delete organization domain: %v", err)
+	}
+	policy, err := orgs.OrganizationPolicyGet(ctx, asgard.Id)
+	if err != nil {
+		t.Fatalf("get organization policy: %v", err)
+	}
+	if len(policy.AllowedDomains) != 0 || policy.AutoJoin {
+		t.Fatalf("expected the deleted domain to be removed from the policy, got %v auto join %v", policy.AllowedDomains, policy.AutoJoin)
+	}
+}
@@ -0,0 +1,131 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/auth"
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/user"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestPasskeyCreateRecoveryCodes makes sure a users first passkey comes with recovery codes
+// and later passkeys don't replace them
+func TestPasskeyCreateRecoveryCodes(t *testing.T) {
+	d := testDB(t)
+	ctx := context.Background()
+	us := &user.Service{DB: d.DB, Logger: d.Logger}
+	as := &auth.Service{DB: d.DB, Logger: d.Logger, AESHashkey: testAESHashKey}
+
+	u, err := us.CreateUserGuest(ctx, "Thor")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	newPasskey := func(CredentialId string) *thunderdome.Passkey {
+		return &thunderdome.Passkey{
+			UserId:       u.Id,
+			Name:         "Mjolnir",
+			CredentialId: CredentialId + "-" + u.Id,
+			PublicKey:    []byte("public key"),
+			Algorithm:    -7,
+			Transports:   []string{},
+		}
+	}
+
+	_, RecoveryCodes, err := as.PasskeyCreate(ctx, newPasskey("mjolnir-1"))
+	if err != nil {
+		t.Fatalf("create passkey: %v", err)
+	}
+	if len(RecoveryCodes) == 0 {
+		t.Fatal("expected recovery codes with the first passkey")
+	}
+
+	_, RecoveryCodes, err = as.PasskeyCreate(ctx, newPasskey("mjolnir-2"))
+	if err != nil {
+		t.Fatalf("create passkey: %v", err)
+	}
+	if len(RecoveryCodes) != 0 {
+		t.Fatalf("expected no new recovery codes with the second passkey, got %d", len(RecoveryCodes))
+	}
+}
+
+// TestPasskeyDeleteRecoveryCodes makes sure the recovery codes are kept until the users last passkey is deleted
+func TestPasskeyDeleteRecoveryCodes(t *testing.T) {
+	d := testDB(t)
+	ctx := context.Background()
+	us := &user.Service{DB: d.DB, Logger: d.Logger}
+	as := &auth.Service{DB: d.DB, Logger: d.Logger, AESHashkey: testAESHashKey}
+
+	u, err := us.CreateUserGuest(ctx, "Thor")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	Passkeys := make([]*thunderdome.Passkey, 0)
+	for _, CredentialId := range []string{"stormbreaker-1", "stormbreaker-2"} {
+		Passkey, _, err := as.PasskeyCreate(ctx, &thunderdome.Passkey{
+			UserId:       u.Id,
+			Name:         "Stormbreaker",
+			CredentialId: CredentialId + "-" + u.Id,
+			PublicKey:    []byte("public key"),
+			Algorithm:    -7,
+			Transports:   []string{},
+		})
+		if err != nil {
+			t.Fatalf("create passkey: %v", err)
+		}
+		Passkeys = append(Passkeys, Passkey)
+	}
+
+	if err := as.PasskeyDelete(ctx, u.Id, Passkeys[0].Id); err != nil {
+		t.Fatalf("delete passkey: %v", err)
+	}
+	if count := recoveryCodeCount(t, d, u.Id); count == 0 {
+		t.Fatal("expected the recovery codes to be kept while a passkey remains")
+	}
+
+	if err := as.PasskeyDelete(ctx, u.Id, Passkeys[1].Id); err != nil {
+		t.Fatalf("delete passkey: %v", err)
+	}
+	if count := recoveryCodeCount(t, d, u.Id); count != 0 {
+		t.Fatalf("expected the recovery codes to be removed with the last passkey, got %d", count)
+	}
+}
+
+// TestPasskeyUsedSignCount makes sure a passkeys signature counter only moves forward,
+// so concurrent logins with a cloned authenticator or a replayed assertion are rejected
+func TestPasskeyUsedSignCount(t *testing.T) {
+	d := testDB(t)
+	ctx := context.Background()
+	us := &user.Service{DB: d.DB, Logger: d.Logger}
+	as := &auth.Service{DB: d.DB, Logger: d.Logger, AESHashkey: testAESHashKey}
+
+	u, err := us.CreateUserGuest(ctx, "Thor")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	Passkey, _, err := as.PasskeyCreate(ctx, &thunderdome.Passkey{
+		UserId:       u.Id,
+		Name:         "Gungnir",
+		CredentialId: "gungnir-" + u.Id,
+		PublicKey:    []byte("public key"),
+		Algorithm:    -7,
+		Transports:   []string{},
+	})
+	if err != nil {
+		t.Fatalf("create passkey: %v", err)
+	}
+
+	if err := as.PasskeyUsed(ctx, Passkey.Id, 5); err != nil {
+		t.Fatalf("passkey used: %v", err)
+	}
+	for _, SignCount := range []uint32{5, 3} {
+		if err := as.PasskeyUsed(ctx, Passkey.Id, SignCount); err == nil || err.Error() != "INVALID_SIGN_COUNT" {
+			t.Fatalf("expected INVALID_SIGN_COUNT for sign count %d, got %v", SignCount, err)
+		}
+	}
+	if err := as.PasskeyUsed(ctx, Passkey.Id, 6); err != nil {
+		t.Fatalf("passkey used: %v", err)
+	}
+}
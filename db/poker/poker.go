@@ -102,7 +102,7 @@ func (d *Service) CreateGame(ctx context.Context, FacilitatorID string, Name str
 }
 
 // TeamCreateGame creates a new story pointing session associated to a team
-func (d *Service) TeamCreateGame(ctx context.Context, TeamID string, FacilitatorID string, Name string, PointValuesAllowed []string, Stories []*thunderdome.Story, AutoFinishVoting bool, PointAverageRounding string, JoinCode string, FacilitatorCode string, HideVoterIdentity bool) (*thunderdome.Poker, error) {
+func (d *Service) TeamCreateGame(ctx context.Context, TeamID string, FacilitatorID string, Name string, PointValuesAllowed []string, Stories []*thunderdome.Story, AutoFinishVoting bool, PointAverageRounding string, JoinCode string, FacilitatorCode string, HideVoterIdentity bool, TemplateID string) (*thunderdome.Poker, error) {
 	var pointValuesJSON, _ = json.Marshal(PointValuesAllowed)
 	var encryptedJoinCode string
 	var encryptedLeaderCode string
@@ -139,7 +139,7 @@ func (d *Service) TeamCreateGame(ctx context.Context, TeamID string, Facilitator
 	b.Facilitators = append(b.Facilitators, FacilitatorID)
 
 	e := d.DB.QueryRowContext(ctx,
-		`SELECT pokerid FROM thunderdome.team_create_poker($1, $2, $3, $4, $5, $6, $7, $8, $9);`,
+		`SELECT pokerid FROM thunderdome.team_create_poker($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, '')::uuid);`,
 		TeamID,
 		FacilitatorID,
 		Name,
@@ -149,8 +149,12 @@ func (d *Service) TeamCreateGame(ctx context.Context, TeamID string, Facilitator
 		HideVoterIdentity,
 		encryptedJoinCode,
 		encryptedLeaderCode,
+		TemplateID,
 	).Scan(&b.Id)
 	if e != nil {
+		if db.RaisedException(e) == "TEMPLATE_NOT_FOUND" {
+			return nil, errors.New("TEMPLATE_NOT_FOUND")
+		}
 		d.Logger.Error("team_create_poker query error", zap.Error(e))
 		return nil, errors.New("error creating poker")
 	}
@@ -178,6 +182,30 @@ func (d *Service) TeamCreateGame(ctx context.Context, TeamID string, Facilitator
 	return b, nil
 }
 
+// CloneGame copies a games settings and un-estimated stories into a new game without its codes,
+// belonging to the team when given otherwise the games teams
+func (d *Service) CloneGame(ctx context.Context, PokerID string, FacilitatorID string, Name string, Template bool, TeamID string) (*thunderdome.Poker, error) {
+	var PokerCloneID string
+
+	e := d.DB.QueryRowContext(ctx,
+		`SELECT * FROM thunderdome.poker_clone($1, $2, $3, $4, NULLIF($5, '')::uuid);`,
+		PokerID,
+		FacilitatorID,
+		Name,
+		Template,
+		TeamID,
+	).Scan(&PokerCloneID)
+	if e != nil {
+		if db.RaisedException(e) == "TEMPLATE_REQUIRES_TEAM" {
+			return nil, errors.New("TEMPLATE_REQUIRES_TEAM")
+		}
+		d.Logger.Ctx(ctx).Error("poker_clone query error", zap.Error(e))
+		return nil, errors.New("error cloning poker")
+	}
+
+	return d.GetGame(PokerCloneID, FacilitatorID)
+}
+
 // UpdateGame updates the game by ID
 func (d *Service) UpdateGame(PokerID string, Name string, PointValuesAllowed []string, AutoFinishVoting bool, PointAverageRounding string, HideVoterIdentity bool, JoinCode string, FacilitatorCode string) error {
 	var pointValuesJSON, _ = json.Marshal(PointValuesAllowed)
@@ -257,7 +285,7 @@ func (d *Service) GetGame(PokerID string, UserID string) (*thunderdome.Poker, er
 	var FacilitatorCode string
 	e := d.DB.QueryRow(
 		`
-		SELECT b.id, b.name, b.voting_locked, b.active_story_id, b.point_values_allowed, b.auto_finish_voting, b.point_average_rounding, b.hide_voter_identity, COALESCE(b.join_code, ''), COALESCE(b.leader_code, ''), b.created_date, b.updated_date,
+		SELECT b.id, b.name, b.voting_locked, b.active_story_id, b.point_values_allowed, b.auto_finish_voting, b.point_average_rounding, b.hide_voter_identity, COALESCE(b.join_code, ''), COALESCE(b.leader_code, ''), b.template, b.created_date, b.updated_date,
 		CASE WHEN COUNT(bl) = 0 THEN '[]'::json ELSE array_to_json(array_agg(bl.user_id)) END AS leaders
 		FROM thunderdome.poker b
 		LEFT JOIN thunderdome.poker_facilitator bl ON b.id = bl.poker_id
@@ -275,6 +303,7 @@ func (d *Service) GetGame(PokerID string, UserID string) (*thunderdome.Poker, er
 		&b.HideVoterIdentity,
 		&JoinCode,
 		&FacilitatorCode,
+		&b.Template,
 		&b.CreatedDate,
 		&b.UpdatedDate,
 		&facilitators,
@@ -0,0 +1,35 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+
+	"go.uber.org/zap"
+)
+
+// Service represents a PostgreSQL implementation of thunderdome.RateLimitDataSvc.
+type Service struct {
+	DB     *sql.DB
+	Logger *otelzap.Logger
+}
+
+// RateLimitTake takes a request token from the keys bucket, shared by all application replicas
+func (d *Service) RateLimitTake(ctx context.Context, Key string, Capacity int, RefillRate float64) (*thunderdome.RateLimitBucket, error) {
+	b := &thunderdome.RateLimitBucket{Key: Key}
+
+	err := d.DB.QueryRowContext(ctx,
+		`SELECT allowed, remaining FROM thunderdome.rate_limit_take($1, $2, $3);`,
+		Key,
+		Capacity,
+		RefillRate,
+	).Scan(&b.Allowed, &b.Tokens)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("rate limit take query error", zap.Error(err))
+		return nil, err
+	}
+
+	return b, nil
+}
@@ -3,6 +3,7 @@ package retro
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 
 	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
 
@@ -23,10 +24,14 @@ func (d *Service) CreateRetroAction(RetroID string, UserID string, Content strin
 }
 
 // UpdateRetroAction updates an actions status
-func (d *Service) UpdateRetroAction(RetroID string, ActionID string, Content string, Completed bool) (Actions []*thunderdome.RetroAction, DeleteError error) {
-	if _, err := d.DB.Exec(
-		`UPDATE thunderdome.retro_action SET completed = $2, content = $3, updated_date = NOW() WHERE id = $1;`, ActionID, Completed, Content); err != nil {
+func (d *Service) UpdateRetroAction(RetroID string, ActionID string, Content string, Completed bool, Version int) (Actions []*thunderdome.RetroAction, DeleteError error) {
+	result, err := d.DB.Exec(
+		`UPDATE thunderdome.retro_action SET completed = $2, content = $3, version = version + 1, updated_date = NOW()
+		WHERE id = $1 AND version = $4;`, ActionID, Completed, Content, Version)
+	if err != nil {
 		d.Logger.Error("update retro_action error", zap.Error(err))
+	} else if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, d.getRetroActionConflict(ActionID)
 	}
 
 	actions := d.GetRetroActions(RetroID)
@@ -34,6 +39,29 @@ func (d *Service) UpdateRetroAction(RetroID string, ActionID string, Content str
 	return actions, nil
 }
 
+// getRetroActionConflict gets the current retro action for a stale version update
+func (d *Service) getRetroActionConflict(ActionID string) error {
+	var ra = &thunderdome.RetroAction{}
+	err := d.DB.QueryRow(
+		`SELECT id, content, completed, version FROM thunderdome.retro_action WHERE id = $1;`,
+		ActionID,
+	).Scan(&ra.ID, &ra.Content, &ra.Completed, &ra.Version)
+	if err != nil {
+		d.Logger.Error("get retro_action conflict error", zap.Error(err))
+		return errors.New("RETRO_ACTION_NOT_FOUND")
+	}
+
+	return &thunderdome.VersionConflict{
+		Type: "action",
+		ID:   ra.ID,
+		Value: map[string]interface{}{
+			"content":   ra.Content,
+			"completed": ra.Completed,
+		},
+		Version: ra.Version,
+	}
+}
+
 // DeleteRetroAction removes a goal from the current board by ID
 func (d *Service) DeleteRetroAction(RetroID string, userID string, ActionID string) ([]*thunderdome.RetroAction, error) {
 	if _, err := d.DB.Exec(
@@ -51,7 +79,7 @@ func (d *Service) GetRetroActions(RetroID string) []*thunderdome.RetroAction {
 	var actions = make([]*thunderdome.RetroAction, 0)
 
 	actionRows, actionsErr := d.DB.Query(
-		`SELECT id, content, completed FROM thunderdome.retro_action WHERE retro_id = $1 ORDER BY created_date ASC;`,
+		`SELECT id, content, completed, version FROM thunderdome.retro_action WHERE retro_id = $1 ORDER BY created_date ASC;`,
 		RetroID,
 	)
 	if actionsErr == nil {
@@ -62,7 +90,7 @@ func (d *Service) GetRetroActions(RetroID string) []*thunderdome.RetroAction {
 				Content:   "",
 				Completed: false,
 			}
-			if err := actionRows.Scan(&ri.ID, &ri.Content, &ri.Completed); err != nil {
+			if err := actionRows.Scan(&ri.ID, &ri.Content, &ri.Completed, &ri.Version); err != nil {
 				d.Logger.Error("get retro actions error", zap.Error(err))
 			} else {
 				actions = append(actions, ri)
@@ -93,7 +121,7 @@ func (d *Service) GetTeamRetroActions(TeamID string, Limit int, Offset int, Comp
 	}
 
 	actionRows, err := d.DB.Query(
-		`SELECT ra.id, ra.content, ra.completed, ra.retro_id,
+		`SELECT ra.id, ra.content, ra.completed, ra.retro_id, ra.version,
 				COALESCE(
 					json_agg(rac ORDER BY rac.created_date) FILTER (WHERE rac.id IS NOT NULL), '[]'
 				) AS comments
@@ -113,7 +141,7 @@ func (d *Service) GetTeamRetroActions(TeamID string, Limit int, Offset int, Comp
 		for actionRows.Next() {
 			var comments string
 			var ri = &thunderdome.RetroAction{}
-			if err := actionRows.Scan(&ri.ID, &ri.Content, &ri.Completed, &ri.RetroID, &comments); err != nil {
+			if err := actionRows.Scan(&ri.ID, &ri.Content, &ri.Completed, &ri.RetroID, &ri.Version, &comments); err != nil {
 				d.Logger.Error("get retro actions error", zap.Error(err))
 			} else {
 				Comments := make([]*thunderdome.RetroActionComment, 0)
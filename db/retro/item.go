@@ -91,14 +91,14 @@ func (d *Service) GetRetroGroups(RetroID string) []*thunderdome.RetroGroup {
 	var groups = make([]*thunderdome.RetroGroup, 0)
 
 	itemRows, itemsErr := d.DB.Query(
-		`SELECT id, COALESCE(name, '') FROM thunderdome.retro_group WHERE retro_id = $1 ORDER BY created_date ASC;`,
+		`SELECT id, COALESCE(name, ''), version FROM thunderdome.retro_group WHERE retro_id = $1 ORDER BY created_date ASC;`,
 		RetroID,
 	)
 	if itemsErr == nil {
 		defer itemRows.Close()
 		for itemRows.Next() {
 			var ri = &thunderdome.RetroGroup{}
-			if err := itemRows.Scan(&ri.ID, &ri.Name); err != nil {
+			if err := itemRows.Scan(&ri.ID, &ri.Name, &ri.Version); err != nil {
 				d.Logger.Error("get retro groups query scan error", zap.Error(err))
 			} else {
 				groups = append(groups, ri)
@@ -112,12 +112,31 @@ func (d *Service) GetRetroGroups(RetroID string) []*thunderdome.RetroGroup {
 }
 
 // GroupNameChange changes retro item group name
-func (d *Service) GroupNameChange(RetroID string, GroupId string, Name string) ([]*thunderdome.RetroGroup, error) {
-	if _, err := d.DB.Exec(
-		`UPDATE thunderdome.retro_group SET name = $3 WHERE retro_id = $1 AND id = $2;`,
-		RetroID, GroupId, Name,
-	); err != nil {
+func (d *Service) GroupNameChange(RetroID string, GroupId string, Name string, Version int) ([]*thunderdome.RetroGroup, error) {
+	result, err := d.DB.Exec(
+		`UPDATE thunderdome.retro_group SET name = $3, version = version + 1 WHERE retro_id = $1 AND id = $2 AND version = $4;`,
+		RetroID, GroupId, Name, Version,
+	)
+	if err != nil {
 		d.Logger.Error("update retro group error", zap.Error(err))
+	} else if rows, _ := result.RowsAffected(); rows == 0 {
+		var rg = &thunderdome.RetroGroup{}
+		err := d.DB.QueryRow(
+			`SELECT id, COALESCE(name, ''), version FROM thunderdome.retro_group WHERE retro_id = $1 AND id = $2;`,
+			RetroID, GroupId,
+		).Scan(&rg.ID, &rg.Name, &rg.Version)
+		if err != nil {
+			d.Logger.Error("get retro group conflict error", zap.Error(err))
+			return nil, errors.New("RETRO_GROUP_NOT_FOUND")
+		}
+
+		return nil, &thunderdome.VersionConflict{
+			Type:    "group",
+			ID:      rg.ID,
+			Field:   "name",
+			Value:   rg.Name,
+			Version: rg.Version,
+		}
 	}
 
 	groups := d.GetRetroGroups(RetroID)
@@ -124,6 +124,30 @@ func (d *Service) TeamRetroCreate(ctx context.Context, TeamID string, OwnerID st
 	return b, nil
 }
 
+// CloneRetro copies a retros settings into a new retro without its codes,
+// belonging to the team when given otherwise the retros teams
+func (d *Service) CloneRetro(ctx context.Context, RetroID string, OwnerID string, RetroName string, Template bool, TeamID string) (*thunderdome.Retro, error) {
+	var RetroCloneID string
+
+	e := d.DB.QueryRowContext(ctx,
+		`SELECT * FROM thunderdome.retro_clone($1, $2, $3, $4, NULLIF($5, '')::uuid);`,
+		RetroID,
+		OwnerID,
+		RetroName,
+		Template,
+		TeamID,
+	).Scan(&RetroCloneID)
+	if e != nil {
+		if db.RaisedException(e) == "TEMPLATE_REQUIRES_TEAM" {
+			return nil, errors.New("TEMPLATE_REQUIRES_TEAM")
+		}
+		d.Logger.Ctx(ctx).Error("retro_clone query error", zap.Error(e))
+		return nil, errors.New("error cloning retro")
+	}
+
+	return d.RetroGet(RetroCloneID, OwnerID)
+}
+
 // EditRetro updates the retro by ID
 func (d *Service) EditRetro(RetroID string, RetroName string, JoinCode string, FacilitatorCode string, maxVotes int, brainstormVisibility string) error {
 	var encryptedJoinCode string
@@ -177,7 +201,7 @@ func (d *Service) RetroGet(RetroID string, UserID string) (*thunderdome.Retro, e
 	e := d.DB.QueryRow(
 		`SELECT
 			r.id, r.name, r.owner_id, r.format, r.phase, COALESCE(r.join_code, ''), COALESCE(r.facilitator_code, ''),
-			r.max_votes, r.brainstorm_visibility, r.created_date, r.updated_date,
+			r.max_votes, r.brainstorm_visibility, r.template, r.created_date, r.updated_date,
 			CASE WHEN COUNT(rf) = 0 THEN '[]'::json ELSE array_to_json(array_agg(rf.user_id)) END AS facilitators
 		FROM thunderdome.retro r 
 		LEFT JOIN thunderdome.retro_facilitator rf ON r.id = rf.retro_id
@@ -194,6 +218,7 @@ func (d *Service) RetroGet(RetroID string, UserID string) (*thunderdome.Retro, e
 		&FacilitatorCode,
 		&b.MaxVotes,
 		&b.BrainstormVisibility,
+		&b.Template,
 		&b.CreatedDate,
 		&b.UpdatedDate,
 		&Facilitators,
@@ -296,7 +321,7 @@ func (d *Service) RetroGetUsers(RetroID string) []*thunderdome.RetroUser {
 	var users = make([]*thunderdome.RetroUser, 0)
 	rows, err := d.DB.Query(
 		`SELECT
-			u.id, u.name, su.active, u.avatar, COALESCE(u.email, '')
+			u.id, u.name, su.active, u.avatar, COALESCE(u.email, ''), u.type
 		FROM thunderdome.retro_user su
 		LEFT JOIN thunderdome.users u ON su.user_id = u.id
 		WHERE su.retro_id = $1
@@ -307,7 +332,7 @@ func (d *Service) RetroGetUsers(RetroID string) []*thunderdome.RetroUser {
 		defer rows.Close()
 		for rows.Next() {
 			var w thunderdome.RetroUser
-			if err := rows.Scan(&w.ID, &w.Name, &w.Active, &w.Avatar, &w.GravatarHash); err != nil {
+			if err := rows.Scan(&w.ID, &w.Name, &w.Active, &w.Avatar, &w.GravatarHash, &w.Type); err != nil {
 				d.Logger.Error("get retro users error", zap.Error(err))
 			} else {
 				if w.GravatarHash != "" {
@@ -0,0 +1,78 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/team"
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/user"
+)
+
+// TestSCIMUserLifecycle makes sure only the organization that provisioned a user, and that they don't
+// share with another organization, manages the account and deactivating only removes them from the organization
+func TestSCIMUserLifecycle(t *testing.T) {
+	d := testDB(t)
+	ctx := context.Background()
+	us := &user.Service{DB: d.DB, Logger: d.Logger}
+	orgs := &team.OrganizationService{DB: d.DB, Logger: d.Logger}
+	ss := &team.SCIMService{DB: d.DB, Logger: d.Logger}
+
+	owner, err := us.CreateUserGuest(ctx, "Odin")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	asgard, err := orgs.OrganizationCreate(ctx, owner.Id, "Asgard")
+	if err != nil {
+		t.Fatalf("create organization: %v", err)
+	}
+	vanaheim, err := orgs.OrganizationCreate(ctx, owner.Id, "Vanaheim")
+	if err != nil {
+		t.Fatalf("create organization: %v", err)
+	}
+
+	freya, _, err := us.CreateUserRegistered(ctx, "Freya", "freya@vanaheim.dev", "", "")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if err := ss.SCIMUserProvision(ctx, asgard.Id, freya.Id); err != nil {
+		t.Fatalf("provision user: %v", err)
+	}
+	if err := ss.SCIMUserActivate(ctx, asgard.Id, freya.Id); err != nil {
+		t.Fatalf("activate user: %v", err)
+	}
+
+	if managed, err := ss.SCIMUserManaged(ctx, asgard.Id, freya.Id); err != nil || !managed {
+		t.Fatalf("expected the provisioning organization to manage the user, got %v %v", managed, err)
+	}
+	if managed, err := ss.SCIMUserManaged(ctx, vanaheim.Id, freya.Id); err != nil || managed {
+		t.Fatalf("expected another organization not to manage the user, got %v %v", managed, err)
+	}
+
+	if _, err := orgs.OrganizationAddUser(ctx, vanaheim.Id, freya.Id, "MEMBER"); err != nil {
+		t.Fatalf("add user to organization: %v", err)
+	}
+	if managed, err := ss.SCIMUserManaged(ctx, asgard.Id, freya.Id); err != nil || managed {
+		t.Fatalf("expected a user of another organization not to be managed, got %v %v", managed, err)
+	}
+
+	if err := ss.SCIMUserDeactivate(ctx, asgard.Id, freya.Id); err != nil {
+		t.Fatalf("deactivate user: %v", err)
+	}
+	u, err := ss.SCIMUserGet(ctx, asgard.Id, freya.Id)
+	if err != nil || !u.Disabled {
+		t.Fatalf("expected the deactivated user to be listed as inactive, got %v", err)
+	}
+	if u, err := ss.SCIMUserGet(ctx, vanaheim.Id, freya.Id); err != nil || u.Disabled {
+		t.Fatalf("expected the user to stay active in the other organization, got %v", err)
+	}
+	if u, err := us.GetUser(ctx, freya.Id); err != nil || u.Disabled {
+		t.Fatalf("expected the users account to stay enabled, got %v", err)
+	}
+
+	if err := ss.SCIMUserDelete(ctx, asgard.Id, freya.Id); err != nil {
+		t.Fatalf("delete user: %v", err)
+	}
+	if _, err := ss.SCIMUserGet(ctx, asgard.Id, freya.Id); err == nil {
+		t.Fatal("expected the deleted user to no longer be listed")
+	}
+}
@@ -21,13 +21,33 @@ func (d *Service) CreateStoryboardColumn(StoryboardID string, GoalID string, use
 }
 
 // ReviseStoryboardColumn revises a storyboard column
-func (d *Service) ReviseStoryboardColumn(StoryboardID string, UserID string, ColumnID string, ColumnName string) ([]*thunderdome.StoryboardGoal, error) {
-	if _, err := d.DB.Exec(
-		`UPDATE thunderdome.storyboard_column SET name = $2, updated_date = NOW() WHERE id = $1;`,
+func (d *Service) ReviseStoryboardColumn(StoryboardID string, UserID string, ColumnID string, ColumnName string, Version int) ([]*thunderdome.StoryboardGoal, error) {
+	result, err := d.DB.Exec(
+		`UPDATE thunderdome.storyboard_column SET name = $2, version = version + 1, updated_date = NOW() WHERE id = $1 AND version = $3;`,
 		ColumnID,
 		ColumnName,
-	); err != nil {
+		Version,
+	)
+	if err != nil {
 		d.Logger.Error("CALL thunderdome.revise_storyboard_column error", zap.Error(err))
+	} else if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, d.getVersionConflict("column", ColumnID, "name")
+	}
+
+	goals := d.GetStoryboardGoals(StoryboardID)
+
+	return goals, nil
+}
+
+// ReviseStoryboardColumnWipLimit revises a storyboard columns WIP limit, 0 being no limit
+func (d *Service) ReviseStoryboardColumnWipLimit(StoryboardID string, UserID string, ColumnID string, WipLimit int) ([]*thunderdome.StoryboardGoal, error) {
+	if _, err := d.DB.Exec(
+		`UPDATE thunderdome.storyboard_column SET wip_limit = $2, updated_date = NOW() WHERE id = $1 AND storyboard_id = $3;`,
+		ColumnID,
+		WipLimit,
+		StoryboardID,
+	); err != nil {
+		d.Logger.Error("revise storyboard column wip_limit error", zap.Error(err))
 	}
 
 	goals := d.GetStoryboardGoals(StoryboardID)
@@ -0,0 +1,40 @@
+package storyboard
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// conflictTables maps the storyboard entity types that are versioned to their table
+var conflictTables = map[string]string{
+	"goal":   "storyboard_goal",
+	"column": "storyboard_column",
+	"story":  "storyboard_story",
+}
+
+// getVersionConflict gets the current field value and version of a storyboard entity
+// for an update made against a stale version
+func (d *Service) getVersionConflict(Type string, ID string, Field string) error {
+	var conflict = &thunderdome.VersionConflict{
+		Type:  Type,
+		ID:    ID,
+		Field: Field,
+	}
+	var value string
+
+	err := d.DB.QueryRow(
+		fmt.Sprintf(`SELECT COALESCE(%s, ''), version FROM thunderdome.%s WHERE id = $1;`, Field, conflictTables[Type]),
+		ID,
+	).Scan(&value, &conflict.Version)
+	if err != nil {
+		d.Logger.Error("get storyboard version conflict error", zap.Error(err), zap.String("type", Type))
+		return errors.New("NOT_FOUND")
+	}
+	conflict.Value = value
+
+	return conflict
+}
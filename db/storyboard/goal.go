@@ -26,13 +26,17 @@ func (d *Service) CreateStoryboardGoal(StoryboardID string, userID string, GoalN
 }
 
 // ReviseGoalName updates the plan name by ID
-func (d *Service) ReviseGoalName(StoryboardID string, userID string, GoalID string, GoalName string) ([]*thunderdome.StoryboardGoal, error) {
-	if _, err := d.DB.Exec(
-		`UPDATE thunderdome.storyboard_goal SET name = $2, updated_date = NOW() WHERE id = $1;`,
+func (d *Service) ReviseGoalName(StoryboardID string, userID string, GoalID string, GoalName string, Version int) ([]*thunderdome.StoryboardGoal, error) {
+	result, err := d.DB.Exec(
+		`UPDATE thunderdome.storyboard_goal SET name = $2, version = version + 1, updated_date = NOW() WHERE id = $1 AND version = $3;`,
 		GoalID,
 		GoalName,
-	); err != nil {
+		Version,
+	)
+	if err != nil {
 		d.Logger.Error("CALL thunderdome.update_storyboard_goal error", zap.Error(err))
+	} else if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, d.getVersionConflict("goal", GoalID, "name")
 	}
 
 	goals := d.GetStoryboardGoals(StoryboardID)
@@ -71,7 +75,7 @@ func (d *Service) GetStoryboardGoals(StoryboardID string) []*thunderdome.Storybo
 				SortOrder: 0,
 				Columns:   make([]*thunderdome.StoryboardColumn, 0),
 			}
-			if err := goalRows.Scan(&sg.Id, &sg.SortOrder, &sg.Name, &columns, &personas); err != nil {
+			if err := goalRows.Scan(&sg.Id, &sg.SortOrder, &sg.Name, &sg.Version, &columns, &personas); err != nil {
 				d.Logger.Error("get_storyboard_goals query scan error", zap.Error(err))
 			} else {
 				goalColumns := make([]*thunderdome.StoryboardColumn, 0)
@@ -91,5 +95,10 @@ func (d *Service) GetStoryboardGoals(StoryboardID string) []*thunderdome.Storybo
 		}
 	}
 
+	states, _, err := d.getStoryboardWorkflow(StoryboardID)
+	if err == nil {
+		setColumnWipCounts(goals, states)
+	}
+
 	return goals
 }
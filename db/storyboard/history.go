@@ -0,0 +1,174 @@
+package storyboard
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+	"github.com/lib/pq"
+
+	"go.uber.org/zap"
+)
+
+// historyRetention the most history entries kept per storyboard, older entries are pruned as new ones are added
+const historyRetention = 200
+
+// GetStoryboardEntityIDs gets the ids of the entities of the kind under the parent e.g. the stories in a column,
+// the parent of goals and personas being the storyboard
+func (d *Service) GetStoryboardEntityIDs(StoryboardID string, EntityKind string, ParentID string) ([]string, error) {
+	var ids = make([]string, 0)
+
+	err := d.DB.QueryRow(
+		`SELECT thunderdome.sb_entity_ids($1, $2, NULLIF($3, '')::uuid);`,
+		StoryboardID,
+		EntityKind,
+		ParentID,
+	).Scan(pq.Array(&ids))
+	if err != nil {
+		d.Logger.Error("get storyboard entity ids error", zap.Error(err))
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// GetStoryboardEntitySnapshot gets a snapshot of the entities and everything under them
+// e.g. a column with its stories, empty when none of the entities exist
+func (d *Service) GetStoryboardEntitySnapshot(StoryboardID string, EntityKind string, EntityIDs []string) (string, error) {
+	var snapshot sql.NullString
+
+	err := d.DB.QueryRow(
+		`SELECT thunderdome.sb_entity_snapshot($1, $2, $3);`,
+		StoryboardID,
+		EntityKind,
+		pq.Array(EntityIDs),
+	).Scan(&snapshot)
+	if err != nil {
+		d.Logger.Error("get storyboard entity snapshot error", zap.Error(err))
+		return "", err
+	}
+
+	return snapshot.String, nil
+}
+
+// StoryboardHistoryAdd appends a change to the storyboards history log pruning entries beyond the retention,
+// the changed entities and their before and after snapshots are optional for changes that can't be undone
+func (d *Service) StoryboardHistoryAdd(StoryboardID string, UserID string, EventType string, EntityKind string, EntityIDs []string, Before string, After string) error {
+	if _, err := d.DB.Exec(
+		`INSERT INTO thunderdome.storyboard_history (storyboard_id, user_id, event_type, entity_kind, entity_ids, before, after)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, NULLIF($6, '')::jsonb, NULLIF($7, '')::jsonb);`,
+		StoryboardID,
+		UserID,
+		EventType,
+		EntityKind,
+		pq.Array(EntityIDs),
+		Before,
+		After,
+	); err != nil {
+		d.Logger.Error("insert storyboard history error", zap.Error(err))
+		return err
+	}
+
+	if _, err := d.DB.Exec(
+		`DELETE FROM thunderdome.storyboard_history WHERE storyboard_id = $1 AND id NOT IN (
+			SELECT id FROM thunderdome.storyboard_history WHERE storyboard_id = $1
+			ORDER BY created_date DESC LIMIT $2
+		);`,
+		StoryboardID,
+		historyRetention,
+	); err != nil {
+		d.Logger.Error("prune storyboard history error", zap.Error(err))
+	}
+
+	return nil
+}
+
+// GetStoryboardHistory gets a page of the storyboards history log, most recent first
+func (d *Service) GetStoryboardHistory(StoryboardID string, Limit int, Offset int) ([]*thunderdome.StoryboardHistory, int, error) {
+	var history = make([]*thunderdome.StoryboardHistory, 0)
+	var Count int
+
+	e := d.DB.QueryRow(
+		`SELECT COUNT(*) FROM thunderdome.storyboard_history WHERE storyboard_id = $1;`,
+		StoryboardID,
+	).Scan(
+		&Count,
+	)
+	if e != nil {
+		d.Logger.Error("get storyboard history count error", zap.Error(e))
+		return nil, Count, e
+	}
+
+	rows, err := d.DB.Query(
+		`SELECT h.id, COALESCE(h.user_id::TEXT, ''), COALESCE(u.name, ''), h.event_type,
+			COALESCE(h.entity_kind, ''), h.entity_ids, COALESCE(h.before, 'null'::jsonb), COALESCE(h.after, 'null'::jsonb),
+			COALESCE(h.undo_of::TEXT, ''), h.created_date
+		FROM thunderdome.storyboard_history h
+		LEFT JOIN thunderdome.users u ON u.id = h.user_id
+		WHERE h.storyboard_id = $1
+		ORDER BY h.created_date DESC
+		LIMIT $2 OFFSET $3;`,
+		StoryboardID,
+		Limit,
+		Offset,
+	)
+	if err != nil {
+		d.Logger.Error("get storyboard history error", zap.Error(err))
+		return nil, Count, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var before string
+		var after string
+		var h = &thunderdome.StoryboardHistory{}
+		if err := rows.Scan(
+			&h.Id,
+			&h.UserID,
+			&h.UserName,
+			&h.EventType,
+			&h.EntityKind,
+			pq.Array(&h.EntityIDs),
+			&before,
+			&after,
+			&h.UndoOf,
+			&h.CreatedDate,
+		); err != nil {
+			d.Logger.Error("get storyboard history scan error", zap.Error(err))
+		} else {
+			h.Before = json.RawMessage(before)
+			h.After = json.RawMessage(after)
+			history = append(history, h)
+		}
+	}
+
+	return history, Count, nil
+}
+
+// StoryboardHistoryUndo reverts the entities changed by the most recent storyboard change that hasn't already
+// been undone to their snapshot from before the change, a change to entities whose goal or column has since
+// been deleted is skipped with UNDO_CONFLICT
+func (d *Service) StoryboardHistoryUndo(StoryboardID string, UserID string) (*thunderdome.Storyboard, error) {
+	var historyID sql.NullString
+
+	err := d.DB.QueryRow(
+		`SELECT thunderdome.sb_history_undo($1, $2);`,
+		StoryboardID,
+		UserID,
+	).Scan(&historyID)
+	if err != nil {
+		d.Logger.Error("storyboard history undo error", zap.Error(err))
+		return nil, errors.New("NOTHING_TO_UNDO")
+	}
+	if !historyID.Valid {
+		return nil, errors.New("UNDO_CONFLICT")
+	}
+
+	storyboard, err := d.GetStoryboard(StoryboardID, "")
+	if err != nil {
+		return nil, errors.New("unable to get storyboard")
+	}
+
+	return storyboard, nil
+}
@@ -1,6 +1,9 @@
 package storyboard
 
 import (
+	"database/sql"
+	"errors"
+
 	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
 	"go.uber.org/zap"
 )
@@ -8,8 +11,9 @@ import (
 // CreateStoryboardStory adds a new story to a Storyboard
 func (d *Service) CreateStoryboardStory(StoryboardID string, GoalID string, ColumnID string, userID string) ([]*thunderdome.StoryboardGoal, error) {
 	if _, err := d.DB.Exec(
-		`INSERT INTO thunderdome.storyboard_story (storyboard_id, goal_id, column_id, sort_order) 
-		VALUES ($1, $2, $3, ((SELECT coalesce(MAX(sort_order), 0) FROM thunderdome.storyboard_story WHERE column_id = $3) + 1));`,
+		`INSERT INTO thunderdome.storyboard_story (storyboard_id, goal_id, column_id, sort_order, state)
+		VALUES ($1, $2, $3, ((SELECT coalesce(MAX(sort_order), 0) FROM thunderdome.storyboard_story WHERE column_id = $3) + 1),
+			(SELECT COALESCE(workflow_states->0->>'name', 'todo') FROM thunderdome.storyboard WHERE id = $1));`,
 		StoryboardID, GoalID, ColumnID,
 	); err != nil {
 		d.Logger.Error("CALL thunderdome.create_storyboard_story error", zap.Error(err))
@@ -21,13 +25,17 @@ func (d *Service) CreateStoryboardStory(StoryboardID string, GoalID string, Colu
 }
 
 // ReviseStoryName updates the story name by ID
-func (d *Service) ReviseStoryName(StoryboardID string, userID string, StoryID string, StoryName string) ([]*thunderdome.StoryboardGoal, error) {
-	if _, err := d.DB.Exec(
-		`UPDATE thunderdome.storyboard_story SET name = $2, updated_date = NOW() WHERE id = $1;`,
+func (d *Service) ReviseStoryName(StoryboardID string, userID string, StoryID string, StoryName string, Version int) ([]*thunderdome.StoryboardGoal, error) {
+	result, err := d.DB.Exec(
+		`UPDATE thunderdome.storyboard_story SET name = $2, version = version + 1, updated_date = NOW() WHERE id = $1 AND version = $3;`,
 		StoryID,
 		StoryName,
-	); err != nil {
+		Version,
+	)
+	if err != nil {
 		d.Logger.Error("CALL thunderdome.update_story_name error", zap.Error(err))
+	} else if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, d.getVersionConflict("story", StoryID, "name")
 	}
 
 	goals := d.GetStoryboardGoals(StoryboardID)
@@ -36,13 +44,17 @@ func (d *Service) ReviseStoryName(StoryboardID string, userID string, StoryID st
 }
 
 // ReviseStoryContent updates the story content by ID
-func (d *Service) ReviseStoryContent(StoryboardID string, userID string, StoryID string, StoryContent string) ([]*thunderdome.StoryboardGoal, error) {
-	if _, err := d.DB.Exec(
-		`UPDATE thunderdome.storyboard_story SET content = $2, updated_date = NOW() WHERE id = $1;`,
+func (d *Service) ReviseStoryContent(StoryboardID string, userID string, StoryID string, StoryContent string, Version int) ([]*thunderdome.StoryboardGoal, error) {
+	result, err := d.DB.Exec(
+		`UPDATE thunderdome.storyboard_story SET content = $2, version = version + 1, updated_date = NOW() WHERE id = $1 AND version = $3;`,
 		StoryID,
 		StoryContent,
-	); err != nil {
+		Version,
+	)
+	if err != nil {
 		d.Logger.Error("CALL thunderdome.update_story_content error", zap.Error(err))
+	} else if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, d.getVersionConflict("story", StoryID, "content")
 	}
 
 	goals := d.GetStoryboardGoals(StoryboardID)
@@ -83,7 +95,12 @@ func (d *Service) ReviseStoryPoints(StoryboardID string, userID string, StoryID
 // ReviseStoryClosed updates the story closed status by ID
 func (d *Service) ReviseStoryClosed(StoryboardID string, userID string, StoryID string, Closed bool) ([]*thunderdome.StoryboardGoal, error) {
 	if _, err := d.DB.Exec(
-		`UPDATE thunderdome.storyboard_story SET closed = $2, updated_date = NOW() WHERE id = $1;`,
+		`UPDATE thunderdome.storyboard_story ss SET closed = $2, updated_date = NOW(),
+			state = CASE WHEN ss.closed = $2 THEN ss.state ELSE COALESCE((
+				SELECT st->>'name' FROM thunderdome.storyboard s, jsonb_array_elements(s.workflow_states) WITH ORDINALITY AS w(st, idx)
+				WHERE s.id = ss.storyboard_id AND COALESCE((st->>'done')::BOOL, false) = $2 ORDER BY w.idx LIMIT 1
+			), ss.state) END
+		WHERE ss.id = $1;`,
 		StoryID,
 		Closed,
 	); err != nil {
@@ -95,6 +112,71 @@ func (d *Service) ReviseStoryClosed(StoryboardID string, userID string, StoryID
 	return goals, nil
 }
 
+// ReviseStoryState updates the story workflow state by ID, when the storyboard enforces WIP limits
+// changes that would exceed the stories column WIP limit are rejected
+func (d *Service) ReviseStoryState(StoryboardID string, userID string, StoryID string, State string) ([]*thunderdome.StoryboardGoal, error) {
+	states, WipLimitEnforced, err := d.getStoryboardWorkflow(StoryboardID)
+	if err != nil {
+		return nil, err
+	}
+
+	var newState *thunderdome.WorkflowState
+	for _, s := range states {
+		if s.Name == State {
+			newState = s
+			break
+		}
+	}
+	if newState == nil {
+		return nil, errors.New("INVALID_WORKFLOW_STATE")
+	}
+
+	tx, err := d.DB.Begin()
+	if err != nil {
+		d.Logger.Error("update storyboard story state transaction error", zap.Error(err))
+		return nil, err
+	}
+
+	var ColumnID string
+	if err := tx.QueryRow(
+		`SELECT column_id FROM thunderdome.storyboard_story WHERE id = $1 AND storyboard_id = $2;`,
+		StoryID,
+		StoryboardID,
+	).Scan(&ColumnID); err != nil {
+		_ = tx.Rollback()
+		d.Logger.Error("get storyboard story column error", zap.Error(err))
+		return nil, errors.New("STORY_NOT_FOUND")
+	}
+
+	if newState.WIP && WipLimitEnforced {
+		if err := d.checkColumnWipLimit(tx, StoryboardID, ColumnID, StoryID); err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if _, err := tx.Exec(
+		`UPDATE thunderdome.storyboard_story SET state = $2, closed = $3, updated_date = NOW() WHERE id = $1 AND storyboard_id = $4;`,
+		StoryID,
+		newState.Name,
+		newState.Done,
+		StoryboardID,
+	); err != nil {
+		_ = tx.Rollback()
+		d.Logger.Error("update storyboard story state error", zap.Error(err))
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		d.Logger.Error("update storyboard story state commit error", zap.Error(err))
+		return nil, err
+	}
+
+	goals := d.GetStoryboardGoals(StoryboardID)
+
+	return goals, nil
+}
+
 // ReviseStoryLink updates the story link by ID
 func (d *Service) ReviseStoryLink(StoryboardID string, userID string, StoryID string, Link string) ([]*thunderdome.StoryboardGoal, error) {
 	if _, err := d.DB.Exec(
@@ -110,16 +192,54 @@ func (d *Service) ReviseStoryLink(StoryboardID string, userID string, StoryID st
 	return goals, nil
 }
 
-// MoveStoryboardStory moves the story by ID to Goal/Column by ID
+// MoveStoryboardStory moves the story by ID to Goal/Column by ID, when the storyboard enforces WIP limits
+// moving a story in a WIP state into a column at its WIP limit is rejected
 func (d *Service) MoveStoryboardStory(StoryboardID string, userID string, StoryID string, GoalID string, ColumnID string, PlaceBefore string) ([]*thunderdome.StoryboardGoal, error) {
-	if _, err := d.DB.Exec(
+	states, WipLimitEnforced, err := d.getStoryboardWorkflow(StoryboardID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := d.DB.Begin()
+	if err != nil {
+		d.Logger.Error("move storyboard story transaction error", zap.Error(err))
+		return nil, err
+	}
+
+	var State string
+	var CurrentColumnID string
+	if err := tx.QueryRow(
+		`SELECT state, column_id FROM thunderdome.storyboard_story WHERE id = $1 AND storyboard_id = $2;`,
+		StoryID,
+		StoryboardID,
+	).Scan(&State, &CurrentColumnID); err != nil {
+		_ = tx.Rollback()
+		d.Logger.Error("get storyboard story state error", zap.Error(err))
+		return nil, errors.New("STORY_NOT_FOUND")
+	}
+
+	if WipLimitEnforced && ColumnID != CurrentColumnID && isWipState(states, State) {
+		if err := d.checkColumnWipLimit(tx, StoryboardID, ColumnID, StoryID); err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if _, err := tx.Exec(
 		`CALL thunderdome.sb_story_move($1, $2, $3, $4);`,
 		StoryID,
 		GoalID,
 		ColumnID,
 		PlaceBefore,
 	); err != nil {
+		_ = tx.Rollback()
 		d.Logger.Error("CALL thunderdome.sb_story_move error", zap.Error(err))
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		d.Logger.Error("move storyboard story commit error", zap.Error(err))
+		return nil, err
 	}
 
 	goals := d.GetStoryboardGoals(StoryboardID)
@@ -185,3 +305,43 @@ func (d *Service) DeleteStoryComment(StoryboardID string, CommentID string) ([]*
 
 	return goals, nil
 }
+
+// checkColumnWipLimit locks the column for the rest of the transaction so concurrent changes can't both
+// take its last WIP slot, then rejects with WIP_LIMIT_EXCEEDED when the column has no WIP slot left
+// for the story
+func (d *Service) checkColumnWipLimit(tx *sql.Tx, StoryboardID string, ColumnID string, StoryID string) error {
+	var WipLimit int
+	if err := tx.QueryRow(
+		`SELECT wip_limit FROM thunderdome.storyboard_column WHERE id = $1 AND storyboard_id = $2 FOR UPDATE;`,
+		ColumnID,
+		StoryboardID,
+	).Scan(&WipLimit); err != nil {
+		d.Logger.Error("get storyboard column wip limit error", zap.Error(err))
+		return errors.New("COLUMN_NOT_FOUND")
+	}
+
+	if WipLimit == 0 {
+		return nil
+	}
+
+	var WipCount int
+	if err := tx.QueryRow(
+		`SELECT COUNT(ss.id)
+		FROM thunderdome.storyboard_story ss
+		JOIN thunderdome.storyboard s ON s.id = ss.storyboard_id
+		WHERE ss.column_id = $1 AND ss.id <> $2 AND ss.state IN (
+			SELECT st->>'name' FROM jsonb_array_elements(s.workflow_states) st WHERE COALESCE((st->>'wip')::BOOL, false)
+		);`,
+		ColumnID,
+		StoryID,
+	).Scan(&WipCount); err != nil {
+		d.Logger.Error("get storyboard column wip count error", zap.Error(err))
+		return err
+	}
+
+	if WipCount >= WipLimit {
+		return errors.New("WIP_LIMIT_EXCEEDED")
+	}
+
+	return nil
+}
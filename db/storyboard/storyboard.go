@@ -65,7 +65,7 @@ func (d *Service) CreateStoryboard(ctx context.Context, OwnerID string, Storyboa
 }
 
 // TeamCreateStoryboard adds a new storyboard associated to a team
-func (d *Service) TeamCreateStoryboard(ctx context.Context, TeamID string, OwnerID string, StoryboardName string, JoinCode string, FacilitatorCode string) (*thunderdome.Storyboard, error) {
+func (d *Service) TeamCreateStoryboard(ctx context.Context, TeamID string, OwnerID string, StoryboardName string, JoinCode string, FacilitatorCode string, TemplateID string) (*thunderdome.Storyboard, error) {
 	var encryptedJoinCode string
 	var encryptedFacilitatorCode string
 
@@ -93,14 +93,18 @@ func (d *Service) TeamCreateStoryboard(ctx context.Context, TeamID string, Owner
 	}
 
 	e := d.DB.QueryRowContext(ctx,
-		`SELECT * FROM thunderdome.team_create_storyboard($1, $2, $3, $4, $5);`,
+		`SELECT * FROM thunderdome.team_create_storyboard($1, $2, $3, $4, $5, NULLIF($6, '')::uuid);`,
 		TeamID,
 		OwnerID,
 		StoryboardName,
 		encryptedJoinCode,
 		encryptedFacilitatorCode,
+		TemplateID,
 	).Scan(&b.Id)
 	if e != nil {
+		if db.RaisedException(e) == "TEMPLATE_NOT_FOUND" {
+			return nil, errors.New("TEMPLATE_NOT_FOUND")
+		}
 		d.Logger.Error("team_create_storyboard query error", zap.Error(e))
 		return nil, errors.New("error creating storyboard")
 	}
@@ -108,6 +112,30 @@ func (d *Service) TeamCreateStoryboard(ctx context.Context, TeamID string, Owner
 	return b, nil
 }
 
+// CloneStoryboard copies a storyboards goals, columns, color legend and personas into a new storyboard without its codes,
+// belonging to the team when given otherwise the storyboards teams
+func (d *Service) CloneStoryboard(ctx context.Context, StoryboardID string, OwnerID string, StoryboardName string, Template bool, TeamID string) (*thunderdome.Storyboard, error) {
+	var StoryboardCloneID string
+
+	e := d.DB.QueryRowContext(ctx,
+		`SELECT * FROM thunderdome.sb_clone($1, $2, $3, $4, NULLIF($5, '')::uuid);`,
+		StoryboardID,
+		OwnerID,
+		StoryboardName,
+		Template,
+		TeamID,
+	).Scan(&StoryboardCloneID)
+	if e != nil {
+		if db.RaisedException(e) == "TEMPLATE_REQUIRES_TEAM" {
+			return nil, errors.New("TEMPLATE_REQUIRES_TEAM")
+		}
+		d.Logger.Ctx(ctx).Error("sb_clone query error", zap.Error(e))
+		return nil, errors.New("error cloning storyboard")
+	}
+
+	return d.GetStoryboard(StoryboardCloneID, OwnerID)
+}
+
 // EditStoryboard updates the storyboard by ID
 func (d *Service) EditStoryboard(StoryboardID string, StoryboardName string, JoinCode string, FacilitatorCode string) error {
 	var encryptedJoinCode string
@@ -144,24 +172,26 @@ func (d *Service) EditStoryboard(StoryboardID string, StoryboardName string, Joi
 // GetStoryboard gets a storyboard by ID
 func (d *Service) GetStoryboard(StoryboardID string, UserID string) (*thunderdome.Storyboard, error) {
 	var cl string
+	var ws string
 	var JoinCode string
 	var facilitators string
 	var FacilitatorCode string
 	var b = &thunderdome.Storyboard{
-		Id:          StoryboardID,
-		OwnerID:     "",
-		Name:        "",
-		Users:       make([]*thunderdome.StoryboardUser, 0),
-		Goals:       make([]*thunderdome.StoryboardGoal, 0),
-		ColorLegend: make([]*thunderdome.Color, 0),
-		Personas:    make([]*thunderdome.StoryboardPersona, 0),
+		Id:             StoryboardID,
+		OwnerID:        "",
+		Name:           "",
+		Users:          make([]*thunderdome.StoryboardUser, 0),
+		Goals:          make([]*thunderdome.StoryboardGoal, 0),
+		ColorLegend:    make([]*thunderdome.Color, 0),
+		Personas:       make([]*thunderdome.StoryboardPersona, 0),
+		WorkflowStates: make([]*thunderdome.WorkflowState, 0),
 	}
 
 	// get storyboard
 	e := d.DB.QueryRow(
 		`SELECT
-				s.id, s.name, s.owner_id, s.color_legend, COALESCE(s.join_code, ''), COALESCE(s.facilitator_code, ''),
-				 s.created_date, s.updated_date,
+				s.id, s.name, s.owner_id, s.color_legend, s.workflow_states, s.wip_limit_enforced,
+				COALESCE(s.join_code, ''), COALESCE(s.facilitator_code, ''), s.template, s.created_date, s.updated_date,
 				COALESCE(json_agg(sf.user_id) FILTER (WHERE sf.storyboard_id IS NOT NULL), '[]') AS facilitators
 				FROM thunderdome.storyboard s
 				LEFT JOIN thunderdome.storyboard_facilitator sf ON sf.storyboard_id = s.id
@@ -173,8 +203,11 @@ func (d *Service) GetStoryboard(StoryboardID string, UserID string) (*thunderdom
 		&b.Name,
 		&b.OwnerID,
 		&cl,
+		&ws,
+		&b.WipLimitEnforced,
 		&JoinCode,
 		&FacilitatorCode,
+		&b.Template,
 		&b.CreatedDate,
 		&b.UpdatedDate,
 		&facilitators,
@@ -189,6 +222,11 @@ func (d *Service) GetStoryboard(StoryboardID string, UserID string) (*thunderdom
 		d.Logger.Error("color legend json error", zap.Error(clErr))
 	}
 
+	wsErr := json.Unmarshal([]byte(ws), &b.WorkflowStates)
+	if wsErr != nil {
+		d.Logger.Error("workflow states json error", zap.Error(wsErr))
+	}
+
 	facilError := json.Unmarshal([]byte(facilitators), &b.Facilitators)
 	if facilError != nil {
 		d.Logger.Error("facilitators json error", zap.Error(facilError))
@@ -198,6 +236,7 @@ func (d *Service) GetStoryboard(StoryboardID string, UserID string) (*thunderdom
 	b.Users = d.GetStoryboardUsers(StoryboardID)
 	b.Goals = d.GetStoryboardGoals(StoryboardID)
 	b.Personas = d.GetStoryboardPersonas(StoryboardID)
+	b.StateCounts = getStoryStateCounts(b.Goals, b.WorkflowStates)
 
 	if JoinCode != "" {
 		DecryptedCode, codeErr := db.Decrypt(JoinCode, d.AESHashKey)
@@ -281,7 +320,7 @@ func (d *Service) GetStoryboardUsers(StoryboardID string) []*thunderdome.Storybo
 	var users = make([]*thunderdome.StoryboardUser, 0)
 	rows, err := d.DB.Query(
 		`SELECT
-			w.id, w.name, su.active, w.avatar, COALESCE(w.email, '')
+			w.id, w.name, su.active, w.avatar, COALESCE(w.email, ''), w.type
 		FROM thunderdome.storyboard_user su
 		LEFT JOIN thunderdome.users w ON su.user_id = w.id
 		WHERE su.storyboard_id = $1
@@ -292,7 +331,7 @@ func (d *Service) GetStoryboardUsers(StoryboardID string) []*thunderdome.Storybo
 		defer rows.Close()
 		for rows.Next() {
 			var w thunderdome.StoryboardUser
-			if err := rows.Scan(&w.Id, &w.Name, &w.Active, &w.Avatar, &w.GravatarHash); err != nil {
+			if err := rows.Scan(&w.Id, &w.Name, &w.Active, &w.Avatar, &w.GravatarHash, &w.Type); err != nil {
 				d.Logger.Error("get_storyboard_users query scan error", zap.Error(err))
 			} else {
 				if w.GravatarHash != "" {
@@ -0,0 +1,136 @@
+package storyboard
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// getStoryboardWorkflow gets the storyboards workflow states and whether WIP limits are enforced
+func (d *Service) getStoryboardWorkflow(StoryboardID string) ([]*thunderdome.WorkflowState, bool, error) {
+	var ws string
+	var WipLimitEnforced bool
+	var states = make([]*thunderdome.WorkflowState, 0)
+
+	err := d.DB.QueryRow(
+		`SELECT workflow_states, wip_limit_enforced FROM thunderdome.storyboard WHERE id = $1;`,
+		StoryboardID,
+	).Scan(&ws, &WipLimitEnforced)
+	if err != nil {
+		d.Logger.Error("get storyboard workflow query error", zap.Error(err))
+		return nil, false, errors.New("storyboard not found")
+	}
+
+	err = json.Unmarshal([]byte(ws), &states)
+	if err != nil {
+		d.Logger.Error("workflow states json error", zap.Error(err))
+	}
+
+	return states, WipLimitEnforced, nil
+}
+
+// StoryboardReviseWorkflow revises the storyboard workflow states and WIP limit enforcement,
+// any stories in a removed state are moved to the first state
+func (d *Service) StoryboardReviseWorkflow(StoryboardID string, UserID string, WorkflowStates []*thunderdome.WorkflowState, WipLimitEnforced bool) (*thunderdome.Storyboard, error) {
+	if len(WorkflowStates) == 0 {
+		return nil, errors.New("WORKFLOW_STATES_REQUIRED")
+	}
+	workflowStatesJSON, _ := json.Marshal(WorkflowStates)
+
+	tx, err := d.DB.Begin()
+	if err != nil {
+		d.Logger.Error("revise storyboard workflow transaction error", zap.Error(err))
+		return nil, err
+	}
+
+	if _, err := tx.Exec(
+		`UPDATE thunderdome.storyboard SET updated_date = NOW(), workflow_states = $2, wip_limit_enforced = $3 WHERE id = $1;`,
+		StoryboardID,
+		string(workflowStatesJSON),
+		WipLimitEnforced,
+	); err != nil {
+		_ = tx.Rollback()
+		d.Logger.Error("revise storyboard workflow error", zap.Error(err))
+		return nil, err
+	}
+
+	if _, err := tx.Exec(
+		`UPDATE thunderdome.storyboard_story ss SET state = $2, updated_date = NOW()
+		WHERE ss.storyboard_id = $1 AND ss.state NOT IN (
+			SELECT st->>'name' FROM thunderdome.storyboard s, jsonb_array_elements(s.workflow_states) st WHERE s.id = $1
+		);`,
+		StoryboardID,
+		WorkflowStates[0].Name,
+	); err != nil {
+		_ = tx.Rollback()
+		d.Logger.Error("revise storyboard workflow story states error", zap.Error(err))
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		d.Logger.Error("revise storyboard workflow commit error", zap.Error(err))
+		return nil, err
+	}
+
+	storyboard, err := d.GetStoryboard(StoryboardID, "")
+	if err != nil {
+		return nil, errors.New("unable to get storyboard")
+	}
+
+	return storyboard, nil
+}
+
+// setColumnWipCounts sets each columns count of stories in a WIP state
+// and flags the columns that exceed their WIP limit
+func setColumnWipCounts(goals []*thunderdome.StoryboardGoal, states []*thunderdome.WorkflowState) {
+	wipStates := make(map[string]bool)
+	for _, s := range states {
+		if s.WIP {
+			wipStates[s.Name] = true
+		}
+	}
+
+	for _, g := range goals {
+		for _, c := range g.Columns {
+			c.WipCount = 0
+			for _, s := range c.Stories {
+				if wipStates[s.State] {
+					c.WipCount++
+				}
+			}
+			c.WipExceeded = c.WipLimit > 0 && c.WipCount > c.WipLimit
+		}
+	}
+}
+
+// isWipState checks whether the named workflow state counts towards column WIP limits
+func isWipState(states []*thunderdome.WorkflowState, State string) bool {
+	for _, s := range states {
+		if s.Name == State {
+			return s.WIP
+		}
+	}
+
+	return false
+}
+
+// getStoryStateCounts gets the count of stories in each workflow state
+func getStoryStateCounts(goals []*thunderdome.StoryboardGoal, states []*thunderdome.WorkflowState) map[string]int {
+	counts := make(map[string]int)
+	for _, s := range states {
+		counts[s.Name] = 0
+	}
+
+	for _, g := range goals {
+		for _, c := range g.Columns {
+			for _, s := range c.Stories {
+				counts[s.State]++
+			}
+		}
+	}
+
+	return counts
+}
@@ -0,0 +1,289 @@
+package team
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+	"github.com/lib/pq"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+
+	"go.uber.org/zap"
+)
+
+// ChatIntegrationService represents a PostgreSQL implementation of thunderdome.ChatIntegrationDataSvc.
+type ChatIntegrationService struct {
+	DB         *sql.DB
+	Logger     *otelzap.Logger
+	AESHashKey string
+}
+
+// ChatIntegrationList gets a list of the teams chat integrations
+func (d *ChatIntegrationService) ChatIntegrationList(ctx context.Context, TeamId string) ([]*thunderdome.ChatIntegration, error) {
+	Integrations := make([]*thunderdome.ChatIntegration, 0)
+
+	rows, err := d.DB.QueryContext(ctx,
+		`SELECT id, team_id, provider, name, events, enabled, created_date, updated_date
+		FROM thunderdome.team_chat_integration
+		WHERE team_id = $1
+		ORDER BY created_date;`,
+		TeamId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ci thunderdome.ChatIntegration
+		var events pq.StringArray
+
+		if err := rows.Scan(
+			&ci.Id,
+			&ci.TeamId,
+			&ci.Provider,
+			&ci.Name,
+			&events,
+			&ci.Enabled,
+			&ci.CreatedDate,
+			&ci.UpdatedDate,
+		); err != nil {
+			d.Logger.Ctx(ctx).Error("chat integration scan error", zap.Error(err))
+		} else {
+			ci.Events = events
+			Integrations = append(Integrations, &ci)
+		}
+	}
+
+	return Integrations, nil
+}
+
+// ChatIntegrationCreate creates a teams chat integration
+func (d *ChatIntegrationService) ChatIntegrationCreate(
+	ctx context.Context, TeamId string, Provider string, Name string, WebhookURL string, Events []string,
+) (*thunderdome.ChatIntegration, error) {
+	var ci thunderdome.ChatIntegration
+	var events pq.StringArray
+
+	EncryptedURL, encErr := db.Encrypt(WebhookURL, d.AESHashKey)
+	if encErr != nil {
+		return nil, encErr
+	}
+
+	err := d.DB.QueryRowContext(ctx,
+		`INSERT INTO thunderdome.team_chat_integration (team_id, provider, name, webhook_url, events)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, team_id, provider, name, events, enabled, created_date, updated_date;`,
+		TeamId,
+		Provider,
+		Name,
+		EncryptedURL,
+		pq.StringArray(Events),
+	).Scan(
+		&ci.Id,
+		&ci.TeamId,
+		&ci.Provider,
+		&ci.Name,
+		&events,
+		&ci.Enabled,
+		&ci.CreatedDate,
+		&ci.UpdatedDate,
+	)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("chat integration create query error", zap.Error(err))
+		return nil, err
+	}
+	ci.Events = events
+
+	return &ci, nil
+}
+
+// ChatIntegrationUpdate updates a teams chat integration, an empty webhook URL keeps the current URL
+func (d *ChatIntegrationService) ChatIntegrationUpdate(
+	ctx context.Context, TeamId string, IntegrationId string, Name string, WebhookURL string, Events []string, Enabled bool,
+) (*thunderdome.ChatIntegration, error) {
+	var ci thunderdome.ChatIntegration
+	var events pq.StringArray
+	var EncryptedURL string
+
+	if WebhookURL != "" {
+		var encErr error
+		EncryptedURL, encErr = db.Encrypt(WebhookURL, d.AESHashKey)
+		if encErr != nil {
+			return nil, encErr
+		}
+	}
+
+	err := d.DB.QueryRowContext(ctx,
+		`UPDATE thunderdome.team_chat_integration
+		SET name = $3, webhook_url = COALESCE(NULLIF($4, ''), webhook_url), events = $5, enabled = $6, updated_date = NOW()
+		WHERE team_id = $1 AND id = $2
+		RETURNING id, team_id, provider, name, events, enabled, created_date, updated_date;`,
+		TeamId,
+		IntegrationId,
+		Name,
+		EncryptedURL,
+		pq.StringArray(Events),
+		Enabled,
+	).Scan(
+		&ci.Id,
+		&ci.TeamId,
+		&ci.Provider,
+		&ci.Name,
+		&events,
+		&ci.Enabled,
+		&ci.CreatedDate,
+		&ci.UpdatedDate,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("CHAT_INTEGRATION_NOT_FOUND")
+		}
+		d.Logger.Ctx(ctx).Error("chat integration update query error", zap.Error(err))
+		return nil, err
+	}
+	ci.Events = events
+
+	return &ci, nil
+}
+
+// ChatIntegrationDelete deletes a teams chat integration
+func (d *ChatIntegrationService) ChatIntegrationDelete(ctx context.Context, TeamId string, IntegrationId string) error {
+	if _, err := d.DB.ExecContext(ctx,
+		`DELETE FROM thunderdome.team_chat_integration WHERE team_id = $1 AND id = $2;`,
+		TeamId,
+		IntegrationId,
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("chat integration delete query error", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// ChatWebhooksByTeam gets the teams enabled chat webhooks subscribed to the event
+func (d *ChatIntegrationService) ChatWebhooksByTeam(ctx context.Context, TeamId string, Event string) ([]*thunderdome.ChatWebhook, error) {
+	return d.getChatWebhooks(ctx,
+		`SELECT ci.provider, ci.webhook_url, t.id, t.name
+		FROM thunderdome.team_chat_integration ci
+		JOIN thunderdome.team t ON t.id = ci.team_id
+		WHERE ci.team_id = $1 AND ci.enabled = true AND $2 = ANY(ci.events);`,
+		TeamId, Event,
+	)
+}
+
+// ChatWebhooksByPoker gets the enabled chat webhooks subscribed to the event for the poker games teams
+func (d *ChatIntegrationService) ChatWebhooksByPoker(ctx context.Context, PokerId string, Event string) ([]*thunderdome.ChatWebhook, error) {
+	return d.getChatWebhooks(ctx,
+		`SELECT ci.provider, ci.webhook_url, t.id, t.name
+		FROM thunderdome.team_battle tb
+		JOIN thunderdome.team t ON t.id = tb.team_id
+		JOIN thunderdome.team_chat_integration ci ON ci.team_id = tb.team_id
+		WHERE tb.battle_id = $1 AND ci.enabled = true AND $2 = ANY(ci.events);`,
+		PokerId, Event,
+	)
+}
+
+// ChatWebhooksByRetro gets the enabled chat webhooks subscribed to the event for the retros teams
+func (d *ChatIntegrationService) ChatWebhooksByRetro(ctx context.Context, RetroId string, Event string) ([]*thunderdome.ChatWebhook, error) {
+	return d.getChatWebhooks(ctx,
+		`SELECT ci.provider, ci.webhook_url, t.id, t.name
+		FROM thunderdome.team_retro tr
+		JOIN thunderdome.team t ON t.id = tr.team_id
+		JOIN thunderdome.team_chat_integration ci ON ci.team_id = tr.team_id
+		WHERE tr.retro_id = $1 AND ci.enabled = true AND $2 = ANY(ci.events);`,
+		RetroId, Event,
+	)
+}
+
+// getChatWebhooks runs the webhook query and decrypts the webhook URLs
+func (d *ChatIntegrationService) getChatWebhooks(ctx context.Context, query string, args ...interface{}) ([]*thunderdome.ChatWebhook, error) {
+	Webhooks := make([]*thunderdome.ChatWebhook, 0)
+
+	rows, err := d.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var wh thunderdome.ChatWebhook
+		var EncryptedURL string
+
+		if err := rows.Scan(
+			&wh.Provider,
+			&EncryptedURL,
+			&wh.TeamId,
+			&wh.TeamName,
+		); err != nil {
+			d.Logger.Ctx(ctx).Error("chat webhook scan error", zap.Error(err))
+			continue
+		}
+
+		URL, decErr := db.Decrypt(EncryptedURL, d.AESHashKey)
+		if decErr != nil {
+			d.Logger.Ctx(ctx).Error("chat webhook decrypt error", zap.Error(decErr))
+			continue
+		}
+		wh.URL = URL
+		Webhooks = append(Webhooks, &wh)
+	}
+
+	return Webhooks, nil
+}
+
+// SlackChannelTeamGet gets the ID of the team connected to the slack channel
+func (d *ChatIntegrationService) SlackChannelTeamGet(ctx context.Context, SlackTeamId string, ChannelId string) (string, error) {
+	var TeamId string
+
+	err := d.DB.QueryRowContext(ctx,
+		`SELECT team_id FROM thunderdome.team_slack_channel
+		WHERE slack_team_id = $1 AND slack_channel_id = $2;`,
+		SlackTeamId,
+		ChannelId,
+	).Scan(&TeamId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", errors.New("SLACK_CHANNEL_NOT_CONNECTED")
+		}
+		d.Logger.Ctx(ctx).Error("slack channel team get query error", zap.Error(err))
+		return "", err
+	}
+
+	return TeamId, nil
+}
+
+// SlackChannelConnect connects the slack channel to the team, replacing any existing connection
+func (d *ChatIntegrationService) SlackChannelConnect(ctx context.Context, SlackTeamId string, ChannelId string, TeamId string, UserId string) error {
+	if _, err := d.DB.ExecContext(ctx,
+		`INSERT INTO thunderdome.team_slack_channel (slack_team_id, slack_channel_id, team_id, created_by)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (slack_team_id, slack_channel_id) DO UPDATE SET
+		team_id = EXCLUDED.team_id, created_by = EXCLUDED.created_by, created_date = NOW();`,
+		SlackTeamId,
+		ChannelId,
+		TeamId,
+		UserId,
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("slack channel connect query error", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// SlackChannelDisconnect removes the slack channels team connection
+func (d *ChatIntegrationService) SlackChannelDisconnect(ctx context.Context, SlackTeamId string, ChannelId string) error {
+	if _, err := d.DB.ExecContext(ctx,
+		`DELETE FROM thunderdome.team_slack_channel WHERE slack_team_id = $1 AND slack_channel_id = $2;`,
+		SlackTeamId,
+		ChannelId,
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("slack channel disconnect query error", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
@@ -24,6 +24,11 @@ type CheckinService struct {
 
 // CheckinList gets a list of team checkins by day
 func (d *CheckinService) CheckinList(ctx context.Context, TeamId string, Date string, TimeZone string) ([]*thunderdome.TeamCheckin, error) {
+	return d.CheckinListRange(ctx, TeamId, Date, Date, TimeZone)
+}
+
+// CheckinListRange gets a list of team checkins between the from and to dates inclusive
+func (d *CheckinService) CheckinListRange(ctx context.Context, TeamId string, From string, To string, TimeZone string) ([]*thunderdome.TeamCheckin, error) {
 	Checkins := make([]*thunderdome.TeamCheckin, 0)
 
 	rows, err := d.DB.QueryContext(ctx, `SELECT
@@ -31,6 +36,14 @@ func (d *CheckinService) CheckinList(ctx context.Context, TeamId string, Date st
  		COALESCE(tc.yesterday, ''), COALESCE(tc.today, ''),
  		COALESCE(tc.blockers, ''), coalesce(tc.discuss, ''),
  		tc.goals_met, tc.created_date, tc.updated_date,
+ 		COALESCE(
+			(SELECT json_agg(json_build_object(
+				'questionId', tca.question_id, 'question', tcq.question, 'answer', tca.answer
+			) ORDER BY tcq.sort_order, tcq.created_date)
+			FROM thunderdome.team_checkin_answer tca
+			JOIN thunderdome.team_checkin_question tcq ON tcq.id = tca.question_id
+			WHERE tca.checkin_id = tc.id), '[]'
+		) AS answers,
  		COALESCE(
 			json_agg(tcc ORDER BY tcc.created_date) FILTER (WHERE tcc.id IS NOT NULL), '[]'
 		) AS comments
@@ -38,12 +51,14 @@ func (d *CheckinService) CheckinList(ctx context.Context, TeamId string, Date st
 		LEFT JOIN thunderdome.users u ON tc.user_id = u.id
 		LEFT JOIN thunderdome.team_checkin_comment tcc ON tcc.checkin_id = tc.id
 		WHERE tc.team_id = $1
-		AND date(tc.created_date AT TIME ZONE $3) = $2
-		GROUP BY tc.id, u.id;
+		AND date(tc.created_date AT TIME ZONE $3) BETWEEN $2 AND $4
+		GROUP BY tc.id, u.id
+		ORDER BY tc.created_date;
 		`,
 		TeamId,
-		Date,
+		From,
 		TimeZone,
+		To,
 	)
 
 	if err == nil {
@@ -51,6 +66,7 @@ func (d *CheckinService) CheckinList(ctx context.Context, TeamId string, Date st
 		for rows.Next() {
 			var checkin thunderdome.TeamCheckin
 			var user thunderdome.TeamUser
+			var answers string
 			var comments string
 
 			if err := rows.Scan(
@@ -66,6 +82,7 @@ func (d *CheckinService) CheckinList(ctx context.Context, TeamId string, Date st
 				&checkin.GoalsMet,
 				&checkin.CreatedDate,
 				&checkin.UpdatedDate,
+				&answers,
 				&comments,
 			); err != nil {
 				return nil, err
@@ -80,6 +97,13 @@ func (d *CheckinService) CheckinList(ctx context.Context, TeamId string, Date st
 				}
 				checkin.Comments = Comments
 
+				Answers := make([]*thunderdome.CheckinAnswer, 0)
+				jsonErr = json.Unmarshal([]byte(answers), &Answers)
+				if jsonErr != nil {
+					d.Logger.Ctx(ctx).Error("checkin answers json error", zap.Error(jsonErr))
+				}
+				checkin.Answers = Answers
+
 				Checkins = append(Checkins, &checkin)
 			}
 		}
@@ -93,7 +117,7 @@ func (d *CheckinService) CheckinCreate(
 	ctx context.Context,
 	TeamId string, UserId string,
 	Yesterday string, Today string, Blockers string, Discuss string,
-	GoalsMet bool,
+	GoalsMet bool, Answers []*thunderdome.CheckinAnswer,
 ) error {
 	var userCount int
 	// target user must be on team to check in
@@ -112,10 +136,20 @@ func (d *CheckinService) CheckinCreate(
 	SanitizedToday := d.HTMLSanitizerPolicy.Sanitize(Today)
 	SanitizedBlockers := d.HTMLSanitizerPolicy.Sanitize(Blockers)
 	SanitizedDiscuss := d.HTMLSanitizerPolicy.Sanitize(Discuss)
+	SanitizedAnswers, answersErr := d.validateCheckinAnswers(ctx, TeamId, Answers)
+	if answersErr != nil {
+		return answersErr
+	}
 
-	if _, err := d.DB.Exec(`INSERT INTO thunderdome.team_checkin
+	tx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	var CheckinId string
+	if err := tx.QueryRowContext(ctx, `INSERT INTO thunderdome.team_checkin
 		(team_id, user_id, yesterday, today, blockers, discuss, goals_met)
-		VALUES ($1, $2, $3, $4, $5, $6, $7);
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id;
 		`,
 		TeamId,
 		UserId,
@@ -124,11 +158,17 @@ func (d *CheckinService) CheckinCreate(
 		SanitizedBlockers,
 		SanitizedDiscuss,
 		GoalsMet,
-	); err != nil {
+	).Scan(&CheckinId); err != nil {
+		_ = tx.Rollback()
 		return err
 	}
 
-	return nil
+	if err := saveCheckinAnswers(ctx, tx, CheckinId, SanitizedAnswers); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // CheckinUpdate updates a team checkin
@@ -136,14 +176,31 @@ func (d *CheckinService) CheckinUpdate(
 	ctx context.Context,
 	CheckinId string,
 	Yesterday string, Today string, Blockers string, Discuss string,
-	GoalsMet bool,
+	GoalsMet bool, Answers []*thunderdome.CheckinAnswer,
 ) error {
+	var TeamId string
+	teamErr := d.DB.QueryRowContext(ctx, `SELECT team_id FROM thunderdome.team_checkin WHERE id = $1;`,
+		CheckinId,
+	).Scan(&TeamId)
+	if teamErr != nil {
+		return errors.New("CHECKIN_NOT_FOUND")
+	}
+
 	SanitizedYesterday := d.HTMLSanitizerPolicy.Sanitize(Yesterday)
 	SanitizedToday := d.HTMLSanitizerPolicy.Sanitize(Today)
 	SanitizedBlockers := d.HTMLSanitizerPolicy.Sanitize(Blockers)
 	SanitizedDiscuss := d.HTMLSanitizerPolicy.Sanitize(Discuss)
+	SanitizedAnswers, answersErr := d.validateCheckinAnswers(ctx, TeamId, Answers)
+	if answersErr != nil {
+		return answersErr
+	}
 
-	if _, err := d.DB.ExecContext(ctx, `
+	tx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
 		UPDATE thunderdome.team_checkin
 		SET Yesterday = $2, today = $3, blockers = $4, discuss = $5, goals_met = $6
 		WHERE id = $1;
@@ -155,10 +212,27 @@ func (d *CheckinService) CheckinUpdate(
 		SanitizedDiscuss,
 		GoalsMet,
 	); err != nil {
+		_ = tx.Rollback()
 		return err
 	}
 
-	return nil
+	// answers to archived questions are kept as they can no longer be answered
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM thunderdome.team_checkin_answer tca
+		USING thunderdome.team_checkin_question tcq
+		WHERE tca.checkin_id = $1 AND tcq.id = tca.question_id AND tcq.archived_date IS NULL;`,
+		CheckinId,
+	); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := saveCheckinAnswers(ctx, tx, CheckinId, SanitizedAnswers); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // CheckinDelete deletes a team checkin
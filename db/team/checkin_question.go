@@ -0,0 +1,209 @@
+package team
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// getTeamCheckinQuestions gets the teams own checkin questions, excluding archived questions
+func (d *CheckinService) getTeamCheckinQuestions(ctx context.Context, TeamId string) ([]*thunderdome.CheckinQuestion, error) {
+	Questions := make([]*thunderdome.CheckinQuestion, 0)
+
+	rows, err := d.DB.QueryContext(ctx,
+		`SELECT id, question, type, required, sort_order
+		FROM thunderdome.team_checkin_question
+		WHERE team_id = $1 AND archived_date IS NULL
+		ORDER BY sort_order, created_date;`,
+		TeamId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var q thunderdome.CheckinQuestion
+
+		if err := rows.Scan(
+			&q.Id,
+			&q.Question,
+			&q.Type,
+			&q.Required,
+			&q.SortOrder,
+		); err != nil {
+			d.Logger.Ctx(ctx).Error("checkin question scan error", zap.Error(err))
+		} else {
+			Questions = append(Questions, &q)
+		}
+	}
+
+	return Questions, nil
+}
+
+// CheckinQuestionList gets the teams checkin questions, or the default questions if the team has none
+func (d *CheckinService) CheckinQuestionList(ctx context.Context, TeamId string) ([]*thunderdome.CheckinQuestion, error) {
+	Questions, err := d.getTeamCheckinQuestions(ctx, TeamId)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(Questions) == 0 {
+		return thunderdome.DefaultCheckinQuestions, nil
+	}
+
+	return Questions, nil
+}
+
+// CheckinQuestionCreate adds a checkin question to the end of the teams questions
+func (d *CheckinService) CheckinQuestionCreate(ctx context.Context, TeamId string, Question string, Type string, Required bool) (*thunderdome.CheckinQuestion, error) {
+	var q = thunderdome.CheckinQuestion{
+		Question: Question,
+		Type:     Type,
+		Required: Required,
+	}
+
+	err := d.DB.QueryRowContext(ctx,
+		`INSERT INTO thunderdome.team_checkin_question (team_id, question, type, required, sort_order)
+		VALUES ($1, $2, $3, $4, (
+			SELECT COALESCE(MAX(sort_order), 0) + 1 FROM thunderdome.team_checkin_question
+			WHERE team_id = $1 AND archived_date IS NULL
+		))
+		RETURNING id, sort_order;`,
+		TeamId,
+		Question,
+		Type,
+		Required,
+	).Scan(&q.Id, &q.SortOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	return &q, nil
+}
+
+// CheckinQuestionUpdate updates a team checkin question
+func (d *CheckinService) CheckinQuestionUpdate(ctx context.Context, TeamId string, QuestionId string, Question string, Type string, Required bool, SortOrder int) (*thunderdome.CheckinQuestion, error) {
+	var q = thunderdome.CheckinQuestion{
+		Id:        QuestionId,
+		Question:  Question,
+		Type:      Type,
+		Required:  Required,
+		SortOrder: SortOrder,
+	}
+
+	err := d.DB.QueryRowContext(ctx,
+		`UPDATE thunderdome.team_checkin_question
+		SET question = $3, type = $4, required = $5, sort_order = $6, updated_date = NOW()
+		WHERE team_id = $1 AND id = $2 AND archived_date IS NULL
+		RETURNING id;`,
+		TeamId,
+		QuestionId,
+		Question,
+		Type,
+		Required,
+		SortOrder,
+	).Scan(&q.Id)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("CHECKIN_QUESTION_NOT_FOUND")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &q, nil
+}
+
+// CheckinQuestionArchive archives a team checkin question so it's no longer asked,
+// keeping its answers on past checkins
+func (d *CheckinService) CheckinQuestionArchive(ctx context.Context, TeamId string, QuestionId string) error {
+	res, err := d.DB.ExecContext(ctx,
+		`UPDATE thunderdome.team_checkin_question SET archived_date = NOW(), updated_date = NOW()
+		WHERE team_id = $1 AND id = $2 AND archived_date IS NULL;`,
+		TeamId,
+		QuestionId,
+	)
+	if err != nil {
+		return err
+	}
+
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return errors.New("CHECKIN_QUESTION_NOT_FOUND")
+	}
+
+	return nil
+}
+
+// validateCheckinAnswers validates the answers against the teams checkin questions
+// returning the non-empty answers with text answers sanitized
+func (d *CheckinService) validateCheckinAnswers(ctx context.Context, TeamId string, Answers []*thunderdome.CheckinAnswer) ([]*thunderdome.CheckinAnswer, error) {
+	Questions, err := d.getTeamCheckinQuestions(ctx, TeamId)
+	if err != nil {
+		return nil, err
+	}
+
+	answerMap := make(map[string]string)
+	for _, a := range Answers {
+		if a != nil {
+			answerMap[a.QuestionID] = a.Answer
+		}
+	}
+
+	SanitizedAnswers := make([]*thunderdome.CheckinAnswer, 0)
+	for _, q := range Questions {
+		answer := answerMap[q.Id]
+		delete(answerMap, q.Id)
+
+		if answer == "" {
+			if q.Required {
+				return nil, errors.New("CHECKIN_ANSWER_REQUIRED")
+			}
+			continue
+		}
+
+		switch q.Type {
+		case thunderdome.CheckinQuestionYesNo:
+			if answer != "yes" && answer != "no" {
+				return nil, errors.New("INVALID_CHECKIN_ANSWER")
+			}
+		case thunderdome.CheckinQuestionScale:
+			if len(answer) != 1 || answer < "1" || answer > "5" {
+				return nil, errors.New("INVALID_CHECKIN_ANSWER")
+			}
+		default:
+			answer = d.HTMLSanitizerPolicy.Sanitize(answer)
+		}
+
+		SanitizedAnswers = append(SanitizedAnswers, &thunderdome.CheckinAnswer{
+			QuestionID: q.Id,
+			Answer:     answer,
+		})
+	}
+
+	// any remaining answers aren't for one of the teams questions
+	if len(answerMap) > 0 {
+		return nil, errors.New("INVALID_CHECKIN_ANSWER")
+	}
+
+	return SanitizedAnswers, nil
+}
+
+// saveCheckinAnswers inserts the checkin answers
+func saveCheckinAnswers(ctx context.Context, tx *sql.Tx, CheckinId string, Answers []*thunderdome.CheckinAnswer) error {
+	for _, a := range Answers {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO thunderdome.team_checkin_answer (checkin_id, question_id, answer) VALUES ($1, $2, $3);`,
+			CheckinId,
+			a.QuestionID,
+			a.Answer,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
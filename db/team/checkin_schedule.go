@@ -0,0 +1,228 @@
+package team
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+	"github.com/lib/pq"
+
+	"go.uber.org/zap"
+)
+
+// CheckinScheduleGet gets the teams checkin schedule
+func (d *CheckinService) CheckinScheduleGet(ctx context.Context, TeamId string) (*thunderdome.CheckinSchedule, error) {
+	var s thunderdome.CheckinSchedule
+	var days pq.Int64Array
+
+	err := d.DB.QueryRowContext(ctx,
+		`SELECT cs.team_id, t.name, cs.enabled, cs.days, cs.reminder_time, cs.digest_time, cs.time_zone,
+		COALESCE(TO_CHAR(cs.last_reminder_date, 'YYYY-MM-DD'), ''), COALESCE(TO_CHAR(cs.last_digest_date, 'YYYY-MM-DD'), ''),
+		cs.created_date, cs.updated_date
+		FROM thunderdome.team_checkin_schedule cs
+		JOIN thunderdome.team t ON t.id = cs.team_id
+		WHERE cs.team_id = $1;`,
+		TeamId,
+	).Scan(
+		&s.TeamId,
+		&s.TeamName,
+		&s.Enabled,
+		&days,
+		&s.ReminderTime,
+		&s.DigestTime,
+		&s.TimeZone,
+		&s.LastReminderDate,
+		&s.LastDigestDate,
+		&s.CreatedDate,
+		&s.UpdatedDate,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("CHECKIN_SCHEDULE_NOT_FOUND")
+		}
+		d.Logger.Ctx(ctx).Error("checkin schedule get query error", zap.Error(err))
+		return nil, err
+	}
+	s.Days = scheduleDays(days)
+
+	return &s, nil
+}
+
+// CheckinScheduleUpsert creates or updates the teams checkin schedule
+func (d *CheckinService) CheckinScheduleUpsert(
+	ctx context.Context, TeamId string, Enabled bool, Days []int,
+	ReminderTime string, DigestTime string, TimeZone string,
+) (*thunderdome.CheckinSchedule, error) {
+	days := make(pq.Int64Array, 0, len(Days))
+	for _, day := range Days {
+		days = append(days, int64(day))
+	}
+
+	if _, err := d.DB.ExecContext(ctx,
+		`INSERT INTO thunderdome.team_checkin_schedule
+		(team_id, enabled, days, reminder_time, digest_time, time_zone)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (team_id) DO UPDATE SET
+		enabled = EXCLUDED.enabled, days = EXCLUDED.days, reminder_time = EXCLUDED.reminder_time,
+		digest_time = EXCLUDED.digest_time, time_zone = EXCLUDED.time_zone, updated_date = NOW();`,
+		TeamId,
+		Enabled,
+		days,
+		ReminderTime,
+		DigestTime,
+		TimeZone,
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("checkin schedule upsert query error", zap.Error(err))
+		return nil, err
+	}
+
+	return d.CheckinScheduleGet(ctx, TeamId)
+}
+
+// CheckinScheduleDelete deletes the teams checkin schedule
+func (d *CheckinService) CheckinScheduleDelete(ctx context.Context, TeamId string) error {
+	if _, err := d.DB.ExecContext(ctx,
+		`DELETE FROM thunderdome.team_checkin_schedule WHERE team_id = $1;`,
+		TeamId,
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("checkin schedule delete query error", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// CheckinScheduleListEnabled gets all the enabled team checkin schedules
+func (d *CheckinService) CheckinScheduleListEnabled(ctx context.Context) ([]*thunderdome.CheckinSchedule, error) {
+	Schedules := make([]*thunderdome.CheckinSchedule, 0)
+
+	rows, err := d.DB.QueryContext(ctx,
+		`SELECT cs.team_id, t.name, cs.enabled, cs.days, cs.reminder_time, cs.digest_time, cs.time_zone,
+		COALESCE(TO_CHAR(cs.last_reminder_date, 'YYYY-MM-DD'), ''), COALESCE(TO_CHAR(cs.last_digest_date, 'YYYY-MM-DD'), ''),
+		cs.created_date, cs.updated_date
+		FROM thunderdome.team_checkin_schedule cs
+		JOIN thunderdome.team t ON t.id = cs.team_id
+		WHERE cs.enabled = true;`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s thunderdome.CheckinSchedule
+		var days pq.Int64Array
+
+		if err := rows.Scan(
+			&s.TeamId,
+			&s.TeamName,
+			&s.Enabled,
+			&days,
+			&s.ReminderTime,
+			&s.DigestTime,
+			&s.TimeZone,
+			&s.LastReminderDate,
+			&s.LastDigestDate,
+			&s.CreatedDate,
+			&s.UpdatedDate,
+		); err != nil {
+			d.Logger.Ctx(ctx).Error("checkin schedule scan error", zap.Error(err))
+		} else {
+			s.Days = scheduleDays(days)
+			Schedules = append(Schedules, &s)
+		}
+	}
+
+	return Schedules, nil
+}
+
+// CheckinScheduleClaim marks the teams reminder or digest as sent for the date,
+// returning false when it was already claimed so that only one instance sends it
+func (d *CheckinService) CheckinScheduleClaim(ctx context.Context, TeamId string, NotificationType string, Date string) (bool, error) {
+	var column string
+	switch NotificationType {
+	case thunderdome.CheckinNotificationReminder:
+		column = "last_reminder_date"
+	case thunderdome.CheckinNotificationDigest:
+		column = "last_digest_date"
+	default:
+		return false, errors.New("INVALID_CHECKIN_NOTIFICATION_TYPE")
+	}
+
+	result, err := d.DB.ExecContext(ctx,
+		`UPDATE thunderdome.team_checkin_schedule SET `+column+` = $2
+		WHERE team_id = $1 AND (`+column+` IS NULL OR `+column+` < $2);`,
+		TeamId,
+		Date,
+	)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("checkin schedule claim query error", zap.Error(err))
+		return false, err
+	}
+
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return claimed == 1, nil
+}
+
+// CheckinNotificationRecipients gets the team members with notifications enabled,
+// optionally only those who haven't checked in on the date
+func (d *CheckinService) CheckinNotificationRecipients(
+	ctx context.Context, TeamId string, Date string, TimeZone string, MissingCheckinOnly bool,
+) ([]*thunderdome.User, error) {
+	Users := make([]*thunderdome.User, 0)
+
+	rows, err := d.DB.QueryContext(ctx,
+		`SELECT u.id, u.name, u.email
+		FROM thunderdome.team_user tu
+		JOIN thunderdome.users u ON u.id = tu.user_id
+		WHERE tu.team_id = $1
+		AND u.email IS NOT NULL AND u.email <> ''
+		AND u.notifications_enabled = true
+		AND u.disabled = false
+		AND (NOT $4::boolean OR NOT EXISTS (
+			SELECT 1 FROM thunderdome.team_checkin tc
+			WHERE tc.team_id = tu.team_id AND tc.user_id = tu.user_id
+			AND date(tc.created_date AT TIME ZONE $3) = $2
+		))
+		ORDER BY tu.created_date;`,
+		TeamId,
+		Date,
+		TimeZone,
+		MissingCheckinOnly,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var u thunderdome.User
+
+		if err := rows.Scan(
+			&u.Id,
+			&u.Name,
+			&u.Email,
+		); err != nil {
+			d.Logger.Ctx(ctx).Error("checkin notification recipient scan error", zap.Error(err))
+		} else {
+			u.NotificationsEnabled = true
+			Users = append(Users, &u)
+		}
+	}
+
+	return Users, nil
+}
+
+func scheduleDays(days pq.Int64Array) []int {
+	Days := make([]int, 0, len(days))
+	for _, day := range days {
+		Days = append(Days, int(day))
+	}
+
+	return Days
+}
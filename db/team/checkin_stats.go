@@ -0,0 +1,180 @@
+package team
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"github.com/microcosm-cc/bluemonday"
+)
+
+// checkinStatsRow is a team members checkin for a day used to build checkin stats
+type checkinStatsRow struct {
+	UserID   string
+	Date     string
+	GoalsMet bool
+	Blockers string
+}
+
+// CheckinStats gets the teams checkin participation, goals met and blocker analytics
+// between the from and to dates inclusive
+func (d *CheckinService) CheckinStats(ctx context.Context, TeamId string, From string, To string, TimeZone string) (*thunderdome.CheckinStats, error) {
+	Stats := &thunderdome.CheckinStats{
+		From:          From,
+		To:            To,
+		Participation: make([]*thunderdome.CheckinParticipation, 0),
+		GoalsMet:      make([]*thunderdome.CheckinGoalsMetDay, 0),
+		BlockerDays:   make([]string, 0),
+		Blockers:      make([]*thunderdome.CheckinBlocker, 0),
+	}
+	users := make(map[string]*thunderdome.TeamUser)
+	userOrder := make([]string, 0)
+
+	userRows, err := d.DB.QueryContext(ctx,
+		`SELECT u.id, u.name, COALESCE(u.email, ''), tu.role, u.avatar
+		FROM thunderdome.team_user tu
+		LEFT JOIN thunderdome.users u ON tu.user_id = u.id
+		WHERE tu.team_id = $1
+		ORDER BY tu.created_date;`,
+		TeamId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer userRows.Close()
+
+	for userRows.Next() {
+		var user thunderdome.TeamUser
+		if err := userRows.Scan(&user.Id, &user.Name, &user.GravatarHash, &user.Role, &user.Avatar); err != nil {
+			return nil, err
+		}
+		user.GravatarHash = db.CreateGravatarHash(user.GravatarHash)
+		users[user.Id] = &user
+		userOrder = append(userOrder, user.Id)
+	}
+
+	// only the latest checkin per member per day is considered
+	rows, err := d.DB.QueryContext(ctx,
+		`SELECT DISTINCT ON (tc.user_id, date(tc.created_date AT TIME ZONE $3))
+			tc.user_id, to_char(date(tc.created_date AT TIME ZONE $3), 'YYYY-MM-DD'),
+			COALESCE(tc.goals_met, false), COALESCE(tc.blockers, '')
+		FROM thunderdome.team_checkin tc
+		WHERE tc.team_id = $1
+		AND date(tc.created_date AT TIME ZONE $3) BETWEEN $2 AND $4
+		ORDER BY tc.user_id, date(tc.created_date AT TIME ZONE $3), tc.created_date DESC;`,
+		TeamId,
+		From,
+		TimeZone,
+		To,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	userCheckins := make(map[string][]*checkinStatsRow)
+	for rows.Next() {
+		var c checkinStatsRow
+		if err := rows.Scan(&c.UserID, &c.Date, &c.GoalsMet, &c.Blockers); err != nil {
+			return nil, err
+		}
+		// rich text blockers may contain only empty markup
+		c.Blockers = strings.TrimSpace(bluemonday.StrictPolicy().Sanitize(c.Blockers))
+
+		if _, ok := users[c.UserID]; !ok {
+			// former team member
+			users[c.UserID] = &thunderdome.TeamUser{Id: c.UserID}
+			userOrder = append(userOrder, c.UserID)
+		}
+		userCheckins[c.UserID] = append(userCheckins[c.UserID], &c)
+	}
+
+	days := make(map[string]*thunderdome.CheckinGoalsMetDay)
+	blockerDays := make(map[string]bool)
+	for _, checkins := range userCheckins {
+		for _, c := range checkins {
+			day, ok := days[c.Date]
+			if !ok {
+				day = &thunderdome.CheckinGoalsMetDay{Date: c.Date}
+				days[c.Date] = day
+			}
+			day.Checkins++
+			if c.GoalsMet {
+				day.GoalsMet++
+			}
+			if c.Blockers != "" {
+				blockerDays[c.Date] = true
+			}
+		}
+	}
+	Stats.CheckinDays = len(days)
+
+	for _, day := range days {
+		day.Rate = float64(day.GoalsMet) / float64(day.Checkins)
+		Stats.GoalsMet = append(Stats.GoalsMet, day)
+	}
+	sort.Slice(Stats.GoalsMet, func(i, j int) bool {
+		return Stats.GoalsMet[i].Date < Stats.GoalsMet[j].Date
+	})
+
+	for date := range blockerDays {
+		Stats.BlockerDays = append(Stats.BlockerDays, date)
+	}
+	sort.Strings(Stats.BlockerDays)
+
+	for _, userId := range userOrder {
+		checkins := userCheckins[userId]
+		p := &thunderdome.CheckinParticipation{
+			User:     users[userId],
+			Checkins: len(checkins),
+		}
+		if Stats.CheckinDays > 0 {
+			p.Rate = float64(p.Checkins) / float64(Stats.CheckinDays)
+		}
+		Stats.Participation = append(Stats.Participation, p)
+
+		Stats.Blockers = append(Stats.Blockers, getCheckinBlockers(users[userId], checkins)...)
+	}
+	sort.SliceStable(Stats.Blockers, func(i, j int) bool {
+		return Stats.Blockers[i].Checkins > Stats.Blockers[j].Checkins
+	})
+
+	return Stats, nil
+}
+
+// getCheckinBlockers gets the blockers carried across a members consecutive checkins (ordered by date)
+func getCheckinBlockers(User *thunderdome.TeamUser, checkins []*checkinStatsRow) []*thunderdome.CheckinBlocker {
+	blockers := make([]*thunderdome.CheckinBlocker, 0)
+	var current *thunderdome.CheckinBlocker
+
+	for i, c := range checkins {
+		if c.Blockers == "" {
+			current = nil
+			continue
+		}
+
+		if current == nil {
+			current = &thunderdome.CheckinBlocker{
+				User:      User,
+				FirstDate: c.Date,
+			}
+			blockers = append(blockers, current)
+		}
+		current.Blocker = c.Blockers
+		current.LastDate = c.Date
+		current.Checkins++
+		current.Ongoing = i == len(checkins)-1
+	}
+
+	for _, b := range blockers {
+		first, _ := time.Parse("2006-01-02", b.FirstDate)
+		last, _ := time.Parse("2006-01-02", b.LastDate)
+		b.Days = int(last.Sub(first).Hours()/24) + 1
+	}
+
+	return blockers
+}
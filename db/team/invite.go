@@ -0,0 +1,324 @@
+package team
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+	"github.com/lib/pq"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+
+	"go.uber.org/zap"
+)
+
+// InviteService represents a PostgreSQL implementation of thunderdome.InviteDataSvc.
+type InviteService struct {
+	DB     *sql.DB
+	Logger *otelzap.Logger
+}
+
+const inviteColumns = `id, invite_type, COALESCE(team_id, organization_id, department_id), email, role,
+	COALESCE(invited_by::text, ''), created_date, expire_date`
+
+// InviteCreate creates a pending invite replacing any existing invite for the email to the same target
+func (d *InviteService) InviteCreate(
+	ctx context.Context, InviteType string, TargetId string, Email string, Role string, InvitedBy string,
+) (*thunderdome.UserInvite, error) {
+	var invite thunderdome.UserInvite
+	Email = strings.ToLower(Email)
+
+	tx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM thunderdome.user_invite
+		WHERE invite_type = $1 AND COALESCE(team_id, organization_id, department_id) = $2 AND LOWER(email) = $3;`,
+		InviteType,
+		TargetId,
+		Email,
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("invite create delete existing query error", zap.Error(err))
+		return nil, err
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO thunderdome.user_invite (invite_type, team_id, organization_id, department_id, email, role, invited_by)
+		VALUES (
+			$1,
+			CASE WHEN $1 = 'team' THEN $2::uuid END,
+			CASE WHEN $1 = 'organization' THEN $2::uuid END,
+			CASE WHEN $1 = 'department' THEN $2::uuid END,
+			$3, $4, NULLIF($5, '')::uuid
+		)
+		RETURNING `+inviteColumns+`;`,
+		InviteType,
+		TargetId,
+		Email,
+		Role,
+		InvitedBy,
+	).Scan(
+		&invite.Id,
+		&invite.Type,
+		&invite.TargetId,
+		&invite.Email,
+		&invite.Role,
+		&invite.InvitedBy,
+		&invite.CreatedDate,
+		&invite.ExpireDate,
+	)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("invite create query error", zap.Error(err))
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &invite, nil
+}
+
+// InviteGet gets a pending invite that has not expired
+func (d *InviteService) InviteGet(ctx context.Context, InviteId string) (*thunderdome.UserInvite, error) {
+	var invite thunderdome.UserInvite
+
+	err := d.DB.QueryRowContext(ctx,
+		`SELECT `+inviteColumns+`
+		FROM thunderdome.user_invite
+		WHERE id = $1 AND expire_date > NOW();`,
+		InviteId,
+	).Scan(
+		&invite.Id,
+		&invite.Type,
+		&invite.TargetId,
+		&invite.Email,
+		&invite.Role,
+		&invite.InvitedBy,
+		&invite.CreatedDate,
+		&invite.ExpireDate,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("INVITE_NOT_FOUND")
+		}
+		d.Logger.Ctx(ctx).Error("invite get query error", zap.Error(err))
+		return nil, err
+	}
+
+	return &invite, nil
+}
+
+// InviteList gets a list of the targets pending invites that have not expired
+func (d *InviteService) InviteList(ctx context.Context, InviteType string, TargetId string) ([]*thunderdome.UserInvite, error) {
+	Invites := make([]*thunderdome.UserInvite, 0)
+
+	rows, err := d.DB.QueryContext(ctx,
+		`SELECT `+inviteColumns+`
+		FROM thunderdome.user_invite
+		WHERE invite_type = $1 AND COALESCE(team_id, organization_id, department_id) = $2 AND expire_date > NOW()
+		ORDER BY created_date;`,
+		InviteType,
+		TargetId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var invite thunderdome.UserInvite
+
+		if err := rows.Scan(
+			&invite.Id,
+			&invite.Type,
+			&invite.TargetId,
+			&invite.Email,
+			&invite.Role,
+			&invite.InvitedBy,
+			&invite.CreatedDate,
+			&invite.ExpireDate,
+		); err != nil {
+			d.Logger.Ctx(ctx).Error("invite list scan error", zap.Error(err))
+		} else {
+			Invites = append(Invites, &invite)
+		}
+	}
+
+	return Invites, nil
+}
+
+// InviteRevoke deletes a targets pending invite
+func (d *InviteService) InviteRevoke(ctx context.Context, InviteType string, TargetId string, InviteId string) error {
+	if _, err := d.DB.ExecContext(ctx,
+		`DELETE FROM thunderdome.user_invite
+		WHERE id = $3 AND invite_type = $1 AND COALESCE(team_id, organization_id, department_id) = $2;`,
+		InviteType,
+		TargetId,
+		InviteId,
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("invite revoke query error", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// inviteOrganizationID gets the organization the invites team, organization or department belongs to,
+// empty for teams outside an organization
+func inviteOrganizationID(ctx context.Context, tx *sql.Tx, Invite *thunderdome.UserInvite) (string, error) {
+	var OrgID sql.NullString
+	var err error
+
+	switch Invite.Type {
+	case thunderdome.InviteTypeOrganization:
+		return Invite.TargetId, nil
+	case thunderdome.InviteTypeDepartment:
+		err = tx.QueryRowContext(ctx,
+			`SELECT organization_id FROM thunderdome.organization_department WHERE id = $1;`,
+			Invite.TargetId,
+		).Scan(&OrgID)
+	default:
+		err = tx.QueryRowContext(ctx,
+			`SELECT COALESCE(
+				(SELECT ot.organization_id FROM thunderdome.organization_team ot WHERE ot.team_id = $1),
+				(SELECT od.organization_id FROM thunderdome.department_team dt
+				JOIN thunderdome.organization_department od ON od.id = dt.department_id
+				WHERE dt.team_id = $1)
+			);`,
+			Invite.TargetId,
+		).Scan(&OrgID)
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+
+	return OrgID.String, nil
+}
+
+// InviteAcceptByEmail adds the user to the teams, organizations, and departments
+// of the emails pending invites, then removes the emails invites.
+// Department invites also add the user to the departments organization as a member.
+// Invites to an organization, or its departments and teams, are only accepted when the
+// organizations email domain policy allows the user, the others stay pending e.g. until the email is verified
+func (d *InviteService) InviteAcceptByEmail(ctx context.Context, UserId string, UserEmail string) ([]*thunderdome.UserInvite, error) {
+	Accepted := make([]*thunderdome.UserInvite, 0)
+	UserEmail = strings.ToLower(UserEmail)
+
+	tx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT `+inviteColumns+`
+		FROM thunderdome.user_invite
+		WHERE LOWER(email) = $1 AND expire_date > NOW()
+		FOR UPDATE;`,
+		UserEmail,
+	)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("invite accept query error", zap.Error(err))
+		return nil, err
+	}
+
+	Invites := make([]*thunderdome.UserInvite, 0)
+	for rows.Next() {
+		var invite thunderdome.UserInvite
+
+		if err := rows.Scan(
+			&invite.Id,
+			&invite.Type,
+			&invite.TargetId,
+			&invite.Email,
+			&invite.Role,
+			&invite.InvitedBy,
+			&invite.CreatedDate,
+			&invite.ExpireDate,
+		); err != nil {
+			d.Logger.Ctx(ctx).Error("invite accept scan error", zap.Error(err))
+		} else {
+			Invites = append(Invites, &invite)
+		}
+	}
+	rows.Close()
+
+	for _, invite := range Invites {
+		OrgID, err := inviteOrganizationID(ctx, tx, invite)
+		if err != nil {
+			d.Logger.Ctx(ctx).Error("invite accept organization query error", zap.Error(err))
+			return nil, err
+		}
+		if OrgID != "" {
+			allowed, err := organizationUserAllowed(ctx, tx, OrgID, UserId)
+			if err != nil {
+				d.Logger.Ctx(ctx).Error("invite accept organization policy query error", zap.Error(err))
+				return nil, err
+			}
+			if !allowed {
+				continue
+			}
+		}
+
+		switch invite.Type {
+		case thunderdome.InviteTypeTeam:
+			_, err = tx.ExecContext(ctx,
+				`INSERT INTO thunderdome.team_user (team_id, user_id, role) VALUES ($1, $2, $3)
+				ON CONFLICT DO NOTHING;`,
+				invite.TargetId, UserId, invite.Role,
+			)
+		case thunderdome.InviteTypeOrganization:
+			_, err = tx.ExecContext(ctx,
+				`INSERT INTO thunderdome.organization_user (organization_id, user_id, role) VALUES ($1, $2, $3)
+				ON CONFLICT DO NOTHING;`,
+				invite.TargetId, UserId, invite.Role,
+			)
+		case thunderdome.InviteTypeDepartment:
+			_, err = tx.ExecContext(ctx,
+				`INSERT INTO thunderdome.organization_user (organization_id, user_id, role)
+				SELECT od.organization_id, $2, 'MEMBER' FROM thunderdome.organization_department od WHERE od.id = $1
+				ON CONFLICT DO NOTHING;`,
+				invite.TargetId, UserId,
+			)
+			if err == nil {
+				_, err = tx.ExecContext(ctx,
+					`INSERT INTO thunderdome.department_user (department_id, user_id, role) VALUES ($1, $2, $3)
+					ON CONFLICT DO NOTHING;`,
+					invite.TargetId, UserId, invite.Role,
+				)
+			}
+		}
+		if err != nil {
+			d.Logger.Ctx(ctx).Error("invite accept add user query error", zap.Error(err))
+			return nil, err
+		}
+
+		Accepted = append(Accepted, invite)
+	}
+
+	AcceptedIds := make([]string, 0, len(Accepted))
+	for _, invite := range Accepted {
+		AcceptedIds = append(AcceptedIds, invite.Id)
+	}
+
+	// expired invites are cleaned up along with the accepted ones
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM thunderdome.user_invite WHERE LOWER(email) = $1 AND (expire_date <= NOW() OR id = ANY($2));`,
+		UserEmail,
+		pq.StringArray(AcceptedIds),
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("invite accept delete query error", zap.Error(err))
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return Accepted, nil
+}
@@ -124,7 +124,7 @@ func (d *OrganizationService) OrganizationCreate(ctx context.Context, UserID str
 func (d *OrganizationService) OrganizationUserList(ctx context.Context, OrgID string, Limit int, Offset int) []*thunderdome.OrganizationUser {
 	var users = make([]*thunderdome.OrganizationUser, 0)
 	rows, err := d.DB.QueryContext(ctx,
-		`SELECT u.id, u.name, COALESCE(u.email, ''), ou.role, u.avatar
+		`SELECT u.id, u.name, COALESCE(u.email, ''), ou.role, u.avatar, u.type
         FROM thunderdome.organization_user ou
         LEFT JOIN thunderdome.users u ON ou.user_id = u.id
         WHERE ou.organization_id = $1
@@ -147,6 +147,7 @@ func (d *OrganizationService) OrganizationUserList(ctx context.Context, OrgID st
 				&usr.Email,
 				&usr.Role,
 				&usr.Avatar,
+				&usr.Type,
 			); err != nil {
 				d.Logger.Ctx(ctx).Error("organization_user_list query scan error", zap.Error(err))
 			} else {
@@ -161,8 +162,39 @@ func (d *OrganizationService) OrganizationUserList(ctx context.Context, OrgID st
 	return users
 }
 
-// OrganizationAddUser adds a user to an organization
+// rowQuerier is implemented by both *sql.DB and *sql.Tx
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// organizationUserAllowed checks the organizations email domain policy allows the user to be a member,
+// users must have a verified email on one of the allowed domains when the policy has any
+func organizationUserAllowed(ctx context.Context, q rowQuerier, OrgID string, UserID string) (bool, error) {
+	var allowed bool
+	err := q.QueryRowContext(ctx,
+		`SELECT op.organization_id IS NULL OR cardinality(op.allowed_domains) = 0
+			OR (u.verified AND LOWER(split_part(u.email, '@', 2)) = ANY(op.allowed_domains))
+		FROM thunderdome.users u
+		LEFT JOIN thunderdome.organization_policy op ON op.organization_id = $1
+		WHERE u.id = $2;`,
+		OrgID,
+		UserID,
+	).Scan(&allowed)
+
+	return allowed, err
+}
+
+// OrganizationAddUser adds a user to an organization when allowed by the organizations email domain policy
 func (d *OrganizationService) OrganizationAddUser(ctx context.Context, OrgID string, UserID string, Role string) (string, error) {
+	allowed, policyErr := organizationUserAllowed(ctx, d.DB, OrgID, UserID)
+	if policyErr != nil {
+		d.Logger.Ctx(ctx).Error("Unable to check organization policy for user", zap.Error(policyErr))
+		return "", policyErr
+	}
+	if !allowed {
+		return "", errors.New("ORGANIZATION_EMAIL_DOMAIN_NOT_ALLOWED")
+	}
+
 	_, err := d.DB.ExecContext(ctx,
 		`INSERT INTO thunderdome.organization_user (organization_id, user_id, role) VALUES ($1, $2, $3);`,
 		OrgID,
@@ -0,0 +1,193 @@
+package team
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// OrganizationDomainList gets a list of the organizations claimed domains
+func (d *OrganizationService) OrganizationDomainList(ctx context.Context, OrgID string) ([]*thunderdome.OrganizationDomain, error) {
+	Domains := make([]*thunderdome.OrganizationDomain, 0)
+
+	rows, err := d.DB.QueryContext(ctx,
+		`SELECT id, organization_id, domain, verification_token, verified_date, created_date
+		FROM thunderdome.organization_domain
+		WHERE organization_id = $1
+		ORDER BY domain;`,
+		OrgID,
+	)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("organization domain list query error", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var od thunderdome.OrganizationDomain
+
+		if err := rows.Scan(
+			&od.Id,
+			&od.OrgId,
+			&od.Domain,
+			&od.VerificationToken,
+			&od.VerifiedDate,
+			&od.CreatedDate,
+		); err != nil {
+			d.Logger.Ctx(ctx).Error("organization domain list scan error", zap.Error(err))
+		} else {
+			Domains = append(Domains, &od)
+		}
+	}
+
+	return Domains, nil
+}
+
+// OrganizationDomainGet gets an organizations claimed domain
+func (d *OrganizationService) OrganizationDomainGet(ctx context.Context, OrgID string, DomainID string) (*thunderdome.OrganizationDomain, error) {
+	var od thunderdome.OrganizationDomain
+
+	err := d.DB.QueryRowContext(ctx,
+		`SELECT id, organization_id, domain, verification_token, verified_date, created_date
+		FROM thunderdome.organization_domain
+		WHERE organization_id = $1 AND id = $2;`,
+		OrgID,
+		DomainID,
+	).Scan(
+		&od.Id,
+		&od.OrgId,
+		&od.Domain,
+		&od.VerificationToken,
+		&od.VerifiedDate,
+		&od.CreatedDate,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("DOMAIN_NOT_FOUND")
+		}
+		d.Logger.Ctx(ctx).Error("organization domain get query error", zap.Error(err))
+		return nil, err
+	}
+
+	return &od, nil
+}
+
+// OrganizationDomainCreate claims the domain for the organization, it has to be verified before the
+// organizations policy can use it and can't be claimed once another organization has verified it
+func (d *OrganizationService) OrganizationDomainCreate(ctx context.Context, OrgID string, Domain string) (*thunderdome.OrganizationDomain, error) {
+	token, tokenErr := db.RandomString(32)
+	if tokenErr != nil {
+		d.Logger.Ctx(ctx).Error("error generating domain verification token", zap.Error(tokenErr))
+		return nil, errors.New("error generating domain verification token")
+	}
+
+	od := &thunderdome.OrganizationDomain{
+		OrgId:             OrgID,
+		Domain:            strings.ToLower(strings.TrimSpace(Domain)),
+		VerificationToken: token,
+	}
+
+	err := d.DB.QueryRowContext(ctx,
+		`INSERT INTO thunderdome.organization_domain (organization_id, domain, verification_token)
+		SELECT $1, $2, $3
+		WHERE NOT EXISTS (
+			SELECT 1 FROM thunderdome.organization_domain WHERE domain = $2 AND verified_date IS NOT NULL
+		)
+		RETURNING id, created_date;`,
+		OrgID,
+		od.Domain,
+		token,
+	).Scan(&od.Id, &od.CreatedDate)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("DOMAIN_ALREADY_VERIFIED")
+		}
+		if db.UniqueViolation(err) {
+			return nil, errors.New("DOMAIN_ALREADY_ADDED")
+		}
+		d.Logger.Ctx(ctx).Error("organization domain create query error", zap.Error(err))
+		return nil, err
+	}
+
+	return od, nil
+}
+
+// OrganizationDomainVerify marks the organizations domain as verified, the caller is expected to have checked
+// the domains verification record
+func (d *OrganizationService) OrganizationDomainVerify(ctx context.Context, OrgID string, DomainID string) (*thunderdome.OrganizationDomain, error) {
+	var od thunderdome.OrganizationDomain
+
+	err := d.DB.QueryRowContext(ctx,
+		`UPDATE thunderdome.organization_domain SET verified_date = COALESCE(verified_date, NOW())
+		WHERE organization_id = $1 AND id = $2
+		RETURNING id, organization_id, domain, verification_token, verified_date, created_date;`,
+		OrgID,
+		DomainID,
+	).Scan(
+		&od.Id,
+		&od.OrgId,
+		&od.Domain,
+		&od.VerificationToken,
+		&od.VerifiedDate,
+		&od.CreatedDate,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("DOMAIN_NOT_FOUND")
+		}
+		if db.UniqueViolation(err) {
+			return nil, errors.New("DOMAIN_ALREADY_VERIFIED")
+		}
+		d.Logger.Ctx(ctx).Error("organization domain verify query error", zap.Error(err))
+		return nil, err
+	}
+
+	return &od, nil
+}
+
+// OrganizationDomainDelete removes the organizations claim on the domain along with it from the organizations policy
+func (d *OrganizationService) OrganizationDomainDelete(ctx context.Context, OrgID string, DomainID string) error {
+	tx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("organization domain delete begin error", zap.Error(err))
+		return err
+	}
+
+	var Domain string
+	err = tx.QueryRowContext(ctx,
+		`DELETE FROM thunderdome.organization_domain WHERE organization_id = $1 AND id = $2 RETURNING domain;`,
+		OrgID,
+		DomainID,
+	).Scan(&Domain)
+	if err != nil {
+		_ = tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("DOMAIN_NOT_FOUND")
+		}
+		d.Logger.Ctx(ctx).Error("organization domain delete query error", zap.Error(err))
+		return err
+	}
+
+	// auto join is turned off with the last domain so adding a domain later doesn't start auto joining users
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE thunderdome.organization_policy
+		SET allowed_domains = array_remove(allowed_domains, $2),
+		auto_join = auto_join AND cardinality(array_remove(allowed_domains, $2)) > 0,
+		updated_date = NOW()
+		WHERE organization_id = $1 AND $2 = ANY(allowed_domains);`,
+		OrgID,
+		Domain,
+	); err != nil {
+		_ = tx.Rollback()
+		d.Logger.Ctx(ctx).Error("organization domain delete policy query error", zap.Error(err))
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,132 @@
+package team
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+	"github.com/lib/pq"
+
+	"go.uber.org/zap"
+)
+
+// OrganizationPolicyGet gets the organizations security policy, organizations without one get the default open policy
+func (d *OrganizationService) OrganizationPolicyGet(ctx context.Context, OrgID string) (*thunderdome.OrganizationPolicy, error) {
+	var p = &thunderdome.OrganizationPolicy{OrgId: OrgID, AllowedDomains: make([]string, 0)}
+	var domains pq.StringArray
+
+	err := d.DB.QueryRowContext(ctx,
+		`SELECT require_mfa, allowed_domains, auto_join, session_lifetime_hours, updated_date
+		FROM thunderdome.organization_policy
+		WHERE organization_id = $1;`,
+		OrgID,
+	).Scan(
+		&p.RequireMFA,
+		&domains,
+		&p.AutoJoin,
+		&p.SessionLifetimeHours,
+		&p.UpdatedDate,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return p, nil
+		}
+		d.Logger.Ctx(ctx).Error("organization policy get query error", zap.Error(err))
+		return nil, err
+	}
+	p.AllowedDomains = domains
+
+	return p, nil
+}
+
+// OrganizationPolicyUpdate creates or updates the organizations security policy, the allowed domains must be
+// domains the organization has verified
+func (d *OrganizationService) OrganizationPolicyUpdate(
+	ctx context.Context, OrgID string, RequireMFA bool, AllowedDomains []string, AutoJoin bool, SessionLifetimeHours int,
+) (*thunderdome.OrganizationPolicy, error) {
+	var p = &thunderdome.OrganizationPolicy{OrgId: OrgID}
+	var domains pq.StringArray
+
+	Domains := make([]string, 0, len(AllowedDomains))
+	seen := make(map[string]bool, len(AllowedDomains))
+	for _, domain := range AllowedDomains {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		if !seen[domain] {
+			seen[domain] = true
+			Domains = append(Domains, domain)
+		}
+	}
+
+	if len(Domains) > 0 {
+		var verified int
+		if err := d.DB.QueryRowContext(ctx,
+			`SELECT count(*) FROM thunderdome.organization_domain
+			WHERE organization_id = $1 AND domain = ANY($2) AND verified_date IS NOT NULL;`,
+			OrgID,
+			pq.StringArray(Domains),
+		).Scan(&verified); err != nil {
+			d.Logger.Ctx(ctx).Error("organization policy verified domains query error", zap.Error(err))
+			return nil, err
+		}
+		if verified != len(Domains) {
+			return nil, errors.New("DOMAIN_NOT_VERIFIED")
+		}
+	}
+
+	err := d.DB.QueryRowContext(ctx,
+		`INSERT INTO thunderdome.organization_policy
+		(organization_id, require_mfa, allowed_domains, auto_join, session_lifetime_hours)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (organization_id) DO UPDATE SET
+		require_mfa = EXCLUDED.require_mfa, allowed_domains = EXCLUDED.allowed_domains,
+		auto_join = EXCLUDED.auto_join, session_lifetime_hours = EXCLUDED.session_lifetime_hours,
+		updated_date = NOW()
+		RETURNING require_mfa, allowed_domains, auto_join, session_lifetime_hours, updated_date;`,
+		OrgID,
+		RequireMFA,
+		pq.StringArray(Domains),
+		AutoJoin,
+		SessionLifetimeHours,
+	).Scan(
+		&p.RequireMFA,
+		&domains,
+		&p.AutoJoin,
+		&p.SessionLifetimeHours,
+		&p.UpdatedDate,
+	)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("organization policy update query error", zap.Error(err))
+		return nil, err
+	}
+	p.AllowedDomains = domains
+
+	return p, nil
+}
+
+// OrganizationPolicyAutoJoin adds the user as a member of the organizations that auto join users on the emails domain
+// and have verified it, the email is expected to be verified
+func (d *OrganizationService) OrganizationPolicyAutoJoin(ctx context.Context, UserID string, UserEmail string) error {
+	at := strings.LastIndex(UserEmail, "@")
+	if at == -1 {
+		return nil
+	}
+
+	if _, err := d.DB.ExecContext(ctx,
+		`INSERT INTO thunderdome.organization_user (organization_id, user_id, role)
+		SELECT op.organization_id, $1, 'MEMBER'
+		FROM thunderdome.organization_policy op
+		JOIN thunderdome.organization_domain od ON od.organization_id = op.organization_id
+			AND od.domain = $2 AND od.verified_date IS NOT NULL
+		WHERE op.auto_join = true AND $2 = ANY(op.allowed_domains)
+		ON CONFLICT DO NOTHING;`,
+		UserID,
+		strings.ToLower(UserEmail[at+1:]),
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("organization policy auto join query error", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,457 @@
+package team
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+
+	"go.uber.org/zap"
+)
+
+// SCIMService represents a PostgreSQL implementation of thunderdome.SCIMDataSvc.
+type SCIMService struct {
+	DB     *sql.DB
+	Logger *otelzap.Logger
+}
+
+// SCIMTokenCreate generates a new SCIM bearer token for the organization, only the tokens hash is stored
+func (d *SCIMService) SCIMTokenCreate(ctx context.Context, OrgId string, Name string, UserId string) (*thunderdome.SCIMToken, error) {
+	prefix, prefixErr := db.RandomString(8)
+	if prefixErr != nil {
+		d.Logger.Ctx(ctx).Error("error generating scim token prefix", zap.Error(prefixErr))
+		return nil, errors.New("error generating scim token prefix")
+	}
+
+	secret, secretErr := db.RandomString(32)
+	if secretErr != nil {
+		d.Logger.Ctx(ctx).Error("error generating scim token secret", zap.Error(secretErr))
+		return nil, errors.New("error generating scim token secret")
+	}
+
+	t := &thunderdome.SCIMToken{
+		OrgId:     OrgId,
+		Name:      Name,
+		Prefix:    prefix,
+		Token:     prefix + "." + secret,
+		CreatedBy: UserId,
+	}
+
+	err := d.DB.QueryRowContext(ctx,
+		`INSERT INTO thunderdome.organization_scim_token (organization_id, name, prefix, token_hash, created_by)
+		VALUES ($1, $2, $3, $4, NULLIF($5, '')::uuid)
+		RETURNING id, created_date;`,
+		OrgId,
+		Name,
+		prefix,
+		db.HashString(t.Token),
+		UserId,
+	).Scan(&t.Id, &t.CreatedDate)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("scim token create query error", zap.Error(err))
+		return nil, errors.New("unable to create new scim token")
+	}
+
+	return t, nil
+}
+
+// SCIMTokenList gets a list of the organizations SCIM tokens
+func (d *SCIMService) SCIMTokenList(ctx context.Context, OrgId string) ([]*thunderdome.SCIMToken, error) {
+	Tokens := make([]*thunderdome.SCIMToken, 0)
+
+	rows, err := d.DB.QueryContext(ctx,
+		`SELECT id, organization_id, name, prefix, COALESCE(created_by::text, ''), created_date, last_used_date
+		FROM thunderdome.organization_scim_token
+		WHERE organization_id = $1
+		ORDER BY created_date;`,
+		OrgId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t thunderdome.SCIMToken
+
+		if err := rows.Scan(
+			&t.Id,
+			&t.OrgId,
+			&t.Name,
+			&t.Prefix,
+			&t.CreatedBy,
+			&t.CreatedDate,
+			&t.LastUsedDate,
+		); err != nil {
+			d.Logger.Ctx(ctx).Error("scim token list scan error", zap.Error(err))
+		} else {
+			Tokens = append(Tokens, &t)
+		}
+	}
+
+	return Tokens, nil
+}
+
+// SCIMTokenDelete deletes an organizations SCIM token
+func (d *SCIMService) SCIMTokenDelete(ctx context.Context, OrgId string, TokenId string) error {
+	if _, err := d.DB.ExecContext(ctx,
+		`DELETE FROM thunderdome.organization_scim_token WHERE organization_id = $1 AND id = $2;`,
+		OrgId,
+		TokenId,
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("scim token delete query error", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// SCIMTokenOrganization gets the ID of the organization the SCIM token belongs to
+func (d *SCIMService) SCIMTokenOrganization(ctx context.Context, Token string) (string, error) {
+	var OrgId string
+
+	err := d.DB.QueryRowContext(ctx,
+		`UPDATE thunderdome.organization_scim_token SET last_used_date = NOW()
+		WHERE token_hash = $1
+		RETURNING organization_id;`,
+		db.HashString(Token),
+	).Scan(&OrgId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", errors.New("INVALID_SCIM_TOKEN")
+		}
+		d.Logger.Ctx(ctx).Error("scim token organization query error", zap.Error(err))
+		return "", err
+	}
+
+	return OrgId, nil
+}
+
+// scimUsersQuery selects the IDs of the users the SCIM client sees for organization $1,
+// its members and the users it provisioned that have since been deactivated
+const scimUsersQuery = `SELECT ou.user_id FROM thunderdome.organization_user ou WHERE ou.organization_id = $1
+		UNION SELECT su.user_id FROM thunderdome.organization_scim_user su WHERE su.organization_id = $1`
+
+// scimUserInactiveColumn selects whether the user is inactive for organization $1,
+// either their account is disabled or they are no longer a member
+const scimUserInactiveColumn = `(u.disabled OR NOT EXISTS (
+			SELECT 1 FROM thunderdome.organization_user ou WHERE ou.organization_id = $1 AND ou.user_id = u.id
+		))`
+
+// SCIMUserList gets a list of the organizations users excluding service accounts, optionally filtered by email
+func (d *SCIMService) SCIMUserList(ctx context.Context, OrgId string, Email string, Limit int, Offset int) ([]*thunderdome.User, int, error) {
+	Users := make([]*thunderdome.User, 0)
+	var Count int
+
+	err := d.DB.QueryRowContext(ctx,
+		`SELECT count(u.id)
+		FROM thunderdome.users u
+		WHERE u.id IN (`+scimUsersQuery+`) AND u.type <> 'SERVICE' AND ($2 = '' OR LOWER(u.email) = LOWER($2));`,
+		OrgId,
+		Email,
+	).Scan(&Count)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("scim user list count query error", zap.Error(err))
+		return nil, 0, err
+	}
+
+	if Count == 0 {
+		return Users, Count, nil
+	}
+
+	rows, err := d.DB.QueryContext(ctx,
+		`SELECT u.id, u.name, COALESCE(u.email, ''), `+scimUserInactiveColumn+`, u.created_date, u.updated_date
+		FROM thunderdome.users u
+		WHERE u.id IN (`+scimUsersQuery+`) AND u.type <> 'SERVICE' AND ($2 = '' OR LOWER(u.email) = LOWER($2))
+		ORDER BY u.created_date, u.id
+		LIMIT $3
+		OFFSET $4;`,
+		OrgId,
+		Email,
+		Limit,
+		Offset,
+	)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("scim user list query error", zap.Error(err))
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var u thunderdome.User
+
+		if err := rows.Scan(
+			&u.Id,
+			&u.Name,
+			&u.Email,
+			&u.Disabled,
+			&u.CreatedDate,
+			&u.UpdatedDate,
+		); err != nil {
+			d.Logger.Ctx(ctx).Error("scim user list scan error", zap.Error(err))
+		} else {
+			Users = append(Users, &u)
+		}
+	}
+
+	return Users, Count, nil
+}
+
+// SCIMUserGet gets a user of the organization, users the organization provisioned that are
+// no longer members are returned as disabled
+func (d *SCIMService) SCIMUserGet(ctx context.Context, OrgId string, UserId string) (*thunderdome.User, error) {
+	var u thunderdome.User
+
+	err := d.DB.QueryRowContext(ctx,
+		`SELECT u.id, u.name, COALESCE(u.email, ''), `+scimUserInactiveColumn+`, u.created_date, u.updated_date
+		FROM thunderdome.users u
+		WHERE u.id IN (`+scimUsersQuery+`) AND u.id = $2;`,
+		OrgId,
+		UserId,
+	).Scan(
+		&u.Id,
+		&u.Name,
+		&u.Email,
+		&u.Disabled,
+		&u.CreatedDate,
+		&u.UpdatedDate,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("USER_NOT_FOUND")
+		}
+		d.Logger.Ctx(ctx).Error("scim user get query error", zap.Error(err))
+		return nil, err
+	}
+
+	return &u, nil
+}
+
+// SCIMUserProvision records that the organization provisioned the user
+func (d *SCIMService) SCIMUserProvision(ctx context.Context, OrgId string, UserId string) error {
+	if _, err := d.DB.ExecContext(ctx,
+		`INSERT INTO thunderdome.organization_scim_user (organization_id, user_id) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING;`,
+		OrgId,
+		UserId,
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("scim user provision query error", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// SCIMUserProvisioned gets whether the organization provisioned the user
+func (d *SCIMService) SCIMUserProvisioned(ctx context.Context, OrgId string, UserId string) (bool, error) {
+	var provisioned bool
+
+	err := d.DB.QueryRowContext(ctx,
+		`SELECT EXISTS (
+			SELECT 1 FROM thunderdome.organization_scim_user WHERE organization_id = $1 AND user_id = $2
+		);`,
+		OrgId,
+		UserId,
+	).Scan(&provisioned)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("scim user provisioned query error", zap.Error(err))
+		return false, err
+	}
+
+	return provisioned, nil
+}
+
+// SCIMUserManaged gets whether the organization manages the users account, it provisioned the
+// user and the user doesn't belong to any other organization
+func (d *SCIMService) SCIMUserManaged(ctx context.Context, OrgId string, UserId string) (bool, error) {
+	var managed bool
+
+	err := d.DB.QueryRowContext(ctx,
+		`SELECT EXISTS (
+			SELECT 1 FROM thunderdome.organization_scim_user WHERE organization_id = $1 AND user_id = $2
+		) AND NOT EXISTS (
+			SELECT 1 FROM thunderdome.organization_user WHERE organization_id <> $1 AND user_id = $2
+		);`,
+		OrgId,
+		UserId,
+	).Scan(&managed)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("scim user managed query error", zap.Error(err))
+		return false, err
+	}
+
+	return managed, nil
+}
+
+// SCIMUserActivate adds the user back to the organization as a member when they aren't one
+func (d *SCIMService) SCIMUserActivate(ctx context.Context, OrgId string, UserId string) error {
+	allowed, policyErr := organizationUserAllowed(ctx, d.DB, OrgId, UserId)
+	if policyErr != nil {
+		d.Logger.Ctx(ctx).Error("scim user activate policy query error", zap.Error(policyErr))
+		return policyErr
+	}
+	if !allowed {
+		return errors.New("ORGANIZATION_EMAIL_DOMAIN_NOT_ALLOWED")
+	}
+
+	if _, err := d.DB.ExecContext(ctx,
+		`INSERT INTO thunderdome.organization_user (organization_id, user_id, role) VALUES ($1, $2, 'MEMBER')
+		ON CONFLICT DO NOTHING;`,
+		OrgId,
+		UserId,
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("scim user activate query error", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// SCIMUserDeactivate removes the user from the organization including its departments and teams,
+// the user stays visible to the SCIM client as inactive when the organization provisioned them
+func (d *SCIMService) SCIMUserDeactivate(ctx context.Context, OrgId string, UserId string) error {
+	if _, err := d.DB.ExecContext(ctx,
+		`CALL thunderdome.organization_user_remove($1, $2);`,
+		OrgId,
+		UserId,
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("scim user deactivate query error", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// SCIMUserDelete forgets that the organization provisioned the user and removes them from the organization,
+// not in a transaction as the remove procedure commits itself
+func (d *SCIMService) SCIMUserDelete(ctx context.Context, OrgId string, UserId string) error {
+	if _, err := d.DB.ExecContext(ctx,
+		`DELETE FROM thunderdome.organization_scim_user WHERE organization_id = $1 AND user_id = $2;`,
+		OrgId,
+		UserId,
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("scim user delete query error", zap.Error(err))
+		return err
+	}
+
+	return d.SCIMUserDeactivate(ctx, OrgId, UserId)
+}
+
+// SCIMGroupList gets a list of the organizations teams, optionally filtered by name
+func (d *SCIMService) SCIMGroupList(ctx context.Context, OrgId string, Name string, Limit int, Offset int) ([]*thunderdome.Team, int, error) {
+	Teams := make([]*thunderdome.Team, 0)
+	var Count int
+
+	err := d.DB.QueryRowContext(ctx,
+		`SELECT count(ot.team_id)
+		FROM thunderdome.organization_team ot
+		JOIN thunderdome.team t ON t.id = ot.team_id
+		WHERE ot.organization_id = $1 AND ($2 = '' OR t.name = $2);`,
+		OrgId,
+		Name,
+	).Scan(&Count)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("scim group list count query error", zap.Error(err))
+		return nil, 0, err
+	}
+
+	if Count == 0 {
+		return Teams, Count, nil
+	}
+
+	rows, err := d.DB.QueryContext(ctx,
+		`SELECT t.id, t.name, t.created_date, t.updated_date
+		FROM thunderdome.organization_team ot
+		JOIN thunderdome.team t ON t.id = ot.team_id
+		WHERE ot.organization_id = $1 AND ($2 = '' OR t.name = $2)
+		ORDER BY t.created_date
+		LIMIT $3
+		OFFSET $4;`,
+		OrgId,
+		Name,
+		Limit,
+		Offset,
+	)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("scim group list query error", zap.Error(err))
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t thunderdome.Team
+
+		if err := rows.Scan(
+			&t.Id,
+			&t.Name,
+			&t.CreatedDate,
+			&t.UpdatedDate,
+		); err != nil {
+			d.Logger.Ctx(ctx).Error("scim group list scan error", zap.Error(err))
+		} else {
+			Teams = append(Teams, &t)
+		}
+	}
+
+	return Teams, Count, nil
+}
+
+// SCIMGroupGet gets a team of the organization
+func (d *SCIMService) SCIMGroupGet(ctx context.Context, OrgId string, TeamId string) (*thunderdome.Team, error) {
+	var t thunderdome.Team
+
+	err := d.DB.QueryRowContext(ctx,
+		`SELECT t.id, t.name, t.created_date, t.updated_date
+		FROM thunderdome.organization_team ot
+		JOIN thunderdome.team t ON t.id = ot.team_id
+		WHERE ot.organization_id = $1 AND ot.team_id = $2;`,
+		OrgId,
+		TeamId,
+	).Scan(
+		&t.Id,
+		&t.Name,
+		&t.CreatedDate,
+		&t.UpdatedDate,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("TEAM_NOT_FOUND")
+		}
+		d.Logger.Ctx(ctx).Error("scim group get query error", zap.Error(err))
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// SCIMGroupRename updates the name of a team of the organization
+func (d *SCIMService) SCIMGroupRename(ctx context.Context, OrgId string, TeamId string, Name string) (*thunderdome.Team, error) {
+	var t thunderdome.Team
+
+	err := d.DB.QueryRowContext(ctx,
+		`UPDATE thunderdome.team t SET name = $3, updated_date = NOW()
+		FROM thunderdome.organization_team ot
+		WHERE ot.team_id = t.id AND ot.organization_id = $1 AND t.id = $2
+		RETURNING t.id, t.name, t.created_date, t.updated_date;`,
+		OrgId,
+		TeamId,
+		Name,
+	).Scan(
+		&t.Id,
+		&t.Name,
+		&t.CreatedDate,
+		&t.UpdatedDate,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("TEAM_NOT_FOUND")
+		}
+		d.Logger.Ctx(ctx).Error("scim group rename query error", zap.Error(err))
+		return nil, err
+	}
+
+	return &t, nil
+}
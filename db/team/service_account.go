@@ -0,0 +1,267 @@
+package team
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+
+	"go.uber.org/zap"
+)
+
+// ServiceAccountService represents a PostgreSQL implementation of thunderdome.ServiceAccountDataSvc.
+type ServiceAccountService struct {
+	DB     *sql.DB
+	Logger *otelzap.Logger
+}
+
+// ServiceAccountCreate creates a service account user owned by the organization as an organization member
+func (d *ServiceAccountService) ServiceAccountCreate(ctx context.Context, OrgId string, Name string, CreatedBy string) (*thunderdome.ServiceAccount, error) {
+	sa := &thunderdome.ServiceAccount{
+		OrgId:     OrgId,
+		Name:      Name,
+		CreatedBy: CreatedBy,
+		Teams:     make([]*thunderdome.ServiceAccountTeam, 0),
+	}
+
+	tx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("service account create begin transaction error", zap.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO thunderdome.users (name, type, verified) VALUES ($1, $2, true) RETURNING id;`,
+		Name,
+		thunderdome.ServiceAccountUserType,
+	).Scan(&sa.Id)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("service account user create query error", zap.Error(err))
+		return nil, errors.New("unable to create new service account")
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO thunderdome.organization_service_account (user_id, organization_id, created_by)
+		VALUES ($1, $2, NULLIF($3, '')::uuid)
+		RETURNING created_date;`,
+		sa.Id,
+		OrgId,
+		CreatedBy,
+	).Scan(&sa.CreatedDate)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("service account create query error", zap.Error(err))
+		return nil, errors.New("unable to create new service account")
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO thunderdome.organization_user (organization_id, user_id, role) VALUES ($1, $2, 'MEMBER');`,
+		OrgId,
+		sa.Id,
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("service account organization user add query error", zap.Error(err))
+		return nil, errors.New("unable to create new service account")
+	}
+
+	if err := tx.Commit(); err != nil {
+		d.Logger.Ctx(ctx).Error("service account create commit error", zap.Error(err))
+		return nil, err
+	}
+
+	return sa, nil
+}
+
+// ServiceAccountList gets a list of the organizations service accounts along with their teams
+func (d *ServiceAccountService) ServiceAccountList(ctx context.Context, OrgId string) ([]*thunderdome.ServiceAccount, error) {
+	Accounts := make([]*thunderdome.ServiceAccount, 0)
+	AccountsById := make(map[string]*thunderdome.ServiceAccount)
+
+	rows, err := d.DB.QueryContext(ctx,
+		`SELECT u.id, sa.organization_id, u.name, COALESCE(sa.created_by::text, ''), sa.created_date
+		FROM thunderdome.organization_service_account sa
+		JOIN thunderdome.users u ON u.id = sa.user_id
+		WHERE sa.organization_id = $1
+		ORDER BY sa.created_date;`,
+		OrgId,
+	)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("service account list query error", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		sa := thunderdome.ServiceAccount{
+			Teams: make([]*thunderdome.ServiceAccountTeam, 0),
+		}
+
+		if err := rows.Scan(
+			&sa.Id,
+			&sa.OrgId,
+			&sa.Name,
+			&sa.CreatedBy,
+			&sa.CreatedDate,
+		); err != nil {
+			d.Logger.Ctx(ctx).Error("service account list scan error", zap.Error(err))
+		} else {
+			Accounts = append(Accounts, &sa)
+			AccountsById[sa.Id] = &sa
+		}
+	}
+
+	teamRows, err := d.DB.QueryContext(ctx,
+		`SELECT tu.user_id, t.id, t.name, tu.role
+		FROM thunderdome.organization_service_account sa
+		JOIN thunderdome.team_user tu ON tu.user_id = sa.user_id
+		JOIN thunderdome.team t ON t.id = tu.team_id
+		WHERE sa.organization_id = $1
+		ORDER BY t.name;`,
+		OrgId,
+	)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("service account list teams query error", zap.Error(err))
+		return nil, err
+	}
+	defer teamRows.Close()
+
+	for teamRows.Next() {
+		var UserId string
+		var t thunderdome.ServiceAccountTeam
+
+		if err := teamRows.Scan(&UserId, &t.TeamId, &t.TeamName, &t.Role); err != nil {
+			d.Logger.Ctx(ctx).Error("service account list teams scan error", zap.Error(err))
+		} else if sa, ok := AccountsById[UserId]; ok {
+			sa.Teams = append(sa.Teams, &t)
+		}
+	}
+
+	return Accounts, nil
+}
+
+// ServiceAccountGet gets a service account of the organization
+func (d *ServiceAccountService) ServiceAccountGet(ctx context.Context, OrgId string, UserId string) (*thunderdome.ServiceAccount, error) {
+	sa := &thunderdome.ServiceAccount{
+		Teams: make([]*thunderdome.ServiceAccountTeam, 0),
+	}
+
+	err := d.DB.QueryRowContext(ctx,
+		`SELECT u.id, sa.organization_id, u.name, COALESCE(sa.created_by::text, ''), sa.created_date
+		FROM thunderdome.organization_service_account sa
+		JOIN thunderdome.users u ON u.id = sa.user_id
+		WHERE sa.organization_id = $1 AND sa.user_id = $2;`,
+		OrgId,
+		UserId,
+	).Scan(
+		&sa.Id,
+		&sa.OrgId,
+		&sa.Name,
+		&sa.CreatedBy,
+		&sa.CreatedDate,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("SERVICE_ACCOUNT_NOT_FOUND")
+		}
+		d.Logger.Ctx(ctx).Error("service account get query error", zap.Error(err))
+		return nil, err
+	}
+
+	rows, err := d.DB.QueryContext(ctx,
+		`SELECT t.id, t.name, tu.role
+		FROM thunderdome.team_user tu
+		JOIN thunderdome.team t ON t.id = tu.team_id
+		WHERE tu.user_id = $1
+		ORDER BY t.name;`,
+		UserId,
+	)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("service account get teams query error", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t thunderdome.ServiceAccountTeam
+
+		if err := rows.Scan(&t.TeamId, &t.TeamName, &t.Role); err != nil {
+			d.Logger.Ctx(ctx).Error("service account get teams scan error", zap.Error(err))
+		} else {
+			sa.Teams = append(sa.Teams, &t)
+		}
+	}
+
+	return sa, nil
+}
+
+// ServiceAccountDelete deletes the organizations service account along with its user and API keys
+func (d *ServiceAccountService) ServiceAccountDelete(ctx context.Context, OrgId string, UserId string) error {
+	result, err := d.DB.ExecContext(ctx,
+		`DELETE FROM thunderdome.organization_service_account WHERE organization_id = $1 AND user_id = $2;`,
+		OrgId,
+		UserId,
+	)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("service account delete query error", zap.Error(err))
+		return err
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return errors.New("SERVICE_ACCOUNT_NOT_FOUND")
+	}
+
+	return nil
+}
+
+// ServiceAccountTeamAssign adds the service account to a team of the organization (directly or by department),
+// updating its role when already assigned
+func (d *ServiceAccountService) ServiceAccountTeamAssign(ctx context.Context, OrgId string, UserId string, TeamId string, Role string) error {
+	result, err := d.DB.ExecContext(ctx,
+		`INSERT INTO thunderdome.team_user (team_id, user_id, role)
+		SELECT t.id, sa.user_id, $4
+		FROM thunderdome.organization_service_account sa
+		JOIN thunderdome.team t ON t.id = $3
+		WHERE sa.organization_id = $1 AND sa.user_id = $2
+		AND (
+			EXISTS (SELECT 1 FROM thunderdome.organization_team ot WHERE ot.organization_id = $1 AND ot.team_id = t.id)
+			OR EXISTS (
+				SELECT 1 FROM thunderdome.department_team dt
+				JOIN thunderdome.organization_department od ON od.id = dt.department_id
+				WHERE od.organization_id = $1 AND dt.team_id = t.id
+			)
+		)
+		ON CONFLICT (team_id, user_id) DO UPDATE SET role = EXCLUDED.role, updated_date = NOW();`,
+		OrgId,
+		UserId,
+		TeamId,
+		Role,
+	)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("service account team assign query error", zap.Error(err))
+		return err
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return errors.New("TEAM_NOT_FOUND")
+	}
+
+	return nil
+}
+
+// ServiceAccountTeamRemove removes the service account from a team
+func (d *ServiceAccountService) ServiceAccountTeamRemove(ctx context.Context, OrgId string, UserId string, TeamId string) error {
+	if _, err := d.DB.ExecContext(ctx,
+		`DELETE FROM thunderdome.team_user tu
+		USING thunderdome.organization_service_account sa
+		WHERE sa.organization_id = $1 AND sa.user_id = $2 AND tu.user_id = sa.user_id AND tu.team_id = $3;`,
+		OrgId,
+		UserId,
+		TeamId,
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("service account team remove query error", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package team
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 
 	"github.com/StevenWeathers/thunderdome-planning-poker/db"
@@ -40,6 +41,78 @@ func (d *Service) TeamUserRole(ctx context.Context, UserID string, TeamID string
 	return teamRole, nil
 }
 
+// TeamPokerUserRole gets the users highest role on the teams the poker game belongs to
+func (d *Service) TeamPokerUserRole(ctx context.Context, UserID string, PokerID string) (string, error) {
+	var teamRole string
+
+	err := d.DB.QueryRowContext(ctx,
+		`SELECT tu.role
+        FROM thunderdome.team_poker tp
+        JOIN thunderdome.team_user tu ON tu.team_id = tp.team_id
+        WHERE tp.poker_id = $2 AND tu.user_id = $1
+        ORDER BY tu.role = 'ADMIN' DESC
+        LIMIT 1;`,
+		UserID,
+		PokerID,
+	).Scan(
+		&teamRole,
+	)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			d.Logger.Ctx(ctx).Error("team_poker_user_role query error", zap.Error(err))
+		}
+		return "", errors.New("REQUIRES_TEAM_USER")
+	}
+
+	return teamRole, nil
+}
+
+// teamEntityQueries selects the IDs of the teams an entity belongs to by the entity type
+var teamEntityQueries = map[string]string{
+	"team":       `SELECT $1::uuid`,
+	"poker":      `SELECT team_id FROM thunderdome.team_poker WHERE poker_id = $1`,
+	"retro":      `SELECT team_id FROM thunderdome.team_retro WHERE retro_id = $1`,
+	"storyboard": `SELECT team_id FROM thunderdome.team_storyboard WHERE storyboard_id = $1`,
+}
+
+// TeamEntityOrganizationIDs gets the IDs of the organizations the team, or the teams a poker game, retro,
+// or storyboard belongs to are in, directly or through a department
+func (d *Service) TeamEntityOrganizationIDs(ctx context.Context, EntityType string, EntityID string) ([]string, error) {
+	OrgIDs := make([]string, 0)
+
+	teamsQuery, ok := teamEntityQueries[EntityType]
+	if !ok {
+		return nil, errors.New("INVALID_ENTITY_TYPE")
+	}
+
+	rows, err := d.DB.QueryContext(ctx,
+		`WITH teams AS (`+teamsQuery+`)
+		SELECT ot.organization_id FROM thunderdome.organization_team ot
+		WHERE ot.team_id IN (SELECT * FROM teams)
+		UNION
+		SELECT od.organization_id FROM thunderdome.department_team dt
+		JOIN thunderdome.organization_department od ON od.id = dt.department_id
+		WHERE dt.team_id IN (SELECT * FROM teams);`,
+		EntityID,
+	)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("team entity organizations query error", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var OrgID string
+		if err := rows.Scan(&OrgID); err != nil {
+			d.Logger.Ctx(ctx).Error("team entity organizations scan error", zap.Error(err))
+			return nil, err
+		}
+		OrgIDs = append(OrgIDs, OrgID)
+	}
+
+	return OrgIDs, nil
+}
+
 // TeamGet gets a team
 func (d *Service) TeamGet(ctx context.Context, TeamID string) (*thunderdome.Team, error) {
 	var team = &thunderdome.Team{}
@@ -154,7 +227,7 @@ func (d *Service) TeamUserList(ctx context.Context, TeamID string, Limit int, Of
 	}
 
 	rows, err := d.DB.QueryContext(ctx,
-		`SELECT u.id, u.name, COALESCE(u.email, ''), tu.role, u.avatar
+		`SELECT u.id, u.name, COALESCE(u.email, ''), tu.role, u.avatar, u.type
         FROM thunderdome.team_user tu
         LEFT JOIN thunderdome.users u ON tu.user_id = u.id
         WHERE tu.team_id = $1
@@ -177,6 +250,7 @@ func (d *Service) TeamUserList(ctx context.Context, TeamID string, Limit int, Of
 				&usr.Email,
 				&usr.Role,
 				&usr.Avatar,
+				&usr.Type,
 			); err != nil {
 				d.Logger.Ctx(ctx).Error("team_user_list query scan error", zap.Error(err))
 			} else {
@@ -215,7 +289,7 @@ func (d *Service) TeamPokerList(ctx context.Context, TeamID string, Limit int, O
 		`SELECT b.id, b.name
         FROM thunderdome.team_poker tb
         LEFT JOIN thunderdome.poker b ON tb.poker_id = b.id
-        WHERE tb.team_id = $1
+        WHERE tb.team_id = $1 AND b.template = false
         ORDER BY tb.created_date DESC
 		LIMIT $2
 		OFFSET $3;`,
@@ -245,6 +319,50 @@ func (d *Service) TeamPokerList(ctx context.Context, TeamID string, Limit int, O
 	return pokers
 }
 
+// TeamPokerTemplateList gets a list of team poker game templates
+func (d *Service) TeamPokerTemplateList(ctx context.Context, TeamID string, Limit int, Offset int) []*thunderdome.Poker {
+	var pokers = make([]*thunderdome.Poker, 0)
+	rows, err := d.DB.QueryContext(ctx,
+		`SELECT b.id, b.name, b.point_values_allowed, b.auto_finish_voting, b.point_average_rounding, b.hide_voter_identity
+        FROM thunderdome.team_poker tb
+        LEFT JOIN thunderdome.poker b ON tb.poker_id = b.id
+        WHERE tb.team_id = $1 AND b.template = true
+        ORDER BY b.name
+		LIMIT $2
+		OFFSET $3;`,
+		TeamID,
+		Limit,
+		Offset,
+	)
+
+	if err == nil {
+		defer rows.Close()
+		for rows.Next() {
+			var tb thunderdome.Poker
+			var pv string
+
+			if err := rows.Scan(
+				&tb.Id,
+				&tb.Name,
+				&pv,
+				&tb.AutoFinishVoting,
+				&tb.PointAverageRounding,
+				&tb.HideVoterIdentity,
+			); err != nil {
+				d.Logger.Ctx(ctx).Error("team_poker template list query scan error", zap.Error(err))
+			} else {
+				_ = json.Unmarshal([]byte(pv), &tb.PointValuesAllowed)
+				tb.Template = true
+				pokers = append(pokers, &tb)
+			}
+		}
+	} else {
+		d.Logger.Ctx(ctx).Error("team_poker template list query error", zap.Error(err))
+	}
+
+	return pokers
+}
+
 // TeamAddPoker adds a poker game to a team
 func (d *Service) TeamAddPoker(ctx context.Context, TeamID string, PokerID string) error {
 	_, err := d.DB.ExecContext(ctx,
@@ -299,7 +417,7 @@ func (d *Service) TeamRetroList(ctx context.Context, TeamID string, Limit int, O
 		`SELECT b.id, b.name, b.format, b.phase
         FROM thunderdome.team_retro tb
         LEFT JOIN thunderdome.retro b ON tb.retro_id = b.id
-        WHERE tb.team_id = $1
+        WHERE tb.team_id = $1 AND b.template = false
         ORDER BY tb.created_date DESC
 		LIMIT $2
 		OFFSET $3;`,
@@ -331,6 +449,47 @@ func (d *Service) TeamRetroList(ctx context.Context, TeamID string, Limit int, O
 	return retros
 }
 
+// TeamRetroTemplateList gets a list of team retro templates
+func (d *Service) TeamRetroTemplateList(ctx context.Context, TeamID string, Limit int, Offset int) []*thunderdome.Retro {
+	var retros = make([]*thunderdome.Retro, 0)
+	rows, err := d.DB.QueryContext(ctx,
+		`SELECT b.id, b.name, b.format, b.max_votes, b.brainstorm_visibility
+        FROM thunderdome.team_retro tb
+        LEFT JOIN thunderdome.retro b ON tb.retro_id = b.id
+        WHERE tb.team_id = $1 AND b.template = true
+        ORDER BY b.name
+		LIMIT $2
+		OFFSET $3;`,
+		TeamID,
+		Limit,
+		Offset,
+	)
+
+	if err == nil {
+		defer rows.Close()
+		for rows.Next() {
+			var tb thunderdome.Retro
+
+			if err := rows.Scan(
+				&tb.Id,
+				&tb.Name,
+				&tb.Format,
+				&tb.MaxVotes,
+				&tb.BrainstormVisibility,
+			); err != nil {
+				d.Logger.Ctx(ctx).Error("team_retro_template_list query scan error", zap.Error(err))
+			} else {
+				tb.Template = true
+				retros = append(retros, &tb)
+			}
+		}
+	} else {
+		d.Logger.Ctx(ctx).Error("team_retro_template_list query error", zap.Error(err))
+	}
+
+	return retros
+}
+
 // TeamAddRetro adds a retro to a team
 func (d *Service) TeamAddRetro(ctx context.Context, TeamID string, RetroID string) error {
 	_, err := d.DB.ExecContext(ctx,
@@ -370,7 +529,7 @@ func (d *Service) TeamStoryboardList(ctx context.Context, TeamID string, Limit i
 		`SELECT b.id, b.name
         FROM thunderdome.team_storyboard tb
         LEFT JOIN thunderdome.storyboard b ON tb.storyboard_id = b.id
-        WHERE tb.team_id = $1
+        WHERE tb.team_id = $1 AND b.template = false
         ORDER BY tb.created_date DESC
 		LIMIT $2
 		OFFSET $3;`,
@@ -400,6 +559,44 @@ func (d *Service) TeamStoryboardList(ctx context.Context, TeamID string, Limit i
 	return storyboards
 }
 
+// TeamStoryboardTemplateList gets a list of team storyboard templates
+func (d *Service) TeamStoryboardTemplateList(ctx context.Context, TeamID string, Limit int, Offset int) []*thunderdome.Storyboard {
+	var storyboards = make([]*thunderdome.Storyboard, 0)
+	rows, err := d.DB.QueryContext(ctx,
+		`SELECT b.id, b.name
+        FROM thunderdome.team_storyboard tb
+        LEFT JOIN thunderdome.storyboard b ON tb.storyboard_id = b.id
+        WHERE tb.team_id = $1 AND b.template = true
+        ORDER BY b.name
+		LIMIT $2
+		OFFSET $3;`,
+		TeamID,
+		Limit,
+		Offset,
+	)
+
+	if err == nil {
+		defer rows.Close()
+		for rows.Next() {
+			var tb thunderdome.Storyboard
+
+			if err := rows.Scan(
+				&tb.Id,
+				&tb.Name,
+			); err != nil {
+				d.Logger.Ctx(ctx).Error("team_storyboard_template_list query scan error", zap.Error(err))
+			} else {
+				tb.Template = true
+				storyboards = append(storyboards, &tb)
+			}
+		}
+	} else {
+		d.Logger.Ctx(ctx).Error("team_storyboard_template_list query error", zap.Error(err))
+	}
+
+	return storyboards
+}
+
 // TeamAddStoryboard adds a storyboard to a team
 func (d *Service) TeamAddStoryboard(ctx context.Context, TeamID string, StoryboardID string) error {
 	_, err := d.DB.ExecContext(ctx,
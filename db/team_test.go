@@ -0,0 +1,66 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/poker"
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/team"
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/user"
+)
+
+// TestTeamEntityOrganizationIDs makes sure the organizations of a game are found through its teams,
+// whether the team is in the organization directly or through a department
+func TestTeamEntityOrganizationIDs(t *testing.T) {
+	d := testDB(t)
+	ctx := context.Background()
+	us := &user.Service{DB: d.DB, Logger: d.Logger}
+	ps := &poker.Service{DB: d.DB, Logger: d.Logger, AESHashKey: testAESHashKey, HTMLSanitizerPolicy: d.HTMLSanitizerPolicy}
+	ts := &team.Service{DB: d.DB, Logger: d.Logger}
+	orgs := &team.OrganizationService{DB: d.DB, Logger: d.Logger}
+
+	owner, err := us.CreateUserGuest(ctx, "Odin")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	tm, err := ts.TeamCreate(ctx, owner.Id, "Bilgesnipe")
+	if err != nil {
+		t.Fatalf("create team: %v", err)
+	}
+	org, err := orgs.OrganizationCreate(ctx, owner.Id, "Asgard")
+	if err != nil {
+		t.Fatalf("create organization: %v", err)
+	}
+	dept, err := orgs.DepartmentCreate(ctx, org.Id, "Valhalla")
+	if err != nil {
+		t.Fatalf("create department: %v", err)
+	}
+	deptTeam, err := orgs.DepartmentTeamCreate(ctx, dept.Id, "Valkyries")
+	if err != nil {
+		t.Fatalf("create department team: %v", err)
+	}
+
+	g, err := ps.CreateGame(ctx, owner.Id, "Bifrost", []string{"1", "2", "3"}, nil, true, "ceil", "", "", false)
+	if err != nil {
+		t.Fatalf("create game: %v", err)
+	}
+
+	if OrgIDs, err := ts.TeamEntityOrganizationIDs(ctx, "poker", g.Id); err != nil || len(OrgIDs) != 0 {
+		t.Fatalf("expected a game without teams to have no organizations, got %v %v", OrgIDs, err)
+	}
+
+	for _, TeamID := range []string{tm.Id, deptTeam.Id} {
+		if err := ts.TeamAddPoker(ctx, TeamID, g.Id); err != nil {
+			t.Fatalf("add game to team: %v", err)
+		}
+	}
+
+	OrgIDs, err := ts.TeamEntityOrganizationIDs(ctx, "poker", g.Id)
+	if err != nil || len(OrgIDs) != 1 || OrgIDs[0] != org.Id {
+		t.Fatalf("expected the game to be in the departments organization, got %v %v", OrgIDs, err)
+	}
+
+	if OrgIDs, err := ts.TeamEntityOrganizationIDs(ctx, "team", tm.Id); err != nil || len(OrgIDs) != 0 {
+		t.Fatalf("expected a team outside an organization to have no organizations, got %v %v", OrgIDs, err)
+	}
+}
@@ -0,0 +1,66 @@
+package db_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+
+	"go.uber.org/zap"
+)
+
+const testAESHashKey = "therevengers"
+
+// testDB connects to and migrates the database named by TEST_DB_NAME using the DB_* connection environment
+// variables, skipping the test when it isn't set
+func testDB(t *testing.T) *db.Service {
+	t.Helper()
+
+	name := os.Getenv("TEST_DB_NAME")
+	if name == "" {
+		t.Skip("TEST_DB_NAME not set, skipping database test")
+	}
+
+	config := &db.Config{
+		Host:            getEnv("DB_HOST", "localhost"),
+		Port:            5432,
+		User:            getEnv("DB_USER", "thor"),
+		Password:        getEnv("DB_PASS", "odinson"),
+		Name:            name,
+		SSLMode:         getEnv("DB_SSLMODE", "disable"),
+		AESHashkey:      testAESHashKey,
+		MaxOpenConns:    5,
+		MaxIdleConns:    5,
+		ConnMaxLifetime: 5,
+	}
+
+	d := db.Open(config, otelzap.New(zap.NewNop()))
+	if err := d.MigrateUp(); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	t.Cleanup(func() { _ = d.DB.Close() })
+
+	return d
+}
+
+func getEnv(key string, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// recoveryCodeCount the number of MFA recovery codes the user has
+func recoveryCodeCount(t *testing.T, d *db.Service, UserID string) int {
+	t.Helper()
+
+	var count int
+	if err := d.DB.QueryRow(
+		`SELECT COUNT(*) FROM thunderdome.user_mfa_recovery_code WHERE user_id = $1;`, UserID,
+	).Scan(&count); err != nil {
+		t.Fatalf("count recovery codes: %v", err)
+	}
+
+	return count
+}
@@ -14,6 +14,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -179,3 +180,20 @@ func CreateGravatarHash(email string) string {
 	gh := md5.Sum([]byte(email))
 	return hex.EncodeToString(gh[:])
 }
+
+// RaisedException gets the message of an exception raised by a db function e.g. TEMPLATE_REQUIRES_TEAM,
+// empty when the error was not a raised exception
+func RaisedException(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "P0001" {
+		return pqErr.Message
+	}
+
+	return ""
+}
+
+// UniqueViolation checks whether the error is a unique constraint violation
+func UniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
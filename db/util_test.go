@@ -1,7 +1,11 @@
 package db
 
 import (
+	"errors"
+	"fmt"
 	"testing"
+
+	"github.com/lib/pq"
 )
 
 // TestHashString calls hashString and makes sure the return is not the same as the input
@@ -24,3 +28,31 @@ func TestHashString(t *testing.T) {
 		t.Fatalf(`expected HashedResult1: %s to match HashedString: %s`, HashedResult1, HashedString)
 	}
 }
+
+// TestRaisedException makes sure only exceptions raised by db functions return their message
+func TestRaisedException(t *testing.T) {
+	raised := &pq.Error{Code: "P0001", Message: "TEMPLATE_REQUIRES_TEAM"}
+	if msg := RaisedException(fmt.Errorf("clone: %w", raised)); msg != "TEMPLATE_REQUIRES_TEAM" {
+		t.Fatalf(`expected RaisedException to return TEMPLATE_REQUIRES_TEAM, got %s`, msg)
+	}
+
+	violation := &pq.Error{Code: "23505", Message: "duplicate key value violates unique constraint"}
+	if msg := RaisedException(violation); msg != "" {
+		t.Fatalf(`expected RaisedException to be empty for a unique violation, got %s`, msg)
+	}
+
+	if msg := RaisedException(errors.New("TEMPLATE_REQUIRES_TEAM")); msg != "" {
+		t.Fatalf(`expected RaisedException to be empty for a non db error, got %s`, msg)
+	}
+}
+
+// TestUniqueViolation makes sure only unique constraint violations are detected
+func TestUniqueViolation(t *testing.T) {
+	if !UniqueViolation(fmt.Errorf("verify: %w", &pq.Error{Code: "23505"})) {
+		t.Fatal("expected a unique violation to be detected")
+	}
+
+	if UniqueViolation(&pq.Error{Code: "P0001", Message: "TEMPLATE_REQUIRES_TEAM"}) {
+		t.Fatal("expected a raised exception not to be a unique violation")
+	}
+}
@@ -0,0 +1,161 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"github.com/matcornic/hermes/v2"
+	"github.com/microcosm-cc/bluemonday"
+	"go.uber.org/zap"
+)
+
+// checkinTextPolicy strips the rich text formatting from checkin content for email tables
+var checkinTextPolicy = bluemonday.StrictPolicy()
+
+// checkinText strips the rich text formatting from checkin content, unescaping the text
+// that's left as the email template escapes it
+func checkinText(Content string) string {
+	return html.UnescapeString(checkinTextPolicy.Sanitize(Content))
+}
+
+// SendCheckinReminder sends a reminder to a team member that hasn't checked in yet
+func (s *Service) SendCheckinReminder(ctx context.Context, UserName string, UserEmail string, TeamName string, TeamID string) error {
+	emailBody, err := s.generateBody(
+		hermes.Body{
+			Name: UserName,
+			Intros: []string{
+				fmt.Sprintf("You haven't checked in with %s today.", TeamName),
+			},
+			Actions: []hermes.Action{
+				{
+					Instructions: "Let your team know how things are going.",
+					Button: hermes.Button{
+						Color: "#22BC66",
+						Text:  "Check In",
+						Link:  s.Config.AppURL + "team/" + TeamID + "/checkin",
+					},
+				},
+			},
+			Outros: []string{
+				"You can turn off these emails by disabling notifications in your profile.",
+			},
+		},
+	)
+	if err != nil {
+		s.Logger.Error("Error Generating Checkin Reminder Email HTML", zap.Error(err))
+		return err
+	}
+
+	sendErr := s.sendContext(
+		ctx,
+		UserName,
+		UserEmail,
+		fmt.Sprintf("Reminder to check in with %s", TeamName),
+		emailBody,
+	)
+	if sendErr != nil {
+		s.Logger.Error("Error sending Checkin Reminder Email", zap.Error(sendErr))
+		return sendErr
+	}
+
+	return nil
+}
+
+// checkinDigestRows the digest table rows for the checkins, one per member with their answers
+// to the teams own questions and the checkin comments
+func checkinDigestRows(Checkins []*thunderdome.TeamCheckin) [][]hermes.Entry {
+	userNames := make(map[string]string)
+	for _, checkin := range Checkins {
+		userNames[checkin.User.Id] = checkin.User.Name
+	}
+
+	data := make([][]hermes.Entry, 0, len(Checkins))
+	for _, checkin := range Checkins {
+		goalsMet := "No"
+		if checkin.GoalsMet {
+			goalsMet = "Yes"
+		}
+
+		answers := make([]string, 0, len(checkin.Answers))
+		for _, answer := range checkin.Answers {
+			answers = append(answers, answer.Question+": "+checkinText(answer.Answer))
+		}
+
+		comments := make([]string, 0, len(checkin.Comments))
+		for _, comment := range checkin.Comments {
+			text := checkinText(comment.Comment)
+			if name, ok := userNames[comment.UserID]; ok {
+				text = name + ": " + text
+			}
+			comments = append(comments, text)
+		}
+
+		data = append(data, []hermes.Entry{
+			{Key: "Member", Value: checkin.User.Name},
+			{Key: "Yesterday", Value: checkinText(checkin.Yesterday)},
+			{Key: "Today", Value: checkinText(checkin.Today)},
+			{Key: "Blockers", Value: checkinText(checkin.Blockers)},
+			{Key: "Goals Met", Value: goalsMet},
+			{Key: "Answers", Value: strings.Join(answers, "\n")},
+			{Key: "Comments", Value: strings.Join(comments, "\n")},
+		})
+	}
+
+	return data
+}
+
+// SendCheckinDigest sends a team member the days team checkins and their comments
+func (s *Service) SendCheckinDigest(ctx context.Context, UserName string, UserEmail string, TeamName string, TeamID string, Date string, Checkins []*thunderdome.TeamCheckin) error {
+	data := checkinDigestRows(Checkins)
+
+	intro := fmt.Sprintf("Here's what %s checked in with on %s.", TeamName, Date)
+	if len(Checkins) == 0 {
+		intro = fmt.Sprintf("Nobody checked in with %s on %s.", TeamName, Date)
+	}
+
+	emailBody, err := s.generateBody(
+		hermes.Body{
+			Name: UserName,
+			Intros: []string{
+				intro,
+			},
+			Table: hermes.Table{
+				Data: data,
+			},
+			Actions: []hermes.Action{
+				{
+					Instructions: "Catch up with your team and join the discussion.",
+					Button: hermes.Button{
+						Text: "View Checkins",
+						Link: s.Config.AppURL + "team/" + TeamID + "/checkin",
+					},
+				},
+			},
+			Outros: []string{
+				"You can turn off these emails by disabling notifications in your profile.",
+			},
+		},
+	)
+	if err != nil {
+		s.Logger.Error("Error Generating Checkin Digest Email HTML", zap.Error(err))
+		return err
+	}
+
+	sendErr := s.sendContext(
+		ctx,
+		UserName,
+		UserEmail,
+		fmt.Sprintf("%s checkins for %s", TeamName, Date),
+		emailBody,
+	)
+	if sendErr != nil {
+		s.Logger.Error("Error sending Checkin Digest Email", zap.Error(sendErr))
+		return sendErr
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package email
+
+import (
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestCheckinDigestRows makes sure the digest strips the rich text without escaping it,
+// leaving that to the email template, and includes the answers to the teams own questions
+func TestCheckinDigestRows(t *testing.T) {
+	rows := checkinDigestRows([]*thunderdome.TeamCheckin{
+		{
+			User:     &thunderdome.TeamUser{Id: "thor", Name: "Thor"},
+			Today:    "<p>Fix Mjolnir &amp; Stormbreaker</p>",
+			GoalsMet: true,
+			Answers: []*thunderdome.CheckinAnswer{
+				{QuestionID: "mood", Question: "Mood", Answer: "4"},
+				{QuestionID: "focus", Question: "Focus", Answer: "<p>Bifrost <b>repairs</b></p>"},
+			},
+			Comments: []*thunderdome.CheckinComment{
+				{UserID: "thor", Comment: "<p>Loki's &quot;help&quot;</p>"},
+			},
+		},
+	})
+	if len(rows) != 1 {
+		t.Fatalf("expected 1 row, got %d", len(rows))
+	}
+
+	values := make(map[string]string)
+	for _, entry := range rows[0] {
+		values[entry.Key] = entry.Value
+	}
+
+	for key, want := range map[string]string{
+		"Member":    "Thor",
+		"Today":     "Fix Mjolnir & Stormbreaker",
+		"Goals Met": "Yes",
+		"Answers":   "Mood: 4\nFocus: Bifrost repairs",
+		"Comments":  `Thor: Loki's "help"`,
+	} {
+		if values[key] != want {
+			t.Errorf("expected %s %q, got %q", key, want, values[key])
+		}
+	}
+}
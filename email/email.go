@@ -2,36 +2,19 @@
 package email
 
 import (
-	"crypto/tls"
-	"fmt"
+	"context"
 	"net/mail"
-	"net/smtp"
 	"strconv"
 	"time"
 
+	"github.com/StevenWeathers/thunderdome-planning-poker/metrics"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
 	"github.com/uptrace/opentelemetry-go-extra/otelzap"
 
 	"github.com/matcornic/hermes/v2"
 	"github.com/spf13/viper"
-	"go.uber.org/zap"
 )
 
-// smtpServer data to smtp server
-type smtpServer struct {
-	host string
-	port string
-}
-
-// Address URI to smtp server
-func (s *smtpServer) Address() string {
-	return s.host + ":" + s.port
-}
-
-var smtpServerConfig = smtpServer{}
-var tlsConfig = &tls.Config{}
-var smtpFrom = mail.Address{}
-var smtpAuth smtp.Auth
-
 // Config contains all the mail server values
 type Config struct {
 	AppURL       string
@@ -47,8 +30,11 @@ type Config struct {
 
 // Service contains all the methods to send application emails
 type Service struct {
-	Config *Config
-	Logger *otelzap.Logger
+	Config    *Config
+	Logger    *otelzap.Logger
+	Transport Transport
+	// Queue when set emails are queued to be sent in the background by a QueueWorker instead of sent immediately
+	Queue thunderdome.EmailQueueDataSvc
 }
 
 // New creates a new instance of Service
@@ -70,23 +56,31 @@ func New(AppDomain string, PathPrefix string, logger *otelzap.Logger) *Service {
 		Logger: logger,
 	}
 
-	// smtp server configuration.
-	smtpServerConfig = smtpServer{host: s.Config.smtpHost, port: s.Config.smtpPort}
-
-	// smtp sender info
-	smtpFrom = mail.Address{
+	from := mail.Address{
 		Name:    s.Config.SenderName,
 		Address: s.Config.smtpSender,
 	}
 
-	// TLS config
-	tlsConfig = &tls.Config{
-		InsecureSkipVerify: !s.Config.smtpSecure,
-		ServerName:         s.Config.smtpHost,
+	switch transport := viper.GetString("email.transport"); transport {
+	case "smtp":
+		s.Transport = &SMTPTransport{
+			Host:     s.Config.smtpHost,
+			Port:     s.Config.smtpPort,
+			Secure:   s.Config.smtpSecure,
+			Identity: s.Config.smtpIdentity,
+			User:     s.Config.smtpUser,
+			Pass:     s.Config.smtpPass,
+			From:     from,
+			Logger:   logger,
+		}
+	case "sendmail":
+		s.Transport = &SendmailTransport{Path: viper.GetString("email.sendmail_path"), From: from}
+	case "file":
+		s.Transport = &FileTransport{Path: viper.GetString("email.file_path"), From: from}
+	default:
+		logger.Fatal("unknown email transport " + transport)
 	}
 
-	smtpAuth = smtp.PlainAuth(s.Config.smtpIdentity, s.Config.smtpUser, s.Config.smtpPass, s.Config.smtpHost)
-
 	return s
 }
 
@@ -116,85 +110,30 @@ func (s *Service) generateBody(Body hermes.Body) (emailBody string, generateErr
 	return emailBody, nil
 }
 
-// send - utility function to send emails
+// send - utility function to send emails, queuing them when a queue is set
 func (s *Service) send(UserName string, UserEmail string, Subject string, Body string) error {
+	return s.sendContext(context.Background(), UserName, UserEmail, Subject, Body)
+}
+
+// sendContext sends the email like send, stopping when the context is done
+func (s *Service) sendContext(ctx context.Context, UserName string, UserEmail string, Subject string, Body string) error {
 	if !viper.GetBool("smtp.enabled") {
 		return nil
 	}
 
-	to := mail.Address{
-		Name:    UserName,
-		Address: UserEmail,
-	}
-
-	// Setup headers
-	headers := make(map[string]string)
-	headers["From"] = smtpFrom.String()
-	headers["To"] = to.String()
-	headers["Subject"] = Subject
-	headers["MIME-version"] = "1.0"
-	headers["Content-Type"] = "text/html"
-
-	// Setup message
-	message := ""
-	for k, v := range headers {
-		message += fmt.Sprintf("%s: %s\r\n", k, v)
-	}
-	message += "\r\n" + Body
-
-	c, err := smtp.Dial(smtpServerConfig.Address())
-	if err != nil {
-		s.Logger.Error("Error dialing SMTP", zap.Error(err))
-		return err
-	}
-
-	tlsErr := c.StartTLS(tlsConfig)
-	if tlsErr != nil {
-		s.Logger.Error("Error starting TLS", zap.Error(tlsErr))
-	}
-
-	// Auth
-	if s.Config.smtpSecure {
-		if err = c.Auth(smtpAuth); err != nil {
-			s.Logger.Error("Error authenticating SMTP", zap.Error(err))
-			return err
-		}
-	}
-
-	// To && From
-	if err = c.Mail(smtpFrom.Address); err != nil {
-		s.Logger.Error("Error setting SMTP from", zap.Error(err))
-		return err
-	}
-
-	if err = c.Rcpt(to.Address); err != nil {
-		s.Logger.Error("Error setting SMTP to", zap.Error(err))
-		return err
+	if s.Queue != nil {
+		return s.Queue.EmailQueueAdd(ctx, UserName, UserEmail, Subject, Body)
 	}
 
-	// Data
-	w, err := c.Data()
+	err := s.Transport.Send(ctx, Message{
+		ToName:  UserName,
+		ToEmail: UserEmail,
+		Subject: Subject,
+		Body:    Body,
+	})
 	if err != nil {
-		s.Logger.Error("Error setting SMTP data", zap.Error(err))
-		return err
-	}
-
-	_, err = w.Write([]byte(message))
-	if err != nil {
-		s.Logger.Error("Error sending email", zap.Error(err))
-		return err
-	}
-
-	err = w.Close()
-	if err != nil {
-		s.Logger.Error("Error closing SMTP", zap.Error(err))
-		return err
-	}
-
-	quitErr := c.Quit()
-	if quitErr != nil {
-		s.Logger.Error("Error quitting smtp server connection", zap.Error(quitErr))
+		metrics.EmailSendFailures.Inc()
 	}
 
-	return nil
+	return err
 }
@@ -0,0 +1,102 @@
+package email
+
+import (
+	"context"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/metrics"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// queueBatchSize the emails claimed from the queue at a time
+	queueBatchSize = 50
+	// queueLease how long a claimed email is held before another attempt can claim it
+	queueLease = 5 * time.Minute
+	// maxRetryDelay the longest wait between attempts
+	maxRetryDelay = time.Hour
+)
+
+// QueueWorker sends the queued emails through the transport, retrying failures with an increasing delay
+// until MaxAttempts when the email is dead-lettered as failed
+type QueueWorker struct {
+	Interval    time.Duration
+	MaxAttempts int
+	Transport   Transport
+	DataSvc     thunderdome.EmailQueueDataSvc
+	Logger      *otelzap.Logger
+}
+
+// Run sends the due queued emails every interval until the context is done
+func (q *QueueWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(q.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			q.process(ctx)
+		}
+	}
+}
+
+// process sends batches of due emails until the queue has none left due
+func (q *QueueWorker) process(ctx context.Context) {
+	for ctx.Err() == nil {
+		emails, err := q.DataSvc.EmailQueueClaim(ctx, queueBatchSize, queueLease)
+		if err != nil {
+			return
+		}
+
+		for _, e := range emails {
+			q.send(ctx, e)
+		}
+
+		if len(emails) < queueBatchSize {
+			return
+		}
+	}
+}
+
+func (q *QueueWorker) send(ctx context.Context, e *thunderdome.QueuedEmail) {
+	err := q.Transport.Send(ctx, Message{
+		ToName:  e.ToName,
+		ToEmail: e.ToEmail,
+		Subject: e.Subject,
+		Body:    e.Body,
+	})
+	if err == nil {
+		_ = q.DataSvc.EmailQueueSent(ctx, e.Id)
+		return
+	}
+
+	metrics.EmailSendFailures.Inc()
+	if e.Attempts >= q.MaxAttempts {
+		q.Logger.Ctx(ctx).Error("email send failed, giving up",
+			zap.String("email_id", e.Id), zap.Int("attempts", e.Attempts), zap.Error(err))
+		_ = q.DataSvc.EmailQueueDeadLetter(ctx, e.Id, err.Error())
+		return
+	}
+
+	q.Logger.Ctx(ctx).Warn("email send failed, retrying later",
+		zap.String("email_id", e.Id), zap.Int("attempts", e.Attempts), zap.Error(err))
+	_ = q.DataSvc.EmailQueueRetryLater(ctx, e.Id, err.Error(), retryDelay(e.Attempts))
+}
+
+// retryDelay the wait before the next attempt, doubling from a minute with each attempt up to an hour
+func retryDelay(Attempts int) time.Duration {
+	delay := time.Minute
+	for i := 1; i < Attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+
+	return delay
+}
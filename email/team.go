@@ -0,0 +1,51 @@
+package email
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/matcornic/hermes/v2"
+	"go.uber.org/zap"
+)
+
+// SendTeamInvite sends an invitation to join a team, organization, or department
+// to an email address that doesn't have an account yet
+func (s *Service) SendTeamInvite(InviterName string, UserEmail string, TeamName string, InviteToken string) error {
+	emailBody, err := s.generateBody(
+		hermes.Body{
+			Intros: []string{
+				fmt.Sprintf("%s has invited you to join %s on Thunderdome.", InviterName, TeamName),
+			},
+			Actions: []hermes.Action{
+				{
+					Instructions: "Create your account with this email to accept the invitation, the following link will expire in 7 days.",
+					Button: hermes.Button{
+						Color: "#22BC66",
+						Text:  "Accept Invite",
+						Link:  s.Config.AppURL + "register?invite=" + url.QueryEscape(InviteToken),
+					},
+				},
+			},
+			Outros: []string{
+				"Already have an account with this email? Just log in and the invitation will be accepted.",
+			},
+		},
+	)
+	if err != nil {
+		s.Logger.Error("Error Generating Team Invite Email HTML", zap.Error(err))
+		return err
+	}
+
+	sendErr := s.send(
+		"",
+		UserEmail,
+		fmt.Sprintf("You've been invited to join %s on Thunderdome", TeamName),
+		emailBody,
+	)
+	if sendErr != nil {
+		s.Logger.Error("Error sending Team Invite Email", zap.Error(sendErr))
+		return sendErr
+	}
+
+	return nil
+}
@@ -0,0 +1,184 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+
+	"go.uber.org/zap"
+)
+
+// Message an email to a single recipient
+type Message struct {
+	ToName  string
+	ToEmail string
+	Subject string
+	Body    string
+}
+
+// Transport delivers emails
+type Transport interface {
+	Send(ctx context.Context, Msg Message) error
+}
+
+// bytes formats the message with its headers from the sender
+func (m Message) bytes(From mail.Address) []byte {
+	to := mail.Address{
+		Name:    m.ToName,
+		Address: m.ToEmail,
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", From.String())
+	fmt.Fprintf(&b, "To: %s\r\n", to.String())
+	fmt.Fprintf(&b, "Subject: %s\r\n", m.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-version: 1.0\r\n")
+	b.WriteString("Content-Type: text/html\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(m.Body)
+
+	return b.Bytes()
+}
+
+// SMTPTransport sends emails through an SMTP server
+type SMTPTransport struct {
+	Host     string
+	Port     string
+	Secure   bool
+	Identity string
+	User     string
+	Pass     string
+	From     mail.Address
+	Logger   *otelzap.Logger
+}
+
+// Send sends the email through the SMTP server
+func (t *SMTPTransport) Send(ctx context.Context, Msg Message) error {
+	conn, err := (&net.Dialer{Timeout: 30 * time.Second}).DialContext(ctx, "tcp", net.JoinHostPort(t.Host, t.Port))
+	if err != nil {
+		t.Logger.Ctx(ctx).Error("Error dialing SMTP", zap.Error(err))
+		return err
+	}
+
+	c, err := smtp.NewClient(conn, t.Host)
+	if err != nil {
+		_ = conn.Close()
+		t.Logger.Ctx(ctx).Error("Error dialing SMTP", zap.Error(err))
+		return err
+	}
+	defer c.Close()
+
+	tlsErr := c.StartTLS(&tls.Config{
+		InsecureSkipVerify: !t.Secure,
+		ServerName:         t.Host,
+	})
+	if tlsErr != nil {
+		t.Logger.Ctx(ctx).Error("Error starting TLS", zap.Error(tlsErr))
+	}
+
+	// Auth
+	if t.Secure {
+		if err = c.Auth(smtp.PlainAuth(t.Identity, t.User, t.Pass, t.Host)); err != nil {
+			t.Logger.Ctx(ctx).Error("Error authenticating SMTP", zap.Error(err))
+			return err
+		}
+	}
+
+	// To && From
+	if err = c.Mail(t.From.Address); err != nil {
+		t.Logger.Ctx(ctx).Error("Error setting SMTP from", zap.Error(err))
+		return err
+	}
+
+	if err = c.Rcpt(Msg.ToEmail); err != nil {
+		t.Logger.Ctx(ctx).Error("Error setting SMTP to", zap.Error(err))
+		return err
+	}
+
+	// Data
+	w, err := c.Data()
+	if err != nil {
+		t.Logger.Ctx(ctx).Error("Error setting SMTP data", zap.Error(err))
+		return err
+	}
+
+	_, err = w.Write(Msg.bytes(t.From))
+	if err != nil {
+		t.Logger.Ctx(ctx).Error("Error sending email", zap.Error(err))
+		return err
+	}
+
+	err = w.Close()
+	if err != nil {
+		t.Logger.Ctx(ctx).Error("Error closing SMTP", zap.Error(err))
+		return err
+	}
+
+	quitErr := c.Quit()
+	if quitErr != nil {
+		t.Logger.Ctx(ctx).Error("Error quitting smtp server connection", zap.Error(quitErr))
+	}
+
+	return nil
+}
+
+// SendmailTransport sends emails by piping them to a sendmail compatible binary
+type SendmailTransport struct {
+	Path string
+	From mail.Address
+}
+
+// Send pipes the email to sendmail, reading the recipient from its headers
+func (t *SendmailTransport) Send(ctx context.Context, Msg Message) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, t.Path, "-t", "-i", "-f", t.From.Address)
+	cmd.Stdin = bytes.NewReader(Msg.bytes(t.From))
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("sendmail: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
+
+// FileTransport appends emails to a file or stdout instead of sending them, for local development
+type FileTransport struct {
+	// Path the file emails are appended to, stdout when empty
+	Path string
+	From mail.Address
+
+	mu sync.Mutex
+}
+
+// Send appends the email followed by a blank line to the file
+func (t *FileTransport) Send(ctx context.Context, Msg Message) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var w io.Writer = os.Stdout
+	if t.Path != "" {
+		f, err := os.OpenFile(t.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	_, err := w.Write(append(Msg.bytes(t.From), "\r\n\r\n"...))
+	return err
+}
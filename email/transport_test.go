@@ -0,0 +1,55 @@
+package email
+
+import (
+	"context"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileTransport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.log")
+	transport := &FileTransport{Path: path, From: mail.Address{Name: "Thunderdome", Address: "no-reply@thunderdome.dev"}}
+
+	for _, subject := range []string{"Welcome", "Verify"} {
+		if err := transport.Send(context.Background(), Message{
+			ToName: "Thor", ToEmail: "thor@thunderdome.dev", Subject: subject, Body: "<p>hi</p>",
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(b)
+	for _, want := range []string{
+		"From: \"Thunderdome\" <no-reply@thunderdome.dev>\r\n",
+		"To: \"Thor\" <thor@thunderdome.dev>\r\n",
+		"Subject: Welcome\r\n",
+		"Subject: Verify\r\n",
+		"\r\n\r\n<p>hi</p>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected file to contain %q, got %q", want, out)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	for attempts, want := range map[int]time.Duration{
+		1:  time.Minute,
+		2:  2 * time.Minute,
+		4:  8 * time.Minute,
+		7:  time.Hour,
+		20: time.Hour,
+	} {
+		if got := retryDelay(attempts); got != want {
+			t.Errorf("retryDelay(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
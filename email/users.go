@@ -1,6 +1,10 @@
 package email
 
 import (
+	"fmt"
+	"math"
+	"time"
+
 	"github.com/matcornic/hermes/v2"
 	"go.uber.org/zap"
 )
@@ -219,6 +223,90 @@ func (s *Service) SendPasswordUpdate(UserName string, UserEmail string) error {
 	return nil
 }
 
+// SendMFARecoveryCodeUsed sends a notice to the user that one of their MFA recovery codes was used to log in
+func (s *Service) SendMFARecoveryCodeUsed(UserName string, UserEmail string, RemainingCodes int) error {
+	emailBody, err := s.generateBody(
+		hermes.Body{
+			Name: UserName,
+			Intros: []string{
+				"An MFA recovery code was just used to log in to your Thunderdome account.",
+				fmt.Sprintf("You have %d unused recovery codes remaining.", RemainingCodes),
+			},
+			Outros: []string{
+				"If this wasn't you, reset your password and regenerate your recovery codes immediately.",
+			},
+			Actions: []hermes.Action{
+				{
+					Instructions: "Need help, or have questions? Visit our Github page",
+					Button: hermes.Button{
+						Text: "Github Repo",
+						Link: "https://github.com/StevenWeathers/thunderdome-planning-poker/",
+					},
+				},
+			},
+		},
+	)
+	if err != nil {
+		s.Logger.Error("Error Generating MFA Recovery Code Used Email HTML", zap.Error(err))
+		return err
+	}
+
+	sendErr := s.send(
+		UserName,
+		UserEmail,
+		"An MFA recovery code was used on your Thunderdome account",
+		emailBody,
+	)
+	if sendErr != nil {
+		s.Logger.Error("Error sending MFA Recovery Code Used Email", zap.Error(sendErr))
+		return sendErr
+	}
+
+	return nil
+}
+
+// SendAccountLocked sends a notice to the user that their account was temporarily locked after repeated failed logins
+func (s *Service) SendAccountLocked(UserName string, UserEmail string, Lockout time.Duration) error {
+	emailBody, err := s.generateBody(
+		hermes.Body{
+			Name: UserName,
+			Intros: []string{
+				"Your Thunderdome account was temporarily locked after repeated failed login attempts.",
+				fmt.Sprintf("You can try logging in again in %d minutes.", int(math.Ceil(Lockout.Minutes()))),
+			},
+			Outros: []string{
+				"If this wasn't you, someone may be trying to guess your password, consider resetting it and enabling MFA.",
+			},
+			Actions: []hermes.Action{
+				{
+					Instructions: "Need help, or have questions? Visit our Github page",
+					Button: hermes.Button{
+						Text: "Github Repo",
+						Link: "https://github.com/StevenWeathers/thunderdome-planning-poker/",
+					},
+				},
+			},
+		},
+	)
+	if err != nil {
+		s.Logger.Error("Error Generating Account Locked Email HTML", zap.Error(err))
+		return err
+	}
+
+	sendErr := s.send(
+		UserName,
+		UserEmail,
+		"Your Thunderdome account was temporarily locked",
+		emailBody,
+	)
+	if sendErr != nil {
+		s.Logger.Error("Error sending Account Locked Email", zap.Error(sendErr))
+		return sendErr
+	}
+
+	return nil
+}
+
 // SendDeleteConfirmation Sends an delete account confirmation email to user
 func (s *Service) SendDeleteConfirmation(UserName string, UserEmail string) error {
 	emailBody, err := s.generateBody(
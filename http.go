@@ -5,18 +5,28 @@ import (
 	"io/fs"
 	"net/http"
 	"os"
+	"time"
 
+	"github.com/StevenWeathers/thunderdome-planning-poker/audit"
+	"github.com/StevenWeathers/thunderdome-planning-poker/chat"
 	"github.com/StevenWeathers/thunderdome-planning-poker/db/admin"
 	"github.com/StevenWeathers/thunderdome-planning-poker/db/alert"
 	"github.com/StevenWeathers/thunderdome-planning-poker/db/apikey"
+	dbaudit "github.com/StevenWeathers/thunderdome-planning-poker/db/audit"
 	"github.com/StevenWeathers/thunderdome-planning-poker/db/auth"
+	dbemail "github.com/StevenWeathers/thunderdome-planning-poker/db/email"
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/job"
 	"github.com/StevenWeathers/thunderdome-planning-poker/db/poker"
+	dbratelimit "github.com/StevenWeathers/thunderdome-planning-poker/db/ratelimit"
 	"github.com/StevenWeathers/thunderdome-planning-poker/db/retro"
 	"github.com/StevenWeathers/thunderdome-planning-poker/db/storyboard"
 	"github.com/StevenWeathers/thunderdome-planning-poker/db/team"
 	"github.com/StevenWeathers/thunderdome-planning-poker/db/user"
 
 	api "github.com/StevenWeathers/thunderdome-planning-poker/http"
+	"github.com/StevenWeathers/thunderdome-planning-poker/ratelimit"
+	"github.com/StevenWeathers/thunderdome-planning-poker/scheduler"
+	"github.com/StevenWeathers/thunderdome-planning-poker/throttle"
 	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
 	"github.com/spf13/viper"
 )
@@ -43,6 +53,7 @@ func (s *server) routes() {
 
 	httpConfig := &api.Config{
 		AppDomain:                 s.config.AppDomain,
+		AppURL:                    appURL(s.config.AppDomain, s.config.PathPrefix, viper.GetBool("http.secure_protocol")),
 		FrontendCookieName:        s.config.FrontendCookieName,
 		SecureCookieName:          viper.GetString("http.backend_cookie_name"),
 		SecureCookieFlag:          viper.GetBool("http.secure_cookie"),
@@ -59,6 +70,12 @@ func (s *server) routes() {
 		OrganizationsEnabled:      viper.GetBool("config.organizations_enabled"),
 		AvatarService:             s.config.AvatarService,
 		EmbedUseOS:                embedUseOS,
+		SlackSigningSecret:        viper.GetString("slack.signing_secret"),
+		SlackBotToken:             viper.GetString("slack.bot_token"),
+		PasskeyLoginEnabled:       viper.GetBool("auth.passkey.passwordless") && viper.GetString("auth.method") == "normal",
+		MetricsEnabled:            viper.GetBool("metrics.enabled"),
+		MetricsListenAddress:      viper.GetString("metrics.listen_address"),
+		MetricsBearerToken:        viper.GetString("metrics.bearer_token"),
 	}
 
 	appConfig := thunderdome.AppConfig{
@@ -86,6 +103,7 @@ func (s *server) routes() {
 		ShowActiveCountries:       viper.GetBool("config.show_active_countries"),
 		LdapEnabled:               s.config.LdapEnabled,
 		HeaderAuthEnabled:         s.config.HeaderAuthEnabled,
+		PasskeyLoginEnabled:       viper.GetBool("auth.passkey.passwordless") && viper.GetString("auth.method") == "normal",
 		FeaturePoker:              viper.GetBool("feature.poker"),
 		FeatureRetro:              viper.GetBool("feature.retro"),
 		FeatureStoryboard:         viper.GetBool("feature.storyboard"),
@@ -108,31 +126,158 @@ func (s *server) routes() {
 		HTMLSanitizerPolicy: s.db.HTMLSanitizerPolicy,
 	}
 	checkinService := &team.CheckinService{DB: s.db.DB, Logger: s.logger, HTMLSanitizerPolicy: s.db.HTMLSanitizerPolicy}
+	s.CheckinService = checkinService
 	retroService := &retro.Service{DB: s.db.DB, Logger: s.logger, AESHashKey: s.db.Config.AESHashkey}
 	storyboardService := &storyboard.Service{DB: s.db.DB, Logger: s.logger, AESHashKey: s.db.Config.AESHashkey}
 	teamService := &team.Service{DB: s.db.DB, Logger: s.logger}
 	organizationService := &team.OrganizationService{DB: s.db.DB, Logger: s.logger}
 	adminService := &admin.Service{DB: s.db.DB, Logger: s.logger}
+	chatIntegrationService := &team.ChatIntegrationService{DB: s.db.DB, Logger: s.logger, AESHashKey: s.db.Config.AESHashkey}
+	inviteService := &team.InviteService{DB: s.db.DB, Logger: s.logger}
+	scimService := &team.SCIMService{DB: s.db.DB, Logger: s.logger}
+	serviceAccountService := &team.ServiceAccountService{DB: s.db.DB, Logger: s.logger}
+	auditService := &dbaudit.Service{DB: s.db.DB, Logger: s.logger}
+	schedules, err := jobSchedules()
+	if err != nil {
+		s.logger.Fatal(err.Error())
+	}
+	trustedProxies, err := throttle.ParseTrustedProxies(viper.GetString("http.trusted_proxies"))
+	if err != nil {
+		s.logger.Fatal(err.Error())
+	}
+	maintenanceJobs := maintenance(s.email, userService, battleService, retroService, storyboardService, authService)
+	s.jobScheduler = &scheduler.JobScheduler{
+		Interval:   time.Minute,
+		Enabled:    viper.GetBool("jobs.enabled"),
+		Logger:     s.logger,
+		JobDataSvc: &job.Service{DB: s.db.DB, Logger: s.logger},
+		Jobs:       maintenanceJobs.Jobs(schedules),
+	}
 
 	a := api.Service{
-		Config:              httpConfig,
-		Router:              s.router,
-		Email:               s.email,
-		Cookie:              s.cookie,
-		Logger:              s.logger,
-		UserDataSvc:         userService,
-		ApiKeyDataSvc:       apkService,
-		AlertDataSvc:        s.AlertService,
-		AuthDataSvc:         authService,
-		PokerDataSvc:        battleService,
-		CheckinDataSvc:      checkinService,
-		RetroDataSvc:        retroService,
-		StoryboardDataSvc:   storyboardService,
-		TeamDataSvc:         teamService,
-		OrganizationDataSvc: organizationService,
-		AdminDataSvc:        adminService,
-		UIConfig:            uiConfig,
-	}
-
-	api.Init(a, FSS, HFS)
+		Config:                 httpConfig,
+		Router:                 s.router,
+		Email:                  s.email,
+		ChatService:            chat.New(httpConfig.AppURL, s.logger, chatIntegrationService),
+		Cookie:                 s.cookie,
+		Logger:                 s.logger,
+		UserDataSvc:            userService,
+		ApiKeyDataSvc:          apkService,
+		AlertDataSvc:           s.AlertService,
+		AuthDataSvc:            authService,
+		PokerDataSvc:           battleService,
+		CheckinDataSvc:         checkinService,
+		RetroDataSvc:           retroService,
+		StoryboardDataSvc:      storyboardService,
+		TeamDataSvc:            teamService,
+		OrganizationDataSvc:    organizationService,
+		AdminDataSvc:           adminService,
+		ChatIntegrationDataSvc: chatIntegrationService,
+		InviteDataSvc:          inviteService,
+		SCIMDataSvc:            scimService,
+		ServiceAccountDataSvc:  serviceAccountService,
+		PasskeyDataSvc:         authService,
+		AuditDataSvc:           auditService,
+		EmailQueueDataSvc:      &dbemail.Service{DB: s.db.DB, Logger: s.logger},
+		Audit:                  &audit.Recorder{DataSvc: auditService, Logger: s.logger},
+		Maintenance:            maintenanceJobs,
+		AuthLimiter: &throttle.Limiter{
+			Config: throttle.Config{
+				AccountAttempts:  viper.GetInt("auth.throttle.account_attempts"),
+				IPAttempts:       viper.GetInt("auth.throttle.ip_attempts"),
+				JoinCodeAttempts: viper.GetInt("auth.throttle.join_code_attempts"),
+				Lockout:          time.Duration(viper.GetInt("auth.throttle.lockout_seconds")) * time.Second,
+				MaxLockout:       time.Duration(viper.GetInt("auth.throttle.max_lockout_seconds")) * time.Second,
+				TrustedProxies:   trustedProxies,
+			},
+			DataSvc: authService,
+			Logger:  s.logger,
+		},
+		RateLimiter: s.rateLimiter(),
+		ReadyCheck:  s.db.Ready,
+		JobStatus:   s.jobScheduler.Status,
+		UIConfig:    uiConfig,
+	}
+
+	s.api = api.Init(a, FSS, HFS)
+}
+
+// appURL gets the applications base URL for links back to it
+func appURL(AppDomain string, PathPrefix string, SecureProtocol bool) string {
+	scheme := "http"
+	if SecureProtocol {
+		scheme = "https"
+	}
+
+	return scheme + "://" + AppDomain + PathPrefix + "/"
+}
+
+// jobSchedules parses the scheduled jobs cron expressions from config, a job without a schedule is disabled
+func jobSchedules() (map[string]*scheduler.Cron, error) {
+	schedules := make(map[string]*scheduler.Cron)
+	for _, name := range []string{
+		scheduler.JobCleanGuests, scheduler.JobCleanBattles, scheduler.JobCleanRetros,
+		scheduler.JobCleanStoryboards, scheduler.JobLowercaseUserEmails, scheduler.JobCleanAuthAttempts,
+	} {
+		expr := viper.GetString("jobs." + name)
+		if expr == "" {
+			continue
+		}
+
+		schedule, err := scheduler.ParseCron(expr)
+		if err != nil {
+			return nil, err
+		}
+		schedules[name] = schedule
+	}
+
+	return schedules, nil
+}
+
+// maintenance creates the data cleanup jobs with the retention days from config
+func maintenance(
+	Email thunderdome.EmailService, UserDataSvc thunderdome.UserDataSvc, PokerDataSvc thunderdome.PokerDataSvc,
+	RetroDataSvc thunderdome.RetroDataSvc, StoryboardDataSvc thunderdome.StoryboardDataSvc,
+	AuthAttemptDataSvc thunderdome.AuthAttemptDataSvc,
+) *scheduler.Maintenance {
+	return &scheduler.Maintenance{
+		Email:              Email,
+		UserDataSvc:        UserDataSvc,
+		PokerDataSvc:       PokerDataSvc,
+		RetroDataSvc:       RetroDataSvc,
+		StoryboardDataSvc:  StoryboardDataSvc,
+		AuthAttemptDataSvc: AuthAttemptDataSvc,
+		GuestsDaysOld:      viper.GetInt("config.cleanup_guests_days_old"),
+		BattlesDaysOld:     viper.GetInt("config.cleanup_battles_days_old"),
+		RetrosDaysOld:      viper.GetInt("config.cleanup_retros_days_old"),
+		StoryboardsDaysOld: viper.GetInt("config.cleanup_storyboards_days_old"),
+	}
+}
+
+// rateLimiter creates the API request rate limiter from config, nil when rate limiting is disabled
+func (s *server) rateLimiter() *ratelimit.Limiter {
+	if !viper.GetBool("ratelimit.enabled") {
+		return nil
+	}
+
+	groups, err := ratelimit.ParseLimits(viper.GetString("ratelimit.groups"))
+	if err != nil {
+		s.logger.Fatal(err.Error())
+	}
+
+	var store thunderdome.RateLimitDataSvc = ratelimit.NewMemoryStore()
+	if viper.GetString("ratelimit.store") == "postgres" {
+		store = &dbratelimit.Service{DB: s.db.DB, Logger: s.logger}
+	}
+
+	return &ratelimit.Limiter{
+		Default: ratelimit.Limit{
+			Requests: viper.GetInt("ratelimit.requests"),
+			Period:   time.Duration(viper.GetInt("ratelimit.period_seconds")) * time.Second,
+		},
+		Groups:       groups,
+		AllowOnError: viper.GetBool("ratelimit.allow_on_error"),
+		DataSvc:      store,
+		Logger:       s.logger,
+	}
 }
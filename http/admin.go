@@ -5,6 +5,8 @@ import (
 	"io"
 	"net/http"
 
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
 	"github.com/gorilla/mux"
 )
 
@@ -108,6 +110,8 @@ func (s *Service) handleUserCreate() http.HandlerFunc {
 
 		_ = s.Email.SendWelcome(user.Name, user.Email, VerifyID)
 
+		s.audit(r, thunderdome.AuditActionUserCreate, "user", newUser.Id, nil, map[string]string{"name": user.Name, "email": user.Email})
+
 		s.Success(w, r, http.StatusOK, newUser, nil)
 	}
 }
@@ -133,12 +137,20 @@ func (s *Service) handleUserPromote() http.HandlerFunc {
 			return
 		}
 
+		User, userErr := s.UserDataSvc.GetUser(r.Context(), UserID)
+		if userErr != nil {
+			s.Failure(w, r, http.StatusInternalServerError, userErr)
+			return
+		}
+
 		err := s.UserDataSvc.PromoteUser(r.Context(), UserID)
 		if err != nil {
 			s.Failure(w, r, http.StatusInternalServerError, err)
 			return
 		}
 
+		s.audit(r, thunderdome.AuditActionUserPromote, "user", UserID, map[string]string{"type": User.Type}, map[string]string{"type": adminUserType})
+
 		s.Success(w, r, http.StatusOK, nil, nil)
 	}
 }
@@ -163,12 +175,20 @@ func (s *Service) handleUserDemote() http.HandlerFunc {
 			return
 		}
 
+		User, userErr := s.UserDataSvc.GetUser(r.Context(), UserID)
+		if userErr != nil {
+			s.Failure(w, r, http.StatusInternalServerError, userErr)
+			return
+		}
+
 		err := s.UserDataSvc.DemoteUser(r.Context(), UserID)
 		if err != nil {
 			s.Failure(w, r, http.StatusInternalServerError, err)
 			return
 		}
 
+		s.audit(r, thunderdome.AuditActionUserDemote, "user", UserID, map[string]string{"type": User.Type}, map[string]string{"type": "REGISTERED"})
+
 		s.Success(w, r, http.StatusOK, nil, nil)
 	}
 }
@@ -193,12 +213,23 @@ func (s *Service) handleUserDisable() http.HandlerFunc {
 			return
 		}
 
+		User, userErr := s.UserDataSvc.GetUser(r.Context(), UserID)
+		if userErr != nil {
+			s.Failure(w, r, http.StatusInternalServerError, userErr)
+			return
+		}
+
+		// revoke the sessions first as disabling removes them without closing their open connections
+		_ = s.revokeUserSessions(r.Context(), UserID, "")
+
 		err := s.UserDataSvc.DisableUser(r.Context(), UserID)
 		if err != nil {
 			s.Failure(w, r, http.StatusInternalServerError, err)
 			return
 		}
 
+		s.audit(r, thunderdome.AuditActionUserDisable, "user", UserID, map[string]bool{"disabled": User.Disabled}, map[string]bool{"disabled": true})
+
 		s.Success(w, r, http.StatusOK, nil, nil)
 	}
 }
@@ -223,12 +254,20 @@ func (s *Service) handleUserEnable() http.HandlerFunc {
 			return
 		}
 
+		User, userErr := s.UserDataSvc.GetUser(r.Context(), UserID)
+		if userErr != nil {
+			s.Failure(w, r, http.StatusInternalServerError, userErr)
+			return
+		}
+
 		err := s.UserDataSvc.EnableUser(r.Context(), UserID)
 		if err != nil {
 			s.Failure(w, r, http.StatusInternalServerError, err)
 			return
 		}
 
+		s.audit(r, thunderdome.AuditActionUserEnable, "user", UserID, map[string]bool{"disabled": User.Disabled}, map[string]bool{"disabled": false})
+
 		s.Success(w, r, http.StatusOK, nil, nil)
 	}
 }
@@ -279,8 +318,12 @@ func (s *Service) handleAdminUpdateUserPassword() http.HandlerFunc {
 			return
 		}
 
+		_ = s.revokeUserSessions(r.Context(), UserID, "")
+
 		_ = s.Email.SendPasswordUpdate(UserName, UserEmail)
 
+		s.audit(r, thunderdome.AuditActionUserPasswordUpdate, "user", UserID, nil, nil)
+
 		s.Success(w, r, http.StatusOK, nil, nil)
 	}
 }
@@ -5,6 +5,8 @@ import (
 	"io"
 	"net/http"
 
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
 	"github.com/gorilla/mux"
 )
 
@@ -86,6 +88,8 @@ func (s *Service) handleAlertCreate() http.HandlerFunc {
 			return
 		}
 
+		s.audit(r, thunderdome.AuditActionAlertCreate, "alert", "", nil, alert)
+
 		ActiveAlerts = s.AlertDataSvc.GetActiveAlerts(r.Context())
 
 		s.Success(w, r, http.StatusOK, ActiveAlerts, nil)
@@ -132,12 +136,16 @@ func (s *Service) handleAlertUpdate() http.HandlerFunc {
 			return
 		}
 
+		Before, _ := s.AlertDataSvc.AlertGet(r.Context(), ID)
+
 		err := s.AlertDataSvc.AlertsUpdate(r.Context(), ID, alert.Name, alert.Type, alert.Content, alert.Active, alert.AllowDismiss, alert.RegisteredOnly)
 		if err != nil {
 			s.Failure(w, r, http.StatusInternalServerError, err)
 			return
 		}
 
+		s.audit(r, thunderdome.AuditActionAlertUpdate, "alert", ID, Before, alert)
+
 		ActiveAlerts = s.AlertDataSvc.GetActiveAlerts(r.Context())
 
 		s.Success(w, r, http.StatusOK, ActiveAlerts, nil)
@@ -164,12 +172,16 @@ func (s *Service) handleAlertDelete() http.HandlerFunc {
 			return
 		}
 
+		Before, _ := s.AlertDataSvc.AlertGet(r.Context(), AlertID)
+
 		err := s.AlertDataSvc.AlertDelete(r.Context(), AlertID)
 		if err != nil {
 			s.Failure(w, r, http.StatusInternalServerError, err)
 			return
 		}
 
+		s.audit(r, thunderdome.AuditActionAlertDelete, "alert", AlertID, Before, nil)
+
 		ActiveAlerts = s.AlertDataSvc.GetActiveAlerts(r.Context())
 
 		s.Success(w, r, http.StatusOK, ActiveAlerts, nil)
@@ -4,6 +4,11 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
 
 	"github.com/gorilla/mux"
 )
@@ -41,11 +46,16 @@ func (s *Service) handleUserAPIKeys() http.HandlerFunc {
 
 type apikeyGenerateRequestBody struct {
 	Name string `json:"name" validate:"required"`
+	// Scopes granted to the key, the read only scopes when empty
+	Scopes []string `json:"scopes"`
+	// TeamId restricts the key to the teams routes
+	TeamId     string     `json:"teamId" validate:"omitempty,uuid"`
+	ExpireDate *time.Time `json:"expireDate"`
 }
 
 // handleAPIKeyGenerate handles generating an API key for a user
 // @Summary Generate API Key
-// @Description Generates an API key for the user
+// @Description Generates an API key for the user with the scopes (read only when empty), optional team restriction and expiration
 // @Tags apikey
 // @Produce  json
 // @Param userId path string true "the user ID to generate API key for"
@@ -59,51 +69,108 @@ func (s *Service) handleAPIKeyGenerate() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
 		UserID := vars["userId"]
-		ctx := r.Context()
 		idErr := validate.Var(UserID, "required,uuid")
 		if idErr != nil {
 			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
 			return
 		}
 
-		body, bodyErr := io.ReadAll(r.Body)
-		if bodyErr != nil {
-			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, bodyErr.Error()))
-			return
-		}
+		_ = s.apiKeyGenerate(w, r, UserID)
+	}
+}
 
-		var k = apikeyGenerateRequestBody{}
-		jsonErr := json.Unmarshal(body, &k)
-		if jsonErr != nil {
-			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, jsonErr.Error()))
-			return
-		}
+// apiKeyGenerate generates an API key for the user from the request body,
+// validating the requested scopes and team restriction, returns the generated key or nil when the request failed
+func (s *Service) apiKeyGenerate(w http.ResponseWriter, r *http.Request, UserID string) *thunderdome.APIKey {
+	ctx := r.Context()
+	body, bodyErr := io.ReadAll(r.Body)
+	if bodyErr != nil {
+		s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, bodyErr.Error()))
+		return nil
+	}
 
-		inputErr := validate.Struct(k)
-		if inputErr != nil {
-			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, inputErr.Error()))
-			return
-		}
+	var k = apikeyGenerateRequestBody{}
+	jsonErr := json.Unmarshal(body, &k)
+	if jsonErr != nil {
+		s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, jsonErr.Error()))
+		return nil
+	}
 
-		APIKeys, keysErr := s.ApiKeyDataSvc.GetUserApiKeys(ctx, UserID)
-		if keysErr != nil {
-			s.Failure(w, r, http.StatusInternalServerError, keysErr)
-			return
+	inputErr := validate.Struct(k)
+	if inputErr != nil {
+		s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, inputErr.Error()))
+		return nil
+	}
+
+	if k.ExpireDate != nil && !k.ExpireDate.After(time.Now()) {
+		s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "INVALID_EXPIRE_DATE"))
+		return nil
+	}
+
+	Scopes := k.Scopes
+	if len(Scopes) == 0 {
+		Scopes = thunderdome.APIKeyReadScopes
+	}
+	for _, scope := range Scopes {
+		if !validAPIKeyScope(scope) {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "INVALID_APIKEY_SCOPE"))
+			return nil
 		}
+	}
 
-		if len(APIKeys) == s.Config.UserAPIKeyLimit {
-			s.Failure(w, r, http.StatusForbidden, Errorf(EINVALID, "USER_APIKEY_LIMIT_REACHED"))
-			return
+	// a key can't be used to create a key with more access than itself
+	if AuthKey, ok := ctx.Value(contextKeyAPIKey).(*thunderdome.APIKey); ok {
+		if len(k.Scopes) == 0 {
+			Scopes = make([]string, 0)
+			for _, scope := range thunderdome.APIKeyReadScopes {
+				if AuthKey.HasScope(scope) {
+					Scopes = append(Scopes, scope)
+				}
+			}
+		}
+		if len(Scopes) == 0 {
+			s.Failure(w, r, http.StatusForbidden, Errorf(EUNAUTHORIZED, "APIKEY_SCOPE_REQUIRED"))
+			return nil
+		}
+		for _, scope := range Scopes {
+			if !AuthKey.HasScope(scope) {
+				s.Failure(w, r, http.StatusForbidden, Errorf(EUNAUTHORIZED, "APIKEY_SCOPE_REQUIRED"))
+				return nil
+			}
 		}
+		if AuthKey.TeamId != "" && k.TeamId != AuthKey.TeamId {
+			s.Failure(w, r, http.StatusForbidden, Errorf(EUNAUTHORIZED, "APIKEY_TEAM_RESTRICTED"))
+			return nil
+		}
+	}
 
-		APIKey, keyErr := s.ApiKeyDataSvc.GenerateApiKey(ctx, UserID, k.Name)
-		if keyErr != nil {
-			s.Failure(w, r, http.StatusInternalServerError, keyErr)
-			return
+	if k.TeamId != "" {
+		if _, err := s.TeamDataSvc.TeamUserRole(ctx, UserID, k.TeamId); err != nil {
+			s.Failure(w, r, http.StatusForbidden, Errorf(EUNAUTHORIZED, "REQUIRES_TEAM_USER"))
+			return nil
 		}
+	}
+
+	APIKeys, keysErr := s.ApiKeyDataSvc.GetUserApiKeys(ctx, UserID)
+	if keysErr != nil {
+		s.Failure(w, r, http.StatusInternalServerError, keysErr)
+		return nil
+	}
 
-		s.Success(w, r, http.StatusOK, APIKey, nil)
+	if len(APIKeys) == s.Config.UserAPIKeyLimit {
+		s.Failure(w, r, http.StatusForbidden, Errorf(EINVALID, "USER_APIKEY_LIMIT_REACHED"))
+		return nil
 	}
+
+	APIKey, keyErr := s.ApiKeyDataSvc.GenerateApiKey(ctx, UserID, k.Name, Scopes, k.TeamId, k.ExpireDate)
+	if keyErr != nil {
+		s.Failure(w, r, http.StatusInternalServerError, keyErr)
+		return nil
+	}
+
+	s.Success(w, r, http.StatusOK, APIKey, nil)
+
+	return APIKey
 }
 
 type apikeyUpdateRequestBody struct {
@@ -195,3 +262,291 @@ func (s *Service) handleUserAPIKeyDelete() http.HandlerFunc {
 		s.Success(w, r, http.StatusOK, APIKeys, nil)
 	}
 }
+
+// validAPIKeyScope checks whether the scope is a known API key scope
+func validAPIKeyScope(Scope string) bool {
+	for _, s := range thunderdome.APIKeyScopes {
+		if s == Scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// apiKeyRouteResources the resource of each API route (path template relative to /api) API keys can access,
+// routes not listed are denied to API keys
+var apiKeyRouteResources = map[string]string{
+	// admin
+	"/admin/apikeys":                 "admin",
+	"/admin/audit":                   "admin",
+	"/admin/emails/failed":           "admin",
+	"/admin/emails/{emailId}":        "admin",
+	"/admin/emails/{emailId}/retry":  "admin",
+	"/admin/jobs":                    "admin",
+	"/admin/organizations":           "admin",
+	"/admin/search/users/email":      "admin",
+	"/admin/stats":                   "admin",
+	"/admin/teams":                   "admin",
+	"/admin/users":                   "admin",
+	"/admin/users/{userId}/demote":   "admin",
+	"/admin/users/{userId}/disable":  "admin",
+	"/admin/users/{userId}/enable":   "admin",
+	"/admin/users/{userId}/password": "admin",
+	"/admin/users/{userId}/promote":  "admin",
+
+	// alerts
+	"/alerts":           "admin",
+	"/alerts/{alertId}": "admin",
+
+	// authentication
+	"/auth":                    "users",
+	"/auth/forgot-password":    "users",
+	"/auth/guest":              "users",
+	"/auth/ldap":               "users",
+	"/auth/logout":             "users",
+	"/auth/mfa":                "users",
+	"/auth/mfa/recovery-codes": "users",
+	"/auth/mfa/setup/generate": "users",
+	"/auth/mfa/setup/validate": "users",
+	"/auth/passkey":            "users",
+	"/auth/passkey/options":    "users",
+	"/auth/register":           "users",
+	"/auth/reset-password":     "users",
+	"/auth/update-password":    "users",
+	"/auth/user":               "users",
+	"/auth/verify":             "users",
+
+	// poker games
+	"/battles":                  "poker",
+	"/battles/{battleId}":       "poker",
+	"/battles/{battleId}/clone": "poker",
+	"/battles/{battleId}/plans": "poker",
+
+	// slack integration
+	"/integrations/slack/commands":     "teams",
+	"/integrations/slack/interactions": "teams",
+
+	// maintenance
+	"/maintenance/clean-battles":     "admin",
+	"/maintenance/clean-guests":      "admin",
+	"/maintenance/clean-retros":      "admin",
+	"/maintenance/clean-storyboards": "admin",
+	"/maintenance/lowercase-emails":  "admin",
+
+	// organizations, their departments and teams
+	"/organizations/{orgId}":                                                                                     "teams",
+	"/organizations/{orgId}/audit":                                                                               "teams",
+	"/organizations/{orgId}/departments":                                                                         "teams",
+	"/organizations/{orgId}/departments/{departmentId}":                                                          "teams",
+	"/organizations/{orgId}/departments/{departmentId}/invites":                                                  "teams",
+	"/organizations/{orgId}/departments/{departmentId}/invites/{inviteId}":                                       "teams",
+	"/organizations/{orgId}/departments/{departmentId}/teams":                                                    "teams",
+	"/organizations/{orgId}/departments/{departmentId}/teams/{teamId}":                                           "teams",
+	"/organizations/{orgId}/departments/{departmentId}/teams/{teamId}/battles":                                   "poker",
+	"/organizations/{orgId}/departments/{departmentId}/teams/{teamId}/battles/{battleId}":                        "poker",
+	"/organizations/{orgId}/departments/{departmentId}/teams/{teamId}/checkins":                                  "teams",
+	"/organizations/{orgId}/departments/{departmentId}/teams/{teamId}/checkins/questions":                        "teams",
+	"/organizations/{orgId}/departments/{departmentId}/teams/{teamId}/checkins/questions/{questionId}":           "teams",
+	"/organizations/{orgId}/departments/{departmentId}/teams/{teamId}/checkins/schedule":                         "teams",
+	"/organizations/{orgId}/departments/{departmentId}/teams/{teamId}/checkins/stats":                            "teams",
+	"/organizations/{orgId}/departments/{departmentId}/teams/{teamId}/checkins/{checkinId}":                      "teams",
+	"/organizations/{orgId}/departments/{departmentId}/teams/{teamId}/checkins/{checkinId}/comments":             "teams",
+	"/organizations/{orgId}/departments/{departmentId}/teams/{teamId}/checkins/{checkinId}/comments/{commentId}": "teams",
+	"/organizations/{orgId}/departments/{departmentId}/teams/{teamId}/integrations/chat":                         "teams",
+	"/organizations/{orgId}/departments/{departmentId}/teams/{teamId}/integrations/chat/{integrationId}":         "teams",
+	"/organizations/{orgId}/departments/{departmentId}/teams/{teamId}/retro-actions":                             "retro",
+	"/organizations/{orgId}/departments/{departmentId}/teams/{teamId}/retros":                                    "retro",
+	"/organizations/{orgId}/departments/{departmentId}/teams/{teamId}/retros/{retroId}":                          "retro",
+	"/organizations/{orgId}/departments/{departmentId}/teams/{teamId}/storyboards":                               "storyboard",
+	"/organizations/{orgId}/departments/{departmentId}/teams/{teamId}/storyboards/{storyboardId}":                "storyboard",
+	"/organizations/{orgId}/departments/{departmentId}/teams/{teamId}/templates":                                 "teams",
+	"/organizations/{orgId}/departments/{departmentId}/teams/{teamId}/users":                                     "teams",
+	"/organizations/{orgId}/departments/{departmentId}/teams/{teamId}/users/{userId}":                            "teams",
+	"/organizations/{orgId}/departments/{departmentId}/teams/{teamId}/users/{userId}/battles":                    "poker",
+	"/organizations/{orgId}/departments/{departmentId}/teams/{teamId}/users/{userId}/retros":                     "retro",
+	"/organizations/{orgId}/departments/{departmentId}/teams/{teamId}/users/{userId}/storyboards":                "storyboard",
+	"/organizations/{orgId}/departments/{departmentId}/users":                                                    "teams",
+	"/organizations/{orgId}/departments/{departmentId}/users/{userId}":                                           "teams",
+	"/organizations/{orgId}/domains":                                                                             "teams",
+	"/organizations/{orgId}/domains/{domainId}":                                                                  "teams",
+	"/organizations/{orgId}/domains/{domainId}/verify":                                                           "teams",
+	"/organizations/{orgId}/invites":                                                                             "teams",
+	"/organizations/{orgId}/invites/{inviteId}":                                                                  "teams",
+	"/organizations/{orgId}/policy":                                                                              "teams",
+	"/organizations/{orgId}/scim-tokens":                                                                         "organizations:admin",
+	"/organizations/{orgId}/scim-tokens/{tokenId}":                                                               "organizations:admin",
+	"/organizations/{orgId}/service-accounts":                                                                    "organizations:admin",
+	"/organizations/{orgId}/service-accounts/{userId}":                                                           "organizations:admin",
+	"/organizations/{orgId}/service-accounts/{userId}/apikeys":                                                   "organizations:admin",
+	"/organizations/{orgId}/service-accounts/{userId}/apikeys/{keyID}":                                           "organizations:admin",
+	"/organizations/{orgId}/service-accounts/{userId}/teams/{teamId}":                                            "organizations:admin",
+	"/organizations/{orgId}/teams":                                                                               "teams",
+	"/organizations/{orgId}/teams/{teamId}":                                                                      "teams",
+	"/organizations/{orgId}/teams/{teamId}/battles":                                                              "poker",
+	"/organizations/{orgId}/teams/{teamId}/battles/{battleId}":                                                   "poker",
+	"/organizations/{orgId}/teams/{teamId}/checkins":                                                             "teams",
+	"/organizations/{orgId}/teams/{teamId}/checkins/questions":                                                   "teams",
+	"/organizations/{orgId}/teams/{teamId}/checkins/questions/{questionId}":                                      "teams",
+	"/organizations/{orgId}/teams/{teamId}/checkins/schedule":                                                    "teams",
+	"/organizations/{orgId}/teams/{teamId}/checkins/stats":                                                       "teams",
+	"/organizations/{orgId}/teams/{teamId}/checkins/{checkinId}":                                                 "teams",
+	"/organizations/{orgId}/teams/{teamId}/checkins/{checkinId}/comments":                                        "teams",
+	"/organizations/{orgId}/teams/{teamId}/checkins/{checkinId}/comments/{commentId}":                            "teams",
+	"/organizations/{orgId}/teams/{teamId}/integrations/chat":                                                    "teams",
+	"/organizations/{orgId}/teams/{teamId}/integrations/chat/{integrationId}":                                    "teams",
+	"/organizations/{orgId}/teams/{teamId}/retro-actions":                                                        "retro",
+	"/organizations/{orgId}/teams/{teamId}/retros":                                                               "retro",
+	"/organizations/{orgId}/teams/{teamId}/retros/{retroId}":                                                     "retro",
+	"/organizations/{orgId}/teams/{teamId}/storyboards":                                                          "storyboard",
+	"/organizations/{orgId}/teams/{teamId}/storyboards/{storyboardId}":                                           "storyboard",
+	"/organizations/{orgId}/teams/{teamId}/templates":                                                            "teams",
+	"/organizations/{orgId}/teams/{teamId}/users":                                                                "teams",
+	"/organizations/{orgId}/teams/{teamId}/users/{userId}":                                                       "teams",
+	"/organizations/{orgId}/teams/{teamId}/users/{userId}/battles":                                               "poker",
+	"/organizations/{orgId}/teams/{teamId}/users/{userId}/retros":                                                "retro",
+	"/organizations/{orgId}/teams/{teamId}/users/{userId}/storyboards":                                           "storyboard",
+	"/organizations/{orgId}/users":                                                                               "teams",
+	"/organizations/{orgId}/users/{userId}":                                                                      "teams",
+
+	// retros
+	"/retros":                              "retro",
+	"/retros/{retroId}":                    "retro",
+	"/retros/{retroId}/actions/{actionId}": "retro",
+	"/retros/{retroId}/actions/{actionId}/comments":             "retro",
+	"/retros/{retroId}/actions/{actionId}/comments/{commentId}": "retro",
+	"/retros/{retroId}/clone":                                   "retro",
+
+	// storyboards
+	"/storyboards":                        "storyboard",
+	"/storyboards/{storyboardId}":         "storyboard",
+	"/storyboards/{storyboardId}/clone":   "storyboard",
+	"/storyboards/{storyboardId}/history": "storyboard",
+
+	// teams
+	"/teams/{teamId}":                                           "teams",
+	"/teams/{teamId}/battles":                                   "poker",
+	"/teams/{teamId}/battles/{battleId}":                        "poker",
+	"/teams/{teamId}/checkins":                                  "teams",
+	"/teams/{teamId}/checkins/questions":                        "teams",
+	"/teams/{teamId}/checkins/questions/{questionId}":           "teams",
+	"/teams/{teamId}/checkins/schedule":                         "teams",
+	"/teams/{teamId}/checkins/stats":                            "teams",
+	"/teams/{teamId}/checkins/{checkinId}":                      "teams",
+	"/teams/{teamId}/checkins/{checkinId}/comments":             "teams",
+	"/teams/{teamId}/checkins/{checkinId}/comments/{commentId}": "teams",
+	"/teams/{teamId}/integrations/chat":                         "teams",
+	"/teams/{teamId}/integrations/chat/{integrationId}":         "teams",
+	"/teams/{teamId}/invites":                                   "teams",
+	"/teams/{teamId}/invites/{inviteId}":                        "teams",
+	"/teams/{teamId}/retro-actions":                             "retro",
+	"/teams/{teamId}/retros":                                    "retro",
+	"/teams/{teamId}/retros/{retroId}":                          "retro",
+	"/teams/{teamId}/storyboards":                               "storyboard",
+	"/teams/{teamId}/storyboards/{storyboardId}":                "storyboard",
+	"/teams/{teamId}/templates":                                 "teams",
+	"/teams/{teamId}/users":                                     "teams",
+	"/teams/{teamId}/users/{userId}":                            "teams",
+	"/teams/{teamId}/users/{userId}/battles":                    "poker",
+	"/teams/{teamId}/users/{userId}/retros":                     "retro",
+	"/teams/{teamId}/users/{userId}/storyboards":                "storyboard",
+
+	// users
+	"/users/{userId}":                      "users",
+	"/users/{userId}/apikeys":              "users",
+	"/users/{userId}/apikeys/{keyID}":      "users",
+	"/users/{userId}/battles":              "poker",
+	"/users/{userId}/organizations":        "teams",
+	"/users/{userId}/passkeys":             "users",
+	"/users/{userId}/passkeys/options":     "users",
+	"/users/{userId}/passkeys/{passkeyId}": "users",
+	"/users/{userId}/request-verify":       "users",
+	"/users/{userId}/retros":               "retro",
+	"/users/{userId}/sessions":             "users",
+	"/users/{userId}/sessions/{sessionId}": "users",
+	"/users/{userId}/storyboards":          "storyboard",
+	"/users/{userId}/teams":                "teams",
+}
+
+// apiKeyRouteScope resolves the API key scope required by the requests matched route,
+// only read access is required for GET requests, returns empty when API keys can't access the route
+func apiKeyRouteScope(r *http.Request) string {
+	var path string
+	if route := mux.CurrentRoute(r); route != nil {
+		path, _ = route.GetPathTemplate()
+	}
+	if i := strings.Index(path, "/api/"); i != -1 {
+		path = path[i+len("/api"):]
+	}
+
+	resource, ok := apiKeyRouteResources[path]
+	if !ok {
+		return ""
+	}
+	if resource == thunderdome.APIKeyScopeAdmin || resource == thunderdome.APIKeyScopeOrganizationsAdmin {
+		return resource
+	}
+
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return resource + ":read"
+	}
+
+	return resource + ":write"
+}
+
+// apiKeyRouteAllowed checks the API key was granted the scope required by the route
+// and when restricted to a team that the route is for that team
+func apiKeyRouteAllowed(r *http.Request, APIKey *thunderdome.APIKey) error {
+	Scope := apiKeyRouteScope(r)
+	if Scope == "" || !APIKey.HasScope(Scope) {
+		return Errorf(EUNAUTHORIZED, "APIKEY_SCOPE_REQUIRED")
+	}
+
+	if APIKey.TeamId != "" && mux.Vars(r)["teamId"] != APIKey.TeamId {
+		return Errorf(EUNAUTHORIZED, "APIKEY_TEAM_RESTRICTED")
+	}
+
+	return nil
+}
+
+// apiKeyUsedInterval how often an API keys last used date and IP are recorded
+const apiKeyUsedInterval = time.Minute
+
+// apiKeyUsage tracks when each API keys usage was last recorded
+// to avoid writing to the database on every request made with the key
+type apiKeyUsage struct {
+	sync.Mutex
+	recorded map[string]time.Time
+}
+
+// due checks whether the API keys usage should be recorded, marking it as recorded when it is
+func (u *apiKeyUsage) due(KeyID string, now time.Time) bool {
+	u.Lock()
+	defer u.Unlock()
+
+	if u.recorded == nil {
+		u.recorded = make(map[string]time.Time)
+	}
+	if last, ok := u.recorded[KeyID]; ok && now.Sub(last) < apiKeyUsedInterval {
+		return false
+	}
+
+	// drop the keys not used within the interval so the map doesn't keep every key ever used
+	for id, last := range u.recorded {
+		if now.Sub(last) >= apiKeyUsedInterval {
+			delete(u.recorded, id)
+		}
+	}
+	u.recorded[KeyID] = now
+
+	return true
+}
+
+// apiKeyUsed records the API keys last used date and IP at most once per apiKeyUsedInterval
+func (s *Service) apiKeyUsed(r *http.Request, KeyID string) {
+	if !s.apiKeyUsage.due(KeyID, time.Now()) {
+		return
+	}
+
+	_ = s.ApiKeyDataSvc.APIKeyUsed(r.Context(), KeyID, s.AuthLimiter.ClientIP(r))
+}
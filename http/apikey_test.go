@@ -0,0 +1,167 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"github.com/gorilla/mux"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+	"go.uber.org/zap"
+)
+
+type testAlertDataSvc struct {
+	thunderdome.AlertDataSvc
+}
+
+func (testAlertDataSvc) GetActiveAlerts(ctx context.Context) []interface{} {
+	return nil
+}
+
+type testApiKeyDataSvc struct {
+	thunderdome.APIKeyDataSvc
+	APIKey *thunderdome.APIKey
+}
+
+func (s testApiKeyDataSvc) GetApiKeyUser(ctx context.Context, APK string) (*thunderdome.User, *thunderdome.APIKey, error) {
+	return &thunderdome.User{Id: s.APIKey.UserId}, s.APIKey, nil
+}
+
+// TestAPIKeyRouteResources makes sure every API route has its API key resource mapped,
+// so a new route isn't left inaccessible to API keys by mistake
+func TestAPIKeyRouteResources(t *testing.T) {
+	configs := []*Config{
+		{ExternalAPIEnabled: true, OrganizationsEnabled: true, FeaturePoker: true, FeatureRetro: true, FeatureStoryboard: true, PasskeyLoginEnabled: true, SlackSigningSecret: "bifrost"},
+		{ExternalAPIEnabled: true, LdapEnabled: true},
+	}
+	FSS := fstest.MapFS{"static/index.html": &fstest.MapFile{Data: []byte("")}}
+
+	for _, c := range configs {
+		a := Init(Service{
+			Config:       c,
+			Router:       mux.NewRouter(),
+			Logger:       otelzap.New(zap.NewNop()),
+			AlertDataSvc: testAlertDataSvc{},
+		}, FSS, http.FS(fstest.MapFS{}))
+
+		_ = a.Router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+			path, err := route.GetPathTemplate()
+			if err != nil || !strings.HasPrefix(path, "/api/") {
+				return nil
+			}
+			// websocket and subrouter routes don't have methods
+			if methods, _ := route.GetMethods(); len(methods) == 0 {
+				return nil
+			}
+			if _, ok := apiKeyRouteResources[strings.TrimPrefix(path, "/api")]; !ok {
+				t.Errorf("expected %s to have an API key resource", path)
+			}
+			return nil
+		})
+	}
+}
+
+// TestAPIKeyRouteAllowed makes sure API keys only access the routes their scopes grant
+func TestAPIKeyRouteAllowed(t *testing.T) {
+	tests := []struct {
+		method  string
+		path    string
+		scopes  []string
+		allowed bool
+	}{
+		{http.MethodGet, "/api/battles/{battleId}", []string{thunderdome.APIKeyScopePokerRead}, true},
+		{http.MethodDelete, "/api/battles/{battleId}", []string{thunderdome.APIKeyScopePokerRead}, false},
+		{http.MethodDelete, "/api/battles/{battleId}", []string{thunderdome.APIKeyScopePokerWrite}, true},
+		{http.MethodGet, "/api/teams/{teamId}/retros", []string{thunderdome.APIKeyScopeTeamsRead}, false},
+		{http.MethodGet, "/api/teams/{teamId}/retros", []string{thunderdome.APIKeyScopeRetroRead}, true},
+		{http.MethodGet, "/api/admin/users", thunderdome.APIKeyReadScopes, false},
+		{http.MethodGet, "/api/admin/users", []string{thunderdome.APIKeyScopeAdmin}, true},
+		{http.MethodGet, "/api/unmapped", thunderdome.APIKeyScopes, false},
+		{http.MethodGet, "/api/organizations/{orgId}/scim-tokens", []string{thunderdome.APIKeyScopeTeamsWrite}, false},
+		{http.MethodPost, "/api/organizations/{orgId}/scim-tokens", []string{thunderdome.APIKeyScopeTeamsWrite}, false},
+		{http.MethodPost, "/api/organizations/{orgId}/scim-tokens", []string{thunderdome.APIKeyScopeOrganizationsAdmin}, true},
+		{http.MethodGet, "/api/organizations/{orgId}/service-accounts", []string{thunderdome.APIKeyScopeTeamsWrite}, false},
+		{http.MethodPost, "/api/organizations/{orgId}/service-accounts", []string{thunderdome.APIKeyScopeTeamsWrite}, false},
+		{http.MethodPost, "/api/organizations/{orgId}/service-accounts/{userId}/apikeys", []string{thunderdome.APIKeyScopeTeamsWrite}, false},
+		{http.MethodPost, "/api/organizations/{orgId}/service-accounts/{userId}/apikeys", []string{thunderdome.APIKeyScopeOrganizationsAdmin}, true},
+	}
+
+	for _, tt := range tests {
+		router := mux.NewRouter()
+		var err error
+		router.HandleFunc(tt.path, func(w http.ResponseWriter, r *http.Request) {
+			err = apiKeyRouteAllowed(r, &thunderdome.APIKey{Scopes: tt.scopes})
+		})
+		path := strings.NewReplacer("{battleId}", "mjolnir", "{teamId}", "asgard", "{orgId}", "avengers", "{userId}", "thor").Replace(tt.path)
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, path, nil))
+
+		if allowed := err == nil; allowed != tt.allowed {
+			t.Errorf("%s %s with %v allowed = %v, expected %v", tt.method, tt.path, tt.scopes, allowed, tt.allowed)
+		}
+	}
+}
+
+// TestAPIKeyOrganizationCredentialRoutes makes sure a teams scoped API key is forbidden
+// from managing an organizations SCIM tokens and service accounts
+func TestAPIKeyOrganizationCredentialRoutes(t *testing.T) {
+	a := Init(Service{
+		Config:       &Config{ExternalAPIEnabled: true, OrganizationsEnabled: true},
+		Router:       mux.NewRouter(),
+		Logger:       otelzap.New(zap.NewNop()),
+		AlertDataSvc: testAlertDataSvc{},
+		ApiKeyDataSvc: testApiKeyDataSvc{APIKey: &thunderdome.APIKey{
+			Id:     "mjolnir",
+			UserId: "thor",
+			Scopes: []string{thunderdome.APIKeyScopeTeamsRead, thunderdome.APIKeyScopeTeamsWrite},
+		}},
+	}, fstest.MapFS{"static/index.html": &fstest.MapFile{Data: []byte("")}}, http.FS(fstest.MapFS{}))
+
+	orgID := "0b0e4a6c-6f3e-4c8a-9d1a-2c2d6c6f7a11"
+	userID := "5f1c8f0e-8e0b-4c8e-b6a5-3b9b8f5d2e22"
+	routes := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodGet, "/api/organizations/" + orgID + "/scim-tokens"},
+		{http.MethodPost, "/api/organizations/" + orgID + "/scim-tokens"},
+		{http.MethodGet, "/api/organizations/" + orgID + "/service-accounts"},
+		{http.MethodPost, "/api/organizations/" + orgID + "/service-accounts"},
+		{http.MethodPost, "/api/organizations/" + orgID + "/service-accounts/" + userID + "/apikeys"},
+	}
+
+	for _, rt := range routes {
+		req := httptest.NewRequest(rt.method, rt.path, strings.NewReader("{}"))
+		req.Header.Set(apiKeyHeaderName, "mjolnir.stormbreaker")
+		w := httptest.NewRecorder()
+		a.Router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s %s with a teams scoped key status = %d, expected %d", rt.method, rt.path, w.Code, http.StatusForbidden)
+		}
+	}
+}
+
+// TestAPIKeyUsageDue makes sure an API keys usage is recorded at most once per interval
+func TestAPIKeyUsageDue(t *testing.T) {
+	u := &apiKeyUsage{}
+	now := time.Now()
+
+	if !u.due("mjolnir", now) {
+		t.Fatal("expected the first use to be recorded")
+	}
+	if u.due("mjolnir", now.Add(time.Second)) {
+		t.Fatal("expected a use within the interval not to be recorded")
+	}
+	if !u.due("stormbreaker", now.Add(time.Second)) {
+		t.Fatal("expected another keys first use to be recorded")
+	}
+	if !u.due("mjolnir", now.Add(apiKeyUsedInterval)) {
+		t.Fatal("expected a use after the interval to be recorded")
+	}
+}
@@ -0,0 +1,221 @@
+package http
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"github.com/gorilla/mux"
+)
+
+// rows fetched per query when exporting the audit log as CSV
+const auditExportPageSize = 500
+
+// audit records the session users action on the target to the audit log,
+// scoped to the organization of the route when it has one
+func (s *Service) audit(r *http.Request, Action string, TargetType string, TargetID string, Before interface{}, After interface{}) {
+	ctx := r.Context()
+	ActorID, _ := ctx.Value(contextKeyUserID).(string)
+
+	s.Audit.Record(ctx, thunderdome.AuditLog{
+		ActorId:    ActorID,
+		OrgId:      mux.Vars(r)["orgId"],
+		Action:     Action,
+		TargetType: TargetType,
+		TargetId:   TargetID,
+		Before:     Before,
+		After:      After,
+		IP:         s.AuthLimiter.ClientIP(r),
+	})
+}
+
+// getAuditLogFilterFromRequest gets the audit log filter query parameters from the request
+func getAuditLogFilterFromRequest(r *http.Request) (thunderdome.AuditLogFilter, error) {
+	query := r.URL.Query()
+	Filter := thunderdome.AuditLogFilter{
+		ActorId:    query.Get("actorId"),
+		Action:     query.Get("action"),
+		TargetType: query.Get("targetType"),
+		TargetId:   query.Get("targetId"),
+	}
+
+	if err := validate.Var(Filter.ActorId, "omitempty,uuid"); err != nil {
+		return Filter, Errorf(EINVALID, "INVALID_ACTOR_ID")
+	}
+
+	if since := query.Get("since"); since != "" {
+		Since, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return Filter, Errorf(EINVALID, "INVALID_SINCE_DATE")
+		}
+		Filter.Since = &Since
+	}
+
+	if until := query.Get("until"); until != "" {
+		Until, err := time.Parse(time.RFC3339, until)
+		if err != nil {
+			return Filter, Errorf(EINVALID, "INVALID_UNTIL_DATE")
+		}
+		Filter.Until = &Until
+	}
+
+	return Filter, nil
+}
+
+// auditLogs responds with the audit log entries matching the requests filter,
+// as a CSV export of all matching entries when requested with format=csv
+func (s *Service) auditLogs(w http.ResponseWriter, r *http.Request, OrgID string) {
+	ctx := r.Context()
+	Filter, filterErr := getAuditLogFilterFromRequest(r)
+	if filterErr != nil {
+		s.Failure(w, r, http.StatusBadRequest, filterErr)
+		return
+	}
+	Filter.OrgId = OrgID
+
+	if r.URL.Query().Get("format") == "csv" {
+		s.auditLogsCSV(w, r, Filter)
+		return
+	}
+
+	Limit, Offset := getLimitOffsetFromRequest(r)
+	Entries, Count, err := s.AuditDataSvc.AuditLogList(ctx, Filter, Limit, Offset)
+	if err != nil {
+		s.Failure(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	Meta := &pagination{
+		Count:  Count,
+		Offset: Offset,
+		Limit:  Limit,
+	}
+
+	s.Success(w, r, http.StatusOK, Entries, Meta)
+}
+
+// auditLogsCSV writes all audit log entries matching the filter as a CSV file
+func (s *Service) auditLogsCSV(w http.ResponseWriter, r *http.Request, Filter thunderdome.AuditLogFilter) {
+	ctx := r.Context()
+
+	Entries, Count, err := s.AuditDataSvc.AuditLogList(ctx, Filter, auditExportPageSize, 0)
+	if err != nil {
+		s.Failure(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit-log.csv"`)
+	w.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{
+		"id", "created_date", "actor_id", "actor_name", "organization_id",
+		"action", "target_type", "target_id", "before", "after", "ip",
+	})
+
+	for Offset := 0; Offset < Count; Offset += auditExportPageSize {
+		if Offset > 0 {
+			if Entries, _, err = s.AuditDataSvc.AuditLogList(ctx, Filter, auditExportPageSize, Offset); err != nil {
+				break
+			}
+		}
+
+		for _, e := range Entries {
+			_ = cw.Write(csvSafeRecord([]string{
+				e.Id, e.CreatedDate.UTC().Format(time.RFC3339), e.ActorId, e.ActorName, e.OrgId,
+				e.Action, e.TargetType, e.TargetId, auditStateString(e.Before), auditStateString(e.After), e.IP,
+			}))
+		}
+		cw.Flush()
+	}
+
+	cw.Flush()
+}
+
+// csvSafeRecord prefixes the cells that spreadsheet applications would evaluate as a formula with a quote
+func csvSafeRecord(Record []string) []string {
+	for i, cell := range Record {
+		if cell != "" && strings.ContainsAny(cell[:1], "=+-@\t\r") {
+			Record[i] = "'" + cell
+		}
+	}
+
+	return Record
+}
+
+// auditStateString formats the before or after state of an audit log entry as JSON
+func auditStateString(State interface{}) string {
+	if State == nil {
+		return ""
+	}
+
+	b, _ := json.Marshal(State)
+	return string(b)
+}
+
+// handleAuditLogs gets the audit log
+// @Summary Get Audit Log
+// @Description Gets the audit log of administrative, organization, team and facilitator actions newest first,
+// @Description optionally filtered, format=csv exports all matching entries as CSV
+// @Tags admin
+// @Produce  json
+// @Produce  text/csv
+// @Param actorId query string false "the acting user ID"
+// @Param action query string false "the action e.g. user.promote"
+// @Param targetType query string false "the target type e.g. user"
+// @Param targetId query string false "the target ID"
+// @Param since query string false "RFC3339 date entries were created on or after"
+// @Param until query string false "RFC3339 date entries were created before"
+// @Param format query string false "csv to export as CSV"
+// @Param limit query int false "Max number of results to return"
+// @Param offset query int false "Starting point to return rows from, should be multiplied by limit or 0"
+// @Success 200 object standardJsonResponse{data=[]thunderdome.AuditLog}
+// @Failure 400 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /admin/audit [get]
+func (s *Service) handleAuditLogs() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.auditLogs(w, r, "")
+	}
+}
+
+// handleOrganizationAuditLogs gets the organizations audit log
+// @Summary Get Organization Audit Log
+// @Description Gets the audit log of the organizations actions newest first,
+// @Description optionally filtered, format=csv exports all matching entries as CSV
+// @Tags organization
+// @Produce  json
+// @Produce  text/csv
+// @Param orgId path string true "the organization ID"
+// @Param actorId query string false "the acting user ID"
+// @Param action query string false "the action e.g. team.delete"
+// @Param targetType query string false "the target type e.g. team"
+// @Param targetId query string false "the target ID"
+// @Param since query string false "RFC3339 date entries were created on or after"
+// @Param until query string false "RFC3339 date entries were created before"
+// @Param format query string false "csv to export as CSV"
+// @Param limit query int false "Max number of results to return"
+// @Param offset query int false "Starting point to return rows from, should be multiplied by limit or 0"
+// @Success 200 object standardJsonResponse{data=[]thunderdome.AuditLog}
+// @Failure 400 object standardJsonResponse{}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /organizations/{orgId}/audit [get]
+func (s *Service) handleOrganizationAuditLogs() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.Config.OrganizationsEnabled {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "ORGANIZATIONS_DISABLED"))
+			return
+		}
+
+		vars := mux.Vars(r)
+		s.auditLogs(w, r, vars["orgId"])
+	}
+}
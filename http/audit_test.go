@@ -0,0 +1,16 @@
+package http
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestCSVSafeRecord makes sure audit log export cells can't be evaluated as spreadsheet formulas
+func TestCSVSafeRecord(t *testing.T) {
+	Record := csvSafeRecord([]string{"=HYPERLINK(\"http://jotunheim.dev\")", "+1", "-1", "@SUM(A1)", "\t=1", "Thor", ""})
+	expected := []string{"'=HYPERLINK(\"http://jotunheim.dev\")", "'+1", "'-1", "'@SUM(A1)", "'\t=1", "Thor", ""}
+
+	if !reflect.DeepEqual(Record, expected) {
+		t.Fatalf("expected %q, got %q", expected, Record)
+	}
+}
@@ -6,14 +6,17 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/StevenWeathers/thunderdome-planning-poker/throttle"
 	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+	"github.com/StevenWeathers/thunderdome-planning-poker/webauthn"
 
 	"github.com/spf13/viper"
 )
 
 type userLoginRequestBody struct {
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=6,max=72"`
+	Email       string `json:"email" validate:"required,email"`
+	Password    string `json:"password" validate:"required,min=6,max=72"`
+	InviteToken string `json:"inviteToken"`
 }
 
 type loginResponse struct {
@@ -32,6 +35,7 @@ type loginResponse struct {
 // @Success 200 object standardJsonResponse{data=loginResponse}
 // @Failure 401 object standardJsonResponse{}
 // @Failure 500 object standardJsonResponse{}
+// @Failure 429 object standardJsonResponse{}
 // @Router /auth [post]
 func (s *Service) handleLogin() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -54,16 +58,23 @@ func (s *Service) handleLogin() http.HandlerFunc {
 			return
 		}
 
+		Email := strings.ToLower(u.Email)
+		if s.throttleLocked(w, r, throttle.ScopeLogin, Email) {
+			return
+		}
+
 		authedUser, sessionId, err := s.AuthDataSvc.AuthUser(r.Context(), u.Email, u.Password)
 		if err != nil {
 			userErr := err.Error()
 			if userErr == "USER_NOT_FOUND" || userErr == "INVALID_PASSWORD" || userErr == "USER_DISABLED" {
+				s.loginFailed(r, Email)
 				s.Failure(w, r, http.StatusUnauthorized, Errorf(EINVALID, "INVALID_LOGIN"))
 			} else {
 				s.Failure(w, r, http.StatusInternalServerError, err)
 			}
 			return
 		}
+		s.AuthLimiter.Reset(r.Context(), throttle.ScopeLogin, Email)
 
 		res := loginResponse{
 			User:        authedUser,
@@ -76,6 +87,9 @@ func (s *Service) handleLogin() http.HandlerFunc {
 			return
 		}
 
+		s.acceptUserInvites(r.Context(), authedUser.Id, authedUser.Email, authedUser.Verified, u.InviteToken)
+		s.orgAutoJoin(r.Context(), authedUser.Id, authedUser.Email, authedUser.Verified)
+
 		cookieErr := s.createSessionCookie(w, sessionId)
 		if cookieErr != nil {
 			s.Failure(w, r, http.StatusInternalServerError, Errorf(EINVALID, "INVALID_COOKIE"))
@@ -97,6 +111,7 @@ func (s *Service) handleLogin() http.HandlerFunc {
 // @Success 200 object standardJsonResponse{data=loginResponse}
 // @Failure 401 object standardJsonResponse{}
 // @Failure 500 object standardJsonResponse{}
+// @Failure 429 object standardJsonResponse{}
 // @Router /auth/ldap [post]
 func (s *Service) handleLdapLogin() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -119,11 +134,18 @@ func (s *Service) handleLdapLogin() http.HandlerFunc {
 			return
 		}
 
+		Email := strings.ToLower(u.Email)
+		if s.throttleLocked(w, r, throttle.ScopeLogin, Email) {
+			return
+		}
+
 		authedUser, sessionId, err := s.authAndCreateUserLdap(r.Context(), u.Email, u.Password)
 		if err != nil {
+			s.loginFailed(r, Email)
 			s.Failure(w, r, http.StatusUnauthorized, Errorf(EINVALID, "INVALID_LOGIN"))
 			return
 		}
+		s.AuthLimiter.Reset(r.Context(), throttle.ScopeLogin, Email)
 
 		res := loginResponse{
 			User:        authedUser,
@@ -136,6 +158,10 @@ func (s *Service) handleLdapLogin() http.HandlerFunc {
 			return
 		}
 
+		// ldap asserts the users email
+		s.acceptUserInvites(r.Context(), authedUser.Id, authedUser.Email, true, u.InviteToken)
+		s.orgAutoJoin(r.Context(), authedUser.Id, authedUser.Email, true)
+
 		cookieErr := s.createSessionCookie(w, sessionId)
 		if cookieErr != nil {
 			s.Failure(w, r, http.StatusInternalServerError, Errorf(EINVALID, "INVALID_COOKIE"))
@@ -186,6 +212,10 @@ func (s *Service) handleHeaderLogin() http.HandlerFunc {
 			return
 		}
 
+		// the auth proxy asserts the users email
+		s.acceptUserInvites(r.Context(), authedUser.Id, authedUser.Email, true, "")
+		s.orgAutoJoin(r.Context(), authedUser.Id, authedUser.Email, true)
+
 		cookieErr := s.createSessionCookie(w, sessionId)
 		if cookieErr != nil {
 			s.Failure(w, r, http.StatusInternalServerError, Errorf(EINVALID, "INVALID_COOKIE"))
@@ -197,19 +227,26 @@ func (s *Service) handleHeaderLogin() http.HandlerFunc {
 }
 
 type mfaLoginRequestBody struct {
-	Passcode  string `json:"passcode" validate:"required"`
+	Passcode  string `json:"passcode" validate:"required_without_all=Passkey RecoveryCode"`
 	SessionId string `json:"sessionId" validate:"required"`
+	// RecoveryCode one of the users one-time recovery codes used in place of the authenticator token
+	RecoveryCode string `json:"recoveryCode"`
+	// Challenge of the passkey options when using a passkey as the second factor
+	Challenge   string                      `json:"challenge" validate:"required_with=Passkey"`
+	Passkey     *webauthn.AssertionResponse `json:"passkey"`
+	InviteToken string                      `json:"inviteToken"`
 }
 
 // handleMFALogin attempts to log in the user with MFA token
 // @Summary MFA Login
-// @Description attempts to log the user in with provided MFA token
+// @Description attempts to log the user in with provided MFA token, recovery code or passkey
 // @Tags auth
 // @Produce  json
 // @Param credentials body mfaLoginRequestBody false "mfa login object"
 // @Success 200 object standardJsonResponse{}
 // @Failure 401 object standardJsonResponse{}
 // @Failure 500 object standardJsonResponse{}
+// @Failure 429 object standardJsonResponse{}
 // @Router /auth/mfa [post]
 func (s *Service) handleMFALogin() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -232,12 +269,49 @@ func (s *Service) handleMFALogin() http.HandlerFunc {
 			return
 		}
 
-		err := s.AuthDataSvc.MFATokenValidate(r.Context(), u.SessionId, u.Passcode)
-		if err != nil {
-			s.Failure(w, r, http.StatusUnauthorized, Errorf(EINVALID, "INVALID_AUTHENTICATOR_TOKEN"))
+		// failed MFA logins are throttled per user as each password login creates a new session
+		var UserID string
+		User, userErr := s.AuthDataSvc.GetSessionUser(r.Context(), u.SessionId)
+		if userErr == nil {
+			UserID = User.Id
+		}
+		if s.throttleLocked(w, r, throttle.ScopeMFA, UserID) {
 			return
 		}
 
+		if u.Passkey != nil {
+			err := s.passkeyMFAValidate(r.Context(), u.SessionId, u.Challenge, *u.Passkey)
+			if err != nil {
+				s.mfaLoginFailed(r, User)
+				s.Failure(w, r, http.StatusUnauthorized, Errorf(EINVALID, "INVALID_PASSKEY"))
+				return
+			}
+		} else if u.RecoveryCode != "" {
+			Remaining, err := s.AuthDataSvc.MFARecoveryCodeValidate(r.Context(), u.SessionId, u.RecoveryCode)
+			if err != nil {
+				s.mfaLoginFailed(r, User)
+				s.Failure(w, r, http.StatusUnauthorized, Errorf(EINVALID, "INVALID_RECOVERY_CODE"))
+				return
+			}
+
+			if userErr == nil {
+				_ = s.Email.SendMFARecoveryCodeUsed(User.Name, User.Email, Remaining)
+			}
+		} else {
+			err := s.AuthDataSvc.MFATokenValidate(r.Context(), u.SessionId, u.Passcode)
+			if err != nil {
+				s.mfaLoginFailed(r, User)
+				s.Failure(w, r, http.StatusUnauthorized, Errorf(EINVALID, "INVALID_AUTHENTICATOR_TOKEN"))
+				return
+			}
+		}
+
+		if userErr == nil {
+			s.AuthLimiter.Reset(r.Context(), throttle.ScopeMFA, User.Id)
+			s.acceptUserInvites(r.Context(), User.Id, User.Email, User.Verified, u.InviteToken)
+			s.orgAutoJoin(r.Context(), User.Id, User.Email, User.Verified)
+		}
+
 		cookieErr := s.createSessionCookie(w, u.SessionId)
 		if cookieErr != nil {
 			s.Failure(w, r, http.StatusInternalServerError, Errorf(EINVALID, "INVALID_COOKIE"))
@@ -331,10 +405,11 @@ func (s *Service) handleCreateGuestUser() http.HandlerFunc {
 }
 
 type userRegisterRequestBody struct {
-	Name      string `json:"name" validate:"required"`
-	Email     string `json:"email" validate:"required,email"`
-	Password1 string `json:"password1" validate:"required,min=6,max=72"`
-	Password2 string `json:"password2" validate:"required,min=6,max=72,eqfield=Password1"`
+	Name        string `json:"name" validate:"required"`
+	Email       string `json:"email" validate:"required,email"`
+	Password1   string `json:"password1" validate:"required,min=6,max=72"`
+	Password2   string `json:"password2" validate:"required,min=6,max=72,eqfield=Password1"`
+	InviteToken string `json:"inviteToken"`
 }
 
 // handleUserRegistration registers a new authenticated user
@@ -395,6 +470,8 @@ func (s *Service) handleUserRegistration() http.HandlerFunc {
 
 		_ = s.Email.SendWelcome(UserName, UserEmail, VerifyID)
 
+		s.acceptUserInvites(r.Context(), newUser.Id, UserEmail, false, u.InviteToken)
+
 		if ActiveUserID != "" {
 			s.clearUserCookies(w)
 		}
@@ -426,6 +503,7 @@ type forgotPasswordRequestBody struct {
 // @Produce json
 // @Param user body forgotPasswordRequestBody false "forgot password object"
 // @Success 200 object standardJsonResponse{}
+// @Failure 429 object standardJsonResponse{}
 // @Router /auth/forgot-password [post]
 func (s *Service) handleForgotPassword() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -449,6 +527,11 @@ func (s *Service) handleForgotPassword() http.HandlerFunc {
 		}
 
 		UserEmail := strings.ToLower(u.Email)
+		if s.throttleLocked(w, r, throttle.ScopePasswordReset, UserEmail) {
+			return
+		}
+		// every request counts towards the limit as the outcome is not revealed
+		s.throttleFail(r, throttle.ScopePasswordReset, UserEmail)
 
 		ResetID, UserName, resetErr := s.AuthDataSvc.UserResetRequest(r.Context(), UserEmail)
 		if resetErr == nil {
@@ -502,6 +585,10 @@ func (s *Service) handleResetPassword() http.HandlerFunc {
 			return
 		}
 
+		if User, err := s.UserDataSvc.GetUserByEmail(r.Context(), UserEmail); err == nil {
+			_ = s.revokeUserSessions(r.Context(), User.Id, "")
+		}
+
 		_ = s.Email.SendPasswordReset(UserName, UserEmail)
 
 		s.Success(w, r, http.StatusOK, nil, nil)
@@ -552,6 +639,9 @@ func (s *Service) handleUpdatePassword() http.HandlerFunc {
 			return
 		}
 
+		SessionID, _ := r.Context().Value(contextKeySessionID).(string)
+		_ = s.revokeUserSessions(r.Context(), UserID, SessionID)
+
 		_ = s.Email.SendPasswordUpdate(UserName, UserEmail)
 
 		s.Success(w, r, http.StatusOK, nil, nil)
@@ -671,19 +761,55 @@ func (s *Service) handleMFASetupValidate() http.HandlerFunc {
 		}
 
 		type result struct {
-			Result string `json:"result"`
+			Result        string   `json:"result"`
+			RecoveryCodes []string `json:"recoveryCodes,omitempty"`
 		}
 		res := result{Result: "SUCCESS"}
 
-		err := s.AuthDataSvc.MFASetupValidate(ctx, UserID, v.Secret, v.Passcode)
+		RecoveryCodes, err := s.AuthDataSvc.MFASetupValidate(ctx, UserID, v.Secret, v.Passcode)
 		if err != nil {
 			res.Result = err.Error()
 		}
+		res.RecoveryCodes = RecoveryCodes
 
 		s.Success(w, r, http.StatusOK, res, nil)
 	}
 }
 
+// handleMFARecoveryCodesGenerate regenerates the users MFA recovery codes invalidating any previous codes
+// @Summary Regenerate MFA Recovery Codes
+// @Description Generates a new set of one-time MFA recovery codes, previous codes are no longer valid
+// @Tags auth
+// @Success 200 object standardJsonResponse{data=[]string}
+// @Failure 400 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Router /auth/mfa/recovery-codes [post]
+func (s *Service) handleMFARecoveryCodesGenerate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		UserID := ctx.Value(contextKeyUserID).(string)
+
+		User, err := s.UserDataSvc.GetUser(ctx, UserID)
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if !User.MFAEnabled {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "MFA_NOT_ENABLED"))
+			return
+		}
+
+		RecoveryCodes, err := s.AuthDataSvc.MFARecoveryCodesGenerate(ctx, UserID)
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, RecoveryCodes, nil)
+	}
+}
+
 // handleMFARemove removes MFA requirement from user auth
 // @Summary Remove MFA
 // @Description Removes MFA requirement from user auth
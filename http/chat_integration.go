@@ -0,0 +1,239 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/chat"
+
+	"github.com/gorilla/mux"
+)
+
+type chatIntegrationCreateRequestBody struct {
+	Provider   string   `json:"provider" validate:"required,oneof=slack mattermost"`
+	Name       string   `json:"name" validate:"max=64"`
+	WebhookURL string   `json:"webhookUrl" validate:"required,url"`
+	Events     []string `json:"events" validate:"required,min=1,unique,dive,oneof=checkin_blockers poker_finished retro_completed"`
+}
+
+type chatIntegrationUpdateRequestBody struct {
+	Name       string   `json:"name" validate:"max=64"`
+	WebhookURL string   `json:"webhookUrl" validate:"omitempty,url"`
+	Events     []string `json:"events" validate:"required,min=1,unique,dive,oneof=checkin_blockers poker_finished retro_completed"`
+	Enabled    bool     `json:"enabled"`
+}
+
+// handleChatIntegrationsGet gets the teams chat integrations
+// @Summary Get Team Chat Integrations
+// @Description Get the teams Slack and Mattermost chat integrations, webhook URLs are not returned
+// @Tags team
+// @Produce  json
+// @Param teamId path string true "the team ID"
+// @Success 200 object standardJsonResponse{data=[]thunderdome.ChatIntegration}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /teams/{teamId}/integrations/chat [get]
+func (s *Service) handleChatIntegrationsGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		TeamID := vars["teamId"]
+		idErr := validate.Var(TeamID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+
+		Integrations, err := s.ChatIntegrationDataSvc.ChatIntegrationList(r.Context(), TeamID)
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, Integrations, nil)
+	}
+}
+
+// handleChatIntegrationCreate handles adding a team chat integration
+// @Summary Create Team Chat Integration
+// @Description Adds a Slack or Mattermost incoming webhook to notify of team events, webhook URLs must use https,
+// @Description Slack webhooks must be hooks.slack.com and Mattermost webhooks can't be for a private or loopback address
+// @Param teamId path string true "the team ID"
+// @Param integration body chatIntegrationCreateRequestBody true "new chat integration object"
+// @Tags team
+// @Produce  json
+// @Success 200 object standardJsonResponse{data=thunderdome.ChatIntegration}
+// @Failure 400 object standardJsonResponse{}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /teams/{teamId}/integrations/chat [post]
+func (s *Service) handleChatIntegrationCreate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		TeamID := vars["teamId"]
+		idErr := validate.Var(TeamID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+
+		var ci = chatIntegrationCreateRequestBody{}
+		body, bodyErr := io.ReadAll(r.Body)
+		if bodyErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, bodyErr.Error()))
+			return
+		}
+
+		jsonErr := json.Unmarshal(body, &ci)
+		if jsonErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, jsonErr.Error()))
+			return
+		}
+
+		inputErr := validate.Struct(ci)
+		if inputErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, inputErr.Error()))
+			return
+		}
+
+		if urlErr := chat.ValidateWebhookURL(ci.Provider, ci.WebhookURL); urlErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, urlErr.Error()))
+			return
+		}
+
+		Integration, err := s.ChatIntegrationDataSvc.ChatIntegrationCreate(r.Context(), TeamID, ci.Provider, ci.Name, ci.WebhookURL, ci.Events)
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, Integration, nil)
+	}
+}
+
+// handleChatIntegrationUpdate handles updating a team chat integration
+// @Summary Update Team Chat Integration
+// @Description Updates a team chat integration, omit the webhook URL to keep the current one
+// @Param teamId path string true "the team ID"
+// @Param integrationId path string true "the chat integration ID"
+// @Param integration body chatIntegrationUpdateRequestBody true "updated chat integration object"
+// @Tags team
+// @Produce  json
+// @Success 200 object standardJsonResponse{data=thunderdome.ChatIntegration}
+// @Failure 400 object standardJsonResponse{}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 404 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /teams/{teamId}/integrations/chat/{integrationId} [put]
+func (s *Service) handleChatIntegrationUpdate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		TeamID := vars["teamId"]
+		idErr := validate.Var(TeamID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+		IntegrationID := vars["integrationId"]
+		idErr = validate.Var(IntegrationID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+
+		var ci = chatIntegrationUpdateRequestBody{}
+		body, bodyErr := io.ReadAll(r.Body)
+		if bodyErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, bodyErr.Error()))
+			return
+		}
+
+		jsonErr := json.Unmarshal(body, &ci)
+		if jsonErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, jsonErr.Error()))
+			return
+		}
+
+		inputErr := validate.Struct(ci)
+		if inputErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, inputErr.Error()))
+			return
+		}
+
+		if ci.WebhookURL != "" {
+			Integrations, err := s.ChatIntegrationDataSvc.ChatIntegrationList(r.Context(), TeamID)
+			if err != nil {
+				s.Failure(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			var Provider string
+			for _, i := range Integrations {
+				if i.Id == IntegrationID {
+					Provider = i.Provider
+				}
+			}
+			if Provider == "" {
+				s.Failure(w, r, http.StatusNotFound, Errorf(ENOTFOUND, "CHAT_INTEGRATION_NOT_FOUND"))
+				return
+			}
+			if urlErr := chat.ValidateWebhookURL(Provider, ci.WebhookURL); urlErr != nil {
+				s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, urlErr.Error()))
+				return
+			}
+		}
+
+		Integration, err := s.ChatIntegrationDataSvc.ChatIntegrationUpdate(
+			r.Context(), TeamID, IntegrationID, ci.Name, ci.WebhookURL, ci.Events, ci.Enabled,
+		)
+		if err != nil && err.Error() == "CHAT_INTEGRATION_NOT_FOUND" {
+			s.Failure(w, r, http.StatusNotFound, Errorf(ENOTFOUND, err.Error()))
+			return
+		}
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, Integration, nil)
+	}
+}
+
+// handleChatIntegrationDelete handles removing a team chat integration
+// @Summary Delete Team Chat Integration
+// @Description Removes a team chat integration
+// @Param teamId path string true "the team ID"
+// @Param integrationId path string true "the chat integration ID"
+// @Tags team
+// @Produce  json
+// @Success 200 object standardJsonResponse{}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /teams/{teamId}/integrations/chat/{integrationId} [delete]
+func (s *Service) handleChatIntegrationDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		TeamID := vars["teamId"]
+		idErr := validate.Var(TeamID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+		IntegrationID := vars["integrationId"]
+		idErr = validate.Var(IntegrationID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+
+		err := s.ChatIntegrationDataSvc.ChatIntegrationDelete(r.Context(), TeamID, IntegrationID)
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, nil, nil)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/StevenWeathers/thunderdome-planning-poker/http/checkin"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
 
 	"github.com/gorilla/mux"
 )
@@ -18,8 +19,11 @@ import (
 // @Produce  json
 // @Param teamId path string true "the team ID"
 // @Param date query string false "the date in YYYY-MM-DD format"
+// @Param from query string false "the range start date in YYYY-MM-DD format, used with to instead of date, ranges span at most 366 days"
+// @Param to query string false "the range end date in YYYY-MM-DD format, used with from instead of date"
 // @Param tz query string false "the timezone name e.g. America/New_York"
 // @Success 200 object standardJsonResponse{data=[]thunderdome.TeamCheckin}
+// @Failure 400 object standardJsonResponse{}
 // @Security ApiKeyAuth
 // @Router /teams/{teamId}/checkins [get]
 func (s *Service) handleCheckinsGet() http.HandlerFunc {
@@ -33,17 +37,28 @@ func (s *Service) handleCheckinsGet() http.HandlerFunc {
 		}
 		query := r.URL.Query()
 		date := query.Get("date")
-		tz := query.Get("tz")
+		tz, loc, tzErr := getTimeZoneFromRequest(r)
+		if tzErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, tzErr.Error()))
+			return
+		}
 
 		if date == "" {
-			date = time.Now().Format("1988-01-02")
+			date = time.Now().In(loc).Format("2006-01-02")
 		}
 
-		if tz == "" {
-			tz = "America/New_York"
+		var Checkins []*thunderdome.TeamCheckin
+		var err error
+		if query.Get("from") != "" || query.Get("to") != "" {
+			from, to, rangeErr := getDateRangeFromRequest(r, 0, loc)
+			if rangeErr != nil {
+				s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, rangeErr.Error()))
+				return
+			}
+			Checkins, err = s.CheckinDataSvc.CheckinListRange(r.Context(), TeamID, from, to, tz)
+		} else {
+			Checkins, err = s.CheckinDataSvc.CheckinList(r.Context(), TeamID, date, tz)
 		}
-
-		Checkins, err := s.CheckinDataSvc.CheckinList(r.Context(), TeamID, date, tz)
 		if err != nil {
 			s.Failure(w, r, http.StatusInternalServerError, err)
 			return
@@ -53,13 +68,59 @@ func (s *Service) handleCheckinsGet() http.HandlerFunc {
 	}
 }
 
+// handleCheckinStats gets the teams checkin analytics
+// @Summary Get Team Checkin Stats
+// @Description Get the teams checkin participation rate per member, goals met rate per day, days with blockers and blocker lifetimes
+// @Tags team
+// @Produce  json
+// @Param teamId path string true "the team ID"
+// @Param from query string false "the range start date in YYYY-MM-DD format, defaults to 30 days before to"
+// @Param to query string false "the range end date in YYYY-MM-DD format, defaults to today in the timezone, ranges span at most 366 days"
+// @Param tz query string false "the timezone name e.g. America/New_York"
+// @Success 200 object standardJsonResponse{data=thunderdome.CheckinStats}
+// @Failure 400 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /teams/{teamId}/checkins/stats [get]
+func (s *Service) handleCheckinStats() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		TeamID := vars["teamId"]
+		idErr := validate.Var(TeamID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+		tz, loc, tzErr := getTimeZoneFromRequest(r)
+		if tzErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, tzErr.Error()))
+			return
+		}
+
+		from, to, rangeErr := getDateRangeFromRequest(r, 30, loc)
+		if rangeErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, rangeErr.Error()))
+			return
+		}
+
+		Stats, err := s.CheckinDataSvc.CheckinStats(r.Context(), TeamID, from, to, tz)
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, Stats, nil)
+	}
+}
+
 type checkinCreateRequestBody struct {
-	UserId    string `json:"userId" validate:"required,uuid"`
-	Yesterday string `json:"yesterday"`
-	Today     string `json:"today"`
-	Blockers  string `json:"blockers"`
-	Discuss   string `json:"discuss"`
-	GoalsMet  bool   `json:"goalsMet"`
+	UserId    string                       `json:"userId" validate:"required,uuid"`
+	Yesterday string                       `json:"yesterday"`
+	Today     string                       `json:"today"`
+	Blockers  string                       `json:"blockers"`
+	Discuss   string                       `json:"discuss"`
+	GoalsMet  bool                         `json:"goalsMet"`
+	Answers   []*thunderdome.CheckinAnswer `json:"answers" validate:"omitempty,max=50,dive,required"`
 }
 
 // handleCheckinCreate handles creating a team user checkin
@@ -105,7 +166,7 @@ func (s *Service) handleCheckinCreate(tc *checkin.Service) http.HandlerFunc {
 
 		err := tc.APIEvent(r.Context(), TeamId, c.UserId, "checkin_create", string(body))
 		if err != nil {
-			if err.Error() == "REQUIRES_TEAM_USER" {
+			if err.Error() == "REQUIRES_TEAM_USER" || isCheckinAnswerError(err) {
 				s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, err.Error()))
 				return
 			}
@@ -118,12 +179,13 @@ func (s *Service) handleCheckinCreate(tc *checkin.Service) http.HandlerFunc {
 }
 
 type checkinUpdateRequestBody struct {
-	CheckinId string `json:"checkinId" swaggerignore:"true"`
-	Yesterday string `json:"yesterday"`
-	Today     string `json:"today"`
-	Blockers  string `json:"blockers"`
-	Discuss   string `json:"discuss"`
-	GoalsMet  bool   `json:"goalsMet"`
+	CheckinId string                       `json:"checkinId" swaggerignore:"true"`
+	Yesterday string                       `json:"yesterday"`
+	Today     string                       `json:"today"`
+	Blockers  string                       `json:"blockers"`
+	Discuss   string                       `json:"discuss"`
+	GoalsMet  bool                         `json:"goalsMet"`
+	Answers   []*thunderdome.CheckinAnswer `json:"answers" validate:"omitempty,max=50,dive,required"`
 }
 
 // handleCheckinUpdate handles updating a team user checkin
@@ -179,6 +241,10 @@ func (s *Service) handleCheckinUpdate(tc *checkin.Service) http.HandlerFunc {
 
 		err := tc.APIEvent(ctx, TeamId, userId, "checkin_update", string(cu))
 		if err != nil {
+			if isCheckinAnswerError(err) {
+				s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, err.Error()))
+				return
+			}
 			s.Failure(w, r, http.StatusInternalServerError, err)
 			return
 		}
@@ -13,11 +13,13 @@ type Service struct {
 	logger                *otelzap.Logger
 	validateSessionCookie func(w http.ResponseWriter, r *http.Request) (string, error)
 	validateUserCookie    func(w http.ResponseWriter, r *http.Request) (string, error)
+	policyCheck           func(ctx context.Context, EntityType string, EntityID string, User *thunderdome.User, SessionID string) error
 	eventHandlers         map[string]func(context.Context, string, string, string) ([]byte, error, bool)
 	UserService           thunderdome.UserDataSvc
 	AuthService           thunderdome.AuthDataSvc
 	CheckinService        thunderdome.CheckinDataSvc
 	TeamService           thunderdome.TeamDataSvc
+	ChatService           thunderdome.ChatService
 }
 
 // New returns a new retro with websocket hub/client and event handlers
@@ -25,26 +27,31 @@ func New(
 	logger *otelzap.Logger,
 	validateSessionCookie func(w http.ResponseWriter, r *http.Request) (string, error),
 	validateUserCookie func(w http.ResponseWriter, r *http.Request) (string, error),
+	policyCheck func(ctx context.Context, EntityType string, EntityID string, User *thunderdome.User, SessionID string) error,
 	userService thunderdome.UserDataSvc, authService thunderdome.AuthDataSvc,
 	checkinService thunderdome.CheckinDataSvc, teamService thunderdome.TeamDataSvc,
+	chatService thunderdome.ChatService,
 ) *Service {
 	c := &Service{
 		logger:                logger,
 		validateSessionCookie: validateSessionCookie,
 		validateUserCookie:    validateUserCookie,
+		policyCheck:           policyCheck,
 		UserService:           userService,
 		AuthService:           authService,
 		CheckinService:        checkinService,
 		TeamService:           teamService,
+		ChatService:           chatService,
 	}
 
 	c.eventHandlers = map[string]func(context.Context, string, string, string) ([]byte, error, bool){
-		"checkin_create": c.CheckinCreate,
-		"checkin_update": c.CheckinUpdate,
-		"checkin_delete": c.CheckinDelete,
-		"comment_create": c.CommentCreate,
-		"comment_update": c.CommentUpdate,
-		"comment_delete": c.CommentDelete,
+		"checkin_create":    c.CheckinCreate,
+		"checkin_update":    c.CheckinUpdate,
+		"checkin_delete":    c.CheckinDelete,
+		"comment_create":    c.CommentCreate,
+		"comment_update":    c.CommentUpdate,
+		"comment_delete":    c.CommentDelete,
+		"questions_updated": c.QuestionsUpdated,
 	}
 
 	go h.run()
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/StevenWeathers/thunderdome-planning-poker/metrics"
 	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
 
 	"go.uber.org/zap"
@@ -40,6 +41,9 @@ type connection struct {
 
 	// Buffered channel of outbound messages.
 	send chan []byte
+
+	// The user session the connection was authenticated with, empty for guests.
+	sessionID string
 }
 
 // readPump pumps messages from the websocket connection to the hub.
@@ -91,7 +95,9 @@ func (sub subscription) readPump(b *Service, ctx context.Context) {
 
 		// find event handler and execute otherwise invalid event
 		if _, ok := b.eventHandlers[eventType]; ok && !badEvent {
+			eventStart := time.Now()
 			msg, eventErr, forceClosed = b.eventHandlers[eventType](ctx, RetroID, UserID, eventValue)
+			metrics.ObserveEvent(hubName, eventType, eventStart, eventErr != nil && !forceClosed)
 			if eventErr != nil {
 				badEvent = true
 
@@ -119,6 +125,20 @@ func (c *connection) write(mt int, payload []byte) error {
 	return c.ws.WriteMessage(mt, payload)
 }
 
+// closeRevoked closes the websocket connection after its user session was revoked.
+func (c *connection) closeRevoked() {
+	cm := websocket.FormatCloseMessage(4001, "unauthorized")
+	_ = c.ws.WriteControl(websocket.CloseMessage, cm, time.Now().Add(writeWait))
+	_ = c.ws.Close()
+}
+
+// closeRestart closes the websocket connection with the service restart close code so the client reconnects.
+func (c *connection) closeRestart() {
+	cm := websocket.FormatCloseMessage(websocket.CloseServiceRestart, "service restart")
+	_ = c.ws.WriteControl(websocket.CloseMessage, cm, time.Now().Add(writeWait))
+	_ = c.ws.Close()
+}
+
 // writePump pumps messages from the hub to the websocket connection.
 func (sub *subscription) writePump() {
 	c := sub.conn
@@ -156,6 +176,33 @@ func (b *Service) handleSocketClose(ctx context.Context, ws *websocket.Conn, clo
 	}
 }
 
+// CloseSessions closes any websocket connections authenticated with the revoked user sessions
+func (b *Service) CloseSessions(SessionIDs []string) {
+	if len(SessionIDs) == 0 {
+		return
+	}
+
+	h.closeSessions <- SessionIDs
+}
+
+// Shutdown closes all websocket connections with the service restart close code for clients to reconnect,
+// waiting until they've disconnected or the context is done
+func (b *Service) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	select {
+	case h.shutdown <- done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // ServeWs handles websocket requests from the peer.
 func (b *Service) ServeWs() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -185,6 +232,7 @@ func (b *Service) ServeWs() http.HandlerFunc {
 				b.handleSocketClose(ctx, ws, 4001, "unauthorized")
 				return
 			}
+			c.sessionID = SessionId
 		} else {
 			UserID, err := b.validateUserCookie(w, r)
 			if err != nil {
@@ -207,6 +255,12 @@ func (b *Service) ServeWs() http.HandlerFunc {
 			return
 		}
 
+		// make sure the user satisfies the policy of the organizations the team is in
+		if policyErr := b.policyCheck(ctx, "team", teamID, User, SessionId); policyErr != nil {
+			b.handleSocketClose(ctx, ws, 4006, policyErr.Error())
+			return
+		}
+
 		// make sure user is a team user
 		_, UserErr := b.TeamService.TeamUserRole(ctx, User.Id, teamID)
 		if UserErr != nil {
@@ -230,7 +284,9 @@ func (b *Service) ServeWs() http.HandlerFunc {
 func (b *Service) APIEvent(ctx context.Context, arenaID string, UserID, eventType string, eventValue string) error {
 	// find event handler and execute otherwise invalid event
 	if _, ok := b.eventHandlers[eventType]; ok {
+		eventStart := time.Now()
 		msg, eventErr, _ := b.eventHandlers[eventType](ctx, arenaID, UserID, eventValue)
+		metrics.ObserveEvent(hubName, eventType, eventStart, eventErr != nil)
 		if eventErr != nil {
 			return eventErr
 		}
@@ -3,28 +3,41 @@ package checkin
 import (
 	"context"
 	"encoding/json"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
 )
 
 // CheckinCreate creates a checkin
 func (b *Service) CheckinCreate(ctx context.Context, TeamID string, UserID string, EventValue string) ([]byte, error, bool) {
 	var c struct {
-		UserId    string `json:"userId"`
-		Yesterday string `json:"yesterday"`
-		Today     string `json:"today"`
-		Blockers  string `json:"blockers"`
-		Discuss   string `json:"discuss"`
-		GoalsMet  bool   `json:"goalsMet"`
+		UserId    string                       `json:"userId"`
+		Yesterday string                       `json:"yesterday"`
+		Today     string                       `json:"today"`
+		Blockers  string                       `json:"blockers"`
+		Discuss   string                       `json:"discuss"`
+		GoalsMet  bool                         `json:"goalsMet"`
+		Answers   []*thunderdome.CheckinAnswer `json:"answers"`
 	}
 	err := json.Unmarshal([]byte(EventValue), &c)
 	if err != nil {
 		return nil, err, false
 	}
 
-	err = b.CheckinService.CheckinCreate(context.Background(), TeamID, c.UserId, c.Yesterday, c.Today, c.Blockers, c.Discuss, c.GoalsMet)
+	err = b.CheckinService.CheckinCreate(context.Background(), TeamID, c.UserId, c.Yesterday, c.Today, c.Blockers, c.Discuss, c.GoalsMet, c.Answers)
 	if err != nil {
 		return nil, err, false
 	}
 
+	if c.Blockers != "" {
+		go func() {
+			user, err := b.UserService.GetUser(context.Background(), c.UserId)
+			if err != nil {
+				return
+			}
+			b.ChatService.CheckinBlockers(context.Background(), TeamID, user.Name, c.Blockers)
+		}()
+	}
+
 	msg := createSocketEvent("checkin_added", "", "")
 
 	return msg, nil, false
@@ -33,19 +46,20 @@ func (b *Service) CheckinCreate(ctx context.Context, TeamID string, UserID strin
 // CheckinUpdate updates a checkin
 func (b *Service) CheckinUpdate(ctx context.Context, TeamID string, UserID string, EventValue string) ([]byte, error, bool) {
 	var c struct {
-		CheckinId string `json:"checkinId"`
-		Yesterday string `json:"yesterday"`
-		Today     string `json:"today"`
-		Blockers  string `json:"blockers"`
-		Discuss   string `json:"discuss"`
-		GoalsMet  bool   `json:"goalsMet"`
+		CheckinId string                       `json:"checkinId"`
+		Yesterday string                       `json:"yesterday"`
+		Today     string                       `json:"today"`
+		Blockers  string                       `json:"blockers"`
+		Discuss   string                       `json:"discuss"`
+		GoalsMet  bool                         `json:"goalsMet"`
+		Answers   []*thunderdome.CheckinAnswer `json:"answers"`
 	}
 	err := json.Unmarshal([]byte(EventValue), &c)
 	if err != nil {
 		return nil, err, false
 	}
 
-	err = b.CheckinService.CheckinUpdate(context.Background(), c.CheckinId, c.Yesterday, c.Today, c.Blockers, c.Discuss, c.GoalsMet)
+	err = b.CheckinService.CheckinUpdate(context.Background(), c.CheckinId, c.Yesterday, c.Today, c.Blockers, c.Discuss, c.GoalsMet, c.Answers)
 	if err != nil {
 		return nil, err, false
 	}
@@ -139,6 +153,19 @@ func (b *Service) CommentDelete(ctx context.Context, TeamID string, UserID strin
 	return msg, nil, false
 }
 
+// QuestionsUpdated sends the teams current checkin questions
+func (b *Service) QuestionsUpdated(ctx context.Context, TeamID string, UserID string, EventValue string) ([]byte, error, bool) {
+	questions, err := b.CheckinService.CheckinQuestionList(ctx, TeamID)
+	if err != nil {
+		return nil, err, false
+	}
+
+	updatedQuestions, _ := json.Marshal(questions)
+	msg := createSocketEvent("questions_updated", string(updatedQuestions), "")
+
+	return msg, nil, false
+}
+
 // socketEvent is the event structure used for socket messages
 type socketEvent struct {
 	Type  string `json:"type"`
@@ -1,5 +1,10 @@
 package checkin
 
+import "github.com/StevenWeathers/thunderdome-planning-poker/metrics"
+
+// hubName the hub label of the websocket metrics
+const hubName = "checkin"
+
 type message struct {
 	data  []byte
 	arena string
@@ -25,13 +30,24 @@ type hub struct {
 
 	// Unregister requests from connections.
 	unregister chan subscription
+
+	// Close requests for connections of revoked user sessions.
+	closeSessions chan []string
+
+	// Shutdown requests closing all connections, the channel is closed once they've unregistered.
+	shutdown chan chan struct{}
+
+	// Closed once all connections have unregistered after a shutdown request.
+	drained chan struct{}
 }
 
 var h = hub{
-	broadcast:  make(chan message),
-	register:   make(chan subscription),
-	unregister: make(chan subscription),
-	arenas:     make(map[string]map[*connection]struct{}),
+	broadcast:     make(chan message),
+	register:      make(chan subscription),
+	unregister:    make(chan subscription),
+	closeSessions: make(chan []string),
+	shutdown:      make(chan chan struct{}),
+	arenas:        make(map[string]map[*connection]struct{}),
 }
 
 func (h *hub) run() {
@@ -43,6 +59,9 @@ func (h *hub) run() {
 				connections = make(map[*connection]struct{})
 				h.arenas[a.arena] = connections
 			}
+			if _, ok := connections[a.conn]; !ok {
+				metrics.WebsocketConnections.WithLabelValues(hubName).Inc()
+			}
 			h.arenas[a.arena][a.conn] = struct{}{}
 		case a := <-h.unregister:
 			connections := h.arenas[a.arena]
@@ -50,11 +69,25 @@ func (h *hub) run() {
 				if _, ok := connections[a.conn]; ok {
 					delete(connections, a.conn)
 					close(a.conn.send)
+					metrics.WebsocketConnections.WithLabelValues(hubName).Dec()
 					if len(connections) == 0 {
 						delete(h.arenas, a.arena)
 					}
 				}
 			}
+			h.checkDrained()
+		case sessionIDs := <-h.closeSessions:
+			revoked := make(map[string]struct{}, len(sessionIDs))
+			for _, id := range sessionIDs {
+				revoked[id] = struct{}{}
+			}
+			for _, connections := range h.arenas {
+				for c := range connections {
+					if _, ok := revoked[c.sessionID]; ok && c.sessionID != "" {
+						go c.closeRevoked()
+					}
+				}
+			}
 		case m := <-h.broadcast:
 			connections := h.arenas[m.arena]
 			for c := range connections {
@@ -63,11 +96,30 @@ func (h *hub) run() {
 				default:
 					close(c.send)
 					delete(connections, c)
+					metrics.WebsocketConnections.WithLabelValues(hubName).Dec()
+					metrics.HubBroadcastDrops.WithLabelValues(hubName).Inc()
 					if len(connections) == 0 {
 						delete(h.arenas, m.arena)
 					}
 				}
 			}
+			h.checkDrained()
+		case done := <-h.shutdown:
+			h.drained = done
+			for _, connections := range h.arenas {
+				for c := range connections {
+					go c.closeRestart()
+				}
+			}
+			h.checkDrained()
 		}
 	}
 }
+
+// checkDrained signals a shutdown request once all connections have unregistered
+func (h *hub) checkDrained() {
+	if h.drained != nil && len(h.arenas) == 0 {
+		close(h.drained)
+		h.drained = nil
+	}
+}
@@ -0,0 +1,221 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/http/checkin"
+
+	"github.com/gorilla/mux"
+)
+
+type checkinQuestionRequestBody struct {
+	Question  string `json:"question" validate:"required,max=256"`
+	Type      string `json:"type" validate:"required,oneof=text yesno scale"`
+	Required  bool   `json:"required"`
+	SortOrder int    `json:"sort_order" validate:"min=0"`
+}
+
+// isCheckinAnswerError determines whether the error is due to invalid checkin answers
+func isCheckinAnswerError(err error) bool {
+	return err.Error() == "CHECKIN_ANSWER_REQUIRED" || err.Error() == "INVALID_CHECKIN_ANSWER"
+}
+
+// getCheckinQuestionRequestBody gets and validates the checkin question from the request body
+func getCheckinQuestionRequestBody(r *http.Request) (*checkinQuestionRequestBody, error) {
+	var q = checkinQuestionRequestBody{}
+	body, bodyErr := io.ReadAll(r.Body)
+	if bodyErr != nil {
+		return nil, bodyErr
+	}
+
+	jsonErr := json.Unmarshal(body, &q)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	inputErr := validate.Struct(q)
+	if inputErr != nil {
+		return nil, inputErr
+	}
+
+	return &q, nil
+}
+
+// handleCheckinQuestionsGet gets the teams checkin questions
+// @Summary Get Team Checkin Questions
+// @Description Get the teams checkin questions, teams without their own questions get the default questions
+// @Tags team
+// @Produce  json
+// @Param teamId path string true "the team ID"
+// @Success 200 object standardJsonResponse{data=[]thunderdome.CheckinQuestion}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /teams/{teamId}/checkins/questions [get]
+func (s *Service) handleCheckinQuestionsGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		TeamID := vars["teamId"]
+		idErr := validate.Var(TeamID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+
+		Questions, err := s.CheckinDataSvc.CheckinQuestionList(r.Context(), TeamID)
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, Questions, nil)
+	}
+}
+
+// handleCheckinQuestionCreate handles adding a team checkin question
+// @Summary Create Team Checkin Question
+// @Description Adds a checkin question to the team, replacing the default questions
+// @Param teamId path string true "the team ID"
+// @Param question body checkinQuestionRequestBody true "new checkin question object"
+// @Tags team
+// @Produce  json
+// @Success 200 object standardJsonResponse{data=thunderdome.CheckinQuestion}
+// @Failure 400 object standardJsonResponse{}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /teams/{teamId}/checkins/questions [post]
+func (s *Service) handleCheckinQuestionCreate(tc *checkin.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		UserID := ctx.Value(contextKeyUserID).(string)
+		vars := mux.Vars(r)
+		TeamID := vars["teamId"]
+		idErr := validate.Var(TeamID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+
+		q, qErr := getCheckinQuestionRequestBody(r)
+		if qErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, qErr.Error()))
+			return
+		}
+
+		Question, err := s.CheckinDataSvc.CheckinQuestionCreate(ctx, TeamID, q.Question, q.Type, q.Required)
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		_ = tc.APIEvent(ctx, TeamID, UserID, "questions_updated", "")
+
+		s.Success(w, r, http.StatusOK, Question, nil)
+	}
+}
+
+// handleCheckinQuestionUpdate handles updating a team checkin question
+// @Summary Update Team Checkin Question
+// @Description Updates a team checkin question
+// @Param teamId path string true "the team ID"
+// @Param questionId path string true "the checkin question ID"
+// @Param question body checkinQuestionRequestBody true "updated checkin question object"
+// @Tags team
+// @Produce  json
+// @Success 200 object standardJsonResponse{data=thunderdome.CheckinQuestion}
+// @Failure 400 object standardJsonResponse{}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 404 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /teams/{teamId}/checkins/questions/{questionId} [put]
+func (s *Service) handleCheckinQuestionUpdate(tc *checkin.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		UserID := ctx.Value(contextKeyUserID).(string)
+		vars := mux.Vars(r)
+		TeamID := vars["teamId"]
+		idErr := validate.Var(TeamID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+		QuestionID := vars["questionId"]
+		idErr = validate.Var(QuestionID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+
+		q, qErr := getCheckinQuestionRequestBody(r)
+		if qErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, qErr.Error()))
+			return
+		}
+
+		Question, err := s.CheckinDataSvc.CheckinQuestionUpdate(ctx, TeamID, QuestionID, q.Question, q.Type, q.Required, q.SortOrder)
+		if err != nil {
+			if err.Error() == "CHECKIN_QUESTION_NOT_FOUND" {
+				s.Failure(w, r, http.StatusNotFound, Errorf(ENOTFOUND, err.Error()))
+				return
+			}
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		_ = tc.APIEvent(ctx, TeamID, UserID, "questions_updated", "")
+
+		s.Success(w, r, http.StatusOK, Question, nil)
+	}
+}
+
+// handleCheckinQuestionArchive handles archiving a team checkin question
+// @Summary Archive Team Checkin Question
+// @Description Archives a team checkin question so it's no longer asked, answers on past checkins are kept.
+// @Description Teams without active questions revert to the default questions
+// @Param teamId path string true "the team ID"
+// @Param questionId path string true "the checkin question ID"
+// @Tags team
+// @Produce  json
+// @Success 200 object standardJsonResponse{}
+// @Failure 400 object standardJsonResponse{}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 404 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /teams/{teamId}/checkins/questions/{questionId} [delete]
+func (s *Service) handleCheckinQuestionArchive(tc *checkin.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		UserID := ctx.Value(contextKeyUserID).(string)
+		vars := mux.Vars(r)
+		TeamID := vars["teamId"]
+		idErr := validate.Var(TeamID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+		QuestionID := vars["questionId"]
+		idErr = validate.Var(QuestionID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+
+		err := s.CheckinDataSvc.CheckinQuestionArchive(ctx, TeamID, QuestionID)
+		if err != nil {
+			if err.Error() == "CHECKIN_QUESTION_NOT_FOUND" {
+				s.Failure(w, r, http.StatusNotFound, Errorf(ENOTFOUND, err.Error()))
+				return
+			}
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		_ = tc.APIEvent(ctx, TeamID, UserID, "questions_updated", "")
+
+		s.Success(w, r, http.StatusOK, nil, nil)
+	}
+}
@@ -0,0 +1,142 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type checkinScheduleRequestBody struct {
+	Enabled      bool   `json:"enabled"`
+	Days         []int  `json:"days" validate:"required,min=1,max=7,unique,dive,min=0,max=6"`
+	ReminderTime string `json:"reminderTime" validate:"required,datetime=15:04"`
+	DigestTime   string `json:"digestTime" validate:"required,datetime=15:04"`
+	TimeZone     string `json:"timeZone" validate:"required,timezone"`
+}
+
+// handleCheckinScheduleGet gets the teams checkin reminder and digest schedule
+// @Summary Get Team Checkin Schedule
+// @Description Get the teams checkin reminder and digest email schedule
+// @Tags team
+// @Produce  json
+// @Param teamId path string true "the team ID"
+// @Success 200 object standardJsonResponse{data=thunderdome.CheckinSchedule}
+// @Failure 404 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /teams/{teamId}/checkins/schedule [get]
+func (s *Service) handleCheckinScheduleGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		TeamID := vars["teamId"]
+		idErr := validate.Var(TeamID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+
+		Schedule, err := s.CheckinDataSvc.CheckinScheduleGet(r.Context(), TeamID)
+		if err != nil && err.Error() == "CHECKIN_SCHEDULE_NOT_FOUND" {
+			s.Failure(w, r, http.StatusNotFound, Errorf(ENOTFOUND, err.Error()))
+			return
+		}
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, Schedule, nil)
+	}
+}
+
+// handleCheckinScheduleUpdate handles setting the teams checkin schedule
+// @Summary Update Team Checkin Schedule
+// @Description Sets the days, local times and time zone that checkin reminders and the daily digest are emailed to the team
+// @Param teamId path string true "the team ID"
+// @Param schedule body checkinScheduleRequestBody true "checkin schedule object"
+// @Tags team
+// @Produce  json
+// @Success 200 object standardJsonResponse{data=thunderdome.CheckinSchedule}
+// @Failure 400 object standardJsonResponse{}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /teams/{teamId}/checkins/schedule [put]
+func (s *Service) handleCheckinScheduleUpdate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		TeamID := vars["teamId"]
+		idErr := validate.Var(TeamID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+
+		var cs = checkinScheduleRequestBody{}
+		body, bodyErr := io.ReadAll(r.Body)
+		if bodyErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, bodyErr.Error()))
+			return
+		}
+
+		jsonErr := json.Unmarshal(body, &cs)
+		if jsonErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, jsonErr.Error()))
+			return
+		}
+
+		inputErr := validate.Struct(cs)
+		if inputErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, inputErr.Error()))
+			return
+		}
+		// times are zero padded so compare lexically
+		if cs.DigestTime <= cs.ReminderTime {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "DIGEST_TIME_BEFORE_REMINDER_TIME"))
+			return
+		}
+
+		Schedule, err := s.CheckinDataSvc.CheckinScheduleUpsert(
+			r.Context(), TeamID, cs.Enabled, cs.Days, cs.ReminderTime, cs.DigestTime, cs.TimeZone,
+		)
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, Schedule, nil)
+	}
+}
+
+// handleCheckinScheduleDelete handles removing the teams checkin schedule
+// @Summary Delete Team Checkin Schedule
+// @Description Removes the teams checkin schedule, stopping reminder and digest emails
+// @Param teamId path string true "the team ID"
+// @Tags team
+// @Produce  json
+// @Success 200 object standardJsonResponse{}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /teams/{teamId}/checkins/schedule [delete]
+func (s *Service) handleCheckinScheduleDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		TeamID := vars["teamId"]
+		idErr := validate.Var(TeamID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+
+		err := s.CheckinDataSvc.CheckinScheduleDelete(r.Context(), TeamID)
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, nil, nil)
+	}
+}
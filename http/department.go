@@ -137,6 +137,8 @@ func (s *Service) handleCreateDepartment() http.HandlerFunc {
 			return
 		}
 
+		s.audit(r, thunderdome.AuditActionDepartmentCreate, "department", NewDepartment.Id, nil, map[string]string{"name": team.Name})
+
 		s.Success(w, r, http.StatusOK, NewDepartment, nil)
 	}
 }
@@ -233,13 +235,15 @@ func (s *Service) handleCreateDepartmentTeam() http.HandlerFunc {
 			return
 		}
 
+		s.audit(r, thunderdome.AuditActionTeamCreate, "team", NewTeam.Id, nil, map[string]string{"name": team.Name, "departmentId": DepartmentID})
+
 		s.Success(w, r, http.StatusOK, NewTeam, nil)
 	}
 }
 
 // handleDepartmentAddUser handles adding user to an organization department
 // @Summary Add Department User
-// @Description Add a department User
+// @Description Add a department User, emails an invite when no account exists for the email
 // @Tags organization
 // @Produce  json
 // @Param orgId path string true "the organization ID"
@@ -257,6 +261,7 @@ func (s *Service) handleDepartmentAddUser() http.HandlerFunc {
 		}
 
 		vars := mux.Vars(r)
+		OrgID := vars["orgId"]
 		DepartmentId := vars["departmentId"]
 
 		var u = teamAddUserRequestBody{}
@@ -272,11 +277,34 @@ func (s *Service) handleDepartmentAddUser() http.HandlerFunc {
 			return
 		}
 
+		inputErr := validate.Struct(u)
+		if inputErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, inputErr.Error()))
+			return
+		}
+
 		UserEmail := u.Email
 
 		User, UserErr := s.UserDataSvc.GetUserByEmail(r.Context(), UserEmail)
 		if UserErr != nil {
-			s.Failure(w, r, http.StatusInternalServerError, Errorf(ENOTFOUND, "USER_NOT_FOUND"))
+			if policyErr := s.orgPolicyEmailAllowed(r.Context(), OrgID, UserEmail); policyErr != nil {
+				s.Failure(w, r, http.StatusBadRequest, policyErr)
+				return
+			}
+
+			Department, err := s.OrganizationDataSvc.DepartmentGet(r.Context(), DepartmentId)
+			if err != nil {
+				s.Failure(w, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			Invite, err := s.inviteUser(r.Context(), thunderdome.InviteTypeDepartment, DepartmentId, Department.Name, UserEmail, u.Role)
+			if err != nil {
+				s.Failure(w, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			s.Success(w, r, http.StatusOK, Invite, nil)
 			return
 		}
 
@@ -286,6 +314,8 @@ func (s *Service) handleDepartmentAddUser() http.HandlerFunc {
 			return
 		}
 
+		s.audit(r, thunderdome.AuditActionDepartmentUserAdd, "department", DepartmentId, nil, map[string]string{"userId": User.Id, "role": u.Role})
+
 		s.Success(w, r, http.StatusOK, nil, nil)
 	}
 }
@@ -323,6 +353,8 @@ func (s *Service) handleDepartmentRemoveUser() http.HandlerFunc {
 			return
 		}
 
+		s.audit(r, thunderdome.AuditActionDepartmentUserRemove, "department", DepartmentID, map[string]string{"userId": UserID}, nil)
+
 		s.Success(w, r, http.StatusOK, nil, nil)
 	}
 }
@@ -385,6 +417,8 @@ func (s *Service) handleDepartmentTeamAddUser() http.HandlerFunc {
 			return
 		}
 
+		s.audit(r, thunderdome.AuditActionTeamUserAdd, "team", TeamID, nil, map[string]string{"userId": User.Id, "role": u.Role})
+
 		s.Success(w, r, http.StatusOK, nil, nil)
 	}
 }
@@ -474,6 +508,8 @@ func (s *Service) handleDeleteDepartment() http.HandlerFunc {
 			return
 		}
 
+		s.audit(r, thunderdome.AuditActionDepartmentDelete, "department", DepartmentID, nil, nil)
+
 		s.Success(w, r, http.StatusOK, nil, nil)
 	}
 }
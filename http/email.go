@@ -0,0 +1,116 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"github.com/gorilla/mux"
+)
+
+// handleGetFailedEmails gets the failed outbound emails
+// @Summary Get Failed Emails
+// @Description Gets the outbound emails that failed to send after all attempts, most recently failed first
+// @Tags admin
+// @Produce  json
+// @Param limit query int false "Max number of results to return"
+// @Param offset query int false "Starting point to return rows from, should be multiplied by limit or 0"
+// @Success 200 object standardJsonResponse{data=[]thunderdome.QueuedEmail}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /admin/emails/failed [get]
+func (s *Service) handleGetFailedEmails() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		Limit, Offset := getLimitOffsetFromRequest(r)
+
+		Emails, Count, err := s.EmailQueueDataSvc.EmailQueueFailedList(r.Context(), Limit, Offset)
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		Meta := &pagination{
+			Count:  Count,
+			Offset: Offset,
+			Limit:  Limit,
+		}
+
+		s.Success(w, r, http.StatusOK, Emails, Meta)
+	}
+}
+
+// handleFailedEmailRetry requeues a failed email
+// @Summary Retry Failed Email
+// @Description Requeues a failed email to be sent again with its attempts reset
+// @Tags admin
+// @Produce  json
+// @Param emailId path string true "the email ID to retry"
+// @Success 200 object standardJsonResponse{}
+// @Failure 400 object standardJsonResponse{}
+// @Failure 404 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /admin/emails/{emailId}/retry [post]
+func (s *Service) handleFailedEmailRetry() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		EmailID := vars["emailId"]
+		idErr := validate.Var(EmailID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+
+		err := s.EmailQueueDataSvc.EmailQueueRetry(r.Context(), EmailID)
+		if err != nil && err.Error() == "EMAIL_NOT_FOUND" {
+			s.Failure(w, r, http.StatusNotFound, Errorf(ENOTFOUND, err.Error()))
+			return
+		}
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.audit(r, thunderdome.AuditActionEmailRetry, "email", EmailID, nil, nil)
+
+		s.Success(w, r, http.StatusOK, nil, nil)
+	}
+}
+
+// handleFailedEmailDelete deletes a failed email
+// @Summary Delete Failed Email
+// @Description Deletes a failed email without sending it
+// @Tags admin
+// @Produce  json
+// @Param emailId path string true "the email ID to delete"
+// @Success 200 object standardJsonResponse{}
+// @Failure 400 object standardJsonResponse{}
+// @Failure 404 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /admin/emails/{emailId} [delete]
+func (s *Service) handleFailedEmailDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		EmailID := vars["emailId"]
+		idErr := validate.Var(EmailID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+
+		err := s.EmailQueueDataSvc.EmailQueueDelete(r.Context(), EmailID)
+		if err != nil && err.Error() == "EMAIL_NOT_FOUND" {
+			s.Failure(w, r, http.StatusNotFound, Errorf(ENOTFOUND, err.Error()))
+			return
+		}
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.audit(r, thunderdome.AuditActionEmailDelete, "email", EmailID, nil, nil)
+
+		s.Success(w, r, http.StatusOK, nil, nil)
+	}
+}
@@ -0,0 +1,67 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+// socketHub a websocket service whose connections are closed on shutdown
+type socketHub interface {
+	Shutdown(ctx context.Context) error
+}
+
+// handleHealthz responds whether the application is alive
+func (s *Service) handleHealthz() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.Success(w, r, http.StatusOK, nil, nil)
+	}
+}
+
+// handleReadyz responds whether the application is ready to serve requests,
+// not while shutting down or when the database is unreachable or its migrations aren't applied
+func (s *Service) handleReadyz() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&s.shuttingDown) == 1 {
+			s.Failure(w, r, http.StatusServiceUnavailable, Errorf(EINVALID, "SHUTTING_DOWN"))
+			return
+		}
+
+		if s.ReadyCheck != nil {
+			if err := s.ReadyCheck(r.Context()); err != nil {
+				s.Logger.Ctx(r.Context()).Error("readiness check failed", zap.Error(err))
+				s.Failure(w, r, http.StatusServiceUnavailable, Errorf(EINVALID, "NOT_READY"))
+				return
+			}
+		}
+
+		s.Success(w, r, http.StatusOK, nil, nil)
+	}
+}
+
+// BeginShutdown fails readiness checks so no new traffic is routed to the application
+func (s *Service) BeginShutdown() {
+	atomic.StoreInt32(&s.shuttingDown, 1)
+}
+
+// Shutdown closes the websocket connections of all hubs with a reconnect close code,
+// waiting until they've disconnected or the context is done
+func (s *Service) Shutdown(ctx context.Context) error {
+	hubErrs := make(chan error, len(s.socketHubs))
+	for _, hub := range s.socketHubs {
+		go func(hub socketHub) {
+			hubErrs <- hub.Shutdown(ctx)
+		}(hub)
+	}
+
+	var err error
+	for range s.socketHubs {
+		if hubErr := <-hubErrs; hubErr != nil {
+			err = hubErr
+		}
+	}
+
+	return err
+}
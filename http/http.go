@@ -6,11 +6,16 @@ import (
 	"io/fs"
 	"net/http"
 
+	"github.com/StevenWeathers/thunderdome-planning-poker/audit"
+	"github.com/StevenWeathers/thunderdome-planning-poker/chat"
 	"github.com/StevenWeathers/thunderdome-planning-poker/http/checkin"
 	"github.com/StevenWeathers/thunderdome-planning-poker/http/poker"
 	"github.com/StevenWeathers/thunderdome-planning-poker/http/retro"
 	"github.com/StevenWeathers/thunderdome-planning-poker/http/storyboard"
+	"github.com/StevenWeathers/thunderdome-planning-poker/ratelimit"
+	"github.com/StevenWeathers/thunderdome-planning-poker/scheduler"
 	"github.com/StevenWeathers/thunderdome-planning-poker/swaggerdocs"
+	"github.com/StevenWeathers/thunderdome-planning-poker/throttle"
 	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
@@ -26,6 +31,8 @@ var validate *validator.Validate
 type Config struct {
 	// the domain of the application for cookie securing
 	AppDomain string
+	// AppURL the applications base URL including the path prefix and a trailing slash, for links back to it
+	AppURL string
 	// PathPrefix allows the application to be run on a shared domain
 	PathPrefix string
 	// Whether the external API is enabled
@@ -58,26 +65,63 @@ type Config struct {
 	AvatarService string
 	// Whether to use the OS filesystem or embedded
 	EmbedUseOS bool
+	// Signing secret used to verify inbound Slack requests, Slack commands are disabled when empty
+	SlackSigningSecret string
+	// Slack bot token used to look up Slack users emails
+	SlackBotToken string
+	// Whether users can log in with a passkey without a password
+	PasskeyLoginEnabled bool
+	// Whether the Prometheus metrics endpoint is enabled
+	MetricsEnabled bool
+	// Address the metrics are served on instead of the application router when set
+	MetricsListenAddress string
+	// Bearer token required to scrape the metrics, the metrics aren't served on the application router without one
+	MetricsBearerToken string
 }
 
 type Service struct {
-	Config              *Config
-	UIConfig            thunderdome.UIConfig
-	Router              *mux.Router
-	Email               thunderdome.EmailService
-	Cookie              *securecookie.SecureCookie
-	Logger              *otelzap.Logger
-	UserDataSvc         thunderdome.UserDataSvc
-	ApiKeyDataSvc       thunderdome.APIKeyDataSvc
-	AlertDataSvc        thunderdome.AlertDataSvc
-	AuthDataSvc         thunderdome.AuthDataSvc
-	PokerDataSvc        thunderdome.PokerDataSvc
-	CheckinDataSvc      thunderdome.CheckinDataSvc
-	RetroDataSvc        thunderdome.RetroDataSvc
-	StoryboardDataSvc   thunderdome.StoryboardDataSvc
-	TeamDataSvc         thunderdome.TeamDataSvc
-	OrganizationDataSvc thunderdome.OrganizationDataSvc
-	AdminDataSvc        thunderdome.AdminDataSvc
+	Config                 *Config
+	UIConfig               thunderdome.UIConfig
+	Router                 *mux.Router
+	Email                  thunderdome.EmailService
+	ChatService            thunderdome.ChatService
+	Cookie                 *securecookie.SecureCookie
+	Logger                 *otelzap.Logger
+	UserDataSvc            thunderdome.UserDataSvc
+	ApiKeyDataSvc          thunderdome.APIKeyDataSvc
+	AlertDataSvc           thunderdome.AlertDataSvc
+	AuthDataSvc            thunderdome.AuthDataSvc
+	PokerDataSvc           thunderdome.PokerDataSvc
+	CheckinDataSvc         thunderdome.CheckinDataSvc
+	RetroDataSvc           thunderdome.RetroDataSvc
+	StoryboardDataSvc      thunderdome.StoryboardDataSvc
+	TeamDataSvc            thunderdome.TeamDataSvc
+	OrganizationDataSvc    thunderdome.OrganizationDataSvc
+	AdminDataSvc           thunderdome.AdminDataSvc
+	ChatIntegrationDataSvc thunderdome.ChatIntegrationDataSvc
+	InviteDataSvc          thunderdome.InviteDataSvc
+	SCIMDataSvc            thunderdome.SCIMDataSvc
+	ServiceAccountDataSvc  thunderdome.ServiceAccountDataSvc
+	PasskeyDataSvc         thunderdome.PasskeyDataSvc
+	AuthLimiter            *throttle.Limiter
+	RateLimiter            *ratelimit.Limiter
+	AuditDataSvc           thunderdome.AuditDataSvc
+	EmailQueueDataSvc      thunderdome.EmailQueueDataSvc
+	Audit                  *audit.Recorder
+	// Maintenance the maintenance jobs triggered manually by the admin maintenance endpoints
+	Maintenance *scheduler.Maintenance
+	// ReadyCheck checks the dependencies required to serve requests e.g. the database are available
+	ReadyCheck func(ctx context.Context) error
+	// JobStatus gets the scheduled jobs with their next and latest runs
+	JobStatus func(ctx context.Context) ([]*thunderdome.Job, error)
+	// websocket services whose connections are closed when their user session is revoked
+	socketClosers []sessionSocketCloser
+	// websocket services whose connections are closed on shutdown
+	socketHubs []socketHub
+	// set once shutdown has begun, failing readiness checks
+	shuttingDown int32
+	// when each API keys usage was last recorded
+	apiKeyUsage *apiKeyUsage
 }
 
 // standardJsonResponse structure used for all restful APIs response body
@@ -104,6 +148,10 @@ const (
 	contextKeyOrgRole        contextKey = "orgRole"
 	contextKeyDepartmentRole contextKey = "departmentRole"
 	contextKeyTeamRole       contextKey = "teamRole"
+	contextKeySCIMOrgID      contextKey = "scimOrgId"
+	contextKeySessionID      contextKey = "sessionId"
+	contextKeyAPIKey         contextKey = "apiKey"
+	contextKeyRateLimitAuth  contextKey = "rateLimitAuth"
 	adminUserType            string     = "ADMIN"
 	guestUserType            string     = "GUEST"
 )
@@ -125,10 +173,13 @@ func Init(apiService Service, FSS fs.FS, HFS http.FileSystem) *Service {
 	staticHandler := http.FileServer(HFS)
 
 	var a = &apiService
-	poker := poker.New(a.Logger, a.validateSessionCookie, a.validateUserCookie, a.UserDataSvc, a.AuthDataSvc, a.PokerDataSvc)
-	rs := retro.New(a.Logger, a.validateSessionCookie, a.validateUserCookie, a.UserDataSvc, a.AuthDataSvc, a.RetroDataSvc)
-	sb := storyboard.New(a.Logger, a.validateSessionCookie, a.validateUserCookie, a.UserDataSvc, a.AuthDataSvc, a.StoryboardDataSvc)
-	tc := checkin.New(a.Logger, a.validateSessionCookie, a.validateUserCookie, a.UserDataSvc, a.AuthDataSvc, a.CheckinDataSvc, a.TeamDataSvc)
+	poker := poker.New(a.Logger, a.validateSessionCookie, a.validateUserCookie, a.websocketPolicyCheck, a.UserDataSvc, a.AuthDataSvc, a.PokerDataSvc, a.ChatService, a.AuthLimiter, a.Audit)
+	rs := retro.New(a.Logger, a.validateSessionCookie, a.validateUserCookie, a.websocketPolicyCheck, a.UserDataSvc, a.AuthDataSvc, a.RetroDataSvc, a.ChatService, a.AuthLimiter, a.Audit)
+	sb := storyboard.New(a.Logger, a.validateSessionCookie, a.validateUserCookie, a.websocketPolicyCheck, a.UserDataSvc, a.AuthDataSvc, a.StoryboardDataSvc, a.AuthLimiter, a.Audit)
+	tc := checkin.New(a.Logger, a.validateSessionCookie, a.validateUserCookie, a.websocketPolicyCheck, a.UserDataSvc, a.AuthDataSvc, a.CheckinDataSvc, a.TeamDataSvc, a.ChatService)
+	a.socketClosers = []sessionSocketCloser{poker, rs, sb, tc}
+	a.socketHubs = []socketHub{poker, rs, sb, tc}
+	a.apiKeyUsage = &apiKeyUsage{}
 	swaggerJsonPath := "/" + a.Config.PathPrefix + "swagger/doc.json"
 	validate = validator.New()
 
@@ -138,7 +189,20 @@ func Init(apiService Service, FSS fs.FS, HFS http.FileSystem) *Service {
 		a.Router.PathPrefix("/swagger/").Handler(httpSwagger.Handler(httpSwagger.URL(swaggerJsonPath)))
 	}
 
+	// health checks for orchestrators e.g. kubernetes probes
+	a.Router.HandleFunc("/healthz", a.handleHealthz()).Methods("GET")
+	a.Router.HandleFunc("/readyz", a.handleReadyz()).Methods("GET")
+
+	// prometheus metrics when enabled, on the application router only when they require a bearer token
+	if a.Config.MetricsEnabled {
+		a.Router.Use(a.requestMetrics)
+		if a.Config.MetricsListenAddress == "" && a.Config.MetricsBearerToken != "" {
+			a.Router.Handle("/metrics", a.MetricsHandler()).Methods("GET")
+		}
+	}
+
 	apiRouter := a.Router.PathPrefix("/api").Subrouter()
+	apiRouter.Use(a.rateLimit)
 	userRouter := apiRouter.PathPrefix("/users").Subrouter()
 	orgRouter := apiRouter.PathPrefix("/organizations").Subrouter()
 	teamRouter := apiRouter.PathPrefix("/teams").Subrouter()
@@ -158,9 +222,14 @@ func Init(apiService Service, FSS fs.FS, HFS http.FileSystem) *Service {
 		apiRouter.HandleFunc("/auth/register", a.handleUserRegistration()).Methods("POST")
 	}
 	apiRouter.HandleFunc("/auth/mfa", a.handleMFALogin()).Methods("POST")
+	apiRouter.HandleFunc("/auth/passkey/options", a.handlePasskeyLoginOptions()).Methods("POST")
+	if a.Config.PasskeyLoginEnabled {
+		apiRouter.HandleFunc("/auth/passkey", a.handlePasskeyLogin()).Methods("POST")
+	}
 	apiRouter.HandleFunc("/auth/mfa", a.userOnly(a.registeredUserOnly(a.handleMFARemove()))).Methods("DELETE")
 	apiRouter.HandleFunc("/auth/mfa/setup/generate", a.userOnly(a.registeredUserOnly(a.handleMFASetupGenerate()))).Methods("POST")
 	apiRouter.HandleFunc("/auth/mfa/setup/validate", a.userOnly(a.registeredUserOnly(a.handleMFASetupValidate()))).Methods("POST")
+	apiRouter.HandleFunc("/auth/mfa/recovery-codes", a.userOnly(a.registeredUserOnly(a.handleMFARecoveryCodesGenerate()))).Methods("POST")
 	apiRouter.HandleFunc("/auth/guest", a.handleCreateGuestUser()).Methods("POST")
 	apiRouter.HandleFunc("/auth/user", a.userOnly(a.handleSessionUserProfile())).Methods("GET")
 	apiRouter.HandleFunc("/auth/logout", a.handleLogout()).Methods("DELETE")
@@ -168,6 +237,13 @@ func Init(apiService Service, FSS fs.FS, HFS http.FileSystem) *Service {
 	userRouter.HandleFunc("/{userId}", a.userOnly(a.entityUserOnly(a.handleUserProfile()))).Methods("GET")
 	userRouter.HandleFunc("/{userId}", a.userOnly(a.entityUserOnly(a.handleUserProfileUpdate()))).Methods("PUT")
 	userRouter.HandleFunc("/{userId}", a.userOnly(a.entityUserOnly(a.handleUserDelete()))).Methods("DELETE")
+	userRouter.HandleFunc("/{userId}/sessions", a.userOnly(a.entityUserOnly(a.handleUserSessions()))).Methods("GET")
+	userRouter.HandleFunc("/{userId}/sessions", a.userOnly(a.entityUserOnly(a.handleUserSessionsDelete()))).Methods("DELETE")
+	userRouter.HandleFunc("/{userId}/sessions/{sessionId}", a.userOnly(a.entityUserOnly(a.handleUserSessionDelete()))).Methods("DELETE")
+	userRouter.HandleFunc("/{userId}/passkeys", a.userOnly(a.entityUserOnly(a.handlePasskeys()))).Methods("GET")
+	userRouter.HandleFunc("/{userId}/passkeys", a.userOnly(a.registeredUserOnly(a.entityUserOnly(a.handlePasskeyRegister())))).Methods("POST")
+	userRouter.HandleFunc("/{userId}/passkeys/options", a.userOnly(a.registeredUserOnly(a.entityUserOnly(a.handlePasskeyRegisterOptions())))).Methods("POST")
+	userRouter.HandleFunc("/{userId}/passkeys/{passkeyId}", a.userOnly(a.entityUserOnly(a.handlePasskeyDelete()))).Methods("DELETE")
 	userRouter.HandleFunc("/{userId}/request-verify", a.userOnly(a.entityUserOnly(a.handleVerifyRequest()))).Methods("POST")
 	userRouter.HandleFunc("/{userId}/organizations", a.userOnly(a.entityUserOnly(a.handleGetOrganizationsByUser()))).Methods("GET")
 	userRouter.HandleFunc("/{userId}/organizations", a.userOnly(a.entityUserOnly(a.handleCreateOrganization()))).Methods("POST")
@@ -195,6 +271,8 @@ func Init(apiService Service, FSS fs.FS, HFS http.FileSystem) *Service {
 	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/users", a.userOnly(a.departmentUserOnly(a.handleGetDepartmentUsers()))).Methods("GET")
 	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/users", a.userOnly(a.departmentAdminOnly(a.handleDepartmentAddUser()))).Methods("POST")
 	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/users/{userId}", a.userOnly(a.departmentAdminOnly(a.handleDepartmentRemoveUser()))).Methods("DELETE")
+	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/invites", a.userOnly(a.departmentAdminOnly(a.handleInviteList(thunderdome.InviteTypeDepartment, "departmentId")))).Methods("GET")
+	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/invites/{inviteId}", a.userOnly(a.departmentAdminOnly(a.handleInviteRevoke(thunderdome.InviteTypeDepartment, "departmentId")))).Methods("DELETE")
 	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams", a.userOnly(a.departmentUserOnly(a.handleGetDepartmentTeams()))).Methods("GET")
 	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams", a.userOnly(a.departmentAdminOnly(a.handleCreateDepartmentTeam()))).Methods("POST")
 	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams/{teamId}", a.userOnly(a.departmentTeamUserOnly(a.handleDepartmentTeamByUser()))).Methods("GET")
@@ -202,8 +280,21 @@ func Init(apiService Service, FSS fs.FS, HFS http.FileSystem) *Service {
 	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams/{teamId}/users", a.userOnly(a.departmentTeamUserOnly(a.handleGetTeamUsers()))).Methods("GET")
 	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams/{teamId}/users", a.userOnly(a.departmentTeamAdminOnly(a.handleDepartmentTeamAddUser()))).Methods("POST")
 	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams/{teamId}/users/{userId}", a.userOnly(a.departmentTeamAdminOnly(a.handleTeamRemoveUser()))).Methods("DELETE")
+	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams/{teamId}/templates", a.userOnly(a.departmentTeamUserOnly(a.handleGetTeamTemplates()))).Methods("GET")
 	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams/{teamId}/checkins", a.userOnly(a.departmentTeamUserOnly(a.handleCheckinsGet()))).Methods("GET")
 	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams/{teamId}/checkins", a.userOnly(a.departmentTeamUserOnly(a.handleCheckinCreate(tc)))).Methods("POST")
+	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams/{teamId}/checkins/stats", a.userOnly(a.departmentTeamUserOnly(a.handleCheckinStats()))).Methods("GET")
+	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams/{teamId}/integrations/chat", a.userOnly(a.departmentTeamAdminOnly(a.handleChatIntegrationsGet()))).Methods("GET")
+	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams/{teamId}/integrations/chat", a.userOnly(a.departmentTeamAdminOnly(a.handleChatIntegrationCreate()))).Methods("POST")
+	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams/{teamId}/integrations/chat/{integrationId}", a.userOnly(a.departmentTeamAdminOnly(a.handleChatIntegrationUpdate()))).Methods("PUT")
+	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams/{teamId}/integrations/chat/{integrationId}", a.userOnly(a.departmentTeamAdminOnly(a.handleChatIntegrationDelete()))).Methods("DELETE")
+	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams/{teamId}/checkins/schedule", a.userOnly(a.departmentTeamUserOnly(a.handleCheckinScheduleGet()))).Methods("GET")
+	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams/{teamId}/checkins/schedule", a.userOnly(a.departmentTeamAdminOnly(a.handleCheckinScheduleUpdate()))).Methods("PUT")
+	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams/{teamId}/checkins/schedule", a.userOnly(a.departmentTeamAdminOnly(a.handleCheckinScheduleDelete()))).Methods("DELETE")
+	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams/{teamId}/checkins/questions", a.userOnly(a.departmentTeamUserOnly(a.handleCheckinQuestionsGet()))).Methods("GET")
+	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams/{teamId}/checkins/questions", a.userOnly(a.departmentTeamAdminOnly(a.handleCheckinQuestionCreate(tc)))).Methods("POST")
+	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams/{teamId}/checkins/questions/{questionId}", a.userOnly(a.departmentTeamAdminOnly(a.handleCheckinQuestionUpdate(tc)))).Methods("PUT")
+	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams/{teamId}/checkins/questions/{questionId}", a.userOnly(a.departmentTeamAdminOnly(a.handleCheckinQuestionArchive(tc)))).Methods("DELETE")
 	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams/{teamId}/checkins/{checkinId}", a.userOnly(a.departmentTeamUserOnly(a.handleCheckinUpdate(tc)))).Methods("PUT")
 	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams/{teamId}/checkins/{checkinId}", a.userOnly(a.departmentTeamUserOnly(a.handleCheckinDelete(tc)))).Methods("DELETE")
 	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams/{teamId}/checkins/{checkinId}/comments", a.userOnly(a.departmentTeamUserOnly(a.handleCheckinComment(tc)))).Methods("POST")
@@ -217,8 +308,21 @@ func Init(apiService Service, FSS fs.FS, HFS http.FileSystem) *Service {
 	orgRouter.HandleFunc("/{orgId}/teams/{teamId}/users", a.userOnly(a.orgTeamOnly(a.handleGetTeamUsers()))).Methods("GET")
 	orgRouter.HandleFunc("/{orgId}/teams/{teamId}/users", a.userOnly(a.orgTeamAdminOnly(a.handleOrganizationTeamAddUser()))).Methods("POST")
 	orgRouter.HandleFunc("/{orgId}/teams/{teamId}/users/{userId}", a.userOnly(a.orgTeamAdminOnly(a.handleTeamRemoveUser()))).Methods("DELETE")
+	orgRouter.HandleFunc("/{orgId}/teams/{teamId}/templates", a.userOnly(a.orgTeamOnly(a.handleGetTeamTemplates()))).Methods("GET")
 	orgRouter.HandleFunc("/{orgId}/teams/{teamId}/checkins", a.userOnly(a.orgTeamOnly(a.handleCheckinsGet()))).Methods("GET")
 	orgRouter.HandleFunc("/{orgId}/teams/{teamId}/checkins", a.userOnly(a.orgTeamOnly(a.handleCheckinCreate(tc)))).Methods("POST")
+	orgRouter.HandleFunc("/{orgId}/teams/{teamId}/checkins/stats", a.userOnly(a.orgTeamOnly(a.handleCheckinStats()))).Methods("GET")
+	orgRouter.HandleFunc("/{orgId}/teams/{teamId}/integrations/chat", a.userOnly(a.orgTeamAdminOnly(a.handleChatIntegrationsGet()))).Methods("GET")
+	orgRouter.HandleFunc("/{orgId}/teams/{teamId}/integrations/chat", a.userOnly(a.orgTeamAdminOnly(a.handleChatIntegrationCreate()))).Methods("POST")
+	orgRouter.HandleFunc("/{orgId}/teams/{teamId}/integrations/chat/{integrationId}", a.userOnly(a.orgTeamAdminOnly(a.handleChatIntegrationUpdate()))).Methods("PUT")
+	orgRouter.HandleFunc("/{orgId}/teams/{teamId}/integrations/chat/{integrationId}", a.userOnly(a.orgTeamAdminOnly(a.handleChatIntegrationDelete()))).Methods("DELETE")
+	orgRouter.HandleFunc("/{orgId}/teams/{teamId}/checkins/schedule", a.userOnly(a.orgTeamOnly(a.handleCheckinScheduleGet()))).Methods("GET")
+	orgRouter.HandleFunc("/{orgId}/teams/{teamId}/checkins/schedule", a.userOnly(a.orgTeamAdminOnly(a.handleCheckinScheduleUpdate()))).Methods("PUT")
+	orgRouter.HandleFunc("/{orgId}/teams/{teamId}/checkins/schedule", a.userOnly(a.orgTeamAdminOnly(a.handleCheckinScheduleDelete()))).Methods("DELETE")
+	orgRouter.HandleFunc("/{orgId}/teams/{teamId}/checkins/questions", a.userOnly(a.orgTeamOnly(a.handleCheckinQuestionsGet()))).Methods("GET")
+	orgRouter.HandleFunc("/{orgId}/teams/{teamId}/checkins/questions", a.userOnly(a.orgTeamAdminOnly(a.handleCheckinQuestionCreate(tc)))).Methods("POST")
+	orgRouter.HandleFunc("/{orgId}/teams/{teamId}/checkins/questions/{questionId}", a.userOnly(a.orgTeamAdminOnly(a.handleCheckinQuestionUpdate(tc)))).Methods("PUT")
+	orgRouter.HandleFunc("/{orgId}/teams/{teamId}/checkins/questions/{questionId}", a.userOnly(a.orgTeamAdminOnly(a.handleCheckinQuestionArchive(tc)))).Methods("DELETE")
 	orgRouter.HandleFunc("/{orgId}/teams/{teamId}/checkins/{checkinId}", a.userOnly(a.orgTeamOnly(a.handleCheckinUpdate(tc)))).Methods("PUT")
 	orgRouter.HandleFunc("/{orgId}/teams/{teamId}/checkins/{checkinId}", a.userOnly(a.orgTeamOnly(a.handleCheckinDelete(tc)))).Methods("DELETE")
 	orgRouter.HandleFunc("/{orgId}/teams/{teamId}/checkins/{checkinId}/comments", a.userOnly(a.orgTeamOnly(a.handleCheckinComment(tc)))).Methods("POST")
@@ -228,15 +332,50 @@ func Init(apiService Service, FSS fs.FS, HFS http.FileSystem) *Service {
 	orgRouter.HandleFunc("/{orgId}/users", a.userOnly(a.orgUserOnly(a.handleGetOrganizationUsers()))).Methods("GET")
 	orgRouter.HandleFunc("/{orgId}/users", a.userOnly(a.orgAdminOnly(a.handleOrganizationAddUser()))).Methods("POST")
 	orgRouter.HandleFunc("/{orgId}/users/{userId}", a.userOnly(a.orgAdminOnly(a.handleOrganizationRemoveUser()))).Methods("DELETE")
+	orgRouter.HandleFunc("/{orgId}/invites", a.userOnly(a.orgAdminOnly(a.handleInviteList(thunderdome.InviteTypeOrganization, "orgId")))).Methods("GET")
+	orgRouter.HandleFunc("/{orgId}/invites/{inviteId}", a.userOnly(a.orgAdminOnly(a.handleInviteRevoke(thunderdome.InviteTypeOrganization, "orgId")))).Methods("DELETE")
+	orgRouter.HandleFunc("/{orgId}/audit", a.userOnly(a.orgAdminOnly(a.handleOrganizationAuditLogs()))).Methods("GET")
+	orgRouter.HandleFunc("/{orgId}/policy", a.userOnly(a.orgAdminOnly(a.handleOrganizationPolicyGet()))).Methods("GET")
+	orgRouter.HandleFunc("/{orgId}/policy", a.userOnly(a.orgAdminOnly(a.handleOrganizationPolicyUpdate()))).Methods("PUT")
+	orgRouter.HandleFunc("/{orgId}/domains", a.userOnly(a.orgAdminOnly(a.handleOrganizationDomains()))).Methods("GET")
+	orgRouter.HandleFunc("/{orgId}/domains", a.userOnly(a.orgAdminOnly(a.handleOrganizationDomainCreate()))).Methods("POST")
+	orgRouter.HandleFunc("/{orgId}/domains/{domainId}/verify", a.userOnly(a.orgAdminOnly(a.handleOrganizationDomainVerify()))).Methods("POST")
+	orgRouter.HandleFunc("/{orgId}/domains/{domainId}", a.userOnly(a.orgAdminOnly(a.handleOrganizationDomainDelete()))).Methods("DELETE")
+	orgRouter.HandleFunc("/{orgId}/scim-tokens", a.userOnly(a.orgAdminOnly(a.handleSCIMTokens()))).Methods("GET")
+	orgRouter.HandleFunc("/{orgId}/scim-tokens", a.userOnly(a.orgAdminOnly(a.handleSCIMTokenCreate()))).Methods("POST")
+	orgRouter.HandleFunc("/{orgId}/scim-tokens/{tokenId}", a.userOnly(a.orgAdminOnly(a.handleSCIMTokenDelete()))).Methods("DELETE")
+	orgRouter.HandleFunc("/{orgId}/service-accounts", a.userOnly(a.orgAdminOnly(a.handleServiceAccounts()))).Methods("GET")
+	orgRouter.HandleFunc("/{orgId}/service-accounts", a.userOnly(a.orgAdminOnly(a.handleServiceAccountCreate()))).Methods("POST")
+	orgRouter.HandleFunc("/{orgId}/service-accounts/{userId}", a.userOnly(a.orgAdminOnly(a.handleServiceAccountDelete()))).Methods("DELETE")
+	orgRouter.HandleFunc("/{orgId}/service-accounts/{userId}/teams/{teamId}", a.userOnly(a.orgAdminOnly(a.handleServiceAccountTeamAssign()))).Methods("PUT")
+	orgRouter.HandleFunc("/{orgId}/service-accounts/{userId}/teams/{teamId}", a.userOnly(a.orgAdminOnly(a.handleServiceAccountTeamRemove()))).Methods("DELETE")
+	orgRouter.HandleFunc("/{orgId}/service-accounts/{userId}/apikeys", a.userOnly(a.orgAdminOnly(a.handleServiceAccountAPIKeys()))).Methods("GET")
+	orgRouter.HandleFunc("/{orgId}/service-accounts/{userId}/apikeys", a.userOnly(a.orgAdminOnly(a.handleServiceAccountAPIKeyGenerate()))).Methods("POST")
+	orgRouter.HandleFunc("/{orgId}/service-accounts/{userId}/apikeys/{keyID}", a.userOnly(a.orgAdminOnly(a.handleServiceAccountAPIKeyDelete()))).Methods("DELETE")
 	// teams(s)
 	teamRouter.HandleFunc("/{teamId}", a.userOnly(a.teamUserOnly(a.handleGetTeamByUser()))).Methods("GET")
 	teamRouter.HandleFunc("/{teamId}", a.userOnly(a.teamAdminOnly(a.handleDeleteTeam()))).Methods("DELETE")
 	teamRouter.HandleFunc("/{teamId}/users", a.userOnly(a.teamUserOnly(a.handleGetTeamUsers()))).Methods("GET")
 	teamRouter.HandleFunc("/{teamId}/users", a.userOnly(a.teamAdminOnly(a.handleTeamAddUser()))).Methods("POST")
 	teamRouter.HandleFunc("/{teamId}/users/{userId}", a.userOnly(a.teamAdminOnly(a.handleTeamRemoveUser()))).Methods("DELETE")
+	teamRouter.HandleFunc("/{teamId}/invites", a.userOnly(a.teamAdminOnly(a.handleInviteList(thunderdome.InviteTypeTeam, "teamId")))).Methods("GET")
+	teamRouter.HandleFunc("/{teamId}/invites/{inviteId}", a.userOnly(a.teamAdminOnly(a.handleInviteRevoke(thunderdome.InviteTypeTeam, "teamId")))).Methods("DELETE")
+	teamRouter.HandleFunc("/{teamId}/templates", a.userOnly(a.teamUserOnly(a.handleGetTeamTemplates()))).Methods("GET")
 	teamRouter.HandleFunc("/{teamId}/checkin", tc.ServeWs())
 	teamRouter.HandleFunc("/{teamId}/checkins", a.userOnly(a.teamUserOnly(a.handleCheckinsGet()))).Methods("GET")
 	teamRouter.HandleFunc("/{teamId}/checkins", a.userOnly(a.teamUserOnly(a.handleCheckinCreate(tc)))).Methods("POST")
+	teamRouter.HandleFunc("/{teamId}/checkins/stats", a.userOnly(a.teamUserOnly(a.handleCheckinStats()))).Methods("GET")
+	teamRouter.HandleFunc("/{teamId}/integrations/chat", a.userOnly(a.teamAdminOnly(a.handleChatIntegrationsGet()))).Methods("GET")
+	teamRouter.HandleFunc("/{teamId}/integrations/chat", a.userOnly(a.teamAdminOnly(a.handleChatIntegrationCreate()))).Methods("POST")
+	teamRouter.HandleFunc("/{teamId}/integrations/chat/{integrationId}", a.userOnly(a.teamAdminOnly(a.handleChatIntegrationUpdate()))).Methods("PUT")
+	teamRouter.HandleFunc("/{teamId}/integrations/chat/{integrationId}", a.userOnly(a.teamAdminOnly(a.handleChatIntegrationDelete()))).Methods("DELETE")
+	teamRouter.HandleFunc("/{teamId}/checkins/schedule", a.userOnly(a.teamUserOnly(a.handleCheckinScheduleGet()))).Methods("GET")
+	teamRouter.HandleFunc("/{teamId}/checkins/schedule", a.userOnly(a.teamAdminOnly(a.handleCheckinScheduleUpdate()))).Methods("PUT")
+	teamRouter.HandleFunc("/{teamId}/checkins/schedule", a.userOnly(a.teamAdminOnly(a.handleCheckinScheduleDelete()))).Methods("DELETE")
+	teamRouter.HandleFunc("/{teamId}/checkins/questions", a.userOnly(a.teamUserOnly(a.handleCheckinQuestionsGet()))).Methods("GET")
+	teamRouter.HandleFunc("/{teamId}/checkins/questions", a.userOnly(a.teamAdminOnly(a.handleCheckinQuestionCreate(tc)))).Methods("POST")
+	teamRouter.HandleFunc("/{teamId}/checkins/questions/{questionId}", a.userOnly(a.teamAdminOnly(a.handleCheckinQuestionUpdate(tc)))).Methods("PUT")
+	teamRouter.HandleFunc("/{teamId}/checkins/questions/{questionId}", a.userOnly(a.teamAdminOnly(a.handleCheckinQuestionArchive(tc)))).Methods("DELETE")
 	teamRouter.HandleFunc("/{teamId}/checkins/{checkinId}", a.userOnly(a.teamUserOnly(a.handleCheckinUpdate(tc)))).Methods("PUT")
 	teamRouter.HandleFunc("/{teamId}/checkins/{checkinId}", a.userOnly(a.teamUserOnly(a.handleCheckinDelete(tc)))).Methods("DELETE")
 	teamRouter.HandleFunc("/{teamId}/checkins/{checkinId}/comments", a.userOnly(a.teamUserOnly(a.handleCheckinComment(tc)))).Methods("POST")
@@ -255,6 +394,37 @@ func Init(apiService Service, FSS fs.FS, HFS http.FileSystem) *Service {
 	adminRouter.HandleFunc("/teams", a.userOnly(a.adminOnly(a.handleGetTeams()))).Methods("GET")
 	adminRouter.HandleFunc("/apikeys", a.userOnly(a.adminOnly(a.handleGetAPIKeys()))).Methods("GET")
 	adminRouter.HandleFunc("/search/users/email", a.userOnly(a.adminOnly(a.handleSearchRegisteredUsersByEmail()))).Methods("GET")
+	adminRouter.HandleFunc("/audit", a.userOnly(a.adminOnly(a.handleAuditLogs()))).Methods("GET")
+	adminRouter.HandleFunc("/jobs", a.userOnly(a.adminOnly(a.handleGetJobs()))).Methods("GET")
+	adminRouter.HandleFunc("/emails/failed", a.userOnly(a.adminOnly(a.handleGetFailedEmails()))).Methods("GET")
+	adminRouter.HandleFunc("/emails/{emailId}/retry", a.userOnly(a.adminOnly(a.handleFailedEmailRetry()))).Methods("POST")
+	adminRouter.HandleFunc("/emails/{emailId}", a.userOnly(a.adminOnly(a.handleFailedEmailDelete()))).Methods("DELETE")
+	// slack slash commands and interactions, authenticated by the slack request signature
+	if a.Config.SlackSigningSecret != "" {
+		slack := chat.NewSlack(a.Config.SlackSigningSecret, a.Config.SlackBotToken)
+		apiRouter.HandleFunc("/integrations/slack/commands", a.handleSlackCommand(slack, tc)).Methods("POST")
+		apiRouter.HandleFunc("/integrations/slack/interactions", a.handleSlackInteraction(slack, poker)).Methods("POST")
+	}
+
+	// scim provisioning, authenticated by an organization scoped bearer token
+	if a.Config.OrganizationsEnabled {
+		scimRouter := a.Router.PathPrefix("/scim/v2").Subrouter()
+		scimRouter.Use(a.rateLimit)
+		scimRouter.HandleFunc("/ServiceProviderConfig", a.handleSCIMServiceProviderConfig()).Methods("GET")
+		scimRouter.HandleFunc("/Users", a.scimOrganizationOnly(a.handleSCIMUsers())).Methods("GET")
+		scimRouter.HandleFunc("/Users", a.scimOrganizationOnly(a.handleSCIMUserCreate())).Methods("POST")
+		scimRouter.HandleFunc("/Users/{userId}", a.scimOrganizationOnly(a.handleSCIMUserGet())).Methods("GET")
+		scimRouter.HandleFunc("/Users/{userId}", a.scimOrganizationOnly(a.handleSCIMUserReplace())).Methods("PUT")
+		scimRouter.HandleFunc("/Users/{userId}", a.scimOrganizationOnly(a.handleSCIMUserPatch())).Methods("PATCH")
+		scimRouter.HandleFunc("/Users/{userId}", a.scimOrganizationOnly(a.handleSCIMUserDelete())).Methods("DELETE")
+		scimRouter.HandleFunc("/Groups", a.scimOrganizationOnly(a.handleSCIMGroups())).Methods("GET")
+		scimRouter.HandleFunc("/Groups", a.scimOrganizationOnly(a.handleSCIMGroupCreate())).Methods("POST")
+		scimRouter.HandleFunc("/Groups/{groupId}", a.scimOrganizationOnly(a.handleSCIMGroupGet())).Methods("GET")
+		scimRouter.HandleFunc("/Groups/{groupId}", a.scimOrganizationOnly(a.handleSCIMGroupReplace())).Methods("PUT")
+		scimRouter.HandleFunc("/Groups/{groupId}", a.scimOrganizationOnly(a.handleSCIMGroupPatch())).Methods("PATCH")
+		scimRouter.HandleFunc("/Groups/{groupId}", a.scimOrganizationOnly(a.handleSCIMGroupDelete())).Methods("DELETE")
+	}
+
 	// alert
 	apiRouter.HandleFunc("/alerts", a.userOnly(a.adminOnly(a.handleGetAlerts()))).Methods("GET")
 	apiRouter.HandleFunc("/alerts", a.userOnly(a.adminOnly(a.handleAlertCreate()))).Methods("POST")
@@ -280,6 +450,7 @@ func Init(apiService Service, FSS fs.FS, HFS http.FileSystem) *Service {
 		apiRouter.HandleFunc("/battles", a.userOnly(a.adminOnly(a.handleGetPokerGames()))).Methods("GET")
 		apiRouter.HandleFunc("/battles/{battleId}", a.userOnly(a.handleGetPokerGame())).Methods("GET")
 		apiRouter.HandleFunc("/battles/{battleId}", a.userOnly(a.handlePokerDelete(poker))).Methods("DELETE")
+		apiRouter.HandleFunc("/battles/{battleId}/clone", a.userOnly(a.handlePokerClone())).Methods("POST")
 		apiRouter.HandleFunc("/battles/{battleId}/plans", a.userOnly(a.handlePokerStoryAdd(poker))).Methods("POST")
 		apiRouter.HandleFunc("/arena/{battleId}", poker.ServeBattleWs())
 	}
@@ -303,6 +474,7 @@ func Init(apiService Service, FSS fs.FS, HFS http.FileSystem) *Service {
 		apiRouter.HandleFunc("/retros", a.userOnly(a.adminOnly(a.handleGetRetros()))).Methods("GET")
 		apiRouter.HandleFunc("/retros/{retroId}", a.userOnly(a.handleRetroGet())).Methods("GET")
 		apiRouter.HandleFunc("/retros/{retroId}", a.userOnly(a.handleRetroDelete(rs))).Methods("DELETE")
+		apiRouter.HandleFunc("/retros/{retroId}/clone", a.userOnly(a.handleRetroClone())).Methods("POST")
 		apiRouter.HandleFunc("/retros/{retroId}/actions/{actionId}", a.userOnly(a.handleRetroActionUpdate(rs))).Methods("PUT")
 		apiRouter.HandleFunc("/retros/{retroId}/actions/{actionId}", a.userOnly(a.handleRetroActionDelete(rs))).Methods("DELETE")
 		apiRouter.HandleFunc("/retros/{retroId}/actions/{actionId}/comments", a.userOnly(a.handleRetroActionCommentAdd())).Methods("POST")
@@ -327,6 +499,8 @@ func Init(apiService Service, FSS fs.FS, HFS http.FileSystem) *Service {
 		apiRouter.HandleFunc("/storyboards", a.userOnly(a.adminOnly(a.handleGetStoryboards()))).Methods("GET")
 		apiRouter.HandleFunc("/storyboards/{storyboardId}", a.userOnly(a.handleStoryboardGet())).Methods("GET")
 		apiRouter.HandleFunc("/storyboards/{storyboardId}", a.userOnly(a.handleStoryboardDelete(sb))).Methods("DELETE")
+		apiRouter.HandleFunc("/storyboards/{storyboardId}/clone", a.userOnly(a.handleStoryboardClone())).Methods("POST")
+		apiRouter.HandleFunc("/storyboards/{storyboardId}/history", a.userOnly(a.handleStoryboardHistory())).Methods("GET")
 		apiRouter.HandleFunc("/storyboard/{storyboardId}", sb.ServeWs())
 	}
 
@@ -0,0 +1,143 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// inviteTokenName is the securecookie name used to sign invite tokens
+const inviteTokenName = "invite"
+
+// inviteUser creates a pending invite for an email without an account and emails
+// the signed invite token to the invitee
+func (s *Service) inviteUser(ctx context.Context, InviteType string, TargetId string, TargetName string, Email string, Role string) (*thunderdome.UserInvite, error) {
+	InviterId, _ := ctx.Value(contextKeyUserID).(string)
+	InviterName := "A Thunderdome user"
+	if Inviter, err := s.UserDataSvc.GetUser(ctx, InviterId); err == nil {
+		InviterName = Inviter.Name
+	}
+
+	Invite, err := s.InviteDataSvc.InviteCreate(ctx, InviteType, TargetId, Email, Role, InviterId)
+	if err != nil {
+		return nil, err
+	}
+
+	InviteToken, err := s.Cookie.Encode(inviteTokenName, Invite.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = s.Email.SendTeamInvite(InviterName, Invite.Email, TargetName, InviteToken)
+
+	return Invite, nil
+}
+
+// acceptUserInvites accepts the pending invites for the users email when the email is verified
+// or a valid invite token for the same email was provided
+func (s *Service) acceptUserInvites(ctx context.Context, UserId string, UserEmail string, EmailVerified bool, InviteToken string) {
+	if UserEmail == "" {
+		return
+	}
+
+	if !EmailVerified {
+		if InviteToken == "" {
+			return
+		}
+
+		var InviteId string
+		if err := s.Cookie.Decode(inviteTokenName, InviteToken, &InviteId); err != nil {
+			return
+		}
+
+		Invite, err := s.InviteDataSvc.InviteGet(ctx, InviteId)
+		if err != nil || !strings.EqualFold(Invite.Email, UserEmail) {
+			return
+		}
+	}
+
+	if _, err := s.InviteDataSvc.InviteAcceptByEmail(ctx, UserId, UserEmail); err != nil {
+		s.Logger.Ctx(ctx).Error("error accepting user invites", zap.Error(err), zap.String("user_id", UserId))
+	}
+}
+
+// handleInviteList gets a list of pending invites
+// @Summary Get Pending Invites
+// @Description Gets a list of pending invites for email addresses without an account
+// @Tags team, organization
+// @Produce  json
+// @Param teamId path string false "the team ID"
+// @Param orgId path string false "the organization ID"
+// @Param departmentId path string false "the department ID"
+// @Success 200 object standardJsonResponse{data=[]thunderdome.UserInvite}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /teams/{teamId}/invites [get]
+// @Router /organizations/{orgId}/invites [get]
+// @Router /organizations/{orgId}/departments/{departmentId}/invites [get]
+func (s *Service) handleInviteList(InviteType string, TargetVar string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		TargetId := vars[TargetVar]
+		idErr := validate.Var(TargetId, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+
+		Invites, err := s.InviteDataSvc.InviteList(r.Context(), InviteType, TargetId)
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, Invites, nil)
+	}
+}
+
+// handleInviteRevoke revokes a pending invite
+// @Summary Revoke Pending Invite
+// @Description Revokes a pending invite
+// @Tags team, organization
+// @Produce  json
+// @Param teamId path string false "the team ID"
+// @Param orgId path string false "the organization ID"
+// @Param departmentId path string false "the department ID"
+// @Param inviteId path string true "the invite ID"
+// @Success 200 object standardJsonResponse{}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /teams/{teamId}/invites/{inviteId} [delete]
+// @Router /organizations/{orgId}/invites/{inviteId} [delete]
+// @Router /organizations/{orgId}/departments/{departmentId}/invites/{inviteId} [delete]
+func (s *Service) handleInviteRevoke(InviteType string, TargetVar string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		TargetId := vars[TargetVar]
+		idErr := validate.Var(TargetId, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+		InviteId := vars["inviteId"]
+		idErr = validate.Var(InviteId, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+
+		err := s.InviteDataSvc.InviteRevoke(r.Context(), InviteType, TargetId, InviteId)
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, nil, nil)
+	}
+}
@@ -0,0 +1,32 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// handleGetJobs gets the scheduled jobs
+// @Summary Get Scheduled Jobs
+// @Description Gets the scheduled maintenance jobs with their cron schedule, next run and latest run result
+// @Tags admin
+// @Produce  json
+// @Success 200 object standardJsonResponse{data=[]thunderdome.Job}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /admin/jobs [get]
+func (s *Service) handleGetJobs() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		Jobs := make([]*thunderdome.Job, 0)
+		if s.JobStatus != nil {
+			var err error
+			Jobs, err = s.JobStatus(r.Context())
+			if err != nil {
+				s.Failure(w, r, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		s.Success(w, r, http.StatusOK, Jobs, nil)
+	}
+}
@@ -3,10 +3,24 @@ package http
 import (
 	"net/http"
 
-	"github.com/spf13/viper"
-	"go.uber.org/zap"
+	"github.com/StevenWeathers/thunderdome-planning-poker/scheduler"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
 )
 
+// runMaintenanceJob runs the maintenance job the same as when run on its schedule,
+// recording its result to the audit log
+func (s *Service) runMaintenanceJob(w http.ResponseWriter, r *http.Request, JobName string, TargetType string) {
+	result, err := s.Maintenance.Run(r.Context(), JobName)
+	if err != nil {
+		s.Failure(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.audit(r, thunderdome.AuditActionMaintenanceClean, TargetType, "", nil, map[string]string{"result": result})
+
+	s.Success(w, r, http.StatusOK, nil, nil)
+}
+
 // handleCleanBattles handles cleaning up old battles (ADMIN Manually Triggered)
 // @Summary Clean Old Battles
 // @Description Deletes battles older than {config.cleanup_battles_days_old} based on last activity date
@@ -18,15 +32,7 @@ import (
 // @Router /maintenance/clean-battles [delete]
 func (s *Service) handleCleanBattles() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		DaysOld := viper.GetInt("config.cleanup_battles_days_old")
-
-		err := s.PokerDataSvc.PurgeOldGames(r.Context(), DaysOld)
-		if err != nil {
-			s.Failure(w, r, http.StatusInternalServerError, err)
-			return
-		}
-
-		s.Success(w, r, http.StatusOK, nil, nil)
+		s.runMaintenanceJob(w, r, scheduler.JobCleanBattles, "poker")
 	}
 }
 
@@ -41,15 +47,7 @@ func (s *Service) handleCleanBattles() http.HandlerFunc {
 // @Router /maintenance/clean-retros [delete]
 func (s *Service) handleCleanRetros() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		DaysOld := viper.GetInt("config.cleanup_retros_days_old")
-
-		err := s.RetroDataSvc.CleanRetros(r.Context(), DaysOld)
-		if err != nil {
-			s.Failure(w, r, http.StatusInternalServerError, err)
-			return
-		}
-
-		s.Success(w, r, http.StatusOK, nil, nil)
+		s.runMaintenanceJob(w, r, scheduler.JobCleanRetros, "retro")
 	}
 }
 
@@ -64,15 +62,7 @@ func (s *Service) handleCleanRetros() http.HandlerFunc {
 // @Router /maintenance/clean-storyboards [delete]
 func (s *Service) handleCleanStoryboards() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		DaysOld := viper.GetInt("config.cleanup_storyboards_days_old")
-
-		err := s.StoryboardDataSvc.CleanStoryboards(r.Context(), DaysOld)
-		if err != nil {
-			s.Failure(w, r, http.StatusInternalServerError, err)
-			return
-		}
-
-		s.Success(w, r, http.StatusOK, nil, nil)
+		s.runMaintenanceJob(w, r, scheduler.JobCleanStoryboards, "storyboard")
 	}
 }
 
@@ -87,15 +77,7 @@ func (s *Service) handleCleanStoryboards() http.HandlerFunc {
 // @Router /maintenance/clean-guests [delete]
 func (s *Service) handleCleanGuests() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		DaysOld := viper.GetInt("config.cleanup_guests_days_old")
-
-		err := s.UserDataSvc.CleanGuests(r.Context(), DaysOld)
-		if err != nil {
-			s.Failure(w, r, http.StatusInternalServerError, err)
-			return
-		}
-
-		s.Success(w, r, http.StatusOK, nil, nil)
+		s.runMaintenanceJob(w, r, scheduler.JobCleanGuests, "user")
 	}
 }
 
@@ -110,28 +92,6 @@ func (s *Service) handleCleanGuests() http.HandlerFunc {
 // @Router /maintenance/lowercase-emails [patch]
 func (s *Service) handleLowercaseUserEmails() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		lowercasedUsers, err := s.UserDataSvc.LowercaseUserEmails(r.Context())
-		if err != nil {
-			s.Failure(w, r, http.StatusInternalServerError, err)
-			return
-		}
-
-		s.Logger.Info("Lowercased user emails", zap.Int("count", len(lowercasedUsers)))
-		for _, u := range lowercasedUsers {
-			_ = s.Email.SendEmailUpdate(u.Name, u.Email)
-		}
-
-		mergedUsers, err := s.UserDataSvc.MergeDuplicateAccounts(r.Context())
-		if err != nil {
-			s.Failure(w, r, http.StatusInternalServerError, err)
-			return
-		}
-
-		s.Logger.Info("Merged user accounts", zap.Int("count", len(mergedUsers)))
-		for _, u := range mergedUsers {
-			_ = s.Email.SendMergedUpdate(u.Name, u.Email)
-		}
-
-		s.Success(w, r, http.StatusOK, nil, nil)
+		s.runMaintenanceJob(w, r, scheduler.JobLowercaseUserEmails, "user")
 	}
 }
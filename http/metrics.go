@@ -0,0 +1,87 @@
+package http
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/metrics"
+
+	"github.com/gorilla/mux"
+)
+
+// statusRecorder records the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status   int
+	hijacked bool
+}
+
+func (sr *statusRecorder) WriteHeader(code int) {
+	sr.status = code
+	sr.ResponseWriter.WriteHeader(code)
+}
+
+// Hijack lets websocket connections take over the connection
+func (sr *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := sr.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	sr.hijacked = true
+	return h.Hijack()
+}
+
+// Flush sends any buffered data to the client
+func (sr *statusRecorder) Flush() {
+	if f, ok := sr.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// requestMetrics records the duration of requests by method, route template and status code,
+// websocket connections are excluded as they're measured by their hub
+func (s *Service) requestMetrics(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sr := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		h.ServeHTTP(sr, r)
+
+		if sr.hijacked {
+			return
+		}
+
+		route := "unknown"
+		if current := mux.CurrentRoute(r); current != nil {
+			if tmpl, err := current.GetPathTemplate(); err == nil {
+				route = tmpl
+			}
+		}
+
+		metrics.HTTPRequestDuration.WithLabelValues(r.Method, route, strconv.Itoa(sr.status)).Observe(time.Since(start).Seconds())
+	})
+}
+
+// MetricsHandler serves the Prometheus metrics, requiring the metrics bearer token when one is configured
+func (s *Service) MetricsHandler() http.Handler {
+	h := metrics.Handler()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.Config.MetricsBearerToken != "" {
+			token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(token), []byte(s.Config.MetricsBearerToken)) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				s.Failure(w, r, http.StatusUnauthorized, Errorf(EUNAUTHORIZED, "INVALID_METRICS_TOKEN"))
+				return
+			}
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
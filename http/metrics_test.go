@@ -0,0 +1,32 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+	"go.uber.org/zap"
+)
+
+// TestMetricsHandler makes sure the metrics require the bearer token when one is configured
+func TestMetricsHandler(t *testing.T) {
+	s := &Service{Config: &Config{MetricsBearerToken: "bifrost"}, Logger: otelzap.New(zap.NewNop())}
+
+	for token, expected := range map[string]int{
+		"":                http.StatusUnauthorized,
+		"Bearer heimdall": http.StatusUnauthorized,
+		"Bearer bifrost":  http.StatusOK,
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if token != "" {
+			r.Header.Set("Authorization", token)
+		}
+		w := httptest.NewRecorder()
+		s.MetricsHandler().ServeHTTP(w, r)
+
+		if w.Code != expected {
+			t.Errorf("expected %d with the authorization %q, got %d", expected, token, w.Code)
+		}
+	}
+}
@@ -17,14 +17,27 @@ func (s *Service) userOnly(h http.HandlerFunc) http.HandlerFunc {
 		apiKey = strings.TrimSpace(apiKey)
 		ctx := r.Context()
 		var User *thunderdome.User
+		// the user and API key may already have been looked up by the rate limiter
+		Auth, _ := ctx.Value(contextKeyRateLimitAuth).(*rateLimitAuth)
 
 		if apiKey != "" && s.Config.ExternalAPIEnabled {
+			var APIKey *thunderdome.APIKey
 			var apiKeyErr error
-			User, apiKeyErr = s.ApiKeyDataSvc.GetApiKeyUser(ctx, apiKey)
+			if Auth != nil && Auth.APIKey != nil {
+				User, APIKey = Auth.User, Auth.APIKey
+			} else {
+				User, APIKey, apiKeyErr = s.ApiKeyDataSvc.GetApiKeyUser(ctx, apiKey)
+			}
 			if apiKeyErr != nil {
 				s.Failure(w, r, http.StatusUnauthorized, Errorf(EINVALID, "INVALID_APIKEY"))
 				return
 			}
+			if scopeErr := apiKeyRouteAllowed(r, APIKey); scopeErr != nil {
+				s.Failure(w, r, http.StatusForbidden, scopeErr)
+				return
+			}
+			ctx = context.WithValue(ctx, contextKeyAPIKey, APIKey)
+			s.apiKeyUsed(r, APIKey.Id)
 		} else {
 			SessionId, cookieErr := s.validateSessionCookie(w, r)
 			if cookieErr != nil && cookieErr.Error() != "NO_SESSION_COOKIE" {
@@ -34,11 +47,17 @@ func (s *Service) userOnly(h http.HandlerFunc) http.HandlerFunc {
 
 			if SessionId != "" {
 				var userErr error
-				User, userErr = s.AuthDataSvc.GetSessionUser(ctx, SessionId)
+				if Auth != nil && Auth.SessionID == SessionId {
+					User = Auth.User
+				} else {
+					User, userErr = s.AuthDataSvc.GetSessionUser(ctx, SessionId)
+				}
 				if userErr != nil {
 					s.Failure(w, r, http.StatusUnauthorized, Errorf(EINVALID, "INVALID_USER"))
 					return
 				}
+				ctx = context.WithValue(ctx, contextKeySessionID, SessionId)
+				_ = s.AuthDataSvc.SessionSeen(ctx, SessionId, r.UserAgent(), s.AuthLimiter.ClientIP(r))
 			} else {
 				UserID, err := s.validateUserCookie(w, r)
 				if err != nil {
@@ -109,6 +128,11 @@ func (s *Service) adminOnly(h http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
+		if APIKey, ok := r.Context().Value(contextKeyAPIKey).(*thunderdome.APIKey); ok && !APIKey.HasScope(thunderdome.APIKeyScopeAdmin) {
+			s.Failure(w, r, http.StatusForbidden, Errorf(EUNAUTHORIZED, "APIKEY_SCOPE_REQUIRED"))
+			return
+		}
+
 		h(w, r)
 	}
 }
@@ -169,6 +193,10 @@ func (s *Service) orgUserOnly(h http.HandlerFunc) http.HandlerFunc {
 				s.Failure(w, r, http.StatusForbidden, Errorf(EUNAUTHORIZED, "ORGANIZATION_USER_REQUIRED"))
 				return
 			}
+			if policyErr := s.orgPolicyCheck(ctx, OrgID, UserID); policyErr != nil {
+				s.Failure(w, r, http.StatusForbidden, policyErr)
+				return
+			}
 		} else {
 			Role = adminUserType
 		}
@@ -205,6 +233,10 @@ func (s *Service) orgAdminOnly(h http.HandlerFunc) http.HandlerFunc {
 				s.Failure(w, r, http.StatusForbidden, Errorf(EUNAUTHORIZED, "REQUIRES_ORG_ADMIN"))
 				return
 			}
+			if policyErr := s.orgPolicyCheck(ctx, OrgID, UserID); policyErr != nil {
+				s.Failure(w, r, http.StatusForbidden, policyErr)
+				return
+			}
 		} else {
 			Role = adminUserType
 		}
@@ -244,6 +276,10 @@ func (s *Service) orgTeamOnly(h http.HandlerFunc) http.HandlerFunc {
 				s.Failure(w, r, http.StatusForbidden, Errorf(EUNAUTHORIZED, "REQUIRES_TEAM_USER"))
 				return
 			}
+			if policyErr := s.orgPolicyCheck(ctx, OrgID, UserID); policyErr != nil {
+				s.Failure(w, r, http.StatusForbidden, policyErr)
+				return
+			}
 		} else {
 			OrgRole = adminUserType
 			TeamRole = adminUserType
@@ -289,6 +325,10 @@ func (s *Service) orgTeamAdminOnly(h http.HandlerFunc) http.HandlerFunc {
 				s.Failure(w, r, http.StatusForbidden, Errorf(EUNAUTHORIZED, "REQUIRES_TEAM_OR_ORGANIZATION_ADMIN"))
 				return
 			}
+			if policyErr := s.orgPolicyCheck(ctx, OrgID, UserID); policyErr != nil {
+				s.Failure(w, r, http.StatusForbidden, policyErr)
+				return
+			}
 		} else {
 			OrgRole = adminUserType
 			TeamRole = adminUserType
@@ -330,6 +370,10 @@ func (s *Service) departmentUserOnly(h http.HandlerFunc) http.HandlerFunc {
 				s.Failure(w, r, http.StatusForbidden, Errorf(EUNAUTHORIZED, "REQUIRES_DEPARTMENT_USER"))
 				return
 			}
+			if policyErr := s.orgPolicyCheck(ctx, OrgID, UserID); policyErr != nil {
+				s.Failure(w, r, http.StatusForbidden, policyErr)
+				return
+			}
 		} else {
 			OrgRole = adminUserType
 			DepartmentRole = adminUserType
@@ -375,6 +419,10 @@ func (s *Service) departmentAdminOnly(h http.HandlerFunc) http.HandlerFunc {
 				s.Failure(w, r, http.StatusForbidden, Errorf(EUNAUTHORIZED, "REQUIRES_DEPARTMENT_OR_ORGANIZATION_ADMIN"))
 				return
 			}
+			if policyErr := s.orgPolicyCheck(ctx, OrgID, UserID); policyErr != nil {
+				s.Failure(w, r, http.StatusForbidden, policyErr)
+				return
+			}
 		} else {
 			OrgRole = adminUserType
 			DepartmentRole = adminUserType
@@ -423,6 +471,10 @@ func (s *Service) departmentTeamUserOnly(h http.HandlerFunc) http.HandlerFunc {
 				s.Failure(w, r, http.StatusForbidden, Errorf(EUNAUTHORIZED, "REQUIRES_TEAM_USER"))
 				return
 			}
+			if policyErr := s.orgPolicyCheck(ctx, OrgID, UserID); policyErr != nil {
+				s.Failure(w, r, http.StatusForbidden, policyErr)
+				return
+			}
 		} else {
 			OrgRole = adminUserType
 			DepartmentRole = adminUserType
@@ -478,6 +530,10 @@ func (s *Service) departmentTeamAdminOnly(h http.HandlerFunc) http.HandlerFunc {
 				s.Failure(w, r, http.StatusForbidden, Errorf(EUNAUTHORIZED, "REQUIRES_TEAM_OR_DEPARTMENT_OR_ORGANIZATION_ADMIN"))
 				return
 			}
+			if policyErr := s.orgPolicyCheck(ctx, OrgID, UserID); policyErr != nil {
+				s.Failure(w, r, http.StatusForbidden, policyErr)
+				return
+			}
 		} else {
 			OrgRole = adminUserType
 			DepartmentRole = adminUserType
@@ -492,7 +548,7 @@ func (s *Service) departmentTeamAdminOnly(h http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
-// teamUserOnly validates that the request was made by a valid user of the team
+// teamUserOnly validates that the request was made by a valid user of the team that satisfies its organizations policy
 func (s *Service) teamUserOnly(h http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
@@ -514,6 +570,10 @@ func (s *Service) teamUserOnly(h http.HandlerFunc) http.HandlerFunc {
 				s.Failure(w, r, http.StatusForbidden, Errorf(EUNAUTHORIZED, "REQUIRES_TEAM_USER"))
 				return
 			}
+			if policyErr := s.entityPolicyCheck(ctx, "team", TeamID, UserID); policyErr != nil {
+				s.Failure(w, r, http.StatusForbidden, policyErr)
+				return
+			}
 		} else {
 			Role = adminUserType
 		}
@@ -524,7 +584,7 @@ func (s *Service) teamUserOnly(h http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
-// teamAdminOnly validates that the request was made by an ADMIN of the team
+// teamAdminOnly validates that the request was made by an ADMIN of the team that satisfies its organizations policy
 func (s *Service) teamAdminOnly(h http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
@@ -550,6 +610,10 @@ func (s *Service) teamAdminOnly(h http.HandlerFunc) http.HandlerFunc {
 				s.Failure(w, r, http.StatusForbidden, Errorf(EUNAUTHORIZED, "REQUIRES_TEAM_ADMIN"))
 				return
 			}
+			if policyErr := s.entityPolicyCheck(ctx, "team", TeamID, UserID); policyErr != nil {
+				s.Failure(w, r, http.StatusForbidden, policyErr)
+				return
+			}
 		} else {
 			Role = adminUserType
 		}
@@ -224,13 +224,15 @@ func (s *Service) handleCreateOrganizationTeam() http.HandlerFunc {
 			return
 		}
 
+		s.audit(r, thunderdome.AuditActionTeamCreate, "team", NewTeam.Id, nil, map[string]string{"name": team.Name})
+
 		s.Success(w, r, http.StatusOK, NewTeam, nil)
 	}
 }
 
 // handleOrganizationAddUser handles adding user to an organization
 // @Summary Add Org User
-// @Description Add user to organization
+// @Description Add user to organization, emails an invite when no account exists for the email
 // @Tags organization
 // @Produce  json
 // @Param orgId path string true "organization id"
@@ -263,20 +265,49 @@ func (s *Service) handleOrganizationAddUser() http.HandlerFunc {
 			return
 		}
 
+		inputErr := validate.Struct(u)
+		if inputErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, inputErr.Error()))
+			return
+		}
+
 		UserEmail := u.Email
 
 		User, UserErr := s.UserDataSvc.GetUserByEmail(r.Context(), UserEmail)
 		if UserErr != nil {
-			s.Failure(w, r, http.StatusInternalServerError, Errorf(ENOTFOUND, "USER_NOT_FOUND"))
+			if policyErr := s.orgPolicyEmailAllowed(r.Context(), OrgID, UserEmail); policyErr != nil {
+				s.Failure(w, r, http.StatusBadRequest, policyErr)
+				return
+			}
+
+			Organization, err := s.OrganizationDataSvc.OrganizationGet(r.Context(), OrgID)
+			if err != nil {
+				s.Failure(w, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			Invite, err := s.inviteUser(r.Context(), thunderdome.InviteTypeOrganization, OrgID, Organization.Name, UserEmail, u.Role)
+			if err != nil {
+				s.Failure(w, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			s.Success(w, r, http.StatusOK, Invite, nil)
 			return
 		}
 
 		_, err := s.OrganizationDataSvc.OrganizationAddUser(r.Context(), OrgID, User.Id, u.Role)
+		if err != nil && err.Error() == "ORGANIZATION_EMAIL_DOMAIN_NOT_ALLOWED" {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, err.Error()))
+			return
+		}
 		if err != nil {
 			s.Failure(w, r, http.StatusInternalServerError, err)
 			return
 		}
 
+		s.audit(r, thunderdome.AuditActionOrganizationUserAdd, "organization", OrgID, nil, map[string]string{"userId": User.Id, "role": u.Role})
+
 		s.Success(w, r, http.StatusOK, nil, nil)
 	}
 }
@@ -314,6 +345,8 @@ func (s *Service) handleOrganizationRemoveUser() http.HandlerFunc {
 			return
 		}
 
+		s.audit(r, thunderdome.AuditActionOrganizationUserRemove, "organization", OrgID, map[string]string{"userId": UserID}, nil)
+
 		s.Success(w, r, http.StatusOK, nil, nil)
 	}
 }
@@ -425,6 +458,8 @@ func (s *Service) handleOrganizationTeamAddUser() http.HandlerFunc {
 			return
 		}
 
+		s.audit(r, thunderdome.AuditActionTeamUserAdd, "team", TeamID, nil, map[string]string{"userId": User.Id, "role": u.Role})
+
 		s.Success(w, r, http.StatusOK, nil, nil)
 	}
 }
@@ -456,6 +491,8 @@ func (s *Service) handleDeleteOrganization() http.HandlerFunc {
 			return
 		}
 
+		s.audit(r, thunderdome.AuditActionOrganizationDelete, "organization", OrgID, nil, nil)
+
 		s.Success(w, r, http.StatusOK, nil, nil)
 	}
 }
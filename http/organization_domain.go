@@ -0,0 +1,255 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"github.com/gorilla/mux"
+)
+
+// domainVerificationPrefix the prefix of the TXT record value that verifies an organization owns a domain
+const domainVerificationPrefix = "thunderdome-verification="
+
+// domainVerificationTimeout how long to wait on the domains DNS lookup
+const domainVerificationTimeout = 10 * time.Second
+
+// lookupTXT looks up the domains TXT records, replaced in tests
+var lookupTXT = net.DefaultResolver.LookupTXT
+
+type organizationDomainRequestBody struct {
+	Domain string `json:"domain" validate:"required,fqdn,max=253"`
+}
+
+// domainVerified checks the domain has a TXT record with the verification token
+func domainVerified(ctx context.Context, Domain string, Token string) bool {
+	ctx, cancel := context.WithTimeout(ctx, domainVerificationTimeout)
+	defer cancel()
+
+	records, err := lookupTXT(ctx, Domain)
+	if err != nil {
+		return false
+	}
+
+	for _, record := range records {
+		if strings.TrimSpace(record) == domainVerificationPrefix+Token {
+			return true
+		}
+	}
+
+	return false
+}
+
+// handleOrganizationDomains gets a list of the organizations claimed domains
+// @Summary Get Organization Domains
+// @Description Gets a list of the domains the organization has claimed and whether they are verified
+// @Tags organization
+// @Produce  json
+// @Param orgId path string true "the organization ID"
+// @Success 200 object standardJsonResponse{data=[]thunderdome.OrganizationDomain}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /organizations/{orgId}/domains [get]
+func (s *Service) handleOrganizationDomains() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.Config.OrganizationsEnabled {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "ORGANIZATIONS_DISABLED"))
+			return
+		}
+
+		vars := mux.Vars(r)
+		OrgID := vars["orgId"]
+
+		Domains, err := s.OrganizationDataSvc.OrganizationDomainList(r.Context(), OrgID)
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, Domains, nil)
+	}
+}
+
+// handleOrganizationDomainCreate claims a domain for the organization
+// @Summary Create Organization Domain
+// @Description Claims a domain for the organization, the domain has to be verified with a TXT record of thunderdome-verification={verificationToken} before the organizations policy can use it
+// @Tags organization
+// @Produce  json
+// @Param orgId path string true "the organization ID"
+// @Param domain body organizationDomainRequestBody true "new domain object"
+// @Success 200 object standardJsonResponse{data=thunderdome.OrganizationDomain}
+// @Failure 400 object standardJsonResponse{}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 409 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /organizations/{orgId}/domains [post]
+func (s *Service) handleOrganizationDomainCreate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.Config.OrganizationsEnabled {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "ORGANIZATIONS_DISABLED"))
+			return
+		}
+
+		ctx := r.Context()
+		vars := mux.Vars(r)
+		OrgID := vars["orgId"]
+
+		var d = organizationDomainRequestBody{}
+		body, bodyErr := io.ReadAll(r.Body)
+		if bodyErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, bodyErr.Error()))
+			return
+		}
+
+		jsonErr := json.Unmarshal(body, &d)
+		if jsonErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, jsonErr.Error()))
+			return
+		}
+
+		inputErr := validate.Struct(d)
+		if inputErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, inputErr.Error()))
+			return
+		}
+
+		Domain, err := s.OrganizationDataSvc.OrganizationDomainCreate(ctx, OrgID, d.Domain)
+		if err != nil {
+			if err.Error() == "DOMAIN_ALREADY_VERIFIED" || err.Error() == "DOMAIN_ALREADY_ADDED" {
+				s.Failure(w, r, http.StatusConflict, Errorf(ECONFLICT, err.Error()))
+				return
+			}
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.audit(r, thunderdome.AuditActionOrganizationDomainCreate, "organization_domain", Domain.Id, nil, map[string]string{"domain": Domain.Domain})
+
+		s.Success(w, r, http.StatusOK, Domain, nil)
+	}
+}
+
+// handleOrganizationDomainVerify verifies the organization owns the domain
+// @Summary Verify Organization Domain
+// @Description Verifies the organization owns the domain by its thunderdome-verification TXT record, application admins can verify a domain without the record
+// @Tags organization
+// @Produce  json
+// @Param orgId path string true "the organization ID"
+// @Param domainId path string true "the domain ID"
+// @Success 200 object standardJsonResponse{data=thunderdome.OrganizationDomain}
+// @Failure 400 object standardJsonResponse{}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 404 object standardJsonResponse{}
+// @Failure 409 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /organizations/{orgId}/domains/{domainId}/verify [post]
+func (s *Service) handleOrganizationDomainVerify() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.Config.OrganizationsEnabled {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "ORGANIZATIONS_DISABLED"))
+			return
+		}
+
+		ctx := r.Context()
+		UserType := ctx.Value(contextKeyUserType).(string)
+		vars := mux.Vars(r)
+		OrgID := vars["orgId"]
+		DomainID := vars["domainId"]
+		idErr := validate.Var(DomainID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+
+		Domain, err := s.OrganizationDataSvc.OrganizationDomainGet(ctx, OrgID, DomainID)
+		if err != nil {
+			if err.Error() == "DOMAIN_NOT_FOUND" {
+				s.Failure(w, r, http.StatusNotFound, Errorf(ENOTFOUND, err.Error()))
+				return
+			}
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if Domain.VerifiedDate != nil {
+			s.Success(w, r, http.StatusOK, Domain, nil)
+			return
+		}
+
+		if UserType != adminUserType && !domainVerified(ctx, Domain.Domain, Domain.VerificationToken) {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "DOMAIN_VERIFICATION_FAILED"))
+			return
+		}
+
+		Domain, err = s.OrganizationDataSvc.OrganizationDomainVerify(ctx, OrgID, DomainID)
+		if err != nil {
+			switch err.Error() {
+			case "DOMAIN_NOT_FOUND":
+				s.Failure(w, r, http.StatusNotFound, Errorf(ENOTFOUND, err.Error()))
+			case "DOMAIN_ALREADY_VERIFIED":
+				s.Failure(w, r, http.StatusConflict, Errorf(ECONFLICT, err.Error()))
+			default:
+				s.Failure(w, r, http.StatusInternalServerError, err)
+			}
+			return
+		}
+
+		s.audit(r, thunderdome.AuditActionOrganizationDomainVerify, "organization_domain", Domain.Id, nil, map[string]string{"domain": Domain.Domain})
+
+		s.Success(w, r, http.StatusOK, Domain, nil)
+	}
+}
+
+// handleOrganizationDomainDelete removes the organizations claim on a domain
+// @Summary Delete Organization Domain
+// @Description Removes the organizations claim on the domain, the domain is also removed from the organizations policy
+// @Tags organization
+// @Produce  json
+// @Param orgId path string true "the organization ID"
+// @Param domainId path string true "the domain ID"
+// @Success 200 object standardJsonResponse{}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 404 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /organizations/{orgId}/domains/{domainId} [delete]
+func (s *Service) handleOrganizationDomainDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.Config.OrganizationsEnabled {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "ORGANIZATIONS_DISABLED"))
+			return
+		}
+
+		vars := mux.Vars(r)
+		OrgID := vars["orgId"]
+		DomainID := vars["domainId"]
+		idErr := validate.Var(DomainID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+
+		err := s.OrganizationDataSvc.OrganizationDomainDelete(r.Context(), OrgID, DomainID)
+		if err != nil {
+			if err.Error() == "DOMAIN_NOT_FOUND" {
+				s.Failure(w, r, http.StatusNotFound, Errorf(ENOTFOUND, err.Error()))
+				return
+			}
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.audit(r, thunderdome.AuditActionOrganizationDomainDelete, "organization_domain", DomainID, nil, nil)
+
+		s.Success(w, r, http.StatusOK, nil, nil)
+	}
+}
@@ -0,0 +1,32 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestDomainVerified makes sure a domain is only verified by a TXT record with its verification token
+func TestDomainVerified(t *testing.T) {
+	records := map[string][]string{
+		"asgard.dev":    {"v=spf1 -all", " thunderdome-verification=bifrost "},
+		"jotunheim.dev": {"thunderdome-verification=frostgiant"},
+	}
+	defer func(lookup func(context.Context, string) ([]string, error)) { lookupTXT = lookup }(lookupTXT)
+	lookupTXT = func(ctx context.Context, name string) ([]string, error) {
+		if txt, ok := records[name]; ok {
+			return txt, nil
+		}
+		return nil, errors.New("no such host")
+	}
+
+	if !domainVerified(context.Background(), "asgard.dev", "bifrost") {
+		t.Error("expected asgard.dev to be verified")
+	}
+	if domainVerified(context.Background(), "jotunheim.dev", "bifrost") {
+		t.Error("expected jotunheim.dev with another token not to be verified")
+	}
+	if domainVerified(context.Background(), "vanaheim.dev", "bifrost") {
+		t.Error("expected a domain without records not to be verified")
+	}
+}
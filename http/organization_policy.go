@@ -0,0 +1,255 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+type organizationPolicyRequestBody struct {
+	RequireMFA           bool     `json:"requireMfa"`
+	AllowedDomains       []string `json:"allowedDomains" validate:"max=50,dive,required,fqdn"`
+	AutoJoin             bool     `json:"autoJoin"`
+	SessionLifetimeHours int      `json:"sessionLifetimeHours" validate:"min=0,max=8760"`
+}
+
+// checkOrgPolicy validates the user (and their session when authenticated by one) against the organization policy
+func checkOrgPolicy(Policy *thunderdome.OrganizationPolicy, User *thunderdome.User, SessionCreated *time.Time) error {
+	// service accounts are owned by the organization and only authenticate by API key
+	if User.Type == thunderdome.ServiceAccountUserType {
+		return nil
+	}
+
+	if Policy.RequireMFA && !User.MFAEnabled {
+		return Errorf(EUNAUTHORIZED, "ORGANIZATION_MFA_REQUIRED")
+	}
+
+	if len(Policy.AllowedDomains) > 0 && (!User.Verified || !Policy.EmailDomainAllowed(User.Email)) {
+		return Errorf(EUNAUTHORIZED, "ORGANIZATION_EMAIL_DOMAIN_NOT_ALLOWED")
+	}
+
+	if Policy.SessionLifetimeHours > 0 && SessionCreated != nil &&
+		time.Since(*SessionCreated) > time.Duration(Policy.SessionLifetimeHours)*time.Hour {
+		return Errorf(EUNAUTHORIZED, "ORGANIZATION_SESSION_EXPIRED")
+	}
+
+	return nil
+}
+
+// orgPolicyCheck enforces the organizations security policy for the request user
+func (s *Service) orgPolicyCheck(ctx context.Context, OrgID string, UserID string) error {
+	Policy, err := s.OrganizationDataSvc.OrganizationPolicyGet(ctx, OrgID)
+	if err != nil {
+		return err
+	}
+
+	if !Policy.RequireMFA && len(Policy.AllowedDomains) == 0 && Policy.SessionLifetimeHours == 0 {
+		return nil
+	}
+
+	User, err := s.UserDataSvc.GetUser(ctx, UserID)
+	if err != nil {
+		return err
+	}
+
+	var SessionCreated *time.Time
+	if SessionID, ok := ctx.Value(contextKeySessionID).(string); ok && Policy.SessionLifetimeHours > 0 {
+		CreatedDate, err := s.AuthDataSvc.GetSessionCreatedDate(ctx, SessionID)
+		if err != nil {
+			return Errorf(EUNAUTHORIZED, "ORGANIZATION_SESSION_EXPIRED")
+		}
+		SessionCreated = &CreatedDate
+	}
+
+	return checkOrgPolicy(Policy, User, SessionCreated)
+}
+
+// entityPolicyCheck enforces the security policy of the organizations the team, or the teams a poker game,
+// retro, or storyboard belongs to, are in for the request user
+func (s *Service) entityPolicyCheck(ctx context.Context, EntityType string, EntityID string, UserID string) error {
+	OrgIDs, err := s.TeamDataSvc.TeamEntityOrganizationIDs(ctx, EntityType, EntityID)
+	if err != nil {
+		return err
+	}
+
+	for _, OrgID := range OrgIDs {
+		if err := s.orgPolicyCheck(ctx, OrgID, UserID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// websocketPolicyCheck enforces the organizations security policy for a websocket user, application admins are exempt
+// as they are on the api routes, the returned error is the policy error message to close the websocket with
+func (s *Service) websocketPolicyCheck(ctx context.Context, EntityType string, EntityID string, User *thunderdome.User, SessionID string) error {
+	if User.Type == adminUserType {
+		return nil
+	}
+	if SessionID != "" {
+		ctx = context.WithValue(ctx, contextKeySessionID, SessionID)
+	}
+
+	if err := s.entityPolicyCheck(ctx, EntityType, EntityID, User.Id); err != nil {
+		return errors.New(ErrorMessage(err))
+	}
+
+	return nil
+}
+
+// orgPolicyEmailAllowed validates that the email is allowed to join the organization by its security policy
+func (s *Service) orgPolicyEmailAllowed(ctx context.Context, OrgID string, Email string) error {
+	Policy, err := s.OrganizationDataSvc.OrganizationPolicyGet(ctx, OrgID)
+	if err != nil {
+		return err
+	}
+
+	if !Policy.EmailDomainAllowed(Email) {
+		return Errorf(EINVALID, "ORGANIZATION_EMAIL_DOMAIN_NOT_ALLOWED")
+	}
+
+	return nil
+}
+
+// orgAutoJoin adds the user to the organizations that auto join users on their verified emails domain,
+// only organizations that have verified the domain join users on it
+func (s *Service) orgAutoJoin(ctx context.Context, UserId string, UserEmail string, EmailVerified bool) {
+	if !s.Config.OrganizationsEnabled || !EmailVerified || UserEmail == "" {
+		return
+	}
+
+	if err := s.OrganizationDataSvc.OrganizationPolicyAutoJoin(ctx, UserId, UserEmail); err != nil {
+		s.Logger.Ctx(ctx).Error("organization auto join error", zap.Error(err), zap.String("user_id", UserId))
+	}
+}
+
+// handleOrganizationPolicyGet gets the organizations security policy
+// @Summary Get Organization Policy
+// @Description Gets the organizations security policy
+// @Tags organization
+// @Produce  json
+// @Param orgId path string true "the organization ID"
+// @Success 200 object standardJsonResponse{data=thunderdome.OrganizationPolicy}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /organizations/{orgId}/policy [get]
+func (s *Service) handleOrganizationPolicyGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.Config.OrganizationsEnabled {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "ORGANIZATIONS_DISABLED"))
+			return
+		}
+
+		vars := mux.Vars(r)
+		OrgID := vars["orgId"]
+
+		Policy, err := s.OrganizationDataSvc.OrganizationPolicyGet(r.Context(), OrgID)
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, Policy, nil)
+	}
+}
+
+// handleOrganizationPolicyUpdate updates the organizations security policy
+// @Summary Update Organization Policy
+// @Description Updates the organizations security policy, a policy the updating organization admin would not satisfy is rejected, the allowed domains must be verified organization domains
+// @Tags organization
+// @Produce  json
+// @Param orgId path string true "the organization ID"
+// @Param policy body organizationPolicyRequestBody true "organization policy object"
+// @Success 200 object standardJsonResponse{data=thunderdome.OrganizationPolicy}
+// @Failure 400 object standardJsonResponse{}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /organizations/{orgId}/policy [put]
+func (s *Service) handleOrganizationPolicyUpdate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.Config.OrganizationsEnabled {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "ORGANIZATIONS_DISABLED"))
+			return
+		}
+
+		ctx := r.Context()
+		UserID := ctx.Value(contextKeyUserID).(string)
+		UserType := ctx.Value(contextKeyUserType).(string)
+		vars := mux.Vars(r)
+		OrgID := vars["orgId"]
+
+		var p = organizationPolicyRequestBody{}
+		body, bodyErr := io.ReadAll(r.Body)
+		if bodyErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, bodyErr.Error()))
+			return
+		}
+
+		jsonErr := json.Unmarshal(body, &p)
+		if jsonErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, jsonErr.Error()))
+			return
+		}
+
+		inputErr := validate.Struct(p)
+		if inputErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, inputErr.Error()))
+			return
+		}
+
+		if p.AllowedDomains == nil {
+			p.AllowedDomains = make([]string, 0)
+		}
+
+		// prevent an organization admin from locking themselves out of the organization
+		if UserType != adminUserType {
+			User, err := s.UserDataSvc.GetUser(ctx, UserID)
+			if err != nil {
+				s.Failure(w, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			NewPolicy := &thunderdome.OrganizationPolicy{
+				RequireMFA:     p.RequireMFA,
+				AllowedDomains: p.AllowedDomains,
+			}
+			if policyErr := checkOrgPolicy(NewPolicy, User, nil); policyErr != nil {
+				s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "POLICY_WOULD_LOCK_OUT_USER"))
+				return
+			}
+		}
+
+		OldPolicy, err := s.OrganizationDataSvc.OrganizationPolicyGet(ctx, OrgID)
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		Policy, err := s.OrganizationDataSvc.OrganizationPolicyUpdate(
+			ctx, OrgID, p.RequireMFA, p.AllowedDomains, p.AutoJoin, p.SessionLifetimeHours,
+		)
+		if err != nil {
+			if err.Error() == "DOMAIN_NOT_VERIFIED" {
+				s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, err.Error()))
+				return
+			}
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.audit(r, thunderdome.AuditActionOrganizationPolicyUpdate, "organization", OrgID, OldPolicy, Policy)
+
+		s.Success(w, r, http.StatusOK, Policy, nil)
+	}
+}
@@ -0,0 +1,469 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+	"github.com/StevenWeathers/thunderdome-planning-poker/webauthn"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+type passkeyRegisterRequestBody struct {
+	Name      string                       `json:"name" validate:"required,max=64"`
+	Challenge string                       `json:"challenge" validate:"required"`
+	Response  webauthn.AttestationResponse `json:"response"`
+}
+
+type passkeyRegisterResponse struct {
+	*thunderdome.Passkey
+	// RecoveryCodes issued with the users first second factor, only shown once
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+}
+
+type passkeyOptionsRequestBody struct {
+	// SessionId of the login pending MFA when using a passkey as the second factor, omitted for passwordless login
+	SessionId string `json:"sessionId"`
+}
+
+type passkeyLoginRequestBody struct {
+	Challenge   string                     `json:"challenge" validate:"required"`
+	Response    webauthn.AssertionResponse `json:"response"`
+	InviteToken string                     `json:"inviteToken"`
+}
+
+// relyingParty gets the WebAuthn relying party for the applications domain
+func (s *Service) relyingParty() *webauthn.RelyingParty {
+	host := s.Config.AppDomain
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	origins := []string{"https://" + s.Config.AppDomain}
+	if !s.Config.SecureCookieFlag {
+		origins = append(origins, "http://"+s.Config.AppDomain)
+	}
+
+	return &webauthn.RelyingParty{ID: host, Name: "Thunderdome", Origins: origins}
+}
+
+// passkeyAuthenticate verifies a passkey assertion for the consumed ceremony challenge, returning the used passkey
+func (s *Service) passkeyAuthenticate(
+	ctx context.Context, Challenge *thunderdome.PasskeyChallenge, Response webauthn.AssertionResponse, RequireUserVerification bool,
+) (*thunderdome.Passkey, error) {
+	Passkey, err := s.PasskeyDataSvc.PasskeyGetByCredential(ctx, Response.CredentialId)
+	if err != nil {
+		return nil, err
+	}
+
+	if Challenge.UserId != "" && Challenge.UserId != Passkey.UserId {
+		return nil, errors.New("PASSKEY_USER_MISMATCH")
+	}
+
+	if Response.UserHandle != "" {
+		if UserId, err := webauthn.UserHandle(Response.UserHandle); err != nil || UserId != Passkey.UserId {
+			return nil, errors.New("PASSKEY_USER_MISMATCH")
+		}
+	}
+
+	SignCount, err := s.relyingParty().VerifyAssertion(
+		Challenge.Challenge, Passkey.PublicKey, Passkey.Algorithm, Passkey.SignCount, RequireUserVerification, Response,
+	)
+	if err != nil {
+		s.Logger.Ctx(ctx).Warn("passkey assertion failed", zap.Error(err), zap.String("passkey_id", Passkey.Id))
+		return nil, err
+	}
+
+	if err := s.PasskeyDataSvc.PasskeyUsed(ctx, Passkey.Id, SignCount); err != nil {
+		return nil, err
+	}
+
+	return Passkey, nil
+}
+
+// passkeyMFAValidate validates a passkey as the second factor of the login session, enabling the session
+func (s *Service) passkeyMFAValidate(ctx context.Context, SessionId string, Challenge string, Response webauthn.AssertionResponse) error {
+	PasskeyChallenge, err := s.PasskeyDataSvc.PasskeyChallengeConsume(ctx, Challenge, thunderdome.PasskeyCeremonyMFA)
+	if err != nil {
+		return err
+	}
+
+	if PasskeyChallenge.SessionId != SessionId {
+		return errors.New("INVALID_CHALLENGE")
+	}
+
+	if _, err := s.passkeyAuthenticate(ctx, PasskeyChallenge, Response, false); err != nil {
+		return err
+	}
+
+	return s.AuthDataSvc.EnableSession(ctx, SessionId)
+}
+
+// handlePasskeys gets a list of the users passkeys
+// @Summary Get User Passkeys
+// @Description Gets a list of the users registered passkeys
+// @Tags user
+// @Produce  json
+// @Param userId path string true "the user ID"
+// @Success 200 object standardJsonResponse{data=[]thunderdome.Passkey}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /users/{userId}/passkeys [get]
+func (s *Service) handlePasskeys() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		UserID := vars["userId"]
+
+		Passkeys, err := s.PasskeyDataSvc.PasskeyList(r.Context(), UserID)
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, Passkeys, nil)
+	}
+}
+
+// handlePasskeyRegisterOptions begins a passkey registration ceremony
+// @Summary Passkey Registration Options
+// @Description Gets the WebAuthn credential creation options to register a new passkey for the user
+// @Tags user
+// @Produce  json
+// @Param userId path string true "the user ID"
+// @Success 200 object standardJsonResponse{data=webauthn.CreationOptions}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /users/{userId}/passkeys/options [post]
+func (s *Service) handlePasskeyRegisterOptions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		SessionUserID := ctx.Value(contextKeyUserID).(string)
+		vars := mux.Vars(r)
+		UserID := vars["userId"]
+
+		// passkeys are only registered by the user themselves using their own authenticator
+		if SessionUserID != UserID {
+			s.Failure(w, r, http.StatusForbidden, Errorf(EUNAUTHORIZED, "PASSKEY_OWNER_ONLY"))
+			return
+		}
+
+		User, err := s.UserDataSvc.GetUser(ctx, UserID)
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		Passkeys, err := s.PasskeyDataSvc.PasskeyList(ctx, UserID)
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		Exclude := make([]webauthn.CredentialDescriptor, 0, len(Passkeys))
+		for _, p := range Passkeys {
+			Exclude = append(Exclude, webauthn.NewCredentialDescriptor(p.CredentialId, p.Transports))
+		}
+
+		Challenge, err := webauthn.NewChallenge()
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := s.PasskeyDataSvc.PasskeyChallengeCreate(ctx, Challenge, thunderdome.PasskeyCeremonyRegister, UserID, ""); err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		UserName := User.Email
+		if UserName == "" {
+			UserName = User.Name
+		}
+
+		s.Success(w, r, http.StatusOK, s.relyingParty().CreationOptions(Challenge, User.Id, UserName, Exclude), nil)
+	}
+}
+
+// handlePasskeyRegister completes a passkey registration ceremony
+// @Summary Register Passkey
+// @Description Registers a passkey for the user from the WebAuthn attestation response, enabling MFA for the user
+// @Description along with returning a set of recovery codes when the user doesn't have any yet
+// @Tags user
+// @Produce  json
+// @Param userId path string true "the user ID"
+// @Param passkey body passkeyRegisterRequestBody true "passkey registration object"
+// @Success 200 object standardJsonResponse{data=passkeyRegisterResponse}
+// @Failure 400 object standardJsonResponse{}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /users/{userId}/passkeys [post]
+func (s *Service) handlePasskeyRegister() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		SessionUserID := ctx.Value(contextKeyUserID).(string)
+		vars := mux.Vars(r)
+		UserID := vars["userId"]
+
+		if SessionUserID != UserID {
+			s.Failure(w, r, http.StatusForbidden, Errorf(EUNAUTHORIZED, "PASSKEY_OWNER_ONLY"))
+			return
+		}
+
+		var p = passkeyRegisterRequestBody{}
+		body, bodyErr := io.ReadAll(r.Body)
+		if bodyErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, bodyErr.Error()))
+			return
+		}
+
+		jsonErr := json.Unmarshal(body, &p)
+		if jsonErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, jsonErr.Error()))
+			return
+		}
+
+		inputErr := validate.Struct(p)
+		if inputErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, inputErr.Error()))
+			return
+		}
+
+		Challenge, err := s.PasskeyDataSvc.PasskeyChallengeConsume(ctx, p.Challenge, thunderdome.PasskeyCeremonyRegister)
+		if err != nil || Challenge.UserId != UserID {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "INVALID_CHALLENGE"))
+			return
+		}
+
+		Credential, err := s.relyingParty().VerifyRegistration(Challenge.Challenge, p.Response)
+		if err != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, err.Error()))
+			return
+		}
+
+		Transports := Credential.Transports
+		if Transports == nil {
+			Transports = make([]string, 0)
+		}
+
+		Passkey, RecoveryCodes, err := s.PasskeyDataSvc.PasskeyCreate(ctx, &thunderdome.Passkey{
+			UserId:       UserID,
+			Name:         p.Name,
+			CredentialId: Credential.Id,
+			PublicKey:    Credential.PublicKey,
+			Algorithm:    Credential.Algorithm,
+			SignCount:    Credential.SignCount,
+			Transports:   Transports,
+		})
+		if err != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, err.Error()))
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, passkeyRegisterResponse{Passkey: Passkey, RecoveryCodes: RecoveryCodes}, nil)
+	}
+}
+
+// handlePasskeyDelete deletes a users passkey
+// @Summary Delete Passkey
+// @Description Deletes a users passkey, MFA is disabled when it was the users last second factor
+// @Tags user
+// @Produce  json
+// @Param userId path string true "the user ID"
+// @Param passkeyId path string true "the passkey ID"
+// @Success 200 object standardJsonResponse{}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 404 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /users/{userId}/passkeys/{passkeyId} [delete]
+func (s *Service) handlePasskeyDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		UserID := vars["userId"]
+		PasskeyID := vars["passkeyId"]
+		idErr := validate.Var(PasskeyID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+
+		err := s.PasskeyDataSvc.PasskeyDelete(r.Context(), UserID, PasskeyID)
+		if err != nil && err.Error() == "PASSKEY_NOT_FOUND" {
+			s.Failure(w, r, http.StatusNotFound, Errorf(ENOTFOUND, err.Error()))
+			return
+		}
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, nil, nil)
+	}
+}
+
+// handlePasskeyLoginOptions begins a passkey authentication ceremony
+// @Summary Passkey Login Options
+// @Description Gets the WebAuthn credential request options to authenticate with a passkey,
+// @Description either as the MFA step of a login session or for passwordless login when enabled
+// @Tags auth
+// @Produce  json
+// @Param options body passkeyOptionsRequestBody false "passkey options object"
+// @Success 200 object standardJsonResponse{data=webauthn.RequestOptions}
+// @Failure 400 object standardJsonResponse{}
+// @Failure 401 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Router /auth/passkey/options [post]
+func (s *Service) handlePasskeyLoginOptions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		var p = passkeyOptionsRequestBody{}
+		body, bodyErr := io.ReadAll(r.Body)
+		if bodyErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, bodyErr.Error()))
+			return
+		}
+
+		if len(body) > 0 {
+			jsonErr := json.Unmarshal(body, &p)
+			if jsonErr != nil {
+				s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, jsonErr.Error()))
+				return
+			}
+		}
+
+		if p.SessionId == "" && !s.Config.PasskeyLoginEnabled {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "PASSKEY_LOGIN_DISABLED"))
+			return
+		}
+
+		Challenge, err := webauthn.NewChallenge()
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		// passwordless login lets the user pick any discoverable passkey and requires user verification
+		if p.SessionId == "" {
+			if err := s.PasskeyDataSvc.PasskeyChallengeCreate(ctx, Challenge, thunderdome.PasskeyCeremonyLogin, "", ""); err != nil {
+				s.Failure(w, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			s.Success(w, r, http.StatusOK, s.relyingParty().RequestOptions(Challenge, nil, "required"), nil)
+			return
+		}
+
+		User, err := s.AuthDataSvc.GetSessionUser(ctx, p.SessionId)
+		if err != nil {
+			s.Failure(w, r, http.StatusUnauthorized, Errorf(EUNAUTHORIZED, "INVALID_SESSION"))
+			return
+		}
+
+		Passkeys, err := s.PasskeyDataSvc.PasskeyList(ctx, User.Id)
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		if len(Passkeys) == 0 {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "NO_PASSKEYS_REGISTERED"))
+			return
+		}
+		Allow := make([]webauthn.CredentialDescriptor, 0, len(Passkeys))
+		for _, pk := range Passkeys {
+			Allow = append(Allow, webauthn.NewCredentialDescriptor(pk.CredentialId, pk.Transports))
+		}
+
+		if err := s.PasskeyDataSvc.PasskeyChallengeCreate(ctx, Challenge, thunderdome.PasskeyCeremonyMFA, User.Id, p.SessionId); err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, s.relyingParty().RequestOptions(Challenge, Allow, "preferred"), nil)
+	}
+}
+
+// handlePasskeyLogin attempts to log in the user with a passkey without a password
+// @Summary Passkey Login
+// @Description attempts to log the user in with a passkey, only available when passwordless login is enabled
+// @Tags auth
+// @Produce  json
+// @Param credentials body passkeyLoginRequestBody true "passkey login object"
+// @Success 200 object standardJsonResponse{data=loginResponse}
+// @Failure 400 object standardJsonResponse{}
+// @Failure 401 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Router /auth/passkey [post]
+func (s *Service) handlePasskeyLogin() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		var u = passkeyLoginRequestBody{}
+		body, bodyErr := io.ReadAll(r.Body)
+		if bodyErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, bodyErr.Error()))
+			return
+		}
+
+		jsonErr := json.Unmarshal(body, &u)
+		if jsonErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, jsonErr.Error()))
+			return
+		}
+
+		inputErr := validate.Struct(u)
+		if inputErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, inputErr.Error()))
+			return
+		}
+
+		Challenge, err := s.PasskeyDataSvc.PasskeyChallengeConsume(ctx, u.Challenge, thunderdome.PasskeyCeremonyLogin)
+		if err != nil {
+			s.Failure(w, r, http.StatusUnauthorized, Errorf(EINVALID, "INVALID_LOGIN"))
+			return
+		}
+
+		Passkey, err := s.passkeyAuthenticate(ctx, Challenge, u.Response, true)
+		if err != nil {
+			s.Failure(w, r, http.StatusUnauthorized, Errorf(EINVALID, "INVALID_LOGIN"))
+			return
+		}
+
+		authedUser, err := s.UserDataSvc.GetUser(ctx, Passkey.UserId)
+		if err != nil || authedUser.Disabled {
+			s.Failure(w, r, http.StatusUnauthorized, Errorf(EINVALID, "INVALID_LOGIN"))
+			return
+		}
+
+		SessionId, err := s.AuthDataSvc.CreateSession(ctx, authedUser.Id)
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		// a user verified passkey is already multi-factor
+		if err := s.AuthDataSvc.EnableSession(ctx, SessionId); err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.acceptUserInvites(ctx, authedUser.Id, authedUser.Email, authedUser.Verified, u.InviteToken)
+		s.orgAutoJoin(ctx, authedUser.Id, authedUser.Email, authedUser.Verified)
+
+		cookieErr := s.createSessionCookie(w, SessionId)
+		if cookieErr != nil {
+			s.Failure(w, r, http.StatusInternalServerError, Errorf(EINVALID, "INVALID_COOKIE"))
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, loginResponse{User: authedUser, SessionId: SessionId}, nil)
+	}
+}
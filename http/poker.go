@@ -59,6 +59,7 @@ type battleRequestBody struct {
 	BattleLeaders        []string             `json:"battleLeaders"`
 	JoinCode             string               `json:"joinCode"`
 	LeaderCode           string               `json:"leaderCode"`
+	TemplateID           string               `json:"templateId" validate:"omitempty,uuid"`
 }
 
 // handlePokerCreate handles creating a poker game
@@ -72,7 +73,9 @@ type battleRequestBody struct {
 // @Param teamId path string false "the team ID"
 // @Param battle body battleRequestBody false "new poker game object"
 // @Success 200 object standardJsonResponse{data=thunderdome.Poker}
+// @Failure 400 object standardJsonResponse{}
 // @Failure 403 object standardJsonResponse{}
+// @Failure 404 object standardJsonResponse{}
 // @Failure 500 object standardJsonResponse{}
 // @Security ApiKeyAuth
 // @Router /users/{userId}/battles [post]
@@ -110,12 +113,21 @@ func (s *Service) handlePokerCreate() http.HandlerFunc {
 			return
 		}
 
+		if !teamIdExists && b.TemplateID != "" {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "TEMPLATE_REQUIRES_TEAM"))
+			return
+		}
+
 		var newBattle *thunderdome.Poker
 		var err error
 		// if battle created with team association
 		if teamIdExists {
 			if isTeamUserOrAnAdmin(r) {
-				newBattle, err = s.PokerDataSvc.TeamCreateGame(ctx, TeamID, UserID, b.BattleName, b.PointValuesAllowed, b.Plans, b.AutoFinishVoting, b.PointAverageRounding, b.JoinCode, b.LeaderCode, b.HideVoterIdentity)
+				newBattle, err = s.PokerDataSvc.TeamCreateGame(ctx, TeamID, UserID, b.BattleName, b.PointValuesAllowed, b.Plans, b.AutoFinishVoting, b.PointAverageRounding, b.JoinCode, b.LeaderCode, b.HideVoterIdentity, b.TemplateID)
+				if err != nil && err.Error() == "TEMPLATE_NOT_FOUND" {
+					s.Failure(w, r, http.StatusNotFound, Errorf(ENOTFOUND, err.Error()))
+					return
+				}
 				if err != nil {
 					s.Failure(w, r, http.StatusInternalServerError, err)
 					return
@@ -291,6 +303,66 @@ func (s *Service) handlePokerStoryAdd(b *poker.Service) http.HandlerFunc {
 	}
 }
 
+// handlePokerClone handles cloning a poker game
+// @Summary Clone Poker Game
+// @Description Clones a poker games settings and un-estimated stories into a new game, optionally as a template
+// @Tags poker
+// @Produce  json
+// @Param battleId path string true "the poker game ID to clone"
+// @Param clone body cloneRequestBody false "clone options, templates must belong to a team"
+// @Success 200 object standardJsonResponse{data=thunderdome.Poker}
+// @Failure 400 object standardJsonResponse{}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /battles/{battleId}/clone [post]
+func (s *Service) handlePokerClone() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		vars := mux.Vars(r)
+		BattleID := vars["battleId"]
+		idErr := validate.Var(BattleID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+		UserID := ctx.Value(contextKeyUserID).(string)
+		UserType := ctx.Value(contextKeyUserType).(string)
+
+		c, cErr := getCloneRequestBody(r)
+		if cErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, cErr.Error()))
+			return
+		}
+
+		if UserType != adminUserType {
+			if err := s.PokerDataSvc.ConfirmFacilitator(BattleID, UserID); err != nil {
+				s.Failure(w, r, http.StatusForbidden, Errorf(EUNAUTHORIZED, "REQUIRES_BATTLE_FACILITATOR"))
+				return
+			}
+		}
+
+		if c.TeamID != "" && UserType != adminUserType {
+			if _, err := s.TeamDataSvc.TeamUserRole(ctx, UserID, c.TeamID); err != nil {
+				s.Failure(w, r, http.StatusForbidden, Errorf(EUNAUTHORIZED, "REQUIRES_TEAM_USER"))
+				return
+			}
+		}
+
+		clone, err := s.PokerDataSvc.CloneGame(ctx, BattleID, UserID, c.Name, c.Template, c.TeamID)
+		if err != nil && err.Error() == "TEMPLATE_REQUIRES_TEAM" {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, err.Error()))
+			return
+		}
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, clone, nil)
+	}
+}
+
 // handlePokerDelete handles deleting a poker game
 // @Summary Delete Poker Game
 // @Description Deletes a poker game
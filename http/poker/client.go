@@ -8,6 +8,8 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/StevenWeathers/thunderdome-planning-poker/audit"
+	"github.com/StevenWeathers/thunderdome-planning-poker/metrics"
 	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
 
 	"go.uber.org/zap"
@@ -58,6 +60,9 @@ type connection struct {
 
 	// Buffered channel of outbound messages.
 	send chan []byte
+
+	// The user session the connection was authenticated with, empty for guests.
+	sessionID string
 }
 
 // readPump pumps messages from the websocket connection to the hub.
@@ -124,7 +129,9 @@ func (sub subscription) readPump(b *Service, ctx context.Context) {
 
 		// find event handler and execute otherwise invalid event
 		if _, ok := b.eventHandlers[eventType]; ok && !badEvent {
+			eventStart := time.Now()
 			msg, eventErr, forceClosed = b.eventHandlers[eventType](ctx, BattleID, UserID, eventValue)
+			metrics.ObserveEvent(hubName, eventType, eventStart, eventErr != nil && !forceClosed)
 			if eventErr != nil {
 				badEvent = true
 
@@ -152,6 +159,20 @@ func (c *connection) write(mt int, payload []byte) error {
 	return c.ws.WriteMessage(mt, payload)
 }
 
+// closeRevoked closes the websocket connection after its user session was revoked.
+func (c *connection) closeRevoked() {
+	cm := websocket.FormatCloseMessage(4001, "unauthorized")
+	_ = c.ws.WriteControl(websocket.CloseMessage, cm, time.Now().Add(writeWait))
+	_ = c.ws.Close()
+}
+
+// closeRestart closes the websocket connection with the service restart close code so the client reconnects.
+func (c *connection) closeRestart() {
+	cm := websocket.FormatCloseMessage(websocket.CloseServiceRestart, "service restart")
+	_ = c.ws.WriteControl(websocket.CloseMessage, cm, time.Now().Add(writeWait))
+	_ = c.ws.Close()
+}
+
 // writePump pumps messages from the hub to the websocket connection.
 func (sub *subscription) writePump() {
 	c := sub.conn
@@ -189,6 +210,33 @@ func (b *Service) handleSocketClose(ctx context.Context, ws *websocket.Conn, clo
 	}
 }
 
+// CloseSessions closes any websocket connections authenticated with the revoked user sessions
+func (b *Service) CloseSessions(SessionIDs []string) {
+	if len(SessionIDs) == 0 {
+		return
+	}
+
+	h.closeSessions <- SessionIDs
+}
+
+// Shutdown closes all websocket connections with the service restart close code for clients to reconnect,
+// waiting until they've disconnected or the context is done
+func (b *Service) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	select {
+	case h.shutdown <- done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // ServeBattleWs handles websocket requests from the peer.
 func (b *Service) ServeBattleWs() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -219,6 +267,7 @@ func (b *Service) ServeBattleWs() http.HandlerFunc {
 				b.handleSocketClose(ctx, ws, 4001, "unauthorized")
 				return
 			}
+			c.sessionID = SessionId
 		} else {
 			UserID, err := b.validateUserCookie(w, r)
 			if err != nil {
@@ -241,6 +290,12 @@ func (b *Service) ServeBattleWs() http.HandlerFunc {
 			return
 		}
 
+		// make sure the user satisfies the policy of the organizations the battles teams are in
+		if policyErr := b.policyCheck(ctx, "poker", battleID, User, SessionId); policyErr != nil {
+			b.handleSocketClose(ctx, ws, 4006, policyErr.Error())
+			return
+		}
+
 		// check users battle active status
 		UserErr := b.BattleService.GetUserActiveStatus(battleID, User.Id)
 		if UserErr != nil && !errors.Is(UserErr, sql.ErrNoRows) {
@@ -256,9 +311,16 @@ func (b *Service) ServeBattleWs() http.HandlerFunc {
 		}
 
 		if battle.JoinCode != "" && (UserErr != nil && errors.Is(UserErr, sql.ErrNoRows)) {
+			ClientIP := b.Limiter.ClientIP(r)
+			if b.Limiter.JoinCodeLocked(ctx, ClientIP, battleID) {
+				b.handleSocketClose(ctx, ws, 4029, "too many join code attempts")
+				return
+			}
+
 			jcrEvent := createSocketEvent("join_code_required", "", User.Id)
 			_ = c.write(websocket.TextMessage, jcrEvent)
 
+			var wrongJoinCodes int
 			for {
 				_, msg, err := c.ws.ReadMessage()
 				if err != nil {
@@ -275,9 +337,15 @@ func (b *Service) ServeBattleWs() http.HandlerFunc {
 				}
 
 				if keyVal["type"] == "auth_battle" && keyVal["value"] == battle.JoinCode {
+					b.Limiter.JoinCodeReset(ctx, ClientIP, battleID)
 					UserAuthed = true
 					break
 				} else if keyVal["type"] == "auth_battle" {
+					wrongJoinCodes++
+					if b.Limiter.JoinCodeFailed(ctx, ClientIP, battleID, wrongJoinCodes) {
+						b.handleSocketClose(ctx, ws, 4029, "too many join code attempts")
+						return
+					}
 					authIncorrect := createSocketEvent("join_code_incorrect", "", User.Id)
 					_ = c.write(websocket.TextMessage, authIncorrect)
 				}
@@ -302,7 +370,7 @@ func (b *Service) ServeBattleWs() http.HandlerFunc {
 			h.broadcast <- m
 
 			go ss.writePump()
-			go ss.readPump(b, ctx)
+			go ss.readPump(b, audit.WithIP(ctx, b.Limiter.ClientIP(r)))
 		}
 	}
 }
@@ -320,7 +388,9 @@ func (b *Service) APIEvent(ctx context.Context, arenaID string, UserID, eventTyp
 
 	// find event handler and execute otherwise invalid event
 	if _, ok := b.eventHandlers[eventType]; ok {
+		eventStart := time.Now()
 		msg, eventErr, _ := b.eventHandlers[eventType](ctx, arenaID, UserID, eventValue)
+		metrics.ObserveEvent(hubName, eventType, eventStart, eventErr != nil)
 		if eventErr != nil {
 			return eventErr
 		}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
 )
 
 // UserNudge handles notifying user that they need to vote
@@ -67,6 +69,14 @@ func (b *Service) UserPromote(ctx context.Context, BattleID string, UserID strin
 	}
 	leadersJson, _ := json.Marshal(leaders)
 
+	b.Audit.Record(ctx, thunderdome.AuditLog{
+		ActorId:    UserID,
+		Action:     thunderdome.AuditActionPokerFacilitatorAdd,
+		TargetType: "poker",
+		TargetId:   BattleID,
+		After:      map[string]string{"userId": EventValue},
+	})
+
 	msg := createSocketEvent("leaders_updated", string(leadersJson), "")
 
 	return msg, nil, false
@@ -80,6 +90,14 @@ func (b *Service) UserDemote(ctx context.Context, BattleID string, UserID string
 	}
 	leadersJson, _ := json.Marshal(leaders)
 
+	b.Audit.Record(ctx, thunderdome.AuditLog{
+		ActorId:    UserID,
+		Action:     thunderdome.AuditActionPokerFacilitatorRemove,
+		TargetType: "poker",
+		TargetId:   BattleID,
+		Before:     map[string]string{"userId": EventValue},
+	})
+
 	msg := createSocketEvent("leaders_updated", string(leadersJson), "")
 
 	return msg, nil, false
@@ -99,6 +117,14 @@ func (b *Service) UserPromoteSelf(ctx context.Context, BattleID string, UserID s
 		}
 		leadersJson, _ := json.Marshal(leaders)
 
+		b.Audit.Record(ctx, thunderdome.AuditLog{
+			ActorId:    UserID,
+			Action:     thunderdome.AuditActionPokerFacilitatorAdd,
+			TargetType: "poker",
+			TargetId:   BattleID,
+			After:      map[string]string{"userId": UserID},
+		})
+
 		msg := createSocketEvent("leaders_updated", string(leadersJson), "")
 
 		return msg, nil, false
@@ -183,6 +209,14 @@ func (b *Service) Delete(ctx context.Context, BattleID string, UserID string, Ev
 	if err != nil {
 		return nil, err, false
 	}
+
+	b.Audit.Record(ctx, thunderdome.AuditLog{
+		ActorId:    UserID,
+		Action:     thunderdome.AuditActionPokerDelete,
+		TargetType: "poker",
+		TargetId:   BattleID,
+	})
+
 	msg := createSocketEvent("battle_conceded", "", "")
 
 	return msg, nil, false
@@ -288,16 +322,43 @@ func (b *Service) PlanFinalize(ctx context.Context, BattleID string, UserID stri
 		return nil, err, false
 	}
 
+	// only notify when finalizing the last unpointed story, not when re-pointing a finished game
+	wasFinished := storiesFinished(b.BattleService.GetStories(BattleID, UserID))
+
 	plans, err := b.BattleService.FinalizeStory(BattleID, p.Id, p.Points)
 	if err != nil {
 		return nil, err, false
 	}
+
+	if !wasFinished && storiesFinished(plans) {
+		go func() {
+			game, err := b.BattleService.GetGame(BattleID, UserID)
+			if err != nil {
+				return
+			}
+			b.ChatService.PokerFinished(context.Background(), game)
+		}()
+	}
 	updatedPlans, _ := json.Marshal(plans)
 	msg := createSocketEvent("plan_finalized", string(updatedPlans), "")
 
 	return msg, nil, false
 }
 
+// storiesFinished determines whether every story has been pointed or skipped
+func storiesFinished(stories []*thunderdome.Story) bool {
+	if len(stories) == 0 {
+		return false
+	}
+	for _, story := range stories {
+		if story.Points == "" && !story.Skipped {
+			return false
+		}
+	}
+
+	return true
+}
+
 // Abandon handles setting abandoned true so battle doesn't show up in users battle list, then leaves battle
 func (b *Service) Abandon(ctx context.Context, BattleID string, UserID string, EventValue string) ([]byte, error, bool) {
 	_, err := b.BattleService.AbandonGame(BattleID, UserID)
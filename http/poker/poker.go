@@ -5,6 +5,8 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/StevenWeathers/thunderdome-planning-poker/audit"
+	"github.com/StevenWeathers/thunderdome-planning-poker/throttle"
 	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
 	"github.com/uptrace/opentelemetry-go-extra/otelzap"
 )
@@ -14,10 +16,14 @@ type Service struct {
 	logger                *otelzap.Logger
 	validateSessionCookie func(w http.ResponseWriter, r *http.Request) (string, error)
 	validateUserCookie    func(w http.ResponseWriter, r *http.Request) (string, error)
+	policyCheck           func(ctx context.Context, EntityType string, EntityID string, User *thunderdome.User, SessionID string) error
 	eventHandlers         map[string]func(context.Context, string, string, string) ([]byte, error, bool)
 	UserService           thunderdome.UserDataSvc
 	AuthService           thunderdome.AuthDataSvc
 	BattleService         thunderdome.PokerDataSvc
+	ChatService           thunderdome.ChatService
+	Limiter               *throttle.Limiter
+	Audit                 *audit.Recorder
 }
 
 // New returns a new battle with websocket hub/client and event handlers
@@ -25,16 +31,22 @@ func New(
 	logger *otelzap.Logger,
 	validateSessionCookie func(w http.ResponseWriter, r *http.Request) (string, error),
 	validateUserCookie func(w http.ResponseWriter, r *http.Request) (string, error),
+	policyCheck func(ctx context.Context, EntityType string, EntityID string, User *thunderdome.User, SessionID string) error,
 	userService thunderdome.UserDataSvc, authService thunderdome.AuthDataSvc,
-	battleService thunderdome.PokerDataSvc,
+	battleService thunderdome.PokerDataSvc, chatService thunderdome.ChatService,
+	limiter *throttle.Limiter, auditRecorder *audit.Recorder,
 ) *Service {
 	b := &Service{
 		logger:                logger,
 		validateSessionCookie: validateSessionCookie,
 		validateUserCookie:    validateUserCookie,
+		policyCheck:           policyCheck,
 		UserService:           userService,
 		AuthService:           authService,
 		BattleService:         battleService,
+		ChatService:           chatService,
+		Limiter:               limiter,
+		Audit:                 auditRecorder,
 	}
 
 	b.eventHandlers = map[string]func(context.Context, string, string, string) ([]byte, error, bool){
@@ -0,0 +1,152 @@
+package http
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/ratelimit"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"github.com/gorilla/mux"
+)
+
+// rateLimitAuth the user, API key or SCIM token organization the rate limiter authenticated the request as,
+// reused by userOnly and scimOrganizationOnly so they aren't looked up again
+type rateLimitAuth struct {
+	User      *thunderdome.User
+	APIKey    *thunderdome.APIKey
+	SessionID string
+	SCIMOrgID string
+}
+
+// rateLimit limits the rate of API requests per API key, user, SCIM token or IP address
+// using the limit of the requests route group, responding with too many requests when exceeded
+func (s *Service) rateLimit(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.RateLimiter == nil {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		Key, Auth := s.rateLimitKey(r)
+		if Auth != nil {
+			r = r.WithContext(context.WithValue(r.Context(), contextKeyRateLimitAuth, Auth))
+		}
+
+		Group := rateLimitGroup(r)
+		Result, err := s.RateLimiter.Take(r.Context(), Group, Key)
+		if err != nil {
+			if Group == rateLimitGroupSCIM {
+				s.scimFailure(w, r, http.StatusServiceUnavailable, "", "rate limit unavailable")
+				return
+			}
+			s.Failure(w, r, http.StatusServiceUnavailable, Errorf(EINTERNAL, "RATE_LIMIT_UNAVAILABLE"))
+			return
+		}
+		if Result == nil {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		setRateLimitHeaders(w, Result)
+
+		if !Result.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(Result.RetryAfter.Seconds()))))
+			if Group == rateLimitGroupSCIM {
+				s.scimFailure(w, r, http.StatusTooManyRequests, "", "rate limit exceeded")
+				return
+			}
+			s.Failure(w, r, http.StatusTooManyRequests, Errorf(EINVALID, "RATE_LIMIT_EXCEEDED"))
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// setRateLimitHeaders sets the RateLimit header fields describing the limit and its remaining requests
+func setRateLimitHeaders(w http.ResponseWriter, Result *ratelimit.Result) {
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(Result.Limit.Requests))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(Result.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(int(math.Ceil(Result.Reset.Seconds()))))
+	w.Header().Set("RateLimit-Policy",
+		strconv.Itoa(Result.Limit.Requests)+";w="+strconv.Itoa(int(Result.Limit.Period.Seconds())))
+}
+
+// rateLimitGroupSCIM the route group of the SCIM provisioning API
+const rateLimitGroupSCIM = "scim"
+
+// rateLimitGroup resolves the route group of the request, the first path segment of the matched api route
+// e.g. battles for /api/battles/{battleId}, or scim for the SCIM provisioning API
+func rateLimitGroup(r *http.Request) string {
+	path := r.URL.Path
+	if route := mux.CurrentRoute(r); route != nil {
+		if tmpl, err := route.GetPathTemplate(); err == nil {
+			path = tmpl
+		}
+	}
+
+	if i := strings.Index(path, "/api/"); i != -1 {
+		path = path[i+len("/api/"):]
+	} else if strings.Contains(path, "/scim/v2/") {
+		return rateLimitGroupSCIM
+	}
+
+	return strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)[0]
+}
+
+// rateLimitKey resolves who the request is limited as, the ID of its API key or the authenticated user,
+// the hash of its SCIM bearer token, otherwise the client IP address,
+// along with the user and API key it was authenticated as
+func (s *Service) rateLimitKey(r *http.Request) (string, *rateLimitAuth) {
+	ctx := r.Context()
+	ipKey := "ip:" + s.AuthLimiter.ClientIP(r)
+
+	if rateLimitGroup(r) == rateLimitGroupSCIM {
+		authHeader := r.Header.Get("Authorization")
+		if !strings.HasPrefix(authHeader, "Bearer ") {
+			return ipKey, nil
+		}
+		Token := strings.TrimPrefix(authHeader, "Bearer ")
+		// invalid tokens are limited by IP address so guessing tokens doesn't get a bucket per guess
+		OrgID, err := s.SCIMDataSvc.SCIMTokenOrganization(ctx, Token)
+		if err != nil {
+			return ipKey, nil
+		}
+		// the token is hashed so it isn't kept in the rate limit store
+		hash := sha256.Sum256([]byte(Token))
+		return "scim:" + hex.EncodeToString(hash[:]), &rateLimitAuth{SCIMOrgID: OrgID}
+	}
+
+	if apiKey := strings.TrimSpace(r.Header.Get(apiKeyHeaderName)); apiKey != "" && s.Config.ExternalAPIEnabled {
+		User, APIKey, err := s.ApiKeyDataSvc.GetApiKeyUser(ctx, apiKey)
+		if err != nil {
+			return ipKey, nil
+		}
+		return "apikey:" + APIKey.Id, &rateLimitAuth{User: User, APIKey: APIKey}
+	}
+
+	var value string
+	if cookie, err := r.Cookie(s.Config.SessionCookieName); err == nil {
+		if err := s.Cookie.Decode(s.Config.SessionCookieName, cookie.Value, &value); err == nil && value != "" {
+			User, err := s.AuthDataSvc.GetSessionUser(ctx, value)
+			if err != nil {
+				return ipKey, nil
+			}
+			return "user:" + User.Id, &rateLimitAuth{User: User, SessionID: value}
+		}
+	}
+	// the guest user cookie is signed so its user ID can be trusted without a lookup
+	if cookie, err := r.Cookie(s.Config.SecureCookieName); err == nil {
+		if err := s.Cookie.Decode(s.Config.SecureCookieName, cookie.Value, &value); err == nil && value != "" {
+			return "user:" + value, nil
+		}
+	}
+
+	return ipKey, nil
+}
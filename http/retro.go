@@ -2,6 +2,7 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"strconv"
@@ -201,6 +202,7 @@ type actionUpdateRequestBody struct {
 	ActionID  string `json:"id" swaggerignore:"true" validate:"required,uuid"`
 	Completed bool   `json:"completed" example:"false"`
 	Content   string `json:"content" example:"update documentation" validate:"required"`
+	Version   int    `json:"version" example:"1" validate:"required,min=1"`
 }
 
 // handleRetroActionUpdate handles updating a retro action item
@@ -213,6 +215,7 @@ type actionUpdateRequestBody struct {
 // @Produce  json
 // @Success 200 object standardJsonResponse{}
 // @Success 403 object standardJsonResponse{}
+// @Failure 409 object standardJsonResponse{data=thunderdome.VersionConflict} "the action was edited since the version, data is the current action"
 // @Success 500 object standardJsonResponse{}
 // @Security ApiKeyAuth
 // @Router /retros/{retroId}/actions/{actionId} [put]
@@ -250,6 +253,11 @@ func (s *Service) handleRetroActionUpdate(rs *retro.Service) http.HandlerFunc {
 		updatedActionJson, _ := json.Marshal(ra)
 
 		err := rs.APIEvent(r.Context(), RetroID, UserID, "update_action", string(updatedActionJson))
+		var conflict *thunderdome.VersionConflict
+		if errors.As(err, &conflict) {
+			s.Conflict(w, r, conflict)
+			return
+		}
 		if err != nil {
 			s.Failure(w, r, http.StatusInternalServerError, err)
 			return
@@ -476,6 +484,66 @@ func (s *Service) handleRetroActionCommentDelete() http.HandlerFunc {
 	}
 }
 
+// handleRetroClone handles cloning a retro
+// @Summary Clone Retro
+// @Description Clones a retros settings into a new retro, optionally as a template
+// @Tags retro
+// @Produce  json
+// @Param retroId path string true "the retro ID to clone"
+// @Param clone body cloneRequestBody false "clone options, templates must belong to a team"
+// @Success 200 object standardJsonResponse{data=thunderdome.Retro}
+// @Failure 400 object standardJsonResponse{}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /retros/{retroId}/clone [post]
+func (s *Service) handleRetroClone() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		vars := mux.Vars(r)
+		RetroID := vars["retroId"]
+		idErr := validate.Var(RetroID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+		UserID := ctx.Value(contextKeyUserID).(string)
+		UserType := ctx.Value(contextKeyUserType).(string)
+
+		c, cErr := getCloneRequestBody(r)
+		if cErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, cErr.Error()))
+			return
+		}
+
+		if UserType != adminUserType {
+			if err := s.RetroDataSvc.RetroConfirmFacilitator(RetroID, UserID); err != nil {
+				s.Failure(w, r, http.StatusForbidden, Errorf(EUNAUTHORIZED, "REQUIRES_RETRO_FACILITATOR"))
+				return
+			}
+		}
+
+		if c.TeamID != "" && UserType != adminUserType {
+			if _, err := s.TeamDataSvc.TeamUserRole(ctx, UserID, c.TeamID); err != nil {
+				s.Failure(w, r, http.StatusForbidden, Errorf(EUNAUTHORIZED, "REQUIRES_TEAM_USER"))
+				return
+			}
+		}
+
+		clone, err := s.RetroDataSvc.CloneRetro(ctx, RetroID, UserID, c.Name, c.Template, c.TeamID)
+		if err != nil && err.Error() == "TEMPLATE_REQUIRES_TEAM" {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, err.Error()))
+			return
+		}
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, clone, nil)
+	}
+}
+
 // handleRetroDelete handles deleting a retro
 // @Summary Retro Delete
 // @Description Delete a retro
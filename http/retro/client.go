@@ -8,6 +8,8 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/StevenWeathers/thunderdome-planning-poker/audit"
+	"github.com/StevenWeathers/thunderdome-planning-poker/metrics"
 	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
 
 	"go.uber.org/zap"
@@ -39,6 +41,11 @@ var ownerOnlyOperations = map[string]struct{}{
 	"concede_retro":      {},
 }
 
+// senderOnlyEvents contains a map of events only sent to the connection whose event caused them
+var senderOnlyEvents = map[string]struct{}{
+	"conflict": {},
+}
+
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
@@ -51,6 +58,9 @@ type connection struct {
 
 	// Buffered channel of outbound messages.
 	send chan []byte
+
+	// The user session the connection was authenticated with, empty for guests.
+	sessionID string
 }
 
 // readPump pumps messages from the websocket connection to the hub.
@@ -117,7 +127,9 @@ func (sub subscription) readPump(b *Service, ctx context.Context) {
 
 		// find event handler and execute otherwise invalid event
 		if _, ok := b.eventHandlers[eventType]; ok && !badEvent {
+			eventStart := time.Now()
 			msg, eventErr, forceClosed = b.eventHandlers[eventType](ctx, RetroID, UserID, eventValue)
+			metrics.ObserveEvent(hubName, eventType, eventStart, eventErr != nil && !forceClosed)
 			if eventErr != nil {
 				badEvent = true
 
@@ -130,7 +142,11 @@ func (sub subscription) readPump(b *Service, ctx context.Context) {
 
 		if !badEvent {
 			m := message{msg, sub.arena}
-			h.broadcast <- m
+			if isSenderOnly(msg) {
+				h.direct <- directMessage{m, c}
+			} else {
+				h.broadcast <- m
+			}
 		}
 
 		if forceClosed {
@@ -145,6 +161,20 @@ func (c *connection) write(mt int, payload []byte) error {
 	return c.ws.WriteMessage(mt, payload)
 }
 
+// closeRevoked closes the websocket connection after its user session was revoked.
+func (c *connection) closeRevoked() {
+	cm := websocket.FormatCloseMessage(4001, "unauthorized")
+	_ = c.ws.WriteControl(websocket.CloseMessage, cm, time.Now().Add(writeWait))
+	_ = c.ws.Close()
+}
+
+// closeRestart closes the websocket connection with the service restart close code so the client reconnects.
+func (c *connection) closeRestart() {
+	cm := websocket.FormatCloseMessage(websocket.CloseServiceRestart, "service restart")
+	_ = c.ws.WriteControl(websocket.CloseMessage, cm, time.Now().Add(writeWait))
+	_ = c.ws.Close()
+}
+
 // writePump pumps messages from the hub to the websocket connection.
 func (sub *subscription) writePump() {
 	c := sub.conn
@@ -182,6 +212,33 @@ func (b *Service) handleSocketClose(ctx context.Context, ws *websocket.Conn, clo
 	}
 }
 
+// CloseSessions closes any websocket connections authenticated with the revoked user sessions
+func (b *Service) CloseSessions(SessionIDs []string) {
+	if len(SessionIDs) == 0 {
+		return
+	}
+
+	h.closeSessions <- SessionIDs
+}
+
+// Shutdown closes all websocket connections with the service restart close code for clients to reconnect,
+// waiting until they've disconnected or the context is done
+func (b *Service) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	select {
+	case h.shutdown <- done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // ServeWs handles websocket requests from the peer.
 func (b *Service) ServeWs() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -212,6 +269,7 @@ func (b *Service) ServeWs() http.HandlerFunc {
 				b.handleSocketClose(ctx, ws, 4001, "unauthorized")
 				return
 			}
+			c.sessionID = SessionId
 		} else {
 			UserID, err := b.validateUserCookie(w, r)
 			if err != nil {
@@ -234,6 +292,12 @@ func (b *Service) ServeWs() http.HandlerFunc {
 			return
 		}
 
+		// make sure the user satisfies the policy of the organizations the retros teams are in
+		if policyErr := b.policyCheck(ctx, "retro", retroID, User, SessionId); policyErr != nil {
+			b.handleSocketClose(ctx, ws, 4006, policyErr.Error())
+			return
+		}
+
 		// check users retro active status
 		UserErr := b.RetroService.GetRetroUserActiveStatus(retroID, User.Id)
 		if UserErr != nil && !errors.Is(UserErr, sql.ErrNoRows) {
@@ -249,9 +313,16 @@ func (b *Service) ServeWs() http.HandlerFunc {
 		}
 
 		if retro.JoinCode != "" && (UserErr != nil && errors.Is(UserErr, sql.ErrNoRows)) {
+			ClientIP := b.Limiter.ClientIP(r)
+			if b.Limiter.JoinCodeLocked(ctx, ClientIP, retroID) {
+				b.handleSocketClose(ctx, ws, 4029, "too many join code attempts")
+				return
+			}
+
 			jcrEvent := createSocketEvent("join_code_required", "", User.Id)
 			_ = c.write(websocket.TextMessage, jcrEvent)
 
+			var wrongJoinCodes int
 			for {
 				_, msg, err := c.ws.ReadMessage()
 				if err != nil {
@@ -268,9 +339,15 @@ func (b *Service) ServeWs() http.HandlerFunc {
 				}
 
 				if keyVal["type"] == "auth_retro" && keyVal["value"] == retro.JoinCode {
+					b.Limiter.JoinCodeReset(ctx, ClientIP, retroID)
 					UserAuthed = true
 					break
 				} else if keyVal["type"] == "auth_retro" {
+					wrongJoinCodes++
+					if b.Limiter.JoinCodeFailed(ctx, ClientIP, retroID, wrongJoinCodes) {
+						b.handleSocketClose(ctx, ws, 4029, "too many join code attempts")
+						return
+					}
 					authIncorrect := createSocketEvent("join_code_incorrect", "", User.Id)
 					_ = c.write(websocket.TextMessage, authIncorrect)
 				}
@@ -295,7 +372,7 @@ func (b *Service) ServeWs() http.HandlerFunc {
 			h.broadcast <- m
 
 			go ss.writePump()
-			go ss.readPump(b, ctx)
+			go ss.readPump(b, audit.WithIP(ctx, b.Limiter.ClientIP(r)))
 		}
 	}
 }
@@ -312,12 +389,19 @@ func (b *Service) APIEvent(ctx context.Context, arenaID string, UserID, eventTyp
 
 	// find event handler and execute otherwise invalid event
 	if _, ok := b.eventHandlers[eventType]; ok {
+		eventStart := time.Now()
 		msg, eventErr, _ := b.eventHandlers[eventType](ctx, arenaID, UserID, eventValue)
+		metrics.ObserveEvent(hubName, eventType, eventStart, eventErr != nil)
 		if eventErr != nil {
 			return eventErr
 		}
 
-		if _, ok := h.arenas[arenaID]; ok {
+		// api callers have no connection to send a conflict to so it's returned instead
+		if conflict := eventConflict(msg); conflict != nil {
+			return conflict
+		}
+
+		if _, ok := h.arenas[arenaID]; ok && !isSenderOnly(msg) {
 			m := message{msg, arenaID}
 			h.broadcast <- m
 		}
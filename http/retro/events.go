@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
 )
 
 // CreateItem creates a retro item
@@ -79,13 +81,17 @@ func (b *Service) GroupNameChange(ctx context.Context, RetroID string, UserID st
 	var rs struct {
 		GroupId string `json:"groupId"`
 		Name    string `json:"name"`
+		Version int    `json:"version"`
 	}
 	err := json.Unmarshal([]byte(EventValue), &rs)
 	if err != nil {
 		return nil, err, false
 	}
 
-	groups, err := b.RetroService.GroupNameChange(RetroID, rs.GroupId, rs.Name)
+	groups, err := b.RetroService.GroupNameChange(RetroID, rs.GroupId, rs.Name, rs.Version)
+	if msg, ok := conflictEvent(err, UserID); ok {
+		return msg, nil, false
+	}
 	if err != nil {
 		return nil, err, false
 	}
@@ -165,13 +171,17 @@ func (b *Service) UpdateAction(ctx context.Context, RetroID string, UserID strin
 		ActionID  string `json:"id"`
 		Completed bool   `json:"completed"`
 		Content   string `json:"content"`
+		Version   int    `json:"version"`
 	}
 	err := json.Unmarshal([]byte(EventValue), &rs)
 	if err != nil {
 		return nil, err, false
 	}
 
-	items, err := b.RetroService.UpdateRetroAction(RetroID, rs.ActionID, rs.Content, rs.Completed)
+	items, err := b.RetroService.UpdateRetroAction(RetroID, rs.ActionID, rs.Content, rs.Completed, rs.Version)
+	if msg, ok := conflictEvent(err, UserID); ok {
+		return msg, nil, false
+	}
 	if err != nil {
 		return nil, err, false
 	}
@@ -218,6 +228,16 @@ func (b *Service) AdvancePhase(ctx context.Context, RetroID string, UserID strin
 		return nil, err, false
 	}
 
+	if rs.Phase == "completed" {
+		go func() {
+			completed, err := b.RetroService.RetroGet(RetroID, UserID)
+			if err != nil {
+				return
+			}
+			b.ChatService.RetroCompleted(context.Background(), completed)
+		}()
+	}
+
 	updatedItems, _ := json.Marshal(retro)
 	msg := createSocketEvent("phase_updated", string(updatedItems), "")
 
@@ -240,6 +260,14 @@ func (b *Service) FacilitatorAdd(ctx context.Context, RetroID string, UserID str
 	}
 	updatedFacilitators, _ := json.Marshal(facilitators)
 
+	b.Audit.Record(ctx, thunderdome.AuditLog{
+		ActorId:    UserID,
+		Action:     thunderdome.AuditActionRetroFacilitatorAdd,
+		TargetType: "retro",
+		TargetId:   RetroID,
+		After:      map[string]string{"userId": rs.UserID},
+	})
+
 	msg := createSocketEvent("facilitators_updated", string(updatedFacilitators), "")
 
 	return msg, nil, false
@@ -261,6 +289,14 @@ func (b *Service) FacilitatorRemove(ctx context.Context, RetroID string, UserID
 	}
 	updatedFacilitators, _ := json.Marshal(facilitators)
 
+	b.Audit.Record(ctx, thunderdome.AuditLog{
+		ActorId:    UserID,
+		Action:     thunderdome.AuditActionRetroFacilitatorRemove,
+		TargetType: "retro",
+		TargetId:   RetroID,
+		Before:     map[string]string{"userId": rs.UserID},
+	})
+
 	msg := createSocketEvent("facilitators_updated", string(updatedFacilitators), "")
 
 	return msg, nil, false
@@ -280,6 +316,14 @@ func (b *Service) FacilitatorSelf(ctx context.Context, RetroID string, UserID st
 		}
 		updatedFacilitators, _ := json.Marshal(facilitators)
 
+		b.Audit.Record(ctx, thunderdome.AuditLog{
+			ActorId:    UserID,
+			Action:     thunderdome.AuditActionRetroFacilitatorAdd,
+			TargetType: "retro",
+			TargetId:   RetroID,
+			After:      map[string]string{"userId": UserID},
+		})
+
 		msg := createSocketEvent("facilitators_updated", string(updatedFacilitators), "")
 
 		return msg, nil, false
@@ -326,6 +370,14 @@ func (b *Service) Delete(ctx context.Context, RetroID string, UserID string, Eve
 	if err != nil {
 		return nil, err, false
 	}
+
+	b.Audit.Record(ctx, thunderdome.AuditLog{
+		ActorId:    UserID,
+		Action:     thunderdome.AuditActionRetroDelete,
+		TargetType: "retro",
+		TargetId:   RetroID,
+	})
+
 	msg := createSocketEvent("conceded", "", "")
 
 	return msg, nil, false
@@ -348,6 +400,43 @@ type socketEvent struct {
 	User  string `json:"userId"`
 }
 
+// isSenderOnly checks whether the event is only sent to the connection whose event caused it
+func isSenderOnly(msg []byte) bool {
+	var e socketEvent
+	if err := json.Unmarshal(msg, &e); err != nil {
+		return false
+	}
+	_, ok := senderOnlyEvents[e.Type]
+
+	return ok
+}
+
+// eventConflict gets the version conflict of a conflict event, nil for any other event
+func eventConflict(msg []byte) *thunderdome.VersionConflict {
+	var e socketEvent
+	if err := json.Unmarshal(msg, &e); err != nil || e.Type != "conflict" {
+		return nil
+	}
+
+	var conflict thunderdome.VersionConflict
+	if err := json.Unmarshal([]byte(e.Value), &conflict); err != nil {
+		return nil
+	}
+
+	return &conflict
+}
+
+// conflictEvent creates a conflict event for the editor when the error is a version conflict
+func conflictEvent(err error, UserID string) ([]byte, bool) {
+	var conflict *thunderdome.VersionConflict
+	if !errors.As(err, &conflict) {
+		return nil, false
+	}
+	value, _ := json.Marshal(conflict)
+
+	return createSocketEvent("conflict", string(value), UserID), true
+}
+
 func createSocketEvent(Type string, Value string, User string) []byte {
 	newEvent := &socketEvent{
 		Type:  Type,
@@ -4,6 +4,8 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/StevenWeathers/thunderdome-planning-poker/audit"
+	"github.com/StevenWeathers/thunderdome-planning-poker/throttle"
 	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
 	"github.com/uptrace/opentelemetry-go-extra/otelzap"
 )
@@ -13,10 +15,14 @@ type Service struct {
 	logger                *otelzap.Logger
 	validateSessionCookie func(w http.ResponseWriter, r *http.Request) (string, error)
 	validateUserCookie    func(w http.ResponseWriter, r *http.Request) (string, error)
+	policyCheck           func(ctx context.Context, EntityType string, EntityID string, User *thunderdome.User, SessionID string) error
 	eventHandlers         map[string]func(context.Context, string, string, string) ([]byte, error, bool)
 	UserService           thunderdome.UserDataSvc
 	AuthService           thunderdome.AuthDataSvc
 	RetroService          thunderdome.RetroDataSvc
+	ChatService           thunderdome.ChatService
+	Limiter               *throttle.Limiter
+	Audit                 *audit.Recorder
 }
 
 // New returns a new retro with websocket hub/client and event handlers
@@ -24,16 +30,22 @@ func New(
 	logger *otelzap.Logger,
 	validateSessionCookie func(w http.ResponseWriter, r *http.Request) (string, error),
 	validateUserCookie func(w http.ResponseWriter, r *http.Request) (string, error),
+	policyCheck func(ctx context.Context, EntityType string, EntityID string, User *thunderdome.User, SessionID string) error,
 	userService thunderdome.UserDataSvc, authService thunderdome.AuthDataSvc,
-	retroService thunderdome.RetroDataSvc,
+	retroService thunderdome.RetroDataSvc, chatService thunderdome.ChatService,
+	limiter *throttle.Limiter, auditRecorder *audit.Recorder,
 ) *Service {
 	rs := &Service{
 		logger:                logger,
 		validateSessionCookie: validateSessionCookie,
 		validateUserCookie:    validateUserCookie,
+		policyCheck:           policyCheck,
 		UserService:           userService,
 		AuthService:           authService,
 		RetroService:          retroService,
+		ChatService:           chatService,
+		Limiter:               limiter,
+		Audit:                 auditRecorder,
 	}
 
 	rs.eventHandlers = map[string]func(context.Context, string, string, string) ([]byte, error, bool){
@@ -0,0 +1,681 @@
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// SCIM 2.0 (RFC 7643, RFC 7644) provisioning for an organization where
+// SCIM users are the organizations users and SCIM groups are the organizations teams
+
+const (
+	scimSchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimSchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	scimSchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	scimSchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	scimSchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+	scimSchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	scimContentType                 = "application/scim+json"
+	scimDefaultCount                = 100
+	scimMaxCount                    = 500
+)
+
+// scimFilterRegex matches the attribute equality filters supported, e.g. userName eq "thor@thunderdome.dev"
+var scimFilterRegex = regexp.MustCompile(`^\s*([A-Za-z][A-Za-z0-9._]*)\s+(?i:eq)\s+"((?:[^"\\]|\\.)*)"\s*$`)
+
+type scimMeta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location"`
+}
+
+type scimName struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+type scimEmail struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type scimUser struct {
+	Schemas     []string    `json:"schemas"`
+	Id          string      `json:"id,omitempty"`
+	ExternalId  string      `json:"externalId,omitempty"`
+	UserName    string      `json:"userName"`
+	Name        *scimName   `json:"name,omitempty"`
+	DisplayName string      `json:"displayName,omitempty"`
+	Emails      []scimEmail `json:"emails,omitempty"`
+	Active      *bool       `json:"active,omitempty"`
+	Meta        *scimMeta   `json:"meta,omitempty"`
+}
+
+type scimListResponse struct {
+	Schemas      []string      `json:"schemas"`
+	TotalResults int           `json:"totalResults"`
+	StartIndex   int           `json:"startIndex"`
+	ItemsPerPage int           `json:"itemsPerPage"`
+	Resources    []interface{} `json:"Resources"`
+}
+
+type scimPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+type scimPatchRequest struct {
+	Schemas    []string             `json:"schemas"`
+	Operations []scimPatchOperation `json:"Operations"`
+}
+
+type scimErrorResponse struct {
+	Schemas  []string `json:"schemas"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail"`
+	Status   string   `json:"status"`
+}
+
+// scimUserChanges the user attributes to apply from a SCIM request, empty values are left unchanged
+type scimUserChanges struct {
+	Name   string
+	Email  string
+	Active *bool
+}
+
+// email gets the users primary email falling back to the userName
+func (u scimUser) email() string {
+	for _, e := range u.Emails {
+		if e.Primary && e.Value != "" {
+			return e.Value
+		}
+	}
+	if len(u.Emails) > 0 && u.Emails[0].Value != "" {
+		return u.Emails[0].Value
+	}
+
+	return u.UserName
+}
+
+// displayName gets the users full name from the most specific attribute provided
+func (u scimUser) displayName() string {
+	if u.DisplayName != "" {
+		return u.DisplayName
+	}
+	if u.Name != nil {
+		if name := u.Name.fullName(); name != "" {
+			return name
+		}
+	}
+
+	return u.UserName
+}
+
+// fullName gets the formatted name or joins the given and family names
+func (n scimName) fullName() string {
+	if n.Formatted != "" {
+		return n.Formatted
+	}
+
+	return strings.TrimSpace(n.GivenName + " " + n.FamilyName)
+}
+
+// scimUserFromUser converts a user to the SCIM user resource
+func (s *Service) scimUserFromUser(u *thunderdome.User) scimUser {
+	active := !u.Disabled
+
+	return scimUser{
+		Schemas:     []string{scimSchemaUser},
+		Id:          u.Id,
+		UserName:    u.Email,
+		Name:        &scimName{Formatted: u.Name},
+		DisplayName: u.Name,
+		Emails:      []scimEmail{{Value: u.Email, Type: "work", Primary: true}},
+		Active:      &active,
+		Meta: &scimMeta{
+			ResourceType: "User",
+			Created:      u.CreatedDate,
+			LastModified: u.UpdatedDate,
+			Location:     s.Config.AppURL + "scim/v2/Users/" + u.Id,
+		},
+	}
+}
+
+// parseSCIMFilter parses an attribute equality filter returning the lowercased attribute and value
+func parseSCIMFilter(filter string) (string, string, error) {
+	matches := scimFilterRegex.FindStringSubmatch(filter)
+	if matches == nil {
+		return "", "", errors.New("unsupported filter, only attribute eq \"value\" filters are supported")
+	}
+	value, err := strconv.Unquote(`"` + matches[2] + `"`)
+	if err != nil {
+		return "", "", errors.New("invalid filter value")
+	}
+
+	return strings.ToLower(matches[1]), value, nil
+}
+
+// parseSCIMBool parses a SCIM boolean value, some identity providers send booleans as strings
+func parseSCIMBool(raw json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(raw, &b); err == nil {
+		return b, nil
+	}
+
+	var str string
+	if err := json.Unmarshal(raw, &str); err != nil {
+		return false, errors.New("invalid boolean value")
+	}
+
+	return strconv.ParseBool(strings.ToLower(str))
+}
+
+// scimPagination gets the 1-based startIndex and count query params
+func scimPagination(r *http.Request) (int, int) {
+	query := r.URL.Query()
+
+	startIndex, err := strconv.Atoi(query.Get("startIndex"))
+	if err != nil || startIndex < 1 {
+		startIndex = 1
+	}
+
+	count, err := strconv.Atoi(query.Get("count"))
+	if err != nil || count < 0 {
+		count = scimDefaultCount
+	}
+	if count > scimMaxCount {
+		count = scimMaxCount
+	}
+
+	return startIndex, count
+}
+
+// applyUserAttribute applies a SCIM user attribute value from a PATCH operation, unsupported attributes are ignored
+func applyUserAttribute(changes *scimUserChanges, attribute string, raw json.RawMessage) error {
+	attribute = strings.ToLower(attribute)
+
+	switch {
+	case attribute == "active":
+		active, err := parseSCIMBool(raw)
+		if err != nil {
+			return err
+		}
+		changes.Active = &active
+	case attribute == "displayname" || attribute == "name.formatted":
+		return json.Unmarshal(raw, &changes.Name)
+	case attribute == "name":
+		var name scimName
+		if err := json.Unmarshal(raw, &name); err != nil {
+			return err
+		}
+		if fullName := name.fullName(); fullName != "" {
+			changes.Name = fullName
+		}
+	case attribute == "username" || (strings.HasPrefix(attribute, "emails[") && strings.HasSuffix(attribute, ".value")):
+		return json.Unmarshal(raw, &changes.Email)
+	case attribute == "emails":
+		var emails []scimEmail
+		if err := json.Unmarshal(raw, &emails); err != nil {
+			return err
+		}
+		if email := (scimUser{Emails: emails}).email(); email != "" {
+			changes.Email = email
+		}
+	}
+
+	return nil
+}
+
+// scimRespond writes the SCIM response body
+func (s *Service) scimRespond(w http.ResponseWriter, r *http.Request, status int, body interface{}) {
+	w.Header().Set("Content-Type", scimContentType)
+	w.WriteHeader(status)
+
+	if body == nil {
+		return
+	}
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		s.Logger.Ctx(r.Context()).Error("error encoding scim response", zap.Error(err))
+	}
+}
+
+// scimFailure writes a SCIM error response
+func (s *Service) scimFailure(w http.ResponseWriter, r *http.Request, status int, scimType string, detail string) {
+	s.scimRespond(w, r, status, scimErrorResponse{
+		Schemas:  []string{scimSchemaError},
+		ScimType: scimType,
+		Detail:   detail,
+		Status:   strconv.Itoa(status),
+	})
+}
+
+// scimOrganizationOnly validates the organization scoped SCIM bearer token
+func (s *Service) scimOrganizationOnly(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if !strings.HasPrefix(authHeader, "Bearer ") {
+			s.scimFailure(w, r, http.StatusUnauthorized, "", "bearer token required")
+			return
+		}
+
+		var OrgID string
+		// the token may already have been looked up by the rate limiter
+		if Auth, _ := r.Context().Value(contextKeyRateLimitAuth).(*rateLimitAuth); Auth != nil && Auth.SCIMOrgID != "" {
+			OrgID = Auth.SCIMOrgID
+		} else {
+			var err error
+			OrgID, err = s.SCIMDataSvc.SCIMTokenOrganization(r.Context(), strings.TrimPrefix(authHeader, "Bearer "))
+			if err != nil {
+				s.scimFailure(w, r, http.StatusUnauthorized, "", "invalid bearer token")
+				return
+			}
+		}
+
+		ctx := context.WithValue(r.Context(), contextKeySCIMOrgID, OrgID)
+
+		h(w, r.WithContext(ctx))
+	}
+}
+
+// scimUpdateUser applies the name, email, and active changes to the user, the name and email are only
+// changed when the organization manages the users account and active only changes organization membership
+func (s *Service) scimUpdateUser(ctx context.Context, OrgID string, User *thunderdome.User, changes scimUserChanges) error {
+	if (changes.Name != "" && changes.Name != User.Name) || (changes.Email != "" && !strings.EqualFold(changes.Email, User.Email)) {
+		managed, err := s.SCIMDataSvc.SCIMUserManaged(ctx, OrgID, User.Id)
+		if err != nil {
+			return err
+		}
+		if managed {
+			FullUser, err := s.UserDataSvc.GetUser(ctx, User.Id)
+			if err != nil {
+				return err
+			}
+			Name := FullUser.Name
+			if changes.Name != "" {
+				Name = changes.Name
+			}
+			Email := FullUser.Email
+			if changes.Email != "" {
+				Email = changes.Email
+			}
+
+			if err := s.UserDataSvc.UpdateUserAccount(
+				ctx, User.Id, Name, Email, FullUser.Avatar, FullUser.NotificationsEnabled,
+				FullUser.Country, FullUser.Locale, FullUser.Company, FullUser.JobTitle,
+			); err != nil {
+				return err
+			}
+		}
+	}
+
+	if changes.Active != nil {
+		if *changes.Active {
+			return s.SCIMDataSvc.SCIMUserActivate(ctx, OrgID, User.Id)
+		}
+		return s.SCIMDataSvc.SCIMUserDeactivate(ctx, OrgID, User.Id)
+	}
+
+	return nil
+}
+
+// handleSCIMServiceProviderConfig gets the SCIM features supported
+func (s *Service) handleSCIMServiceProviderConfig() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		type supported struct {
+			Supported bool `json:"supported"`
+		}
+
+		s.scimRespond(w, r, http.StatusOK, map[string]interface{}{
+			"schemas": []string{scimSchemaServiceProviderConfig},
+			"patch":   supported{Supported: true},
+			"bulk": map[string]interface{}{
+				"supported":      false,
+				"maxOperations":  0,
+				"maxPayloadSize": 0,
+			},
+			"filter": map[string]interface{}{
+				"supported":  true,
+				"maxResults": scimMaxCount,
+			},
+			"changePassword": supported{Supported: false},
+			"sort":           supported{Supported: false},
+			"etag":           supported{Supported: false},
+			"authenticationSchemes": []map[string]interface{}{
+				{
+					"type":        "oauthbearertoken",
+					"name":        "OAuth Bearer Token",
+					"description": "Organization scoped SCIM token",
+					"primary":     true,
+				},
+			},
+		})
+	}
+}
+
+// handleSCIMUsers gets a list of the organizations users, supports userName and emails.value eq filters
+func (s *Service) handleSCIMUsers() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		OrgID := ctx.Value(contextKeySCIMOrgID).(string)
+		startIndex, count := scimPagination(r)
+
+		var Email string
+		if filter := r.URL.Query().Get("filter"); filter != "" {
+			attribute, value, err := parseSCIMFilter(filter)
+			if err != nil {
+				s.scimFailure(w, r, http.StatusBadRequest, "invalidFilter", err.Error())
+				return
+			}
+			if attribute != "username" && attribute != "emails.value" && attribute != "emails" {
+				s.scimFailure(w, r, http.StatusBadRequest, "invalidFilter", "unsupported filter attribute "+attribute)
+				return
+			}
+			if value == "" {
+				s.scimRespond(w, r, http.StatusOK, scimListResponse{
+					Schemas: []string{scimSchemaListResponse}, StartIndex: startIndex, Resources: []interface{}{},
+				})
+				return
+			}
+			Email = value
+		}
+
+		Users, Count, err := s.SCIMDataSvc.SCIMUserList(ctx, OrgID, Email, count, startIndex-1)
+		if err != nil {
+			s.scimFailure(w, r, http.StatusInternalServerError, "", err.Error())
+			return
+		}
+
+		Resources := make([]interface{}, 0, len(Users))
+		for _, u := range Users {
+			Resources = append(Resources, s.scimUserFromUser(u))
+		}
+
+		s.scimRespond(w, r, http.StatusOK, scimListResponse{
+			Schemas:      []string{scimSchemaListResponse},
+			TotalResults: Count,
+			StartIndex:   startIndex,
+			ItemsPerPage: len(Resources),
+			Resources:    Resources,
+		})
+	}
+}
+
+// handleSCIMUserGet gets a user of the organization
+func (s *Service) handleSCIMUserGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		OrgID := ctx.Value(contextKeySCIMOrgID).(string)
+		UserID := mux.Vars(r)["userId"]
+		if idErr := validate.Var(UserID, "required,uuid"); idErr != nil {
+			s.scimFailure(w, r, http.StatusNotFound, "", "user not found")
+			return
+		}
+
+		User, err := s.SCIMDataSvc.SCIMUserGet(ctx, OrgID, UserID)
+		if err != nil {
+			s.scimFailure(w, r, http.StatusNotFound, "", "user not found")
+			return
+		}
+
+		s.scimRespond(w, r, http.StatusOK, s.scimUserFromUser(User))
+	}
+}
+
+// scimUnusablePassword a random password for SCIM provisioned users, who don't choose one
+func scimUnusablePassword() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// handleSCIMUserCreate creates a verified account for the email and adds it to the organization,
+// existing accounts aren't linked as the organization doesn't own them
+func (s *Service) handleSCIMUserCreate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		OrgID := ctx.Value(contextKeySCIMOrgID).(string)
+
+		var u scimUser
+		body, bodyErr := io.ReadAll(r.Body)
+		if bodyErr != nil {
+			s.scimFailure(w, r, http.StatusBadRequest, "invalidSyntax", bodyErr.Error())
+			return
+		}
+		if err := json.Unmarshal(body, &u); err != nil {
+			s.scimFailure(w, r, http.StatusBadRequest, "invalidSyntax", err.Error())
+			return
+		}
+
+		UserEmail := u.email()
+		if inputErr := validate.Var(UserEmail, "required,email"); inputErr != nil {
+			s.scimFailure(w, r, http.StatusBadRequest, "invalidValue", "userName or emails must be a valid email address")
+			return
+		}
+
+		User, _ := s.UserDataSvc.GetUserByEmail(ctx, UserEmail)
+		if User == nil {
+			// nobody knows the random password, the user signs in through the identity provider
+			// or sets a password with a password reset
+			Password, err := scimUnusablePassword()
+			if err != nil {
+				s.scimFailure(w, r, http.StatusInternalServerError, "", err.Error())
+				return
+			}
+			NewUser, VerifyID, err := s.UserDataSvc.CreateUserRegistered(ctx, u.displayName(), UserEmail, Password, "")
+			if err != nil {
+				s.scimFailure(w, r, http.StatusInternalServerError, "", err.Error())
+				return
+			}
+			// the identity provider asserts the users email
+			if err := s.AuthDataSvc.VerifyUserAccount(ctx, VerifyID); err != nil {
+				s.Logger.Ctx(ctx).Error("error verifying scim provisioned user", zap.Error(err))
+			}
+			if err := s.SCIMDataSvc.SCIMUserProvision(ctx, OrgID, NewUser.Id); err != nil {
+				s.scimFailure(w, r, http.StatusInternalServerError, "", err.Error())
+				return
+			}
+			User = NewUser
+		} else if _, err := s.SCIMDataSvc.SCIMUserGet(ctx, OrgID, User.Id); err == nil {
+			s.scimFailure(w, r, http.StatusConflict, "uniqueness", "user already exists in the organization")
+			return
+		} else {
+			// the account isn't the organizations, the user has to accept an invite to join the organization
+			s.scimFailure(w, r, http.StatusConflict, "uniqueness", "an account already exists for the email, invite the user to the organization")
+			return
+		}
+
+		active := true
+		if u.Active != nil {
+			active = *u.Active
+		}
+		if err := s.scimUpdateUser(ctx, OrgID, User, scimUserChanges{Active: &active}); err != nil {
+			s.scimFailure(w, r, http.StatusInternalServerError, "", err.Error())
+			return
+		}
+
+		User, err := s.SCIMDataSvc.SCIMUserGet(ctx, OrgID, User.Id)
+		if err != nil {
+			s.scimFailure(w, r, http.StatusInternalServerError, "", err.Error())
+			return
+		}
+
+		s.scimRespond(w, r, http.StatusCreated, s.scimUserFromUser(User))
+	}
+}
+
+// handleSCIMUserReplace replaces the users name, email, and active status
+func (s *Service) handleSCIMUserReplace() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		OrgID := ctx.Value(contextKeySCIMOrgID).(string)
+		UserID := mux.Vars(r)["userId"]
+		if idErr := validate.Var(UserID, "required,uuid"); idErr != nil {
+			s.scimFailure(w, r, http.StatusNotFound, "", "user not found")
+			return
+		}
+
+		User, err := s.SCIMDataSvc.SCIMUserGet(ctx, OrgID, UserID)
+		if err != nil {
+			s.scimFailure(w, r, http.StatusNotFound, "", "user not found")
+			return
+		}
+
+		var u scimUser
+		body, bodyErr := io.ReadAll(r.Body)
+		if bodyErr != nil {
+			s.scimFailure(w, r, http.StatusBadRequest, "invalidSyntax", bodyErr.Error())
+			return
+		}
+		if err := json.Unmarshal(body, &u); err != nil {
+			s.scimFailure(w, r, http.StatusBadRequest, "invalidSyntax", err.Error())
+			return
+		}
+
+		UserEmail := u.email()
+		if inputErr := validate.Var(UserEmail, "required,email"); inputErr != nil {
+			s.scimFailure(w, r, http.StatusBadRequest, "invalidValue", "userName or emails must be a valid email address")
+			return
+		}
+
+		active := true
+		if u.Active != nil {
+			active = *u.Active
+		}
+
+		if err := s.scimUpdateUser(ctx, OrgID, User, scimUserChanges{Name: u.displayName(), Email: UserEmail, Active: &active}); err != nil {
+			s.scimFailure(w, r, http.StatusInternalServerError, "", err.Error())
+			return
+		}
+
+		User, err = s.SCIMDataSvc.SCIMUserGet(ctx, OrgID, UserID)
+		if err != nil {
+			s.scimFailure(w, r, http.StatusInternalServerError, "", err.Error())
+			return
+		}
+
+		s.scimRespond(w, r, http.StatusOK, s.scimUserFromUser(User))
+	}
+}
+
+// handleSCIMUserPatch applies PATCH operations to the user, deactivating the user removes them from the organization
+func (s *Service) handleSCIMUserPatch() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		OrgID := ctx.Value(contextKeySCIMOrgID).(string)
+		UserID := mux.Vars(r)["userId"]
+		if idErr := validate.Var(UserID, "required,uuid"); idErr != nil {
+			s.scimFailure(w, r, http.StatusNotFound, "", "user not found")
+			return
+		}
+
+		User, err := s.SCIMDataSvc.SCIMUserGet(ctx, OrgID, UserID)
+		if err != nil {
+			s.scimFailure(w, r, http.StatusNotFound, "", "user not found")
+			return
+		}
+
+		var p scimPatchRequest
+		body, bodyErr := io.ReadAll(r.Body)
+		if bodyErr != nil {
+			s.scimFailure(w, r, http.StatusBadRequest, "invalidSyntax", bodyErr.Error())
+			return
+		}
+		if err := json.Unmarshal(body, &p); err != nil {
+			s.scimFailure(w, r, http.StatusBadRequest, "invalidSyntax", err.Error())
+			return
+		}
+
+		var changes scimUserChanges
+		for _, op := range p.Operations {
+			switch strings.ToLower(op.Op) {
+			case "add", "replace":
+				if op.Path != "" {
+					err = applyUserAttribute(&changes, op.Path, op.Value)
+					break
+				}
+				var attributes map[string]json.RawMessage
+				if err = json.Unmarshal(op.Value, &attributes); err != nil {
+					break
+				}
+				for attribute, value := range attributes {
+					if err = applyUserAttribute(&changes, attribute, value); err != nil {
+						break
+					}
+				}
+			case "remove":
+				// name, email, and active are required so there is nothing to remove
+			default:
+				err = errors.New("unsupported patch operation " + op.Op)
+			}
+			if err != nil {
+				s.scimFailure(w, r, http.StatusBadRequest, "invalidValue", err.Error())
+				return
+			}
+		}
+
+		if changes.Email != "" {
+			if inputErr := validate.Var(changes.Email, "email"); inputErr != nil {
+				s.scimFailure(w, r, http.StatusBadRequest, "invalidValue", "userName or emails must be a valid email address")
+				return
+			}
+		}
+
+		if err := s.scimUpdateUser(ctx, OrgID, User, changes); err != nil {
+			s.scimFailure(w, r, http.StatusInternalServerError, "", err.Error())
+			return
+		}
+
+		User, err = s.SCIMDataSvc.SCIMUserGet(ctx, OrgID, UserID)
+		if err != nil {
+			s.scimFailure(w, r, http.StatusInternalServerError, "", err.Error())
+			return
+		}
+
+		s.scimRespond(w, r, http.StatusOK, s.scimUserFromUser(User))
+	}
+}
+
+// handleSCIMUserDelete removes the user from the organization including its departments and teams
+func (s *Service) handleSCIMUserDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		OrgID := ctx.Value(contextKeySCIMOrgID).(string)
+		UserID := mux.Vars(r)["userId"]
+		if idErr := validate.Var(UserID, "required,uuid"); idErr != nil {
+			s.scimFailure(w, r, http.StatusNotFound, "", "user not found")
+			return
+		}
+
+		if _, err := s.SCIMDataSvc.SCIMUserGet(ctx, OrgID, UserID); err != nil {
+			s.scimFailure(w, r, http.StatusNotFound, "", "user not found")
+			return
+		}
+
+		if err := s.SCIMDataSvc.SCIMUserDelete(ctx, OrgID, UserID); err != nil {
+			s.scimFailure(w, r, http.StatusInternalServerError, "", err.Error())
+			return
+		}
+
+		s.scimRespond(w, r, http.StatusNoContent, nil)
+	}
+}
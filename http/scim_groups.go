@@ -0,0 +1,459 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+	"github.com/gorilla/mux"
+)
+
+// scimMemberFilterRegex matches a members value filter path, e.g. members[value eq "2819c223-7f76-453a-919d-413861904646"]
+var scimMemberFilterRegex = regexp.MustCompile(`(?i)^\s*members\[\s*value\s+eq\s+"([^"]+)"\s*\]\s*$`)
+
+type scimGroupMember struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+type scimGroup struct {
+	Schemas     []string          `json:"schemas"`
+	Id          string            `json:"id,omitempty"`
+	ExternalId  string            `json:"externalId,omitempty"`
+	DisplayName string            `json:"displayName"`
+	Members     []scimGroupMember `json:"members,omitempty"`
+	Meta        *scimMeta         `json:"meta,omitempty"`
+}
+
+// scimGroupFromTeam converts a team and its members to the SCIM group resource
+func (s *Service) scimGroupFromTeam(t *thunderdome.Team, Members []scimGroupMember) scimGroup {
+	return scimGroup{
+		Schemas:     []string{scimSchemaGroup},
+		Id:          t.Id,
+		DisplayName: t.Name,
+		Members:     Members,
+		Meta: &scimMeta{
+			ResourceType: "Group",
+			Created:      t.CreatedDate,
+			LastModified: t.UpdatedDate,
+			Location:     s.Config.AppURL + "scim/v2/Groups/" + t.Id,
+		},
+	}
+}
+
+// scimMemberIds gets the user IDs of the SCIM group members
+func scimMemberIds(Members []scimGroupMember) []string {
+	ids := make([]string, 0, len(Members))
+	for _, m := range Members {
+		ids = append(ids, m.Value)
+	}
+
+	return ids
+}
+
+// scimGroupMembers gets all the members of the team
+func (s *Service) scimGroupMembers(ctx context.Context, TeamID string) ([]scimGroupMember, error) {
+	Members := make([]scimGroupMember, 0)
+	Limit := 100
+
+	for Offset := 0; ; Offset += Limit {
+		Users, Count, err := s.TeamDataSvc.TeamUserList(ctx, TeamID, Limit, Offset)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range Users {
+			Members = append(Members, scimGroupMember{
+				Value:   u.Id,
+				Display: u.Name,
+				Ref:     s.Config.AppURL + "scim/v2/Users/" + u.Id,
+			})
+		}
+		if len(Users) == 0 || Offset+Limit >= Count {
+			break
+		}
+	}
+
+	return Members, nil
+}
+
+// scimSetGroupMembers adds and removes team users, only users of the organization can be added
+func (s *Service) scimSetGroupMembers(ctx context.Context, OrgID string, TeamID string, Add []string, Remove []string) error {
+	Current, err := s.scimGroupMembers(ctx, TeamID)
+	if err != nil {
+		return err
+	}
+	isMember := make(map[string]bool, len(Current))
+	for _, m := range Current {
+		isMember[m.Value] = true
+	}
+
+	for _, UserID := range Add {
+		if isMember[UserID] {
+			continue
+		}
+		if validate.Var(UserID, "required,uuid") != nil {
+			return errors.New("USER_NOT_FOUND")
+		}
+		// deactivated users are no longer organization members so can't be added to its teams
+		if User, err := s.SCIMDataSvc.SCIMUserGet(ctx, OrgID, UserID); err != nil || User.Disabled {
+			return errors.New("USER_NOT_FOUND")
+		}
+		if _, err := s.TeamDataSvc.TeamAddUser(ctx, TeamID, UserID, "MEMBER"); err != nil {
+			return err
+		}
+		isMember[UserID] = true
+	}
+
+	for _, UserID := range Remove {
+		if !isMember[UserID] {
+			continue
+		}
+		if err := s.TeamDataSvc.TeamRemoveUser(ctx, TeamID, UserID); err != nil {
+			return err
+		}
+		isMember[UserID] = false
+	}
+
+	return nil
+}
+
+// scimReplaceGroupMembers replaces the team users with the members
+func (s *Service) scimReplaceGroupMembers(ctx context.Context, OrgID string, TeamID string, Members []string) error {
+	Current, err := s.scimGroupMembers(ctx, TeamID)
+	if err != nil {
+		return err
+	}
+
+	keep := make(map[string]bool, len(Members))
+	for _, id := range Members {
+		keep[id] = true
+	}
+	Remove := make([]string, 0)
+	for _, m := range Current {
+		if !keep[m.Value] {
+			Remove = append(Remove, m.Value)
+		}
+	}
+
+	return s.scimSetGroupMembers(ctx, OrgID, TeamID, Members, Remove)
+}
+
+// scimGroupResponse writes the SCIM group resource for the team
+func (s *Service) scimGroupResponse(w http.ResponseWriter, r *http.Request, status int, Team *thunderdome.Team) {
+	Members, err := s.scimGroupMembers(r.Context(), Team.Id)
+	if err != nil {
+		s.scimFailure(w, r, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+
+	s.scimRespond(w, r, status, s.scimGroupFromTeam(Team, Members))
+}
+
+// scimMembersFailure writes the SCIM error for a failed team members update
+func (s *Service) scimMembersFailure(w http.ResponseWriter, r *http.Request, err error) {
+	if err.Error() == "USER_NOT_FOUND" {
+		s.scimFailure(w, r, http.StatusBadRequest, "invalidValue", "members must be users of the organization")
+		return
+	}
+
+	s.scimFailure(w, r, http.StatusInternalServerError, "", err.Error())
+}
+
+// handleSCIMGroups gets a list of the organizations teams, supports displayName eq filters
+func (s *Service) handleSCIMGroups() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		OrgID := ctx.Value(contextKeySCIMOrgID).(string)
+		startIndex, count := scimPagination(r)
+		excludeMembers := strings.Contains(strings.ToLower(r.URL.Query().Get("excludedAttributes")), "members")
+
+		var Name string
+		if filter := r.URL.Query().Get("filter"); filter != "" {
+			attribute, value, err := parseSCIMFilter(filter)
+			if err != nil {
+				s.scimFailure(w, r, http.StatusBadRequest, "invalidFilter", err.Error())
+				return
+			}
+			if attribute != "displayname" {
+				s.scimFailure(w, r, http.StatusBadRequest, "invalidFilter", "unsupported filter attribute "+attribute)
+				return
+			}
+			if value == "" {
+				s.scimRespond(w, r, http.StatusOK, scimListResponse{
+					Schemas: []string{scimSchemaListResponse}, StartIndex: startIndex, Resources: []interface{}{},
+				})
+				return
+			}
+			Name = value
+		}
+
+		Teams, Count, err := s.SCIMDataSvc.SCIMGroupList(ctx, OrgID, Name, count, startIndex-1)
+		if err != nil {
+			s.scimFailure(w, r, http.StatusInternalServerError, "", err.Error())
+			return
+		}
+
+		Resources := make([]interface{}, 0, len(Teams))
+		for _, t := range Teams {
+			var Members []scimGroupMember
+			if !excludeMembers {
+				Members, err = s.scimGroupMembers(ctx, t.Id)
+				if err != nil {
+					s.scimFailure(w, r, http.StatusInternalServerError, "", err.Error())
+					return
+				}
+			}
+			Resources = append(Resources, s.scimGroupFromTeam(t, Members))
+		}
+
+		s.scimRespond(w, r, http.StatusOK, scimListResponse{
+			Schemas:      []string{scimSchemaListResponse},
+			TotalResults: Count,
+			StartIndex:   startIndex,
+			ItemsPerPage: len(Resources),
+			Resources:    Resources,
+		})
+	}
+}
+
+// handleSCIMGroupGet gets a team of the organization
+func (s *Service) handleSCIMGroupGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		OrgID := ctx.Value(contextKeySCIMOrgID).(string)
+		TeamID := mux.Vars(r)["groupId"]
+		if idErr := validate.Var(TeamID, "required,uuid"); idErr != nil {
+			s.scimFailure(w, r, http.StatusNotFound, "", "group not found")
+			return
+		}
+
+		Team, err := s.SCIMDataSvc.SCIMGroupGet(ctx, OrgID, TeamID)
+		if err != nil {
+			s.scimFailure(w, r, http.StatusNotFound, "", "group not found")
+			return
+		}
+
+		if strings.Contains(strings.ToLower(r.URL.Query().Get("excludedAttributes")), "members") {
+			s.scimRespond(w, r, http.StatusOK, s.scimGroupFromTeam(Team, nil))
+			return
+		}
+
+		s.scimGroupResponse(w, r, http.StatusOK, Team)
+	}
+}
+
+// handleSCIMGroupCreate creates an organization team with the members
+func (s *Service) handleSCIMGroupCreate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		OrgID := ctx.Value(contextKeySCIMOrgID).(string)
+
+		var g scimGroup
+		body, bodyErr := io.ReadAll(r.Body)
+		if bodyErr != nil {
+			s.scimFailure(w, r, http.StatusBadRequest, "invalidSyntax", bodyErr.Error())
+			return
+		}
+		if err := json.Unmarshal(body, &g); err != nil {
+			s.scimFailure(w, r, http.StatusBadRequest, "invalidSyntax", err.Error())
+			return
+		}
+		if g.DisplayName == "" {
+			s.scimFailure(w, r, http.StatusBadRequest, "invalidValue", "displayName is required")
+			return
+		}
+
+		if _, Count, err := s.SCIMDataSvc.SCIMGroupList(ctx, OrgID, g.DisplayName, 1, 0); err == nil && Count > 0 {
+			s.scimFailure(w, r, http.StatusConflict, "uniqueness", "group already exists in the organization")
+			return
+		}
+
+		Team, err := s.OrganizationDataSvc.OrganizationTeamCreate(ctx, OrgID, g.DisplayName)
+		if err != nil {
+			s.scimFailure(w, r, http.StatusInternalServerError, "", err.Error())
+			return
+		}
+
+		if err := s.scimSetGroupMembers(ctx, OrgID, Team.Id, scimMemberIds(g.Members), nil); err != nil {
+			s.scimMembersFailure(w, r, err)
+			return
+		}
+
+		s.scimGroupResponse(w, r, http.StatusCreated, Team)
+	}
+}
+
+// handleSCIMGroupReplace replaces the teams name and members
+func (s *Service) handleSCIMGroupReplace() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		OrgID := ctx.Value(contextKeySCIMOrgID).(string)
+		TeamID := mux.Vars(r)["groupId"]
+		if idErr := validate.Var(TeamID, "required,uuid"); idErr != nil {
+			s.scimFailure(w, r, http.StatusNotFound, "", "group not found")
+			return
+		}
+
+		Team, err := s.SCIMDataSvc.SCIMGroupGet(ctx, OrgID, TeamID)
+		if err != nil {
+			s.scimFailure(w, r, http.StatusNotFound, "", "group not found")
+			return
+		}
+
+		var g scimGroup
+		body, bodyErr := io.ReadAll(r.Body)
+		if bodyErr != nil {
+			s.scimFailure(w, r, http.StatusBadRequest, "invalidSyntax", bodyErr.Error())
+			return
+		}
+		if err := json.Unmarshal(body, &g); err != nil {
+			s.scimFailure(w, r, http.StatusBadRequest, "invalidSyntax", err.Error())
+			return
+		}
+
+		if g.DisplayName != "" && g.DisplayName != Team.Name {
+			Team, err = s.SCIMDataSvc.SCIMGroupRename(ctx, OrgID, TeamID, g.DisplayName)
+			if err != nil {
+				s.scimFailure(w, r, http.StatusInternalServerError, "", err.Error())
+				return
+			}
+		}
+
+		if err := s.scimReplaceGroupMembers(ctx, OrgID, TeamID, scimMemberIds(g.Members)); err != nil {
+			s.scimMembersFailure(w, r, err)
+			return
+		}
+
+		s.scimGroupResponse(w, r, http.StatusOK, Team)
+	}
+}
+
+// handleSCIMGroupPatch applies PATCH operations to the teams name and members
+func (s *Service) handleSCIMGroupPatch() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		OrgID := ctx.Value(contextKeySCIMOrgID).(string)
+		TeamID := mux.Vars(r)["groupId"]
+		if idErr := validate.Var(TeamID, "required,uuid"); idErr != nil {
+			s.scimFailure(w, r, http.StatusNotFound, "", "group not found")
+			return
+		}
+
+		Team, err := s.SCIMDataSvc.SCIMGroupGet(ctx, OrgID, TeamID)
+		if err != nil {
+			s.scimFailure(w, r, http.StatusNotFound, "", "group not found")
+			return
+		}
+
+		var p scimPatchRequest
+		body, bodyErr := io.ReadAll(r.Body)
+		if bodyErr != nil {
+			s.scimFailure(w, r, http.StatusBadRequest, "invalidSyntax", bodyErr.Error())
+			return
+		}
+		if err := json.Unmarshal(body, &p); err != nil {
+			s.scimFailure(w, r, http.StatusBadRequest, "invalidSyntax", err.Error())
+			return
+		}
+
+		for _, op := range p.Operations {
+			Op := strings.ToLower(op.Op)
+			Path := strings.ToLower(op.Path)
+
+			var patch scimGroup
+			switch {
+			case Path == "members":
+				if len(op.Value) > 0 {
+					if err := json.Unmarshal(op.Value, &patch.Members); err != nil {
+						s.scimFailure(w, r, http.StatusBadRequest, "invalidValue", err.Error())
+						return
+					}
+				}
+			case Path == "displayname":
+				if err := json.Unmarshal(op.Value, &patch.DisplayName); err != nil {
+					s.scimFailure(w, r, http.StatusBadRequest, "invalidValue", err.Error())
+					return
+				}
+			case Path == "" && Op != "remove":
+				if err := json.Unmarshal(op.Value, &patch); err != nil {
+					s.scimFailure(w, r, http.StatusBadRequest, "invalidValue", err.Error())
+					return
+				}
+			case Op == "remove" && scimMemberFilterRegex.MatchString(op.Path):
+				UserID := scimMemberFilterRegex.FindStringSubmatch(op.Path)[1]
+				if err := s.scimSetGroupMembers(ctx, OrgID, TeamID, nil, []string{UserID}); err != nil {
+					s.scimMembersFailure(w, r, err)
+					return
+				}
+				continue
+			default:
+				s.scimFailure(w, r, http.StatusBadRequest, "invalidPath", "unsupported path "+op.Path)
+				return
+			}
+
+			if patch.DisplayName != "" && patch.DisplayName != Team.Name {
+				Team, err = s.SCIMDataSvc.SCIMGroupRename(ctx, OrgID, TeamID, patch.DisplayName)
+				if err != nil {
+					s.scimFailure(w, r, http.StatusInternalServerError, "", err.Error())
+					return
+				}
+			}
+
+			MemberIds := scimMemberIds(patch.Members)
+			switch Op {
+			case "add":
+				err = s.scimSetGroupMembers(ctx, OrgID, TeamID, MemberIds, nil)
+			case "replace":
+				if Path == "members" || patch.Members != nil {
+					err = s.scimReplaceGroupMembers(ctx, OrgID, TeamID, MemberIds)
+				}
+			case "remove":
+				if len(op.Value) == 0 {
+					err = s.scimReplaceGroupMembers(ctx, OrgID, TeamID, nil)
+				} else {
+					err = s.scimSetGroupMembers(ctx, OrgID, TeamID, nil, MemberIds)
+				}
+			default:
+				s.scimFailure(w, r, http.StatusBadRequest, "invalidSyntax", "unsupported patch operation "+op.Op)
+				return
+			}
+			if err != nil {
+				s.scimMembersFailure(w, r, err)
+				return
+			}
+		}
+
+		s.scimGroupResponse(w, r, http.StatusOK, Team)
+	}
+}
+
+// handleSCIMGroupDelete deletes the organization team
+func (s *Service) handleSCIMGroupDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		OrgID := ctx.Value(contextKeySCIMOrgID).(string)
+		TeamID := mux.Vars(r)["groupId"]
+		if idErr := validate.Var(TeamID, "required,uuid"); idErr != nil {
+			s.scimFailure(w, r, http.StatusNotFound, "", "group not found")
+			return
+		}
+
+		if _, err := s.SCIMDataSvc.SCIMGroupGet(ctx, OrgID, TeamID); err != nil {
+			s.scimFailure(w, r, http.StatusNotFound, "", "group not found")
+			return
+		}
+
+		if err := s.TeamDataSvc.TeamDelete(ctx, TeamID); err != nil {
+			s.scimFailure(w, r, http.StatusInternalServerError, "", err.Error())
+			return
+		}
+
+		s.scimRespond(w, r, http.StatusNoContent, nil)
+	}
+}
@@ -0,0 +1,96 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/ratelimit"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"github.com/gorilla/mux"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+	"go.uber.org/zap"
+)
+
+type testSCIMDataSvc struct {
+	thunderdome.SCIMDataSvc
+}
+
+func (testSCIMDataSvc) SCIMTokenOrganization(ctx context.Context, Token string) (string, error) {
+	if Token == "bifrost" || Token == "gjallarhorn" {
+		return "asgard", nil
+	}
+	return "", errors.New("INVALID_SCIM_TOKEN")
+}
+
+func (testSCIMDataSvc) SCIMUserList(ctx context.Context, OrgId string, Email string, Limit int, Offset int) ([]*thunderdome.User, int, error) {
+	return []*thunderdome.User{}, 0, nil
+}
+
+// TestSCIMUnusablePassword makes sure SCIM provisioned users get a distinct random password
+// that fits within bcrypts 72 byte limit instead of an empty one
+func TestSCIMUnusablePassword(t *testing.T) {
+	first, err := scimUnusablePassword()
+	if err != nil {
+		t.Fatalf("generate password: %v", err)
+	}
+	second, err := scimUnusablePassword()
+	if err != nil {
+		t.Fatalf("generate password: %v", err)
+	}
+
+	if first == "" || len(first) > 72 {
+		t.Fatalf("expected a non empty password of at most 72 bytes, got %d bytes", len(first))
+	}
+	if first == second {
+		t.Fatal("expected each password to be random")
+	}
+}
+
+// TestSCIMRateLimit makes sure SCIM requests are rate limited per bearer token,
+// and requests with invalid tokens per IP address
+func TestSCIMRateLimit(t *testing.T) {
+	logger := otelzap.New(zap.NewNop())
+	a := Init(Service{
+		Config:       &Config{OrganizationsEnabled: true},
+		Router:       mux.NewRouter(),
+		Logger:       logger,
+		AlertDataSvc: testAlertDataSvc{},
+		SCIMDataSvc:  testSCIMDataSvc{},
+		RateLimiter: &ratelimit.Limiter{
+			Default: ratelimit.Limit{Requests: 1, Period: time.Hour},
+			DataSvc: ratelimit.NewMemoryStore(),
+			Logger:  logger,
+		},
+	}, fstest.MapFS{"static/index.html": &fstest.MapFile{Data: []byte("")}}, http.FS(fstest.MapFS{}))
+
+	request := func(Token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/scim/v2/Users", nil)
+		req.Header.Set("Authorization", "Bearer "+Token)
+		w := httptest.NewRecorder()
+		a.Router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	tests := []struct {
+		token  string
+		status int
+	}{
+		{"bifrost", http.StatusOK},
+		{"bifrost", http.StatusTooManyRequests},
+		{"gjallarhorn", http.StatusOK},
+		{"loki", http.StatusUnauthorized},
+		{"laufey", http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		if status := request(tt.token); status != tt.status {
+			t.Errorf("token %s status = %d, expected %d", tt.token, status, tt.status)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"github.com/gorilla/mux"
+)
+
+type scimTokenRequestBody struct {
+	Name string `json:"name" validate:"required,max=64"`
+}
+
+// handleSCIMTokens gets a list of the organizations SCIM tokens
+// @Summary Get Organization SCIM Tokens
+// @Description Gets a list of the organizations SCIM provisioning tokens, the tokens themselves are never returned
+// @Tags organization
+// @Produce  json
+// @Param orgId path string true "the organization ID"
+// @Success 200 object standardJsonResponse{data=[]thunderdome.SCIMToken}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /organizations/{orgId}/scim-tokens [get]
+func (s *Service) handleSCIMTokens() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.Config.OrganizationsEnabled {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "ORGANIZATIONS_DISABLED"))
+			return
+		}
+
+		vars := mux.Vars(r)
+		OrgID := vars["orgId"]
+
+		Tokens, err := s.SCIMDataSvc.SCIMTokenList(r.Context(), OrgID)
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, Tokens, nil)
+	}
+}
+
+// handleSCIMTokenCreate creates an organization SCIM token
+// @Summary Create Organization SCIM Token
+// @Description Creates a SCIM provisioning token for the organization, the token is only returned once
+// @Tags organization
+// @Produce  json
+// @Param orgId path string true "the organization ID"
+// @Param token body scimTokenRequestBody true "new scim token object"
+// @Success 200 object standardJsonResponse{data=thunderdome.SCIMToken}
+// @Failure 400 object standardJsonResponse{}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /organizations/{orgId}/scim-tokens [post]
+func (s *Service) handleSCIMTokenCreate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.Config.OrganizationsEnabled {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "ORGANIZATIONS_DISABLED"))
+			return
+		}
+
+		ctx := r.Context()
+		UserID := ctx.Value(contextKeyUserID).(string)
+		vars := mux.Vars(r)
+		OrgID := vars["orgId"]
+
+		var t = scimTokenRequestBody{}
+		body, bodyErr := io.ReadAll(r.Body)
+		if bodyErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, bodyErr.Error()))
+			return
+		}
+
+		jsonErr := json.Unmarshal(body, &t)
+		if jsonErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, jsonErr.Error()))
+			return
+		}
+
+		inputErr := validate.Struct(t)
+		if inputErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, inputErr.Error()))
+			return
+		}
+
+		Token, err := s.SCIMDataSvc.SCIMTokenCreate(ctx, OrgID, t.Name, UserID)
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.audit(r, thunderdome.AuditActionSCIMTokenCreate, "scim_token", Token.Id, nil, map[string]string{"name": t.Name})
+
+		s.Success(w, r, http.StatusOK, Token, nil)
+	}
+}
+
+// handleSCIMTokenDelete deletes an organization SCIM token
+// @Summary Delete Organization SCIM Token
+// @Description Deletes an organizations SCIM provisioning token
+// @Tags organization
+// @Produce  json
+// @Param orgId path string true "the organization ID"
+// @Param tokenId path string true "the scim token ID"
+// @Success 200 object standardJsonResponse{}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /organizations/{orgId}/scim-tokens/{tokenId} [delete]
+func (s *Service) handleSCIMTokenDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.Config.OrganizationsEnabled {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "ORGANIZATIONS_DISABLED"))
+			return
+		}
+
+		vars := mux.Vars(r)
+		OrgID := vars["orgId"]
+		TokenID := vars["tokenId"]
+		idErr := validate.Var(TokenID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+
+		err := s.SCIMDataSvc.SCIMTokenDelete(r.Context(), OrgID, TokenID)
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.audit(r, thunderdome.AuditActionSCIMTokenDelete, "scim_token", TokenID, nil, nil)
+
+		s.Success(w, r, http.StatusOK, nil, nil)
+	}
+}
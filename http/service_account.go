@@ -0,0 +1,395 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"github.com/gorilla/mux"
+)
+
+type serviceAccountRequestBody struct {
+	Name string `json:"name" validate:"required,max=64"`
+}
+
+type serviceAccountTeamRequestBody struct {
+	Role string `json:"role" enums:"MEMBER,ADMIN" validate:"required,oneof=MEMBER ADMIN"`
+}
+
+// serviceAccountExists responds with not found when the user is not a service account of the organization
+func (s *Service) serviceAccountExists(w http.ResponseWriter, r *http.Request, OrgID string, UserID string) bool {
+	idErr := validate.Var(UserID, "required,uuid")
+	if idErr != nil {
+		s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+		return false
+	}
+
+	if _, err := s.ServiceAccountDataSvc.ServiceAccountGet(r.Context(), OrgID, UserID); err != nil {
+		s.Failure(w, r, http.StatusNotFound, Errorf(ENOTFOUND, "SERVICE_ACCOUNT_NOT_FOUND"))
+		return false
+	}
+
+	return true
+}
+
+// handleServiceAccounts gets a list of the organizations service accounts
+// @Summary Get Organization Service Accounts
+// @Description Gets a list of the organizations service accounts along with their teams
+// @Tags organization
+// @Produce  json
+// @Param orgId path string true "the organization ID"
+// @Success 200 object standardJsonResponse{data=[]thunderdome.ServiceAccount}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /organizations/{orgId}/service-accounts [get]
+func (s *Service) handleServiceAccounts() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.Config.OrganizationsEnabled {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "ORGANIZATIONS_DISABLED"))
+			return
+		}
+
+		vars := mux.Vars(r)
+		OrgID := vars["orgId"]
+
+		Accounts, err := s.ServiceAccountDataSvc.ServiceAccountList(r.Context(), OrgID)
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, Accounts, nil)
+	}
+}
+
+// handleServiceAccountCreate creates an organization service account
+// @Summary Create Organization Service Account
+// @Description Creates a non-login service account owned by the organization for use by integrations
+// @Tags organization
+// @Produce  json
+// @Param orgId path string true "the organization ID"
+// @Param account body serviceAccountRequestBody true "new service account object"
+// @Success 200 object standardJsonResponse{data=thunderdome.ServiceAccount}
+// @Failure 400 object standardJsonResponse{}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /organizations/{orgId}/service-accounts [post]
+func (s *Service) handleServiceAccountCreate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.Config.OrganizationsEnabled {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "ORGANIZATIONS_DISABLED"))
+			return
+		}
+
+		ctx := r.Context()
+		UserID := ctx.Value(contextKeyUserID).(string)
+		vars := mux.Vars(r)
+		OrgID := vars["orgId"]
+
+		var sa = serviceAccountRequestBody{}
+		body, bodyErr := io.ReadAll(r.Body)
+		if bodyErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, bodyErr.Error()))
+			return
+		}
+
+		jsonErr := json.Unmarshal(body, &sa)
+		if jsonErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, jsonErr.Error()))
+			return
+		}
+
+		inputErr := validate.Struct(sa)
+		if inputErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, inputErr.Error()))
+			return
+		}
+
+		Account, err := s.ServiceAccountDataSvc.ServiceAccountCreate(ctx, OrgID, sa.Name, UserID)
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.audit(r, thunderdome.AuditActionServiceAccountCreate, "service_account", Account.Id, nil, map[string]string{"name": sa.Name})
+
+		s.Success(w, r, http.StatusOK, Account, nil)
+	}
+}
+
+// handleServiceAccountDelete deletes an organization service account
+// @Summary Delete Organization Service Account
+// @Description Deletes an organizations service account along with its API keys
+// @Tags organization
+// @Produce  json
+// @Param orgId path string true "the organization ID"
+// @Param userId path string true "the service account user ID"
+// @Success 200 object standardJsonResponse{}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 404 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /organizations/{orgId}/service-accounts/{userId} [delete]
+func (s *Service) handleServiceAccountDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.Config.OrganizationsEnabled {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "ORGANIZATIONS_DISABLED"))
+			return
+		}
+
+		vars := mux.Vars(r)
+		OrgID := vars["orgId"]
+		UserID := vars["userId"]
+		idErr := validate.Var(UserID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+
+		err := s.ServiceAccountDataSvc.ServiceAccountDelete(r.Context(), OrgID, UserID)
+		if err != nil && err.Error() == "SERVICE_ACCOUNT_NOT_FOUND" {
+			s.Failure(w, r, http.StatusNotFound, Errorf(ENOTFOUND, err.Error()))
+			return
+		}
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.audit(r, thunderdome.AuditActionServiceAccountDelete, "service_account", UserID, nil, nil)
+
+		s.Success(w, r, http.StatusOK, nil, nil)
+	}
+}
+
+// handleServiceAccountTeamAssign assigns a team role to an organization service account
+// @Summary Assign Service Account Team
+// @Description Adds the service account to a team of the organization with the role, updating the role when already assigned
+// @Tags organization
+// @Produce  json
+// @Param orgId path string true "the organization ID"
+// @Param userId path string true "the service account user ID"
+// @Param teamId path string true "the team ID"
+// @Param role body serviceAccountTeamRequestBody true "team role object"
+// @Success 200 object standardJsonResponse{}
+// @Failure 400 object standardJsonResponse{}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 404 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /organizations/{orgId}/service-accounts/{userId}/teams/{teamId} [put]
+func (s *Service) handleServiceAccountTeamAssign() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.Config.OrganizationsEnabled {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "ORGANIZATIONS_DISABLED"))
+			return
+		}
+
+		vars := mux.Vars(r)
+		OrgID := vars["orgId"]
+		UserID := vars["userId"]
+		TeamID := vars["teamId"]
+		if !s.serviceAccountExists(w, r, OrgID, UserID) {
+			return
+		}
+		idErr := validate.Var(TeamID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+
+		var t = serviceAccountTeamRequestBody{}
+		body, bodyErr := io.ReadAll(r.Body)
+		if bodyErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, bodyErr.Error()))
+			return
+		}
+
+		jsonErr := json.Unmarshal(body, &t)
+		if jsonErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, jsonErr.Error()))
+			return
+		}
+
+		inputErr := validate.Struct(t)
+		if inputErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, inputErr.Error()))
+			return
+		}
+
+		err := s.ServiceAccountDataSvc.ServiceAccountTeamAssign(r.Context(), OrgID, UserID, TeamID, t.Role)
+		if err != nil && err.Error() == "TEAM_NOT_FOUND" {
+			s.Failure(w, r, http.StatusNotFound, Errorf(ENOTFOUND, err.Error()))
+			return
+		}
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.audit(r, thunderdome.AuditActionServiceAccountTeamAssign, "service_account", UserID, nil, map[string]string{"teamId": TeamID, "role": t.Role})
+
+		s.Success(w, r, http.StatusOK, nil, nil)
+	}
+}
+
+// handleServiceAccountTeamRemove removes an organization service account from a team
+// @Summary Remove Service Account Team
+// @Description Removes the service account from the team
+// @Tags organization
+// @Produce  json
+// @Param orgId path string true "the organization ID"
+// @Param userId path string true "the service account user ID"
+// @Param teamId path string true "the team ID"
+// @Success 200 object standardJsonResponse{}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 404 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /organizations/{orgId}/service-accounts/{userId}/teams/{teamId} [delete]
+func (s *Service) handleServiceAccountTeamRemove() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.Config.OrganizationsEnabled {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "ORGANIZATIONS_DISABLED"))
+			return
+		}
+
+		vars := mux.Vars(r)
+		OrgID := vars["orgId"]
+		UserID := vars["userId"]
+		TeamID := vars["teamId"]
+		if !s.serviceAccountExists(w, r, OrgID, UserID) {
+			return
+		}
+		idErr := validate.Var(TeamID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+
+		err := s.ServiceAccountDataSvc.ServiceAccountTeamRemove(r.Context(), OrgID, UserID, TeamID)
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.audit(r, thunderdome.AuditActionServiceAccountTeamRemove, "service_account", UserID, map[string]string{"teamId": TeamID}, nil)
+
+		s.Success(w, r, http.StatusOK, nil, nil)
+	}
+}
+
+// handleServiceAccountAPIKeys gets an organization service accounts API keys
+// @Summary Get Service Account API Keys
+// @Description Gets a list of the service accounts API keys
+// @Tags organization
+// @Produce  json
+// @Param orgId path string true "the organization ID"
+// @Param userId path string true "the service account user ID"
+// @Success 200 object standardJsonResponse{data=[]thunderdome.APIKey}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 404 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /organizations/{orgId}/service-accounts/{userId}/apikeys [get]
+func (s *Service) handleServiceAccountAPIKeys() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.Config.OrganizationsEnabled {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "ORGANIZATIONS_DISABLED"))
+			return
+		}
+
+		vars := mux.Vars(r)
+		OrgID := vars["orgId"]
+		UserID := vars["userId"]
+		if !s.serviceAccountExists(w, r, OrgID, UserID) {
+			return
+		}
+
+		APIKeys, keysErr := s.ApiKeyDataSvc.GetUserApiKeys(r.Context(), UserID)
+		if keysErr != nil {
+			s.Failure(w, r, http.StatusInternalServerError, keysErr)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, APIKeys, nil)
+	}
+}
+
+// handleServiceAccountAPIKeyGenerate generates an API key for an organization service account
+// @Summary Generate Service Account API Key
+// @Description Generates an API key for the service account with the scopes (read only when empty), optional team restriction and expiration
+// @Tags organization
+// @Produce  json
+// @Param orgId path string true "the organization ID"
+// @Param userId path string true "the service account user ID"
+// @Param key body apikeyGenerateRequestBody true "new APIKey key object"
+// @Success 200 object standardJsonResponse{data=thunderdome.APIKey}
+// @Failure 400 object standardJsonResponse{}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 404 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /organizations/{orgId}/service-accounts/{userId}/apikeys [post]
+func (s *Service) handleServiceAccountAPIKeyGenerate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.Config.OrganizationsEnabled {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "ORGANIZATIONS_DISABLED"))
+			return
+		}
+
+		vars := mux.Vars(r)
+		OrgID := vars["orgId"]
+		UserID := vars["userId"]
+		if !s.serviceAccountExists(w, r, OrgID, UserID) {
+			return
+		}
+
+		s.apiKeyGenerate(w, r, UserID)
+	}
+}
+
+// handleServiceAccountAPIKeyDelete deletes an organization service accounts API key
+// @Summary Delete Service Account API Key
+// @Description Deletes the service accounts API key
+// @Tags organization
+// @Produce  json
+// @Param orgId path string true "the organization ID"
+// @Param userId path string true "the service account user ID"
+// @Param keyID path string true "the API Key ID"
+// @Success 200 object standardJsonResponse{data=[]thunderdome.APIKey}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 404 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /organizations/{orgId}/service-accounts/{userId}/apikeys/{keyID} [delete]
+func (s *Service) handleServiceAccountAPIKeyDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.Config.OrganizationsEnabled {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "ORGANIZATIONS_DISABLED"))
+			return
+		}
+
+		vars := mux.Vars(r)
+		OrgID := vars["orgId"]
+		UserID := vars["userId"]
+		APK := vars["keyID"]
+		if !s.serviceAccountExists(w, r, OrgID, UserID) {
+			return
+		}
+
+		APIKeys, keysErr := s.ApiKeyDataSvc.DeleteUserApiKey(r.Context(), UserID, APK)
+		if keysErr != nil {
+			s.Failure(w, r, http.StatusInternalServerError, keysErr)
+			return
+		}
+
+		s.audit(r, thunderdome.AuditActionServiceAccountKeyDelete, "service_account", UserID, map[string]string{"keyId": APK}, nil)
+
+		s.Success(w, r, http.StatusOK, APIKeys, nil)
+	}
+}
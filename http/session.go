@@ -0,0 +1,130 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// sessionSocketCloser closes the websocket connections of revoked user sessions
+type sessionSocketCloser interface {
+	CloseSessions(SessionIDs []string)
+}
+
+// closeSessionSockets closes any open websocket connections of the revoked user sessions
+func (s *Service) closeSessionSockets(SessionIDs []string) {
+	for _, closer := range s.socketClosers {
+		closer.CloseSessions(SessionIDs)
+	}
+}
+
+// revokeUserSessions deletes all the users sessions except the optional one and closes their websocket connections
+func (s *Service) revokeUserSessions(ctx context.Context, UserID string, ExceptSessionID string) error {
+	SessionIDs, err := s.AuthDataSvc.UserSessionDeleteAll(ctx, UserID, ExceptSessionID)
+	if err != nil {
+		s.Logger.Ctx(ctx).Error("error revoking user sessions", zap.Error(err), zap.String("user_id", UserID))
+		return err
+	}
+
+	s.closeSessionSockets(SessionIDs)
+
+	return nil
+}
+
+// handleUserSessions gets a list of the users active sessions
+// @Summary Get User Sessions
+// @Description Gets a list of the users active sessions
+// @Tags user
+// @Produce  json
+// @Param userId path string true "the user ID"
+// @Success 200 object standardJsonResponse{data=[]thunderdome.UserSession}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /users/{userId}/sessions [get]
+func (s *Service) handleUserSessions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		vars := mux.Vars(r)
+		UserID := vars["userId"]
+		SessionID, _ := ctx.Value(contextKeySessionID).(string)
+
+		Sessions, err := s.AuthDataSvc.UserSessionList(ctx, UserID, SessionID)
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, Sessions, nil)
+	}
+}
+
+// handleUserSessionDelete revokes one of the users sessions
+// @Summary Delete User Session
+// @Description Revokes one of the users sessions, logging it out and closing its open connections
+// @Tags user
+// @Produce  json
+// @Param userId path string true "the user ID"
+// @Param sessionId path string true "the session ID"
+// @Success 200 object standardJsonResponse{}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 404 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /users/{userId}/sessions/{sessionId} [delete]
+func (s *Service) handleUserSessionDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		vars := mux.Vars(r)
+		UserID := vars["userId"]
+		ID := vars["sessionId"]
+		idErr := validate.Var(ID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+
+		SessionID, err := s.AuthDataSvc.UserSessionDelete(ctx, UserID, ID)
+		if err != nil && err.Error() == "SESSION_NOT_FOUND" {
+			s.Failure(w, r, http.StatusNotFound, Errorf(ENOTFOUND, err.Error()))
+			return
+		}
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.closeSessionSockets([]string{SessionID})
+
+		s.Success(w, r, http.StatusOK, nil, nil)
+	}
+}
+
+// handleUserSessionsDelete revokes all the users sessions other than the current one
+// @Summary Delete Other User Sessions
+// @Description Revokes all the users sessions other than the requests session, logging them out and closing their open connections
+// @Tags user
+// @Produce  json
+// @Param userId path string true "the user ID"
+// @Success 200 object standardJsonResponse{}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /users/{userId}/sessions [delete]
+func (s *Service) handleUserSessionsDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		vars := mux.Vars(r)
+		UserID := vars["userId"]
+		SessionID, _ := ctx.Value(contextKeySessionID).(string)
+
+		if err := s.revokeUserSessions(ctx, UserID, SessionID); err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, nil, nil)
+	}
+}
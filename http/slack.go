@@ -0,0 +1,361 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/chat"
+	"github.com/StevenWeathers/thunderdome-planning-poker/http/checkin"
+	"github.com/StevenWeathers/thunderdome-planning-poker/http/poker"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// slackVoteActionID is the action ID of the slack poker vote buttons
+const slackVoteActionID = "poker_vote"
+
+// slackInteractionTimeout how long handling an acknowledged slack interaction can take
+const slackInteractionTimeout = 30 * time.Second
+
+const slackCommandHelp = "*Thunderdome commands*\n" +
+	"`/thunderdome connect <team id>` connect this channel to a team, requires being a team admin\n" +
+	"`/thunderdome disconnect` disconnect this channel from its team\n" +
+	"`/thunderdome poker <name>` start a poker game for the channels team\n" +
+	"`/thunderdome checkin <yesterday> | <today> | <blockers>` check in with the channels team"
+
+// slackMessage is a slack slash command or interaction response
+type slackMessage struct {
+	ResponseType string                   `json:"response_type,omitempty"`
+	Text         string                   `json:"text"`
+	Blocks       []map[string]interface{} `json:"blocks,omitempty"`
+}
+
+// slackEphemeral creates a response only visible to the slack user
+func slackEphemeral(text string) slackMessage {
+	return slackMessage{ResponseType: "ephemeral", Text: text}
+}
+
+// slackRespond writes the slack message response
+func (s *Service) slackRespond(w http.ResponseWriter, msg slackMessage) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(msg); err != nil {
+		s.Logger.Error("slack response encode error", zap.Error(err))
+	}
+}
+
+// slackReadRequest reads the request body and verifies the slack signature
+func (s *Service) slackReadRequest(r *http.Request, slack *chat.Slack) (url.Values, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := slack.VerifyRequest(r.Header, body, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return url.ParseQuery(string(body))
+}
+
+// slackUser gets the thunderdome user matching the slack users email
+func (s *Service) slackUser(ctx context.Context, slack *chat.Slack, SlackUserID string) (*thunderdome.User, error) {
+	email, err := slack.UserEmail(ctx, SlackUserID)
+	if err != nil {
+		s.Logger.Ctx(ctx).Error("slack user email lookup error", zap.Error(err))
+		return nil, errors.New("SLACK_USER_NOT_FOUND")
+	}
+
+	user, err := s.UserDataSvc.GetUserByEmail(ctx, email)
+	if err != nil || user.Disabled {
+		return nil, errors.New("SLACK_USER_NOT_FOUND")
+	}
+
+	return user, nil
+}
+
+// slackVoteBlocks creates the message blocks with vote buttons for the poker games point values
+func (s *Service) slackVoteBlocks(Game *thunderdome.Poker) []map[string]interface{} {
+	buttons := make([]map[string]interface{}, 0, len(Game.PointValuesAllowed))
+	for _, point := range Game.PointValuesAllowed {
+		buttons = append(buttons, map[string]interface{}{
+			"type":      "button",
+			"action_id": fmt.Sprintf("%s_%s", slackVoteActionID, point),
+			"text":      map[string]interface{}{"type": "plain_text", "text": point},
+			"value":     Game.Id + "|" + point,
+		})
+	}
+
+	blocks := []map[string]interface{}{
+		{
+			"type": "section",
+			"text": map[string]interface{}{
+				"type": "mrkdwn",
+				"text": fmt.Sprintf("*<%sbattle/%s|%s>* is ready, vote on the active story below",
+					s.Config.AppURL, Game.Id, Game.Name),
+			},
+		},
+	}
+	// slack limits actions blocks to 25 elements
+	for len(buttons) > 0 {
+		n := len(buttons)
+		if n > 25 {
+			n = 25
+		}
+		blocks = append(blocks, map[string]interface{}{
+			"type":     "actions",
+			"elements": buttons[:n],
+		})
+		buttons = buttons[n:]
+	}
+
+	return blocks
+}
+
+// handleSlackCommand handles the /thunderdome slack slash command
+// @Summary Slack Slash Command
+// @Description Handles the signature verified /thunderdome slack slash command
+// @Tags integrations
+// @Accept  x-www-form-urlencoded
+// @Produce  json
+// @Success 200 object slackMessage
+// @Failure 401 object standardJsonResponse{}
+// @Router /integrations/slack/commands [post]
+func (s *Service) handleSlackCommand(slack *chat.Slack, tc *checkin.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		form, err := s.slackReadRequest(r, slack)
+		if err != nil {
+			s.Failure(w, r, http.StatusUnauthorized, Errorf(EUNAUTHORIZED, err.Error()))
+			return
+		}
+
+		SlackTeamID := form.Get("team_id")
+		ChannelID := form.Get("channel_id")
+		subCommand, args := splitSlackCommand(form.Get("text"))
+		if subCommand == "" || subCommand == "help" {
+			s.slackRespond(w, slackEphemeral(slackCommandHelp))
+			return
+		}
+
+		user, err := s.slackUser(ctx, slack, form.Get("user_id"))
+		if err != nil {
+			s.slackRespond(w, slackEphemeral("No Thunderdome account matches your Slack email address."))
+			return
+		}
+
+		if subCommand == "connect" {
+			TeamID := strings.TrimSpace(args)
+			if validate.Var(TeamID, "required,uuid") != nil {
+				s.slackRespond(w, slackEphemeral("Usage: `/thunderdome connect <team id>`"))
+				return
+			}
+			Role, roleErr := s.TeamDataSvc.TeamUserRole(ctx, user.Id, TeamID)
+			if roleErr != nil || Role != adminUserType {
+				s.slackRespond(w, slackEphemeral("Only team admins can connect a channel to the team."))
+				return
+			}
+			if err := s.ChatIntegrationDataSvc.SlackChannelConnect(ctx, SlackTeamID, ChannelID, TeamID, user.Id); err != nil {
+				s.slackRespond(w, slackEphemeral("Unable to connect the channel, please try again."))
+				return
+			}
+			s.slackRespond(w, slackMessage{ResponseType: "in_channel", Text: "This channel is now connected to Thunderdome."})
+			return
+		}
+
+		TeamID, err := s.ChatIntegrationDataSvc.SlackChannelTeamGet(ctx, SlackTeamID, ChannelID)
+		if err != nil {
+			s.slackRespond(w, slackEphemeral("This channel isn't connected to a team, use `/thunderdome connect <team id>` first."))
+			return
+		}
+		Role, roleErr := s.TeamDataSvc.TeamUserRole(ctx, user.Id, TeamID)
+		if roleErr != nil {
+			s.slackRespond(w, slackEphemeral("You must be on the channels team to do that."))
+			return
+		}
+
+		switch subCommand {
+		case "disconnect":
+			if Role != adminUserType {
+				s.slackRespond(w, slackEphemeral("Only team admins can disconnect the channel."))
+				return
+			}
+			if err := s.ChatIntegrationDataSvc.SlackChannelDisconnect(ctx, SlackTeamID, ChannelID); err != nil {
+				s.slackRespond(w, slackEphemeral("Unable to disconnect the channel, please try again."))
+				return
+			}
+			s.slackRespond(w, slackMessage{ResponseType: "in_channel", Text: "This channel is no longer connected to Thunderdome."})
+		case "poker":
+			Name := strings.TrimSpace(args)
+			if Name == "" || len(Name) > 256 {
+				s.slackRespond(w, slackEphemeral("Usage: `/thunderdome poker <name>`"))
+				return
+			}
+			Game, err := s.PokerDataSvc.TeamCreateGame(
+				ctx, TeamID, user.Id, Name, s.UIConfig.AppConfig.DefaultPointValues,
+				make([]*thunderdome.Story, 0), false, "ceil", "", "", false, "",
+			)
+			if err != nil {
+				s.Logger.Ctx(ctx).Error("slack poker create error", zap.Error(err))
+				s.slackRespond(w, slackEphemeral("Unable to create the poker game, please try again."))
+				return
+			}
+			s.slackRespond(w, slackMessage{
+				ResponseType: "in_channel",
+				Text:         fmt.Sprintf("%s started %s", user.Name, Game.Name),
+				Blocks:       s.slackVoteBlocks(Game),
+			})
+		case "checkin":
+			parts := strings.SplitN(args, "|", 3)
+			for len(parts) < 3 {
+				parts = append(parts, "")
+			}
+			body, _ := json.Marshal(map[string]interface{}{
+				"userId":    user.Id,
+				"yesterday": strings.TrimSpace(parts[0]),
+				"today":     strings.TrimSpace(parts[1]),
+				"blockers":  strings.TrimSpace(parts[2]),
+			})
+			if err := tc.APIEvent(ctx, TeamID, user.Id, "checkin_create", string(body)); err != nil {
+				if isCheckinAnswerError(err) {
+					s.slackRespond(w, slackEphemeral("Your team has required checkin questions, please check in from Thunderdome."))
+					return
+				}
+				s.slackRespond(w, slackEphemeral("Unable to check in, please try again."))
+				return
+			}
+			s.slackRespond(w, slackEphemeral("Thanks for checking in!"))
+		default:
+			s.slackRespond(w, slackEphemeral(slackCommandHelp))
+		}
+	}
+}
+
+// handleSlackInteraction handles slack interactive message actions such as poker vote buttons
+// @Summary Slack Interaction
+// @Description Handles signature verified slack interactive message actions
+// @Tags integrations
+// @Accept  x-www-form-urlencoded
+// @Produce  json
+// @Success 200 object slackMessage
+// @Failure 400 object standardJsonResponse{}
+// @Failure 401 object standardJsonResponse{}
+// @Router /integrations/slack/interactions [post]
+func (s *Service) handleSlackInteraction(slack *chat.Slack, pokerSvc *poker.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		form, err := s.slackReadRequest(r, slack)
+		if err != nil {
+			s.Failure(w, r, http.StatusUnauthorized, Errorf(EUNAUTHORIZED, err.Error()))
+			return
+		}
+
+		var payload struct {
+			Type        string `json:"type"`
+			ResponseURL string `json:"response_url"`
+			User        struct {
+				ID string `json:"id"`
+			} `json:"user"`
+			Actions []struct {
+				ActionID string `json:"action_id"`
+				Value    string `json:"value"`
+			} `json:"actions"`
+		}
+		if err := json.Unmarshal([]byte(form.Get("payload")), &payload); err != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, err.Error()))
+			return
+		}
+		if payload.Type != "block_actions" || len(payload.Actions) == 0 ||
+			!strings.HasPrefix(payload.Actions[0].ActionID, slackVoteActionID) {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		action := strings.SplitN(payload.Actions[0].Value, "|", 2)
+		if len(action) != 2 || validate.Var(action[0], "required,uuid") != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "INVALID_SLACK_ACTION"))
+			return
+		}
+		GameID, VoteValue := action[0], action[1]
+
+		// slack expects an acknowledgement within 3 seconds and ignores block action response bodies,
+		// so acknowledge first then vote and reply through the response url
+		w.WriteHeader(http.StatusOK)
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), slackInteractionTimeout)
+			defer cancel()
+
+			reply := s.slackVote(ctx, slack, pokerSvc, payload.User.ID, GameID, VoteValue)
+			if err := slack.Respond(ctx, payload.ResponseURL, reply); err != nil {
+				s.Logger.Ctx(ctx).Error("slack interaction response error", zap.Error(err))
+			}
+		}()
+	}
+}
+
+// slackVote votes on the poker games active story for the slack user,
+// they must be on one of the games teams or already a participant of the game
+func (s *Service) slackVote(ctx context.Context, slack *chat.Slack, pokerSvc *poker.Service, SlackUserID string, GameID string, VoteValue string) slackMessage {
+	user, err := s.slackUser(ctx, slack, SlackUserID)
+	if err != nil {
+		return slackEphemeral("No Thunderdome account matches your Slack email address.")
+	}
+
+	if _, roleErr := s.TeamDataSvc.TeamPokerUserRole(ctx, user.Id, GameID); roleErr != nil {
+		activeErr := s.PokerDataSvc.GetUserActiveStatus(GameID, user.Id)
+		if activeErr != nil && activeErr.Error() != "DUPLICATE_BATTLE_USER" {
+			return slackEphemeral("You must be on the games team to vote.")
+		}
+	}
+
+	Game, err := s.PokerDataSvc.GetGame(GameID, user.Id)
+	if err != nil {
+		return slackEphemeral("The poker game no longer exists.")
+	}
+	if Game.ActiveStoryID == "" || Game.VotingLocked {
+		return slackEphemeral("There is no story open for voting right now.")
+	}
+	if !isAllowedPointValue(Game.PointValuesAllowed, VoteValue) {
+		return slackEphemeral(fmt.Sprintf("%s isn't one of the games point values.", VoteValue))
+	}
+
+	vote, _ := json.Marshal(map[string]interface{}{
+		"voteValue":        VoteValue,
+		"planId":           Game.ActiveStoryID,
+		"autoFinishVoting": Game.AutoFinishVoting,
+	})
+	if err := pokerSvc.APIEvent(ctx, GameID, user.Id, "vote", string(vote)); err != nil {
+		return slackEphemeral("Unable to vote, please try again.")
+	}
+
+	return slackEphemeral(fmt.Sprintf("You voted %s.", VoteValue))
+}
+
+// splitSlackCommand splits the slash command text into its sub command and arguments
+func splitSlackCommand(text string) (string, string) {
+	fields := strings.SplitN(strings.TrimSpace(text), " ", 2)
+	subCommand := strings.ToLower(fields[0])
+	if len(fields) == 1 {
+		return subCommand, ""
+	}
+
+	return subCommand, fields[1]
+}
+
+// isAllowedPointValue determines whether the vote is one of the games allowed point values
+func isAllowedPointValue(PointValuesAllowed []string, VoteValue string) bool {
+	for _, point := range PointValuesAllowed {
+		if point == VoteValue {
+			return true
+		}
+	}
+
+	return false
+}
@@ -17,6 +17,7 @@ type storyboardCreateRequestBody struct {
 	StoryboardName  string `json:"storyboardName" validate:"required"`
 	JoinCode        string `json:"joinCode"`
 	FacilitatorCode string `json:"facilitatorCode"`
+	TemplateID      string `json:"templateId" validate:"omitempty,uuid"`
 }
 
 // handleStoryboardCreate handles creating a storyboard (arena)
@@ -30,7 +31,9 @@ type storyboardCreateRequestBody struct {
 // @Param teamId path string false "the team ID"
 // @Param storyboard body storyboardCreateRequestBody false "new storyboard object"
 // @Success 200 object standardJsonResponse{data=thunderdome.Storyboard}
+// @Failure 400 object standardJsonResponse{}
 // @Failure 403 object standardJsonResponse{}
+// @Failure 404 object standardJsonResponse{}
 // @Failure 500 object standardJsonResponse{}
 // @Security ApiKeyAuth
 // @Router /users/{userId}/storyboards [post]
@@ -68,13 +71,21 @@ func (s *Service) handleStoryboardCreate() http.HandlerFunc {
 			return
 		}
 
+		if !teamIdExists && sb.TemplateID != "" {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "TEMPLATE_REQUIRES_TEAM"))
+			return
+		}
+
 		var newStoryboard *thunderdome.Storyboard
 		var err error
 		// if storyboard created with team association
 		if teamIdExists {
 			if isTeamUserOrAnAdmin(r) {
-				newStoryboard, err = s.StoryboardDataSvc.TeamCreateStoryboard(ctx, TeamID, UserID, sb.StoryboardName, sb.JoinCode, sb.FacilitatorCode)
-
+				newStoryboard, err = s.StoryboardDataSvc.TeamCreateStoryboard(ctx, TeamID, UserID, sb.StoryboardName, sb.JoinCode, sb.FacilitatorCode, sb.TemplateID)
+				if err != nil && err.Error() == "TEMPLATE_NOT_FOUND" {
+					s.Failure(w, r, http.StatusNotFound, Errorf(ENOTFOUND, err.Error()))
+					return
+				}
 				if err != nil {
 					s.Failure(w, r, http.StatusInternalServerError, err)
 					return
@@ -214,6 +225,117 @@ func (s *Service) handleGetStoryboards() http.HandlerFunc {
 	}
 }
 
+// handleStoryboardClone handles cloning a storyboard
+// @Summary Clone Storyboard
+// @Description Clones a storyboards goals, columns, color legend and personas into a new storyboard, optionally as a template
+// @Tags storyboard
+// @Produce  json
+// @Param storyboardId path string true "the storyboard ID to clone"
+// @Param clone body cloneRequestBody false "clone options, templates must belong to a team"
+// @Success 200 object standardJsonResponse{data=thunderdome.Storyboard}
+// @Failure 400 object standardJsonResponse{}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /storyboards/{storyboardId}/clone [post]
+func (s *Service) handleStoryboardClone() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		vars := mux.Vars(r)
+		StoryboardID := vars["storyboardId"]
+		idErr := validate.Var(StoryboardID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+		UserID := ctx.Value(contextKeyUserID).(string)
+		UserType := ctx.Value(contextKeyUserType).(string)
+
+		c, cErr := getCloneRequestBody(r)
+		if cErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, cErr.Error()))
+			return
+		}
+
+		if UserType != adminUserType {
+			if err := s.StoryboardDataSvc.ConfirmStoryboardFacilitator(StoryboardID, UserID); err != nil {
+				s.Failure(w, r, http.StatusForbidden, Errorf(EUNAUTHORIZED, "REQUIRES_STORYBOARD_FACILITATOR"))
+				return
+			}
+		}
+
+		if c.TeamID != "" && UserType != adminUserType {
+			if _, err := s.TeamDataSvc.TeamUserRole(ctx, UserID, c.TeamID); err != nil {
+				s.Failure(w, r, http.StatusForbidden, Errorf(EUNAUTHORIZED, "REQUIRES_TEAM_USER"))
+				return
+			}
+		}
+
+		clone, err := s.StoryboardDataSvc.CloneStoryboard(ctx, StoryboardID, UserID, c.Name, c.Template, c.TeamID)
+		if err != nil && err.Error() == "TEMPLATE_REQUIRES_TEAM" {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, err.Error()))
+			return
+		}
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, clone, nil)
+	}
+}
+
+// handleStoryboardHistory gets the storyboards change history
+// @Summary Get Storyboard History
+// @Description get the storyboards change history, most recent first, including before and after snapshots of the entities changed by changes that can be undone
+// @Tags storyboard
+// @Produce  json
+// @Param storyboardId path string true "the storyboard ID to get history for"
+// @Param limit query int false "Max number of results to return"
+// @Param offset query int false "Starting point to return rows from, should be multiplied by limit or 0"
+// @Success 200 object standardJsonResponse{data=[]thunderdome.StoryboardHistory}
+// @Failure 400 object standardJsonResponse{}
+// @Failure 403 object standardJsonResponse{}
+// @Failure 500 object standardJsonResponse{}
+// @Security ApiKeyAuth
+// @Router /storyboards/{storyboardId}/history [get]
+func (s *Service) handleStoryboardHistory() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		vars := mux.Vars(r)
+		StoryboardID := vars["storyboardId"]
+		idErr := validate.Var(StoryboardID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+		UserID := ctx.Value(contextKeyUserID).(string)
+		UserType := ctx.Value(contextKeyUserType).(string)
+		Limit, Offset := getLimitOffsetFromRequest(r)
+
+		if UserType != adminUserType {
+			if err := s.StoryboardDataSvc.ConfirmStoryboardFacilitator(StoryboardID, UserID); err != nil {
+				s.Failure(w, r, http.StatusForbidden, Errorf(EUNAUTHORIZED, "REQUIRES_STORYBOARD_FACILITATOR"))
+				return
+			}
+		}
+
+		history, Count, err := s.StoryboardDataSvc.GetStoryboardHistory(StoryboardID, Limit, Offset)
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		Meta := &pagination{
+			Count:  Count,
+			Offset: Offset,
+			Limit:  Limit,
+		}
+
+		s.Success(w, r, http.StatusOK, history, Meta)
+	}
+}
+
 // handleStoryboardDelete handles deleting a storyboard
 // @Summary Storyboard Delete
 // @Description Delete a storyboard
@@ -8,6 +8,8 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/StevenWeathers/thunderdome-planning-poker/audit"
+	"github.com/StevenWeathers/thunderdome-planning-poker/metrics"
 	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
 
 	"go.uber.org/zap"
@@ -36,6 +38,51 @@ var ownerOnlyOperations = map[string]struct{}{
 	"facilitator_remove": {},
 	"edit_storyboard":    {},
 	"concede_storyboard": {},
+	"revise_workflow":    {},
+	"undo":               {},
+}
+
+// senderOnlyEvents contains a map of events only sent to the connection whose event caused them
+var senderOnlyEvents = map[string]struct{}{
+	"conflict":             {},
+	"undo_rejected":        {},
+	"story_state_rejected": {},
+	"story_move_rejected":  {},
+}
+
+// historyOperations contains a map of operations that are recorded to the storyboard history,
+// those that change the storyboard content have a scope of the entities they change whose before and
+// after snapshots are recorded so that they can be undone
+var historyOperations = map[string]historyScopeFunc{
+	"add_goal":                addedScope("goal", ""),
+	"revise_goal":             entityScope("goal", "goalId"),
+	"delete_goal":             entityScope("goal", ""),
+	"add_column":              addedScope("column", "goalId"),
+	"revise_column":           entityScope("column", "id"),
+	"delete_column":           entityScope("column", ""),
+	"update_column_wip_limit": entityScope("column", "columnId"),
+	"add_story":               addedScope("story", "columnId"),
+	"update_story_name":       entityScope("story", "storyId"),
+	"update_story_content":    entityScope("story", "storyId"),
+	"update_story_color":      entityScope("story", "storyId"),
+	"update_story_points":     entityScope("story", "storyId"),
+	"update_story_closed":     entityScope("story", "storyId"),
+	"update_story_state":      entityScope("story", "storyId"),
+	"update_story_link":       entityScope("story", "storyId"),
+	"move_story":              entityScope("story", "storyId"),
+	"add_story_comment":       addedScope("comment", "storyId"),
+	"edit_story_comment":      entityScope("comment", "commentId"),
+	"delete_story_comment":    entityScope("comment", "commentId"),
+	"delete_story":            entityScope("story", ""),
+	"add_persona":             addedScope("persona", ""),
+	"update_persona":          entityScope("persona", "id"),
+	"delete_persona":          entityScope("persona", ""),
+	"revise_color_legend":     settingScope("color_legend"),
+	"revise_workflow":         settingScope("workflow"),
+	"facilitator_add":         nil,
+	"facilitator_remove":      nil,
+	"facilitator_self":        nil,
+	"edit_storyboard":         nil,
 }
 
 var upgrader = websocket.Upgrader{
@@ -50,6 +97,9 @@ type connection struct {
 
 	// Buffered channel of outbound messages.
 	send chan []byte
+
+	// The user session the connection was authenticated with, empty for guests.
+	sessionID string
 }
 
 // readPump pumps messages from the websocket connection to the hub.
@@ -114,9 +164,17 @@ func (sub subscription) readPump(b *Service, ctx context.Context) {
 			}
 		}
 
+		// snapshot the entities before any change that can be undone
+		var scope *historyScope
+		if scopeFn := historyOperations[eventType]; scopeFn != nil && !badEvent {
+			scope = b.historyBefore(StoryboardID, scopeFn(StoryboardID, eventValue))
+		}
+
 		// find event handler and execute otherwise invalid event
 		if _, ok := b.EventHandlers[eventType]; ok && !badEvent {
+			eventStart := time.Now()
 			msg, eventErr, forceClosed = b.EventHandlers[eventType](ctx, StoryboardID, UserID, eventValue)
+			metrics.ObserveEvent(hubName, eventType, eventStart, eventErr != nil && !forceClosed)
 			if eventErr != nil {
 				badEvent = true
 
@@ -127,9 +185,17 @@ func (sub subscription) readPump(b *Service, ctx context.Context) {
 			}
 		}
 
+		if _, ok := historyOperations[eventType]; ok && !badEvent {
+			b.recordHistory(ctx, StoryboardID, UserID, eventType, scope)
+		}
+
 		if !badEvent {
 			m := message{msg, sub.arena}
-			h.broadcast <- m
+			if isSenderOnly(msg) {
+				h.direct <- directMessage{m, c}
+			} else {
+				h.broadcast <- m
+			}
 		}
 
 		if forceClosed {
@@ -144,6 +210,20 @@ func (c *connection) write(mt int, payload []byte) error {
 	return c.ws.WriteMessage(mt, payload)
 }
 
+// closeRevoked closes the websocket connection after its user session was revoked.
+func (c *connection) closeRevoked() {
+	cm := websocket.FormatCloseMessage(4001, "unauthorized")
+	_ = c.ws.WriteControl(websocket.CloseMessage, cm, time.Now().Add(writeWait))
+	_ = c.ws.Close()
+}
+
+// closeRestart closes the websocket connection with the service restart close code so the client reconnects.
+func (c *connection) closeRestart() {
+	cm := websocket.FormatCloseMessage(websocket.CloseServiceRestart, "service restart")
+	_ = c.ws.WriteControl(websocket.CloseMessage, cm, time.Now().Add(writeWait))
+	_ = c.ws.Close()
+}
+
 // writePump pumps messages from the hub to the websocket connection.
 func (sub *subscription) writePump() {
 	c := sub.conn
@@ -181,6 +261,33 @@ func (b *Service) handleSocketClose(ctx context.Context, ws *websocket.Conn, clo
 	}
 }
 
+// CloseSessions closes any websocket connections authenticated with the revoked user sessions
+func (b *Service) CloseSessions(SessionIDs []string) {
+	if len(SessionIDs) == 0 {
+		return
+	}
+
+	h.closeSessions <- SessionIDs
+}
+
+// Shutdown closes all websocket connections with the service restart close code for clients to reconnect,
+// waiting until they've disconnected or the context is done
+func (b *Service) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	select {
+	case h.shutdown <- done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // ServeWs handles websocket requests from the peer.
 func (b *Service) ServeWs() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -211,6 +318,7 @@ func (b *Service) ServeWs() http.HandlerFunc {
 				b.handleSocketClose(ctx, ws, 4001, "unauthorized")
 				return
 			}
+			c.sessionID = SessionId
 		} else {
 			UserID, err := b.ValidateUserCookie(w, r)
 			if err != nil {
@@ -233,6 +341,12 @@ func (b *Service) ServeWs() http.HandlerFunc {
 			return
 		}
 
+		// make sure the user satisfies the policy of the organizations the storyboards teams are in
+		if policyErr := b.PolicyCheck(ctx, "storyboard", storyboardID, User, SessionId); policyErr != nil {
+			b.handleSocketClose(ctx, ws, 4006, policyErr.Error())
+			return
+		}
+
 		// check users storyboard active status
 		UserErr := b.StoryboardService.GetStoryboardUserActiveStatus(storyboardID, User.Id)
 		if UserErr != nil && !errors.Is(UserErr, sql.ErrNoRows) {
@@ -248,9 +362,16 @@ func (b *Service) ServeWs() http.HandlerFunc {
 		}
 
 		if storyboard.JoinCode != "" && (UserErr != nil && errors.Is(UserErr, sql.ErrNoRows)) {
+			ClientIP := b.Limiter.ClientIP(r)
+			if b.Limiter.JoinCodeLocked(ctx, ClientIP, storyboardID) {
+				b.handleSocketClose(ctx, ws, 4029, "too many join code attempts")
+				return
+			}
+
 			jcrEvent := createSocketEvent("join_code_required", "", User.Id)
 			_ = c.write(websocket.TextMessage, jcrEvent)
 
+			var wrongJoinCodes int
 			for {
 				_, msg, err := c.ws.ReadMessage()
 				if err != nil {
@@ -267,9 +388,15 @@ func (b *Service) ServeWs() http.HandlerFunc {
 				}
 
 				if keyVal["type"] == "auth_storyboard" && keyVal["value"] == storyboard.JoinCode {
+					b.Limiter.JoinCodeReset(ctx, ClientIP, storyboardID)
 					UserAuthed = true
 					break
 				} else if keyVal["type"] == "auth_storyboard" {
+					wrongJoinCodes++
+					if b.Limiter.JoinCodeFailed(ctx, ClientIP, storyboardID, wrongJoinCodes) {
+						b.handleSocketClose(ctx, ws, 4029, "too many join code attempts")
+						return
+					}
 					authIncorrect := createSocketEvent("join_code_incorrect", "", User.Id)
 					_ = c.write(websocket.TextMessage, authIncorrect)
 				}
@@ -294,7 +421,7 @@ func (b *Service) ServeWs() http.HandlerFunc {
 			h.broadcast <- m
 
 			go ss.writePump()
-			go ss.readPump(b, ctx)
+			go ss.readPump(b, audit.WithIP(ctx, b.Limiter.ClientIP(r)))
 		}
 	}
 }
@@ -311,12 +438,19 @@ func (b *Service) APIEvent(ctx context.Context, arenaID string, UserID, eventTyp
 
 	// find event handler and execute otherwise invalid event
 	if _, ok := b.EventHandlers[eventType]; ok {
+		eventStart := time.Now()
 		msg, eventErr, _ := b.EventHandlers[eventType](ctx, arenaID, UserID, eventValue)
+		metrics.ObserveEvent(hubName, eventType, eventStart, eventErr != nil)
 		if eventErr != nil {
 			return eventErr
 		}
 
-		if _, ok := h.arenas[arenaID]; ok {
+		// api callers have no connection to send a conflict to so it's returned instead
+		if conflict := eventConflict(msg); conflict != nil {
+			return conflict
+		}
+
+		if _, ok := h.arenas[arenaID]; ok && !isSenderOnly(msg) {
 			m := message{msg, arenaID}
 			h.broadcast <- m
 		}
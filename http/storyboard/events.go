@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
 )
 
 // AddGoal handles adding a goal to storyboard
@@ -20,15 +22,20 @@ func (b *Service) AddGoal(ctx context.Context, StoryboardID string, UserID strin
 
 // ReviseGoal handles revising a storyboard goal
 func (b *Service) ReviseGoal(ctx context.Context, StoryboardID string, UserID string, EventValue string) ([]byte, error, bool) {
-	goalObj := make(map[string]string)
-	err := json.Unmarshal([]byte(EventValue), &goalObj)
+	var rs struct {
+		GoalID  string `json:"goalId"`
+		Name    string `json:"name"`
+		Version int    `json:"version"`
+	}
+	err := json.Unmarshal([]byte(EventValue), &rs)
 	if err != nil {
 		return nil, err, false
 	}
-	GoalID := goalObj["goalId"]
-	GoalName := goalObj["name"]
 
-	goals, err := b.StoryboardService.ReviseGoalName(StoryboardID, UserID, GoalID, GoalName)
+	goals, err := b.StoryboardService.ReviseGoalName(StoryboardID, UserID, rs.GoalID, rs.Name, rs.Version)
+	if msg, ok := conflictEvent(err, UserID); ok {
+		return msg, nil, false
+	}
 	if err != nil {
 		return nil, err, false
 	}
@@ -74,13 +81,41 @@ func (b *Service) ReviseColumn(ctx context.Context, StoryboardID string, UserID
 	var rs struct {
 		ColumnID string `json:"id"`
 		Name     string `json:"name"`
+		Version  int    `json:"version"`
 	}
 	err := json.Unmarshal([]byte(EventValue), &rs)
 	if err != nil {
 		return nil, err, false
 	}
 
-	goals, err := b.StoryboardService.ReviseStoryboardColumn(StoryboardID, UserID, rs.ColumnID, rs.Name)
+	goals, err := b.StoryboardService.ReviseStoryboardColumn(StoryboardID, UserID, rs.ColumnID, rs.Name, rs.Version)
+	if msg, ok := conflictEvent(err, UserID); ok {
+		return msg, nil, false
+	}
+	if err != nil {
+		return nil, err, false
+	}
+	updatedGoals, _ := json.Marshal(goals)
+	msg := createSocketEvent("column_updated", string(updatedGoals), "")
+
+	return msg, nil, false
+}
+
+// ReviseColumnWipLimit handles revising a storyboard goal columns WIP limit
+func (b *Service) ReviseColumnWipLimit(ctx context.Context, StoryboardID string, UserID string, EventValue string) ([]byte, error, bool) {
+	var rs struct {
+		ColumnID string `json:"columnId"`
+		WipLimit int    `json:"wipLimit"`
+	}
+	err := json.Unmarshal([]byte(EventValue), &rs)
+	if err != nil {
+		return nil, err, false
+	}
+	if rs.WipLimit < 0 {
+		return nil, errors.New("INVALID_WIP_LIMIT"), false
+	}
+
+	goals, err := b.StoryboardService.ReviseStoryboardColumnWipLimit(StoryboardID, UserID, rs.ColumnID, rs.WipLimit)
 	if err != nil {
 		return nil, err, false
 	}
@@ -124,15 +159,20 @@ func (b *Service) AddStory(ctx context.Context, StoryboardID string, UserID stri
 
 // UpdateStoryName handles revising a storyboard story name
 func (b *Service) UpdateStoryName(ctx context.Context, StoryboardID string, UserID string, EventValue string) ([]byte, error, bool) {
-	goalObj := make(map[string]string)
-	err := json.Unmarshal([]byte(EventValue), &goalObj)
+	var rs struct {
+		StoryID string `json:"storyId"`
+		Name    string `json:"name"`
+		Version int    `json:"version"`
+	}
+	err := json.Unmarshal([]byte(EventValue), &rs)
 	if err != nil {
 		return nil, err, false
 	}
-	StoryID := goalObj["storyId"]
-	StoryName := goalObj["name"]
 
-	goals, err := b.StoryboardService.ReviseStoryName(StoryboardID, UserID, StoryID, StoryName)
+	goals, err := b.StoryboardService.ReviseStoryName(StoryboardID, UserID, rs.StoryID, rs.Name, rs.Version)
+	if msg, ok := conflictEvent(err, UserID); ok {
+		return msg, nil, false
+	}
 	if err != nil {
 		return nil, err, false
 	}
@@ -144,15 +184,20 @@ func (b *Service) UpdateStoryName(ctx context.Context, StoryboardID string, User
 
 // UpdateStoryContent handles revising a storyboard story content
 func (b *Service) UpdateStoryContent(ctx context.Context, StoryboardID string, UserID string, EventValue string) ([]byte, error, bool) {
-	goalObj := make(map[string]string)
-	err := json.Unmarshal([]byte(EventValue), &goalObj)
+	var rs struct {
+		StoryID string `json:"storyId"`
+		Content string `json:"content"`
+		Version int    `json:"version"`
+	}
+	err := json.Unmarshal([]byte(EventValue), &rs)
 	if err != nil {
 		return nil, err, false
 	}
-	StoryID := goalObj["storyId"]
-	StoryContent := goalObj["content"]
 
-	goals, err := b.StoryboardService.ReviseStoryContent(StoryboardID, UserID, StoryID, StoryContent)
+	goals, err := b.StoryboardService.ReviseStoryContent(StoryboardID, UserID, rs.StoryID, rs.Content, rs.Version)
+	if msg, ok := conflictEvent(err, UserID); ok {
+		return msg, nil, false
+	}
 	if err != nil {
 		return nil, err, false
 	}
@@ -224,6 +269,38 @@ func (b *Service) UpdateStoryClosed(ctx context.Context, StoryboardID string, Us
 	return msg, nil, false
 }
 
+// UpdateStoryState handles revising a storyboard story workflow state,
+// when the columns WIP limit is enforced and would be exceeded the change is rejected
+func (b *Service) UpdateStoryState(ctx context.Context, StoryboardID string, UserID string, EventValue string) ([]byte, error, bool) {
+	var rs struct {
+		StoryID string `json:"storyId"`
+		State   string `json:"state"`
+	}
+	err := json.Unmarshal([]byte(EventValue), &rs)
+	if err != nil {
+		return nil, err, false
+	}
+
+	goals, err := b.StoryboardService.ReviseStoryState(StoryboardID, UserID, rs.StoryID, rs.State)
+	if err != nil && err.Error() == "WIP_LIMIT_EXCEEDED" {
+		rejection, _ := json.Marshal(map[string]string{
+			"storyId": rs.StoryID,
+			"state":   rs.State,
+			"reason":  err.Error(),
+		})
+		msg := createSocketEvent("story_state_rejected", string(rejection), UserID)
+
+		return msg, nil, false
+	}
+	if err != nil {
+		return nil, err, false
+	}
+	updatedGoals, _ := json.Marshal(goals)
+	msg := createSocketEvent("story_updated", string(updatedGoals), "")
+
+	return msg, nil, false
+}
+
 // UpdateStoryLink handles revising a storyboard story link
 func (b *Service) UpdateStoryLink(ctx context.Context, StoryboardID string, UserID string, EventValue string) ([]byte, error, bool) {
 	goalObj := make(map[string]string)
@@ -257,6 +334,13 @@ func (b *Service) MoveStory(ctx context.Context, StoryboardID string, UserID str
 	PlaceBefore := goalObj["placeBefore"]
 
 	goals, err := b.StoryboardService.MoveStoryboardStory(StoryboardID, UserID, StoryID, GoalID, ColumnID, PlaceBefore)
+	if err != nil && err.Error() == "WIP_LIMIT_EXCEEDED" {
+		// the current goals let the mover put the story back where it was
+		currentGoals, _ := json.Marshal(b.StoryboardService.GetStoryboardGoals(StoryboardID))
+		msg := createSocketEvent("story_move_rejected", string(currentGoals), UserID)
+
+		return msg, nil, false
+	}
 	if err != nil {
 		return nil, err, false
 	}
@@ -412,6 +496,14 @@ func (b *Service) FacilitatorAdd(ctx context.Context, StoryboardID string, UserI
 		return nil, err, false
 	}
 	updatedStoryboard, _ := json.Marshal(storyboard)
+	b.Audit.Record(ctx, thunderdome.AuditLog{
+		ActorId:    UserID,
+		Action:     thunderdome.AuditActionStoryboardFacilitatorAdd,
+		TargetType: "storyboard",
+		TargetId:   StoryboardID,
+		After:      map[string]string{"userId": rs.UserID},
+	})
+
 	msg := createSocketEvent("storyboard_updated", string(updatedStoryboard), "")
 
 	return msg, nil, false
@@ -432,6 +524,14 @@ func (b *Service) FacilitatorRemove(ctx context.Context, StoryboardID string, Us
 		return nil, err, false
 	}
 	updatedStoryboard, _ := json.Marshal(storyboard)
+	b.Audit.Record(ctx, thunderdome.AuditLog{
+		ActorId:    UserID,
+		Action:     thunderdome.AuditActionStoryboardFacilitatorRemove,
+		TargetType: "storyboard",
+		TargetId:   StoryboardID,
+		Before:     map[string]string{"userId": rs.UserID},
+	})
+
 	msg := createSocketEvent("storyboard_updated", string(updatedStoryboard), "")
 
 	return msg, nil, false
@@ -451,6 +551,14 @@ func (b *Service) FacilitatorSelf(ctx context.Context, StoryboardID string, User
 		}
 		updatedStoryboard, _ := json.Marshal(storyboard)
 
+		b.Audit.Record(ctx, thunderdome.AuditLog{
+			ActorId:    UserID,
+			Action:     thunderdome.AuditActionStoryboardFacilitatorAdd,
+			TargetType: "storyboard",
+			TargetId:   StoryboardID,
+			After:      map[string]string{"userId": UserID},
+		})
+
 		msg := createSocketEvent("storyboard_updated", string(updatedStoryboard), "")
 
 		return msg, nil, false
@@ -471,6 +579,59 @@ func (b *Service) ReviseColorLegend(ctx context.Context, StoryboardID string, Us
 	return msg, nil, false
 }
 
+// ReviseWorkflow handles revising the storyboard workflow states and WIP limit enforcement
+func (b *Service) ReviseWorkflow(ctx context.Context, StoryboardID string, UserID string, EventValue string) ([]byte, error, bool) {
+	var rb struct {
+		WorkflowStates   []*thunderdome.WorkflowState `json:"workflowStates"`
+		WipLimitEnforced bool                         `json:"wipLimitEnforced"`
+	}
+	err := json.Unmarshal([]byte(EventValue), &rb)
+	if err != nil {
+		return nil, err, false
+	}
+
+	if len(rb.WorkflowStates) == 0 {
+		return nil, errors.New("WORKFLOW_STATES_REQUIRED"), false
+	}
+
+	names := make(map[string]struct{})
+	for _, s := range rb.WorkflowStates {
+		if s == nil || s.Name == "" || len(s.Name) > 64 {
+			return nil, errors.New("INVALID_WORKFLOW_STATE"), false
+		}
+		if _, ok := names[s.Name]; ok {
+			return nil, errors.New("DUPLICATE_WORKFLOW_STATE"), false
+		}
+		names[s.Name] = struct{}{}
+	}
+
+	storyboard, err := b.StoryboardService.StoryboardReviseWorkflow(StoryboardID, UserID, rb.WorkflowStates, rb.WipLimitEnforced)
+	if err != nil {
+		return nil, err, false
+	}
+	updatedStoryboard, _ := json.Marshal(storyboard)
+	msg := createSocketEvent("storyboard_updated", string(updatedStoryboard), "")
+
+	return msg, nil, false
+}
+
+// Undo handles reverting the most recent storyboard change
+func (b *Service) Undo(ctx context.Context, StoryboardID string, UserID string, EventValue string) ([]byte, error, bool) {
+	storyboard, err := b.StoryboardService.StoryboardHistoryUndo(StoryboardID, UserID)
+	if err != nil && (err.Error() == "NOTHING_TO_UNDO" || err.Error() == "UNDO_CONFLICT") {
+		msg := createSocketEvent("undo_rejected", err.Error(), UserID)
+
+		return msg, nil, false
+	}
+	if err != nil {
+		return nil, err, false
+	}
+	updatedStoryboard, _ := json.Marshal(storyboard)
+	msg := createSocketEvent("storyboard_updated", string(updatedStoryboard), "")
+
+	return msg, nil, false
+}
+
 // EditStoryboard handles editing the storyboard settings
 func (b *Service) EditStoryboard(ctx context.Context, StoryboardID string, UserID string, EventValue string) ([]byte, error, bool) {
 	var rb struct {
@@ -505,6 +666,14 @@ func (b *Service) Delete(ctx context.Context, StoryboardID string, UserID string
 	if err != nil {
 		return nil, err, false
 	}
+
+	b.Audit.Record(ctx, thunderdome.AuditLog{
+		ActorId:    UserID,
+		Action:     thunderdome.AuditActionStoryboardDelete,
+		TargetType: "storyboard",
+		TargetId:   StoryboardID,
+	})
+
 	msg := createSocketEvent("storyboard_conceded", "", "")
 
 	return msg, nil, false
@@ -527,6 +696,43 @@ type socketEvent struct {
 	User  string `json:"userId"`
 }
 
+// isSenderOnly checks whether the event is only sent to the connection whose event caused it
+func isSenderOnly(msg []byte) bool {
+	var e socketEvent
+	if err := json.Unmarshal(msg, &e); err != nil {
+		return false
+	}
+	_, ok := senderOnlyEvents[e.Type]
+
+	return ok
+}
+
+// eventConflict gets the version conflict of a conflict event, nil for any other event
+func eventConflict(msg []byte) *thunderdome.VersionConflict {
+	var e socketEvent
+	if err := json.Unmarshal(msg, &e); err != nil || e.Type != "conflict" {
+		return nil
+	}
+
+	var conflict thunderdome.VersionConflict
+	if err := json.Unmarshal([]byte(e.Value), &conflict); err != nil {
+		return nil
+	}
+
+	return &conflict
+}
+
+// conflictEvent creates a conflict event for the editor when the error is a version conflict
+func conflictEvent(err error, UserID string) ([]byte, bool) {
+	var conflict *thunderdome.VersionConflict
+	if !errors.As(err, &conflict) {
+		return nil, false
+	}
+	value, _ := json.Marshal(conflict)
+
+	return createSocketEvent("conflict", string(value), UserID), true
+}
+
 func createSocketEvent(Type string, Value string, User string) []byte {
 	newEvent := &socketEvent{
 		Type:  Type,
@@ -0,0 +1,140 @@
+package storyboard
+
+import (
+	"context"
+	"encoding/json"
+
+	"go.uber.org/zap"
+)
+
+// historyScope the storyboard entities an event changes, an addition is scoped to the parent
+// the entity is added to as its ID isn't known until it's added
+type historyScope struct {
+	Kind     string
+	IDs      []string
+	ParentID string
+	Added    bool
+
+	// existingIDs the parents entities of the kind before an addition
+	existingIDs []string
+	before      string
+}
+
+// historyScopeFunc gets the scope of an event from its value
+type historyScopeFunc func(StoryboardID string, EventValue string) *historyScope
+
+// entityScope scopes an event to the entity whose ID is the events value field, or the value itself when no field
+func entityScope(Kind string, Field string) historyScopeFunc {
+	return func(StoryboardID string, EventValue string) *historyScope {
+		return &historyScope{Kind: Kind, IDs: []string{eventField(EventValue, Field)}}
+	}
+}
+
+// addedScope scopes an event to the entity it adds to the parent whose ID is the events value field,
+// the parent being the storyboard when no field
+func addedScope(Kind string, ParentField string) historyScopeFunc {
+	return func(StoryboardID string, EventValue string) *historyScope {
+		parentID := StoryboardID
+		if ParentField != "" {
+			parentID = eventField(EventValue, ParentField)
+		}
+
+		return &historyScope{Kind: Kind, ParentID: parentID, Added: true}
+	}
+}
+
+// settingScope scopes an event to a storyboard setting e.g. the color legend
+func settingScope(Kind string) historyScopeFunc {
+	return func(StoryboardID string, EventValue string) *historyScope {
+		return &historyScope{Kind: Kind, IDs: []string{}}
+	}
+}
+
+// eventField gets a string field from the events json value, or the value itself when no field
+func eventField(EventValue string, Field string) string {
+	if Field == "" {
+		return EventValue
+	}
+
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(EventValue), &fields); err != nil {
+		return ""
+	}
+	value, _ := fields[Field].(string)
+
+	return value
+}
+
+// historyBefore snapshots the scoped entities before the event changes them,
+// for additions it gets the parents existing entities instead
+func (b *Service) historyBefore(StoryboardID string, scope *historyScope) *historyScope {
+	if scope.Added {
+		ids, err := b.StoryboardService.GetStoryboardEntityIDs(StoryboardID, scope.Kind, scope.ParentID)
+		if err != nil {
+			return nil
+		}
+		scope.existingIDs = ids
+
+		return scope
+	}
+
+	before, err := b.StoryboardService.GetStoryboardEntitySnapshot(StoryboardID, scope.Kind, scope.IDs)
+	if err != nil {
+		return nil
+	}
+	scope.before = before
+
+	return scope
+}
+
+// recordHistory appends the event to the storyboard history, content changes are recorded with before and after
+// snapshots of the changed entities and skipped when nothing changed e.g. a rejected or conflicting edit
+func (b *Service) recordHistory(ctx context.Context, StoryboardID string, UserID string, EventType string, scope *historyScope) {
+	var kind string
+	var ids []string
+	var before string
+	var after string
+
+	if scope != nil {
+		kind = scope.Kind
+		ids = scope.IDs
+		before = scope.before
+
+		if scope.Added {
+			current, err := b.StoryboardService.GetStoryboardEntityIDs(StoryboardID, scope.Kind, scope.ParentID)
+			if err != nil {
+				return
+			}
+			ids = addedIDs(scope.existingIDs, current)
+			if len(ids) == 0 {
+				return
+			}
+		}
+
+		after, _ = b.StoryboardService.GetStoryboardEntitySnapshot(StoryboardID, kind, ids)
+		if before == after {
+			return
+		}
+	}
+
+	if err := b.StoryboardService.StoryboardHistoryAdd(StoryboardID, UserID, EventType, kind, ids, before, after); err != nil {
+		b.Logger.Ctx(ctx).Error("record storyboard history error", zap.Error(err))
+	}
+}
+
+// addedIDs gets the IDs in current that weren't in existing
+func addedIDs(existing []string, current []string) []string {
+	seen := make(map[string]struct{}, len(existing))
+	for _, id := range existing {
+		seen[id] = struct{}{}
+	}
+
+	added := make([]string, 0)
+	for _, id := range current {
+		if _, ok := seen[id]; !ok {
+			added = append(added, id)
+		}
+	}
+
+	return added
+}
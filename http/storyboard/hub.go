@@ -1,10 +1,21 @@
 package storyboard
 
+import "github.com/StevenWeathers/thunderdome-planning-poker/metrics"
+
+// hubName the hub label of the websocket metrics
+const hubName = "storyboard"
+
 type message struct {
 	data  []byte
 	arena string
 }
 
+// directMessage a message for a single connection in the arena e.g. a conflict for the editor
+type directMessage struct {
+	message
+	conn *connection
+}
+
 type subscription struct {
 	conn   *connection
 	arena  string
@@ -20,18 +31,33 @@ type hub struct {
 	// Inbound messages from the connections.
 	broadcast chan message
 
+	// Inbound messages only for the connection that caused them.
+	direct chan directMessage
+
 	// Register requests from the connections.
 	register chan subscription
 
 	// Unregister requests from connections.
 	unregister chan subscription
+
+	// Close requests for connections of revoked user sessions.
+	closeSessions chan []string
+
+	// Shutdown requests closing all connections, the channel is closed once they've unregistered.
+	shutdown chan chan struct{}
+
+	// Closed once all connections have unregistered after a shutdown request.
+	drained chan struct{}
 }
 
 var h = hub{
-	broadcast:  make(chan message),
-	register:   make(chan subscription),
-	unregister: make(chan subscription),
-	arenas:     make(map[string]map[*connection]struct{}),
+	broadcast:     make(chan message),
+	direct:        make(chan directMessage),
+	register:      make(chan subscription),
+	unregister:    make(chan subscription),
+	closeSessions: make(chan []string),
+	shutdown:      make(chan chan struct{}),
+	arenas:        make(map[string]map[*connection]struct{}),
 }
 
 func (h *hub) run() {
@@ -43,6 +69,9 @@ func (h *hub) run() {
 				connections = make(map[*connection]struct{})
 				h.arenas[a.arena] = connections
 			}
+			if _, ok := connections[a.conn]; !ok {
+				metrics.WebsocketConnections.WithLabelValues(hubName).Inc()
+			}
 			h.arenas[a.arena][a.conn] = struct{}{}
 		case a := <-h.unregister:
 			connections := h.arenas[a.arena]
@@ -50,11 +79,25 @@ func (h *hub) run() {
 				if _, ok := connections[a.conn]; ok {
 					delete(connections, a.conn)
 					close(a.conn.send)
+					metrics.WebsocketConnections.WithLabelValues(hubName).Dec()
 					if len(connections) == 0 {
 						delete(h.arenas, a.arena)
 					}
 				}
 			}
+			h.checkDrained()
+		case sessionIDs := <-h.closeSessions:
+			revoked := make(map[string]struct{}, len(sessionIDs))
+			for _, id := range sessionIDs {
+				revoked[id] = struct{}{}
+			}
+			for _, connections := range h.arenas {
+				for c := range connections {
+					if _, ok := revoked[c.sessionID]; ok && c.sessionID != "" {
+						go c.closeRevoked()
+					}
+				}
+			}
 		case m := <-h.broadcast:
 			connections := h.arenas[m.arena]
 			for c := range connections {
@@ -63,11 +106,46 @@ func (h *hub) run() {
 				default:
 					close(c.send)
 					delete(connections, c)
+					metrics.WebsocketConnections.WithLabelValues(hubName).Dec()
+					metrics.HubBroadcastDrops.WithLabelValues(hubName).Inc()
 					if len(connections) == 0 {
 						delete(h.arenas, m.arena)
 					}
 				}
 			}
+			h.checkDrained()
+		case m := <-h.direct:
+			connections := h.arenas[m.arena]
+			if _, ok := connections[m.conn]; ok {
+				select {
+				case m.conn.send <- m.data:
+				default:
+					close(m.conn.send)
+					delete(connections, m.conn)
+					metrics.WebsocketConnections.WithLabelValues(hubName).Dec()
+					metrics.HubBroadcastDrops.WithLabelValues(hubName).Inc()
+					if len(connections) == 0 {
+						delete(h.arenas, m.arena)
+					}
+				}
+			}
+			h.checkDrained()
+		case done := <-h.shutdown:
+			h.drained = done
+			for _, connections := range h.arenas {
+				for c := range connections {
+					go c.closeRestart()
+				}
+			}
+			h.checkDrained()
 		}
 	}
 }
+
+// checkDrained signals a shutdown request once all connections have unregistered
+func (h *hub) checkDrained() {
+	if h.drained != nil && len(h.arenas) == 0 {
+		close(h.drained)
+		h.drained = nil
+	}
+}
@@ -156,7 +156,7 @@ type teamAddUserRequestBody struct {
 
 // handleTeamAddUser handles adding user to a team
 // @Summary Add Team User
-// @Description Adds a user to the team
+// @Description Adds a user to the team, emails an invite when no account exists for the email
 // @Tags team
 // @Produce  json
 // @Param teamId path string true "the team ID"
@@ -187,13 +187,26 @@ func (s *Service) handleTeamAddUser() http.HandlerFunc {
 		inputErr := validate.Struct(u)
 		if inputErr != nil {
 			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, inputErr.Error()))
+			return
 		}
 
 		UserEmail := u.Email
 
 		User, UserErr := s.UserDataSvc.GetUserByEmail(r.Context(), UserEmail)
 		if UserErr != nil {
-			s.Failure(w, r, http.StatusInternalServerError, Errorf(ENOTFOUND, "USER_NOT_FOUND"))
+			Team, err := s.TeamDataSvc.TeamGet(r.Context(), TeamID)
+			if err != nil {
+				s.Failure(w, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			Invite, err := s.inviteUser(r.Context(), thunderdome.InviteTypeTeam, TeamID, Team.Name, UserEmail, u.Role)
+			if err != nil {
+				s.Failure(w, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			s.Success(w, r, http.StatusOK, Invite, nil)
 			return
 		}
 
@@ -203,6 +216,8 @@ func (s *Service) handleTeamAddUser() http.HandlerFunc {
 			return
 		}
 
+		s.audit(r, thunderdome.AuditActionTeamUserAdd, "team", TeamID, nil, map[string]string{"userId": User.Id, "role": u.Role})
+
 		s.Success(w, r, http.StatusOK, nil, nil)
 	}
 }
@@ -236,6 +251,8 @@ func (s *Service) handleTeamRemoveUser() http.HandlerFunc {
 			return
 		}
 
+		s.audit(r, thunderdome.AuditActionTeamUserRemove, "team", TeamID, map[string]string{"userId": UserID}, nil)
+
 		s.Success(w, r, http.StatusOK, nil, nil)
 	}
 }
@@ -322,6 +339,8 @@ func (s *Service) handleDeleteTeam() http.HandlerFunc {
 			return
 		}
 
+		s.audit(r, thunderdome.AuditActionTeamDelete, "team", TeamID, nil, nil)
+
 		s.Success(w, r, http.StatusOK, nil, nil)
 	}
 }
@@ -430,6 +449,50 @@ func (s *Service) handleTeamRemoveStoryboard() http.HandlerFunc {
 	}
 }
 
+type teamTemplatesResponse struct {
+	Poker       []*thunderdome.Poker      `json:"poker"`
+	Retros      []*thunderdome.Retro      `json:"retros"`
+	Storyboards []*thunderdome.Storyboard `json:"storyboards"`
+}
+
+// handleGetTeamTemplates gets a list of poker, retro, and storyboard templates associated to the team
+// @Summary Get Team Templates
+// @Description Get a list of poker, retro, and storyboard templates associated to the team
+// @Tags team
+// @Produce  json
+// @Param teamId path string true "the team ID"
+// @Param limit query int false "Max number of results to return per template type"
+// @Param offset query int false "Starting point to return rows from, should be multiplied by limit or 0"
+// @Success 200 object standardJsonResponse{data=teamTemplatesResponse}
+// @Security ApiKeyAuth
+// @Router /teams/{teamId}/templates [get]
+func (s *Service) handleGetTeamTemplates() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		vars := mux.Vars(r)
+		TeamID := vars["teamId"]
+		Limit, Offset := getLimitOffsetFromRequest(r)
+
+		result := &teamTemplatesResponse{
+			Poker:       make([]*thunderdome.Poker, 0),
+			Retros:      make([]*thunderdome.Retro, 0),
+			Storyboards: make([]*thunderdome.Storyboard, 0),
+		}
+
+		if s.Config.FeaturePoker {
+			result.Poker = s.TeamDataSvc.TeamPokerTemplateList(ctx, TeamID, Limit, Offset)
+		}
+		if s.Config.FeatureRetro {
+			result.Retros = s.TeamDataSvc.TeamRetroTemplateList(ctx, TeamID, Limit, Offset)
+		}
+		if s.Config.FeatureStoryboard {
+			result.Storyboards = s.TeamDataSvc.TeamStoryboardTemplateList(ctx, TeamID, Limit, Offset)
+		}
+
+		s.Success(w, r, http.StatusOK, result, nil)
+	}
+}
+
 // handleGetTeamRetroActions gets a list of retro actions
 // @Summary Get Retro Actions
 // @Description get list of retro actions
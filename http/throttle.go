@@ -0,0 +1,77 @@
+package http
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/throttle"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// throttleLocked responds with too many requests when the request IP or the scoped key is locked out
+func (s *Service) throttleLocked(w http.ResponseWriter, r *http.Request, Scope string, Key string) bool {
+	ctx := r.Context()
+
+	lockout := s.AuthLimiter.Locked(ctx, throttle.ScopeIP, s.AuthLimiter.ClientIP(r))
+	if keyLockout := s.AuthLimiter.Locked(ctx, Scope, Key); keyLockout > lockout {
+		lockout = keyLockout
+	}
+
+	if lockout <= 0 {
+		return false
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(lockout.Seconds()))))
+	s.Failure(w, r, http.StatusTooManyRequests, Errorf(EINVALID, "TOO_MANY_ATTEMPTS"))
+	return true
+}
+
+// throttleFail records a failed attempt of the request IP and the scoped key,
+// returning the keys lockout and whether it was just locked out
+func (s *Service) throttleFail(r *http.Request, Scope string, Key string) (time.Duration, bool) {
+	ctx := r.Context()
+	if s.AuthLimiter == nil {
+		return 0, false
+	}
+
+	s.AuthLimiter.Fail(ctx, throttle.ScopeIP, s.AuthLimiter.ClientIP(r), s.AuthLimiter.Config.IPAttempts)
+
+	return s.AuthLimiter.Fail(ctx, Scope, Key, s.AuthLimiter.Config.AccountAttempts)
+}
+
+// loginFailed records a failed password login of the account email,
+// emailing the user when their account was just locked out
+func (s *Service) loginFailed(r *http.Request, Email string) {
+	Lockout, locked := s.throttleFail(r, throttle.ScopeLogin, Email)
+	if !locked {
+		return
+	}
+
+	if User, err := s.UserDataSvc.GetUserByEmail(r.Context(), Email); err == nil {
+		s.notifyAccountLocked(User, Lockout)
+	}
+}
+
+// notifyAccountLocked emails the user their account was temporarily locked after repeated failed logins
+func (s *Service) notifyAccountLocked(User *thunderdome.User, Lockout time.Duration) {
+	if User == nil || User.Email == "" {
+		return
+	}
+
+	_ = s.Email.SendAccountLocked(User.Name, User.Email, Lockout)
+}
+
+// mfaLoginFailed records a failed MFA login of the user,
+// emailing the user when their account was just locked out
+func (s *Service) mfaLoginFailed(r *http.Request, User *thunderdome.User) {
+	var UserID string
+	if User != nil {
+		UserID = User.Id
+	}
+
+	if Lockout, locked := s.throttleFail(r, throttle.ScopeMFA, UserID); locked {
+		s.notifyAccountLocked(User, Lockout)
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
 	"github.com/anthonynsimon/bild/transform"
 	"github.com/ipsn/go-adorable"
 	"github.com/o1egl/govatar"
@@ -184,6 +186,8 @@ func (s *Service) handleUserDelete() http.HandlerFunc {
 			return
 		}
 
+		s.audit(r, thunderdome.AuditActionUserDelete, "user", UserID, map[string]string{"name": User.Name, "email": User.Email, "type": User.Type}, nil)
+
 		_ = s.Email.SendDeleteConfirmation(User.Name, User.Email)
 
 		// don't clear admins user cookies when deleting other users
@@ -7,10 +7,12 @@ import (
 	"errors"
 	"fmt"
 	"html/template"
+	"io"
 	"io/fs"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
 
@@ -29,6 +31,13 @@ type userPassword struct {
 	Password2 string `json:"password2" validate:"required,min=6,max=72,eqfield=Password1"`
 }
 
+type cloneRequestBody struct {
+	Name     string `json:"name" validate:"max=256"`
+	Template bool   `json:"template"`
+	// TeamID the team the clone belongs to, defaults to the sources teams
+	TeamID string `json:"teamId" validate:"omitempty,uuid"`
+}
+
 // validateUserAccount makes sure user's name, email are valid before creating the account
 func validateUserAccount(name string, email string) (UserName string, UserEmail string, validateErr error) {
 	a := userAccount{
@@ -248,6 +257,73 @@ func (s *Service) Failure(w http.ResponseWriter, r *http.Request, code int, err
 	w.Write(response)
 }
 
+// Conflict responds with a version conflict error and the current value so the editor can reconcile
+func (s *Service) Conflict(w http.ResponseWriter, r *http.Request, conflict *thunderdome.VersionConflict) {
+	result := &standardJsonResponse{
+		Success: false,
+		Error:   conflict.Error(),
+		Data:    conflict,
+		Meta:    map[string]interface{}{},
+	}
+
+	response, _ := json.Marshal(result)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	w.Write(response)
+}
+
+// maxDateRangeDays the most days a from and to date range can span
+const maxDateRangeDays = 366
+
+// getDateRangeFromRequest gets the from and to date query parameters in YYYY-MM-DD format from the request
+// defaulting to today in the location for to and the given number of days before to for from,
+// ranges spanning more than maxDateRangeDays are invalid
+func getDateRangeFromRequest(r *http.Request, defaultDays int, loc *time.Location) (from string, to string, err error) {
+	query := r.URL.Query()
+	// today in the location as a date without the time of day
+	toDate, _ := time.Parse("2006-01-02", time.Now().In(loc).Format("2006-01-02"))
+	if t := query.Get("to"); t != "" {
+		toDate, err = time.Parse("2006-01-02", t)
+		if err != nil {
+			return "", "", errors.New("INVALID_TO_DATE")
+		}
+	}
+
+	fromDate := toDate.AddDate(0, 0, -defaultDays)
+	if f := query.Get("from"); f != "" {
+		fromDate, err = time.Parse("2006-01-02", f)
+		if err != nil {
+			return "", "", errors.New("INVALID_FROM_DATE")
+		}
+	}
+
+	if fromDate.After(toDate) {
+		return "", "", errors.New("INVALID_DATE_RANGE")
+	}
+
+	if toDate.Sub(fromDate) > maxDateRangeDays*24*time.Hour {
+		return "", "", errors.New("DATE_RANGE_TOO_LARGE")
+	}
+
+	return fromDate.Format("2006-01-02"), toDate.Format("2006-01-02"), nil
+}
+
+// getTimeZoneFromRequest gets the tz query parameter timezone name from the request defaulting to America/New_York
+func getTimeZoneFromRequest(r *http.Request) (string, *time.Location, error) {
+	tz := r.URL.Query().Get("tz")
+	if tz == "" {
+		tz = "America/New_York"
+	}
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return "", nil, errors.New("INVALID_TIMEZONE")
+	}
+
+	return tz, loc, nil
+}
+
 // getLimitOffsetFromRequest gets the limit and offset query parameters from the request
 // defaulting to 20 for limit and 0 for offset
 func getLimitOffsetFromRequest(r *http.Request) (limit int, offset int) {
@@ -279,6 +355,30 @@ func getSearchFromRequest(r *http.Request) (search string, err error) {
 	return Search, nil
 }
 
+// getCloneRequestBody reads the optional clone options from the request body
+func getCloneRequestBody(r *http.Request) (*cloneRequestBody, error) {
+	var c = cloneRequestBody{}
+
+	body, bodyErr := io.ReadAll(r.Body)
+	if bodyErr != nil {
+		return nil, bodyErr
+	}
+
+	if len(body) > 0 {
+		jsonErr := json.Unmarshal(body, &c)
+		if jsonErr != nil {
+			return nil, jsonErr
+		}
+	}
+
+	inputErr := validate.Struct(c)
+	if inputErr != nil {
+		return nil, inputErr
+	}
+
+	return &c, nil
+}
+
 // for logging purposes sanitize strings by removing new lines
 func sanitizeUserInputForLogs(unescapedInput string) string {
 	escapedString := strings.Replace(unescapedInput, "\n", "", -1)
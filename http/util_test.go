@@ -1,13 +1,18 @@
 package http
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 )
 
 func TestMain(m *testing.M) {
 	validate = validator.New()
+	os.Exit(m.Run())
 }
 
 // TestValidUserAccount calls validateUserAccountWithPasswords with valid user inputs for name, email, password1, and password2
@@ -49,3 +54,45 @@ func TestInvalidUserPassword(t *testing.T) {
 		t.Fatalf(`validateUserAccountWithPasswords = %v, want error`, err)
 	}
 }
+
+// TestGetDateRangeFromRequest calls getDateRangeFromRequest with valid, reversed and too large date ranges
+func TestGetDateRangeFromRequest(t *testing.T) {
+	tests := []struct {
+		query   string
+		from    string
+		to      string
+		wantErr string
+	}{
+		{query: "from=2023-01-01&to=2023-01-31", from: "2023-01-01", to: "2023-01-31"},
+		{query: "to=2023-03-31", from: "2023-03-01", to: "2023-03-31"},
+		{query: "from=2023-01-01&to=2024-01-01", from: "2023-01-01", to: "2024-01-01"},
+		{query: "from=2023-01-31&to=2023-01-01", wantErr: "INVALID_DATE_RANGE"},
+		{query: "from=2022-01-01&to=2023-12-31", wantErr: "DATE_RANGE_TOO_LARGE"},
+		{query: "from=yesterday", wantErr: "INVALID_FROM_DATE"},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil)
+		from, to, err := getDateRangeFromRequest(r, 30, time.UTC)
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf(`getDateRangeFromRequest(%q) = %v, want %s`, tt.query, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || from != tt.from || to != tt.to {
+			t.Fatalf(`getDateRangeFromRequest(%q) = %s, %s, %v, want %s, %s`, tt.query, from, to, err, tt.from, tt.to)
+		}
+	}
+}
+
+// TestGetDateRangeFromRequestDefaultsToToday calls getDateRangeFromRequest without dates in a timezone ahead of UTC
+func TestGetDateRangeFromRequestDefaultsToToday(t *testing.T) {
+	loc := time.FixedZone("UTC+14", 14*60*60)
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	_, to, err := getDateRangeFromRequest(r, 30, loc)
+	if err != nil || to != time.Now().In(loc).Format("2006-01-02") {
+		t.Fatalf(`getDateRangeFromRequest() to = %s, %v, want today in %s`, to, err, loc)
+	}
+}
@@ -6,7 +6,12 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
+	// the timezone database for checkin timezones, the docker image has none
+	_ "time/tzdata"
 
 	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
 	"github.com/uptrace/opentelemetry-go-extra/otelzap"
@@ -22,7 +27,10 @@ import (
 	"google.golang.org/grpc/credentials"
 
 	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	dbemail "github.com/StevenWeathers/thunderdome-planning-poker/db/email"
 	"github.com/StevenWeathers/thunderdome-planning-poker/email"
+	api "github.com/StevenWeathers/thunderdome-planning-poker/http"
+	"github.com/StevenWeathers/thunderdome-planning-poker/scheduler"
 	"go.uber.org/zap"
 
 	"github.com/gorilla/mux"
@@ -67,13 +75,16 @@ type Config struct {
 }
 
 type server struct {
-	config       *Config
-	router       *mux.Router
-	email        thunderdome.EmailService
-	cookie       *securecookie.SecureCookie
-	db           *db.Service
-	logger       *otelzap.Logger
-	AlertService thunderdome.AlertDataSvc
+	config         *Config
+	router         *mux.Router
+	email          thunderdome.EmailService
+	cookie         *securecookie.SecureCookie
+	db             *db.Service
+	logger         *otelzap.Logger
+	api            *api.Service
+	jobScheduler   *scheduler.JobScheduler
+	AlertService   thunderdome.AlertDataSvc
+	CheckinService thunderdome.CheckinDataSvc
 }
 
 func main() {
@@ -91,6 +102,10 @@ func main() {
 
 	InitConfig(logger)
 
+	if len(os.Args) > 1 && !embedUseOS {
+		os.Exit(runCommand(logger, os.Args[1:]))
+	}
+
 	if viper.GetBool("otel.enabled") {
 		cleanup := initTracer(
 			logger,
@@ -134,8 +149,101 @@ func main() {
 		logger: logger,
 	}
 
-	s.email = email.New(s.config.AppDomain, s.config.PathPrefix, s.logger)
-	s.db = db.New(s.config.AdminEmail, &db.Config{
+	s.db = db.New(s.config.AdminEmail, dbConfig(), s.logger)
+
+	emailService := email.New(s.config.AppDomain, s.config.PathPrefix, s.logger)
+	var emailQueue *email.QueueWorker
+	if viper.GetBool("email.queue_enabled") {
+		emailService.Queue = &dbemail.Service{DB: s.db.DB, Logger: s.logger}
+		emailQueue = &email.QueueWorker{
+			Interval:    5 * time.Second,
+			MaxAttempts: viper.GetInt("email.queue_max_attempts"),
+			Transport:   emailService.Transport,
+			DataSvc:     emailService.Queue,
+			Logger:      s.logger,
+		}
+	}
+	s.email = emailService
+
+	s.routes()
+
+	// background jobs stop once shutdown begins, which waits for them to return before closing the database
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	var jobs sync.WaitGroup
+	runJob := func(run func(ctx context.Context)) {
+		jobs.Add(1)
+		go func() {
+			defer jobs.Done()
+			run(jobsCtx)
+		}()
+	}
+
+	if viper.GetBool("config.checkin_schedule_enabled") {
+		checkinScheduler := &scheduler.CheckinScheduler{
+			Interval:       time.Minute,
+			Logger:         s.logger,
+			Email:          s.email,
+			CheckinDataSvc: s.CheckinService,
+		}
+		runJob(checkinScheduler.Run)
+	}
+
+	if s.jobScheduler.Enabled {
+		runJob(s.jobScheduler.Run)
+	}
+
+	if emailQueue != nil {
+		runJob(emailQueue.Run)
+	}
+
+	srv := &http.Server{
+		Handler:           s.router,
+		Addr:              fmt.Sprintf(":%s", s.config.ListenPort),
+		WriteTimeout:      time.Duration(viper.GetInt("http.write_timeout")) * time.Second,
+		ReadTimeout:       time.Duration(viper.GetInt("http.read_timeout")) * time.Second,
+		IdleTimeout:       time.Duration(viper.GetInt("http.idle_timeout")) * time.Second,
+		ReadHeaderTimeout: time.Duration(viper.GetInt("http.read_header_timeout")) * time.Second,
+	}
+
+	go func() {
+		s.logger.Info("Access the WebUI via 127.0.0.1:" + s.config.ListenPort)
+
+		err := srv.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			s.logger.Fatal(err.Error())
+		}
+	}()
+
+	// metrics served on their own address so they aren't exposed with the application
+	var metricsSrv *http.Server
+	if viper.GetBool("metrics.enabled") && viper.GetString("metrics.listen_address") != "" {
+		metricsRouter := http.NewServeMux()
+		metricsRouter.Handle("/metrics", s.api.MetricsHandler())
+		metricsSrv = &http.Server{
+			Handler:           metricsRouter,
+			Addr:              viper.GetString("metrics.listen_address"),
+			ReadHeaderTimeout: time.Duration(viper.GetInt("http.read_header_timeout")) * time.Second,
+		}
+
+		go func() {
+			err := metricsSrv.ListenAndServe()
+			if err != nil && err != http.ErrServerClosed {
+				s.logger.Fatal(err.Error())
+			}
+		}()
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	<-stop
+
+	stopJobs()
+	s.shutdown(srv, metricsSrv, &jobs, time.Duration(viper.GetInt("http.shutdown_timeout"))*time.Second)
+}
+
+// dbConfig gets the database configuration
+func dbConfig() *db.Config {
+	return &db.Config{
 		Host:            viper.GetString("db.host"),
 		Port:            viper.GetInt("db.port"),
 		User:            viper.GetString("db.user"),
@@ -146,24 +254,46 @@ func main() {
 		MaxIdleConns:    viper.GetInt("db.max_idle_conns"),
 		MaxOpenConns:    viper.GetInt("db.max_open_conns"),
 		ConnMaxLifetime: viper.GetInt("db.conn_max_lifetime"),
-	}, s.logger)
+	}
+}
 
-	s.routes()
+// shutdown gracefully stops the server, it stops accepting new connections and drains in-flight requests,
+// closes websocket connections with a reconnect close code, waits for the background jobs to stop
+// then closes the database connection pool
+func (s *server) shutdown(srv *http.Server, metricsSrv *http.Server, jobs *sync.WaitGroup, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
-	srv := &http.Server{
-		Handler:           s.router,
-		Addr:              fmt.Sprintf(":%s", s.config.ListenPort),
-		WriteTimeout:      time.Duration(viper.GetInt("http.write_timeout")) * time.Second,
-		ReadTimeout:       time.Duration(viper.GetInt("http.read_timeout")) * time.Second,
-		IdleTimeout:       time.Duration(viper.GetInt("http.idle_timeout")) * time.Second,
-		ReadHeaderTimeout: time.Duration(viper.GetInt("http.read_header_timeout")) * time.Second,
+	s.logger.Ctx(ctx).Info("shutting down")
+	s.api.BeginShutdown()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		s.logger.Ctx(ctx).Error("http server shutdown error", zap.Error(err))
 	}
 
-	s.logger.Info("Access the WebUI via 127.0.0.1:" + s.config.ListenPort)
+	if metricsSrv != nil {
+		if err := metricsSrv.Shutdown(ctx); err != nil {
+			s.logger.Ctx(ctx).Error("metrics server shutdown error", zap.Error(err))
+		}
+	}
 
-	err := srv.ListenAndServe()
-	if err != nil {
-		s.logger.Fatal(err.Error())
+	if err := s.api.Shutdown(ctx); err != nil {
+		s.logger.Ctx(ctx).Error("websocket shutdown error", zap.Error(err))
+	}
+
+	jobsDone := make(chan struct{})
+	go func() {
+		jobs.Wait()
+		close(jobsDone)
+	}()
+	select {
+	case <-jobsDone:
+	case <-ctx.Done():
+		s.logger.Ctx(ctx).Warn("background jobs still running at the shutdown timeout")
+	}
+
+	if err := s.db.Close(); err != nil {
+		s.logger.Ctx(ctx).Error("database close error", zap.Error(err))
 	}
 }
 
@@ -0,0 +1,29 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestHandler makes sure the Thunderdome metrics are exposed along with the Go runtime and process metrics
+func TestHandler(t *testing.T) {
+	WebsocketConnections.WithLabelValues("poker").Inc()
+	EmailSendFailures.Inc()
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+
+	for _, expected := range []string{
+		`thunderdome_websocket_connections{hub="poker"} 1`,
+		"thunderdome_email_send_failures_total 1",
+		"go_goroutines",
+		"process_cpu_seconds_total",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected the metrics to contain %s", expected)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// HTTPRequestDuration the latency of HTTP requests by method, matched route template and status code
+	HTTPRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "thunderdome_http_request_duration_seconds",
+		Help:    "Duration of HTTP requests by method, route and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "code"})
+	// WebsocketConnections the active websocket connections by hub e.g. poker
+	WebsocketConnections = factory.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "thunderdome_websocket_connections",
+		Help: "Active websocket connections by hub.",
+	}, []string{"hub"})
+	// HubBroadcastDrops the connections dropped by a hub because their send buffer was full
+	HubBroadcastDrops = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "thunderdome_hub_broadcast_drops_total",
+		Help: "Connections dropped by a hub broadcast because their send buffer was full.",
+	}, []string{"hub"})
+	// EventDuration the latency of websocket event handlers by hub and event type
+	EventDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "thunderdome_event_duration_seconds",
+		Help:    "Duration of websocket event handlers by hub and event type.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"hub", "event"})
+	// EventErrors the websocket event handler errors by hub and event type
+	EventErrors = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "thunderdome_event_errors_total",
+		Help: "Websocket event handler errors by hub and event type.",
+	}, []string{"hub", "event"})
+	// EmailSendFailures the emails that failed to send
+	EmailSendFailures = factory.NewCounter(prometheus.CounterOpts{
+		Name: "thunderdome_email_send_failures_total",
+		Help: "Emails that failed to send.",
+	})
+)
+
+// ObserveEvent records the latency of a websocket event handler started at Start and whether it failed
+func ObserveEvent(Hub string, Event string, Start time.Time, Failed bool) {
+	EventDuration.WithLabelValues(Hub, Event).Observe(time.Since(Start).Seconds())
+	if Failed {
+		EventErrors.WithLabelValues(Hub, Event).Inc()
+	}
+}
@@ -0,0 +1,245 @@
+// Package ratelimit provides token bucket request rate limiting of the Thunderdome API
+// per API key, user or IP address with limits configurable per route group
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+
+	"go.uber.org/zap"
+)
+
+// Limit the requests allowed per period, a bucket holds up to Requests tokens
+// and is continuously refilled at Requests per Period
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// unlimited whether the limit doesn't restrict requests
+func (l Limit) unlimited() bool {
+	return l.Requests <= 0 || l.Period <= 0
+}
+
+// refillRate the tokens refilled per second
+func (l Limit) refillRate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// ParseLimits parses route group limits formatted as group=requests/seconds separated by commas,
+// e.g. auth=30/60,battles=600/60
+func ParseLimits(Value string) (map[string]Limit, error) {
+	Limits := make(map[string]Limit)
+
+	for _, group := range strings.Split(Value, ",") {
+		group = strings.TrimSpace(group)
+		if group == "" {
+			continue
+		}
+
+		nameLimit := strings.SplitN(group, "=", 2)
+		if len(nameLimit) != 2 {
+			return nil, fmt.Errorf("invalid rate limit group %q", group)
+		}
+		requestsPeriod := strings.SplitN(nameLimit[1], "/", 2)
+		if len(requestsPeriod) != 2 {
+			return nil, fmt.Errorf("invalid rate limit group %q", group)
+		}
+
+		requests, err := strconv.Atoi(strings.TrimSpace(requestsPeriod[0]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate limit group %q requests: %v", group, err)
+		}
+		seconds, err := strconv.Atoi(strings.TrimSpace(requestsPeriod[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate limit group %q seconds: %v", group, err)
+		}
+
+		Limits[strings.TrimSpace(nameLimit[0])] = Limit{
+			Requests: requests,
+			Period:   time.Duration(seconds) * time.Second,
+		}
+	}
+
+	return Limits, nil
+}
+
+// Result the outcome of taking a request token
+type Result struct {
+	Allowed bool
+	Limit   Limit
+	// Remaining the requests remaining before being limited
+	Remaining int
+	// Reset the time until the bucket is full again
+	Reset time.Duration
+	// RetryAfter the time until a request will be allowed again, zero when allowed
+	RetryAfter time.Duration
+}
+
+// Limiter rate limits requests per key using token buckets stored by the DataSvc,
+// the database for limits shared across replicas or a MemoryStore for a single instance
+type Limiter struct {
+	// Default the limit of route groups without their own limit
+	Default Limit
+	// Groups the limits of route groups
+	Groups map[string]Limit
+	// AllowOnError allows requests when the store fails to take a token instead of failing them
+	AllowOnError bool
+	DataSvc      thunderdome.RateLimitDataSvc
+	Logger       *otelzap.Logger
+}
+
+// GroupLimit returns the limit of the route group
+func (l *Limiter) GroupLimit(Group string) Limit {
+	if limit, ok := l.Groups[Group]; ok {
+		return limit
+	}
+
+	return l.Default
+}
+
+// Take takes a request token for the key in the route group, returning nil when the group is unlimited.
+// Store errors are logged and returned, unless AllowOnError is set then the request is treated as unlimited.
+func (l *Limiter) Take(ctx context.Context, Group string, Key string) (*Result, error) {
+	if l == nil || Key == "" {
+		return nil, nil
+	}
+
+	limit := l.GroupLimit(Group)
+	if limit.unlimited() {
+		return nil, nil
+	}
+
+	b, err := l.DataSvc.RateLimitTake(ctx, bucketKey(Group, Key), limit.Requests, limit.refillRate())
+	if err != nil {
+		l.Logger.Ctx(ctx).Error("rate limit take error", zap.Error(err), zap.String("group", Group))
+		if l.AllowOnError {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return newResult(limit, b), nil
+}
+
+// maxKeyLength the longest bucket key stored as is, the length of the rate_limit_bucket key column
+const maxKeyLength = 256
+
+// bucketKey the key of the route groups bucket, hashed when too long to be stored
+func bucketKey(Group string, Key string) string {
+	key := Group + ":" + Key
+	if len(key) <= maxKeyLength {
+		return key
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// newResult calculates the result of the limit from the bucket
+func newResult(Limit Limit, Bucket *thunderdome.RateLimitBucket) *Result {
+	rate := Limit.refillRate()
+	r := &Result{
+		Allowed:   Bucket.Allowed,
+		Limit:     Limit,
+		Remaining: int(math.Floor(Bucket.Tokens)),
+		Reset:     secondsDuration((float64(Limit.Requests) - Bucket.Tokens) / rate),
+	}
+	if r.Remaining < 0 {
+		r.Remaining = 0
+	}
+	if !r.Allowed {
+		r.RetryAfter = secondsDuration((1 - Bucket.Tokens) / rate)
+	}
+
+	return r
+}
+
+func secondsDuration(Seconds float64) time.Duration {
+	if Seconds <= 0 {
+		return 0
+	}
+
+	return time.Duration(Seconds * float64(time.Second))
+}
+
+// how often the memory store removes full buckets
+const memoryPruneInterval = time.Minute
+
+type memoryBucket struct {
+	tokens   float64
+	capacity int
+	rate     float64
+	updated  time.Time
+}
+
+// refill returns the buckets tokens refilled for the time elapsed since it was last updated
+func (b *memoryBucket) refill(now time.Time) float64 {
+	return math.Min(float64(b.capacity), b.tokens+now.Sub(b.updated).Seconds()*b.rate)
+}
+
+// MemoryStore an in-memory implementation of thunderdome.RateLimitDataSvc
+// for deployments running a single instance
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+// NewMemoryStore creates an in-memory rate limit store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:   make(map[string]*memoryBucket),
+		lastPrune: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// RateLimitTake takes a request token from the keys bucket
+func (m *MemoryStore) RateLimitTake(ctx context.Context, Key string, Capacity int, RefillRate float64) (*thunderdome.RateLimitBucket, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if now.Sub(m.lastPrune) >= memoryPruneInterval {
+		m.prune(now)
+	}
+
+	b, ok := m.buckets[Key]
+	if !ok {
+		b = &memoryBucket{tokens: float64(Capacity), updated: now}
+		m.buckets[Key] = b
+	}
+	b.capacity = Capacity
+	b.rate = RefillRate
+	b.tokens = b.refill(now)
+	b.updated = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+
+	return &thunderdome.RateLimitBucket{Key: Key, Tokens: b.tokens, Allowed: allowed}, nil
+}
+
+// prune removes the buckets that have refilled, they are equivalent to a new bucket
+func (m *MemoryStore) prune(now time.Time) {
+	for key, b := range m.buckets {
+		if b.refill(now) >= float64(b.capacity) {
+			delete(m.buckets, key)
+		}
+	}
+	m.lastPrune = now
+}
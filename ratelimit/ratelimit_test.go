@@ -0,0 +1,106 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+
+	"go.uber.org/zap"
+)
+
+type failingStore struct{}
+
+func (failingStore) RateLimitTake(ctx context.Context, Key string, Capacity int, RefillRate float64) (*thunderdome.RateLimitBucket, error) {
+	return nil, errors.New("connection refused")
+}
+
+// TestLimiterTakeStoreError makes sure store errors only allow requests when configured to
+func TestLimiterTakeStoreError(t *testing.T) {
+	l := &Limiter{
+		Default: Limit{Requests: 3, Period: time.Second},
+		DataSvc: failingStore{},
+		Logger:  otelzap.New(zap.NewNop()),
+	}
+
+	if _, err := l.Take(context.Background(), "battles", "user:thor"); err == nil {
+		t.Fatal("expected the store error to be returned")
+	}
+
+	l.AllowOnError = true
+	if r, err := l.Take(context.Background(), "battles", "user:thor"); err != nil || r != nil {
+		t.Fatalf("expected the request to be allowed, got %v %v", r, err)
+	}
+}
+
+// TestBucketKey makes sure bucket keys fit the stores key column
+func TestBucketKey(t *testing.T) {
+	if key := bucketKey("battles", "user:thor"); key != "battles:user:thor" {
+		t.Fatalf("expected a short key to be kept, got %s", key)
+	}
+
+	long := bucketKey("battles", "ip:"+strings.Repeat("9", 300))
+	if len(long) > maxKeyLength || !strings.HasPrefix(long, "sha256:") {
+		t.Fatalf("expected a long key to be hashed, got %s", long)
+	}
+	if long == bucketKey("battles", "ip:"+strings.Repeat("8", 300)) {
+		t.Fatal("expected different long keys to hash differently")
+	}
+}
+
+func TestMemoryStoreRateLimitTake(t *testing.T) {
+	now := time.Now()
+	m := NewMemoryStore()
+	m.now = func() time.Time { return now }
+	limit := Limit{Requests: 3, Period: 3 * time.Second}
+
+	take := func() *Result {
+		b, err := m.RateLimitTake(context.Background(), "battles:thor", limit.Requests, limit.refillRate())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return newResult(limit, b)
+	}
+
+	for i := 2; i >= 0; i-- {
+		r := take()
+		if !r.Allowed || r.Remaining != i {
+			t.Fatalf("expected allowed with %d remaining, got %v %d", i, r.Allowed, r.Remaining)
+		}
+	}
+
+	r := take()
+	if r.Allowed || r.RetryAfter != time.Second {
+		t.Fatalf("expected limited with retry after 1s, got %v %s", r.Allowed, r.RetryAfter)
+	}
+
+	now = now.Add(time.Second)
+	if r := take(); !r.Allowed || r.Remaining != 0 || r.Reset != 3*time.Second {
+		t.Fatalf("expected allowed after refill with 0 remaining and reset 3s, got %v %d %s", r.Allowed, r.Remaining, r.Reset)
+	}
+
+	now = now.Add(time.Hour)
+	if r := take(); !r.Allowed || r.Remaining != 2 {
+		t.Fatalf("expected a full bucket, got %v %d", r.Allowed, r.Remaining)
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+	limits, err := ParseLimits("auth=30/60, battles=600/60,")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if limits["auth"] != (Limit{Requests: 30, Period: time.Minute}) ||
+		limits["battles"] != (Limit{Requests: 600, Period: time.Minute}) || len(limits) != 2 {
+		t.Fatalf("unexpected limits %v", limits)
+	}
+
+	if _, err := ParseLimits("auth=30"); err == nil {
+		t.Fatal("expected invalid limit error")
+	}
+}
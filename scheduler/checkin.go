@@ -0,0 +1,120 @@
+// Package scheduler provides background jobs for Thunderdome
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+
+	"go.uber.org/zap"
+)
+
+// CheckinScheduler sends the scheduled team checkin reminder and digest emails
+type CheckinScheduler struct {
+	Interval       time.Duration
+	Logger         *otelzap.Logger
+	Email          thunderdome.EmailService
+	CheckinDataSvc thunderdome.CheckinDataSvc
+}
+
+// Run checks the team checkin schedules every interval until the context is done
+func (s *CheckinScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.process(ctx, now)
+		}
+	}
+}
+
+// process sends any reminders and digests that are due for each enabled schedule
+func (s *CheckinScheduler) process(ctx context.Context, now time.Time) {
+	schedules, err := s.CheckinDataSvc.CheckinScheduleListEnabled(ctx)
+	if err != nil {
+		s.Logger.Ctx(ctx).Error("checkin scheduler list schedules error", zap.Error(err))
+		return
+	}
+
+	for _, schedule := range schedules {
+		loc, err := time.LoadLocation(schedule.TimeZone)
+		if err != nil {
+			s.Logger.Ctx(ctx).Error("checkin scheduler invalid time zone",
+				zap.String("team_id", schedule.TeamId), zap.String("time_zone", schedule.TimeZone))
+			continue
+		}
+		local := now.In(loc)
+		if !scheduledDay(schedule.Days, local.Weekday()) {
+			continue
+		}
+		date := local.Format("2006-01-02")
+		clock := local.Format("15:04")
+
+		// reminders are only useful before the days digest goes out
+		if clock >= schedule.ReminderTime && clock < schedule.DigestTime && schedule.LastReminderDate != date {
+			s.sendReminders(ctx, schedule, date)
+		}
+		if clock >= schedule.DigestTime && schedule.LastDigestDate != date {
+			s.sendDigest(ctx, schedule, date)
+		}
+	}
+}
+
+func (s *CheckinScheduler) sendReminders(ctx context.Context, schedule *thunderdome.CheckinSchedule, date string) {
+	claimed, err := s.CheckinDataSvc.CheckinScheduleClaim(ctx, schedule.TeamId, thunderdome.CheckinNotificationReminder, date)
+	if err != nil || !claimed {
+		return
+	}
+
+	users, err := s.CheckinDataSvc.CheckinNotificationRecipients(ctx, schedule.TeamId, date, schedule.TimeZone, true)
+	if err != nil {
+		s.Logger.Ctx(ctx).Error("checkin scheduler reminder recipients error",
+			zap.String("team_id", schedule.TeamId), zap.Error(err))
+		return
+	}
+
+	for _, user := range users {
+		_ = s.Email.SendCheckinReminder(ctx, user.Name, user.Email, schedule.TeamName, schedule.TeamId)
+	}
+}
+
+func (s *CheckinScheduler) sendDigest(ctx context.Context, schedule *thunderdome.CheckinSchedule, date string) {
+	claimed, err := s.CheckinDataSvc.CheckinScheduleClaim(ctx, schedule.TeamId, thunderdome.CheckinNotificationDigest, date)
+	if err != nil || !claimed {
+		return
+	}
+
+	checkins, err := s.CheckinDataSvc.CheckinList(ctx, schedule.TeamId, date, schedule.TimeZone)
+	if err != nil {
+		s.Logger.Ctx(ctx).Error("checkin scheduler digest checkins error",
+			zap.String("team_id", schedule.TeamId), zap.Error(err))
+		return
+	}
+
+	users, err := s.CheckinDataSvc.CheckinNotificationRecipients(ctx, schedule.TeamId, date, schedule.TimeZone, false)
+	if err != nil {
+		s.Logger.Ctx(ctx).Error("checkin scheduler digest recipients error",
+			zap.String("team_id", schedule.TeamId), zap.Error(err))
+		return
+	}
+
+	for _, user := range users {
+		_ = s.Email.SendCheckinDigest(ctx, user.Name, user.Email, schedule.TeamName, schedule.TeamId, date, checkins)
+	}
+}
+
+func scheduledDay(days []int, weekday time.Weekday) bool {
+	for _, day := range days {
+		if day == int(weekday) {
+			return true
+		}
+	}
+
+	return false
+}
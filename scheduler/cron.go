@@ -0,0 +1,165 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronMacros the supported shorthand cron expressions
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronField the bounds of a cron expression field
+type cronField struct {
+	name string
+	min  int
+	max  int
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12},
+	{name: "day of week", min: 0, max: 7},
+}
+
+// Cron a parsed standard five field cron expression (minute hour day-of-month month day-of-week)
+type Cron struct {
+	expression string
+	fields     [5]map[int]bool
+	// whether the day of month and day of week fields are restricted, when both are a day matching either runs
+	domRestricted bool
+	dowRestricted bool
+}
+
+// ParseCron parses a five field cron expression supporting *, lists, ranges and steps e.g. */15 2-4 * * 1,3
+// or a macro e.g. @daily
+func ParseCron(Expression string) (*Cron, error) {
+	expr := strings.TrimSpace(Expression)
+	if macro, ok := cronMacros[expr]; ok {
+		expr = macro
+	}
+
+	parts := strings.Fields(expr)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("invalid cron expression %q, expected 5 fields", Expression)
+	}
+
+	c := &Cron{expression: Expression}
+	for i, part := range parts {
+		values, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %v", Expression, err)
+		}
+		c.fields[i] = values
+	}
+
+	// sunday can be either 0 or 7
+	if c.fields[4][7] {
+		c.fields[4][0] = true
+	}
+	c.domRestricted = !strings.HasPrefix(parts[2], "*")
+	c.dowRestricted = !strings.HasPrefix(parts[4], "*")
+
+	return c, nil
+}
+
+// parseCronField parses the comma separated values, ranges and steps of a field
+func parseCronField(Value string, Field cronField) (map[int]bool, error) {
+	values := make(map[int]bool)
+
+	for _, item := range strings.Split(Value, ",") {
+		rangePart, step := item, 1
+		if i := strings.Index(item, "/"); i != -1 {
+			var err error
+			rangePart = item[:i]
+			step, err = strconv.Atoi(item[i+1:])
+			if err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid %s step %q", Field.name, item)
+			}
+		}
+
+		start, end := Field.min, Field.max
+		if rangePart != "*" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			start, err = strconv.Atoi(bounds[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q", Field.name, item)
+			}
+			end = start
+			if len(bounds) == 2 {
+				end, err = strconv.Atoi(bounds[1])
+				if err != nil {
+					return nil, fmt.Errorf("invalid %s %q", Field.name, item)
+				}
+			} else if step > 1 {
+				// a start with a step e.g. 5/15 runs through to the end of the field
+				end = Field.max
+			}
+		}
+
+		if start < Field.min || end > Field.max || start > end {
+			return nil, fmt.Errorf("%s %q out of range %d-%d", Field.name, item, Field.min, Field.max)
+		}
+
+		for v := start; v <= end; v += step {
+			values[v] = true
+		}
+	}
+
+	return values, nil
+}
+
+// String returns the cron expression
+func (c *Cron) String() string {
+	return c.expression
+}
+
+// Matches whether the expression matches the minute of the time
+func (c *Cron) Matches(t time.Time) bool {
+	if !c.fields[0][t.Minute()] || !c.fields[1][t.Hour()] || !c.fields[3][int(t.Month())] {
+		return false
+	}
+
+	dom := c.fields[2][t.Day()]
+	dow := c.fields[4][int(t.Weekday())]
+	if c.domRestricted && c.dowRestricted {
+		return dom || dow
+	}
+
+	return dom && dow
+}
+
+// Next returns the next minute after the time the expression matches, zero when none within 5 years
+func (c *Cron) Next(t time.Time) time.Time {
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	limit := next.AddDate(5, 0, 0)
+
+	for next.Before(limit) {
+		if !c.fields[3][int(next.Month())] {
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, next.Location())
+			continue
+		}
+		if c.Matches(next) {
+			return next
+		}
+		if !c.fields[1][next.Hour()] {
+			next = time.Date(next.Year(), next.Month(), next.Day(), next.Hour()+1, 0, 0, 0, next.Location())
+			continue
+		}
+		next = next.Add(time.Minute)
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,63 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	for _, expr := range []string{"60 * * * *", "* * * *", "*/0 * * * *", "5-1 * * * *", "* * 0 * *", "a * * * *"} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("expected %q to be invalid", expr)
+		}
+	}
+
+	c, err := ParseCron("*/15 2-3 * * 1,5")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// monday 2023-12-18
+	monday := time.Date(2023, 12, 18, 2, 30, 0, 0, time.UTC)
+	if !c.Matches(monday) {
+		t.Errorf("expected %s to match %s", c, monday)
+	}
+	if c.Matches(monday.Add(time.Minute)) || c.Matches(monday.AddDate(0, 0, 1)) {
+		t.Errorf("expected %s not to match", c)
+	}
+}
+
+func TestCronDayOfMonthOrWeek(t *testing.T) {
+	c, err := ParseCron("0 0 1 * 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first of the month or any sunday
+	if !c.Matches(time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)) || !c.Matches(time.Date(2023, 12, 17, 0, 0, 0, 0, time.UTC)) {
+		t.Error("expected the first of the month and sunday to match")
+	}
+	if c.Matches(time.Date(2023, 12, 18, 0, 0, 0, 0, time.UTC)) {
+		t.Error("expected monday the 18th not to match")
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	c, err := ParseCron("@daily")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	from := time.Date(2023, 12, 31, 13, 45, 10, 0, time.UTC)
+	if next := c.Next(from); !next.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected next run %s", next)
+	}
+
+	c, err = ParseCron("30 4 29 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next := c.Next(from); !next.Equal(time.Date(2024, 2, 29, 4, 30, 0, 0, time.UTC)) {
+		t.Errorf("unexpected next run %s", next)
+	}
+}
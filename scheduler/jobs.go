@@ -0,0 +1,155 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+
+	"go.uber.org/zap"
+)
+
+// maxCatchUp the most missed minutes checked for due jobs after a delayed tick
+const maxCatchUp = 60
+
+// Job a job run on a cron schedule, Run returns a summary of the work done recorded with the run
+type Job struct {
+	Name string
+	// Schedule nil when the job has no schedule and is disabled
+	Schedule *Cron
+	Run      func(ctx context.Context) (string, error)
+}
+
+// JobScheduler runs the scheduled jobs on the replica holding the leader lock,
+// recording each run so a schedule minute is only ever run once
+type JobScheduler struct {
+	Interval   time.Duration
+	Enabled    bool
+	Logger     *otelzap.Logger
+	JobDataSvc thunderdome.JobDataSvc
+	Jobs       []Job
+
+	lock thunderdome.JobLeaderLock
+	// the last schedule minute checked for due jobs while leader
+	lastMinute time.Time
+}
+
+// Run checks for due jobs every interval until the context is done, then releases the leader lock
+func (s *JobScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if s.lock != nil {
+				s.lock.Release(context.Background())
+				s.lock = nil
+			}
+			return
+		case now := <-ticker.C:
+			s.process(ctx, now)
+		}
+	}
+}
+
+// leader whether this replica holds the leader lock, trying to acquire it when not
+func (s *JobScheduler) leader(ctx context.Context) bool {
+	if s.lock != nil {
+		if s.lock.Held(ctx) {
+			return true
+		}
+		s.Logger.Ctx(ctx).Warn("job scheduler lost leader lock")
+		s.lock.Release(ctx)
+		s.lock = nil
+	}
+
+	lock, err := s.JobDataSvc.JobLeaderLockAcquire(ctx)
+	if err != nil || lock == nil {
+		s.lastMinute = time.Time{}
+		return false
+	}
+	s.lock = lock
+
+	// runs left running by the previous leader will never complete
+	if failed, err := s.JobDataSvc.JobRunsFailRunning(ctx, "interrupted, the job scheduler stopped before it completed"); err == nil && failed > 0 {
+		s.Logger.Ctx(ctx).Warn("job scheduler failed interrupted job runs", zap.Int64("count", failed))
+	}
+
+	return true
+}
+
+// process runs the jobs due in each minute since the last check
+func (s *JobScheduler) process(ctx context.Context, now time.Time) {
+	if !s.leader(ctx) {
+		return
+	}
+
+	minute := now.Truncate(time.Minute)
+	from := s.lastMinute.Add(time.Minute)
+	if s.lastMinute.IsZero() || minute.Sub(from) > maxCatchUp*time.Minute {
+		from = minute
+	}
+	s.lastMinute = minute
+
+	for m := from; !m.After(minute); m = m.Add(time.Minute) {
+		for _, job := range s.Jobs {
+			if job.Schedule != nil && job.Schedule.Matches(m) {
+				s.runJob(ctx, job, m)
+			}
+		}
+	}
+}
+
+// runJob runs the job for the scheduled minute unless another replica already has
+func (s *JobScheduler) runJob(ctx context.Context, job Job, scheduled time.Time) {
+	run, err := s.JobDataSvc.JobRunStart(ctx, job.Name, scheduled)
+	if err != nil || run == nil {
+		return
+	}
+
+	s.Logger.Ctx(ctx).Info("job scheduler running job", zap.String("job_name", job.Name))
+	status := thunderdome.JobRunStatusSucceeded
+	result, err := job.Run(ctx)
+	if err != nil {
+		s.Logger.Ctx(ctx).Error("job scheduler job error", zap.String("job_name", job.Name), zap.Error(err))
+		status = thunderdome.JobRunStatusFailed
+		result = err.Error()
+	}
+
+	// record the result even if shutdown began during the run
+	_ = s.JobDataSvc.JobRunComplete(context.Background(), run.Id, status, result)
+}
+
+// Status returns each jobs schedule, next run and latest run
+func (s *JobScheduler) Status(ctx context.Context) ([]*thunderdome.Job, error) {
+	runs, err := s.JobDataSvc.JobRunsLatest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	lastRuns := make(map[string]*thunderdome.JobRun, len(runs))
+	for _, run := range runs {
+		lastRuns[run.JobName] = run
+	}
+
+	now := time.Now()
+	jobs := make([]*thunderdome.Job, 0, len(s.Jobs))
+	for _, job := range s.Jobs {
+		j := &thunderdome.Job{
+			Name:    job.Name,
+			Enabled: s.Enabled && job.Schedule != nil,
+			LastRun: lastRuns[job.Name],
+		}
+		if job.Schedule != nil {
+			j.Schedule = job.Schedule.String()
+			if next := job.Schedule.Next(now); j.Enabled && !next.IsZero() {
+				j.NextRun = &next
+			}
+		}
+		jobs = append(jobs, j)
+	}
+
+	return jobs, nil
+}
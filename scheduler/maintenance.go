@@ -0,0 +1,120 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/throttle"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// Maintenance job names
+const (
+	JobCleanGuests         = "clean_guests"
+	JobCleanBattles        = "clean_battles"
+	JobCleanRetros         = "clean_retros"
+	JobCleanStoryboards    = "clean_storyboards"
+	JobLowercaseUserEmails = "lowercase_emails"
+	JobCleanAuthAttempts   = "clean_auth_attempts"
+)
+
+// Maintenance the data cleanup jobs also triggered manually by the admin maintenance endpoints
+type Maintenance struct {
+	Email              thunderdome.EmailService
+	UserDataSvc        thunderdome.UserDataSvc
+	PokerDataSvc       thunderdome.PokerDataSvc
+	RetroDataSvc       thunderdome.RetroDataSvc
+	StoryboardDataSvc  thunderdome.StoryboardDataSvc
+	AuthAttemptDataSvc thunderdome.AuthAttemptDataSvc
+	GuestsDaysOld      int
+	BattlesDaysOld     int
+	RetrosDaysOld      int
+	StoryboardsDaysOld int
+}
+
+// Jobs returns the maintenance jobs with the schedules by job name, a job without a schedule is disabled
+func (m *Maintenance) Jobs(Schedules map[string]*Cron) []Job {
+	return []Job{
+		{Name: JobCleanGuests, Schedule: Schedules[JobCleanGuests], Run: m.cleanGuests},
+		{Name: JobCleanBattles, Schedule: Schedules[JobCleanBattles], Run: m.cleanBattles},
+		{Name: JobCleanRetros, Schedule: Schedules[JobCleanRetros], Run: m.cleanRetros},
+		{Name: JobCleanStoryboards, Schedule: Schedules[JobCleanStoryboards], Run: m.cleanStoryboards},
+		{Name: JobLowercaseUserEmails, Schedule: Schedules[JobLowercaseUserEmails], Run: m.lowercaseUserEmails},
+		{Name: JobCleanAuthAttempts, Schedule: Schedules[JobCleanAuthAttempts], Run: m.cleanAuthAttempts},
+	}
+}
+
+// Run runs the maintenance job by name e.g. when triggered manually, returning its result
+func (m *Maintenance) Run(ctx context.Context, Name string) (string, error) {
+	for _, job := range m.Jobs(nil) {
+		if job.Name == Name {
+			return job.Run(ctx)
+		}
+	}
+
+	return "", fmt.Errorf("unknown maintenance job %s", Name)
+}
+
+func (m *Maintenance) cleanGuests(ctx context.Context) (string, error) {
+	if err := m.UserDataSvc.CleanGuests(ctx, m.GuestsDaysOld); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("deleted guests inactive for %d days", m.GuestsDaysOld), nil
+}
+
+func (m *Maintenance) cleanBattles(ctx context.Context) (string, error) {
+	if err := m.PokerDataSvc.PurgeOldGames(ctx, m.BattlesDaysOld); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("deleted battles inactive for %d days", m.BattlesDaysOld), nil
+}
+
+func (m *Maintenance) cleanRetros(ctx context.Context) (string, error) {
+	if err := m.RetroDataSvc.CleanRetros(ctx, m.RetrosDaysOld); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("deleted retros inactive for %d days", m.RetrosDaysOld), nil
+}
+
+func (m *Maintenance) cleanStoryboards(ctx context.Context) (string, error) {
+	if err := m.StoryboardDataSvc.CleanStoryboards(ctx, m.StoryboardsDaysOld); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("deleted storyboards inactive for %d days", m.StoryboardsDaysOld), nil
+}
+
+// cleanAuthAttempts deletes the failed authentication attempts no longer counted towards a lockout
+func (m *Maintenance) cleanAuthAttempts(ctx context.Context) (string, error) {
+	deleted, err := m.AuthAttemptDataSvc.AuthAttemptPrune(ctx, throttle.Window)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("deleted %d expired failed authentication attempts", deleted), nil
+}
+
+// lowercaseUserEmails lowercases user emails then merges the resulting duplicate accounts,
+// emailing the affected users of the change
+func (m *Maintenance) lowercaseUserEmails(ctx context.Context) (string, error) {
+	lowercasedUsers, err := m.UserDataSvc.LowercaseUserEmails(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, u := range lowercasedUsers {
+		_ = m.Email.SendEmailUpdate(u.Name, u.Email)
+	}
+
+	mergedUsers, err := m.UserDataSvc.MergeDuplicateAccounts(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, u := range mergedUsers {
+		_ = m.Email.SendMergedUpdate(u.Name, u.Email)
+	}
+
+	return fmt.Sprintf("lowercased %d user emails, merged %d accounts", len(lowercasedUsers), len(mergedUsers)), nil
+}
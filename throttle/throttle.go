@@ -0,0 +1,249 @@
+// Package throttle provides brute-force protection of Thunderdome authentication
+// by tracking failed attempts per account, IP address and entity with exponential backoff lockouts
+package throttle
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+
+	"go.uber.org/zap"
+)
+
+// Scopes failed attempts are tracked in
+const (
+	// ScopeIP failed attempts of any kind from an IP address
+	ScopeIP = "ip"
+	// ScopeLogin failed password logins of an account email
+	ScopeLogin = "login"
+	// ScopeMFA failed MFA logins of a user
+	ScopeMFA = "mfa"
+	// ScopePasswordReset password reset requests of an account email
+	ScopePasswordReset = "password_reset"
+	// ScopeJoinCode wrong join codes for a battle, retro or storyboard
+	ScopeJoinCode = "join_code"
+)
+
+// Window failures older than the window are no longer counted and can be pruned
+const Window = 24 * time.Hour
+
+// Config the brute-force protection thresholds, an attempts threshold of zero disables its protection
+type Config struct {
+	// AccountAttempts failed attempts allowed per account or entity before it's temporarily locked
+	AccountAttempts int
+	// IPAttempts failed attempts allowed per IP address before it's temporarily locked
+	IPAttempts int
+	// JoinCodeAttempts wrong join codes allowed per websocket connection before it's closed
+	JoinCodeAttempts int
+	// Lockout the first lockout duration, doubled with each further failed attempt
+	Lockout time.Duration
+	// MaxLockout the maximum lockout duration
+	MaxLockout time.Duration
+	// TrustedProxies the proxy networks whose forwarded client address headers are honoured
+	TrustedProxies []*net.IPNet
+}
+
+// Limiter tracks failed authentication attempts in the database so lockouts apply across replicas
+type Limiter struct {
+	Config  Config
+	DataSvc thunderdome.AuthAttemptDataSvc
+	Logger  *otelzap.Logger
+}
+
+// Backoff returns the lockout duration after the number of failures given the allowed attempts,
+// zero while the failures are under the allowed attempts
+func (c Config) Backoff(Failures int, Attempts int) time.Duration {
+	if Attempts <= 0 || Failures < Attempts {
+		return 0
+	}
+
+	lockout := c.Lockout
+	for i := Attempts; i < Failures && lockout < c.MaxLockout; i++ {
+		lockout *= 2
+	}
+	if lockout > c.MaxLockout {
+		lockout = c.MaxLockout
+	}
+
+	return lockout
+}
+
+// Locked returns the remaining lockout duration of the key, zero when not locked.
+// Errors are logged and treated as not locked so an unavailable database doesn't block logins.
+func (l *Limiter) Locked(ctx context.Context, Scope string, Key string) time.Duration {
+	if l == nil || Key == "" {
+		return 0
+	}
+
+	a, err := l.DataSvc.AuthAttemptGet(ctx, Scope, Key)
+	if err != nil {
+		l.Logger.Ctx(ctx).Error("throttle locked check error", zap.Error(err), zap.String("scope", Scope))
+		return 0
+	}
+
+	if a.LockedUntil == nil {
+		return 0
+	}
+
+	return time.Until(*a.LockedUntil)
+}
+
+// Fail records a failed attempt of the key locking it once the allowed attempts are exceeded,
+// returns the lockout duration and whether this failure caused the key to be locked out for the first time
+func (l *Limiter) Fail(ctx context.Context, Scope string, Key string, Attempts int) (time.Duration, bool) {
+	if l == nil || Key == "" || Attempts <= 0 {
+		return 0, false
+	}
+
+	a, err := l.DataSvc.AuthAttemptFail(ctx, Scope, Key, Window)
+	if err != nil {
+		l.Logger.Ctx(ctx).Error("throttle fail error", zap.Error(err), zap.String("scope", Scope))
+		return 0, false
+	}
+
+	lockout := l.Config.Backoff(a.Failures, Attempts)
+	if lockout == 0 {
+		return 0, false
+	}
+
+	if err := l.DataSvc.AuthAttemptLock(ctx, Scope, Key, time.Now().Add(lockout)); err != nil {
+		l.Logger.Ctx(ctx).Error("throttle lock error", zap.Error(err), zap.String("scope", Scope))
+		return 0, false
+	}
+
+	l.Logger.Ctx(ctx).Warn("throttle key locked out",
+		zap.String("scope", Scope), zap.Int("failures", a.Failures), zap.Duration("lockout", lockout))
+
+	return lockout, a.Failures == Attempts
+}
+
+// Reset clears the failed attempts of the key after a successful attempt
+func (l *Limiter) Reset(ctx context.Context, Scope string, Key string) {
+	if l == nil || Key == "" {
+		return
+	}
+
+	if err := l.DataSvc.AuthAttemptReset(ctx, Scope, Key); err != nil {
+		l.Logger.Ctx(ctx).Error("throttle reset error", zap.Error(err), zap.String("scope", Scope))
+	}
+}
+
+// JoinCodeLocked returns whether the IP address is locked out from further join code attempts of the entity,
+// or from attempts of any kind, the lockout is per IP address and entity so other users of the entity aren't locked out
+func (l *Limiter) JoinCodeLocked(ctx context.Context, IP string, EntityID string) bool {
+	return l.Locked(ctx, ScopeIP, IP) > 0 || l.Locked(ctx, ScopeJoinCode, joinCodeKey(IP, EntityID)) > 0
+}
+
+// JoinCodeFailed records a wrong join code for the entity from the IP address,
+// returns whether the connection should be closed as the IP address is now locked out
+// or the connection exceeded the wrong join codes allowed per connection
+func (l *Limiter) JoinCodeFailed(ctx context.Context, IP string, EntityID string, ConnectionFailures int) bool {
+	if l == nil {
+		return false
+	}
+
+	ipLockout, _ := l.Fail(ctx, ScopeIP, IP, l.Config.IPAttempts)
+	lockout, _ := l.Fail(ctx, ScopeJoinCode, joinCodeKey(IP, EntityID), l.Config.AccountAttempts)
+
+	return ipLockout > 0 || lockout > 0 || (l.Config.JoinCodeAttempts > 0 && ConnectionFailures >= l.Config.JoinCodeAttempts)
+}
+
+// JoinCodeReset clears the wrong join codes for the entity from the IP address after the correct one is entered
+func (l *Limiter) JoinCodeReset(ctx context.Context, IP string, EntityID string) {
+	l.Reset(ctx, ScopeJoinCode, joinCodeKey(IP, EntityID))
+}
+
+// joinCodeKey the key wrong join codes are tracked under, the entity and the IP address entering them
+func joinCodeKey(IP string, EntityID string) string {
+	if IP == "" || EntityID == "" {
+		return ""
+	}
+
+	return EntityID + "|" + IP
+}
+
+// ParseTrustedProxies parses the comma separated list of trusted proxy IP addresses and CIDR networks
+func ParseTrustedProxies(Proxies string) ([]*net.IPNet, error) {
+	Networks := make([]*net.IPNet, 0)
+
+	for _, proxy := range strings.Split(Proxies, ",") {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			Networks = append(Networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q", proxy)
+		}
+		Networks = append(Networks, network)
+	}
+
+	return Networks, nil
+}
+
+// trusted whether the IP address is one of the trusted proxies
+func (l *Limiter) trusted(IP string) bool {
+	if l == nil {
+		return false
+	}
+
+	ip := net.ParseIP(IP)
+	if ip == nil {
+		return false
+	}
+	for _, network := range l.Config.TrustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ClientIP gets the client IP of the request, the forwarded client address headers are only honoured
+// when the request comes from a trusted proxy, taking the nearest untrusted address of X-Forwarded-For
+func (l *Limiter) ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	if !l.trusted(host) {
+		return host
+	}
+
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		addresses := strings.Split(forwarded, ",")
+		for i := len(addresses) - 1; i >= 0; i-- {
+			address := strings.TrimSpace(addresses[i])
+			if i == 0 || !l.trusted(address) {
+				return address
+			}
+		}
+	}
+	if realIP := r.Header.Get("X-Real-Ip"); realIP != "" {
+		return strings.TrimSpace(realIP)
+	}
+
+	return host
+}
@@ -0,0 +1,161 @@
+package throttle
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+
+	"go.uber.org/zap"
+)
+
+// testAuthAttemptDataSvc keeps the failed attempts in memory
+type testAuthAttemptDataSvc map[string]*thunderdome.AuthAttempt
+
+func (s testAuthAttemptDataSvc) AuthAttemptGet(ctx context.Context, Scope string, Key string) (*thunderdome.AuthAttempt, error) {
+	if a, ok := s[Scope+"/"+Key]; ok {
+		return a, nil
+	}
+	return &thunderdome.AuthAttempt{Scope: Scope, Key: Key}, nil
+}
+
+func (s testAuthAttemptDataSvc) AuthAttemptFail(ctx context.Context, Scope string, Key string, Window time.Duration) (*thunderdome.AuthAttempt, error) {
+	a, _ := s.AuthAttemptGet(ctx, Scope, Key)
+	a.Failures++
+	s[Scope+"/"+Key] = a
+	return a, nil
+}
+
+func (s testAuthAttemptDataSvc) AuthAttemptLock(ctx context.Context, Scope string, Key string, Until time.Time) error {
+	s[Scope+"/"+Key].LockedUntil = &Until
+	return nil
+}
+
+func (s testAuthAttemptDataSvc) AuthAttemptReset(ctx context.Context, Scope string, Key string) error {
+	delete(s, Scope+"/"+Key)
+	return nil
+}
+
+func (s testAuthAttemptDataSvc) AuthAttemptPrune(ctx context.Context, Window time.Duration) (int64, error) {
+	return 0, nil
+}
+
+func TestBackoff(t *testing.T) {
+	c := Config{Lockout: time.Minute, MaxLockout: 10 * time.Minute}
+
+	tests := []struct {
+		failures int
+		attempts int
+		want     time.Duration
+	}{
+		{failures: 4, attempts: 5, want: 0},
+		{failures: 5, attempts: 5, want: time.Minute},
+		{failures: 6, attempts: 5, want: 2 * time.Minute},
+		{failures: 8, attempts: 5, want: 8 * time.Minute},
+		{failures: 9, attempts: 5, want: 10 * time.Minute},
+		{failures: 500, attempts: 5, want: 10 * time.Minute},
+		{failures: 50, attempts: 0, want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := c.Backoff(tt.failures, tt.attempts); got != tt.want {
+			t.Errorf("Backoff(%d, %d) = %s, want %s", tt.failures, tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies("10.0.0.0/8, 192.168.1.10")
+	if err != nil {
+		t.Fatalf("parse trusted proxies: %v", err)
+	}
+	l := &Limiter{Config: Config{TrustedProxies: proxies}}
+
+	tests := []struct {
+		remoteAddr string
+		forwarded  string
+		realIP     string
+		want       string
+	}{
+		{remoteAddr: "203.0.113.7:4000", want: "203.0.113.7"},
+		{remoteAddr: "203.0.113.7:4000", forwarded: "198.51.100.1", want: "203.0.113.7"},
+		{remoteAddr: "203.0.113.7:4000", realIP: "198.51.100.1", want: "203.0.113.7"},
+		{remoteAddr: "10.1.2.3:4000", forwarded: "198.51.100.1", want: "198.51.100.1"},
+		{remoteAddr: "10.1.2.3:4000", forwarded: "6.6.6.6, 198.51.100.1, 192.168.1.10", want: "198.51.100.1"},
+		{remoteAddr: "192.168.1.10:4000", realIP: "198.51.100.1", want: "198.51.100.1"},
+		{remoteAddr: "192.168.1.11:4000", realIP: "198.51.100.1", want: "192.168.1.11"},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = tt.remoteAddr
+		if tt.forwarded != "" {
+			r.Header.Set("X-Forwarded-For", tt.forwarded)
+		}
+		if tt.realIP != "" {
+			r.Header.Set("X-Real-Ip", tt.realIP)
+		}
+
+		if got := l.ClientIP(r); got != tt.want {
+			t.Errorf("ClientIP from %s forwarded %q = %s, want %s", tt.remoteAddr, tt.forwarded, got, tt.want)
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.1.2.3:4000"
+	r.Header.Set("X-Forwarded-For", "198.51.100.1")
+	if got := (*Limiter)(nil).ClientIP(r); got != "10.1.2.3" {
+		t.Errorf("expected a nil limiter to trust no proxies, got %s", got)
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	if _, err := ParseTrustedProxies("10.0.0.0/8,not-an-ip"); err == nil {
+		t.Error("expected an invalid trusted proxy to fail")
+	}
+
+	proxies, err := ParseTrustedProxies("")
+	if err != nil || len(proxies) != 0 {
+		t.Errorf("expected no trusted proxies, got %v %v", proxies, err)
+	}
+}
+
+// TestJoinCodeLockout makes sure wrong join codes only lock out the IP address entering them
+// and a correct join code only resets that IP addresses wrong join codes
+func TestJoinCodeLockout(t *testing.T) {
+	ctx := context.Background()
+	l := &Limiter{
+		Config:  Config{AccountAttempts: 3, Lockout: time.Minute, MaxLockout: time.Hour},
+		DataSvc: testAuthAttemptDataSvc{},
+		Logger:  otelzap.New(zap.NewNop()),
+	}
+
+	for i := 1; i <= 3; i++ {
+		l.JoinCodeFailed(ctx, "203.0.113.7", "asgard", i)
+	}
+	l.JoinCodeFailed(ctx, "198.51.100.1", "asgard", 1)
+
+	if !l.JoinCodeLocked(ctx, "203.0.113.7", "asgard") {
+		t.Error("expected the guessing IP address to be locked out")
+	}
+	if l.JoinCodeLocked(ctx, "198.51.100.1", "asgard") {
+		t.Error("expected another IP address not to be locked out of the entity")
+	}
+	if l.JoinCodeLocked(ctx, "203.0.113.7", "midgard") {
+		t.Error("expected the guessing IP address not to be locked out of other entities")
+	}
+
+	l.JoinCodeReset(ctx, "198.51.100.1", "asgard")
+	if !l.JoinCodeLocked(ctx, "203.0.113.7", "asgard") {
+		t.Error("expected another IP addresses correct join code not to reset the lockout")
+	}
+	l.JoinCodeFailed(ctx, "198.51.100.1", "asgard", 1)
+	l.JoinCodeFailed(ctx, "198.51.100.1", "asgard", 2)
+	if l.JoinCodeLocked(ctx, "198.51.100.1", "asgard") {
+		t.Error("expected the correct join code to reset the IP addresses wrong join codes")
+	}
+}
@@ -20,6 +20,7 @@ type Alert struct {
 type AlertDataSvc interface {
 	GetActiveAlerts(ctx context.Context) []interface{}
 	AlertsList(ctx context.Context, Limit int, Offset int) ([]*Alert, int, error)
+	AlertGet(ctx context.Context, AlertID string) (*Alert, error)
 	AlertsCreate(ctx context.Context, Name string, Type string, Content string, Active bool, AllowDismiss bool, RegisteredOnly bool) error
 	AlertsUpdate(ctx context.Context, ID string, Name string, Type string, Content string, Active bool, AllowDismiss bool, RegisteredOnly bool) error
 	AlertDelete(ctx context.Context, AlertID string) error
@@ -2,39 +2,102 @@ package thunderdome
 
 import (
 	"context"
+	"strings"
 	"time"
 )
 
-// APIKey structure
+// API key scopes, a write scope also grants its read scope,
+// organizations:admin grants managing an organizations SCIM tokens and service accounts
+const (
+	APIKeyScopePokerRead          = "poker:read"
+	APIKeyScopePokerWrite         = "poker:write"
+	APIKeyScopeRetroRead          = "retro:read"
+	APIKeyScopeRetroWrite         = "retro:write"
+	APIKeyScopeStoryboardRead     = "storyboard:read"
+	APIKeyScopeStoryboardWrite    = "storyboard:write"
+	APIKeyScopeTeamsRead          = "teams:read"
+	APIKeyScopeTeamsWrite         = "teams:write"
+	APIKeyScopeUsersRead          = "users:read"
+	APIKeyScopeUsersWrite         = "users:write"
+	APIKeyScopeAdmin              = "admin"
+	APIKeyScopeOrganizationsAdmin = "organizations:admin"
+)
+
+// APIKeyScopes all the API key scopes
+var APIKeyScopes = []string{
+	APIKeyScopePokerRead, APIKeyScopePokerWrite,
+	APIKeyScopeRetroRead, APIKeyScopeRetroWrite,
+	APIKeyScopeStoryboardRead, APIKeyScopeStoryboardWrite,
+	APIKeyScopeTeamsRead, APIKeyScopeTeamsWrite,
+	APIKeyScopeUsersRead, APIKeyScopeUsersWrite,
+	APIKeyScopeAdmin,
+	APIKeyScopeOrganizationsAdmin,
+}
+
+// APIKeyReadScopes the read only API key scopes, granted to keys created without scopes
+var APIKeyReadScopes = []string{
+	APIKeyScopePokerRead,
+	APIKeyScopeRetroRead,
+	APIKeyScopeStoryboardRead,
+	APIKeyScopeTeamsRead,
+	APIKeyScopeUsersRead,
+}
+
+// APIKey structure, a key with a TeamId is restricted to that teams routes
 type APIKey struct {
-	Id          string    `json:"id"`
-	Prefix      string    `json:"prefix"`
-	UserId      string    `json:"userId"`
-	Name        string    `json:"name"`
-	Key         string    `json:"apiKey"`
-	Active      bool      `json:"active"`
-	CreatedDate time.Time `json:"createdDate"`
-	UpdatedDate time.Time `json:"updatedDate"`
+	Id           string     `json:"id"`
+	Prefix       string     `json:"prefix"`
+	UserId       string     `json:"userId"`
+	Name         string     `json:"name"`
+	Key          string     `json:"apiKey"`
+	Active       bool       `json:"active"`
+	Scopes       []string   `json:"scopes"`
+	TeamId       string     `json:"teamId"`
+	ExpireDate   *time.Time `json:"expireDate"`
+	LastUsedDate *time.Time `json:"lastUsedDate"`
+	LastUsedIP   string     `json:"lastUsedIp"`
+	CreatedDate  time.Time  `json:"createdDate"`
+	UpdatedDate  time.Time  `json:"updatedDate"`
 }
 
 // UserAPIKey structure
 type UserAPIKey struct {
-	Id          string    `json:"id"`
-	Prefix      string    `json:"prefix"`
-	UserId      string    `json:"userId"`
-	UserEmail   string    `json:"userEmail"`
-	UserName    string    `json:"userName"`
-	Name        string    `json:"name"`
-	Key         string    `json:"apiKey"`
-	Active      bool      `json:"active"`
-	CreatedDate time.Time `json:"createdDate"`
-	UpdatedDate time.Time `json:"updatedDate"`
+	Id           string     `json:"id"`
+	Prefix       string     `json:"prefix"`
+	UserId       string     `json:"userId"`
+	UserEmail    string     `json:"userEmail"`
+	UserName     string     `json:"userName"`
+	Name         string     `json:"name"`
+	Key          string     `json:"apiKey"`
+	Active       bool       `json:"active"`
+	Scopes       []string   `json:"scopes"`
+	TeamId       string     `json:"teamId"`
+	ExpireDate   *time.Time `json:"expireDate"`
+	LastUsedDate *time.Time `json:"lastUsedDate"`
+	LastUsedIP   string     `json:"lastUsedIp"`
+	CreatedDate  time.Time  `json:"createdDate"`
+	UpdatedDate  time.Time  `json:"updatedDate"`
+}
+
+// HasScope checks whether the key was granted the scope, a write scope also grants its read scope
+func (k *APIKey) HasScope(Scope string) bool {
+	for _, s := range k.Scopes {
+		if s == Scope {
+			return true
+		}
+		if strings.HasSuffix(Scope, ":read") && s == strings.TrimSuffix(Scope, ":read")+":write" {
+			return true
+		}
+	}
+
+	return false
 }
 
 type APIKeyDataSvc interface {
-	GenerateApiKey(ctx context.Context, UserID string, KeyName string) (*APIKey, error)
+	GenerateApiKey(ctx context.Context, UserID string, KeyName string, Scopes []string, TeamID string, ExpireDate *time.Time) (*APIKey, error)
 	GetUserApiKeys(ctx context.Context, UserID string) ([]*APIKey, error)
-	GetApiKeyUser(ctx context.Context, APK string) (*User, error)
+	GetApiKeyUser(ctx context.Context, APK string) (*User, *APIKey, error)
+	APIKeyUsed(ctx context.Context, KeyID string, IPAddress string) error
 	GetAPIKeys(ctx context.Context, Limit int, Offset int) []*UserAPIKey
 	UpdateUserApiKey(ctx context.Context, UserID string, KeyID string, Active bool) ([]*APIKey, error)
 	DeleteUserApiKey(ctx context.Context, UserID string, KeyID string) ([]*APIKey, error)
@@ -25,6 +25,7 @@ type AppConfig struct {
 	ShowActiveCountries       bool
 	LdapEnabled               bool
 	HeaderAuthEnabled         bool
+	PasskeyLoginEnabled       bool
 	FeaturePoker              bool
 	FeatureRetro              bool
 	FeatureStoryboard         bool
@@ -0,0 +1,87 @@
+package thunderdome
+
+import (
+	"context"
+	"time"
+)
+
+// Audited actions
+const (
+	AuditActionUserCreate                  = "user.create"
+	AuditActionUserPromote                 = "user.promote"
+	AuditActionUserDemote                  = "user.demote"
+	AuditActionUserDisable                 = "user.disable"
+	AuditActionUserEnable                  = "user.enable"
+	AuditActionUserPasswordUpdate          = "user.password_update"
+	AuditActionUserDelete                  = "user.delete"
+	AuditActionAlertCreate                 = "alert.create"
+	AuditActionAlertUpdate                 = "alert.update"
+	AuditActionAlertDelete                 = "alert.delete"
+	AuditActionMaintenanceClean            = "maintenance.clean"
+	AuditActionOrganizationDelete          = "organization.delete"
+	AuditActionOrganizationUserAdd         = "organization.user_add"
+	AuditActionOrganizationUserRemove      = "organization.user_remove"
+	AuditActionOrganizationPolicyUpdate    = "organization.policy_update"
+	AuditActionOrganizationDomainCreate    = "organization.domain_create"
+	AuditActionOrganizationDomainVerify    = "organization.domain_verify"
+	AuditActionOrganizationDomainDelete    = "organization.domain_delete"
+	AuditActionDepartmentCreate            = "department.create"
+	AuditActionDepartmentDelete            = "department.delete"
+	AuditActionDepartmentUserAdd           = "department.user_add"
+	AuditActionDepartmentUserRemove        = "department.user_remove"
+	AuditActionTeamCreate                  = "team.create"
+	AuditActionTeamDelete                  = "team.delete"
+	AuditActionTeamUserAdd                 = "team.user_add"
+	AuditActionTeamUserRemove              = "team.user_remove"
+	AuditActionSCIMTokenCreate             = "scim_token.create"
+	AuditActionSCIMTokenDelete             = "scim_token.delete"
+	AuditActionServiceAccountCreate        = "service_account.create"
+	AuditActionServiceAccountDelete        = "service_account.delete"
+	AuditActionServiceAccountTeamAssign    = "service_account.team_assign"
+	AuditActionServiceAccountTeamRemove    = "service_account.team_remove"
+	AuditActionServiceAccountKeyCreate     = "service_account.apikey_create"
+	AuditActionServiceAccountKeyDelete     = "service_account.apikey_delete"
+	AuditActionPokerDelete                 = "poker.delete"
+	AuditActionPokerFacilitatorAdd         = "poker.facilitator_add"
+	AuditActionPokerFacilitatorRemove      = "poker.facilitator_remove"
+	AuditActionRetroDelete                 = "retro.delete"
+	AuditActionRetroFacilitatorAdd         = "retro.facilitator_add"
+	AuditActionRetroFacilitatorRemove      = "retro.facilitator_remove"
+	AuditActionStoryboardDelete            = "storyboard.delete"
+	AuditActionStoryboardFacilitatorAdd    = "storyboard.facilitator_add"
+	AuditActionStoryboardFacilitatorRemove = "storyboard.facilitator_remove"
+	AuditActionEmailRetry                  = "email.retry"
+	AuditActionEmailDelete                 = "email.delete"
+)
+
+// AuditLog an append-only record of an administrative or facilitator action,
+// Before and After hold the targets state around the change when known
+type AuditLog struct {
+	Id          string      `json:"id"`
+	ActorId     string      `json:"actorId"`
+	ActorName   string      `json:"actorName"`
+	OrgId       string      `json:"organizationId"`
+	Action      string      `json:"action"`
+	TargetType  string      `json:"targetType"`
+	TargetId    string      `json:"targetId"`
+	Before      interface{} `json:"before" swaggertype:"object"`
+	After       interface{} `json:"after" swaggertype:"object"`
+	IP          string      `json:"ip"`
+	CreatedDate time.Time   `json:"createdDate"`
+}
+
+// AuditLogFilter filters audit log queries, empty fields don't filter
+type AuditLogFilter struct {
+	OrgId      string
+	ActorId    string
+	Action     string
+	TargetType string
+	TargetId   string
+	Since      *time.Time
+	Until      *time.Time
+}
+
+type AuditDataSvc interface {
+	AuditLogCreate(ctx context.Context, Entry *AuditLog) error
+	AuditLogList(ctx context.Context, Filter AuditLogFilter, Limit int, Offset int) ([]*AuditLog, int, error)
+}
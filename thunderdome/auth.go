@@ -1,6 +1,20 @@
 package thunderdome
 
-import "context"
+import (
+	"context"
+	"time"
+)
+
+// UserSession an authenticated session of a user
+type UserSession struct {
+	Id           string    `json:"id"`
+	UserAgent    string    `json:"userAgent"`
+	IPAddress    string    `json:"ipAddress"`
+	Current      bool      `json:"current"`
+	CreatedDate  time.Time `json:"createdDate"`
+	LastSeenDate time.Time `json:"lastSeenDate"`
+	ExpireDate   time.Time `json:"expireDate"`
+}
 
 type AuthDataSvc interface {
 	AuthUser(ctx context.Context, UserEmail string, UserPassword string) (*User, string, error)
@@ -10,11 +24,18 @@ type AuthDataSvc interface {
 	UserVerifyRequest(ctx context.Context, UserId string) (*User, string, error)
 	VerifyUserAccount(ctx context.Context, VerifyID string) error
 	MFASetupGenerate(email string) (string, string, error)
-	MFASetupValidate(ctx context.Context, UserID string, secret string, passcode string) error
+	MFASetupValidate(ctx context.Context, UserID string, secret string, passcode string) ([]string, error)
 	MFARemove(ctx context.Context, UserID string) error
 	MFATokenValidate(ctx context.Context, SessionId string, passcode string) error
+	MFARecoveryCodesGenerate(ctx context.Context, UserID string) ([]string, error)
+	MFARecoveryCodeValidate(ctx context.Context, SessionId string, code string) (int, error)
 	CreateSession(ctx context.Context, UserId string) (string, error)
 	EnableSession(ctx context.Context, SessionId string) error
 	GetSessionUser(ctx context.Context, SessionId string) (*User, error)
+	GetSessionCreatedDate(ctx context.Context, SessionId string) (time.Time, error)
 	DeleteSession(ctx context.Context, SessionId string) error
+	SessionSeen(ctx context.Context, SessionId string, UserAgent string, IPAddress string) error
+	UserSessionList(ctx context.Context, UserId string, CurrentSessionId string) ([]*UserSession, error)
+	UserSessionDelete(ctx context.Context, UserId string, Id string) (string, error)
+	UserSessionDeleteAll(ctx context.Context, UserId string, ExceptSessionId string) ([]string, error)
 }
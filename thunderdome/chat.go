@@ -0,0 +1,57 @@
+package thunderdome
+
+import "context"
+
+// Chat integration providers
+const (
+	ChatProviderSlack      = "slack"
+	ChatProviderMattermost = "mattermost"
+)
+
+// Chat integration events
+const (
+	ChatEventCheckinBlockers = "checkin_blockers"
+	ChatEventPokerFinished   = "poker_finished"
+	ChatEventRetroCompleted  = "retro_completed"
+)
+
+// ChatIntegration a teams chat channel incoming webhook, the webhook URL is
+// stored encrypted and never returned
+type ChatIntegration struct {
+	Id          string   `json:"id"`
+	TeamId      string   `json:"teamId"`
+	Provider    string   `json:"provider"`
+	Name        string   `json:"name"`
+	Events      []string `json:"events"`
+	Enabled     bool     `json:"enabled"`
+	CreatedDate string   `json:"createdDate"`
+	UpdatedDate string   `json:"updatedDate"`
+}
+
+// ChatWebhook a decrypted chat integration webhook to notify
+type ChatWebhook struct {
+	Provider string
+	URL      string
+	TeamId   string
+	TeamName string
+}
+
+type ChatIntegrationDataSvc interface {
+	ChatIntegrationList(ctx context.Context, TeamId string) ([]*ChatIntegration, error)
+	ChatIntegrationCreate(ctx context.Context, TeamId string, Provider string, Name string, WebhookURL string, Events []string) (*ChatIntegration, error)
+	ChatIntegrationUpdate(ctx context.Context, TeamId string, IntegrationId string, Name string, WebhookURL string, Events []string, Enabled bool) (*ChatIntegration, error)
+	ChatIntegrationDelete(ctx context.Context, TeamId string, IntegrationId string) error
+	ChatWebhooksByTeam(ctx context.Context, TeamId string, Event string) ([]*ChatWebhook, error)
+	ChatWebhooksByPoker(ctx context.Context, PokerId string, Event string) ([]*ChatWebhook, error)
+	ChatWebhooksByRetro(ctx context.Context, RetroId string, Event string) ([]*ChatWebhook, error)
+	SlackChannelTeamGet(ctx context.Context, SlackTeamId string, ChannelId string) (string, error)
+	SlackChannelConnect(ctx context.Context, SlackTeamId string, ChannelId string, TeamId string, UserId string) error
+	SlackChannelDisconnect(ctx context.Context, SlackTeamId string, ChannelId string) error
+}
+
+// ChatService sends notifications to the chat channels of a teams integrations
+type ChatService interface {
+	CheckinBlockers(ctx context.Context, TeamId string, UserName string, Blockers string)
+	PokerFinished(ctx context.Context, Game *Poker)
+	RetroCompleted(ctx context.Context, Retro *Retro)
+}
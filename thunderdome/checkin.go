@@ -10,6 +10,7 @@ type TeamCheckin struct {
 	Blockers    string            `json:"blockers"`
 	Discuss     string            `json:"discuss"`
 	GoalsMet    bool              `json:"goalsMet"`
+	Answers     []*CheckinAnswer  `json:"answers"`
 	CreatedDate string            `json:"createdDate"`
 	UpdatedDate string            `json:"updatedDate"`
 	Comments    []*CheckinComment `json:"comments"`
@@ -25,12 +26,122 @@ type CheckinComment struct {
 	UpdatedDate string `json:"updated_date"`
 }
 
+// Checkin question types
+const (
+	CheckinQuestionText  = "text"
+	CheckinQuestionYesNo = "yesno"
+	CheckinQuestionScale = "scale"
+)
+
+// CheckinQuestion A team checkin question
+type CheckinQuestion struct {
+	Id        string `json:"id"`
+	Question  string `json:"question"`
+	Type      string `json:"type"`
+	Required  bool   `json:"required"`
+	SortOrder int    `json:"sort_order"`
+	// Default questions are answered with the TeamCheckin fields rather than Answers
+	Default bool `json:"default"`
+}
+
+// CheckinAnswer A users answer to a team checkin question,
+// yes/no answers are "yes" or "no" and scale answers "1" through "5"
+type CheckinAnswer struct {
+	QuestionID string `json:"questionId"`
+	// Question the questions text, included with saved answers so answers to archived questions still display
+	Question string `json:"question,omitempty"`
+	Answer   string `json:"answer"`
+}
+
+// DefaultCheckinQuestions are the questions used by teams without their own checkin questions
+var DefaultCheckinQuestions = []*CheckinQuestion{
+	{Id: "yesterday", Question: "Yesterday", Type: CheckinQuestionText, SortOrder: 1, Default: true},
+	{Id: "today", Question: "Today", Type: CheckinQuestionText, SortOrder: 2, Default: true},
+	{Id: "blockers", Question: "Blockers", Type: CheckinQuestionText, SortOrder: 3, Default: true},
+	{Id: "discuss", Question: "Discuss", Type: CheckinQuestionText, SortOrder: 4, Default: true},
+	{Id: "goalsMet", Question: "Goals Met", Type: CheckinQuestionYesNo, SortOrder: 5, Default: true},
+}
+
+// CheckinStats team checkin analytics for a date range, rates are relative to the
+// days in the range the team checked in rather than calendar days
+type CheckinStats struct {
+	From          string                  `json:"from"`
+	To            string                  `json:"to"`
+	CheckinDays   int                     `json:"checkinDays"`
+	Participation []*CheckinParticipation `json:"participation"`
+	GoalsMet      []*CheckinGoalsMetDay   `json:"goalsMet"`
+	BlockerDays   []string                `json:"blockerDays"`
+	Blockers      []*CheckinBlocker       `json:"blockers"`
+}
+
+// CheckinParticipation a team members checkin participation
+type CheckinParticipation struct {
+	User     *TeamUser `json:"user"`
+	Checkins int       `json:"checkins"`
+	Rate     float64   `json:"rate"`
+}
+
+// CheckinGoalsMetDay the rate of checkins that met their goals for a day
+type CheckinGoalsMetDay struct {
+	Date     string  `json:"date"`
+	Checkins int     `json:"checkins"`
+	GoalsMet int     `json:"goalsMet"`
+	Rate     float64 `json:"rate"`
+}
+
+// CheckinBlocker a blocker carried across a team members consecutive checkins
+type CheckinBlocker struct {
+	User *TeamUser `json:"user"`
+	// Blocker is the blocker from the most recent checkin
+	Blocker   string `json:"blocker"`
+	FirstDate string `json:"firstDate"`
+	LastDate  string `json:"lastDate"`
+	Checkins  int    `json:"checkins"`
+	Days      int    `json:"days"`
+	// Ongoing blockers are still present in the members latest checkin
+	Ongoing bool `json:"ongoing"`
+}
+
+// CheckinSchedule a teams checkin reminder and digest email schedule,
+// days are weekdays with 0 being Sunday and times are HH:MM in the schedules time zone
+type CheckinSchedule struct {
+	TeamId           string `json:"teamId"`
+	TeamName         string `json:"teamName"`
+	Enabled          bool   `json:"enabled"`
+	Days             []int  `json:"days"`
+	ReminderTime     string `json:"reminderTime"`
+	DigestTime       string `json:"digestTime"`
+	TimeZone         string `json:"timeZone"`
+	LastReminderDate string `json:"lastReminderDate"`
+	LastDigestDate   string `json:"lastDigestDate"`
+	CreatedDate      string `json:"createdDate"`
+	UpdatedDate      string `json:"updatedDate"`
+}
+
+// Checkin schedule notification types
+const (
+	CheckinNotificationReminder = "reminder"
+	CheckinNotificationDigest   = "digest"
+)
+
 type CheckinDataSvc interface {
 	CheckinList(ctx context.Context, TeamId string, Date string, TimeZone string) ([]*TeamCheckin, error)
-	CheckinCreate(ctx context.Context, TeamId string, UserId string, Yesterday string, Today string, Blockers string, Discuss string, GoalsMet bool) error
-	CheckinUpdate(ctx context.Context, CheckinId string, Yesterday string, Today string, Blockers string, Discuss string, GoalsMet bool) error
+	CheckinListRange(ctx context.Context, TeamId string, From string, To string, TimeZone string) ([]*TeamCheckin, error)
+	CheckinStats(ctx context.Context, TeamId string, From string, To string, TimeZone string) (*CheckinStats, error)
+	CheckinCreate(ctx context.Context, TeamId string, UserId string, Yesterday string, Today string, Blockers string, Discuss string, GoalsMet bool, Answers []*CheckinAnswer) error
+	CheckinUpdate(ctx context.Context, CheckinId string, Yesterday string, Today string, Blockers string, Discuss string, GoalsMet bool, Answers []*CheckinAnswer) error
 	CheckinDelete(ctx context.Context, CheckinId string) error
 	CheckinComment(ctx context.Context, TeamId string, CheckinId string, UserId string, Comment string) error
 	CheckinCommentEdit(ctx context.Context, TeamId string, UserId string, CommentId string, Comment string) error
 	CheckinCommentDelete(ctx context.Context, CommentId string) error
+	CheckinQuestionList(ctx context.Context, TeamId string) ([]*CheckinQuestion, error)
+	CheckinQuestionCreate(ctx context.Context, TeamId string, Question string, Type string, Required bool) (*CheckinQuestion, error)
+	CheckinQuestionUpdate(ctx context.Context, TeamId string, QuestionId string, Question string, Type string, Required bool, SortOrder int) (*CheckinQuestion, error)
+	CheckinQuestionArchive(ctx context.Context, TeamId string, QuestionId string) error
+	CheckinScheduleGet(ctx context.Context, TeamId string) (*CheckinSchedule, error)
+	CheckinScheduleUpsert(ctx context.Context, TeamId string, Enabled bool, Days []int, ReminderTime string, DigestTime string, TimeZone string) (*CheckinSchedule, error)
+	CheckinScheduleDelete(ctx context.Context, TeamId string) error
+	CheckinScheduleListEnabled(ctx context.Context) ([]*CheckinSchedule, error)
+	CheckinScheduleClaim(ctx context.Context, TeamId string, NotificationType string, Date string) (bool, error)
+	CheckinNotificationRecipients(ctx context.Context, TeamId string, Date string, TimeZone string, MissingCheckinOnly bool) ([]*User, error)
 }
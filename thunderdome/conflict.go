@@ -0,0 +1,15 @@
+package thunderdome
+
+// VersionConflict is returned when an edit is made against a stale version,
+// it contains the current value and version so the editor can reconcile
+type VersionConflict struct {
+	Type    string      `json:"type"`
+	ID      string      `json:"id"`
+	Field   string      `json:"field,omitempty"`
+	Value   interface{} `json:"value"`
+	Version int         `json:"version"`
+}
+
+func (c *VersionConflict) Error() string {
+	return "VERSION_CONFLICT"
+}
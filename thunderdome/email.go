@@ -1,12 +1,55 @@
 package thunderdome
 
+import (
+	"context"
+	"time"
+)
+
 type EmailService interface {
 	SendWelcome(UserName string, UserEmail string, VerifyID string) error
 	SendEmailVerification(UserName string, UserEmail string, VerifyID string) error
 	SendForgotPassword(UserName string, UserEmail string, ResetID string) error
 	SendPasswordReset(UserName string, UserEmail string) error
 	SendPasswordUpdate(UserName string, UserEmail string) error
+	SendMFARecoveryCodeUsed(UserName string, UserEmail string, RemainingCodes int) error
+	SendAccountLocked(UserName string, UserEmail string, Lockout time.Duration) error
 	SendDeleteConfirmation(UserName string, UserEmail string) error
 	SendEmailUpdate(UserName string, UserEmail string) error
 	SendMergedUpdate(UserName string, UserEmail string) error
+	SendCheckinReminder(ctx context.Context, UserName string, UserEmail string, TeamName string, TeamID string) error
+	SendCheckinDigest(ctx context.Context, UserName string, UserEmail string, TeamName string, TeamID string, Date string, Checkins []*TeamCheckin) error
+	SendTeamInvite(InviterName string, UserEmail string, TeamName string, InviteToken string) error
+}
+
+// Email queue statuses, a FAILED email has exhausted its send attempts and is dead-lettered
+const (
+	EmailStatusPending = "PENDING"
+	EmailStatusSending = "SENDING"
+	EmailStatusFailed  = "FAILED"
+)
+
+// QueuedEmail an outbound email waiting to be sent or dead-lettered after failing
+type QueuedEmail struct {
+	Id              string    `json:"id"`
+	ToName          string    `json:"toName"`
+	ToEmail         string    `json:"toEmail"`
+	Subject         string    `json:"subject"`
+	Body            string    `json:"-"`
+	Status          string    `json:"status"`
+	Attempts        int       `json:"attempts"`
+	LastError       string    `json:"lastError"`
+	NextAttemptDate time.Time `json:"nextAttemptDate"`
+	CreatedDate     time.Time `json:"createdDate"`
+	UpdatedDate     time.Time `json:"updatedDate"`
+}
+
+type EmailQueueDataSvc interface {
+	EmailQueueAdd(ctx context.Context, ToName string, ToEmail string, Subject string, Body string) error
+	EmailQueueClaim(ctx context.Context, Limit int, Lease time.Duration) ([]*QueuedEmail, error)
+	EmailQueueSent(ctx context.Context, EmailID string) error
+	EmailQueueRetryLater(ctx context.Context, EmailID string, SendErr string, RetryAfter time.Duration) error
+	EmailQueueDeadLetter(ctx context.Context, EmailID string, SendErr string) error
+	EmailQueueFailedList(ctx context.Context, Limit int, Offset int) ([]*QueuedEmail, int, error)
+	EmailQueueRetry(ctx context.Context, EmailID string) error
+	EmailQueueDelete(ctx context.Context, EmailID string) error
 }
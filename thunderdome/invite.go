@@ -0,0 +1,34 @@
+package thunderdome
+
+import (
+	"context"
+	"time"
+)
+
+// Invite types
+const (
+	InviteTypeTeam         = "team"
+	InviteTypeOrganization = "organization"
+	InviteTypeDepartment   = "department"
+)
+
+// UserInvite a pending invitation for an email address without an account
+// to join a team, organization, or department
+type UserInvite struct {
+	Id          string    `json:"id"`
+	Type        string    `json:"type"`
+	TargetId    string    `json:"targetId"`
+	Email       string    `json:"email"`
+	Role        string    `json:"role"`
+	InvitedBy   string    `json:"invitedBy"`
+	CreatedDate time.Time `json:"createdDate"`
+	ExpireDate  time.Time `json:"expireDate"`
+}
+
+type InviteDataSvc interface {
+	InviteCreate(ctx context.Context, InviteType string, TargetId string, Email string, Role string, InvitedBy string) (*UserInvite, error)
+	InviteGet(ctx context.Context, InviteId string) (*UserInvite, error)
+	InviteList(ctx context.Context, InviteType string, TargetId string) ([]*UserInvite, error)
+	InviteRevoke(ctx context.Context, InviteType string, TargetId string, InviteId string) error
+	InviteAcceptByEmail(ctx context.Context, UserId string, UserEmail string) ([]*UserInvite, error)
+}
@@ -0,0 +1,49 @@
+package thunderdome
+
+import (
+	"context"
+	"time"
+)
+
+// Job run statuses
+const (
+	JobRunStatusRunning   = "RUNNING"
+	JobRunStatusSucceeded = "SUCCEEDED"
+	JobRunStatusFailed    = "FAILED"
+)
+
+// JobRun a run of a scheduled job
+type JobRun struct {
+	Id            string     `json:"id"`
+	JobName       string     `json:"jobName"`
+	ScheduledDate time.Time  `json:"scheduledDate"`
+	StartedDate   time.Time  `json:"startedDate"`
+	CompletedDate *time.Time `json:"completedDate"`
+	Status        string     `json:"status"`
+	Result        string     `json:"result"`
+}
+
+// Job a scheduled job with its most recent run
+type Job struct {
+	Name     string     `json:"name"`
+	Schedule string     `json:"schedule"`
+	Enabled  bool       `json:"enabled"`
+	NextRun  *time.Time `json:"nextRun"`
+	LastRun  *JobRun    `json:"lastRun"`
+}
+
+// JobLeaderLock a held lock electing the application replica that runs the scheduled jobs
+type JobLeaderLock interface {
+	// Held checks the lock is still held
+	Held(ctx context.Context) bool
+	// Release releases the lock for another replica to acquire
+	Release(ctx context.Context)
+}
+
+type JobDataSvc interface {
+	JobLeaderLockAcquire(ctx context.Context) (JobLeaderLock, error)
+	JobRunStart(ctx context.Context, JobName string, ScheduledDate time.Time) (*JobRun, error)
+	JobRunComplete(ctx context.Context, RunID string, Status string, Result string) error
+	JobRunsFailRunning(ctx context.Context, Result string) (int64, error)
+	JobRunsLatest(ctx context.Context) ([]*JobRun, error)
+}
@@ -2,6 +2,7 @@ package thunderdome
 
 import (
 	"context"
+	"strings"
 	"time"
 )
 
@@ -20,6 +21,7 @@ type OrganizationUser struct {
 	Role         string `json:"role"`
 	Avatar       string `json:"avatar"`
 	GravatarHash string `json:"gravatarHash"`
+	Type         string `json:"type"`
 }
 
 type Department struct {
@@ -38,6 +40,52 @@ type DepartmentUser struct {
 	GravatarHash string `json:"gravatarHash"`
 }
 
+// OrganizationPolicy an organizations security policy enforced for its users
+type OrganizationPolicy struct {
+	OrgId string `json:"organizationId"`
+	// RequireMFA requires users to have MFA enabled to access the organization
+	RequireMFA bool `json:"requireMfa"`
+	// AllowedDomains restricts membership to users with a verified email on one of the organizations verified domains
+	AllowedDomains []string `json:"allowedDomains"`
+	// AutoJoin adds users with a verified email on one of the allowed domains as members
+	AutoJoin bool `json:"autoJoin"`
+	// SessionLifetimeHours requires users to re-authenticate when their session is older, 0 is no limit
+	SessionLifetimeHours int       `json:"sessionLifetimeHours"`
+	UpdatedDate          time.Time `json:"updatedDate"`
+}
+
+// OrganizationDomain an email domain claimed by an organization, its policy can only allow and auto join users
+// on the domain once it has been verified, a domain can only be verified by one organization
+type OrganizationDomain struct {
+	Id     string `json:"id"`
+	OrgId  string `json:"organizationId"`
+	Domain string `json:"domain"`
+	// VerificationToken the value of the domains TXT record that verifies the organization owns it
+	VerificationToken string     `json:"verificationToken"`
+	VerifiedDate      *time.Time `json:"verifiedDate"`
+	CreatedDate       time.Time  `json:"createdDate"`
+}
+
+// EmailDomainAllowed checks whether the email is on one of the allowed domains, all domains are allowed when none are set
+func (p *OrganizationPolicy) EmailDomainAllowed(Email string) bool {
+	if len(p.AllowedDomains) == 0 {
+		return true
+	}
+
+	at := strings.LastIndex(Email, "@")
+	if at == -1 {
+		return false
+	}
+	domain := strings.ToLower(Email[at+1:])
+	for _, allowed := range p.AllowedDomains {
+		if strings.ToLower(allowed) == domain {
+			return true
+		}
+	}
+
+	return false
+}
+
 type OrganizationDataSvc interface {
 	OrganizationGet(ctx context.Context, OrgID string) (*Organization, error)
 	OrganizationUserRole(ctx context.Context, UserID string, OrgID string) (string, error)
@@ -51,6 +99,14 @@ type OrganizationDataSvc interface {
 	OrganizationTeamUserRole(ctx context.Context, UserID string, OrgID string, TeamID string) (string, string, error)
 	OrganizationDelete(ctx context.Context, OrgID string) error
 	OrganizationList(ctx context.Context, Limit int, Offset int) []*Organization
+	OrganizationPolicyGet(ctx context.Context, OrgID string) (*OrganizationPolicy, error)
+	OrganizationPolicyUpdate(ctx context.Context, OrgID string, RequireMFA bool, AllowedDomains []string, AutoJoin bool, SessionLifetimeHours int) (*OrganizationPolicy, error)
+	OrganizationPolicyAutoJoin(ctx context.Context, UserID string, UserEmail string) error
+	OrganizationDomainList(ctx context.Context, OrgID string) ([]*OrganizationDomain, error)
+	OrganizationDomainGet(ctx context.Context, OrgID string, DomainID string) (*OrganizationDomain, error)
+	OrganizationDomainCreate(ctx context.Context, OrgID string, Domain string) (*OrganizationDomain, error)
+	OrganizationDomainVerify(ctx context.Context, OrgID string, DomainID string) (*OrganizationDomain, error)
+	OrganizationDomainDelete(ctx context.Context, OrgID string, DomainID string) error
 
 	DepartmentUserRole(ctx context.Context, UserID string, OrgID string, DepartmentID string) (string, string, error)
 	DepartmentGet(ctx context.Context, DepartmentID string) (*Department, error)
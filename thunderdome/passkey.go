@@ -0,0 +1,45 @@
+package thunderdome
+
+import (
+	"context"
+	"time"
+)
+
+// WebAuthn ceremonies a challenge can be issued for
+const (
+	PasskeyCeremonyRegister = "register"
+	PasskeyCeremonyLogin    = "login"
+	PasskeyCeremonyMFA      = "mfa"
+)
+
+// Passkey a WebAuthn credential registered by a user
+type Passkey struct {
+	Id           string     `json:"id"`
+	UserId       string     `json:"userId"`
+	Name         string     `json:"name"`
+	CredentialId string     `json:"credentialId"`
+	PublicKey    []byte     `json:"-"`
+	Algorithm    int        `json:"-"`
+	SignCount    uint32     `json:"-"`
+	Transports   []string   `json:"transports"`
+	CreatedDate  time.Time  `json:"createdDate"`
+	LastUsedDate *time.Time `json:"lastUsedDate"`
+}
+
+// PasskeyChallenge a pending WebAuthn ceremony challenge
+type PasskeyChallenge struct {
+	Challenge string
+	Ceremony  string
+	UserId    string
+	SessionId string
+}
+
+type PasskeyDataSvc interface {
+	PasskeyChallengeCreate(ctx context.Context, Challenge string, Ceremony string, UserId string, SessionId string) error
+	PasskeyChallengeConsume(ctx context.Context, Challenge string, Ceremony string) (*PasskeyChallenge, error)
+	PasskeyCreate(ctx context.Context, Passkey *Passkey) (*Passkey, []string, error)
+	PasskeyList(ctx context.Context, UserId string) ([]*Passkey, error)
+	PasskeyGetByCredential(ctx context.Context, CredentialId string) (*Passkey, error)
+	PasskeyUsed(ctx context.Context, PasskeyId string, SignCount uint32) error
+	PasskeyDelete(ctx context.Context, UserId string, PasskeyId string) error
+}
@@ -0,0 +1,18 @@
+package thunderdome
+
+import (
+	"context"
+)
+
+// RateLimitBucket the token bucket of a rate limited key after taking a request token from it
+type RateLimitBucket struct {
+	Key string
+	// Tokens the requests remaining in the bucket
+	Tokens float64
+	// Allowed whether a token was available for the request
+	Allowed bool
+}
+
+type RateLimitDataSvc interface {
+	RateLimitTake(ctx context.Context, Key string, Capacity int, RefillRate float64) (*RateLimitBucket, error)
+}
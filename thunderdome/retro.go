@@ -15,6 +15,7 @@ type RetroUser struct {
 	Active       bool   `json:"active"`
 	Avatar       string `json:"avatar"`
 	GravatarHash string `json:"gravatarHash"`
+	Type         string `json:"type"`
 }
 
 // Retro A story mapping board
@@ -34,6 +35,7 @@ type Retro struct {
 	FacilitatorCode      string         `json:"facilitatorCode" db:"facilitator_code"`
 	MaxVotes             int            `json:"maxVotes" db:"max_votes"`
 	BrainstormVisibility string         `json:"brainstormVisibility" db:"brainstorm_visibility"`
+	Template             bool           `json:"template" db:"template"`
 	CreatedDate          string         `json:"createdDate" db:"created_date"`
 	UpdatedDate          string         `json:"updatedDate" db:"updated_date"`
 }
@@ -49,8 +51,9 @@ type RetroItem struct {
 
 // RetroGroup is a grouping of retro items
 type RetroGroup struct {
-	ID   string `json:"id" db:"id"`
-	Name string `json:"name" db:"name"`
+	ID      string `json:"id" db:"id"`
+	Name    string `json:"name" db:"name"`
+	Version int    `json:"version" db:"version"`
 }
 
 // RetroAction is an action the team can take based on retro feedback
@@ -60,6 +63,7 @@ type RetroAction struct {
 	Content   string                `json:"content" db:"content"`
 	Completed bool                  `json:"completed" db:"completed"`
 	Comments  []*RetroActionComment `json:"comments"`
+	Version   int                   `json:"version" db:"version"`
 }
 
 // RetroActionComment A retro action comment by a user
@@ -81,6 +85,7 @@ type RetroVote struct {
 type RetroDataSvc interface {
 	RetroCreate(OwnerID string, RetroName string, Format string, JoinCode string, FacilitatorCode string, MaxVotes int, BrainstormVisibility string) (*Retro, error)
 	TeamRetroCreate(ctx context.Context, TeamID string, OwnerID string, RetroName string, Format string, JoinCode string, FacilitatorCode string, MaxVotes int, BrainstormVisibility string) (*Retro, error)
+	CloneRetro(ctx context.Context, RetroID string, OwnerID string, RetroName string, Template bool, TeamID string) (*Retro, error)
 	EditRetro(RetroID string, RetroName string, JoinCode string, FacilitatorCode string, maxVotes int, brainstormVisibility string) error
 	RetroGet(RetroID string, UserID string) (*Retro, error)
 	RetroGetByUser(UserID string) ([]*Retro, error)
@@ -101,7 +106,7 @@ type RetroDataSvc interface {
 	CleanRetros(ctx context.Context, DaysOld int) error
 
 	CreateRetroAction(RetroID string, UserID string, Content string) ([]*RetroAction, error)
-	UpdateRetroAction(RetroID string, ActionID string, Content string, Completed bool) (Actions []*RetroAction, DeleteError error)
+	UpdateRetroAction(RetroID string, ActionID string, Content string, Completed bool, Version int) (Actions []*RetroAction, DeleteError error)
 	DeleteRetroAction(RetroID string, userID string, ActionID string) ([]*RetroAction, error)
 	GetRetroActions(RetroID string) []*RetroAction
 	GetTeamRetroActions(TeamID string, Limit int, Offset int, Completed bool) ([]*RetroAction, int, error)
@@ -116,7 +121,7 @@ type RetroDataSvc interface {
 	DeleteRetroItem(RetroID string, userID string, Type string, ItemID string) ([]*RetroItem, error)
 	GetRetroItems(RetroID string) []*RetroItem
 	GetRetroGroups(RetroID string) []*RetroGroup
-	GroupNameChange(RetroID string, GroupId string, Name string) ([]*RetroGroup, error)
+	GroupNameChange(RetroID string, GroupId string, Name string, Version int) ([]*RetroGroup, error)
 	GetRetroVotes(RetroID string) []*RetroVote
 	GroupUserVote(RetroID string, GroupID string, UserID string) ([]*RetroVote, error)
 	GroupUserSubtractVote(RetroID string, GroupID string, UserID string) ([]*RetroVote, error)
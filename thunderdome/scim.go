@@ -0,0 +1,37 @@
+package thunderdome
+
+import (
+	"context"
+	"time"
+)
+
+// SCIMToken an organization scoped bearer token used by identity providers
+// to provision users and groups, the token is only returned on creation
+type SCIMToken struct {
+	Id           string     `json:"id"`
+	OrgId        string     `json:"organizationId"`
+	Name         string     `json:"name"`
+	Prefix       string     `json:"prefix"`
+	Token        string     `json:"token,omitempty"`
+	CreatedBy    string     `json:"createdBy"`
+	CreatedDate  time.Time  `json:"createdDate"`
+	LastUsedDate *time.Time `json:"lastUsedDate"`
+}
+
+type SCIMDataSvc interface {
+	SCIMTokenCreate(ctx context.Context, OrgId string, Name string, UserId string) (*SCIMToken, error)
+	SCIMTokenList(ctx context.Context, OrgId string) ([]*SCIMToken, error)
+	SCIMTokenDelete(ctx context.Context, OrgId string, TokenId string) error
+	SCIMTokenOrganization(ctx context.Context, Token string) (string, error)
+	SCIMUserList(ctx context.Context, OrgId string, Email string, Limit int, Offset int) ([]*User, int, error)
+	SCIMUserGet(ctx context.Context, OrgId string, UserId string) (*User, error)
+	SCIMUserProvision(ctx context.Context, OrgId string, UserId string) error
+	SCIMUserProvisioned(ctx context.Context, OrgId string, UserId string) (bool, error)
+	SCIMUserManaged(ctx context.Context, OrgId string, UserId string) (bool, error)
+	SCIMUserActivate(ctx context.Context, OrgId string, UserId string) error
+	SCIMUserDeactivate(ctx context.Context, OrgId string, UserId string) error
+	SCIMUserDelete(ctx context.Context, OrgId string, UserId string) error
+	SCIMGroupList(ctx context.Context, OrgId string, Name string, Limit int, Offset int) ([]*Team, int, error)
+	SCIMGroupGet(ctx context.Context, OrgId string, TeamId string) (*Team, error)
+	SCIMGroupRename(ctx context.Context, OrgId string, TeamId string, Name string) (*Team, error)
+}
@@ -0,0 +1,37 @@
+package thunderdome
+
+import (
+	"context"
+	"time"
+)
+
+// ServiceAccountUserType the user type of organization service accounts,
+// non-login users that only authenticate by API key
+const ServiceAccountUserType = "SERVICE"
+
+// ServiceAccount an organization owned non-login user used by integrations,
+// unlike a persons API key it is unaffected by the person leaving
+type ServiceAccount struct {
+	Id          string                `json:"id"`
+	OrgId       string                `json:"organizationId"`
+	Name        string                `json:"name"`
+	CreatedBy   string                `json:"createdBy"`
+	CreatedDate time.Time             `json:"createdDate"`
+	Teams       []*ServiceAccountTeam `json:"teams"`
+}
+
+// ServiceAccountTeam a team the service account is assigned to
+type ServiceAccountTeam struct {
+	TeamId   string `json:"teamId"`
+	TeamName string `json:"teamName"`
+	Role     string `json:"role"`
+}
+
+type ServiceAccountDataSvc interface {
+	ServiceAccountCreate(ctx context.Context, OrgId string, Name string, CreatedBy string) (*ServiceAccount, error)
+	ServiceAccountList(ctx context.Context, OrgId string) ([]*ServiceAccount, error)
+	ServiceAccountGet(ctx context.Context, OrgId string, UserId string) (*ServiceAccount, error)
+	ServiceAccountDelete(ctx context.Context, OrgId string, UserId string) error
+	ServiceAccountTeamAssign(ctx context.Context, OrgId string, UserId string, TeamId string, Role string) error
+	ServiceAccountTeamRemove(ctx context.Context, OrgId string, UserId string, TeamId string) error
+}
@@ -1,6 +1,9 @@
 package thunderdome
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+)
 
 // StoryboardUser aka user
 type StoryboardUser struct {
@@ -10,22 +13,27 @@ type StoryboardUser struct {
 	Avatar       string `json:"avatar"`
 	Abandoned    bool   `json:"abandoned"`
 	GravatarHash string `json:"gravatarHash"`
+	Type         string `json:"type"`
 }
 
 // Storyboard A story mapping board
 type Storyboard struct {
-	Id              string               `json:"id"`
-	OwnerID         string               `json:"owner_id"`
-	Name            string               `json:"name"`
-	Users           []*StoryboardUser    `json:"users"`
-	Facilitators    []string             `json:"facilitators"`
-	Goals           []*StoryboardGoal    `json:"goals"`
-	ColorLegend     []*Color             `json:"color_legend"`
-	Personas        []*StoryboardPersona `json:"personas"`
-	JoinCode        string               `json:"joinCode" db:"join_code"`
-	FacilitatorCode string               `json:"facilitatorCode" db:"facilitator_code"`
-	CreatedDate     string               `json:"createdDate" db:"created_date"`
-	UpdatedDate     string               `json:"updatedDate" db:"updated_date"`
+	Id               string               `json:"id"`
+	OwnerID          string               `json:"owner_id"`
+	Name             string               `json:"name"`
+	Users            []*StoryboardUser    `json:"users"`
+	Facilitators     []string             `json:"facilitators"`
+	Goals            []*StoryboardGoal    `json:"goals"`
+	ColorLegend      []*Color             `json:"color_legend"`
+	Personas         []*StoryboardPersona `json:"personas"`
+	WorkflowStates   []*WorkflowState     `json:"workflow_states"`
+	WipLimitEnforced bool                 `json:"wip_limit_enforced"`
+	StateCounts      map[string]int       `json:"state_counts"`
+	JoinCode         string               `json:"joinCode" db:"join_code"`
+	FacilitatorCode  string               `json:"facilitatorCode" db:"facilitator_code"`
+	Template         bool                 `json:"template" db:"template"`
+	CreatedDate      string               `json:"createdDate" db:"created_date"`
+	UpdatedDate      string               `json:"updatedDate" db:"updated_date"`
 }
 
 // StoryboardGoal A row in a story mapping board
@@ -35,15 +43,20 @@ type StoryboardGoal struct {
 	Personas  []*StoryboardPersona `json:"personas"`
 	Columns   []*StoryboardColumn  `json:"columns"`
 	SortOrder int                  `json:"sort_order"`
+	Version   int                  `json:"version"`
 }
 
 // StoryboardColumn A column in a storyboard goal
 type StoryboardColumn struct {
-	Id        string               `json:"id"`
-	Name      string               `json:"name"`
-	Personas  []*StoryboardPersona `json:"personas"`
-	Stories   []*StoryboardStory   `json:"stories"`
-	SortOrder int                  `json:"sort_order"`
+	Id          string               `json:"id"`
+	Name        string               `json:"name"`
+	Personas    []*StoryboardPersona `json:"personas"`
+	Stories     []*StoryboardStory   `json:"stories"`
+	SortOrder   int                  `json:"sort_order"`
+	WipLimit    int                  `json:"wip_limit"`
+	WipCount    int                  `json:"wip_count"`
+	WipExceeded bool                 `json:"wip_exceeded"`
+	Version     int                  `json:"version"`
 }
 
 // StoryboardStory A story in a storyboard goal column
@@ -54,10 +67,21 @@ type StoryboardStory struct {
 	Color       string          `json:"color"`
 	Points      int             `json:"points"`
 	Closed      bool            `json:"closed"`
+	State       string          `json:"state"`
 	Link        string          `json:"link"`
 	Annotations []string        `json:"annotations"`
 	SortOrder   int             `json:"sort_order"`
 	Comments    []*StoryComment `json:"comments"`
+	Version     int             `json:"version"`
+}
+
+// WorkflowState A storyboard story workflow state e.g. todo, in-progress, done
+type WorkflowState struct {
+	Name string `json:"name" validate:"required,max=64"`
+	// WIP states count towards a columns WIP limit
+	WIP bool `json:"wip"`
+	// Done states close the story
+	Done bool `json:"done"`
 }
 
 // StoryComment A story comment by a user
@@ -78,9 +102,25 @@ type StoryboardPersona struct {
 	Description string `json:"description"`
 }
 
+// StoryboardHistory A storyboard change log entry, before and after are snapshots of the changed entities
+// e.g. a story or a column with its stories
+type StoryboardHistory struct {
+	Id          string          `json:"id"`
+	UserID      string          `json:"user_id"`
+	UserName    string          `json:"user_name"`
+	EventType   string          `json:"event_type"`
+	EntityKind  string          `json:"entity_kind"`
+	EntityIDs   []string        `json:"entity_ids"`
+	Before      json.RawMessage `json:"before"`
+	After       json.RawMessage `json:"after"`
+	UndoOf      string          `json:"undo_of"`
+	CreatedDate string          `json:"created_date"`
+}
+
 type StoryboardDataSvc interface {
 	CreateStoryboard(ctx context.Context, OwnerID string, StoryboardName string, JoinCode string, FacilitatorCode string) (*Storyboard, error)
-	TeamCreateStoryboard(ctx context.Context, TeamID string, OwnerID string, StoryboardName string, JoinCode string, FacilitatorCode string) (*Storyboard, error)
+	TeamCreateStoryboard(ctx context.Context, TeamID string, OwnerID string, StoryboardName string, JoinCode string, FacilitatorCode string, TemplateID string) (*Storyboard, error)
+	CloneStoryboard(ctx context.Context, StoryboardID string, OwnerID string, StoryboardName string, Template bool, TeamID string) (*Storyboard, error)
 	EditStoryboard(StoryboardID string, StoryboardName string, JoinCode string, FacilitatorCode string) error
 	GetStoryboard(StoryboardID string, UserID string) (*Storyboard, error)
 	GetStoryboardsByUser(UserID string) ([]*Storyboard, int, error)
@@ -97,6 +137,7 @@ type StoryboardDataSvc interface {
 	StoryboardFacilitatorRemove(StoryboardId string, UserID string) (*Storyboard, error)
 	GetStoryboardFacilitatorCode(StoryboardID string) (string, error)
 	StoryboardReviseColorLegend(StoryboardID string, UserID string, ColorLegend string) (*Storyboard, error)
+	StoryboardReviseWorkflow(StoryboardID string, UserID string, WorkflowStates []*WorkflowState, WipLimitEnforced bool) (*Storyboard, error)
 	DeleteStoryboard(StoryboardID string, userID string) error
 	CleanStoryboards(ctx context.Context, DaysOld int) error
 
@@ -105,24 +146,32 @@ type StoryboardDataSvc interface {
 	DeleteStoryboardPersona(StoryboardID string, UserID string, PersonaID string) ([]*StoryboardPersona, error)
 
 	CreateStoryboardGoal(StoryboardID string, userID string, GoalName string) ([]*StoryboardGoal, error)
-	ReviseGoalName(StoryboardID string, userID string, GoalID string, GoalName string) ([]*StoryboardGoal, error)
+	ReviseGoalName(StoryboardID string, userID string, GoalID string, GoalName string, Version int) ([]*StoryboardGoal, error)
 	DeleteStoryboardGoal(StoryboardID string, userID string, GoalID string) ([]*StoryboardGoal, error)
 	GetStoryboardGoals(StoryboardID string) []*StoryboardGoal
 
 	CreateStoryboardColumn(StoryboardID string, GoalID string, userID string) ([]*StoryboardGoal, error)
-	ReviseStoryboardColumn(StoryboardID string, UserID string, ColumnID string, ColumnName string) ([]*StoryboardGoal, error)
+	ReviseStoryboardColumn(StoryboardID string, UserID string, ColumnID string, ColumnName string, Version int) ([]*StoryboardGoal, error)
 	DeleteStoryboardColumn(StoryboardID string, userID string, ColumnID string) ([]*StoryboardGoal, error)
+	ReviseStoryboardColumnWipLimit(StoryboardID string, UserID string, ColumnID string, WipLimit int) ([]*StoryboardGoal, error)
 
 	CreateStoryboardStory(StoryboardID string, GoalID string, ColumnID string, userID string) ([]*StoryboardGoal, error)
-	ReviseStoryName(StoryboardID string, userID string, StoryID string, StoryName string) ([]*StoryboardGoal, error)
-	ReviseStoryContent(StoryboardID string, userID string, StoryID string, StoryContent string) ([]*StoryboardGoal, error)
+	ReviseStoryName(StoryboardID string, userID string, StoryID string, StoryName string, Version int) ([]*StoryboardGoal, error)
+	ReviseStoryContent(StoryboardID string, userID string, StoryID string, StoryContent string, Version int) ([]*StoryboardGoal, error)
 	ReviseStoryColor(StoryboardID string, userID string, StoryID string, StoryColor string) ([]*StoryboardGoal, error)
 	ReviseStoryPoints(StoryboardID string, userID string, StoryID string, Points int) ([]*StoryboardGoal, error)
 	ReviseStoryClosed(StoryboardID string, userID string, StoryID string, Closed bool) ([]*StoryboardGoal, error)
+	ReviseStoryState(StoryboardID string, userID string, StoryID string, State string) ([]*StoryboardGoal, error)
 	ReviseStoryLink(StoryboardID string, userID string, StoryID string, Link string) ([]*StoryboardGoal, error)
 	MoveStoryboardStory(StoryboardID string, userID string, StoryID string, GoalID string, ColumnID string, PlaceBefore string) ([]*StoryboardGoal, error)
 	DeleteStoryboardStory(StoryboardID string, userID string, StoryID string) ([]*StoryboardGoal, error)
 	AddStoryComment(StoryboardID string, UserID string, StoryID string, Comment string) ([]*StoryboardGoal, error)
 	EditStoryComment(StoryboardID string, CommentID string, Comment string) ([]*StoryboardGoal, error)
 	DeleteStoryComment(StoryboardID string, CommentID string) ([]*StoryboardGoal, error)
+
+	GetStoryboardEntityIDs(StoryboardID string, EntityKind string, ParentID string) ([]string, error)
+	GetStoryboardEntitySnapshot(StoryboardID string, EntityKind string, EntityIDs []string) (string, error)
+	StoryboardHistoryAdd(StoryboardID string, UserID string, EventType string, EntityKind string, EntityIDs []string, Before string, After string) error
+	GetStoryboardHistory(StoryboardID string, Limit int, Offset int) ([]*StoryboardHistory, int, error)
+	StoryboardHistoryUndo(StoryboardID string, UserID string) (*Storyboard, error)
 }
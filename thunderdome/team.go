@@ -19,10 +19,13 @@ type TeamUser struct {
 	Role         string `json:"role"`
 	Avatar       string `json:"avatar"`
 	GravatarHash string `json:"gravatarHash"`
+	Type         string `json:"type"`
 }
 
 type TeamDataSvc interface {
 	TeamUserRole(ctx context.Context, UserID string, TeamID string) (string, error)
+	TeamPokerUserRole(ctx context.Context, UserID string, PokerID string) (string, error)
+	TeamEntityOrganizationIDs(ctx context.Context, EntityType string, EntityID string) ([]string, error)
 	TeamGet(ctx context.Context, TeamID string) (*Team, error)
 	TeamListByUser(ctx context.Context, UserID string, Limit int, Offset int) []*Team
 	TeamCreate(ctx context.Context, UserID string, TeamName string) (*Team, error)
@@ -30,13 +33,16 @@ type TeamDataSvc interface {
 	TeamUserList(ctx context.Context, TeamID string, Limit int, Offset int) ([]*TeamUser, int, error)
 	TeamRemoveUser(ctx context.Context, TeamID string, UserID string) error
 	TeamPokerList(ctx context.Context, TeamID string, Limit int, Offset int) []*Poker
+	TeamPokerTemplateList(ctx context.Context, TeamID string, Limit int, Offset int) []*Poker
 	TeamAddPoker(ctx context.Context, TeamID string, PokerID string) error
 	TeamRemovePoker(ctx context.Context, TeamID string, PokerID string) error
 	TeamDelete(ctx context.Context, TeamID string) error
 	TeamRetroList(ctx context.Context, TeamID string, Limit int, Offset int) []*Retro
+	TeamRetroTemplateList(ctx context.Context, TeamID string, Limit int, Offset int) []*Retro
 	TeamAddRetro(ctx context.Context, TeamID string, RetroID string) error
 	TeamRemoveRetro(ctx context.Context, TeamID string, RetroID string) error
 	TeamStoryboardList(ctx context.Context, TeamID string, Limit int, Offset int) []*Storyboard
+	TeamStoryboardTemplateList(ctx context.Context, TeamID string, Limit int, Offset int) []*Storyboard
 	TeamAddStoryboard(ctx context.Context, TeamID string, StoryboardID string) error
 	TeamRemoveStoryboard(ctx context.Context, TeamID string, StoryboardID string) error
 	TeamList(ctx context.Context, Limit int, Offset int) ([]*Team, int)
@@ -0,0 +1,22 @@
+package thunderdome
+
+import (
+	"context"
+	"time"
+)
+
+// AuthAttempt the recent failed authentication attempts of a throttled key (an account, IP address or entity)
+type AuthAttempt struct {
+	Scope       string
+	Key         string
+	Failures    int
+	LockedUntil *time.Time
+}
+
+type AuthAttemptDataSvc interface {
+	AuthAttemptGet(ctx context.Context, Scope string, Key string) (*AuthAttempt, error)
+	AuthAttemptFail(ctx context.Context, Scope string, Key string, Window time.Duration) (*AuthAttempt, error)
+	AuthAttemptLock(ctx context.Context, Scope string, Key string, Until time.Time) error
+	AuthAttemptReset(ctx context.Context, Scope string, Key string) error
+	AuthAttemptPrune(ctx context.Context, Window time.Duration) (int64, error)
+}
@@ -0,0 +1,81 @@
+// passkeysSupported whether the browser supports WebAuthn credentials
+export const passkeysSupported = function () {
+  return (
+    typeof window.PublicKeyCredential !== 'undefined' &&
+    typeof navigator.credentials !== 'undefined'
+  );
+};
+
+// decodeBase64url decodes the base64url encoded value of the api into an ArrayBuffer
+const decodeBase64url = function (value: string): ArrayBuffer {
+  const base64 = value.replace(/-/g, '+').replace(/_/g, '/');
+  const padded = base64 + '='.repeat((4 - (base64.length % 4)) % 4);
+  const binary = atob(padded);
+  const bytes = new Uint8Array(binary.length);
+  for (let i = 0; i < binary.length; i++) {
+    bytes[i] = binary.charCodeAt(i);
+  }
+
+  return bytes.buffer;
+};
+
+// encodeBase64url encodes the binary value of the browser for the api
+const encodeBase64url = function (value: ArrayBuffer): string {
+  const bytes = new Uint8Array(value);
+  let binary = '';
+  for (let i = 0; i < bytes.length; i++) {
+    binary += String.fromCharCode(bytes[i]);
+  }
+
+  return btoa(binary)
+    .replace(/\+/g, '-')
+    .replace(/\//g, '_')
+    .replace(/=+$/, '');
+};
+
+const decodeDescriptors = function (descriptors = []) {
+  return descriptors.map(d => ({ ...d, id: decodeBase64url(d.id) }));
+};
+
+// createPasskey runs the registration ceremony with the creation options of the api,
+// returning the attestation response to register the passkey with
+export const createPasskey = async function (options) {
+  const credential = (await navigator.credentials.create({
+    publicKey: {
+      ...options,
+      challenge: decodeBase64url(options.challenge),
+      user: { ...options.user, id: decodeBase64url(options.user.id) },
+      excludeCredentials: decodeDescriptors(options.excludeCredentials),
+    },
+  })) as PublicKeyCredential;
+  const response = credential.response as AuthenticatorAttestationResponse;
+
+  return {
+    clientDataJSON: encodeBase64url(response.clientDataJSON),
+    authenticatorData: encodeBase64url(response.getAuthenticatorData()),
+    publicKey: encodeBase64url(response.getPublicKey()),
+    publicKeyAlgorithm: response.getPublicKeyAlgorithm(),
+    transports: response.getTransports ? response.getTransports() : [],
+  };
+};
+
+// getPasskey runs the authentication ceremony with the request options of the api,
+// returning the assertion response to log in with
+export const getPasskey = async function (options) {
+  const credential = (await navigator.credentials.get({
+    publicKey: {
+      ...options,
+      challenge: decodeBase64url(options.challenge),
+      allowCredentials: decodeDescriptors(options.allowCredentials),
+    },
+  })) as PublicKeyCredential;
+  const response = credential.response as AuthenticatorAssertionResponse;
+
+  return {
+    credentialId: encodeBase64url(credential.rawId),
+    clientDataJSON: encodeBase64url(response.clientDataJSON),
+    authenticatorData: encodeBase64url(response.authenticatorData),
+    signature: encodeBase64url(response.signature),
+    userHandle: response.userHandle ? encodeBase64url(response.userHandle) : '',
+  };
+};
@@ -0,0 +1,173 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/binary"
+	"errors"
+	"math/big"
+)
+
+// COSE key parameters, see RFC 8152 section 7 and 13
+const (
+	coseKeyType      = 1
+	coseKeyAlgorithm = 3
+	coseKeyCurve     = -1 // the RSA modulus for RSA keys
+	coseKeyX         = -2 // the RSA exponent for RSA keys
+	coseKeyY         = -3
+
+	coseKeyTypeOKP = 1
+	coseKeyTypeEC2 = 2
+	coseKeyTypeRSA = 3
+
+	coseCurveP256    = 1
+	coseCurveEd25519 = 6
+)
+
+// CBOR major types used by COSE keys
+const (
+	cborUnsigned   = 0
+	cborNegative   = 1
+	cborByteString = 2
+	cborTextString = 3
+	cborMap        = 5
+)
+
+// cborMaxMapSize limits the size of the decoded COSE key map, keys only have a handful of parameters
+const cborMaxMapSize = 16
+
+// cborItem a decoded CBOR data item of the types COSE keys are made of
+type cborItem struct {
+	Int   int64
+	Bytes []byte
+	Map   map[int64]cborItem
+}
+
+// cborHead decodes the major type and argument of the data item at the start of raw, returning the remaining bytes
+func cborHead(raw []byte) (byte, uint64, []byte, error) {
+	if len(raw) < 1 {
+		return 0, 0, nil, errors.New("INVALID_COSE_KEY")
+	}
+
+	major := raw[0] >> 5
+	info := raw[0] & 0x1f
+	raw = raw[1:]
+
+	switch {
+	case info < 24:
+		return major, uint64(info), raw, nil
+	case info == 24 && len(raw) >= 1:
+		return major, uint64(raw[0]), raw[1:], nil
+	case info == 25 && len(raw) >= 2:
+		return major, uint64(binary.BigEndian.Uint16(raw)), raw[2:], nil
+	case info == 26 && len(raw) >= 4:
+		return major, uint64(binary.BigEndian.Uint32(raw)), raw[4:], nil
+	case info == 27 && len(raw) >= 8:
+		return major, binary.BigEndian.Uint64(raw), raw[8:], nil
+	}
+
+	// indefinite lengths aren't allowed in authenticator data
+	return 0, 0, nil, errors.New("INVALID_COSE_KEY")
+}
+
+// cborDecode decodes the data item at the start of raw, returning the remaining bytes
+func cborDecode(raw []byte, depth int) (cborItem, []byte, error) {
+	var item cborItem
+
+	major, arg, raw, err := cborHead(raw)
+	if err != nil {
+		return item, nil, err
+	}
+
+	switch major {
+	case cborUnsigned, cborNegative:
+		if arg > 1<<62 {
+			return item, nil, errors.New("INVALID_COSE_KEY")
+		}
+		item.Int = int64(arg)
+		if major == cborNegative {
+			item.Int = -1 - item.Int
+		}
+	case cborByteString, cborTextString:
+		if arg > uint64(len(raw)) {
+			return item, nil, errors.New("INVALID_COSE_KEY")
+		}
+		item.Bytes = raw[:arg]
+		raw = raw[arg:]
+	case cborMap:
+		if depth > 0 || arg > cborMaxMapSize {
+			return item, nil, errors.New("INVALID_COSE_KEY")
+		}
+		item.Map = make(map[int64]cborItem, arg)
+		for i := uint64(0); i < arg; i++ {
+			var key, value cborItem
+			if key, raw, err = cborDecode(raw, depth+1); err != nil {
+				return item, nil, err
+			}
+			if value, raw, err = cborDecode(raw, depth+1); err != nil {
+				return item, nil, err
+			}
+			item.Map[key.Int] = value
+		}
+	default:
+		return item, nil, errors.New("INVALID_COSE_KEY")
+	}
+
+	return item, raw, nil
+}
+
+// parseCOSEKey parses the COSE encoded credential public key of the attested credential data, returning
+// the public key and its algorithm
+func parseCOSEKey(raw []byte) (crypto.PublicKey, int, error) {
+	item, _, err := cborDecode(raw, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+	if item.Map == nil {
+		return nil, 0, errors.New("INVALID_COSE_KEY")
+	}
+
+	param := func(label int64) cborItem {
+		return item.Map[label]
+	}
+	Algorithm := int(param(coseKeyAlgorithm).Int)
+
+	switch param(coseKeyType).Int {
+	case coseKeyTypeEC2:
+		x, y := param(coseKeyX).Bytes, param(coseKeyY).Bytes
+		if Algorithm != AlgES256 || param(coseKeyCurve).Int != coseCurveP256 || len(x) != 32 || len(y) != 32 {
+			return nil, 0, errors.New("INVALID_COSE_KEY")
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, 0, errors.New("INVALID_COSE_KEY")
+		}
+		return key, Algorithm, nil
+	case coseKeyTypeOKP:
+		x := param(coseKeyX).Bytes
+		if Algorithm != AlgEdDSA || param(coseKeyCurve).Int != coseCurveEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, 0, errors.New("INVALID_COSE_KEY")
+		}
+		return ed25519.PublicKey(x), Algorithm, nil
+	case coseKeyTypeRSA:
+		n, e := param(coseKeyCurve).Bytes, param(coseKeyX).Bytes
+		if Algorithm != AlgRS256 || len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return nil, 0, errors.New("INVALID_COSE_KEY")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, Algorithm, nil
+	}
+
+	return nil, 0, errors.New("UNSUPPORTED_ALGORITHM")
+}
+
+// publicKeysEqual checks the public keys are the same key
+func publicKeysEqual(a crypto.PublicKey, b crypto.PublicKey) bool {
+	key, ok := a.(interface {
+		Equal(crypto.PublicKey) bool
+	})
+
+	return ok && key.Equal(b)
+}
@@ -0,0 +1,121 @@
+package webauthn
+
+import "encoding/base64"
+
+// ceremony timeout in milliseconds, matches the lifetime of the stored challenges
+const timeout = 300000
+
+// RelyingPartyEntity the relying party of the credential creation options
+type RelyingPartyEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// UserEntity the user account of the credential creation options, the ID is base64url encoded
+type UserEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// CredentialParameter a supported credential type and algorithm
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+// CredentialDescriptor an existing credential, the ID is base64url encoded
+type CredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+// AuthenticatorSelection the authenticator requirements of the credential creation options
+type AuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// CreationOptions the PublicKeyCredentialCreationOptions for navigator.credentials.create,
+// binary values are base64url encoded
+type CreationOptions struct {
+	Challenge              string                 `json:"challenge"`
+	RP                     RelyingPartyEntity     `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int                    `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions the PublicKeyCredentialRequestOptions for navigator.credentials.get,
+// binary values are base64url encoded
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	RPID             string                 `json:"rpId"`
+	Timeout          int                    `json:"timeout"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// NewCredentialDescriptor creates a public key credential descriptor
+func NewCredentialDescriptor(CredentialId string, Transports []string) CredentialDescriptor {
+	return CredentialDescriptor{Type: "public-key", ID: CredentialId, Transports: Transports}
+}
+
+// CreationOptions builds the options for registering a discoverable credential for the user
+func (rp *RelyingParty) CreationOptions(Challenge string, UserId string, UserName string, Exclude []CredentialDescriptor) CreationOptions {
+	if Exclude == nil {
+		Exclude = make([]CredentialDescriptor, 0)
+	}
+
+	return CreationOptions{
+		Challenge: Challenge,
+		RP:        RelyingPartyEntity{ID: rp.ID, Name: rp.Name},
+		User: UserEntity{
+			ID:          base64.RawURLEncoding.EncodeToString([]byte(UserId)),
+			Name:        UserName,
+			DisplayName: UserName,
+		},
+		PubKeyCredParams: []CredentialParameter{
+			{Type: "public-key", Alg: AlgES256},
+			{Type: "public-key", Alg: AlgEdDSA},
+			{Type: "public-key", Alg: AlgRS256},
+		},
+		Timeout:            timeout,
+		ExcludeCredentials: Exclude,
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: "preferred",
+		},
+		Attestation: "none",
+	}
+}
+
+// RequestOptions builds the options for authenticating with one of the allowed credentials,
+// an empty allow list lets the user pick any discoverable credential
+func (rp *RelyingParty) RequestOptions(Challenge string, Allow []CredentialDescriptor, UserVerification string) RequestOptions {
+	if Allow == nil {
+		Allow = make([]CredentialDescriptor, 0)
+	}
+
+	return RequestOptions{
+		Challenge:        Challenge,
+		RPID:             rp.ID,
+		Timeout:          timeout,
+		AllowCredentials: Allow,
+		UserVerification: UserVerification,
+	}
+}
+
+// UserHandle decodes the base64url encoded user handle of an assertion into the user ID
+func UserHandle(Handle string) (string, error) {
+	b, err := decode(Handle)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
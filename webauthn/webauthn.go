@@ -0,0 +1,326 @@
+// Package webauthn provides WebAuthn (passkey) registration and authentication ceremonies for Thunderdome
+package webauthn
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+)
+
+// COSE algorithm identifiers of the supported credential public keys
+const (
+	AlgES256 = -7
+	AlgEdDSA = -8
+	AlgRS256 = -257
+)
+
+// authenticator data flags
+const (
+	flagUserPresent        = 0x01
+	flagUserVerified       = 0x04
+	flagAttestedCredential = 0x40
+)
+
+const (
+	ceremonyCreate = "webauthn.create"
+	ceremonyGet    = "webauthn.get"
+)
+
+// RelyingParty is the WebAuthn relying party (the application) credentials are scoped to
+type RelyingParty struct {
+	// ID the relying party ID, the applications domain without scheme or port
+	ID string
+	// Name the relying party name displayed by authenticators
+	Name string
+	// Origins the origins ceremonies are allowed to be performed from
+	Origins []string
+}
+
+// AttestationResponse the browsers AuthenticatorAttestationResponse of a registration ceremony,
+// binary values are base64url encoded
+type AttestationResponse struct {
+	ClientDataJSON     string   `json:"clientDataJSON" validate:"required"`
+	AuthenticatorData  string   `json:"authenticatorData" validate:"required"`
+	PublicKey          string   `json:"publicKey" validate:"required"`
+	PublicKeyAlgorithm int      `json:"publicKeyAlgorithm" validate:"required"`
+	Transports         []string `json:"transports"`
+}
+
+// AssertionResponse the browsers AuthenticatorAssertionResponse of an authentication ceremony,
+// binary values are base64url encoded
+type AssertionResponse struct {
+	CredentialId      string `json:"credentialId" validate:"required"`
+	ClientDataJSON    string `json:"clientDataJSON" validate:"required"`
+	AuthenticatorData string `json:"authenticatorData" validate:"required"`
+	Signature         string `json:"signature" validate:"required"`
+	UserHandle        string `json:"userHandle"`
+}
+
+// Credential a verified newly registered credential
+type Credential struct {
+	// Id the base64url encoded credential ID
+	Id string
+	// PublicKey the DER encoded SubjectPublicKeyInfo of the credential
+	PublicKey  []byte
+	Algorithm  int
+	SignCount  uint32
+	Transports []string
+}
+
+// clientData the collected client data signed over by the authenticator
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// authenticatorData the parsed authenticator data
+type authenticatorData struct {
+	RPIDHash     []byte
+	Flags        byte
+	SignCount    uint32
+	CredentialId []byte
+	// CredentialPublicKey the COSE encoded credential public key, followed by any extensions
+	CredentialPublicKey []byte
+}
+
+// NewChallenge generates a random base64url encoded ceremony challenge
+func NewChallenge() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// decode decodes a base64url value with or without padding
+func decode(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(trimPadding(value))
+}
+
+func trimPadding(value string) string {
+	for len(value) > 0 && value[len(value)-1] == '=' {
+		value = value[:len(value)-1]
+	}
+	return value
+}
+
+// verifyClientData validates the client data was collected for the ceremony, challenge and an allowed origin
+func (rp *RelyingParty) verifyClientData(raw []byte, Type string, Challenge string) error {
+	var cd clientData
+	if err := json.Unmarshal(raw, &cd); err != nil {
+		return errors.New("INVALID_CLIENT_DATA")
+	}
+
+	if cd.Type != Type {
+		return errors.New("INVALID_CEREMONY_TYPE")
+	}
+
+	if trimPadding(cd.Challenge) != trimPadding(Challenge) {
+		return errors.New("INVALID_CHALLENGE")
+	}
+
+	for _, origin := range rp.Origins {
+		if cd.Origin == origin {
+			return nil
+		}
+	}
+
+	return errors.New("INVALID_ORIGIN")
+}
+
+// parseAuthenticatorData parses the authenticator data validating it is scoped to the relying party
+func (rp *RelyingParty) parseAuthenticatorData(raw []byte, RequireUserVerification bool) (*authenticatorData, error) {
+	if len(raw) < 37 {
+		return nil, errors.New("INVALID_AUTHENTICATOR_DATA")
+	}
+
+	ad := &authenticatorData{
+		RPIDHash:  raw[:32],
+		Flags:     raw[32],
+		SignCount: binary.BigEndian.Uint32(raw[33:37]),
+	}
+
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if !bytes.Equal(ad.RPIDHash, rpIDHash[:]) {
+		return nil, errors.New("INVALID_RP_ID")
+	}
+
+	if ad.Flags&flagUserPresent == 0 {
+		return nil, errors.New("USER_NOT_PRESENT")
+	}
+
+	if RequireUserVerification && ad.Flags&flagUserVerified == 0 {
+		return nil, errors.New("USER_NOT_VERIFIED")
+	}
+
+	if ad.Flags&flagAttestedCredential != 0 {
+		// aaguid (16 bytes) followed by the credential id length (2 bytes) and credential id
+		if len(raw) < 55 {
+			return nil, errors.New("INVALID_AUTHENTICATOR_DATA")
+		}
+		idLen := int(binary.BigEndian.Uint16(raw[53:55]))
+		if len(raw) < 55+idLen {
+			return nil, errors.New("INVALID_AUTHENTICATOR_DATA")
+		}
+		ad.CredentialId = raw[55 : 55+idLen]
+		ad.CredentialPublicKey = raw[55+idLen:]
+	}
+
+	return ad, nil
+}
+
+// VerifyRegistration verifies a registration ceremony response for the challenge, returning the new credential.
+// Attestation statements are not verified as credentials are registered with the "none" attestation preference,
+// the public key has to match the credential public key of the authenticator data.
+func (rp *RelyingParty) VerifyRegistration(Challenge string, Response AttestationResponse) (*Credential, error) {
+	rawClientData, err := decode(Response.ClientDataJSON)
+	if err != nil {
+		return nil, errors.New("INVALID_CLIENT_DATA")
+	}
+	if err := rp.verifyClientData(rawClientData, ceremonyCreate, Challenge); err != nil {
+		return nil, err
+	}
+
+	rawAuthData, err := decode(Response.AuthenticatorData)
+	if err != nil {
+		return nil, errors.New("INVALID_AUTHENTICATOR_DATA")
+	}
+	ad, err := rp.parseAuthenticatorData(rawAuthData, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(ad.CredentialId) == 0 {
+		return nil, errors.New("MISSING_ATTESTED_CREDENTIAL")
+	}
+
+	publicKey, err := decode(Response.PublicKey)
+	if err != nil {
+		return nil, errors.New("INVALID_PUBLIC_KEY")
+	}
+	key, err := parsePublicKey(publicKey, Response.PublicKeyAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	// the browser provided public key is a convenience, it has to be the key the authenticator attested to
+	attestedKey, attestedAlgorithm, err := parseCOSEKey(ad.CredentialPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	if attestedAlgorithm != Response.PublicKeyAlgorithm || !publicKeysEqual(key, attestedKey) {
+		return nil, errors.New("PUBLIC_KEY_MISMATCH")
+	}
+
+	return &Credential{
+		Id:         base64.RawURLEncoding.EncodeToString(ad.CredentialId),
+		PublicKey:  publicKey,
+		Algorithm:  Response.PublicKeyAlgorithm,
+		SignCount:  ad.SignCount,
+		Transports: Response.Transports,
+	}, nil
+}
+
+// VerifyAssertion verifies an authentication ceremony response for the challenge against the stored credential,
+// returning the authenticators new signature counter
+func (rp *RelyingParty) VerifyAssertion(
+	Challenge string, PublicKey []byte, Algorithm int, SignCount uint32, RequireUserVerification bool, Response AssertionResponse,
+) (uint32, error) {
+	rawClientData, err := decode(Response.ClientDataJSON)
+	if err != nil {
+		return 0, errors.New("INVALID_CLIENT_DATA")
+	}
+	if err := rp.verifyClientData(rawClientData, ceremonyGet, Challenge); err != nil {
+		return 0, err
+	}
+
+	rawAuthData, err := decode(Response.AuthenticatorData)
+	if err != nil {
+		return 0, errors.New("INVALID_AUTHENTICATOR_DATA")
+	}
+	ad, err := rp.parseAuthenticatorData(rawAuthData, RequireUserVerification)
+	if err != nil {
+		return 0, err
+	}
+
+	signature, err := decode(Response.Signature)
+	if err != nil {
+		return 0, errors.New("INVALID_SIGNATURE")
+	}
+
+	clientDataHash := sha256.Sum256(rawClientData)
+	signed := append(append([]byte{}, rawAuthData...), clientDataHash[:]...)
+	if err := verifySignature(PublicKey, Algorithm, signed, signature); err != nil {
+		return 0, err
+	}
+
+	// authenticators that don't implement a counter always report zero,
+	// otherwise a counter that did not increase indicates a cloned authenticator
+	if (ad.SignCount != 0 || SignCount != 0) && ad.SignCount <= SignCount {
+		return 0, errors.New("INVALID_SIGN_COUNT")
+	}
+
+	return ad.SignCount, nil
+}
+
+// parsePublicKey parses the DER encoded SubjectPublicKeyInfo validating it matches the algorithm
+func parsePublicKey(PublicKey []byte, Algorithm int) (crypto.PublicKey, error) {
+	key, err := x509.ParsePKIXPublicKey(PublicKey)
+	if err != nil {
+		return nil, errors.New("INVALID_PUBLIC_KEY")
+	}
+
+	switch Algorithm {
+	case AlgES256:
+		if _, ok := key.(*ecdsa.PublicKey); ok {
+			return key, nil
+		}
+	case AlgRS256:
+		if _, ok := key.(*rsa.PublicKey); ok {
+			return key, nil
+		}
+	case AlgEdDSA:
+		if _, ok := key.(ed25519.PublicKey); ok {
+			return key, nil
+		}
+	default:
+		return nil, errors.New("UNSUPPORTED_ALGORITHM")
+	}
+
+	return nil, errors.New("INVALID_PUBLIC_KEY")
+}
+
+// verifySignature verifies the signature over the data with the credential public key
+func verifySignature(PublicKey []byte, Algorithm int, Data []byte, Signature []byte) error {
+	key, err := parsePublicKey(PublicKey, Algorithm)
+	if err != nil {
+		return err
+	}
+
+	var valid bool
+	digest := sha256.Sum256(Data)
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		valid = ecdsa.VerifyASN1(k, digest[:], Signature)
+	case *rsa.PublicKey:
+		valid = rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], Signature) == nil
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(k, Data, Signature)
+	}
+
+	if !valid {
+		return errors.New("INVALID_SIGNATURE")
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+)
+
+var testRP = &RelyingParty{ID: "thunderdome.dev", Name: "Thunderdome", Origins: []string{"https://thunderdome.dev"}}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func testClientData(t *testing.T, Type string, Challenge string, Origin string) []byte {
+	t.Helper()
+	b, err := json.Marshal(clientData{Type: Type, Challenge: Challenge, Origin: Origin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// testCOSEKey encodes the P-256 public key as a COSE EC2 key
+func testCOSEKey(key *ecdsa.PublicKey) []byte {
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	key.X.FillBytes(x)
+	key.Y.FillBytes(y)
+
+	cose := []byte{0xa5, 0x01, 0x02, 0x03, 0x26, 0x20, 0x01, 0x21, 0x58, 0x20}
+	cose = append(cose, x...)
+	cose = append(cose, 0x22, 0x58, 0x20)
+	return append(cose, y...)
+}
+
+func testAuthData(RPID string, Flags byte, SignCount uint32, CredentialId []byte, COSEKey []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(RPID))
+	ad := append([]byte{}, rpIDHash[:]...)
+	ad = append(ad, Flags)
+	counter := make([]byte, 4)
+	binary.BigEndian.PutUint32(counter, SignCount)
+	ad = append(ad, counter...)
+	if CredentialId != nil {
+		ad = append(ad, make([]byte, 16)...)
+		idLen := make([]byte, 2)
+		binary.BigEndian.PutUint16(idLen, uint16(len(CredentialId)))
+		ad = append(ad, idLen...)
+		ad = append(ad, CredentialId...)
+		ad = append(ad, COSEKey...)
+	}
+	return ad
+}
+
+func TestRegistrationAndAssertion(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spki, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentialId := []byte("groot-credential")
+
+	challenge, _ := NewChallenge()
+	credential, err := testRP.VerifyRegistration(challenge, AttestationResponse{
+		ClientDataJSON:     encode(testClientData(t, ceremonyCreate, challenge, "https://thunderdome.dev")),
+		AuthenticatorData:  encode(testAuthData("thunderdome.dev", flagUserPresent|flagAttestedCredential, 0, credentialId, testCOSEKey(&key.PublicKey))),
+		PublicKey:          encode(spki),
+		PublicKeyAlgorithm: AlgES256,
+	})
+	if err != nil {
+		t.Fatalf("expected valid registration, got %v", err)
+	}
+	if credential.Id != encode(credentialId) {
+		t.Fatalf("expected credential id %s, got %s", encode(credentialId), credential.Id)
+	}
+
+	assert := func(Challenge string, Origin string, Flags byte, SignCount uint32, RequireUV bool) (uint32, error) {
+		cd := testClientData(t, ceremonyGet, Challenge, Origin)
+		ad := testAuthData("thunderdome.dev", Flags, SignCount, nil, nil)
+		cdHash := sha256.Sum256(cd)
+		digest := sha256.Sum256(append(append([]byte{}, ad...), cdHash[:]...))
+		sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return testRP.VerifyAssertion(challenge, credential.PublicKey, credential.Algorithm, 5, RequireUV, AssertionResponse{
+			CredentialId:      credential.Id,
+			ClientDataJSON:    encode(cd),
+			AuthenticatorData: encode(ad),
+			Signature:         encode(sig),
+		})
+	}
+
+	if count, err := assert(challenge, "https://thunderdome.dev", flagUserPresent|flagUserVerified, 6, true); err != nil || count != 6 {
+		t.Fatalf("expected valid assertion with count 6, got %d %v", count, err)
+	}
+
+	if _, err := assert("another-challenge", "https://thunderdome.dev", flagUserPresent, 6, false); err == nil || err.Error() != "INVALID_CHALLENGE" {
+		t.Fatalf("expected invalid challenge, got %v", err)
+	}
+
+	if _, err := assert(challenge, "https://evil.example", flagUserPresent, 6, false); err == nil || err.Error() != "INVALID_ORIGIN" {
+		t.Fatalf("expected invalid origin, got %v", err)
+	}
+
+	if _, err := assert(challenge, "https://thunderdome.dev", flagUserPresent, 6, true); err == nil || err.Error() != "USER_NOT_VERIFIED" {
+		t.Fatalf("expected user not verified, got %v", err)
+	}
+
+	if _, err := assert(challenge, "https://thunderdome.dev", flagUserPresent, 5, false); err == nil || err.Error() != "INVALID_SIGN_COUNT" {
+		t.Fatalf("expected invalid sign count, got %v", err)
+	}
+}
+
+func TestVerifyRegistrationRejectsMismatchedAlgorithm(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	spki, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	challenge, _ := NewChallenge()
+
+	_, err := testRP.VerifyRegistration(challenge, AttestationResponse{
+		ClientDataJSON:     encode(testClientData(t, ceremonyCreate, challenge, "https://thunderdome.dev")),
+		AuthenticatorData:  encode(testAuthData("thunderdome.dev", flagUserPresent|flagAttestedCredential, 0, []byte("drax"), testCOSEKey(&key.PublicKey))),
+		PublicKey:          encode(spki),
+		PublicKeyAlgorithm: AlgRS256,
+	})
+	if err == nil || err.Error() != "INVALID_PUBLIC_KEY" {
+		t.Fatalf("expected invalid public key, got %v", err)
+	}
+}
+
+// TestVerifyRegistrationRejectsUnattestedPublicKey makes sure the public key has to be the one in the authenticator data
+func TestVerifyRegistrationRejectsUnattestedPublicKey(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	other, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	spki, _ := x509.MarshalPKIXPublicKey(&other.PublicKey)
+	challenge, _ := NewChallenge()
+
+	_, err := testRP.VerifyRegistration(challenge, AttestationResponse{
+		ClientDataJSON:     encode(testClientData(t, ceremonyCreate, challenge, "https://thunderdome.dev")),
+		AuthenticatorData:  encode(testAuthData("thunderdome.dev", flagUserPresent|flagAttestedCredential, 0, []byte("nebula"), testCOSEKey(&key.PublicKey))),
+		PublicKey:          encode(spki),
+		PublicKeyAlgorithm: AlgES256,
+	})
+	if err == nil || err.Error() != "PUBLIC_KEY_MISMATCH" {
+		t.Fatalf("expected public key mismatch, got %v", err)
+	}
+
+	_, err = testRP.VerifyRegistration(challenge, AttestationResponse{
+		ClientDataJSON:     encode(testClientData(t, ceremonyCreate, challenge, "https://thunderdome.dev")),
+		AuthenticatorData:  encode(testAuthData("thunderdome.dev", flagUserPresent|flagAttestedCredential, 0, []byte("nebula"), []byte{0xa5, 0x01})),
+		PublicKey:          encode(spki),
+		PublicKeyAlgorithm: AlgES256,
+	})
+	if err == nil || err.Error() != "INVALID_COSE_KEY" {
+		t.Fatalf("expected invalid cose key, got %v", err)
+	}
+}